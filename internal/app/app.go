@@ -1,21 +1,50 @@
 package app
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/alexcostache/Xplorer/internal/bookmark"
+	"github.com/alexcostache/Xplorer/internal/clipboard"
 	"github.com/alexcostache/Xplorer/internal/config"
-	"github.com/alexcostache/Xplorer/internal/fileops"
-	"github.com/alexcostache/Xplorer/internal/filesystem"
+	"github.com/alexcostache/Xplorer/internal/diff"
+	"github.com/alexcostache/Xplorer/internal/history"
+	"github.com/alexcostache/Xplorer/internal/launcher"
+	"github.com/alexcostache/Xplorer/internal/listing"
+	"github.com/alexcostache/Xplorer/internal/notes"
+	"github.com/alexcostache/Xplorer/internal/openwith"
+	"github.com/alexcostache/Xplorer/internal/paths"
 	"github.com/alexcostache/Xplorer/internal/preview"
+	"github.com/alexcostache/Xplorer/internal/procs"
+	"github.com/alexcostache/Xplorer/internal/screen"
+	"github.com/alexcostache/Xplorer/internal/search"
+	"github.com/alexcostache/Xplorer/internal/selections"
+	"github.com/alexcostache/Xplorer/internal/shell"
+	"github.com/alexcostache/Xplorer/internal/stats"
 	"github.com/alexcostache/Xplorer/internal/theme"
 	"github.com/alexcostache/Xplorer/internal/ui"
+	"github.com/alexcostache/Xplorer/internal/version"
+	"github.com/alexcostache/Xplorer/internal/viewstate"
+	"github.com/alexcostache/Xplorer/internal/worker"
+	"github.com/alexcostache/Xplorer/pkg/fileops"
+	"github.com/alexcostache/Xplorer/pkg/filesystem"
 
 	"github.com/nsf/termbox-go"
 )
@@ -46,6 +75,22 @@ func (a *App) debugLog(format string, args ...interface{}) {
 	log.Printf(format, args...)
 }
 
+// SetPrintPath makes quitting with the quit key report the final directory
+func (a *App) SetPrintPath(enabled bool) {
+	a.printPath = enabled
+}
+
+// SetPickMode makes Enter on a file pick it (or the selection) and quit
+func (a *App) SetPickMode(enabled bool) {
+	a.pickMode = enabled
+}
+
+// Result returns the paths to print after Run returns and whether the user
+// accepted them (false when quitting with Esc or without picking a file)
+func (a *App) Result() ([]string, bool) {
+	return a.output, a.accepted
+}
+
 // EnableDebug enables debug logging
 func (a *App) EnableDebug() {
 	a.debugEnabled = true
@@ -65,6 +110,12 @@ type App struct {
 	navigator       *filesystem.Navigator
 	renderer        *ui.Renderer
 	fileOpsManager  *fileops.Manager
+	historyManager  *history.Manager
+	openWithManager *openwith.Manager
+	viewManager     *viewstate.Manager
+	selectionSets   *selections.Manager
+	processes       *procs.Manager
+	stats           *stats.Tracker
 	
 	// UI state
 	showHelp        bool
@@ -72,6 +123,11 @@ type App struct {
 	pathEditBuffer  string
 	showContextMenu bool
 	debugEnabled    bool
+	lastVisitedDir  string
+	
+	// Path completion state
+	completions     []string
+	completionIndex int
 	
 	// Mouse state
 	lastClickTime   int64
@@ -79,10 +135,61 @@ type App struct {
 	lastClickY      int
 	ctrlPressed     bool
 	
+	// Dragging files from the list onto a folder with the mouse
+	dragFiles       []string // Carried by a drag starting at the last press
+	dragging        bool
+	dragCopy        bool     // Ctrl+click started it: copy instead of move
+	ctrlClickMenu   bool     // Open the context menu on release unless dragged
+	
+	// Deferred preview reload during rapid cursor movement
+	lastCursorMove  time.Time
+	previewPending  bool
+	spinnerDue      time.Time // When the armed spinner tick fires
+	
+	// Polls the panes' directories for external changes
+	watcher         *filesystem.Watcher
+	stopped         atomic.Bool // Set when Run returns; later wake-ups are dropped
+	
+	// Set while a file operation runs; only one runs at a time
+	operating       atomic.Bool
+	
+	// Days staged items are kept, read by the purge timer
+	softDeleteDays  atomic.Int64
+	
+	// Changes made by file operations, queued for the UI goroutine
+	eventsMu        sync.Mutex
+	events          []fileops.Event
+	
 	// Progress bar state
 	progressHideTime  time.Time
 	showProgress      bool
 	lastOperationWasActive bool
+	
+	// Dual-pane mode; a.navigator is always the focused pane
+	dualPane        bool
+	panes           [2]*filesystem.Navigator
+	activePane      int
+	remotes         map[*filesystem.Navigator]*remoteSession // Panes browsing FTP or WebDAV servers
+	
+	// Range selection: moving the cursor selects from the anchor to it
+	visual          bool
+	visualShift     bool     // Started with a Shift+arrow; other keys end it
+	visualDir       string
+	visualAnchor    int
+	visualBase      []string // Selected before the range started
+	
+	// Derived files are listed after their source (foo.o after foo.c)
+	pairing         bool
+	
+	// Content search options, remembered between searches
+	grepCase        filesystem.CaseMode
+	grepRegex       bool
+	
+	// Output for wrapper scripts
+	printPath       bool     // Print the final directory when quitting with the quit key
+	pickMode        bool     // Enter on a file picks it and quits
+	output          []string // Paths to print after the UI closes
+	accepted        bool     // Quit with a result rather than canceling
 }
 
 // New creates a new application instance
@@ -91,15 +198,22 @@ func New() *App {
 	tm := theme.NewManager()
 	bm := bookmark.NewManager()
 	pm := preview.NewManager()
+	pm.SetLimits(cfg.PreviewSkipExtensions, int64(cfg.PreviewMaxSizeMB)<<20)
 	nav := filesystem.NewNavigator()
+	nav.SetCaseMode(filesystem.ParseCaseMode(cfg.FilterCase))
+	nav.SetScrollOff(cfg.ScrollOff)
+	nav.SetSecondarySort(filesystem.ParseSortKey(cfg.SecondarySort))
 	fom := fileops.NewManager()
+	hm := history.NewManager()
+	owm := openwith.NewManager()
+	owm.SetDefaults(cfg.OpenWith)
 	
 	// Load saved theme
 	tm.LoadSavedTheme()
 	
 	renderer := ui.NewRenderer(tm, bm, pm, cfg, fom)
 	
-	return &App{
+	a := &App{
 		config:          cfg,
 		themeManager:    tm,
 		bookmarkManager: bm,
@@ -107,32 +221,86 @@ func New() *App {
 		navigator:       nav,
 		renderer:        renderer,
 		fileOpsManager:  fom,
+		historyManager:  hm,
+		openWithManager: owm,
+		viewManager:     viewstate.NewManager(),
+		selectionSets:   selections.NewManager(),
+		processes:       procs.NewManager(),
+		stats:           stats.NewTracker(paths.File(".xp_stats.json")),
+		panes:           [2]*filesystem.Navigator{nav, nil},
+		grepCase:        nav.GetCaseMode(),
 		showHelp:        false,
 		inPathEditMode:  false,
 		pathEditBuffer:  "",
 		showContextMenu: false,
 	}
+	a.watcher = filesystem.NewWatcher(watchInterval, a.interrupt)
+	renderer.SetInterrupt(a.interrupt)
+	
+	// Show the starting directory the way it was left last time
+	a.applyRememberView()
+	nav.RestoreView()
+	fom.SetStagingDir(paths.File(".xp_staging"))
+	a.applySoftDelete()
+	a.fileOpsManager.SetVerify(a.config.VerifyCopies)
+	a.applyLowPriority()
+	a.applyBackups()
+	a.applyCreateModes()
+	fom.SetClipboardFile(paths.File(".xp_clipboard.json"))
+	fom.Subscribe(a.queueEvent)
+	return a
 }
 
 // Run starts the application
 func (a *App) Run() error {
-	if err := termbox.Init(); err != nil {
+	if err := screen.Init(); err != nil {
 		return err
 	}
-	defer termbox.Close()
+	defer screen.Close()
+	// Timers and background work may still finish after Run returns
+	defer a.stopped.Store(true)
 	
 	// Enable mouse support if configured
 	if a.config.MouseEnabled {
-		termbox.SetInputMode(termbox.InputEsc | termbox.InputMouse)
+		screen.SetInputMode(termbox.InputEsc | termbox.InputMouse)
 	} else {
-		termbox.SetInputMode(termbox.InputEsc)
+		screen.SetInputMode(termbox.InputEsc)
+	}
+	
+	// Report settings problems before showing the file manager
+	problems := append(append([]config.ValidationError{}, a.config.Problems...), a.themeManager.GetProblems()...)
+	if len(problems) > 0 && a.renderer.ShowProblems(problems) {
+		a.openEditor(problems[0].File)
 	}
 	
+	// Auto-refresh listings changed by other programs
+	a.applyAutoRefresh()
+	defer a.watcher.Stop()
+	a.schedulePurge()
+	
 	// Load initial preview
+	a.trackDirectoryVisit()
 	a.reloadPreview()
 	a.renderer.DrawAndFlush(a.navigator, a.inPathEditMode, a.pathEditBuffer, a.showHelp)
 	
-	return a.eventLoop()
+	err := a.eventLoop()
+	a.disconnectAll()
+	for _, nav := range a.panes {
+		if nav != nil {
+			nav.RememberView()
+		}
+	}
+	_ = a.stats.Save()
+	_ = a.historyManager.Save()
+	return err
+}
+
+// interrupt wakes the event loop for timers and background work, unless
+// Run has returned
+func (a *App) interrupt() {
+	if !a.stopped.Load() {
+		screen.Interrupt()
+	}
 }
 
 // pauseProgressUpdates is now a no-op (kept for compatibility)
@@ -150,7 +318,7 @@ func (a *App) eventLoop() error {
 	for {
 		// Poll for event (this blocks until an event occurs)
 		a.debugLog("Main eventLoop: Waiting for event...")
-		ev := termbox.PollEvent()
+		ev := screen.PollEvent()
 		a.debugLog("Main eventLoop: Got event type=%d key=%d", ev.Type, ev.Key)
 		
 		switch ev.Type {
@@ -158,8 +326,18 @@ func (a *App) eventLoop() error {
 			a.debugLog("Main eventLoop: Resize event")
 			a.drawWithProgress()
 			
+		case termbox.EventInterrupt:
+			// Background work (e.g. directory size measurement) finished,
+			// a deferred preview is due or a watched directory changed
+			a.refreshChanged()
+			a.settlePreview()
+			a.previewManager.Settle()
+			a.tickSpinner()
+			a.drawWithProgress()
+			
 		case termbox.EventKey:
 			a.debugLog("Main eventLoop: Key event")
+			a.renderer.SetHover("", nil)
 			// Track Ctrl key state
 			if ev.Key == termbox.KeyCtrlC {
 				a.ctrlPressed = true
@@ -183,6 +361,14 @@ func (a *App) eventLoop() error {
 			a.drawWithProgress()
 			
 		case termbox.EventMouse:
+			// Hovering redraws only when the file under the mouse changes
+			if screen.IsHover(ev) {
+				if a.renderer.SetHover(a.renderer.HoveredFile(a.navigator, ev.MouseX, ev.MouseY)) {
+					a.drawWithProgress()
+				}
+				continue
+			}
+			a.renderer.SetHover("", nil)
 			if a.handleMouseEvent(ev) {
 				a.debugLog("Main eventLoop: handleMouseEvent returned true, exiting")
 				return nil
@@ -190,11 +376,119 @@ func (a *App) eventLoop() error {
 			a.drawWithProgress()
 		}
 		
+		// Leave the current directory if it was removed externally
+		a.checkCurrentDir()
+		
+		// Record directory changes in the frecency history
+		a.trackDirectoryVisit()
+		
+		// Watch whatever the panes show now
+		a.watchPanes()
+		
 		// Update progress display after each event
 		a.updateProgressDisplay()
 	}
 }
 
+// pick enters the directory under the cursor, or accepts the selected files
+// (or the file under the cursor) and quits
+func (a *App) pick() bool {
+	file := a.navigator.GetSelectedFile()
+	if file == nil {
+		return false
+	}
+	if file.IsDir() && a.fileOpsManager.GetSelectedCount() == 0 {
+		if a.navigator.EnterDirectory() {
+			a.reloadPreview()
+		}
+		return false
+	}
+	a.output = a.targetFiles()
+	sort.Strings(a.output)
+	a.accepted = true
+	return true
+}
+
+// refreshListing re-reads the current directory, keeping the cursor on the same entry
+func (a *App) refreshListing() {
+	selected := a.navigator.GetSelectedFile()
+	a.navigator.Refresh()
+	a.fileOpsManager.PruneSelection()
+	a.fileOpsManager.PruneBasket()
+	if selected != nil {
+		a.navigator.SelectByName(selected.Name(), a.visibleLines())
+	}
+	if a.dualPane {
+		a.panes[1-a.activePane].Refresh()
+	}
+	a.reloadPreview()
+}
+
+// leftDirectory drops the selection after moving to another directory,
+// unless the config keeps it across folders
+func (a *App) leftDirectory() {
+	if !a.config.KeepSelection {
+		a.fileOpsManager.ClearSelection()
+	}
+}
+
+// goToParent moves to the parent directory, unless the current directory
+// is a workspace root
+func (a *App) goToParent() {
+	dir := a.navigator.GetCurrentDir()
+	if root := a.config.WorkspaceRoot(dir); root != "" && root == filepath.Clean(dir) {
+		a.renderer.ShowMessage("Workspace root " + root + " (edit the path to leave it)")
+		return
+	}
+	if a.navigator.GoToParent() {
+		a.leftDirectory()
+		a.reloadPreview()
+	}
+}
+
+// searchRoot returns where grep and fuzzy jump search: the workspace the
+// current directory is in, or else the current directory
+func (a *App) searchRoot() string {
+	if root := a.config.WorkspaceRoot(a.navigator.GetCurrentDir()); root != "" {
+		return root
+	}
+	return a.navigator.GetCurrentDir()
+}
+
+// checkCurrentDir moves up to the nearest existing ancestor when the current
+// directory has been deleted from under us, and tells the user
+func (a *App) checkCurrentDir() {
+	a.navigator.RecoverMissingDir()
+	removed := a.navigator.TakeRemovedDir()
+	if removed == "" {
+		return
+	}
+	a.leftDirectory()
+	a.fileOpsManager.PruneSelection()
+	
+	// Keep the cursor on the branch that led to the missing directory
+	if rel, err := filepath.Rel(a.navigator.GetCurrentDir(), removed); err == nil {
+		a.navigator.SelectByName(strings.SplitN(rel, string(filepath.Separator), 2)[0], a.visibleLines())
+	}
+	a.reloadPreview()
+	a.drawWithProgress()
+	a.renderer.ShowMessage(fmt.Sprintf("%s no longer exists, moved to %s", removed, a.navigator.GetCurrentDir()))
+	a.drawWithProgress()
+}
+
+// trackDirectoryVisit records the current directory in the history when it changes
+func (a *App) trackDirectoryVisit() {
+	currentDir := a.navigator.GetCurrentDir()
+	if currentDir != a.lastVisitedDir {
+		a.lastVisitedDir = currentDir
+		if a.remote(a.navigator) != nil {
+			return // The history holds local folders only
+		}
+		a.historyManager.Visit(currentDir)
+		a.stats.Visited()
+	}
+}
+
 // updateProgressDisplay checks and updates progress bar display
 func (a *App) updateProgressDisplay() {
 	progress := a.fileOpsManager.GetProgress()
@@ -251,17 +545,24 @@ func (a *App) drawWithProgress() {
 	}
 	
 	// Now flush everything to screen
-	termbox.Flush()
+	screen.Flush()
 }
 
 // handlePathEditMode handles input when in path edit mode
 func (a *App) handlePathEditMode(ev termbox.Event) bool {
+	// Any key other than Tab ends the current completion cycle
+	if ev.Key != termbox.KeyTab {
+		a.completions = nil
+	}
+	
 	switch ev.Key {
 	case termbox.KeyEnter:
 		a.inPathEditMode = false
-		newPath := filepath.Clean(a.pathEditBuffer)
+		newPath := filesystem.ExpandPath(a.pathEditBuffer, a.navigator.GetCurrentDir())
 		if stat, err := os.Stat(newPath); err == nil && stat.IsDir() {
+			a.navigator.RecordJump()
 			a.navigator.SetCurrentDir(newPath)
+			a.leftDirectory()
 			a.previewManager.ResetScroll()
 			a.reloadPreview()
 		}
@@ -269,6 +570,12 @@ func (a *App) handlePathEditMode(ev termbox.Event) bool {
 	case termbox.KeyEsc:
 		a.inPathEditMode = false
 		
+	case termbox.KeyTab:
+		a.completePath()
+		
+	case termbox.KeyCtrlV:
+		a.pasteIntoPath()
+		
 	case termbox.KeyBackspace, termbox.KeyBackspace2:
 		if len(a.pathEditBuffer) > 0 {
 			a.pathEditBuffer = a.pathEditBuffer[:len(a.pathEditBuffer)-1]
@@ -284,83 +591,256 @@ func (a *App) handlePathEditMode(ev termbox.Event) bool {
 	return false
 }
 
+// completePath replaces the path edit buffer with the next completion.
+// The first Tab collects candidates; subsequent presses cycle through them.
+func (a *App) completePath() {
+	if a.completions == nil {
+		a.completions = a.pathCompletions(a.pathEditBuffer)
+		a.completionIndex = 0
+	} else if len(a.completions) > 0 {
+		a.completionIndex = (a.completionIndex + 1) % len(a.completions)
+	}
+	
+	if len(a.completions) > 0 {
+		a.pathEditBuffer = a.completions[a.completionIndex]
+	}
+}
+
+// pathCompletions returns completion candidates for a partially typed path.
+// Filesystem matches come first, followed by bookmarks and frecency history
+// when the input is a bare name rather than a path.
+func (a *App) pathCompletions(input string) []string {
+	currentDir := a.navigator.GetCurrentDir()
+	candidates := filesystem.CompleteDir(input, currentDir, a.navigator.GetShowHidden())
+	
+	if input != "" && !strings.ContainsRune(input, os.PathSeparator) && !strings.HasPrefix(input, "~") {
+		candidates = append(candidates, a.bookmarkManager.Match(input)...)
+		candidates = append(candidates, a.historyManager.Match(input)...)
+	}
+	
+	// Remove duplicates while keeping order
+	seen := make(map[string]bool)
+	var unique []string
+	for _, c := range candidates {
+		if !seen[c] {
+			seen[c] = true
+			unique = append(unique, c)
+		}
+	}
+	return unique
+}
+
 // handleKeyEvent handles keyboard input
 func (a *App) handleKeyEvent(ev termbox.Event) bool {
 	keys := a.config.Keys
-	_, h := termbox.Size()
-	visibleLines := h - 4
+	visibleLines := a.visibleLines()
 	
-	// Handle special keys
-	switch ev.Key {
-	case termbox.KeyEsc:
+	key := config.EventKey(ev)
+	defer a.extendVisual()
+	
+	// Esc always closes overlays or quits, whatever the bindings, unless
+	// it starts an Alt combination
+	if ev.Key == termbox.KeyEsc {
+		key = a.readAltKey()
+	}
+	if ev.Key == termbox.KeyEsc && key == "" {
+		if a.visual {
+			a.endVisual()
+			return false
+		}
 		if a.showHelp {
 			a.showHelp = false
 			return false
 		}
+		if a.renderer.IsQuickLook() {
+			a.renderer.SetQuickLook(false)
+			return false
+		}
+		if a.renderer.IsMinimal() {
+			a.renderer.SetMinimal(false)
+			return false
+		}
+		if a.fileOpsManager.Cancel() {
+			return false
+		}
 		return true // Quit
+	}
+	
+	if key == "" {
+		return false
+	}
+	if a.remote(a.navigator) != nil && !a.remoteKeys(key) {
+		a.renderer.ShowMessage("Not available on a remote connection (download from the context menu)")
+		return false
+	}
+	if a.visualShift && key != keys.RangeUp && key != keys.RangeDown {
+		a.endVisual()
+	}
+	
+	switch key {
+	case keys.VisualMode:
+		if a.visual {
+			a.endVisual()
+		} else {
+			a.startVisual(false)
+		}
+		return false
+		
+	case keys.RangeUp, keys.RangeDown:
+		if !a.visual {
+			a.startVisual(true)
+		}
+		if key == keys.RangeUp {
+			a.navigator.MoveUp(visibleLines)
+		} else {
+			a.navigator.MoveDown(visibleLines)
+		}
+		a.schedulePreview()
+		return false
 		
-	case termbox.KeySpace:
-		// Handle Space key for file selection
+	case keys.Select:
 		if selectedPath := a.navigator.GetSelectedPath(); selectedPath != "" {
 			a.fileOpsManager.ToggleSelection(selectedPath)
 		}
 		return false
 		
-	case termbox.KeyArrowUp:
+	case keys.SelectAll:
+		a.fileOpsManager.Select(a.listingPaths())
+		return false
+		
+	case keys.DeselectAll:
+		a.fileOpsManager.ClearSelection()
+		return false
+		
+	case keys.InvertSelection:
+		a.fileOpsManager.InvertSelection(a.listingPaths())
+		return false
+		
+	case keys.SelectPattern:
+		a.selectByPattern()
+		return false
+		
+	case keys.MoveUp:
 		a.navigator.MoveUp(visibleLines)
-		a.previewManager.ResetScroll()
-		a.reloadPreview()
+		a.schedulePreview()
 		return false
 		
-	case termbox.KeyArrowDown:
+	case keys.MoveDown:
 		a.navigator.MoveDown(visibleLines)
-		a.previewManager.ResetScroll()
-		a.reloadPreview()
+		a.schedulePreview()
 		return false
 		
-	case termbox.KeyArrowLeft:
-		if a.navigator.GoToParent() {
-			a.fileOpsManager.ClearSelection() // Clear selections when changing directory
-			a.reloadPreview()
-		}
+	case keys.ParentDir:
+		a.goToParent()
 		return false
 		
-	case termbox.KeyArrowRight:
+	case keys.EnterDir:
 		if a.navigator.EnterDirectory() {
-			a.fileOpsManager.ClearSelection() // Clear selections when changing directory
+			a.leftDirectory()
 			a.reloadPreview()
 		}
 		return false
 		
-	case termbox.KeyPgup:
+	case keys.PageUp:
 		a.navigator.MoveUpFast(visibleLines)
-		a.previewManager.ResetScroll()
-		a.reloadPreview()
+		a.schedulePreview()
 		return false
 		
-	case termbox.KeyPgdn:
+	case keys.PageDown:
 		a.navigator.MoveDownFast(visibleLines)
-		a.previewManager.ResetScroll()
-		a.reloadPreview()
+		a.schedulePreview()
 		return false
 		
-	case termbox.KeyEnter:
+	case keys.Open:
+		if a.pickMode {
+			return a.pick()
+		}
 		if selectedPath := a.navigator.GetSelectedPath(); selectedPath != "" {
-			a.openWithEditorSelection(selectedPath)
+			a.openFile(selectedPath)
+		} else if a.navigator.IsPermissionDenied() {
+			a.openElevatedShell()
+		}
+		return false
+		
+	case keys.GoBack:
+		if a.navigator.GoBack() {
+			a.leftDirectory()
+			a.reloadPreview()
+		}
+		return false
+		
+	case keys.HistoryBack, keys.HistoryForward:
+		moved := false
+		if key == keys.HistoryBack {
+			moved = a.navigator.GoBack()
+		} else {
+			moved = a.navigator.GoForward()
+		}
+		if moved {
+			a.leftDirectory()
+			a.previewManager.ResetScroll()
+			a.reloadPreview()
+		}
+		return false
+		
+	case keys.HistoryPopup:
+		a.showHistory()
+		return false
+		
+	case keys.Refresh:
+		a.refreshListing()
+		return false
+		
+	case keys.CopyToPane:
+		// In dual-pane mode F5 copies to the other pane, like Midnight Commander
+		if a.dualPane {
+			a.transferToOtherPane(false)
+			return false
+		}
+		a.refreshListing()
+		return false
+		
+	case keys.MoveToPane:
+		if a.dualPane {
+			a.transferToOtherPane(true)
+		}
+		return false
+		
+	case keys.SwitchPane:
+		if a.dualPane {
+			a.focusPane(1 - a.activePane)
 		}
 		return false
 		
-	case termbox.KeyCtrlS:
-		// Show sorting popup
-		a.debugLog("Main: Ctrl+S pressed, calling handleSortingPopup")
+	case keys.SwapPanes:
+		if a.dualPane {
+			a.swapPanes()
+		}
+		return false
+		
+	case keys.SortMenu:
+		a.debugLog("Main: sort key pressed, calling handleSortingPopup")
 		a.handleSortingPopup()
 		a.debugLog("Main: handleSortingPopup returned, continuing")
 		return false
-	}
-	
-	// Handle character keys
-	switch ev.Ch {
+		
+	case keys.ContextMenu:
+		a.showContextMenu = true
+		a.handleContextMenu()
+		a.showContextMenu = false
+		return false
+		
+	case keys.OpenWith:
+		if selectedPath := a.navigator.GetSelectedPath(); selectedPath != "" {
+			a.openWithEditorSelection(selectedPath)
+		}
+		return false
+		
 	case keys.Quit:
+		if a.printPath {
+			a.output = []string{a.navigator.GetCurrentDir()}
+			a.accepted = true
+		}
 		return true
 		
 	case keys.OpenTerminal:
@@ -405,6 +885,10 @@ func (a *App) handleKeyEvent(ev termbox.Event) bool {
 		}
 		return false
 		
+	case keys.BookmarkFileDir:
+		a.bookmarkFileDir()
+		return false
+		
 	case keys.BookmarkPopup:
 		if a.bookmarkManager.Count() > 0 {
 			a.pauseProgressUpdates()
@@ -413,7 +897,9 @@ func (a *App) handleKeyEvent(ev termbox.Event) bool {
 			if path != "" {
 				// Check if the bookmarked path still exists
 				if stat, err := os.Stat(path); err == nil && stat.IsDir() {
+					a.navigator.RecordJump()
 					a.navigator.SetCurrentDir(path)
+					a.leftDirectory()
 					a.navigator.ClearFilter()
 					a.previewManager.ResetScroll()
 					a.reloadPreview()
@@ -448,6 +934,14 @@ func (a *App) handleKeyEvent(ev termbox.Event) bool {
 		a.previewManager.ScrollUp(10)
 		return false
 		
+	case keys.ScrollLeft:
+		a.previewManager.ScrollLeft(previewColumnStep)
+		return false
+		
+	case keys.ScrollRight:
+		a.previewManager.ScrollRight(previewColumnStep, a.renderer.PreviewWidth())
+		return false
+		
 	case keys.TogglePath:
 		a.config.ShowRawPath = !a.config.ShowRawPath
 		return false
@@ -456,82 +950,419 @@ func (a *App) handleKeyEvent(ev termbox.Event) bool {
 		a.handleConfigMenu()
 		return false
 		
-	case ' ': // Space key for selection
-		if selectedPath := a.navigator.GetSelectedPath(); selectedPath != "" {
-			a.fileOpsManager.ToggleSelection(selectedPath)
-		}
+	case keys.Slideshow:
+		a.runSlideshow()
 		return false
-	}
-	
-	// Handle Alt/Option key for context menu (using Ctrl+O as alternative since Alt detection is limited)
-	if ev.Key == termbox.KeyCtrlO {
-		a.showContextMenu = true
-		a.handleContextMenu()
-		a.showContextMenu = false
+		
+	case keys.PinDestination:
+		a.pinDestination()
 		return false
-	}
-	
-	return false
-}
-
-// reloadPreview reloads the preview for the currently selected file
-func (a *App) reloadPreview() {
-	selectedPath := a.navigator.GetSelectedPath()
-	if selectedPath != "" {
-		_, h := termbox.Size()
-		maxLines := h * 10 // Load more lines for scrolling
-		a.previewManager.LoadPreview(selectedPath, a.navigator.GetShowHidden(), maxLines)
-	}
-}
-
-// openTerminal opens a terminal in the current directory
-func (a *App) openTerminal() {
-	currentDir := a.navigator.GetCurrentDir()
-	ui.OpenTerminal(currentDir, a.config.TerminalApp)
-}
-
-// isTerminalEditor checks if an editor command is a terminal-based editor
-func isTerminalEditor(editorCmd string) bool {
-	terminalEditors := []string{"vim", "vi", "nvim", "nano", "emacs", "micro", "helix", "hx"}
-	for _, te := range terminalEditors {
-		if strings.Contains(strings.ToLower(editorCmd), te) {
-			return true
+		
+	case keys.CopyToPinned:
+		a.transferToPinned(false)
+		return false
+		
+	case keys.MoveToPinned:
+		a.transferToPinned(true)
+		return false
+		
+	case keys.JumpBack:
+		if a.navigator.JumpBack(visibleLines) {
+			a.leftDirectory()
+			a.previewManager.ResetScroll()
+			a.reloadPreview()
 		}
-	}
-	return false
-}
-
-// openEditor opens a file in the configured editor
-func (a *App) openEditor(path string) {
-	editorCmd := a.config.EditorCmd
-	
-	if isTerminalEditor(editorCmd) {
-		// For terminal editors, we need to:
-		// 1. Close termbox
-		// 2. Run the editor in foreground
-		// 3. Reinitialize termbox when done
-		termbox.Close()
+		return false
 		
-		cmd := exec.Command(editorCmd, path)
-		cmd.Stdin = os.Stdin
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
+	case keys.JumpForward:
+		if a.navigator.JumpForward(visibleLines) {
+			a.leftDirectory()
+			a.previewManager.ResetScroll()
+			a.reloadPreview()
+		}
+		return false
 		
-		_ = cmd.Run()
+	case keys.Grep:
+		a.grep()
+		return false
 		
-		// Reinitialize termbox
-		_ = termbox.Init()
+	case keys.FuzzyJump:
+		a.fuzzyJump()
+		return false
+		
+	case keys.BasketToggle:
+		a.toggleBasket()
+		return false
+		
+	case keys.BasketPopup:
+		a.showBasket()
+		return false
+		
+	case keys.ClipboardPopup:
+		a.showClipboard()
+		return false
+		
+	case keys.Processes:
+		a.showProcesses()
+		return false
+		
+	case keys.Connections:
+		a.showConnections()
+		return false
+		
+	case keys.JobPriority:
+		if !a.fileOpsManager.ToggleLowPriority() {
+			a.renderer.ShowMessage("No operation is running")
+		}
+		return false
+		
+	case keys.SelectionSets:
+		a.showSelectionSets()
+		return false
+		
+	case keys.DirNote:
+		a.editDirNote()
+		return false
+		
+	case keys.ExportListing:
+		a.exportListing()
+		return false
+		
+	case keys.CopyContents:
+		a.copyContents(a.navigator.GetSelectedPath())
+		return false
+		
+	case keys.CopyPath:
+		a.copyPaths("path", func(path string) string { return path })
+		return false
+		
+	case keys.CopyName:
+		a.copyPaths("name", filepath.Base)
+		return false
+		
+	case keys.CopyDir:
+		a.copyPaths("directory", filepath.Dir)
+		return false
+		
+	case keys.OpenFileManager:
+		if err := a.openInFileManager(a.navigator.GetCurrentDir()); err != nil {
+			a.renderer.ShowError(err.Error())
+		}
+		return false
+		
+	case keys.ToggleExec:
+		a.toggleExecutable()
+		return false
+		
+	case keys.RemoveQuarantine:
+		a.removeQuarantine()
+		return false
+		
+	case keys.FollowLink:
+		a.followPreviewLink()
+		return false
+		
+	case keys.DualPane:
+		a.toggleDualPane()
+		return false
+		
+	case keys.MirrorPane:
+		if a.dualPane {
+			a.mirrorPath()
+		}
+		return false
+		
+	case keys.CompareBadges:
+		if a.fileOpsManager.GetPinnedDir() == "" && !a.dualPane && !a.renderer.IsCompare() {
+			a.renderer.ShowMessage(fmt.Sprintf("No destination pinned (press %s on a folder)", keys.PinDestination))
+			return false
+		}
+		a.renderer.SetCompare(!a.renderer.IsCompare())
+		return false
+		
+	case keys.MinimalMode:
+		a.renderer.SetMinimal(!a.renderer.IsMinimal())
+		return false
+		
+	case keys.Treemap:
+		a.renderer.SetTreemap(!a.renderer.IsTreemap())
+		return false
+		
+	case keys.PairFiles:
+		a.pairing = !a.pairing
+		a.applyPairing()
+		return false
+		
+	case keys.Statistics:
+		a.showStats()
+		return false
+		
+	case keys.ShellCommand, keys.ShellTerminal:
+		a.shellCommand(key == keys.ShellTerminal)
+		return false
+		
+	case keys.QuickLook:
+		a.renderer.SetQuickLook(!a.renderer.IsQuickLook())
+		a.previewManager.ResetScroll()
+		return false
+	}
+	
+	return false
+}
+
+// startVisual anchors a range selection at the cursor. shift marks a range
+// made with Shift+arrows, which ends with the next other key.
+func (a *App) startVisual(shift bool) {
+	a.visual = true
+	a.visualShift = shift
+	a.visualDir = a.navigator.GetCurrentDir()
+	a.visualAnchor = a.navigator.GetCursor()
+	a.visualBase = a.fileOpsManager.GetSelectedFiles()
+	a.renderer.SetVisual(true)
+}
+
+// endVisual stops extending the range, keeping it selected
+func (a *App) endVisual() {
+	a.visual = false
+	a.visualShift = false
+	a.visualBase = nil
+	a.renderer.SetVisual(false)
+}
+
+// extendVisual selects the range from the anchor to the cursor, on top of
+// what was selected before the range started. Leaving the directory ends
+// the range.
+func (a *App) extendVisual() {
+	if !a.visual {
+		return
+	}
+	if a.navigator.GetCurrentDir() != a.visualDir {
+		a.endVisual()
+		return
+	}
+	files := a.listingPaths()
+	if len(files) == 0 {
+		return
+	}
+	anchor := min(a.visualAnchor, len(files)-1)
+	cursor := a.navigator.GetCursor()
+	a.fileOpsManager.ClearSelection()
+	a.fileOpsManager.Select(a.visualBase)
+	a.fileOpsManager.Select(files[min(anchor, cursor) : max(anchor, cursor)+1])
+}
+
+// escapeDelay is how long the rest of an Alt combination may take to
+// arrive after its Esc; terminals send both in one write
+const escapeDelay = 20 * time.Millisecond
+
+// xtermKeys maps the xterm sequences for Alt+arrows and Shift+arrows (after
+// "\x1b["), which termbox doesn't know and reports as Esc followed by
+// characters
+var xtermKeys = map[string]config.Key{
+	"1;2A": "shift+up",
+	"1;2B": "shift+down",
+	"1;3A": "alt+up",
+	"1;3B": "alt+down",
+	"1;3C": "alt+right",
+	"1;3D": "alt+left",
+	"1;3H": "alt+home",
+	"1;3F": "alt+end",
+}
+
+// readAltKey is called after Esc and returns the Alt combination (or
+// Shift+arrow) it starts, or "" for a lone Esc. Terminals send Alt+key
+// either as Esc followed by the key (arrows included) or as an xterm
+// sequence.
+func (a *App) readAltKey() config.Key {
+	timer := time.AfterFunc(escapeDelay, a.interrupt)
+	defer timer.Stop()
+	
+	ev := screen.PollEvent()
+	if ev.Type == termbox.EventInterrupt {
+		// Likely the timer, but it may be background work: do its part
+		a.refreshChanged()
+		a.settlePreview()
+		return ""
+	}
+	if ev.Type != termbox.EventKey {
+		return ""
+	}
+	if ev.Ch != '[' {
+		return config.AltKey(ev)
+	}
+	
+	// Read the sequence up to its final letter or '~'
+	var seq []rune
+	for len(seq) < 8 {
+		ev = screen.PollEvent()
+		if ev.Type != termbox.EventKey || ev.Ch == 0 {
+			break
+		}
+		seq = append(seq, ev.Ch)
+		if ev.Ch == '~' || unicode.IsLetter(ev.Ch) {
+			return xtermKeys[string(seq)]
+		}
+	}
+	return ""
+}
+
+// visibleLines returns the number of file list rows on screen
+func (a *App) visibleLines() int {
+	_, lines := a.renderer.ListArea()
+	return lines
+}
+
+// previewColumnStep is how many columns the preview scrolls sideways per
+// key press
+const previewColumnStep = 8
+
+// previewDelay is how long the cursor has to rest during rapid movement
+// before the preview is reloaded
+const previewDelay = 100 * time.Millisecond
+
+// schedulePreview reloads the preview after a cursor move. An isolated move
+// reloads at once; during rapid moves (e.g. a held arrow key) the reload
+// waits until the cursor has rested for previewDelay, so intermediate files
+// are never read.
+func (a *App) schedulePreview() {
+	if !a.previewPending && time.Since(a.lastCursorMove) >= previewDelay {
+		a.lastCursorMove = time.Now()
+		a.previewManager.ResetScroll()
+		a.reloadPreview()
+		return
+	}
+	a.deferPreview()
+}
+
+// deferPreview reloads the preview once the cursor has rested for previewDelay
+func (a *App) deferPreview() {
+	a.lastCursorMove = time.Now()
+	a.previewPending = true
+	time.AfterFunc(previewDelay, a.interrupt)
+}
+
+// settlePreview performs a deferred preview reload once its delay has passed
+func (a *App) settlePreview() {
+	if a.previewPending && time.Since(a.lastCursorMove) >= previewDelay {
+		a.previewPending = false
+		a.previewManager.ResetScroll()
+		a.reloadPreview()
+	}
+}
+
+// previewWait is how long a preview may take to read before the panel
+// shows a spinner and the preview is finished in the background
+const previewWait = 30 * time.Millisecond
+
+// reloadPreview reloads the preview for the currently selected file
+func (a *App) reloadPreview() {
+	selectedPath := a.navigator.GetSelectedPath()
+	if session := a.remote(a.navigator); session != nil && selectedPath != "" {
+		// Reading remote files on every cursor move would be too slow
+		a.previewManager.ShowInfo(selectedPath, a.navigator.GetSelectedFile(), "On "+session.name)
+		return
+	}
+	if selectedPath != "" {
+		_, h := screen.Size()
+		maxLines := h * 10 // Load more lines for scrolling
+		a.previewManager.Request(selectedPath, a.navigator.GetSelectedFile(), a.navigator.GetShowHidden(), maxLines, previewWait, a.interrupt)
+		a.tickSpinner()
+	}
+}
+
+// tickSpinner redraws the loading spinner after a frame while a
+// preview is being read, unless a tick is already due
+func (a *App) tickSpinner() {
+	if a.previewManager.Loading() && !time.Now().Before(a.spinnerDue) {
+		a.spinnerDue = time.Now().Add(ui.SpinnerFrame)
+		time.AfterFunc(ui.SpinnerFrame, a.interrupt)
+	}
+}
+
+// openTerminal opens a terminal in the current directory
+func (a *App) openTerminal() {
+	currentDir := a.navigator.GetCurrentDir()
+	if err := a.processes.Start("Terminal", ui.TerminalCommand(currentDir, a.config.TerminalApp)); err != nil {
+		a.renderer.ShowError(err.Error())
+	}
+}
+
+// openElevatedShell offers a root shell in the current directory when it
+// cannot be read with the user's own permissions
+func (a *App) openElevatedShell() {
+	if runtime.GOOS == "windows" {
+		a.renderer.ShowError("Elevation is not supported on Windows")
+		return
+	}
+	sudo, err := exec.LookPath("sudo")
+	if err != nil {
+		a.renderer.ShowError("sudo not found")
+		return
+	}
+	
+	dir := a.navigator.GetCurrentDir()
+	if !a.renderer.ConfirmPrompt("Open a root shell in " + filepath.Base(dir) + "?") {
+		return
+	}
+	
+	// The directory may not be enterable by us, so let the elevated shell cd
+	screen.Close()
+	cmd := exec.Command(sudo, "sh", "-c", `cd -- "$1" && exec "${SHELL:-sh}"`, "sh", dir)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	_ = cmd.Run()
+	
+	_ = screen.Init()
+	a.navigator.Refresh()
+	a.reloadPreview()
+	a.renderer.DrawAndFlush(a.navigator, a.inPathEditMode, a.pathEditBuffer, a.showHelp)
+}
+
+// isTerminalEditor checks if an editor command is a terminal-based editor
+func isTerminalEditor(editorCmd string) bool {
+	terminalEditors := []string{"vim", "vi", "nvim", "nano", "emacs", "micro", "helix", "hx"}
+	for _, te := range terminalEditors {
+		if strings.Contains(strings.ToLower(editorCmd), te) {
+			return true
+		}
+	}
+	return false
+}
+
+// openEditor opens a file in the configured editor
+func (a *App) openEditor(path string) {
+	editorCmd := a.config.EditorCmd
+	
+	if isTerminalEditor(editorCmd) {
+		// For terminal editors, we need to:
+		// 1. Close termbox
+		// 2. Run the editor in foreground
+		// 3. Reinitialize termbox when done
+		screen.Close()
+		
+		cmd := exec.Command(editorCmd, path)
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		
+		_ = cmd.Run()
+		
+		// Reinitialize termbox
+		_ = screen.Init()
 		a.renderer.DrawAndFlush(a.navigator, a.inPathEditMode, a.pathEditBuffer, a.showHelp)
 	} else {
 		// For GUI editors, run in background
-		go exec.Command(editorCmd, path).Start()
+		if err := a.processes.Start("Editor", exec.Command(editorCmd, path)); err != nil {
+			a.renderer.ShowError(err.Error())
+		}
 	}
 }
 
 
 // openWithEditorSelection shows editor selection popup and opens file with chosen editor
 func (a *App) openWithEditorSelection(path string) {
-	// Build options list: 1) default editor, 2) terminal, 3) file explorer, 4) other editors
+	// Build options list: 1) default editor, 2) system default, terminal and file explorer, 3) user commands, 4) other editors, 5) installed applications
 	var allOptions []config.EditorOption
 	
 	// Find the default editor in available editors to get its proper name
@@ -549,152 +1380,2055 @@ func (a *App) openWithEditorSelection(path string) {
 		}
 	}
 	
-	// If default editor not found in available list, use command as name
-	if !foundDefault {
-		defaultEditorName = a.config.EditorCmd
-		defaultEditorDesc = "Default editor"
+	// If default editor not found in available list, use command as name
+	if !foundDefault {
+		defaultEditorName = a.config.EditorCmd
+		defaultEditorDesc = "Default editor"
+	}
+	
+	// 1. Add default editor first
+	defaultEditor := config.EditorOption{
+		Name:        defaultEditorName,
+		Command:     a.config.EditorCmd,
+		IsTerminal:  isTerminalEditor(a.config.EditorCmd),
+		Description: defaultEditorDesc,
+	}
+	allOptions = append(allOptions, defaultEditor)
+	
+	// 2. Add the desktop's default application for the file type and the
+	// system actions (terminal and file explorer) second
+	if info, err := os.Stat(path); err == nil && !info.IsDir() {
+		allOptions = append(allOptions, openwith.DefaultOption(path))
+	}
+	systemActions := config.GetSystemActions()
+	allOptions = append(allOptions, systemActions...)
+	
+	// 3. Add the user's commands from the config file
+	for _, command := range a.config.Commands {
+		allOptions = append(allOptions, config.EditorOption{
+			Name:        command.Name,
+			Command:     command.Cmd,
+			IsTerminal:  command.Terminal,
+			Description: command.Cmd,
+		})
+	}
+	
+	// 4. Add other available editors (excluding the default one)
+	for _, editor := range availableEditors {
+		if editor.Command != a.config.EditorCmd {
+			allOptions = append(allOptions, editor)
+		}
+	}
+	
+	// 5. Add installed applications registered for the file type last
+	if info, err := os.Stat(path); err == nil && !info.IsDir() {
+		for _, app := range openwith.SystemApps(path) {
+			if !hasEditorCommand(allOptions, app.Command) {
+				allOptions = append(allOptions, app)
+			}
+		}
+	}
+	
+	// Preselect the editor last used for this file type, and mark the
+	// permanent handler
+	initial := 0
+	last, handler := a.openWithManager.Last(path), a.openWithManager.Handler(path)
+	for i, option := range allOptions {
+		if option.Command == last {
+			initial = i
+		}
+		if option.Command == handler {
+			allOptions[i].Description += " (always)"
+		}
+	}
+	
+	// Show editor selection popup
+	a.pauseProgressUpdates()
+	selectedIndex, permanent := a.renderer.ShowEditorSelectionPopup(allOptions, initial, a.navigator, a.inPathEditMode, a.pathEditBuffer, a.showHelp)
+	a.resumeProgressUpdates()
+	
+	// Redraw the main UI after popup closes
+	a.renderer.DrawAndFlush(a.navigator, a.inPathEditMode, a.pathEditBuffer, a.showHelp)
+	
+	// If user cancelled (pressed Esc), return
+	if selectedIndex < 0 {
+		return
+	}
+	
+	// Get the selected option
+	selectedOption := allOptions[selectedIndex]
+	
+	// Handle special system actions
+	switch selectedOption.Command {
+	case "__TERMINAL__":
+		go a.openTerminal()
+		return
+	case "__FINDER__":
+		a.revealInFinder(path)
+		return
+	case "__EXPLORER__":
+		a.revealInExplorer(path)
+		return
+	case "__FILEMANAGER__":
+		a.revealInFileManager(path)
+		return
+	}
+	
+	a.openWithManager.Remember(path, selectedOption.Command)
+	if permanent {
+		// 'p' on the current handler removes it again
+		if selectedOption.Command == handler {
+			a.setHandler(path, "")
+			return
+		}
+		a.setHandler(path, selectedOption.Command)
+	}
+	a.openWith(selectedOption.Command, selectedOption.IsTerminal, path)
+}
+
+// setHandler makes command the handler for files like path in the open_with
+// setting of the config file; an empty command removes it
+func (a *App) setHandler(path, command string) {
+	key := openwith.Key(path)
+	// Handlers used to be kept beside the last choices; drop any old one so
+	// it doesn't hide the config file's
+	a.openWithManager.SetHandler(path, "")
+	handlers := make(map[string]string, len(a.config.OpenWith)+1)
+	for k, v := range a.config.OpenWith {
+		if !strings.EqualFold(k, key) && !strings.EqualFold(k, strings.TrimPrefix(key, ".")) {
+			handlers[k] = v
+		}
+	}
+	if command != "" {
+		handlers[key] = command
+	}
+	a.config.OpenWith = handlers
+	a.openWithManager.SetDefaults(handlers)
+	if err := config.SaveConfigFile(a.config); err != nil {
+		a.renderer.ShowError("Failed to save handler: " + err.Error())
+	}
+}
+
+// hasEditorCommand reports whether options already contain command
+func hasEditorCommand(options []config.EditorOption, command string) bool {
+	for _, option := range options {
+		if option.Command == command {
+			return true
+		}
+	}
+	return false
+}
+
+// openFile opens a file with the permanent handler for its type, or asks
+// which editor to use. Shortcut files open what they point to.
+func (a *App) openFile(path string) {
+	if a.remote(a.navigator) != nil {
+		a.renderer.ShowMessage("Remote files open once downloaded (context menu)")
+		return
+	}
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		a.openWithEditorSelection(path)
+		return
+	}
+	if launcher.IsShortcut(path) && a.openWithManager.Handler(path) == "" {
+		a.openShortcut(path)
+		return
+	}
+	if handler := a.openWithManager.Handler(path); handler != "" {
+		a.openWith(handler, isTerminalEditor(handler), path)
+		return
+	}
+	a.openWithEditorSelection(path)
+}
+
+// openShortcut opens the target of a .url, .webloc, .desktop or .lnk file:
+// addresses in the browser, folders in Xplorer, files with their handler
+// and applications as their entry says
+func (a *App) openShortcut(path string) {
+	target, err := launcher.Resolve(path)
+	if err != nil {
+		a.renderer.ShowError(err.Error())
+		return
+	}
+	
+	switch {
+	case target.URL != "":
+		a.openURL(target.URL)
+		a.renderer.ShowMessage("Opening " + target.URL)
+		
+	case len(target.Command) > 0:
+		name := target.Name
+		if name == "" {
+			name = filepath.Base(target.Command[0])
+		}
+		if target.Terminal {
+			a.runEditor(target.Command, true)
+		} else if err := a.processes.Start(name, exec.Command(target.Command[0], target.Command[1:]...)); err != nil {
+			a.renderer.ShowError(err.Error())
+		}
+		
+	default:
+		info, err := os.Stat(target.Path)
+		if err != nil {
+			a.renderer.ShowError("Shortcut target not found: " + target.Path)
+			return
+		}
+		if !info.IsDir() && !launcher.IsShortcut(target.Path) {
+			a.openFile(target.Path)
+			return
+		}
+		// Show folders, and shortcuts to shortcuts, in the listing
+		a.navigator.RecordJump()
+		a.leftDirectory()
+		a.navigator.ClearFilter()
+		if info.IsDir() {
+			a.navigator.SetCurrentDir(target.Path)
+		} else {
+			a.navigator.SetCurrentDir(filepath.Dir(target.Path))
+			a.navigator.SelectByName(filepath.Base(target.Path), a.visibleLines())
+		}
+		a.previewManager.ResetScroll()
+		a.reloadPreview()
+	}
+}
+
+// testAssociations opens the file association test screen
+func (a *App) testAssociations() {
+	a.pauseProgressUpdates()
+	a.renderer.ShowAssociationTester(a.navigator, a.describeAssociation)
+	a.resumeProgressUpdates()
+}
+
+// describeAssociation explains how Xplorer treats a file name: its type,
+// MIME type, syntax highlighting, preview and what Enter runs. Names are
+// resolved against the current directory, so existing files are judged by
+// their content and size too; a trailing "/" marks a directory.
+func (a *App) describeAssociation(name string) []string {
+	isDir := strings.HasSuffix(name, "/")
+	name = strings.TrimSuffix(name, "/")
+	if name == "" {
+		return []string{"Type a file name to classify it"}
+	}
+	path := name
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(a.navigator.GetCurrentDir(), name)
+	}
+
+	var info os.FileInfo
+	found := "No such file here, classified by name only"
+	if fi, err := os.Stat(path); err == nil {
+		info, isDir = fi, fi.IsDir()
+		found = path
+	}
+
+	kind, mimeType, lang, handler := config.DescribeFileByExt(name), openwith.MimeType(path), preview.DetectLanguage(name), "Directory listing"
+	if isDir {
+		kind, mimeType, lang = "Directory", "inode/directory", ""
+	} else {
+		handler = a.previewManager.Handler(path, info)
+	}
+	if mimeType == "" {
+		mimeType = "unknown"
+	}
+	if lang == "" {
+		lang = "none"
+	}
+
+	open := "Asks, preselecting " + a.config.EditorCmd
+	if command := a.openWithManager.Handler(path); command != "" && !isDir {
+		if command == openwith.SystemDefault {
+			args := openwith.DefaultCommand(path).Args
+			command = strings.Join(args[:len(args)-1], " ")
+		}
+		open = command + " " + path + " (handler for " + openwith.Key(path) + " files)"
+	} else if last := a.openWithManager.Last(path); last != "" {
+		open = "Asks, preselecting " + last + " (last used for " + openwith.Key(path) + ")"
+	}
+
+	lines := []string{
+		"File:      " + found,
+		"Type:      " + kind,
+		"MIME type: " + mimeType,
+		"Language:  " + lang,
+		"Preview:   " + handler,
+		"Open:      " + open,
+	}
+	if info != nil && !isDir {
+		lines = append(lines, "Default:   "+openwith.DefaultOption(path).Name)
+		var apps []string
+		for _, app := range openwith.SystemApps(path) {
+			apps = append(apps, app.Name)
+		}
+		if len(apps) > 0 {
+			lines = append(lines, "Installed: "+strings.Join(apps, ", "))
+		}
+	}
+	return lines
+}
+
+// openWith opens a file with an editor command, suspending the UI for
+// terminal editors
+func (a *App) openWith(command string, isTerminal bool, path string) {
+	// Parse command (might have arguments like "emacs -nw")
+	if command == openwith.SystemDefault {
+		if err := a.processes.Start("Default app", openwith.DefaultCommand(path)); err != nil {
+			a.renderer.ShowError(err.Error())
+		}
+		return
+	}
+	for _, c := range a.config.Commands {
+		if c.Cmd == command {
+			a.runCommand(c, path)
+			return
+		}
+	}
+	parts := strings.Fields(command)
+	if len(parts) == 0 {
+		return
+	}
+	a.runEditor(append(parts, path), isTerminal)
+}
+
+// runCommand runs a user command for path, filling in its placeholders.
+// Terminal commands run with the UI suspended and wait for Enter afterwards,
+// so their output can be read.
+func (a *App) runCommand(c config.Command, path string) {
+	selection := a.fileOpsManager.GetSelectedFiles()
+	if len(selection) == 0 {
+		selection = []string{path}
+	}
+	sort.Strings(selection)
+	args := openwith.Expand(c.Cmd, path, a.navigator.GetCurrentDir(), selection)
+	if len(args) == 0 || a.dryRun(quoteArgs(args), a.navigator.GetCurrentDir()) {
+		return
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Dir = a.navigator.GetCurrentDir()
+	if !c.Terminal {
+		if err := a.processes.Start(c.Name, cmd); err != nil {
+			a.renderer.ShowError(err.Error())
+		}
+		return
+	}
+	a.runInTerminal(c.Name, cmd)
+}
+
+// dryRunLog is the state file commands are logged to in dry-run mode
+const dryRunLog = ".xp_dry_run.log"
+
+// dryRun logs a command line instead of running it when the dry-run
+// setting is on, so commands from shared configs can be audited first. It
+// reports whether the command must not run.
+func (a *App) dryRun(line, dir string) bool {
+	if !a.config.DryRunCommands {
+		return false
+	}
+	entry := fmt.Sprintf("%s  in %s: %s\n", time.Now().Format("2006-01-02 15:04:05"), dir, line)
+	f, err := os.OpenFile(paths.File(dryRunLog), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err == nil {
+		_, err = f.WriteString(entry)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		a.renderer.ShowError("Dry run log: " + err.Error())
+		return true
+	}
+	a.renderer.ShowMessage("Dry run, not run: " + line)
+	return true
+}
+
+// quoteArgs joins command arguments into one line, quoting those that
+// would not read back as a single word
+func quoteArgs(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = arg
+		if arg == "" || strings.ContainsAny(arg, " \t\n\"'\\$") {
+			quoted[i] = strconv.Quote(arg)
+		}
+	}
+	return strings.Join(quoted, " ")
+}
+
+// runInTerminal runs cmd in the terminal with the UI suspended, then waits
+// for Enter so its output can be read
+func (a *App) runInTerminal(name string, cmd *exec.Cmd) {
+	screen.Close()
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "\n%s: %v", name, err)
+	}
+	fmt.Fprint(os.Stderr, "\nPress Enter to return to xp")
+	_, _ = bufio.NewReader(os.Stdin).ReadString('\n')
+	_ = screen.Init()
+	a.navigator.Refresh()
+	a.reloadPreview()
+	a.renderer.DrawAndFlush(a.navigator, a.inPathEditMode, a.pathEditBuffer, a.showHelp)
+}
+
+// maxShellOutput limits the output of a shell command kept for the output pane
+const maxShellOutput = 4 << 20
+
+// shellCommand asks for a shell command and runs it in the current
+// directory with %f, %s and %d filled in. In the terminal it runs with the
+// UI suspended; otherwise its output is shown in a scrollable pane.
+func (a *App) shellCommand(inTerminal bool) {
+	label := ": "
+	if inTerminal {
+		label = "! "
+	}
+	a.pauseProgressUpdates()
+	line := a.renderer.SimplePrompt(label, a.navigator)
+	a.resumeProgressUpdates()
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	
+	dir := a.navigator.GetCurrentDir()
+	file := a.navigator.GetSelectedPath()
+	selection := a.fileOpsManager.GetSelectedFiles()
+	if len(selection) == 0 && file != "" {
+		selection = []string{file}
+	}
+	sort.Strings(selection)
+	line = shell.Expand(line, file, dir, selection)
+	if a.dryRun(line, dir) {
+		return
+	}
+	
+	if inTerminal {
+		a.runInTerminal("Command", shell.Command(context.Background(), line, dir))
+		return
+	}
+	
+	output := shell.NewOutput(maxShellOutput)
+	var runErr error
+	finished := a.runScan("shell:"+line, "Running "+line, func(ctx context.Context) {
+		cmd := shell.Command(ctx, line, dir)
+		cmd.Stdout, cmd.Stderr = output, output
+		runErr = cmd.Run()
+	})
+	
+	lines := output.Lines()
+	if finished && output.Truncated {
+		lines = append(lines, fmt.Sprintf("[output cut off after %s]", formatSize(maxShellOutput)))
+	}
+	switch {
+	case !finished:
+		lines = append(lines, "[canceled]")
+	case runErr != nil:
+		lines = append(lines, "["+runErr.Error()+"]")
+	case len(lines) == 0:
+		lines = append(lines, "[no output]")
+	}
+	a.navigator.Refresh()
+	a.reloadPreview()
+	a.renderer.DrawAndFlush(a.navigator, a.inPathEditMode, a.pathEditBuffer, a.showHelp)
+	a.pauseProgressUpdates()
+	a.renderer.ShowTextPopup("$ "+line, lines)
+	a.resumeProgressUpdates()
+}
+
+// openAtLine opens a file in its handler (or the default editor) with the
+// cursor on the given line, for editors that support it
+func (a *App) openAtLine(path string, line int) {
+	command := a.openWithManager.Handler(path)
+	if command == "" || command == openwith.SystemDefault {
+		command = a.config.EditorCmd
+	}
+	parts := strings.Fields(command)
+	if len(parts) == 0 {
+		return
+	}
+	a.runEditor(append(parts, editorLineArgs(parts[0], path, line)...), isTerminalEditor(command))
+}
+
+// editorLineArgs returns the arguments that open path at line in editor
+func editorLineArgs(editor, path string, line int) []string {
+	switch strings.TrimSuffix(filepath.Base(editor), ".exe") {
+	case "vim", "vi", "nvim", "nano", "emacs", "micro", "gedit", "kak", "joe":
+		return []string{fmt.Sprintf("+%d", line), path}
+	case "hx", "subl", "zed":
+		return []string{fmt.Sprintf("%s:%d", path, line)}
+	case "code", "codium", "cursor":
+		return []string{"-g", fmt.Sprintf("%s:%d", path, line)}
+	case "kate":
+		return []string{"-l", fmt.Sprint(line), path}
+	case "notepad++":
+		return []string{fmt.Sprintf("-n%d", line), path}
+	}
+	return []string{path}
+}
+
+// runEditor runs an editor command line: in the foreground with the UI
+// suspended for terminal editors, in the background otherwise
+func (a *App) runEditor(args []string, isTerminal bool) {
+	if isTerminal {
+		// Terminal editor - suspend UI
+		screen.Close()
+		
+		cmd := exec.Command(args[0], args[1:]...)
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		
+		_ = cmd.Run()
+		
+		// Reinitialize termbox
+		screen.Init()
+		a.renderer.DrawAndFlush(a.navigator, a.inPathEditMode, a.pathEditBuffer, a.showHelp)
+	} else {
+		// GUI editor - run in background
+		if err := a.processes.Start("Editor", exec.Command(args[0], args[1:]...)); err != nil {
+			a.renderer.ShowError(err.Error())
+		}
+	}
+}
+
+// runScan runs a scan on the shared worker pool and waits for it, showing
+// status meanwhile. Esc cancels the scan; it returns false when the scan
+// was canceled or the same scan is already running. A canceled scan may
+// still be running, so its results must then be left alone.
+func (a *App) runScan(key, status string, scan func(ctx context.Context)) bool {
+	done := make(chan struct{})
+	if !worker.Shared().Submit(key, func(ctx context.Context) {
+		scan(ctx)
+		close(done)
+		a.interrupt()
+	}) {
+		a.renderer.ShowMessage("Already running: " + status)
+		return false
+	}
+	
+	status += " (Esc to cancel)"
+	a.renderer.ShowStatus(status)
+	for {
+		select {
+		case <-done:
+			return true
+		default:
+		}
+		ev := screen.PollEvent()
+		switch {
+		case ev.Type == termbox.EventKey && ev.Key == termbox.KeyEsc:
+			// A scan still queued behind others only ends once a worker
+			// takes it, so it is left to finish on its own
+			worker.Shared().Cancel(key)
+			return false
+		case ev.Type == termbox.EventResize:
+			a.renderer.DrawAndFlush(a.navigator, a.inPathEditMode, a.pathEditBuffer, a.showHelp)
+			a.renderer.ShowStatus(status)
+		}
+	}
+}
+
+// grep searches file contents below the search root and opens the
+// chosen match in the editor at its line
+func (a *App) grep() {
+	a.pauseProgressUpdates()
+	defer a.resumeProgressUpdates()
+	
+	pattern, caseMode, regex := a.renderer.GrepPrompt(a.navigator, a.grepCase, a.grepRegex)
+	a.grepCase, a.grepRegex = caseMode, regex
+	if pattern == "" {
+		return
+	}
+	
+	root := a.searchRoot()
+	a.renderer.DrawAndFlush(a.navigator, a.inPathEditMode, a.pathEditBuffer, a.showHelp)
+	var matches []search.Match
+	var truncated bool
+	var err error
+	opts := search.Options{
+		Pattern:    pattern,
+		Regex:      regex,
+		Case:       caseMode,
+		ShowHidden: a.navigator.GetShowHidden(),
+	}
+	if !a.runScan("grep:"+root, "Searching for "+pattern+"...", func(ctx context.Context) {
+		matches, truncated, err = search.Grep(ctx, root, opts)
+	}) {
+		return
+	}
+	if err != nil {
+		a.renderer.ShowError(err.Error())
+		return
+	}
+	if len(matches) == 0 {
+		a.renderer.ShowMessage("No matches for " + pattern)
+		return
+	}
+	
+	// Replacing is last, so Enter on the first result doesn't pick it
+	var labels []string
+	for _, m := range matches {
+		rel, _ := filepath.Rel(root, m.Path)
+		labels = append(labels, fmt.Sprintf("%s:%d: %s", rel, m.Line, m.Text))
+	}
+	labels = append(labels, "Replace in Files...")
+	title := fmt.Sprintf("%d matches", len(matches))
+	if truncated {
+		title = fmt.Sprintf("First %d matches", len(matches))
+	}
+	choice := a.renderer.ShowChoicePopup(title, 120, labels, a.navigator, a.inPathEditMode, a.pathEditBuffer, a.showHelp)
+	a.renderer.DrawAndFlush(a.navigator, a.inPathEditMode, a.pathEditBuffer, a.showHelp)
+	if choice < 0 {
+		return
+	}
+	if choice == len(matches) {
+		// Replacing only the matches found would leave the rest unchanged
+		if truncated {
+			a.renderer.ShowError(fmt.Sprintf("More than %d matches: narrow the search before replacing", len(matches)))
+			return
+		}
+		a.replaceInFiles(root, matches, opts)
+		return
+	}
+	a.openAtLine(matches[choice].Path, matches[choice].Line)
+}
+
+// replaceInFiles asks for a replacement for the grep pattern, previews each
+// changed line for confirmation and then rewrites the accepted lines
+func (a *App) replaceInFiles(root string, matches []search.Match, opts search.Options) {
+	label := "Replace with: "
+	if opts.Regex {
+		label = "Replace with ($1 for groups): "
+	}
+	replacement := a.renderer.SimplePrompt(label, a.navigator)
+	if replacement == "" && !a.renderer.ConfirmPrompt("Replace the matches with nothing?") {
+		return
+	}
+
+	planned, err := search.PlanReplace(matches, opts, replacement)
+	if err != nil {
+		a.renderer.ShowError(err.Error())
+		return
+	}
+	if len(planned) == 0 {
+		a.renderer.ShowMessage("Nothing to replace")
+		return
+	}
+
+	var accepted []search.Replacement
+	all := false
+	for i, rep := range planned {
+		if !all {
+			rel, _ := filepath.Rel(root, rep.Path)
+			a.renderer.DrawAndFlush(a.navigator, a.inPathEditMode, a.pathEditBuffer, a.showHelp)
+			switch a.renderer.ReplacePreview(fmt.Sprintf("%s:%d", rel, rep.Line), i+1, len(planned), rep.Old, rep.New) {
+			case ui.ReplaceCancel:
+				a.renderer.DrawAndFlush(a.navigator, a.inPathEditMode, a.pathEditBuffer, a.showHelp)
+				return
+			case ui.ReplaceNo:
+				continue
+			case ui.ReplaceAll:
+				all = true
+			}
+		}
+		accepted = append(accepted, rep)
+	}
+	a.renderer.DrawAndFlush(a.navigator, a.inPathEditMode, a.pathEditBuffer, a.showHelp)
+	if len(accepted) == 0 {
+		return
+	}
+
+	changed, err := search.ApplyReplacements(accepted, a.fileOpsManager.Backup)
+	a.refreshListing()
+	if err != nil {
+		a.renderer.ShowError(err.Error())
+		return
+	}
+	a.renderer.ShowMessage(fmt.Sprintf("Replaced %d lines in %d files", len(accepted), changed))
+}
+
+// fuzzyResults is how many of the best fuzzy matches are ranked and shown
+const fuzzyResults = 500
+
+// fuzzyJump lists the tree below the search root, lets the user pick
+// an entry by fuzzy matching and selects it in its own directory
+func (a *App) fuzzyJump() {
+	a.pauseProgressUpdates()
+	defer a.resumeProgressUpdates()
+	
+	root := a.searchRoot()
+	var files []string
+	var truncated bool
+	var err error
+	if !a.runScan("files:"+root, "Listing "+root+"...", func(ctx context.Context) {
+		files, truncated, err = search.Files(ctx, root, a.navigator.GetShowHidden())
+	}) {
+		return
+	}
+	if err != nil {
+		a.renderer.ShowError(err.Error())
+		return
+	}
+	
+	title := fmt.Sprintf("Fuzzy jump (%d entries)", len(files))
+	if truncated {
+		title = fmt.Sprintf("Fuzzy jump (first %d entries)", len(files))
+	}
+	caseMode := a.navigator.GetCaseMode()
+	choice := a.renderer.FuzzyFinder(title, func(query string) []search.FuzzyMatch {
+		return search.Fuzzy(query, files, caseMode, fuzzyResults)
+	})
+	a.renderer.DrawAndFlush(a.navigator, a.inPathEditMode, a.pathEditBuffer, a.showHelp)
+	if choice == "" {
+		return
+	}
+	
+	target := filepath.Join(root, choice)
+	a.navigator.RecordJump()
+	a.leftDirectory()
+	a.navigator.ClearFilter()
+	if strings.HasSuffix(choice, string(filepath.Separator)) {
+		a.navigator.SetCurrentDir(target)
+	} else {
+		a.navigator.SetCurrentDir(filepath.Dir(target))
+		a.navigator.SelectByName(filepath.Base(target), a.visibleLines())
+	}
+	a.previewManager.ResetScroll()
+	a.reloadPreview()
+}
+
+// followPreviewLink follows a path or URL on the top line of a text preview:
+// directories are entered, files are selected (scrolling the preview to a
+// "path:line" reference) and URLs open in the browser
+func (a *App) followPreviewLink() {
+	previewed := a.navigator.GetSelectedPath()
+	lines := a.previewManager.GetLines()
+	offset := a.previewManager.GetScrollOffset()
+	if previewed == "" || offset >= len(lines) {
+		return
+	}
+	if info, err := os.Stat(previewed); err != nil || info.IsDir() {
+		return
+	}
+	
+	// Keep URLs and paths that exist, relative to the previewed file
+	var links []preview.Link
+	var labels []string
+	for _, link := range preview.FindLinks(lines[offset]) {
+		if !link.IsURL {
+			target := link.Target
+			if strings.HasPrefix(target, "~") {
+				if home, err := os.UserHomeDir(); err == nil {
+					target = filepath.Join(home, strings.TrimPrefix(target, "~"))
+				}
+			} else if !filepath.IsAbs(target) {
+				target = filepath.Join(filepath.Dir(previewed), target)
+			}
+			if _, err := os.Stat(target); err != nil {
+				continue
+			}
+			link.Target = filepath.Clean(target)
+		}
+		links = append(links, link)
+		labels = append(labels, link.Target)
+	}
+	if len(links) == 0 {
+		a.renderer.ShowMessage("No path or URL on the top preview line (scroll with [ and ])")
+		return
+	}
+	
+	choice := 0
+	if len(links) > 1 {
+		a.pauseProgressUpdates()
+		choice = a.renderer.ShowChoicePopup("Follow", 60, labels, a.navigator, a.inPathEditMode, a.pathEditBuffer, a.showHelp)
+		a.resumeProgressUpdates()
+		if choice < 0 {
+			return
+		}
+	}
+	link := links[choice]
+	
+	if link.IsURL {
+		a.openURL(link.Target)
+		return
+	}
+	a.navigator.RecordJump()
+	a.leftDirectory()
+	a.navigator.ClearFilter()
+	if info, err := os.Stat(link.Target); err == nil && info.IsDir() {
+		a.navigator.SetCurrentDir(link.Target)
+		a.previewManager.ResetScroll()
+		a.reloadPreview()
+		return
+	}
+	a.navigator.SetCurrentDir(filepath.Dir(link.Target))
+	a.navigator.SelectByName(filepath.Base(link.Target), a.visibleLines())
+	a.previewManager.ResetScroll()
+	a.reloadPreview()
+	if link.Line > 1 && link.Line <= len(a.previewManager.GetLines()) {
+		a.previewManager.SetScrollOffset(link.Line - 1)
+	}
+}
+
+// openURL opens a URL in the default browser
+func (a *App) openURL(url string) {
+	switch runtime.GOOS {
+	case "darwin":
+		_ = a.processes.Start("Browser", exec.Command("open", url))
+	case "windows":
+		_ = a.processes.Start("Browser", exec.Command("rundll32", "url.dll,FileProtocolHandler", url))
+	default:
+		_ = a.processes.Start("Browser", exec.Command("xdg-open", url))
+	}
+}
+
+// revealInFinder opens Finder and selects the file (macOS)
+func (a *App) revealInFinder(path string) {
+	_ = a.processes.Start("Finder", exec.Command("open", "-R", path))
+}
+
+// revealInExplorer opens Explorer and selects the file (Windows)
+func (a *App) revealInExplorer(path string) {
+	_ = a.processes.Start("Explorer", exec.Command("explorer", "/select,", path))
+}
+
+// revealInFileManager opens the file manager (Linux)
+func (a *App) revealInFileManager(path string) {
+	// Try common Linux file managers
+	fileManagers := []string{"xdg-open", "nautilus", "dolphin", "thunar", "nemo"}
+	dir := filepath.Dir(path)
+	
+	for _, fm := range fileManagers {
+		if _, err := exec.LookPath(fm); err == nil {
+			if fm == "xdg-open" {
+				_ = a.processes.Start("File manager", exec.Command(fm, dir))
+			} else {
+				_ = a.processes.Start("File manager", exec.Command(fm, path))
+			}
+			return
+		}
+	}
+}
+
+// openInFileManager shows a directory in Finder, Explorer or the desktop's
+// file manager
+func (a *App) openInFileManager(dir string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		return a.processes.Start("Finder", exec.Command("open", dir))
+	case "windows":
+		return a.processes.Start("Explorer", exec.Command("explorer", dir))
+	}
+	for _, fm := range []string{"xdg-open", "nautilus", "dolphin", "thunar", "nemo", "pcmanfm"} {
+		if _, err := exec.LookPath(fm); err == nil {
+			return a.processes.Start("File manager", exec.Command(fm, dir))
+		}
+	}
+	return fmt.Errorf("no file manager found (install xdg-utils)")
+}
+
+// maxClipboardSize limits the files whose contents can be copied
+const maxClipboardSize = 1 << 20
+
+// copyContents puts the text of a file on the system clipboard
+func (a *App) copyContents(path string) {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return
+	}
+	if info.Size() > maxClipboardSize {
+		a.renderer.ShowError(fmt.Sprintf("%s is too large to copy (over %d KB)", info.Name(), maxClipboardSize>>10))
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		a.renderer.ShowError(err.Error())
+		return
+	}
+	if bytes.IndexByte(data, 0) >= 0 || !utf8.Valid(data) {
+		a.renderer.ShowError(info.Name() + " is not a text file")
+		return
+	}
+	if err := clipboard.Write(string(data)); err != nil {
+		a.renderer.ShowError(err.Error())
+		return
+	}
+	a.renderer.ShowMessage(fmt.Sprintf("Copied the contents of %s (%d lines) to the clipboard", info.Name(), bytes.Count(data, []byte("\n"))))
+}
+
+// copyPaths puts part(path) of every target file's absolute path on the
+// system clipboard, one per line without repeats; what names the part in
+// the message. In an empty folder it copies the folder's path.
+func (a *App) copyPaths(what string, part func(string) string) {
+	files := a.targetFiles()
+	var lines []string
+	if len(files) == 0 {
+		// In an empty folder, the folder itself
+		lines = []string{a.navigator.GetCurrentDir()}
+	}
+	seen := make(map[string]bool)
+	for _, file := range files {
+		if abs, err := filepath.Abs(file); err == nil {
+			file = abs
+		}
+		line := part(file)
+		if !seen[line] {
+			seen[line] = true
+			lines = append(lines, line)
+		}
+	}
+	if err := clipboard.Write(strings.Join(lines, "\n")); err != nil {
+		a.renderer.ShowError(err.Error())
+		return
+	}
+	if len(lines) == 1 {
+		a.renderer.ShowMessage(fmt.Sprintf("Copied %s to the clipboard", lines[0]))
+	} else {
+		a.renderer.ShowMessage(fmt.Sprintf("Copied %d %ss to the clipboard", len(lines), what))
+	}
+}
+
+// pasteIntoPath appends the first line of the system clipboard to the path
+// edit buffer, without surrounding quotes or a file:// prefix
+func (a *App) pasteIntoPath() {
+	text, err := clipboard.Read()
+	if err != nil {
+		a.renderer.ShowError(err.Error())
+		return
+	}
+	line, _, _ := strings.Cut(strings.TrimSpace(text), "\n")
+	line = strings.Trim(strings.TrimSpace(line), `"'`)
+	line = strings.TrimPrefix(line, "file://")
+	a.pathEditBuffer += line
+}
+
+// editDirNote opens the note of the current directory in the default
+// editor, creating it first if needed
+func (a *App) editDirNote() {
+	path, err := notes.Create(a.navigator.GetCurrentDir())
+	if err != nil {
+		a.renderer.ShowError(err.Error())
+		return
+	}
+	a.openWith(a.config.EditorCmd, isTerminalEditor(a.config.EditorCmd), path)
+	a.refreshListing()
+}
+
+// ExportListing writes the current listing, as filtered and sorted on
+// screen, to w
+func (a *App) ExportListing(w io.Writer, format listing.Format) error {
+	return listing.Write(w, a.navigator.GetCurrentDir(), a.navigator.GetFileList(), format)
+}
+
+// exportListing asks for a format and a file name and exports the listing
+func (a *App) exportListing() {
+	formats := []listing.Format{listing.Text, listing.CSV, listing.JSON}
+	a.pauseProgressUpdates()
+	defer a.resumeProgressUpdates()
+	choice := a.renderer.ShowChoicePopup("Export Listing", 30, []string{"Text (like ls -l)", "CSV", "JSON"}, a.navigator, a.inPathEditMode, a.pathEditBuffer, a.showHelp)
+	if choice < 0 {
+		return
+	}
+	format := formats[choice]
+	name := a.renderer.RenamePrompt("Export to: ", "listing."+listing.FormatNames[format], a.navigator)
+	if name == "" {
+		return
+	}
+	path := filesystem.ExpandPath(name, a.navigator.GetCurrentDir())
+	if _, err := os.Stat(path); err == nil && !a.renderer.ConfirmPrompt(fmt.Sprintf("Overwrite %s?", filepath.Base(path))) {
+		return
+	}
+	
+	count := len(a.navigator.GetFileList())
+	f, err := os.Create(path)
+	if err != nil {
+		a.renderer.ShowError(err.Error())
+		return
+	}
+	err = a.ExportListing(f, format)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		a.renderer.ShowError(err.Error())
+		return
+	}
+	a.refreshListing()
+	a.renderer.ShowMessage(fmt.Sprintf("Exported %d entries to %s", count, path))
+}
+
+// listingPaths returns the full paths of the entries in the listing, as
+// filtered
+func (a *App) listingPaths() []string {
+	currentDir := a.navigator.GetCurrentDir()
+	var paths []string
+	for _, f := range a.navigator.GetFileList() {
+		paths = append(paths, filepath.Join(currentDir, f.Name()))
+	}
+	return paths
+}
+
+// selectByPattern asks for a glob or /regex/ and selects the matching
+// entries of the listing
+func (a *App) selectByPattern() {
+	a.pauseProgressUpdates()
+	defer a.resumeProgressUpdates()
+	pattern := a.renderer.SimplePrompt("Select (*.log or /regex/): ", a.navigator)
+	if pattern == "" {
+		return
+	}
+	matched, err := a.fileOpsManager.SelectMatching(a.listingPaths(), pattern)
+	if err != nil {
+		a.renderer.ShowError(err.Error())
+		return
+	}
+	if matched == 0 {
+		a.renderer.ShowMessage("Nothing matches " + pattern)
+	}
+}
+
+// targetFiles returns the selected files, or the file under the cursor if none are selected
+func (a *App) targetFiles() []string {
+	files := a.fileOpsManager.GetSelectedFiles()
+	if len(files) == 0 {
+		if selectedPath := a.navigator.GetSelectedPath(); selectedPath != "" {
+			files = []string{selectedPath}
+		}
+	}
+	return files
+}
+
+// applyPairing groups derived files with their source in both panes when
+// pairing is on, using the configured rules, and keeps each cursor on its file
+func (a *App) applyPairing() {
+	var rules filesystem.PairRules
+	if a.pairing {
+		rules = filesystem.PairRules{}
+		for source, derived := range a.config.PairRules {
+			rules[source] = derived
+		}
+	}
+	for _, nav := range a.panes {
+		if nav == nil {
+			continue
+		}
+		selected := nav.GetSelectedFile()
+		nav.SetPairRules(rules)
+		if selected != nil {
+			nav.SelectByName(selected.Name(), a.visibleLines())
+		}
+	}
+}
+
+// withCompanions adds the files grouped with each of files to them, when
+// pairing is on and pair_operations asks for it
+func (a *App) withCompanions(files []string) []string {
+	if !a.config.PairOperations || !a.navigator.IsPairing() {
+		return files
+	}
+	dir := a.navigator.GetCurrentDir()
+	seen := make(map[string]bool, len(files))
+	for _, path := range files {
+		seen[path] = true
+	}
+	result := files
+	for _, path := range files {
+		if filepath.Dir(path) != dir {
+			continue
+		}
+		for _, name := range a.navigator.Companions(filepath.Base(path)) {
+			if companion := filepath.Join(dir, name); !seen[companion] {
+				seen[companion] = true
+				result = append(result, companion)
+			}
+		}
+	}
+	return result
+}
+
+// bookmarkFileDir bookmarks the directory holding the item under the cursor:
+// a directory itself, the directory a symlink points into, or otherwise the
+// file's own directory
+func (a *App) bookmarkFileDir() {
+	path := a.navigator.GetSelectedPath()
+	if path == "" {
+		return
+	}
+	dir := filepath.Dir(path)
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		dir = path
+	} else if target, err := filepath.EvalSymlinks(path); err == nil {
+		dir = filepath.Dir(target)
+	}
+	
+	if a.bookmarkManager.IsBookmarked(dir) {
+		a.renderer.ShowMessage("Already bookmarked: " + dir)
+		return
+	}
+	a.bookmarkManager.Toggle(dir)
+	a.renderer.ShowMessage("Bookmarked: " + dir)
+}
+
+// pinDestination pins the directory under the cursor (or the current directory)
+// as the target for quick copy/move, or unpins it if already pinned
+func (a *App) pinDestination() {
+	dir := a.navigator.GetCurrentDir()
+	if file := a.navigator.GetSelectedFile(); file != nil && file.IsDir() {
+		dir = a.navigator.GetSelectedPath()
+	}
+	if a.fileOpsManager.GetPinnedDir() == dir {
+		a.fileOpsManager.SetPinnedDir("")
+		return
+	}
+	a.fileOpsManager.SetPinnedDir(dir)
+}
+
+// transferToPinned copies or moves the target files to the pinned destination
+func (a *App) transferToPinned(move bool) {
+	pinnedDir := a.fileOpsManager.GetPinnedDir()
+	if pinnedDir == "" {
+		a.renderer.ShowMessage(fmt.Sprintf("No destination pinned (press %s on a folder)", a.config.Keys.PinDestination))
+		return
+	}
+	a.transfer(pinnedDir, move)
+}
+
+// transferToOtherPane copies or moves the target files to the directory of
+// the inactive pane
+func (a *App) transferToOtherPane(move bool) {
+	if a.remote(a.panes[1-a.activePane]) != nil {
+		a.renderer.ShowMessage("The other pane is remote; files can only be downloaded from it")
+		return
+	}
+	destDir := a.panes[1-a.activePane].GetCurrentDir()
+	if destDir == a.navigator.GetCurrentDir() {
+		a.renderer.ShowMessage("Both panes show the same directory")
+		return
+	}
+	a.transfer(destDir, move)
+}
+
+// transfer copies or moves the target files to destDir
+func (a *App) transfer(destDir string, move bool) {
+	files := a.withCompanions(a.targetFiles())
+	if len(files) == 0 {
+		return
+	}
+	op := fileops.OpCopy
+	if move {
+		op = fileops.OpCut
+	}
+	plan := a.preflight(func() (*fileops.Plan, error) {
+		return a.fileOpsManager.PlanTransfer(files, op, destDir)
+	})
+	if plan != nil {
+		a.runPlan(plan, nil)
+	}
+}
+
+// toggleDualPane switches between the three-panel layout and two file lists
+// side by side. The second pane starts in the current directory the first
+// time and keeps its own location, filter, hidden and sort state afterwards.
+func (a *App) toggleDualPane() {
+	a.dualPane = !a.dualPane
+	if !a.dualPane {
+		a.renderer.SetDualPane(nil, nil)
+		return
+	}
+	other := 1 - a.activePane
+	if a.panes[other] == nil {
+		nav := filesystem.NewNavigator()
+		nav.SetCaseMode(a.navigator.GetCaseMode())
+		nav.SetScrollOff(a.config.ScrollOff)
+		nav.SetSecondarySort(filesystem.ParseSortKey(a.config.SecondarySort))
+		nav.SetCurrentDir(a.navigator.GetCurrentDir())
+		a.panes[other] = nav
+		a.applyPairing()
+		a.applyRememberView()
+		nav.RestoreView()
+	} else {
+		a.panes[other].Refresh()
+	}
+	a.renderer.SetDualPane(a.panes[0], a.panes[1])
+}
+
+// focusPane makes pane 0 (left) or 1 (right) the focused one
+func (a *App) focusPane(pane int) {
+	if pane == a.activePane {
+		return
+	}
+	a.activePane = pane
+	a.navigator = a.panes[pane]
+	a.leftDirectory() // Selections belong to the pane they were made in
+	a.refreshListing()
+}
+
+// swapPanes exchanges the left and right pane, keeping focus on the same side
+func (a *App) swapPanes() {
+	a.panes[0], a.panes[1] = a.panes[1], a.panes[0]
+	a.navigator = a.panes[a.activePane]
+	a.leftDirectory()
+	a.renderer.SetDualPane(a.panes[0], a.panes[1])
+	a.reloadPreview()
+}
+
+// mirrorPath shows the focused pane's directory in the other pane, which
+// keeps its own hidden-file and sort state for it but drops its filter
+func (a *App) mirrorPath() {
+	other := a.panes[1-a.activePane]
+	if a.remote(other) != nil {
+		a.renderer.ShowMessage("The other pane is remote")
+		return
+	}
+	// Cleared first, so the listing is read unfiltered and the cursor
+	// remembered for the directory is kept
+	other.ClearFilter()
+	other.SetCurrentDir(a.navigator.GetCurrentDir())
+}
+
+// clipboardDiffTarget returns the single regular file on the clipboard when
+// a different regular file is under the cursor, or ""
+func (a *App) clipboardDiffTarget() string {
+	clipboard := a.fileOpsManager.GetClipboard()
+	selectedPath := a.navigator.GetSelectedPath()
+	if len(clipboard) != 1 || selectedPath == "" || clipboard[0] == selectedPath {
+		return ""
+	}
+	for _, path := range []string{clipboard[0], selectedPath} {
+		if info, err := os.Stat(path); err != nil || !info.Mode().IsRegular() {
+			return ""
+		}
+	}
+	return clipboard[0]
+}
+
+// diffWithClipboard shows the differences between the clipboard file (old)
+// and the file under the cursor (new)
+func (a *App) diffWithClipboard() {
+	oldPath := a.clipboardDiffTarget()
+	if oldPath == "" {
+		return
+	}
+	newPath := a.navigator.GetSelectedPath()
+	lines, err := diff.Files(oldPath, newPath)
+	if err != nil {
+		a.renderer.ShowError(err.Error())
+		return
+	}
+	a.pauseProgressUpdates()
+	a.renderer.ShowDiff(fmt.Sprintf("Diff: %s → %s", filepath.Base(oldPath), filepath.Base(newPath)), lines)
+	a.resumeProgressUpdates()
+}
+
+// deleteFiles moves files to the trash, or deletes them for good after a
+// second, explicit confirmation when permanent is set
+func (a *App) deleteFiles(files []string, permanent bool) {
+	a.renderer.ShowStatus("Planning...")
+	var plan *fileops.Plan
+	var err error
+	switch {
+	case permanent:
+		plan, err = a.fileOpsManager.PlanDelete(files, fileops.OpDelete)
+	case a.config.SoftDeleteDays > 0:
+		plan, err = a.fileOpsManager.PlanStage(files)
+	default:
+		plan, err = a.fileOpsManager.PlanDelete(files, fileops.OpTrash)
+	}
+	if err != nil {
+		a.renderer.ShowError(err.Error())
+		return
+	}
+	summary := plan.Summary()
+	if plan.Staged {
+		summary += fmt.Sprintf(" (purged after %d days)", a.config.SoftDeleteDays)
+	}
+	
+	a.pauseProgressUpdates()
+	confirmed := a.renderer.ConfirmPrompt(summary + "?")
+	if confirmed && permanent {
+		confirmed = a.renderer.ConfirmPrompt("This cannot be undone. Really delete?")
+	}
+	a.resumeProgressUpdates()
+	if !confirmed {
+		return
+	}
+	
+	a.runPlan(plan, nil)
+}
+
+// runPlan executes a plan in the background. execute, if set, runs instead
+// of a plain Execute.
+func (a *App) runPlan(plan *fileops.Plan, execute func(*fileops.Plan) error) {
+	if execute == nil {
+		execute = a.fileOpsManager.Execute
+	}
+	a.runOperation(func() error {
+		return execute(plan)
+	})
+}
+
+// runOperation runs a file operation in a goroutine to allow UI updates.
+// The panes it changes are reloaded as its events come in. It refuses to
+// start while another operation is running.
+func (a *App) runOperation(operation func() error) {
+	if !a.operating.CompareAndSwap(false, true) {
+		a.renderer.ShowError("Wait for the running operation to finish")
+		return
+	}
+	// The operation has taken what it needs from the selection
+	a.fileOpsManager.ClearSelection()
+	go func() {
+		progress := a.fileOpsManager.GetProgress()
+		progress.Mu.RLock()
+		started := progress.StartTime
+		progress.Mu.RUnlock()
+		err := operation()
+		a.countOperation(started)
+		a.operating.Store(false)
+		
+		// The listings follow through the operation's events
+		a.reloadPreview()
+		a.drawWithProgress()
+		
+		// A canceled operation is reported by the progress bar
+		if err != nil && !errors.Is(err, context.Canceled) {
+			a.renderer.ShowError(err.Error())
+		}
+	}()
+}
+
+// countOperation adds the operation that started after the given time, if
+// any, to the statistics. runOperation lets no other operation start until
+// it has been counted.
+func (a *App) countOperation(after time.Time) {
+	progress := a.fileOpsManager.GetProgress()
+	progress.Mu.RLock()
+	defer progress.Mu.RUnlock()
+	if !progress.StartTime.After(after) {
+		return // The operation failed before starting
+	}
+	switch progress.Operation {
+	case fileops.OpCopy:
+		a.stats.Copied(progress.ProcessedFiles, progress.ProcessedBytes)
+	case fileops.OpCut:
+		a.stats.Moved(progress.ProcessedFiles, progress.ProcessedBytes)
+	case fileops.OpDelete, fileops.OpTrash:
+		a.stats.Deleted(progress.ProcessedFiles)
+	}
+}
+
+// showStats shows what was done in this session and in all sessions
+func (a *App) showStats() {
+	session := a.stats.Session()
+	total, since := a.stats.AllTime()
+	row := func(label, now, all string) string {
+		return fmt.Sprintf("%-18s %14s %14s", label, now, all)
+	}
+	count := func(n int) string {
+		return fmt.Sprint(n)
+	}
+	spent := func(seconds int64) string {
+		return (time.Duration(seconds) * time.Second).String()
+	}
+	lines := []string{
+		row("", "This session", "All time"),
+		"",
+		row("Time in xp", spent(session.Seconds), spent(total.Seconds)),
+		row("Sessions", "", count(total.Sessions)),
+		row("Folders visited", count(session.DirsVisited), count(total.DirsVisited)),
+		row("Files copied", count(session.FilesCopied), count(total.FilesCopied)),
+		row("Bytes copied", formatSize(session.BytesCopied), formatSize(total.BytesCopied)),
+		row("Files moved", count(session.FilesMoved), count(total.FilesMoved)),
+		row("Bytes moved", formatSize(session.BytesMoved), formatSize(total.BytesMoved)),
+		row("Files deleted", count(session.FilesDeleted), count(total.FilesDeleted)),
+		"",
+		"Counting since " + since.Format("2006-01-02 15:04") + " (" + paths.File(".xp_stats.json") + ")",
+	}
+	a.pauseProgressUpdates()
+	a.renderer.ShowTextPopup("Statistics", lines)
+	a.resumeProgressUpdates()
+}
+
+// preflight plans a copy or move, asks how to resolve each name conflict
+// and warns about names that differ only by case from existing ones. It
+// returns nil if the operation shouldn't run.
+func (a *App) preflight(planFn func() (*fileops.Plan, error)) *fileops.Plan {
+	a.renderer.ShowStatus("Planning...")
+	plan, err := planFn()
+	if err != nil {
+		a.renderer.ShowError(err.Error())
+		return nil
+	}
+	if plan.Conflicts() == 0 && (!a.config.CaseWarnings || len(plan.CaseClashes()) == 0) {
+		return plan
+	}
+	a.pauseProgressUpdates()
+	defer a.resumeProgressUpdates()
+	if plan.Conflicts() > 0 && !a.resolveConflicts(plan) {
+		return nil
+	}
+	if len(plan.Actions) == plan.Skipped() {
+		a.renderer.ShowMessage("Nothing left to do, every item was skipped")
+		return nil
+	}
+	if clashes := plan.CaseClashes(); len(clashes) > 0 && !a.confirmCaseClash(filepath.Base(clashes[0].Dest), clashes[0].CaseClash, len(clashes)-1) {
+		return nil
+	}
+	return plan
+}
+
+// confirmCaseClash asks whether to go ahead with name although existing
+// differs from it only by case, and more names like it, when case_warnings
+// is on. Such names can't both exist on case-insensitive filesystems.
+func (a *App) confirmCaseClash(name, existing string, more int) bool {
+	if !a.config.CaseWarnings {
+		return true
+	}
+	message := fmt.Sprintf("%s and %s differ only by case, which clashes on case-insensitive disks. Continue?", name, existing)
+	if more > 0 {
+		message = fmt.Sprintf("%s and %s (and %d more) differ only by case. Continue?", name, existing, more)
+	}
+	return a.renderer.ConfirmPrompt(message)
+}
+
+// conflictChoices are the options of the conflict dialog, in the order of
+// their fileops.Resolution
+var conflictChoices = []string{"Keep Both (rename)", "Overwrite", "Skip", "Overwrite if Newer"}
+
+// resolveConflicts asks for every conflicting action whether to keep both,
+// overwrite, skip or overwrite if newer. With more conflicts left, the
+// "... All" options apply the choice to the rest. It returns false when
+// canceled.
+func (a *App) resolveConflicts(plan *fileops.Plan) bool {
+	var conflicts []int
+	for i, action := range plan.Actions {
+		if action.Conflict {
+			conflicts = append(conflicts, i)
+		}
+	}
+
+	applyAll := -1
+	for n, i := range conflicts {
+		choice := applyAll
+		if choice < 0 {
+			options := append([]string(nil), conflictChoices...)
+			if remaining := len(conflicts) - n; remaining > 1 {
+				for _, option := range conflictChoices {
+					options = append(options, fmt.Sprintf("%s: All %d", option, remaining))
+				}
+			}
+			action := plan.Actions[i]
+			title := fmt.Sprintf("%s exists in %s", filepath.Base(action.Existing), filepath.Dir(action.Existing))
+			choice = a.renderer.ShowChoicePopup(title, 60, options, a.navigator, a.inPathEditMode, a.pathEditBuffer, a.showHelp)
+			if choice < 0 {
+				return false
+			}
+			if choice >= len(conflictChoices) {
+				choice -= len(conflictChoices)
+				applyAll = choice
+			}
+		}
+		plan.Resolve(i, fileops.Resolution(choice))
+	}
+	return true
+}
+
+// compress asks for an archive name (.zip or .tar.gz) and packs files into
+// it next to them
+func (a *App) compress(files []string) {
+	currentDir := a.navigator.GetCurrentDir()
+	name := filepath.Base(currentDir)
+	if len(files) == 1 {
+		name = filepath.Base(files[0])
+	}
+	
+	a.pauseProgressUpdates()
+	archive := a.renderer.RenamePrompt("Archive name (.zip or .tar.gz): ", name+".zip", a.navigator)
+	a.resumeProgressUpdates()
+	if archive == "" {
+		return
+	}
+	archivePath := filesystem.ExpandPath(archive, currentDir)
+	a.runOperation(func() error {
+		return a.fileOpsManager.Compress(files, archivePath)
+	})
+}
+
+// toggleBasket adds the target files to the basket, or takes them out when
+// they are all in it already
+func (a *App) toggleBasket() {
+	files := a.targetFiles()
+	if len(files) == 0 {
+		return
+	}
+	allIn := true
+	for _, path := range files {
+		allIn = allIn && a.fileOpsManager.InBasket(path)
+	}
+	if allIn {
+		a.fileOpsManager.RemoveFromBasket(files)
+	} else {
+		a.fileOpsManager.AddToBasket(files)
+	}
+	a.fileOpsManager.ClearSelection()
+}
+
+// toggleExecutable makes the target files executable, or not executable
+// when they all are already. The new mode shows in the metadata bar.
+func (a *App) toggleExecutable() {
+	files := a.targetFiles()
+	if len(files) == 0 {
+		return
+	}
+	_, err := fileops.ToggleExecutable(files)
+	a.refreshListing()
+	if err != nil {
+		a.renderer.ShowError(err.Error())
+	}
+}
+
+// removeQuarantine clears the macOS quarantine attribute from the target
+// files, so downloaded scripts and apps run without the Gatekeeper prompt
+func (a *App) removeQuarantine() {
+	files := a.targetFiles()
+	if len(files) == 0 {
+		return
+	}
+	if err := fileops.RemoveQuarantine(files); err != nil {
+		a.renderer.ShowError(err.Error())
+		return
+	}
+	a.renderer.ShowMessage(fmt.Sprintf("Removed the quarantine attribute from %d items", len(files)))
+}
+
+// showBasket lists the basket with actions that copy, move or trash its
+// contents into the current directory. Choosing an item jumps to it.
+func (a *App) showBasket() {
+	basket := a.fileOpsManager.GetBasket()
+	if len(basket) == 0 {
+		a.renderer.ShowMessage(fmt.Sprintf("Basket is empty (press %s to add files)", a.config.Keys.BasketToggle))
+		return
+	}
+	
+	actions := []string{"Copy All Here", "Move All Here", "Move All to Trash", "Clear Basket"}
+	options := append([]string(nil), actions...)
+	for _, path := range basket {
+		options = append(options, "  "+path)
 	}
 	
-	// 1. Add default editor first
-	defaultEditor := config.EditorOption{
-		Name:        defaultEditorName,
-		Command:     a.config.EditorCmd,
-		IsTerminal:  isTerminalEditor(a.config.EditorCmd),
-		Description: defaultEditorDesc,
+	a.pauseProgressUpdates()
+	choice := a.renderer.ShowChoicePopup(fmt.Sprintf("Basket (%d)", len(basket)), 100, options, a.navigator, a.inPathEditMode, a.pathEditBuffer, a.showHelp)
+	a.resumeProgressUpdates()
+	if choice < 0 {
+		return
 	}
-	allOptions = append(allOptions, defaultEditor)
 	
-	// 2. Add system actions (terminal and file explorer) second
-	systemActions := config.GetSystemActions()
-	allOptions = append(allOptions, systemActions...)
+	currentDir := a.navigator.GetCurrentDir()
+	if choice >= len(actions) {
+		path := basket[choice-len(actions)]
+		a.navigator.RecordJump()
+		a.leftDirectory()
+		a.navigator.ClearFilter()
+		a.navigator.SetCurrentDir(filepath.Dir(path))
+		a.navigator.SelectByName(filepath.Base(path), a.visibleLines())
+		a.previewManager.ResetScroll()
+		a.reloadPreview()
+		return
+	}
 	
-	// 3. Add other available editors (excluding the default one) last
-	for _, editor := range availableEditors {
-		if editor.Command != a.config.EditorCmd {
-			allOptions = append(allOptions, editor)
+	switch actions[choice] {
+	case "Copy All Here", "Move All Here":
+		op := fileops.OpCopy
+		if actions[choice] == "Move All Here" {
+			op = fileops.OpCut
+		}
+		plan := a.preflight(func() (*fileops.Plan, error) {
+			return a.fileOpsManager.PlanTransfer(basket, op, currentDir)
+		})
+		if plan != nil {
+			a.runPlan(plan, nil)
+		}
+	case "Move All to Trash":
+		a.deleteFiles(basket, false)
+	case "Clear Basket":
+		a.fileOpsManager.ClearBasket()
+	}
+}
+
+// showClipboard lists the files on the clipboard with actions that paste
+// them here or clear the clipboard. Choosing a file takes it off.
+func (a *App) showClipboard() {
+	for {
+		clipboard := a.fileOpsManager.GetClipboard()
+		count, op := a.fileOpsManager.GetClipboardInfo()
+		if count == 0 {
+			a.renderer.ShowMessage("Clipboard is empty (copy or cut files from the context menu)")
+			return
+		}
+		
+		verb := "Copy"
+		if op == fileops.OpCut {
+			verb = "Move"
+		}
+		actions := []string{"Paste Here (" + strings.ToLower(verb) + ")", "Clear Clipboard"}
+		options := append([]string(nil), actions...)
+		for _, path := range clipboard {
+			options = append(options, "  "+path)
+		}
+		
+		a.pauseProgressUpdates()
+		title := fmt.Sprintf("Clipboard: %s %d (choose a file to remove it)", verb, count)
+		choice := a.renderer.ShowChoicePopup(title, 100, options, a.navigator, a.inPathEditMode, a.pathEditBuffer, a.showHelp)
+		a.resumeProgressUpdates()
+		switch {
+		case choice < 0:
+			return
+		case choice >= len(actions):
+			a.fileOpsManager.RemoveFromClipboard([]string{clipboard[choice-len(actions)]})
+			continue // Show what's left
+		case choice == 0:
+			currentDir := a.navigator.GetCurrentDir()
+			plan := a.preflight(func() (*fileops.Plan, error) {
+				return a.fileOpsManager.PlanPaste(currentDir)
+			})
+			if plan != nil {
+				a.runPlan(plan, a.fileOpsManager.ExecutePaste)
+			}
+		default:
+			a.fileOpsManager.ClearClipboard()
 		}
+		return
+	}
+}
+
+// showProcesses lists the programs started in the background (editors,
+// terminals, file managers) with their status. Choosing a running one
+// offers to kill it.
+func (a *App) showProcesses() {
+	list := a.processes.List()
+	if len(list) == 0 {
+		a.renderer.ShowMessage("No background processes started yet")
+		return
 	}
 	
-	// Show editor selection popup
+	options := []string{"Clear Ended"}
+	for _, p := range list {
+		options = append(options, fmt.Sprintf("%-13s %-20s pid %-7d %s", p.Name, p.Status(), p.PID, p.Command))
+	}
 	a.pauseProgressUpdates()
-	selectedIndex := a.renderer.ShowEditorSelectionPopup(allOptions, a.navigator, a.inPathEditMode, a.pathEditBuffer, a.showHelp)
+	title := fmt.Sprintf("Processes (%d running)", a.processes.Running())
+	choice := a.renderer.ShowChoicePopup(title, 100, options, a.navigator, a.inPathEditMode, a.pathEditBuffer, a.showHelp)
 	a.resumeProgressUpdates()
+	switch {
+	case choice < 0:
+		return
+	case choice == 0:
+		a.processes.Clear()
+		return
+	}
 	
-	// Redraw the main UI after popup closes
-	a.renderer.DrawAndFlush(a.navigator, a.inPathEditMode, a.pathEditBuffer, a.showHelp)
+	p := list[choice-1]
+	if !p.Running() {
+		a.renderer.ShowMessage(fmt.Sprintf("%s (pid %d) %s", p.Name, p.PID, p.Status()))
+		return
+	}
+	if !a.renderer.ConfirmPrompt(fmt.Sprintf("Kill %s (pid %d)?", p.Name, p.PID)) {
+		return
+	}
+	if err := a.processes.Kill(p.ID); err != nil {
+		a.renderer.ShowError(err.Error())
+		return
+	}
+	a.renderer.ShowMessage(fmt.Sprintf("Killed %s (pid %d)", p.Name, p.PID))
+}
+
+// showSelectionSets saves the selection (or the basket) as a named set, or
+// restores a saved set as the selection or into the basket
+func (a *App) showSelectionSets() {
+	sets := a.selectionSets.GetAll()
+	options := []string{"Save Selection as..."}
+	for _, s := range sets {
+		options = append(options, fmt.Sprintf("  %s (%d files, %s)", s.Name, len(s.Paths), s.Saved.Format("2006-01-02")))
+	}
 	
-	// If user cancelled (pressed Esc), return
-	if selectedIndex < 0 {
+	a.pauseProgressUpdates()
+	defer a.resumeProgressUpdates()
+	choice := a.renderer.ShowChoicePopup("Selection Sets", 70, options, a.navigator, a.inPathEditMode, a.pathEditBuffer, a.showHelp)
+	if choice < 0 {
+		return
+	}
+	if choice == 0 {
+		a.saveSelectionSet()
 		return
 	}
 	
-	// Get the selected option
-	selectedOption := allOptions[selectedIndex]
+	set := sets[choice-1]
+	actions := []string{"Select", "Add to Basket", "Delete Set"}
+	action := a.renderer.ShowChoicePopup(set.Name, 40, actions, a.navigator, a.inPathEditMode, a.pathEditBuffer, a.showHelp)
+	if action < 0 {
+		return
+	}
+	if actions[action] == "Delete Set" {
+		if a.renderer.ConfirmPrompt(fmt.Sprintf("Delete selection set %s?", set.Name)) {
+			a.selectionSets.Remove(set.Name)
+		}
+		return
+	}
 	
-	// Handle special system actions
-	switch selectedOption.Command {
-	case "__TERMINAL__":
-		go a.openTerminal()
+	files := set.Existing()
+	if len(files) == 0 {
+		a.renderer.ShowMessage(fmt.Sprintf("None of the %d files of %s exist any more", len(set.Paths), set.Name))
 		return
-	case "__FINDER__":
-		a.revealInFinder(path)
+	}
+	note := ""
+	if missing := len(set.Paths) - len(files); missing > 0 {
+		note = fmt.Sprintf(", %d missing", missing)
+	}
+	if actions[action] == "Add to Basket" {
+		a.fileOpsManager.AddToBasket(files)
+		a.renderer.DrawAndFlush(a.navigator, a.inPathEditMode, a.pathEditBuffer, a.showHelp)
+		a.renderer.ShowMessage(fmt.Sprintf("Added %d files to the basket%s", len(files), note))
 		return
-	case "__EXPLORER__":
-		a.revealInExplorer(path)
+	}
+	
+	// Show the files when they share a folder; otherwise the selection
+	// spans folders and lasts until the directory changes
+	dir := filepath.Dir(files[0])
+	for _, path := range files {
+		if filepath.Dir(path) != dir {
+			dir = ""
+			break
+		}
+	}
+	a.fileOpsManager.ClearSelection()
+	if dir != "" && dir != a.navigator.GetCurrentDir() {
+		a.navigator.RecordJump()
+		a.navigator.ClearFilter()
+		a.navigator.SetCurrentDir(dir)
+		a.navigator.SelectByName(filepath.Base(files[0]), a.visibleLines())
+		a.previewManager.ResetScroll()
+		a.reloadPreview()
+	}
+	a.fileOpsManager.Select(files)
+	a.renderer.DrawAndFlush(a.navigator, a.inPathEditMode, a.pathEditBuffer, a.showHelp)
+	if dir == "" {
+		a.renderer.ShowMessage(fmt.Sprintf("Selected %d files in several folders%s; operate on them before changing folder, or add them to the basket", len(files), note))
+	} else if note != "" {
+		a.renderer.ShowMessage(fmt.Sprintf("Selected %d files%s", len(files), note))
+	}
+}
+
+// saveSelectionSet asks for a name and saves the selection, or the basket
+// when nothing is selected
+func (a *App) saveSelectionSet() {
+	files := a.fileOpsManager.GetSelectedFiles()
+	if len(files) == 0 {
+		files = a.fileOpsManager.GetBasket()
+	}
+	if len(files) == 0 {
+		a.renderer.ShowMessage(fmt.Sprintf("Nothing to save: select files (%s) or add them to the basket (%s)", a.config.Keys.Select, a.config.Keys.BasketToggle))
 		return
-	case "__FILEMANAGER__":
-		a.revealInFileManager(path)
+	}
+	name := strings.TrimSpace(a.renderer.SimplePrompt(fmt.Sprintf("Name for %d files: ", len(files)), a.navigator))
+	if name == "" {
 		return
 	}
+	for _, s := range a.selectionSets.GetAll() {
+		if s.Name == name && !a.renderer.ConfirmPrompt(fmt.Sprintf("Replace selection set %s?", name)) {
+			return
+		}
+	}
+	sort.Strings(files)
+	a.selectionSets.Add(name, files)
+}
+
+// showHistory lists the directories visited this session, most recent
+// first, and moves to the chosen one within the history
+func (a *App) showHistory() {
+	history, current := a.navigator.GetHistory()
+	var indexes []int
+	var options []string
+	seen := make(map[string]bool)
+	for i := len(history) - 1; i >= 0; i-- {
+		if seen[history[i]] {
+			continue
+		}
+		seen[history[i]] = true
+		marker := "  "
+		if i == current {
+			marker = "● "
+		}
+		indexes = append(indexes, i)
+		options = append(options, marker+history[i])
+	}
 	
-	// Open file with the selected editor
-	if selectedOption.IsTerminal {
-		// Terminal editor - suspend UI
-		termbox.Close()
-		
-		// Parse command (might have arguments like "emacs -nw")
-		parts := strings.Fields(selectedOption.Command)
-		cmd := exec.Command(parts[0], append(parts[1:], path)...)
-		cmd.Stdin = os.Stdin
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
+	a.pauseProgressUpdates()
+	choice := a.renderer.ShowChoicePopup(fmt.Sprintf("Recent Directories (%s/%s: back/forward)", a.config.Keys.HistoryBack, a.config.Keys.HistoryForward), 100, options, a.navigator, a.inPathEditMode, a.pathEditBuffer, a.showHelp)
+	a.resumeProgressUpdates()
+	if choice < 0 {
+		return
+	}
+	if a.navigator.GoToHistory(indexes[choice]) {
+		a.leftDirectory()
+		a.previewManager.ResetScroll()
+		a.reloadPreview()
+	}
+}
+
+// binName names the trash, or the staging area when staged is set
+func binName(staged bool) string {
+	if staged {
+		return "Staging Area"
+	}
+	return "Trash"
+}
+
+// restoreFromTrash lists the trash, or the staging area when staged is set,
+// and restores or purges the chosen item
+func (a *App) restoreFromTrash(staged bool) {
+	list := a.fileOpsManager.TrashItems
+	if staged {
+		list = a.fileOpsManager.StagedItems
+	}
+	items, err := list()
+	if err != nil {
+		a.renderer.ShowError(err.Error())
+		return
+	}
+	if len(items) == 0 {
+		a.renderer.ShowError(binName(staged) + " is empty")
+		return
+	}
+	
+	labels := make([]string, len(items))
+	for i, item := range items {
+		labels[i] = fmt.Sprintf("%s  %s  (%s)", filepath.Base(item.OriginalPath),
+			filepath.Dir(item.OriginalPath), item.DeletedAt.Format("2006-01-02 15:04"))
+		if days := a.config.SoftDeleteDays; staged && days > 0 {
+			left := time.Until(item.DeletedAt.AddDate(0, 0, days))
+			// Round up, so a fresh item has all its days left
+			labels[i] += fmt.Sprintf("  %dd left", max(0, int((left+24*time.Hour-1)/(24*time.Hour))))
+		}
+	}
+	
+	a.pauseProgressUpdates()
+	choice := a.renderer.ShowChoicePopup("Restore from "+binName(staged), 100, labels, a.navigator, a.inPathEditMode, a.pathEditBuffer, a.showHelp)
+	action := -1
+	if choice >= 0 {
+		action = a.renderer.ShowChoicePopup(filepath.Base(items[choice].OriginalPath), 40, []string{"Restore", "Purge Now"}, a.navigator, a.inPathEditMode, a.pathEditBuffer, a.showHelp)
+	}
+	if action == 1 {
+		if !a.renderer.ConfirmPrompt("Permanently delete " + filepath.Base(items[choice].OriginalPath) + "?") {
+			action = -1
+		}
+	}
+	a.resumeProgressUpdates()
+	
+	switch action {
+	case 0:
+		restored, err := a.fileOpsManager.Restore(items[choice])
+		if err != nil {
+			a.renderer.ShowError(err.Error())
+			return
+		}
 		
-		_ = cmd.Run()
+		// Show the restored item
+		a.navigator.RecordJump()
+		a.leftDirectory()
+		a.navigator.ClearFilter()
+		a.navigator.SetCurrentDir(filepath.Dir(restored))
+		a.navigator.SelectByName(filepath.Base(restored), a.visibleLines())
+		a.previewManager.ResetScroll()
+		a.reloadPreview()
 		
-		// Reinitialize termbox
-		termbox.Init()
-		a.renderer.DrawAndFlush(a.navigator, a.inPathEditMode, a.pathEditBuffer, a.showHelp)
-	} else {
-		// GUI editor - run in background
-		parts := strings.Fields(selectedOption.Command)
-		cmd := exec.Command(parts[0], append(parts[1:], path)...)
-		_ = cmd.Start()
+	case 1:
+		if err := a.fileOpsManager.Purge(items[choice]); err != nil {
+			a.renderer.ShowError(err.Error())
+		}
 	}
 }
 
-// revealInFinder opens Finder and selects the file (macOS)
-func (a *App) revealInFinder(path string) {
-	exec.Command("open", "-R", path).Start()
+// softDeleteSteps are the staging periods, in days, offered by the
+// configuration menu; 0 deletes to the trash
+var softDeleteSteps = []int{0, 1, 7, 30}
+
+// purgeInterval is how often the staging area is checked for expired items
+const purgeInterval = time.Hour
+
+// applySoftDelete takes up the configured staging period and purges the
+// items kept longer than it
+func (a *App) applySoftDelete() {
+	a.softDeleteDays.Store(int64(a.config.SoftDeleteDays))
+	a.purgeStaging()
 }
 
-// revealInExplorer opens Explorer and selects the file (Windows)
-func (a *App) revealInExplorer(path string) {
-	exec.Command("explorer", "/select,", path).Start()
+// purgeStaging permanently deletes staged items older than the staging
+// period. With soft delete off, staged items are kept until emptied.
+func (a *App) purgeStaging() {
+	days := a.softDeleteDays.Load()
+	if days <= 0 {
+		return
+	}
+	if _, err := a.fileOpsManager.PurgeStagedOlderThan(time.Duration(days) * 24 * time.Hour); err != nil {
+		a.debugLog("Purging the staging area failed: %v", err)
+	}
 }
 
-// revealInFileManager opens the file manager (Linux)
-func (a *App) revealInFileManager(path string) {
-	// Try common Linux file managers
-	fileManagers := []string{"xdg-open", "nautilus", "dolphin", "thunar", "nemo"}
-	dir := filepath.Dir(path)
-	
-	for _, fm := range fileManagers {
-		if _, err := exec.LookPath(fm); err == nil {
-			if fm == "xdg-open" {
-				exec.Command(fm, dir).Start()
-			} else {
-				exec.Command(fm, path).Start()
-			}
+// schedulePurge purges the staging area every purgeInterval until Run
+// returns, so long sessions don't keep expired items
+func (a *App) schedulePurge() {
+	time.AfterFunc(purgeInterval, func() {
+		if a.stopped.Load() {
 			return
 		}
+		a.purgeStaging()
+		a.schedulePurge()
+	})
+}
+
+// backupModes are the backup settings offered by the configuration menu,
+// in the order it cycles through them
+var backupModes = []string{config.BackupOff, config.BackupSuffix, config.BackupDir}
+
+// applyLowPriority lowers the priority of file operations and folder scans
+// when low_priority is on
+func (a *App) applyLowPriority() {
+	a.fileOpsManager.SetLowPriority(a.config.LowPriority)
+	worker.Shared().SetLowPriority(a.config.LowPriority)
+}
+
+// applyBackups sets up backups of overwritten items and purges backups
+// kept longer than the configured days
+func (a *App) applyBackups() {
+	dir := paths.File(".xp_backups")
+	switch a.config.BackupMode {
+	case config.BackupSuffix:
+		a.fileOpsManager.SetBackup(fileops.BackupSuffix, dir)
+	case config.BackupDir:
+		a.fileOpsManager.SetBackup(fileops.BackupDir, dir)
+	default:
+		a.fileOpsManager.SetBackup(fileops.BackupOff, dir)
+	}
+	if a.config.BackupDays <= 0 {
+		return
+	}
+	if _, err := fileops.PurgeBackups(dir, time.Duration(a.config.BackupDays)*24*time.Hour); err != nil {
+		a.debugLog("Purging backups failed: %v", err)
+	}
+}
+
+// applyCreateModes sets the permissions new files and folders are made with.
+// The config only keeps modes that parse.
+func (a *App) applyCreateModes() {
+	fileMode, _ := config.ParseMode(a.config.NewFileMode)
+	dirMode, _ := config.ParseMode(a.config.NewDirMode)
+	a.fileOpsManager.SetCreateModes(fileMode, dirMode, a.config.InheritGroup)
+}
+
+// newFile asks for a name and creates an empty file in dir, or a copy of
+// template when it is set. With edit the file is then opened in the editor.
+func (a *App) newFile(dir string, template *config.Template, edit bool) {
+	a.pauseProgressUpdates()
+	var filename string
+	if template != nil {
+		filename = a.renderer.RenamePrompt("New file name: ", filepath.Base(template.File), a.navigator)
+	} else {
+		filename = a.renderer.SimplePrompt("New file name: ", a.navigator)
+	}
+	if clash := fileops.CaseClash(dir, filename); filename != "" && clash != "" && !a.confirmCaseClash(filename, clash, 0) {
+		filename = ""
+	}
+	a.resumeProgressUpdates()
+	if filename == "" {
+		return
+	}
+	
+	var err error
+	if template != nil {
+		// Relative template paths are in the settings directory
+		source := filesystem.ExpandPath(template.File, filepath.Dir(config.GetConfigFilePath()))
+		err = a.fileOpsManager.CreateFileFrom(dir, filename, source)
+	} else {
+		err = a.fileOpsManager.CreateFile(dir, filename)
+	}
+	if err != nil {
+		a.renderer.ShowError(err.Error())
+		return
+	}
+	a.navigator.Refresh()
+	a.navigator.SelectByName(filename, a.visibleLines())
+	a.reloadPreview()
+	if edit {
+		a.openEditor(filepath.Join(dir, filename))
 	}
 }
 
 // handleContextMenu shows and handles the context menu for file operations
 func (a *App) handleContextMenu() {
-	selectedPath := a.navigator.GetSelectedPath()
+	if a.remote(a.navigator) != nil {
+		a.handleRemoteMenu()
+		return
+	}
 	currentDir := a.navigator.GetCurrentDir()
 	
 	// Get selected files (or current file if none selected)
-	selectedFiles := a.fileOpsManager.GetSelectedFiles()
-	if len(selectedFiles) == 0 && selectedPath != "" {
-		selectedFiles = []string{selectedPath}
-	}
+	selectedFiles := a.targetFiles()
 	
 	// Build menu options based on context
 	var options []string
 	
 	// If we have files selected or a file under cursor, show all options
 	if len(selectedFiles) > 0 {
-		options = []string{
+		options = []string{"Open With..."}
+		for _, command := range a.config.Commands {
+			options = append(options, "Run "+command.Name)
+		}
+		options = append(options,
 			"Copy",
 			"Cut",
 			"Paste",
 			"Rename",
 			"Delete",
+			"Delete Permanently",
+			"Compress to...",
 			"New File",
-			"New Folder",
-			"Cancel",
-		}
+			"New File and Edit",
+		)
 	} else {
 		// Empty directory - only show creation and paste options
 		options = []string{
 			"Paste",
 			"New File",
-			"New Folder",
-			"Cancel",
+			"New File and Edit",
 		}
 	}
+	for _, template := range a.config.Templates {
+		options = append(options, "New from "+template.Name)
+	}
+	options = append(options,
+		"New Folder",
+		"Restore from Trash",
+		"Empty Trash",
+	)
+	// The staging area keeps its items after soft delete is turned off
+	if staged, _ := a.fileOpsManager.StagedItems(); a.config.SoftDeleteDays > 0 || len(staged) > 0 {
+		options = append(options, "Restore from Staging Area", "Empty Staging Area")
+	}
+	options = append(options, "Cancel")
+	
+	// Offer extraction when the item under the cursor is an archive
+	selectedPath := a.navigator.GetSelectedPath()
+	if fileops.IsArchive(selectedPath) {
+		options = append([]string{"Extract Here", "Extract To..."}, options...)
+	}
+	
+	// Offer copying the text of a file
+	if info, err := os.Stat(selectedPath); err == nil && info.Mode().IsRegular() {
+		options = append([]string{"Copy Contents to Clipboard"}, options...)
+	}
+	
+	// Offer a diff when one file is on the clipboard and another is under the cursor
+	if a.clipboardDiffTarget() != "" {
+		options = append([]string{"Diff with Clipboard Item"}, options...)
+	}
 	
 	// Show context menu
 	a.pauseProgressUpdates()
@@ -708,31 +3442,49 @@ func (a *App) handleContextMenu() {
 		return
 	}
 	
+	for _, command := range a.config.Commands {
+		if options[selectedIndex] == "Run "+command.Name {
+			a.runCommand(command, selectedPath)
+			a.drawWithProgress()
+			return
+		}
+	}
+	for _, template := range a.config.Templates {
+		if options[selectedIndex] == "New from "+template.Name {
+			a.newFile(currentDir, &template, template.Edit)
+			return
+		}
+	}
+	
 	// Handle selected operation
 	switch options[selectedIndex] {
+	case "Diff with Clipboard Item":
+		a.diffWithClipboard()
+		
+	case "Copy Contents to Clipboard":
+		a.copyContents(selectedPath)
+		
+	case "Open With...":
+		if selectedPath != "" {
+			a.openWithEditorSelection(selectedPath)
+		}
+		
 	case "Copy":
-		a.fileOpsManager.Copy(selectedFiles)
+		a.fileOpsManager.Copy(a.withCompanions(selectedFiles))
 		a.fileOpsManager.ClearSelection()
 		
 	case "Cut":
-		a.fileOpsManager.Cut(selectedFiles)
+		a.fileOpsManager.Cut(a.withCompanions(selectedFiles))
 		a.fileOpsManager.ClearSelection()
 		
 	case "Paste":
 		if a.fileOpsManager.HasClipboard() {
-			// Run paste operation in goroutine to allow UI updates
-			go func() {
-				err := a.fileOpsManager.Paste(currentDir)
-				
-				// Always refresh the view after operation
-				a.navigator.Refresh()
-				a.reloadPreview()
-				a.drawWithProgress()
-				
-				if err != nil {
-					a.renderer.ShowError(err.Error())
-				}
-			}()
+			plan := a.preflight(func() (*fileops.Plan, error) {
+				return a.fileOpsManager.PlanPaste(currentDir)
+			})
+			if plan != nil {
+				a.runPlan(plan, a.fileOpsManager.ExecutePaste)
+			}
 		}
 		
 	case "Rename":
@@ -740,7 +3492,7 @@ func (a *App) handleContextMenu() {
 			oldPath := selectedFiles[0]
 			oldName := filepath.Base(oldPath)
 			a.pauseProgressUpdates()
-			newName := a.renderer.SimplePrompt("Rename to: ", a.navigator)
+			newName := a.renderer.RenamePrompt("Rename to: ", oldName, a.navigator)
 			a.resumeProgressUpdates()
 			if newName != "" && newName != oldName {
 				if err := a.fileOpsManager.Rename(oldPath, newName); err != nil {
@@ -753,48 +3505,57 @@ func (a *App) handleContextMenu() {
 		}
 		
 	case "Delete":
-		count := len(selectedFiles)
-		confirmMsg := "Delete " + filepath.Base(selectedFiles[0]) + "?"
-		if count > 1 {
-			confirmMsg = fmt.Sprintf("Delete %d files?", count)
-		}
+		a.deleteFiles(a.withCompanions(selectedFiles), false)
+		
+	case "Delete Permanently":
+		a.deleteFiles(a.withCompanions(selectedFiles), true)
+		
+	case "Compress to...":
+		a.compress(selectedFiles)
 		
+	case "Extract Here":
+		a.runOperation(func() error {
+			return a.fileOpsManager.Extract(selectedPath, currentDir)
+		})
+		
+	case "Extract To...":
 		a.pauseProgressUpdates()
-		confirmed := a.renderer.ConfirmPrompt(confirmMsg)
+		dest := a.renderer.RenamePrompt("Extract to: ", fileops.ArchiveStem(selectedPath), a.navigator)
 		a.resumeProgressUpdates()
-		if confirmed {
-			// Run delete operation in goroutine to allow UI updates
-			go func() {
-				err := a.fileOpsManager.Delete(selectedFiles)
-				
-				// Always refresh the view after operation
-				a.fileOpsManager.ClearSelection()
-				a.navigator.Refresh()
-				a.reloadPreview()
-				a.drawWithProgress()
-				
-				if err != nil {
-					a.renderer.ShowError(err.Error())
-				}
-			}()
+		if dest != "" {
+			destDir := filesystem.ExpandPath(dest, currentDir)
+			a.runOperation(func() error {
+				return a.fileOpsManager.Extract(selectedPath, destDir)
+			})
 		}
 		
-	case "New File":
+	case "Restore from Trash", "Restore from Staging Area":
+		a.restoreFromTrash(options[selectedIndex] == "Restore from Staging Area")
+		
+	case "Empty Trash", "Empty Staging Area":
+		staged := options[selectedIndex] == "Empty Staging Area"
 		a.pauseProgressUpdates()
-		filename := a.renderer.SimplePrompt("New file name: ", a.navigator)
+		confirmed := a.renderer.ConfirmPrompt("Permanently delete everything in the " + strings.ToLower(binName(staged)) + "?")
 		a.resumeProgressUpdates()
-		if filename != "" {
-			if err := a.fileOpsManager.CreateFile(currentDir, filename); err != nil {
-				a.renderer.ShowError(err.Error())
-			} else {
-				a.navigator.Refresh()
-				a.reloadPreview()
+		if confirmed {
+			empty := a.fileOpsManager.EmptyTrash
+			if staged {
+				empty = a.fileOpsManager.EmptyStaging
+			}
+			if err := empty(); err != nil {
+				a.renderer.ShowError(err.Error())
 			}
 		}
 		
+	case "New File", "New File and Edit":
+		a.newFile(currentDir, nil, options[selectedIndex] == "New File and Edit")
+		
 	case "New Folder":
 		a.pauseProgressUpdates()
 		foldername := a.renderer.SimplePrompt("New folder name: ", a.navigator)
+		if clash := fileops.CaseClash(currentDir, foldername); foldername != "" && clash != "" && !a.confirmCaseClash(foldername, clash, 0) {
+			foldername = ""
+		}
 		a.resumeProgressUpdates()
 		if foldername != "" {
 			if err := a.fileOpsManager.CreateFolder(currentDir, foldername); err != nil {
@@ -809,6 +3570,114 @@ func (a *App) handleContextMenu() {
 	a.drawWithProgress()
 }
 
+// slideshowInterval is the delay between images when auto-advance is on
+const slideshowInterval = 3 * time.Second
+
+// runSlideshow steps through the images in the current directory full-screen
+func (a *App) runSlideshow() {
+	currentDir := a.navigator.GetCurrentDir()
+	var images []string
+	start := 0
+	for _, f := range a.navigator.GetFileList() {
+		if f.IsDir() || !preview.IsImageFile(f.Name()) {
+			continue
+		}
+		if f.Name() == filepath.Base(a.navigator.GetSelectedPath()) {
+			start = len(images)
+		}
+		images = append(images, filepath.Join(currentDir, f.Name()))
+	}
+	if len(images) == 0 {
+		a.renderer.ShowMessage("No images in this directory")
+		return
+	}
+	
+	index := start
+	autoAdvance := false
+	var timer *time.Timer
+	
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+		// Leave the cursor on the last image shown
+		a.navigator.Refresh()
+		if len(images) > 0 {
+			a.navigator.SelectByName(filepath.Base(images[index]), a.visibleLines())
+		}
+		a.previewManager.ResetScroll()
+		a.reloadPreview()
+	}()
+	
+	for len(images) > 0 {
+		path := images[index]
+		a.previewManager.LoadPreview(path, a.navigator.GetShowHidden(), 1)
+		a.renderer.DrawSlideshow(a.previewManager.GetImage(), filepath.Base(path), index, len(images), autoAdvance)
+		
+		if timer != nil {
+			timer.Stop()
+			timer = nil
+		}
+		if autoAdvance {
+			timer = time.AfterFunc(slideshowInterval, a.interrupt)
+		}
+		
+		ev := screen.PollEvent()
+		if ev.Type == termbox.EventInterrupt {
+			if autoAdvance {
+				index = (index + 1) % len(images)
+			}
+			continue
+		}
+		if ev.Type != termbox.EventKey {
+			continue
+		}
+		
+		switch {
+		case ev.Key == termbox.KeyEsc || ev.Ch == 'q':
+			return
+		case ev.Ch == 'n' || ev.Key == termbox.KeyArrowRight || ev.Key == termbox.KeySpace:
+			index = (index + 1) % len(images)
+		case ev.Ch == 'p' || ev.Key == termbox.KeyArrowLeft:
+			index = (index - 1 + len(images)) % len(images)
+		case ev.Ch == 'a':
+			autoAdvance = !autoAdvance
+		case ev.Ch == 'd':
+			staged := a.config.SoftDeleteDays > 0
+			if a.renderer.ConfirmPrompt("Move " + filepath.Base(path) + " to " + strings.ToLower(binName(staged)) + "?") {
+				remove := a.fileOpsManager.MoveToTrash
+				if staged {
+					remove = a.fileOpsManager.MoveToStaging
+				}
+				if err := remove([]string{path}); err != nil {
+					a.renderer.ShowError(err.Error())
+					continue
+				}
+				images = append(images[:index], images[index+1:]...)
+			}
+		case ev.Ch == 'm':
+			folder := a.renderer.SimplePrompt("Move to folder: ", a.navigator)
+			if folder == "" {
+				continue
+			}
+			destDir := filesystem.ExpandPath(folder, currentDir)
+			if err := os.MkdirAll(destDir, 0755); err != nil {
+				a.renderer.ShowError(err.Error())
+				continue
+			}
+			if err := a.fileOpsManager.MoveTo([]string{path}, destDir); err != nil {
+				a.renderer.ShowError(err.Error())
+				continue
+			}
+			images = append(images[:index], images[index+1:]...)
+		}
+		
+		if index >= len(images) {
+			index = 0
+		}
+	}
+}
+
 // handleConfigMenu shows and handles the configuration menu
 func (a *App) handleConfigMenu() {
 	for {
@@ -824,6 +3693,48 @@ func (a *App) handleConfigMenu() {
 		if strings.HasPrefix(choice, "Toggle Icon Style") {
 			choice = "Toggle Icon Style"
 		}
+		if strings.HasPrefix(choice, "Glyph Mode") {
+			choice = "Glyph Mode"
+		}
+		if strings.HasPrefix(choice, "Filter Case") {
+			choice = "Filter Case"
+		}
+		if strings.HasPrefix(choice, "Keep Selection Across Folders") {
+			choice = "Keep Selection Across Folders"
+		}
+		if strings.HasPrefix(choice, "Truncate Names") {
+			choice = "Truncate Names"
+		}
+		if strings.HasPrefix(choice, "Scroll Margin") {
+			choice = "Scroll Margin"
+		}
+		if strings.HasPrefix(choice, "Column Header") {
+			choice = "Column Header"
+		}
+		if strings.HasPrefix(choice, "File Sizes") {
+			choice = "File Sizes"
+		}
+		if strings.HasPrefix(choice, "Auto Refresh") {
+			choice = "Auto Refresh"
+		}
+		if strings.HasPrefix(choice, "Remember View Per Folder") {
+			choice = "Remember View Per Folder"
+		}
+		if strings.HasPrefix(choice, "Soft Delete") {
+			choice = "Soft Delete"
+		}
+		if strings.HasPrefix(choice, "Verify Copies") {
+			choice = "Verify Copies"
+		}
+		if strings.HasPrefix(choice, "Low Priority Jobs") {
+			choice = "Low Priority Jobs"
+		}
+		if strings.HasPrefix(choice, "Dry Run Commands") {
+			choice = "Dry Run Commands"
+		}
+		if strings.HasPrefix(choice, "Backups Before Overwrite") {
+			choice = "Backups Before Overwrite"
+		}
 		
 		switch choice {
 		case "Select Theme":
@@ -869,7 +3780,7 @@ func (a *App) handleConfigMenu() {
 			a.resumeProgressUpdates()
 			if editorCmd != "" {
 				a.config.EditorCmd = editorCmd
-				if err := config.SaveConfigFile(editorCmd, a.config.TerminalApp, &a.config.MouseEnabled, &a.config.UseAsciiIcons); err != nil {
+				if err := config.SaveConfigFile(a.config); err != nil {
 					a.renderer.ShowError("Failed to save editor: " + err.Error())
 				} else {
 					a.renderer.ShowMessage("Default editor updated!")
@@ -879,15 +3790,15 @@ func (a *App) handleConfigMenu() {
 			
 		case "Toggle Mouse Support":
 			a.config.MouseEnabled = !a.config.MouseEnabled
-			if err := config.SaveConfigFile(a.config.EditorCmd, a.config.TerminalApp, &a.config.MouseEnabled, &a.config.UseAsciiIcons); err != nil {
+			if err := config.SaveConfigFile(a.config); err != nil {
 				a.renderer.ShowError("Failed to save mouse setting: " + err.Error())
 			} else {
 				status := "disabled"
 				if a.config.MouseEnabled {
 					status = "enabled"
-					termbox.SetInputMode(termbox.InputEsc | termbox.InputMouse)
+					screen.SetInputMode(termbox.InputEsc | termbox.InputMouse)
 				} else {
-					termbox.SetInputMode(termbox.InputEsc)
+					screen.SetInputMode(termbox.InputEsc)
 				}
 				a.renderer.ShowMessage("Mouse support " + status + "!")
 			}
@@ -895,7 +3806,7 @@ func (a *App) handleConfigMenu() {
 			
 		case "Toggle Icon Style":
 			a.config.UseAsciiIcons = !a.config.UseAsciiIcons
-			if err := config.SaveConfigFile(a.config.EditorCmd, a.config.TerminalApp, &a.config.MouseEnabled, &a.config.UseAsciiIcons); err != nil {
+			if err := config.SaveConfigFile(a.config); err != nil {
 				a.renderer.ShowError("Failed to save icon setting: " + err.Error())
 			} else {
 				style := "ASCII"
@@ -906,6 +3817,175 @@ func (a *App) handleConfigMenu() {
 			}
 			a.renderer.DrawAndFlush(a.navigator, a.inPathEditMode, a.pathEditBuffer, a.showHelp)
 			
+		case "Glyph Mode":
+			switch a.config.GlyphMode {
+			case config.GlyphModeAuto:
+				a.config.GlyphMode = config.GlyphModeUnicode
+			case config.GlyphModeUnicode:
+				a.config.GlyphMode = config.GlyphModeASCII
+			default:
+				a.config.GlyphMode = config.GlyphModeAuto
+			}
+			a.applyGlyphMode()
+			a.renderer.DrawAndFlush(a.navigator, a.inPathEditMode, a.pathEditBuffer, a.showHelp)
+			
+		case "Filter Case":
+			// Cycle insensitive -> sensitive -> smart
+			mode := (a.navigator.GetCaseMode() + 1) % filesystem.CaseMode(len(filesystem.CaseModeNames))
+			a.config.FilterCase = filesystem.CaseModeNames[mode]
+			a.applyFilterCase()
+			if err := config.SaveConfigFile(a.config); err != nil {
+				a.renderer.ShowError("Failed to save filter case setting: " + err.Error())
+			}
+			a.renderer.DrawAndFlush(a.navigator, a.inPathEditMode, a.pathEditBuffer, a.showHelp)
+			
+		case "Keep Selection Across Folders":
+			a.config.KeepSelection = !a.config.KeepSelection
+			if err := config.SaveConfigFile(a.config); err != nil {
+				a.renderer.ShowError("Failed to save selection setting: " + err.Error())
+			}
+			a.renderer.DrawAndFlush(a.navigator, a.inPathEditMode, a.pathEditBuffer, a.showHelp)
+			
+		case "Truncate Names":
+			if a.config.NameTruncation == config.TruncateMiddle {
+				a.config.NameTruncation = config.TruncateEnd
+			} else {
+				a.config.NameTruncation = config.TruncateMiddle
+			}
+			if err := config.SaveConfigFile(a.config); err != nil {
+				a.renderer.ShowError("Failed to save truncation setting: " + err.Error())
+			}
+			a.renderer.DrawAndFlush(a.navigator, a.inPathEditMode, a.pathEditBuffer, a.showHelp)
+			
+		case "Scroll Margin":
+			// Cycle through common margins, like the filter case modes
+			next := scrollOffSteps[0]
+			for _, step := range scrollOffSteps {
+				if step > a.config.ScrollOff {
+					next = step
+					break
+				}
+			}
+			a.config.ScrollOff = next
+			a.applyScrollOff()
+			if err := config.SaveConfigFile(a.config); err != nil {
+				a.renderer.ShowError("Failed to save scroll margin setting: " + err.Error())
+			}
+			a.renderer.DrawAndFlush(a.navigator, a.inPathEditMode, a.pathEditBuffer, a.showHelp)
+			
+		case "Column Header":
+			a.config.ColumnHeader = !a.config.ColumnHeader
+			if err := config.SaveConfigFile(a.config); err != nil {
+				a.renderer.ShowError("Failed to save column header setting: " + err.Error())
+			}
+			a.renderer.DrawAndFlush(a.navigator, a.inPathEditMode, a.pathEditBuffer, a.showHelp)
+			
+		case "File Sizes":
+			next := sizeModes[0]
+			for i, mode := range sizeModes {
+				if mode == a.config.ShowSizes {
+					next = sizeModes[(i+1)%len(sizeModes)]
+				}
+			}
+			a.config.ShowSizes = next
+			if err := config.SaveConfigFile(a.config); err != nil {
+				a.renderer.ShowError("Failed to save file size setting: " + err.Error())
+			}
+			a.renderer.DrawAndFlush(a.navigator, a.inPathEditMode, a.pathEditBuffer, a.showHelp)
+			
+		case "Auto Refresh":
+			a.config.AutoRefresh = !a.config.AutoRefresh
+			a.applyAutoRefresh()
+			if err := config.SaveConfigFile(a.config); err != nil {
+				a.renderer.ShowError("Failed to save auto refresh setting: " + err.Error())
+			}
+			a.renderer.DrawAndFlush(a.navigator, a.inPathEditMode, a.pathEditBuffer, a.showHelp)
+			
+		case "Remember View Per Folder":
+			a.config.RememberView = !a.config.RememberView
+			a.applyRememberView()
+			if err := config.SaveConfigFile(a.config); err != nil {
+				a.renderer.ShowError("Failed to save view setting: " + err.Error())
+			}
+			a.renderer.DrawAndFlush(a.navigator, a.inPathEditMode, a.pathEditBuffer, a.showHelp)
+			
+		case "Soft Delete":
+			next := softDeleteSteps[0]
+			for _, step := range softDeleteSteps {
+				if step > a.config.SoftDeleteDays {
+					next = step
+					break
+				}
+			}
+			a.config.SoftDeleteDays = next
+			a.applySoftDelete()
+			if err := config.SaveConfigFile(a.config); err != nil {
+				a.renderer.ShowError("Failed to save soft delete setting: " + err.Error())
+			}
+			a.renderer.DrawAndFlush(a.navigator, a.inPathEditMode, a.pathEditBuffer, a.showHelp)
+			
+		case "Verify Copies":
+			a.config.VerifyCopies = !a.config.VerifyCopies
+			a.fileOpsManager.SetVerify(a.config.VerifyCopies)
+			if err := config.SaveConfigFile(a.config); err != nil {
+				a.renderer.ShowError("Failed to save verify setting: " + err.Error())
+			}
+			a.renderer.DrawAndFlush(a.navigator, a.inPathEditMode, a.pathEditBuffer, a.showHelp)
+			
+		case "Low Priority Jobs":
+			a.config.LowPriority = !a.config.LowPriority
+			a.applyLowPriority()
+			if err := config.SaveConfigFile(a.config); err != nil {
+				a.renderer.ShowError("Failed to save priority setting: " + err.Error())
+			}
+			a.renderer.DrawAndFlush(a.navigator, a.inPathEditMode, a.pathEditBuffer, a.showHelp)
+			
+		case "Dry Run Commands":
+			a.config.DryRunCommands = !a.config.DryRunCommands
+			if err := config.SaveConfigFile(a.config); err != nil {
+				a.renderer.ShowError("Failed to save dry run setting: " + err.Error())
+			}
+			a.renderer.DrawAndFlush(a.navigator, a.inPathEditMode, a.pathEditBuffer, a.showHelp)
+			
+		case "Backups Before Overwrite":
+			next := backupModes[0]
+			for i, mode := range backupModes {
+				if mode == a.config.BackupMode {
+					next = backupModes[(i+1)%len(backupModes)]
+				}
+			}
+			a.config.BackupMode = next
+			a.applyBackups()
+			if err := config.SaveConfigFile(a.config); err != nil {
+				a.renderer.ShowError("Failed to save backup setting: " + err.Error())
+			}
+			a.renderer.DrawAndFlush(a.navigator, a.inPathEditMode, a.pathEditBuffer, a.showHelp)
+			
+		case "Test File Associations":
+			a.testAssociations()
+			a.renderer.DrawAndFlush(a.navigator, a.inPathEditMode, a.pathEditBuffer, a.showHelp)
+			
+		case "Check for Updates":
+			a.checkForUpdates()
+			a.renderer.DrawAndFlush(a.navigator, a.inPathEditMode, a.pathEditBuffer, a.showHelp)
+			
+		case "Edit Config File":
+			a.editConfigFile()
+			a.renderer.DrawAndFlush(a.navigator, a.inPathEditMode, a.pathEditBuffer, a.showHelp)
+			return
+			
+		case "Calibrate Glyphs":
+			supported, answered := a.renderer.ShowGlyphCalibration()
+			if answered {
+				if supported {
+					a.config.GlyphMode = config.GlyphModeUnicode
+				} else {
+					a.config.GlyphMode = config.GlyphModeASCII
+				}
+				a.applyGlyphMode()
+			}
+			a.renderer.DrawAndFlush(a.navigator, a.inPathEditMode, a.pathEditBuffer, a.showHelp)
+			
 		case "Restore to Default":
 			if a.renderer.ConfirmPrompt("Restore default theme?") {
 				a.themeManager.RestoreDefaultTheme()
@@ -919,6 +3999,222 @@ func (a *App) handleConfigMenu() {
 	}
 }
 
+// editConfigFile opens the config file in the editor, then validates and
+// applies it, reopening the editor while the user wants to fix problems
+func (a *App) editConfigFile() {
+	path := config.GetConfigFilePath()
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		// Start from the current settings rather than an empty file
+		if err := config.SaveConfigFile(a.config); err != nil {
+			a.renderer.ShowError("Failed to create config file: " + err.Error())
+			return
+		}
+	}
+	
+	for {
+		a.openEditor(path)
+		if !isTerminalEditor(a.config.EditorCmd) {
+			// GUI editors return immediately, so wait for the user
+			a.renderer.ShowMessage("Editing " + filepath.Base(path) + " - press any key when saved")
+		}
+		
+		problems := a.config.Reload()
+		if len(problems) == 0 {
+			a.applyConfig()
+			a.renderer.ShowMessage("Configuration reloaded")
+			return
+		}
+		if !a.renderer.ShowProblems(problems) {
+			return // Keep the current settings
+		}
+	}
+}
+
+// checkForUpdates asks GitHub for the latest release, only after the user agrees
+func (a *App) checkForUpdates() {
+	if !a.renderer.ConfirmPrompt("Contact GitHub to check for a newer release?") {
+		return
+	}
+	release, err := version.CheckLatest(version.ReleasesURL)
+	if err != nil {
+		a.renderer.ShowError("Update check failed: " + err.Error())
+		return
+	}
+	if !version.IsNewer(release.TagName, version.Current()) {
+		a.renderer.ShowMessage("xp " + version.Current() + " is up to date")
+		return
+	}
+	lines := []string{
+		fmt.Sprintf("%s is available (you have %s)", release.TagName, version.Current()),
+		release.URL,
+		"",
+	}
+	lines = append(lines, strings.Split(strings.ReplaceAll(release.Body, "\r\n", "\n"), "\n")...)
+	a.renderer.ShowTextPopup("Update Available", lines)
+}
+
+// applyConfig pushes reloaded settings to the components that cache them
+func (a *App) applyConfig() {
+	ui.SetSafeGlyphs(a.config.SafeGlyphs)
+	a.applyFilterCase()
+	a.applyScrollOff()
+	for _, nav := range a.panes {
+		if nav != nil {
+			nav.SetSecondarySort(filesystem.ParseSortKey(a.config.SecondarySort))
+		}
+	}
+	a.applyPairing()
+	a.applyAutoRefresh()
+	a.applyRememberView()
+	a.applySoftDelete()
+	a.fileOpsManager.SetVerify(a.config.VerifyCopies)
+	a.applyLowPriority()
+	a.applyBackups()
+	a.applyCreateModes()
+	a.openWithManager.SetDefaults(a.config.OpenWith)
+	a.previewManager.SetLimits(a.config.PreviewSkipExtensions, int64(a.config.PreviewMaxSizeMB)<<20)
+	if a.config.MouseEnabled {
+		screen.SetInputMode(termbox.InputEsc | termbox.InputMouse)
+	} else {
+		screen.SetInputMode(termbox.InputEsc)
+	}
+}
+
+// sizeModes are the size column settings offered by the configuration
+// menu, in the order it cycles through them
+var sizeModes = []string{config.SizesAlways, config.SizesCursor, config.SizesHidden}
+
+// scrollOffSteps are the margins offered by the configuration menu
+var scrollOffSteps = []int{0, 2, 4, 8}
+
+// applyScrollOff gives every pane the configured scroll margin
+func (a *App) applyScrollOff() {
+	for _, nav := range a.panes {
+		if nav != nil {
+			nav.SetScrollOff(a.config.ScrollOff)
+		}
+	}
+}
+
+// applyFilterCase gives every pane the configured filter case mode; the
+// filters themselves stay per pane
+func (a *App) applyFilterCase() {
+	for _, nav := range a.panes {
+		if nav != nil {
+			nav.SetCaseMode(filesystem.ParseCaseMode(a.config.FilterCase))
+		}
+	}
+}
+
+// applyRememberView gives every pane the view memory, or takes it away
+func (a *App) applyRememberView() {
+	for _, nav := range a.panes {
+		if nav == nil {
+			continue
+		}
+		if a.config.RememberView && a.remote(nav) == nil {
+			nav.SetViewMemory(a.viewManager)
+		} else {
+			nav.SetViewMemory(nil)
+		}
+	}
+}
+
+// watchInterval is how often the watcher checks the panes' directories
+const watchInterval = 2 * time.Second
+
+// applyAutoRefresh starts or stops watching the panes' directories
+func (a *App) applyAutoRefresh() {
+	if a.config.AutoRefresh {
+		a.watchPanes()
+		a.watcher.Start()
+	} else {
+		a.watcher.Stop()
+	}
+}
+
+// watchPanes points the watcher at the directories on screen, as listed
+func (a *App) watchPanes() {
+	dirs := make(map[string]uint64)
+	for _, nav := range a.panes {
+		if nav != nil && nav.GetReadError() == nil && a.remote(nav) == nil {
+			dirs[nav.GetCurrentDir()] = nav.Signature()
+		}
+	}
+	a.watcher.Watch(dirs)
+}
+
+// refreshChanged re-reads the panes whose directory changed on disk or
+// was changed by a file operation, keeping each cursor on the same entry
+func (a *App) refreshChanged() {
+	changed := a.watcher.Changed()
+	for _, e := range a.takeEvents() {
+		a.fileOpsManager.TrackEvent(e)
+		a.trackHistory(e)
+		changed = append(changed, e.Dirs()...)
+	}
+	if len(changed) == 0 {
+		return
+	}
+	reloaded := make(map[string]bool)
+	for _, dir := range changed {
+		if reloaded[dir] {
+			continue
+		}
+		reloaded[dir] = true
+		for _, nav := range a.panes {
+			if nav != nil && nav.GetCurrentDir() == dir {
+				nav.Reload(a.visibleLines())
+			}
+		}
+	}
+	a.fileOpsManager.PruneSelection()
+	a.fileOpsManager.PruneBasket()
+	a.reloadPreview()
+}
+
+// trackHistory keeps the visited directories used for jumps and path
+// completion pointing at moved directories and drops deleted ones
+func (a *App) trackHistory(e fileops.Event) {
+	switch e.Kind {
+	case fileops.EventMoved:
+		a.historyManager.Move(e.Path, e.Dest)
+	case fileops.EventDeleted:
+		a.historyManager.Forget(e.Path)
+	}
+}
+
+// queueEvent keeps a file operation event for refreshChanged and wakes the
+// event loop for the first event of a batch. Operations done on the UI
+// goroutine emit too, so the wake-up must not wait for the loop.
+func (a *App) queueEvent(e fileops.Event) {
+	a.eventsMu.Lock()
+	wake := len(a.events) == 0
+	a.events = append(a.events, e)
+	a.eventsMu.Unlock()
+	if wake {
+		go a.interrupt()
+	}
+}
+
+// takeEvents returns and clears the queued file operation events
+func (a *App) takeEvents() []fileops.Event {
+	a.eventsMu.Lock()
+	defer a.eventsMu.Unlock()
+	events := a.events
+	a.events = nil
+	return events
+}
+
+// applyGlyphMode resolves and saves the glyph mode and updates the renderer
+func (a *App) applyGlyphMode() {
+	a.config.ResolveGlyphMode()
+	ui.SetSafeGlyphs(a.config.SafeGlyphs)
+	if err := config.SaveConfigFile(a.config); err != nil {
+		a.renderer.ShowError("Failed to save glyph setting: " + err.Error())
+	}
+}
+
 // handleSortingPopup shows and handles the sorting selection popup
 func (a *App) handleSortingPopup() {
 	a.pauseProgressUpdates()
@@ -959,7 +4255,7 @@ func (a *App) handleSortingPopup() {
 
 // handleMouseEvent handles mouse input events
 func (a *App) handleMouseEvent(ev termbox.Event) bool {
-	w, h := termbox.Size()
+	w, h := screen.Size()
 	
 	// Calculate panel boundaries (same as in ui.Draw)
 	parentPanelWidth := w / 5
@@ -968,8 +4264,47 @@ func (a *App) handleMouseEvent(ev termbox.Event) bool {
 	middlePanelStart := separator1Pos + 1
 	separator2Pos := middlePanelStart + middlePanelWidth
 	
+	// Motion with the button down drags, the release drops
+	if ev.Key == termbox.MouseRelease || (ev.Key == termbox.MouseLeft && ev.Mod&termbox.ModMotion != 0) {
+		a.handleDrag(ev)
+		return false
+	}
+	
+	// In dual-pane mode clicks focus the pane under the mouse, whose list
+	// then takes the place of the middle panel
+	if a.dualPane && !a.renderer.IsMinimal() && !a.renderer.IsQuickLook() && ev.Key == termbox.MouseLeft {
+		a.focusPane(a.renderer.PaneAt(ev.MouseX))
+		parentPanelWidth = 0
+		separator1Pos = -1
+		middlePanelStart = 0
+		separator2Pos = w
+	}
+	
+	// The minimal UI shows only the file list across the full width
+	if a.renderer.IsMinimal() {
+		parentPanelWidth = 0
+		separator1Pos = -1
+		middlePanelStart = 0
+		separator2Pos = w
+	}
+	
 	// Handle mouse button events
 	if ev.Key == termbox.MouseLeft {
+		// A new press starts over; a drag needs it on a listed item
+		a.endDrag()
+		
+		// Panels are hidden behind the full-screen preview
+		if a.renderer.IsQuickLook() {
+			return false
+		}
+		
+		// A header label sorts by its column, or reverses the active one
+		if mode, ok := a.renderer.HeaderSortAt(a.navigator, ev.MouseX, ev.MouseY); ok {
+			a.navigator.SetSortMode(mode)
+			a.reloadPreview()
+			return false
+		}
+		
 		// Check if Ctrl is held (for context menu)
 		if a.ctrlPressed {
 			// Ctrl+Click - show context menu
@@ -979,8 +4314,10 @@ func (a *App) handleMouseEvent(ev termbox.Event) bool {
 					// Move cursor to clicked item first
 					a.navigator.SetCursor(fileIndex)
 					a.reloadPreview()
+					a.startDrag(true)
 				}
-				a.handleContextMenu()
+				// Shown on release, unless the press turns into a drag
+				a.ctrlClickMenu = true
 			}
 			a.ctrlPressed = false // Reset after use
 			return false
@@ -1004,32 +4341,112 @@ func (a *App) handleMouseEvent(ev termbox.Event) bool {
 		// Determine which panel was clicked
 		if ev.MouseX >= middlePanelStart && ev.MouseX < separator2Pos {
 			// Middle panel (current directory) clicked
-			return a.handleMiddlePanelClick(ev.MouseY, h, isDoubleClick)
+			quit := a.handleMiddlePanelClick(ev.MouseY, h, isDoubleClick)
+			if !isDoubleClick && a.getFileIndexAtY(ev.MouseY, h) >= 0 {
+				a.startDrag(false)
+			}
+			return quit
 		} else if ev.MouseX < separator1Pos {
 			// Parent panel clicked
 			return a.handleParentPanelClick(ev.MouseY, h, isDoubleClick)
 		}
 		
 	} else if ev.Key == termbox.MouseWheelUp {
-		// Scroll up
-		_, h := termbox.Size()
-		visibleLines := h - 4
-		a.navigator.MoveUp(visibleLines)
-		a.previewManager.ResetScroll()
-		a.reloadPreview()
+		a.scrollWheel(ev.MouseX, -wheelLines)
 		
 	} else if ev.Key == termbox.MouseWheelDown {
-		// Scroll down
-		_, h := termbox.Size()
-		visibleLines := h - 4
-		a.navigator.MoveDown(visibleLines)
-		a.previewManager.ResetScroll()
-		a.reloadPreview()
+		a.scrollWheel(ev.MouseX, wheelLines)
 	}
 	
 	return false
 }
 
+// startDrag takes the files a drag from the cursor carries: the selection
+// when the cursor is on a selected item, otherwise that item
+func (a *App) startDrag(copyFiles bool) {
+	path := a.navigator.GetSelectedPath()
+	if path == "" || a.remote(a.navigator) != nil {
+		return
+	}
+	a.dragFiles = []string{path}
+	if a.fileOpsManager.IsSelected(path) {
+		a.dragFiles = a.fileOpsManager.GetSelectedFiles()
+	}
+	a.dragCopy = copyFiles
+}
+
+// endDrag forgets the drag of the last press
+func (a *App) endDrag() {
+	a.dragFiles = nil
+	a.dragging = false
+	a.dragCopy = false
+	a.ctrlClickMenu = false
+	a.renderer.SetDrag("", 0, 0)
+}
+
+// handleDrag follows the mouse while files are dragged and drops them
+// on the folder under it when the button is released. A Ctrl+click that
+// didn't move opens the context menu instead.
+func (a *App) handleDrag(ev termbox.Event) {
+	target := ""
+	if len(a.dragFiles) > 0 {
+		target = a.renderer.DropTarget(a.navigator, ev.MouseX, ev.MouseY)
+	}
+	if ev.Key == termbox.MouseRelease {
+		files, dragging, copyFiles, menu := a.dragFiles, a.dragging, a.dragCopy, a.ctrlClickMenu
+		a.endDrag()
+		if dragging {
+			a.drop(files, target, copyFiles)
+		} else if menu {
+			a.handleContextMenu()
+		}
+		return
+	}
+	if len(a.dragFiles) == 0 {
+		return
+	}
+	a.dragging = true
+	
+	verb := "Move"
+	if a.dragCopy {
+		verb = "Copy"
+	}
+	label := fmt.Sprintf("%s %d items", verb, len(a.dragFiles))
+	if len(a.dragFiles) == 1 {
+		label = verb + " " + filepath.Base(a.dragFiles[0])
+	}
+	if target != "" && target != a.navigator.GetCurrentDir() {
+		label += " to " + filepath.Base(target)
+	}
+	a.renderer.SetDrag(label, ev.MouseX, ev.MouseY)
+}
+
+// drop moves or copies dragged files into target, asking about conflicts
+// like a paste
+func (a *App) drop(files []string, target string, copyFiles bool) {
+	if target == "" {
+		a.renderer.ShowMessage("Drop files on a folder in the parent or preview panel (or the other pane)")
+		return
+	}
+	if target == a.navigator.GetCurrentDir() {
+		return // Back where they came from
+	}
+	if a.dualPane && a.remote(a.panes[1-a.activePane]) != nil {
+		a.renderer.ShowMessage("Files can't be dropped on a remote pane")
+		return
+	}
+	op := fileops.OpCut
+	if copyFiles {
+		op = fileops.OpCopy
+	}
+	plan := a.preflight(func() (*fileops.Plan, error) {
+		return a.fileOpsManager.PlanTransfer(files, op, target)
+	})
+	if plan != nil {
+		a.runPlan(plan, nil)
+	}
+}
+
 // handleMiddlePanelClick handles clicks in the middle panel (current directory)
 func (a *App) handleMiddlePanelClick(mouseY, height int, isDoubleClick bool) bool {
 	fileIndex := a.getFileIndexAtY(mouseY, height)
@@ -1044,17 +4461,17 @@ func (a *App) handleMiddlePanelClick(mouseY, height int, isDoubleClick bool) boo
 	if isDoubleClick {
 		// Double-click: open file or enter directory
 		if selectedPath := a.navigator.GetSelectedPath(); selectedPath != "" {
-			info, err := os.Stat(selectedPath)
+			info, err := a.navigator.Filesystem().Stat(selectedPath)
 			if err == nil {
 				if info.IsDir() {
 					// Enter directory
 					if a.navigator.EnterDirectory() {
-						a.fileOpsManager.ClearSelection()
+						a.leftDirectory()
 						a.reloadPreview()
 					}
 				} else {
-					// Open file with default editor
-					a.openWithEditorSelection(selectedPath)
+					// Open file with its handler or ask
+					a.openFile(selectedPath)
 				}
 			}
 		}
@@ -1067,28 +4484,40 @@ func (a *App) handleMiddlePanelClick(mouseY, height int, isDoubleClick bool) boo
 func (a *App) handleParentPanelClick(mouseY, height int, isDoubleClick bool) bool {
 	if isDoubleClick {
 		// Double-click in parent panel: go to parent directory
-		if a.navigator.GoToParent() {
-			a.fileOpsManager.ClearSelection()
-			a.reloadPreview()
-		}
+		a.goToParent()
 	}
 	return false
 }
 
+// wheelLines is the number of lines scrolled per mouse wheel step
+const wheelLines = 3
+
+// scrollWheel scrolls the file list under the mouse (the pane under it in
+// dual-pane mode) without moving the cursor unless it leaves the view. If
+// the cursor moves, the preview catches up once the wheel has settled.
+func (a *App) scrollWheel(mouseX, delta int) {
+	nav := a.navigator
+	if a.dualPane && !a.renderer.IsMinimal() && !a.renderer.IsQuickLook() {
+		nav = a.panes[a.renderer.PaneAt(mouseX)]
+	}
+	if nav.ScrollView(delta, a.visibleLines()) && nav == a.navigator {
+		a.deferPreview()
+	}
+}
+
 // getFileIndexAtY calculates which file index corresponds to a Y coordinate
 func (a *App) getFileIndexAtY(mouseY, height int) int {
-	// Address bar is at y=0, files start at y=2
-	if mouseY < 2 {
+	// Files start below the address bar unless the minimal UI hides it
+	listTop, visibleHeight := a.renderer.ListArea()
+	if mouseY < listTop {
 		return -1
 	}
 	
-	// Calculate visible area
-	visibleHeight := height - 4
 	scrollOffset := a.navigator.GetScrollOffset()
 	fileList := a.navigator.GetFileList()
 	
 	// Calculate file index
-	relativeY := mouseY - 2
+	relativeY := mouseY - listTop
 	if relativeY >= visibleHeight {
 		return -1
 	}
@@ -0,0 +1,137 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/alexcostache/Xplorer/internal/screen"
+	"github.com/alexcostache/Xplorer/pkg/filesystem"
+)
+
+// Benchmark settings: every step runs benchRuns times on a headless screen
+// of benchWidth x benchHeight cells
+const (
+	benchRuns   = 5
+	benchWidth  = 160
+	benchHeight = 48
+)
+
+// Benchmark times listing, sorting, rendering a frame and previewing the
+// largest file in dir, and prints the results to w. The first run is shown
+// apart from the median of all runs, since the first one often pays for
+// cold caches (most visibly on network mounts).
+func (a *App) Benchmark(dir string, w io.Writer) error {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+
+	// Leave the remembered views alone and draw off screen, so neither the
+	// state file nor the terminal is touched
+	a.navigator.SetViewMemory(nil)
+	previous := screen.Use(screen.NewHeadless(benchWidth, benchHeight))
+	defer screen.Use(previous)
+
+	var entries []os.FileInfo
+	listing := measure(func() {
+		entries, err = ioutil.ReadDir(dir)
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Benchmark of %s: %d entries, %d runs each\n\n", dir, len(entries), benchRuns)
+	fmt.Fprintf(w, "  %-22s %10s %10s\n", "", "first", "median")
+	printTimes(w, "List directory", listing, "")
+
+	// Sort copies of the listing, which comes in name order like in the app
+	for _, mode := range []filesystem.SortMode{filesystem.SortByName, filesystem.SortBySize, filesystem.SortByModTime, filesystem.SortByExtension} {
+		times := measure(func() {
+			files := append([]os.FileInfo(nil), entries...)
+			filesystem.SortFiles(files, mode, false)
+		})
+		printTimes(w, "Sort: "+filesystem.SortModeNames[mode], times, "")
+	}
+
+	a.navigator.SetCurrentDir(dir)
+	a.reloadPreview()
+	printTimes(w, "Render frame", measure(func() {
+		a.renderer.DrawAndFlush(a.navigator, false, "", false)
+	}), fmt.Sprintf("%dx%d", benchWidth, benchHeight))
+
+	var largest os.FileInfo
+	for _, entry := range entries {
+		if entry.Mode().IsRegular() && (largest == nil || entry.Size() > largest.Size()) {
+			largest = entry
+		}
+	}
+	if largest == nil {
+		fmt.Fprintf(w, "  %-22s %10s %10s  no files\n", "Preview largest file", "-", "-")
+		return nil
+	}
+	path := filepath.Join(dir, largest.Name())
+	times := measure(func() {
+		a.previewManager.LoadPreview(path, a.navigator.GetShowHidden(), benchHeight*10)
+	})
+	printTimes(w, "Preview largest file", times, fmt.Sprintf("%s (%s): %s", largest.Name(), formatSize(largest.Size()), a.previewManager.Handler(path, largest)))
+	return nil
+}
+
+// measure runs step benchRuns times and returns how long each run took
+func measure(step func()) []time.Duration {
+	times := make([]time.Duration, benchRuns)
+	for i := range times {
+		start := time.Now()
+		step()
+		times[i] = time.Since(start)
+	}
+	return times
+}
+
+// printTimes prints one result line: the first run, the median and a note
+func printTimes(w io.Writer, label string, times []time.Duration, note string) {
+	sorted := append([]time.Duration(nil), times...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	line := fmt.Sprintf("  %-22s %10s %10s", label, formatDuration(times[0]), formatDuration(sorted[len(sorted)/2]))
+	if note != "" {
+		line += "  " + note
+	}
+	fmt.Fprintln(w, line)
+}
+
+// formatDuration rounds d to a readable precision
+func formatDuration(d time.Duration) string {
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond).String()
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond).String()
+	default:
+		return d.Round(time.Microsecond / 10).String()
+	}
+}
+
+// formatSize formats a file size
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTPE"[exp])
+}
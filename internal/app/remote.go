@@ -0,0 +1,324 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/alexcostache/Xplorer/internal/config"
+	"github.com/alexcostache/Xplorer/internal/keyring"
+	"github.com/alexcostache/Xplorer/pkg/fileops"
+	"github.com/alexcostache/Xplorer/pkg/filesystem"
+)
+
+// remoteSession is a pane browsing an FTP or WebDAV server
+type remoteSession struct {
+	name     string // Of the saved connection
+	endpoint string
+	password string // Kept for the connections downloads open
+	fsys     filesystem.Remote
+	localDir string // Where the pane was before connecting, and returns to
+}
+
+// remote returns the session of the server nav browses, or nil while it
+// shows the local disk
+func (a *App) remote(nav *filesystem.Navigator) *remoteSession {
+	return a.remotes[nav]
+}
+
+// remoteKeys reports whether a key's action works on a remote pane. Other
+// actions open or change files on the local disk, so they are refused
+// there rather than run on paths that only exist on the server.
+func (a *App) remoteKeys(key config.Key) bool {
+	keys := a.config.Keys
+	switch key {
+	case keys.MoveUp, keys.MoveDown, keys.PageUp, keys.PageDown, keys.ParentDir, keys.EnterDir,
+		keys.Refresh, keys.Filter, keys.ToggleHidden, keys.SortMenu, keys.ContextMenu,
+		keys.SwitchPane, keys.SwapPanes, keys.DualPane, keys.Connections, keys.Help, keys.Quit,
+		keys.ScrollDown, keys.ScrollUp, keys.ScrollDownFast, keys.ScrollUpFast,
+		keys.OpenThemePopup, keys.MinimalMode:
+		return true
+	}
+	return false
+}
+
+// credentialAccount names the keyring entry of an endpoint's password:
+// its scheme, user and host, so all folders on a server share it
+func credentialAccount(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return endpoint
+	}
+	return u.Scheme + "://" + u.User.Username() + "@" + u.Host
+}
+
+// endpointUser returns the user name of an endpoint URL
+func endpointUser(endpoint string) string {
+	if u, err := url.Parse(endpoint); err == nil {
+		return u.User.Username()
+	}
+	return ""
+}
+
+// showConnections is the connection manager: it connects the current pane
+// to a saved FTP or WebDAV endpoint, disconnects it, adds endpoints and
+// manages their saved passwords
+func (a *App) showConnections() {
+	session := a.remote(a.navigator)
+	var options []string
+	if session != nil {
+		options = append(options, "Disconnect from "+session.name)
+	}
+	options = append(options, "Add Connection...")
+	first := len(options)
+	for _, connection := range a.config.Connections {
+		options = append(options, fmt.Sprintf("  %-20s %s", connection.Name, connection.URL))
+	}
+
+	a.pauseProgressUpdates()
+	defer a.resumeProgressUpdates()
+	choice := a.renderer.ShowChoicePopup("Connections", 80, options, a.navigator, a.inPathEditMode, a.pathEditBuffer, a.showHelp)
+	switch {
+	case choice < 0:
+		return
+	case session != nil && choice == 0:
+		a.disconnect(a.navigator)
+		return
+	case choice == first-1:
+		a.addConnection()
+		return
+	}
+
+	index := choice - first
+	connection := a.config.Connections[index]
+	actions := []string{"Connect", "Forget Password", "Remove"}
+	action := a.renderer.ShowChoicePopup(connection.Name, 40, actions, a.navigator, a.inPathEditMode, a.pathEditBuffer, a.showHelp)
+	switch action {
+	case 0:
+		a.connect(connection)
+	case 1:
+		if err := keyring.Delete(credentialAccount(connection.URL)); err != nil {
+			a.renderer.ShowError(err.Error())
+			return
+		}
+		a.renderer.ShowMessage("Forgot the password of " + connection.Name)
+	case 2:
+		if !a.renderer.ConfirmPrompt("Remove connection " + connection.Name + "?") {
+			return
+		}
+		a.config.Connections = append(a.config.Connections[:index:index], a.config.Connections[index+1:]...)
+		if err := config.SaveConfigFile(a.config); err != nil {
+			a.renderer.ShowError("Failed to save connections: " + err.Error())
+		}
+	}
+}
+
+// addConnection asks for the name and URL of a new endpoint and saves it
+func (a *App) addConnection() {
+	name := strings.TrimSpace(a.renderer.SimplePrompt("Connection name: ", a.navigator))
+	if name == "" {
+		return
+	}
+	endpoint := strings.TrimSpace(a.renderer.SimplePrompt("URL (ftp://user@host/dir or https://user@host/dav/): ", a.navigator))
+	if endpoint == "" {
+		return
+	}
+	if err := filesystem.CheckEndpoint(endpoint); err != nil {
+		a.renderer.ShowError(err.Error())
+		return
+	}
+	connection := config.Connection{Name: name, URL: endpoint}
+	a.config.Connections = append(a.config.Connections, connection)
+	if err := config.SaveConfigFile(a.config); err != nil {
+		a.renderer.ShowError("Failed to save connections: " + err.Error())
+	}
+	if a.renderer.ConfirmPrompt("Connect to " + name + " now?") {
+		a.connect(connection)
+	}
+}
+
+// connect makes the current pane browse a saved endpoint. The password
+// comes from the keyring, or else is asked for and offered to the keyring
+// once the login worked.
+func (a *App) connect(connection config.Connection) {
+	account := credentialAccount(connection.URL)
+	user := endpointUser(connection.URL)
+	password, err := keyring.Get(account)
+	saved := err == nil
+	canSave := !errors.Is(err, keyring.ErrUnsupported)
+	if !saved && user != "" {
+		password = a.renderer.PasswordPrompt("Password for "+connection.Name+": ", a.navigator)
+		if password == "" {
+			return
+		}
+	}
+
+	a.renderer.ShowStatus("Connecting to " + connection.Name + "...")
+	fsys, err := filesystem.Dial(connection.URL, password)
+	if err != nil {
+		if saved {
+			err = fmt.Errorf("%v (Forget Password to enter it again)", err)
+		}
+		a.renderer.ShowError(err.Error())
+		return
+	}
+	if !saved && canSave && password != "" && a.renderer.ConfirmPrompt("Save the password in the keyring?") {
+		if err := keyring.Set(account, password); err != nil {
+			a.renderer.ShowError(err.Error())
+		}
+	}
+
+	localDir := a.navigator.GetCurrentDir()
+	if session := a.remote(a.navigator); session != nil {
+		localDir = session.localDir
+		session.fsys.Close()
+	}
+	if a.remotes == nil {
+		a.remotes = make(map[*filesystem.Navigator]*remoteSession)
+	}
+	a.remotes[a.navigator] = &remoteSession{
+		name:     connection.Name,
+		endpoint: connection.URL,
+		password: password,
+		fsys:     fsys,
+		localDir: localDir,
+	}
+
+	// Views remembered for local folders must not be taken for remote ones
+	a.navigator.RememberView()
+	a.navigator.SetViewMemory(nil)
+	a.leftDirectory()
+	a.navigator.ClearFilter()
+	a.navigator.Mount(fsys, filesystem.RemoteDir(connection.URL))
+	a.previewManager.ResetScroll()
+	a.reloadPreview()
+}
+
+// disconnect closes the connection of a remote pane and takes it back to
+// the local folder it showed before
+func (a *App) disconnect(nav *filesystem.Navigator) {
+	session := a.remote(nav)
+	if session == nil {
+		return
+	}
+	delete(a.remotes, nav)
+	session.fsys.Close()
+	nav.ClearFilter()
+	nav.Mount(nil, session.localDir)
+	a.applyRememberView()
+	nav.RestoreView()
+	if nav == a.navigator {
+		a.leftDirectory()
+		a.previewManager.ResetScroll()
+		a.reloadPreview()
+	}
+}
+
+// disconnectAll closes every remote pane's connection
+func (a *App) disconnectAll() {
+	for _, nav := range a.panes {
+		if nav != nil {
+			a.disconnect(nav)
+		}
+	}
+}
+
+// handleRemoteMenu is the context menu of a remote pane: its entries work
+// through the server's filesystem instead of the local disk
+func (a *App) handleRemoteMenu() {
+	session := a.remote(a.navigator)
+	selected := a.navigator.GetSelectedFile()
+	var options []string
+	if selected != nil {
+		options = append(options, "Download to "+a.downloadDir(), "Rename", "Delete")
+	}
+	options = append(options, "New Folder", "Disconnect", "Cancel")
+
+	a.pauseProgressUpdates()
+	choice := a.renderer.ShowChoicePopup(session.name, 60, options, a.navigator, a.inPathEditMode, a.pathEditBuffer, a.showHelp)
+	a.resumeProgressUpdates()
+	if choice < 0 {
+		return
+	}
+
+	fsys := session.fsys
+	currentDir := a.navigator.GetCurrentDir()
+	var err error
+	switch options[choice] {
+	case "Rename":
+		name := a.renderer.RenamePrompt("Rename to: ", selected.Name(), a.navigator)
+		if name == "" || name == selected.Name() {
+			return
+		}
+		err = fsys.Rename(filepath.Join(currentDir, selected.Name()), filepath.Join(currentDir, name))
+		if err == nil {
+			a.navigator.Refresh()
+			a.navigator.SelectByName(name, a.visibleLines())
+		}
+
+	case "Delete":
+		if !a.renderer.ConfirmPrompt("Permanently delete " + selected.Name() + " from " + session.name + "?") {
+			return
+		}
+		err = filesystem.RemoveAll(fsys, filepath.Join(currentDir, selected.Name()))
+		a.navigator.Refresh()
+
+	case "New Folder":
+		name := a.renderer.SimplePrompt("New folder name: ", a.navigator)
+		if name == "" {
+			return
+		}
+		err = fsys.Mkdir(filepath.Join(currentDir, name), 0755)
+		if err == nil {
+			a.navigator.Refresh()
+			a.navigator.SelectByName(name, a.visibleLines())
+		}
+
+	case "Disconnect":
+		a.disconnect(a.navigator)
+		return
+
+	case "Cancel":
+		return
+
+	default:
+		a.download(session, filepath.Join(currentDir, selected.Name()))
+		return
+	}
+	if err != nil {
+		a.renderer.ShowError(err.Error())
+	}
+	a.reloadPreview()
+}
+
+// downloadDir is where downloads from a remote pane go: the other pane's
+// folder when it shows the local disk, else the folder the pane left to
+// connect
+func (a *App) downloadDir() string {
+	if other := a.panes[1-a.activePane]; a.dualPane && other != nil && a.remote(other) == nil {
+		return other.GetCurrentDir()
+	}
+	return a.remote(a.navigator).localDir
+}
+
+// download copies a file or folder from a remote pane into downloadDir in
+// the background, over a connection of its own so the pane stays usable
+func (a *App) download(session *remoteSession, src string) {
+	destDir := a.downloadDir()
+	a.renderer.ShowStatus("Downloading " + filepath.Base(src) + "...")
+	a.runOperation(func() error {
+		fsys, err := filesystem.Dial(session.endpoint, session.password)
+		if err != nil {
+			return err
+		}
+		defer fsys.Close()
+		dest, err := filesystem.Download(context.Background(), fsys, src, destDir)
+		if err == nil {
+			a.queueEvent(fileops.Event{Kind: fileops.EventCreated, Path: dest})
+		}
+		return err
+	})
+}
@@ -3,8 +3,10 @@ package bookmark
 import (
 	"encoding/json"
 	"os"
-	"os/user"
 	"path/filepath"
+	"strings"
+
+	"github.com/alexcostache/Xplorer/internal/paths"
 )
 
 // Bookmark represents a saved directory location
@@ -96,6 +98,18 @@ func (m *Manager) RemoveByPath(path string) bool {
 	return false
 }
 
+// Match returns the paths of bookmarks whose name contains the query (case-insensitive)
+func (m *Manager) Match(query string) []string {
+	lowerQuery := strings.ToLower(query)
+	var matches []string
+	for _, b := range m.bookmarks {
+		if strings.Contains(strings.ToLower(b.Name), lowerQuery) {
+			matches = append(matches, b.Path)
+		}
+	}
+	return matches
+}
+
 // Count returns the number of bookmarks
 func (m *Manager) Count() int {
 	return len(m.bookmarks)
@@ -103,8 +117,7 @@ func (m *Manager) Count() int {
 
 // getBookmarkFile returns the path to the bookmark file
 func (m *Manager) getBookmarkFile() string {
-	usr, _ := user.Current()
-	return filepath.Join(usr.HomeDir, ".xp_bookmarks.json")
+	return paths.File(".xp_bookmarks.json")
 }
 
 // Load loads bookmarks from disk
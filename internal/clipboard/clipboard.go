@@ -0,0 +1,97 @@
+package clipboard
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// copier is a clipboard command: one that reads the text to copy from its
+// standard input, or for pasters one that prints the clipboard
+type copier struct {
+	name string
+	args []string
+}
+
+// copiers returns the clipboard commands to try on this system, best first
+func copiers() []copier {
+	switch runtime.GOOS {
+	case "darwin":
+		return []copier{{"pbcopy", nil}}
+	case "windows":
+		return []copier{{"clip", nil}}
+	}
+	var list []copier
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		list = append(list, copier{"wl-copy", nil})
+	}
+	list = append(list,
+		copier{"xclip", []string{"-selection", "clipboard"}},
+		copier{"xsel", []string{"--clipboard", "--input"}},
+		copier{"clip.exe", nil}, // WSL
+	)
+	return list
+}
+
+// Write puts text on the system clipboard with the first clipboard command
+// found. Without one, as on a server reached over SSH, it falls back to the
+// OSC 52 escape sequence, which many terminals turn into a clipboard write.
+func Write(text string) error {
+	for _, c := range copiers() {
+		if _, err := exec.LookPath(c.name); err != nil {
+			continue
+		}
+		cmd := exec.Command(c.name, c.args...)
+		cmd.Stdin = strings.NewReader(text)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("%s: %v %s", c.name, err, strings.TrimSpace(string(out)))
+		}
+		return nil
+	}
+	if os.Getenv("TERM") == "" || os.Getenv("TERM") == "dumb" {
+		return fmt.Errorf("no clipboard command found (install xclip, xsel or wl-clipboard)")
+	}
+	_, err := fmt.Fprintf(os.Stdout, "\x1b]52;c;%s\a", base64.StdEncoding.EncodeToString([]byte(text)))
+	return err
+}
+
+// pasters returns the commands that print the clipboard on this system,
+// best first
+func pasters() []copier {
+	switch runtime.GOOS {
+	case "darwin":
+		return []copier{{"pbpaste", nil}}
+	case "windows":
+		return []copier{{"powershell", []string{"-NoProfile", "-Command", "Get-Clipboard"}}}
+	}
+	var list []copier
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		list = append(list, copier{"wl-paste", []string{"--no-newline"}})
+	}
+	list = append(list,
+		copier{"xclip", []string{"-selection", "clipboard", "-o"}},
+		copier{"xsel", []string{"--clipboard", "--output"}},
+		copier{"powershell.exe", []string{"-NoProfile", "-Command", "Get-Clipboard"}}, // WSL
+	)
+	return list
+}
+
+// Read returns the text on the system clipboard, using the first clipboard
+// command found. Unlike Write it has no terminal fallback, since OSC 52
+// reads are refused by most terminals.
+func Read() (string, error) {
+	for _, c := range pasters() {
+		if _, err := exec.LookPath(c.name); err != nil {
+			continue
+		}
+		out, err := exec.Command(c.name, c.args...).Output()
+		if err != nil {
+			return "", fmt.Errorf("%s: %v", c.name, err)
+		}
+		return string(out), nil
+	}
+	return "", fmt.Errorf("no clipboard command found (install xclip, xsel or wl-clipboard)")
+}
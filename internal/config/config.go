@@ -2,13 +2,16 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"os/exec"
-	"os/user"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 
+	"github.com/alexcostache/Xplorer/internal/paths"
+	"github.com/alexcostache/Xplorer/pkg/filesystem"
 	"github.com/nsf/termbox-go"
 )
 
@@ -20,7 +23,87 @@ type Config struct {
 	ShowRawPath   bool
 	MouseEnabled  bool
 	UseAsciiIcons bool
+	GlyphMode     string // "auto", "unicode" or "ascii"
+	SafeGlyphs    bool   // Resolved from GlyphMode: draw with ASCII only
+	FilterCase    string // "insensitive", "sensitive" or "smart"
+	KeepSelection bool   // Keep the selection when changing directory
+	NameTruncation string // TruncateEnd or TruncateMiddle
+	ShowSizes     string // SizesAlways, SizesCursor or SizesHidden
+	ScrollOff     int    // Lines of context kept above and below the cursor
+	ColumnHeader  bool   // Show a header row with clickable sort labels above the file list
+	SecondarySort string // Orders entries that tie under the sort mode: "name", "size", "modified" or "type"
+	AutoRefresh   bool   // Re-read listings when they change on disk
+	RememberView  bool   // Restore sorting, hidden files and cursor per directory
+	SoftDeleteDays int   // Keep deleted items in the staging area this long; 0 uses the trash
+	OwnershipColors bool // Warn about files of other users and world-writable files
+	DirNotes      bool   // Show the title of a directory's note or README above the listing
+	VerifyCopies  bool   // Hash copies and compare them with their source
+	CaseWarnings  bool   // Ask before creating or pasting names that differ from existing ones only by case
+	LowPriority   bool   // Run copies, moves, deletes and folder scans with lowered CPU and IO priority
+	DryRunCommands bool  // Log user and shell commands instead of running them
+	BackupMode    string // BackupOff, BackupSuffix or BackupDir
+	BackupDays    int    // Purge backups in the backups directory after this long; 0 keeps them
+	NewFileMode   string // Octal permissions of new files, before the umask
+	NewDirMode    string // Octal permissions of new folders, before the umask
+	InheritGroup  bool   // New items get the group of the folder they are made in
+	PreviewSkipExtensions []string // Extensions previewed as metadata only
+	PreviewMaxSizeMB int          // Larger files are previewed as metadata only; 0 for no limit
+	OpenWith      map[string]string // Extension or file name -> command that Enter opens such files with
+	Commands      []Command         // User commands offered in the Open With and context menus
+	Templates     []Template        // Files new files can start from, offered in the context menu
+	Connections   []Connection      // Saved FTP and WebDAV endpoints, offered by the connection manager
+	WorkspaceRoots []string         // Folders Left doesn't go above and searches default to, as configured
+	PairRules     map[string][]string // Source extension -> extensions of files derived from it
+	PairOperations bool             // Copy, move and delete take the files grouped with the targets along
 	Keys          KeyBindings
+	Problems      []ValidationError // Problems found in the config file at load time
+}
+
+// Glyph modes for rendering icons, borders and indicators
+const (
+	GlyphModeAuto    = "auto"
+	GlyphModeUnicode = "unicode"
+	GlyphModeASCII   = "ascii"
+)
+
+// Ways to shorten file names that don't fit their column
+const (
+	TruncateEnd    = "end"    // "very_long_na…"
+	TruncateMiddle = "middle" // "very_lo…ame.txt", keeping the extension
+)
+
+// Which rows of the file list show the size column
+const (
+	SizesAlways = "always"
+	SizesCursor = "cursor" // Only the cursor row, leaving the others to the names
+	SizesHidden = "hidden"
+)
+
+// Where items are backed up before an operation overwrites them
+const (
+	BackupOff    = "off"
+	BackupSuffix = "suffix" // Next to the item, as name~
+	BackupDir    = "dir"    // In ~/.xp_backups
+)
+
+// ParseMode reads octal permissions such as "0644" or "2775", where the
+// leading digit may hold the setuid (4), setgid (2) and sticky (1) bits
+func ParseMode(s string) (os.FileMode, bool) {
+	n, err := strconv.ParseUint(s, 8, 32)
+	if err != nil || n > 07777 {
+		return 0, false
+	}
+	mode := os.FileMode(n) & os.ModePerm
+	if n&04000 != 0 {
+		mode |= os.ModeSetuid
+	}
+	if n&02000 != 0 {
+		mode |= os.ModeSetgid
+	}
+	if n&01000 != 0 {
+		mode |= os.ModeSticky
+	}
+	return mode, true
 }
 
 // EditorOption represents an editor choice
@@ -31,32 +114,150 @@ type EditorOption struct {
 	Description string
 }
 
+// Command is a user-defined command. Cmd may use the placeholders %f (the
+// file), %d (the current directory) and %s (the selected files).
+type Command struct {
+	Name     string `json:"name"`
+	Cmd      string `json:"cmd"`
+	Terminal bool   `json:"terminal,omitempty"` // Runs in the terminal, with the UI suspended
+}
+
+// Template is a file that new files can be created from
+type Template struct {
+	Name string `json:"name"`
+	File string `json:"file"`           // Holds the contents of new files; "~" is the home folder
+	Edit bool   `json:"edit,omitempty"` // New files are opened in the editor once created
+}
+
+// Connection is a saved FTP or WebDAV endpoint. Its password is kept in
+// the system keyring, never in the URL.
+type Connection struct {
+	Name string `json:"name"`
+	URL  string `json:"url"` // e.g. ftp://user@host/pub or https://user@host/dav/
+}
+
 // ConfigFile represents the JSON config file structure
 type ConfigFile struct {
 	EditorCmd     string `json:"editor_cmd,omitempty"`
 	TerminalApp   string `json:"terminal_app,omitempty"`
 	MouseEnabled  *bool  `json:"mouse_enabled,omitempty"`
 	UseAsciiIcons *bool  `json:"use_ascii_icons,omitempty"`
+	GlyphMode     string `json:"glyph_mode,omitempty"`
+	FilterCase    string `json:"filter_case,omitempty"`
+	KeepSelection *bool  `json:"keep_selection,omitempty"`
+	NameTruncation string `json:"name_truncation,omitempty"`
+	ShowSizes     string `json:"show_sizes,omitempty"`
+	SecondarySort string `json:"secondary_sort,omitempty"`
+	ScrollOff     *int   `json:"scrolloff,omitempty"`
+	ColumnHeader  *bool  `json:"column_header,omitempty"`
+	AutoRefresh   *bool  `json:"auto_refresh,omitempty"`
+	RememberView  *bool  `json:"remember_view,omitempty"`
+	SoftDeleteDays *int  `json:"soft_delete_days,omitempty"`
+	OwnershipColors *bool `json:"ownership_colors,omitempty"`
+	DirNotes      *bool  `json:"dir_notes,omitempty"`
+	VerifyCopies  *bool  `json:"verify_copies,omitempty"`
+	CaseWarnings  *bool  `json:"case_warnings,omitempty"`
+	LowPriority   *bool  `json:"low_priority,omitempty"`
+	DryRunCommands *bool `json:"dry_run_commands,omitempty"`
+	BackupMode    string `json:"backup_mode,omitempty"`
+	BackupDays    *int   `json:"backup_days,omitempty"`
+	NewFileMode   string `json:"new_file_mode,omitempty"`
+	NewDirMode    string `json:"new_dir_mode,omitempty"`
+	InheritGroup  *bool  `json:"inherit_group,omitempty"`
+	PreviewSkipExtensions []string `json:"preview_skip_extensions,omitempty"`
+	PreviewMaxSizeMB *int         `json:"preview_max_size_mb,omitempty"`
+	OpenWith      map[string]string `json:"open_with,omitempty"`
+	Commands      []Command         `json:"commands,omitempty"`
+	Templates     []Template        `json:"templates,omitempty"`
+	Connections   []Connection      `json:"connections,omitempty"`
+	WorkspaceRoots []string         `json:"workspace_roots,omitempty"`
+	PairRules     map[string][]string `json:"pair_rules,omitempty"`
+	PairOperations *bool            `json:"pair_operations,omitempty"`
+	Keys          map[string]string `json:"keys,omitempty"` // Action name -> key name, for bindings that differ from the defaults
 }
 
 // KeyBindings holds all keyboard shortcuts
 type KeyBindings struct {
-	Filter         rune
-	ToggleHidden   rune
-	Quit           rune
-	Help           rune
-	OpenTerminal   rune
-	BookmarkToggle rune
-	BookmarkPopup  rune
-	EditPath       rune
-	ScrollDown     rune
-	ScrollUp       rune
-	ScrollDownFast rune
-	ScrollUpFast   rune
-	OpenThemePopup rune
-	TogglePath     rune
-	OpenWith       rune
-	ConfigMenu     rune
+	MoveUp         Key
+	MoveDown       Key
+	PageUp         Key
+	PageDown       Key
+	ParentDir      Key
+	EnterDir       Key
+	Open           Key
+	GoBack         Key
+	HistoryBack    Key
+	HistoryForward Key
+	HistoryPopup   Key
+	Select         Key
+	ContextMenu    Key
+	SortMenu       Key
+	Refresh        Key
+	SwitchPane     Key
+	SwapPanes      Key
+	CopyToPane     Key
+	MoveToPane     Key
+	Filter         Key
+	ToggleHidden   Key
+	Quit           Key
+	Help           Key
+	OpenTerminal   Key
+	BookmarkToggle Key
+	BookmarkPopup  Key
+	BookmarkFileDir Key
+	EditPath       Key
+	ScrollDown     Key
+	ScrollUp       Key
+	ScrollDownFast Key
+	ScrollUpFast   Key
+	ScrollLeft     Key
+	ScrollRight    Key
+	OpenThemePopup Key
+	TogglePath     Key
+	OpenWith       Key
+	ConfigMenu     Key
+	QuickLook      Key
+	Slideshow      Key
+	MinimalMode    Key
+	Treemap        Key
+	PairFiles      Key
+	Statistics     Key
+	ShellCommand   Key
+	ShellTerminal  Key
+	PinDestination Key
+	CopyToPinned   Key
+	MoveToPinned   Key
+	CompareBadges  Key
+	JumpBack       Key
+	JumpForward    Key
+	DualPane       Key
+	MirrorPane     Key
+	FollowLink     Key
+	Grep           Key
+	FuzzyJump      Key
+	BasketToggle   Key
+	BasketPopup    Key
+	ClipboardPopup Key
+	Processes      Key
+	Connections    Key
+	JobPriority    Key
+	ToggleExec     Key
+	RemoveQuarantine Key
+	OpenFileManager Key
+	CopyContents   Key
+	CopyPath       Key
+	CopyName       Key
+	CopyDir        Key
+	ExportListing  Key
+	DirNote        Key
+	SelectionSets  Key
+	SelectAll      Key
+	DeselectAll    Key
+	InvertSelection Key
+	SelectPattern  Key
+	VisualMode     Key
+	RangeUp        Key
+	RangeDown      Key
 }
 
 // New creates a new configuration with platform-specific defaults
@@ -66,6 +267,21 @@ func New() *Config {
 		ShowRawPath:   true,
 		MouseEnabled:  true, // Enable mouse by default
 		UseAsciiIcons: true, // Enable ASCII icons by default
+		GlyphMode:     GlyphModeAuto,
+		FilterCase:    "insensitive",
+		NameTruncation: TruncateEnd,
+		ShowSizes:     SizesAlways,
+		SecondarySort: "name",
+		AutoRefresh:   true,
+		RememberView:  true,
+		OwnershipColors: true,
+		DirNotes:      true,
+		CaseWarnings:  true,
+		BackupMode:    BackupOff,
+		BackupDays:    30,
+		NewFileMode:   "0644",
+		NewDirMode:    "0755",
+		PairRules:     defaultPairRules(),
 		Keys:          defaultKeyBindings(),
 	}
 
@@ -106,29 +322,308 @@ func New() *Config {
 	if configFile.UseAsciiIcons != nil {
 		cfg.UseAsciiIcons = *configFile.UseAsciiIcons
 	}
+	
+	if configFile.GlyphMode != "" {
+		cfg.GlyphMode = configFile.GlyphMode
+	}
+	
+	if configFile.FilterCase != "" {
+		cfg.FilterCase = configFile.FilterCase
+	}
+	
+	if configFile.KeepSelection != nil {
+		cfg.KeepSelection = *configFile.KeepSelection
+	}
+	
+	if configFile.NameTruncation != "" {
+		cfg.NameTruncation = configFile.NameTruncation
+	}
+	
+	if configFile.ShowSizes != "" {
+		cfg.ShowSizes = configFile.ShowSizes
+	}
+	
+	if configFile.SecondarySort != "" {
+		cfg.SecondarySort = configFile.SecondarySort
+	}
+	
+	if configFile.ScrollOff != nil && *configFile.ScrollOff >= 0 {
+		cfg.ScrollOff = *configFile.ScrollOff
+	}
+	
+	if configFile.ColumnHeader != nil {
+		cfg.ColumnHeader = *configFile.ColumnHeader
+	}
+	
+	if configFile.AutoRefresh != nil {
+		cfg.AutoRefresh = *configFile.AutoRefresh
+	}
+	
+	if configFile.RememberView != nil {
+		cfg.RememberView = *configFile.RememberView
+	}
+	
+	if configFile.SoftDeleteDays != nil && *configFile.SoftDeleteDays >= 0 {
+		cfg.SoftDeleteDays = *configFile.SoftDeleteDays
+	}
+	
+	if configFile.OwnershipColors != nil {
+		cfg.OwnershipColors = *configFile.OwnershipColors
+	}
+	
+	if configFile.DirNotes != nil {
+		cfg.DirNotes = *configFile.DirNotes
+	}
+	
+	if configFile.VerifyCopies != nil {
+		cfg.VerifyCopies = *configFile.VerifyCopies
+	}
+	
+	if configFile.CaseWarnings != nil {
+		cfg.CaseWarnings = *configFile.CaseWarnings
+	}
+	
+	if configFile.LowPriority != nil {
+		cfg.LowPriority = *configFile.LowPriority
+	}
+	
+	if configFile.DryRunCommands != nil {
+		cfg.DryRunCommands = *configFile.DryRunCommands
+	}
+	
+	if configFile.BackupMode != "" {
+		cfg.BackupMode = configFile.BackupMode
+	}
+	
+	if configFile.BackupDays != nil && *configFile.BackupDays >= 0 {
+		cfg.BackupDays = *configFile.BackupDays
+	}
+	
+	if _, ok := ParseMode(configFile.NewFileMode); ok {
+		cfg.NewFileMode = configFile.NewFileMode
+	}
+	
+	if _, ok := ParseMode(configFile.NewDirMode); ok {
+		cfg.NewDirMode = configFile.NewDirMode
+	}
+	
+	if configFile.InheritGroup != nil {
+		cfg.InheritGroup = *configFile.InheritGroup
+	}
+	
+	cfg.Keys.applyKeys(configFile.Keys)
+	
+	cfg.PreviewSkipExtensions = configFile.PreviewSkipExtensions
+	if configFile.PreviewMaxSizeMB != nil && *configFile.PreviewMaxSizeMB >= 0 {
+		cfg.PreviewMaxSizeMB = *configFile.PreviewMaxSizeMB
+	}
+	cfg.OpenWith = configFile.OpenWith
+	cfg.WorkspaceRoots = configFile.WorkspaceRoots
+	if configFile.PairRules != nil {
+		cfg.PairRules = configFile.PairRules
+	}
+	if configFile.PairOperations != nil {
+		cfg.PairOperations = *configFile.PairOperations
+	}
+	for _, command := range configFile.Commands {
+		if command.Name != "" && command.Cmd != "" {
+			cfg.Commands = append(cfg.Commands, command)
+		}
+	}
+	for _, template := range configFile.Templates {
+		if template.Name != "" && template.File != "" {
+			cfg.Templates = append(cfg.Templates, template)
+		}
+	}
+	for _, connection := range configFile.Connections {
+		if connection.Name != "" && filesystem.CheckEndpoint(connection.URL) == nil {
+			cfg.Connections = append(cfg.Connections, connection)
+		}
+	}
+	cfg.ResolveGlyphMode()
+	cfg.Problems = ValidateConfigFile()
 
 	return cfg
 }
 
+// Reload re-reads the config file into c. If the file has problems they are
+// returned and the current settings are kept.
+func (c *Config) Reload() []ValidationError {
+	if problems := ValidateConfigFile(); len(problems) > 0 {
+		return problems
+	}
+	fresh := New()
+	// Keep session toggles that are not stored in the file
+	fresh.ShowHidden = c.ShowHidden
+	fresh.ShowRawPath = c.ShowRawPath
+	*c = *fresh
+	return nil
+}
+
+// WorkspaceRoot returns the innermost workspace root that dir is in or is,
+// or "" when it is in none. Roots may start with ~ for the home directory.
+func (c *Config) WorkspaceRoot(dir string) string {
+	dir = filepath.Clean(dir)
+	best := ""
+	for _, root := range c.WorkspaceRoots {
+		if strings.HasPrefix(root, "~") {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				continue
+			}
+			root = filepath.Join(home, strings.TrimPrefix(root, "~"))
+		}
+		if !filepath.IsAbs(root) {
+			continue
+		}
+		root = filepath.Clean(root)
+		rel, err := filepath.Rel(root, dir)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if len(root) > len(best) {
+			best = root
+		}
+	}
+	return best
+}
+
+// ResolveGlyphMode sets SafeGlyphs from the configured glyph mode,
+// probing the terminal when the mode is "auto"
+func (c *Config) ResolveGlyphMode() {
+	switch c.GlyphMode {
+	case GlyphModeASCII:
+		c.SafeGlyphs = true
+	case GlyphModeUnicode:
+		c.SafeGlyphs = false
+	default:
+		c.SafeGlyphs = !DetectGlyphSupport()
+	}
+}
+
+// DetectGlyphSupport guesses whether the terminal renders emoji and
+// box-drawing glyphs correctly, based on the platform, TERM and locale
+func DetectGlyphSupport() bool {
+	if runtime.GOOS == "windows" {
+		// Windows Terminal handles Unicode well; the legacy console does not
+		return os.Getenv("WT_SESSION") != ""
+	}
+	
+	switch os.Getenv("TERM") {
+	case "linux", "dumb", "vt100", "vt102", "vt220", "cons25":
+		return false
+	}
+	
+	// The first locale variable that is set decides the character encoding
+	for _, key := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if locale := os.Getenv(key); locale != "" {
+			lower := strings.ToLower(locale)
+			return strings.Contains(lower, "utf-8") || strings.Contains(lower, "utf8")
+		}
+	}
+	
+	return true
+}
+
+// defaultPairRules returns the default pairings of source and derived files
+func defaultPairRules() map[string][]string {
+	raw := []string{"raw", "dng", "cr2", "cr3", "nef", "arw", "orf", "rw2", "xmp"}
+	return map[string][]string{
+		"c":    {"o", "obj"},
+		"cpp":  {"o", "obj"},
+		"ts":   {"js", "js.map", "d.ts"},
+		"scss": {"css", "css.map"},
+		"py":   {"pyc"},
+		"java": {"class"},
+		"tex":  {"aux", "log", "toc", "out", "bbl", "blg", "synctex.gz"},
+		"jpg":  raw,
+		"jpeg": raw,
+	}
+}
+
 // defaultKeyBindings returns the default key bindings
 func defaultKeyBindings() KeyBindings {
 	return KeyBindings{
-		Filter:         '/',
-		ToggleHidden:   '.',
-		Quit:           'q',
-		Help:           '?',
-		OpenTerminal:   't',
-		BookmarkToggle: 'B',
-		BookmarkPopup:  'b',
-		EditPath:       'e',
-		ScrollDown:     '[',
-		ScrollUp:       ']',
-		ScrollDownFast: '{',
-		ScrollUpFast:   '}',
-		OpenThemePopup: 'T',
-		TogglePath:     'r',
-		OpenWith:       'o',
-		ConfigMenu:     'P',
+		MoveUp:         "up",
+		MoveDown:       "down",
+		PageUp:         "pgup",
+		PageDown:       "pgdn",
+		ParentDir:      "left",
+		EnterDir:       "right",
+		Open:           "enter",
+		GoBack:         "backspace",
+		HistoryBack:    "alt+left",
+		HistoryForward: "alt+right",
+		HistoryPopup:   "H",
+		Select:         "space",
+		ContextMenu:    "ctrl+o",
+		SortMenu:       "ctrl+s",
+		Refresh:        "ctrl+r",
+		SwitchPane:     "tab",
+		SwapPanes:      "ctrl+u",
+		CopyToPane:     "f5",
+		MoveToPane:     "f6",
+		Filter:         "/",
+		ToggleHidden:   ".",
+		Quit:           "q",
+		Help:           "?",
+		OpenTerminal:   "t",
+		BookmarkToggle: "B",
+		BookmarkPopup:  "b",
+		BookmarkFileDir: "F",
+		EditPath:       "e",
+		ScrollDown:     "[",
+		ScrollUp:       "]",
+		ScrollDownFast: "{",
+		ScrollUpFast:   "}",
+		ScrollLeft:     "<",
+		ScrollRight:    ">",
+		OpenThemePopup: "T",
+		TogglePath:     "r",
+		OpenWith:       "o",
+		ConfigMenu:     "P",
+		QuickLook:      "v",
+		Slideshow:      "S",
+		MinimalMode:    "M",
+		Treemap:        "Z",
+		PairFiles:      "L",
+		Statistics:     "I",
+		ShellCommand:   ":",
+		ShellTerminal:  "!",
+		PinDestination: "D",
+		CopyToPinned:   "c",
+		MoveToPinned:   "x",
+		CompareBadges:  "C",
+		JumpBack:       "(",
+		JumpForward:    ")",
+		DualPane:       "|",
+		MirrorPane:     "=",
+		FollowLink:     "g",
+		Grep:           "G",
+		FuzzyJump:      "ctrl+p",
+		BasketToggle:   "a",
+		BasketPopup:    "A",
+		ClipboardPopup: "K",
+		Processes:      "J",
+		Connections:    "R",
+		JobPriority:    "ctrl+n",
+		ToggleExec:     "X",
+		RemoveQuarantine: "U",
+		OpenFileManager: "O",
+		CopyContents:   "Y",
+		CopyPath:       "y",
+		CopyName:       "ctrl+y",
+		CopyDir:        "ctrl+d",
+		ExportListing:  "E",
+		DirNote:        "N",
+		SelectionSets:  "W",
+		SelectAll:      "ctrl+a",
+		DeselectAll:    "-",
+		InvertSelection: "*",
+		SelectPattern:  "+",
+		VisualMode:     "V",
+		RangeUp:        "shift+up",
+		RangeDown:      "shift+down",
 	}
 }
 
@@ -232,8 +727,7 @@ func getEnvOrDefault(key, defaultValue string) string {
 
 // getConfigFilePath returns the path to the config file
 func getConfigFilePath() string {
-	usr, _ := user.Current()
-	return filepath.Join(usr.HomeDir, ".xp_config.json")
+	return paths.File(".xp_config.json")
 }
 
 // loadConfigFile loads configuration from JSON file
@@ -250,21 +744,82 @@ func loadConfigFile() ConfigFile {
 	return cfg
 }
 
-// SaveConfigFile saves configuration to JSON file
-func SaveConfigFile(editorCmd, terminalApp string, mouseEnabled, useAsciiIcons *bool) error {
+// SaveConfigFile saves configuration to the JSON config file. Keys that this
+// version does not know about are preserved.
+func SaveConfigFile(c *Config) error {
+	return writeConfigFile(getConfigFilePath(), c)
+}
+
+// writeConfigFile merges the settings in c into the JSON document at path
+func writeConfigFile(path string, c *Config) error {
 	cfg := ConfigFile{
-		EditorCmd:     editorCmd,
-		TerminalApp:   terminalApp,
-		MouseEnabled:  mouseEnabled,
-		UseAsciiIcons: useAsciiIcons,
+		EditorCmd:     c.EditorCmd,
+		TerminalApp:   c.TerminalApp,
+		MouseEnabled:  &c.MouseEnabled,
+		UseAsciiIcons: &c.UseAsciiIcons,
+		GlyphMode:     c.GlyphMode,
+		FilterCase:    c.FilterCase,
+		KeepSelection: &c.KeepSelection,
+		NameTruncation: c.NameTruncation,
+		ShowSizes:     c.ShowSizes,
+		SecondarySort: c.SecondarySort,
+		ScrollOff:     &c.ScrollOff,
+		ColumnHeader:  &c.ColumnHeader,
+		AutoRefresh:   &c.AutoRefresh,
+		RememberView:  &c.RememberView,
+		SoftDeleteDays: &c.SoftDeleteDays,
+		OwnershipColors: &c.OwnershipColors,
+		DirNotes:      &c.DirNotes,
+		VerifyCopies:  &c.VerifyCopies,
+		CaseWarnings:  &c.CaseWarnings,
+		LowPriority:   &c.LowPriority,
+		DryRunCommands: &c.DryRunCommands,
+		BackupMode:    c.BackupMode,
+		BackupDays:    &c.BackupDays,
+		NewFileMode:   c.NewFileMode,
+		NewDirMode:    c.NewDirMode,
+		InheritGroup:  &c.InheritGroup,
+		PreviewSkipExtensions: c.PreviewSkipExtensions,
+		PreviewMaxSizeMB: &c.PreviewMaxSizeMB,
+		OpenWith:      c.OpenWith,
+		Commands:      c.Commands,
+		Templates:     c.Templates,
+		Connections:   c.Connections,
+		WorkspaceRoots: c.WorkspaceRoots,
+		PairRules:     c.PairRules,
+		PairOperations: &c.PairOperations,
+		Keys:          c.Keys.overrides(),
 	}
 	
-	data, err := json.MarshalIndent(cfg, "", "  ")
+	doc := make(map[string]json.RawMessage)
+	if data, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return fmt.Errorf("not overwriting invalid config file: %v", err)
+		}
+	}
+	
+	known, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(known, &fields); err != nil {
+		return err
+	}
+	// Known fields left empty are removed rather than kept stale
+	for _, spec := range ConfigSchema {
+		delete(doc, spec.Name)
+	}
+	for key, value := range fields {
+		doc[key] = value
+	}
+	
+	data, err := json.MarshalIndent(doc, "", "  ")
 	if err != nil {
 		return err
 	}
 	
-	return os.WriteFile(getConfigFilePath(), data, 0644)
+	return os.WriteFile(path, data, 0644)
 }
 
 // GetConfigFilePath returns the config file path (exported for external use)
@@ -292,6 +847,14 @@ func AsciiFileIcon(name string, isDir bool) string {
 	return "📄"
 }
 
+// SafeFileIcon returns a plain ASCII icon that renders in any terminal
+func SafeFileIcon(isDir bool) string {
+	if isDir {
+		return "+"
+	}
+	return "-"
+}
+
 // FileIcon returns an icon for a file based on its extension
 func FileIcon(name string, isDir bool, useAscii bool) string {
 	if useAscii {
@@ -339,7 +902,7 @@ func DescribeFileByExt(name string) string {
 		".dll":  "DLL File",
 		".png":  "Image File", ".jpg": "Image File", ".jpeg": "Image File", ".gif": "Image File", ".svg": "Image File",
 		".zip":  "Archive File", ".tar": "Archive File", ".gz": "Archive File", ".rar": "Archive File",
-		".pdf":  "PDF Document",
+		".pdf":  "PDF Document", ".epub": "EPUB Book", ".docx": "Word Document", ".odt": "OpenDocument Text",
 		".mp4":  "Video File", ".mkv": "Video File", ".avi": "Video File",
 		".mp3":  "Audio File", ".wav": "Audio File", ".flac": "Audio File",
 		".bin":  "Binary File", ".dat": "Binary File",
@@ -0,0 +1,315 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/nsf/termbox-go"
+)
+
+// Key names a key as written in the "keys" section of the config file: a
+// single character ("q", "/"), a named key ("up", "pgdn", "enter", "f5"), a
+// Ctrl combination ("ctrl+s") or any of these with Alt ("alt+left").
+// Characters are case-sensitive, names are not.
+type Key string
+
+// namedKeys maps the names of non-character keys to termbox keys
+var namedKeys = map[Key]termbox.Key{
+	"up":        termbox.KeyArrowUp,
+	"down":      termbox.KeyArrowDown,
+	"left":      termbox.KeyArrowLeft,
+	"right":     termbox.KeyArrowRight,
+	"pgup":      termbox.KeyPgup,
+	"pgdn":      termbox.KeyPgdn,
+	"home":      termbox.KeyHome,
+	"end":       termbox.KeyEnd,
+	"insert":    termbox.KeyInsert,
+	"delete":    termbox.KeyDelete,
+	"enter":     termbox.KeyEnter,
+	"space":     termbox.KeySpace,
+	"tab":       termbox.KeyTab,
+	"backspace": termbox.KeyBackspace2,
+	"f1":        termbox.KeyF1,
+	"f2":        termbox.KeyF2,
+	"f3":        termbox.KeyF3,
+	"f4":        termbox.KeyF4,
+	"f5":        termbox.KeyF5,
+	"f6":        termbox.KeyF6,
+	"f7":        termbox.KeyF7,
+	"f8":        termbox.KeyF8,
+	"f9":        termbox.KeyF9,
+	"f10":       termbox.KeyF10,
+	"f11":       termbox.KeyF11,
+	"f12":       termbox.KeyF12,
+}
+
+// sequenceKeys are keys termbox doesn't know; the app reads them from their
+// xterm escape sequences
+var sequenceKeys = map[Key]bool{
+	"shift+up":   true,
+	"shift+down": true,
+}
+
+// keyLabels are the help panel labels of named keys
+var keyLabels = map[Key]string{
+	"up":         "↑",
+	"down":       "↓",
+	"left":       "←",
+	"right":      "→",
+	"pgup":       "PgUp",
+	"pgdn":       "PgDn",
+	"backspace":  "Bksp",
+	"shift+up":   "Shift+↑",
+	"shift+down": "Shift+↓",
+}
+
+// ParseKey normalizes a key name from the config file, reporting whether
+// it names a key that can be bound. Ctrl+H, Ctrl+I and Ctrl+M can't be told
+// apart from Backspace, Tab and Enter, so they are refused.
+func ParseKey(name string) (Key, bool) {
+	if len(name) > 4 && strings.EqualFold(name[:4], "alt+") {
+		key, ok := ParseKey(name[4:])
+		if !ok || key.IsAlt() || sequenceKeys[key] {
+			return "", false
+		}
+		return "alt+" + key, true
+	}
+	if utf8.RuneCountInString(name) == 1 {
+		return Key(name), name != " "
+	}
+	key := Key(strings.ToLower(strings.TrimSpace(name)))
+	if _, ok := namedKeys[key]; ok || sequenceKeys[key] {
+		return key, true
+	}
+	if letter, ok := strings.CutPrefix(string(key), "ctrl+"); ok && len(letter) == 1 &&
+		letter[0] >= 'a' && letter[0] <= 'z' && !strings.Contains("him", letter) {
+		return key, true
+	}
+	return "", false
+}
+
+// EventKey returns the name of the key pressed in ev, or "" for events that
+// no binding can match (including Esc, which always cancels or quits)
+func EventKey(ev termbox.Event) Key {
+	if ev.Type != termbox.EventKey {
+		return ""
+	}
+	if ev.Ch == ' ' {
+		return "space"
+	}
+	if ev.Ch != 0 {
+		return Key(string(ev.Ch))
+	}
+	if ev.Key == termbox.KeyBackspace {
+		return "backspace"
+	}
+	for name, key := range namedKeys {
+		if ev.Key == key {
+			return name
+		}
+	}
+	if ev.Key >= termbox.KeyCtrlA && ev.Key <= termbox.KeyCtrlZ {
+		return Key("ctrl+" + string(rune('a'+ev.Key-termbox.KeyCtrlA)))
+	}
+	return ""
+}
+
+// AltKey returns the Alt combination of the key pressed in ev, for
+// terminals that send Alt as Esc followed by the key
+func AltKey(ev termbox.Event) Key {
+	if key := EventKey(ev); key != "" && !key.IsAlt() {
+		return "alt+" + key
+	}
+	return ""
+}
+
+// IsAlt reports whether the key is an Alt combination
+func (k Key) IsAlt() bool {
+	return strings.HasPrefix(string(k), "alt+")
+}
+
+// String returns the key as shown in the help panel, e.g. "Ctrl+S"
+func (k Key) String() string {
+	if k.IsAlt() {
+		return "Alt+" + k[4:].String()
+	}
+	if label, ok := keyLabels[k]; ok {
+		return label
+	}
+	if letter, ok := strings.CutPrefix(string(k), "ctrl+"); ok {
+		return "Ctrl+" + strings.ToUpper(letter)
+	}
+	if len(k) > 1 {
+		return strings.ToUpper(string(k[:1])) + string(k[1:])
+	}
+	return string(k)
+}
+
+// Binding is one remappable action
+type Binding struct {
+	Name        string // Name in the "keys" section of the config file
+	Description string
+	Key         *Key
+}
+
+// Bindings lists every action with its current key, in help panel order
+func (k *KeyBindings) Bindings() []Binding {
+	return []Binding{
+		{"move_up", "Move up", &k.MoveUp},
+		{"move_down", "Move down", &k.MoveDown},
+		{"page_up", "Move up fast (5 lines)", &k.PageUp},
+		{"page_down", "Move down fast (5 lines)", &k.PageDown},
+		{"parent_dir", "Go to parent directory", &k.ParentDir},
+		{"enter_dir", "Enter directory", &k.EnterDir},
+		{"open", "Open file", &k.Open},
+		{"go_back", "Previous directory", &k.GoBack},
+		{"history_back", "History back", &k.HistoryBack},
+		{"history_forward", "History forward", &k.HistoryForward},
+		{"history_popup", "Recent directories", &k.HistoryPopup},
+		{"select", "Select/Deselect file", &k.Select},
+		{"select_all", "Select all files in the listing", &k.SelectAll},
+		{"deselect_all", "Deselect all", &k.DeselectAll},
+		{"invert_selection", "Invert the selection in the listing", &k.InvertSelection},
+		{"select_pattern", "Select by pattern (*.log or /regex/)", &k.SelectPattern},
+		{"visual_mode", "Visual mode (moving the cursor selects a range)", &k.VisualMode},
+		{"range_up", "Select a range upwards", &k.RangeUp},
+		{"range_down", "Select a range downwards", &k.RangeDown},
+		{"context_menu", "File operations menu", &k.ContextMenu},
+		{"sort_menu", "Change sorting mode", &k.SortMenu},
+		{"refresh", "Refresh listing", &k.Refresh},
+		{"filter", "Filter", &k.Filter},
+		{"grep", "Search file contents (grep)", &k.Grep},
+		{"fuzzy_jump", "Fuzzy jump to a file below this folder", &k.FuzzyJump},
+		{"toggle_hidden", "Toggle hidden files", &k.ToggleHidden},
+		{"open_with", "Open with...", &k.OpenWith},
+		{"copy_contents", "Copy file contents to the system clipboard", &k.CopyContents},
+		{"copy_path", "Copy absolute path to the system clipboard", &k.CopyPath},
+		{"copy_name", "Copy file name to the system clipboard", &k.CopyName},
+		{"copy_dir", "Copy containing directory to the system clipboard", &k.CopyDir},
+		{"export_listing", "Export the listing to a text, CSV or JSON file", &k.ExportListing},
+		{"dir_note", "Create or edit the note of the current directory", &k.DirNote},
+		{"open_terminal", "Open in terminal", &k.OpenTerminal},
+		{"open_file_manager", "Open this folder in the system file manager", &k.OpenFileManager},
+		{"edit_path", "Edit path", &k.EditPath},
+		{"toggle_path", "Toggle path display", &k.TogglePath},
+		{"bookmark_toggle", "Bookmark current folder", &k.BookmarkToggle},
+		{"bookmark_file_dir", "Bookmark the folder of the item under cursor", &k.BookmarkFileDir},
+		{"bookmark_popup", "Jump to a bookmark", &k.BookmarkPopup},
+		{"jump_back", "Jump list back", &k.JumpBack},
+		{"jump_forward", "Jump list forward", &k.JumpForward},
+		{"scroll_down", "Scroll preview ↓", &k.ScrollDown},
+		{"scroll_up", "Scroll preview ↑", &k.ScrollUp},
+		{"scroll_down_fast", "Scroll preview ↓ (fast)", &k.ScrollDownFast},
+		{"scroll_up_fast", "Scroll preview ↑ (fast)", &k.ScrollUpFast},
+		{"scroll_left", "Scroll preview ← (wide tables)", &k.ScrollLeft},
+		{"scroll_right", "Scroll preview → (wide tables)", &k.ScrollRight},
+		{"follow_link", "Follow path/URL on top preview line", &k.FollowLink},
+		{"quick_look", "Quick look (full-screen preview)", &k.QuickLook},
+		{"slideshow", "Image slideshow", &k.Slideshow},
+		{"minimal_mode", "Minimal UI (file list only)", &k.MinimalMode},
+		{"treemap", "Preview folders as a treemap by size", &k.Treemap},
+		{"pair_files", "Group derived files with their source", &k.PairFiles},
+		{"statistics", "Statistics of this and earlier sessions", &k.Statistics},
+		{"shell_command", "Run a shell command, show its output", &k.ShellCommand},
+		{"shell_terminal", "Run a shell command in the terminal", &k.ShellTerminal},
+		{"pin_destination", "Pin/unpin destination folder", &k.PinDestination},
+		{"copy_to_pinned", "Copy to pinned destination", &k.CopyToPinned},
+		{"move_to_pinned", "Move to pinned destination", &k.MoveToPinned},
+		{"compare_badges", "Compare with pinned destination", &k.CompareBadges},
+		{"basket_toggle", "Add/remove selection in the basket", &k.BasketToggle},
+		{"basket_popup", "Basket (copy/move/trash gathered files)", &k.BasketPopup},
+		{"clipboard_popup", "Clipboard (view, remove items, paste)", &k.ClipboardPopup},
+		{"processes", "Background processes (status, kill)", &k.Processes},
+		{"connections", "FTP/WebDAV connections (connect, disconnect)", &k.Connections},
+		{"job_priority", "Lower/restore priority of the running operation", &k.JobPriority},
+		{"selection_sets", "Selection sets (save/restore named selections)", &k.SelectionSets},
+		{"toggle_exec", "Toggle executable bit", &k.ToggleExec},
+		{"remove_quarantine", "Remove quarantine attribute (macOS)", &k.RemoveQuarantine},
+		{"dual_pane", "Dual-pane mode", &k.DualPane},
+		{"switch_pane", "Switch pane (dual-pane)", &k.SwitchPane},
+		{"swap_panes", "Swap panes (dual-pane)", &k.SwapPanes},
+		{"mirror_pane", "Show this directory in the other pane", &k.MirrorPane},
+		{"copy_to_pane", "Copy to other pane (refresh in single pane)", &k.CopyToPane},
+		{"move_to_pane", "Move to other pane", &k.MoveToPane},
+		{"open_theme_popup", "Themes", &k.OpenThemePopup},
+		{"config_menu", "Configuration menu", &k.ConfigMenu},
+		{"help", "Toggle help", &k.Help},
+		{"quit", "Quit", &k.Quit},
+	}
+}
+
+// keyActionNames lists the action names accepted in the "keys" section
+func keyActionNames() []string {
+	var defaults KeyBindings
+	var names []string
+	for _, b := range defaults.Bindings() {
+		names = append(names, b.Name)
+	}
+	return names
+}
+
+// applyKeys overrides bindings with the valid entries of a "keys" section
+func (k *KeyBindings) applyKeys(keys map[string]string) {
+	for _, b := range k.Bindings() {
+		if name, ok := keys[b.Name]; ok {
+			if key, valid := ParseKey(name); valid {
+				*b.Key = key
+			}
+		}
+	}
+}
+
+// overrides returns the bindings that differ from the defaults, as written
+// in the "keys" section
+func (k *KeyBindings) overrides() map[string]string {
+	defaults := defaultKeyBindings()
+	current := k.Bindings()
+	keys := make(map[string]string)
+	for i, b := range defaults.Bindings() {
+		if *current[i].Key != *b.Key {
+			keys[b.Name] = string(*current[i].Key)
+		}
+	}
+	return keys
+}
+
+// keyProblems checks a "keys" section: every key name must be valid, and
+// no two actions may end up on the same key
+func keyProblems(file string, data []byte, keys map[string]string) []ValidationError {
+	var problems []ValidationError
+	report := func(action, format string, args ...interface{}) {
+		problem := ValidationError{File: file, Field: "keys." + action, Message: fmt.Sprintf(format, args...)}
+		if at := bytes.Index(data, []byte(`"`+action+`"`)); at >= 0 {
+			problem.Line, problem.Column = lineColumn(data, int64(at))
+		}
+		problems = append(problems, problem)
+	}
+
+	bound := defaultKeyBindings()
+	for _, b := range bound.Bindings() {
+		if name, ok := keys[b.Name]; ok {
+			if key, valid := ParseKey(name); valid {
+				*b.Key = key
+			} else {
+				report(b.Name, "%q is not a key name", name)
+			}
+		}
+	}
+
+	owners := make(map[Key]string)
+	for _, b := range bound.Bindings() {
+		if owner, taken := owners[*b.Key]; taken {
+			// Blame the action the user remapped rather than the default
+			action, other := b.Name, owner
+			if _, remapped := keys[action]; !remapped {
+				action, other = other, action
+			}
+			report(action, "%q is also bound to %s", string(*b.Key), other)
+			continue
+		}
+		owners[*b.Key] = b.Name
+	}
+	return problems
+}
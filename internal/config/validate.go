@@ -0,0 +1,417 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/alexcostache/Xplorer/pkg/filesystem"
+)
+
+// ValidationError describes a problem found in a JSON settings file
+type ValidationError struct {
+	File    string
+	Line    int
+	Column  int
+	Field   string
+	Message string
+}
+
+// Error formats the problem as file:line:column: field: message
+func (e ValidationError) Error() string {
+	location := e.File
+	if e.Line > 0 {
+		location = fmt.Sprintf("%s:%d:%d", e.File, e.Line, e.Column)
+	}
+	if e.Field != "" {
+		return fmt.Sprintf("%s: %s: %s", location, e.Field, e.Message)
+	}
+	return fmt.Sprintf("%s: %s", location, e.Message)
+}
+
+// FieldSpec describes one allowed top-level field of a JSON object
+type FieldSpec struct {
+	Name    string
+	Kind    string   // "string", "bool", "count", "mode" (octal permissions), "list" (of strings), "lists" (object of lists), "object", "commands", "templates" or "connections"
+	Allowed []string // Allowed string values (for objects: allowed member values)
+	Keys    []string // Allowed member names for objects; nil allows any
+}
+
+// ConfigSchema lists the fields accepted in the config file
+var ConfigSchema = []FieldSpec{
+	{Name: "editor_cmd", Kind: "string"},
+	{Name: "terminal_app", Kind: "string"},
+	{Name: "mouse_enabled", Kind: "bool"},
+	{Name: "use_ascii_icons", Kind: "bool"},
+	{Name: "glyph_mode", Kind: "string", Allowed: []string{GlyphModeAuto, GlyphModeUnicode, GlyphModeASCII}},
+	{Name: "filter_case", Kind: "string", Allowed: []string{"insensitive", "sensitive", "smart"}},
+	{Name: "keep_selection", Kind: "bool"},
+	{Name: "name_truncation", Kind: "string", Allowed: []string{TruncateEnd, TruncateMiddle}},
+	{Name: "show_sizes", Kind: "string", Allowed: []string{SizesAlways, SizesCursor, SizesHidden}},
+	{Name: "scrolloff", Kind: "count"},
+	{Name: "column_header", Kind: "bool"},
+	{Name: "secondary_sort", Kind: "string", Allowed: []string{"name", "size", "modified", "type"}},
+	{Name: "auto_refresh", Kind: "bool"},
+	{Name: "remember_view", Kind: "bool"},
+	{Name: "soft_delete_days", Kind: "count"},
+	{Name: "ownership_colors", Kind: "bool"},
+	{Name: "dir_notes", Kind: "bool"},
+	{Name: "verify_copies", Kind: "bool"},
+	{Name: "case_warnings", Kind: "bool"},
+	{Name: "low_priority", Kind: "bool"},
+	{Name: "dry_run_commands", Kind: "bool"},
+	{Name: "backup_mode", Kind: "string", Allowed: []string{BackupOff, BackupSuffix, BackupDir}},
+	{Name: "backup_days", Kind: "count"},
+	{Name: "new_file_mode", Kind: "mode"},
+	{Name: "new_dir_mode", Kind: "mode"},
+	{Name: "inherit_group", Kind: "bool"},
+	{Name: "preview_skip_extensions", Kind: "list"},
+	{Name: "preview_max_size_mb", Kind: "count"},
+	{Name: "open_with", Kind: "object"},
+	{Name: "commands", Kind: "commands"},
+	{Name: "templates", Kind: "templates"},
+	{Name: "connections", Kind: "connections"},
+	{Name: "workspace_roots", Kind: "list"},
+	{Name: "pair_rules", Kind: "lists"},
+	{Name: "pair_operations", Kind: "bool"},
+	{Name: "keys", Kind: "object", Keys: keyActionNames()},
+}
+
+// ValidateConfigFile checks the config file against ConfigSchema. A missing
+// file is not an error.
+func ValidateConfigFile() []ValidationError {
+	path := getConfigFilePath()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	if problems := ValidateJSON(path, data, ConfigSchema); len(problems) > 0 {
+		return problems
+	}
+	var doc struct {
+		Keys map[string]string `json:"keys"`
+	}
+	if json.Unmarshal(data, &doc) != nil {
+		return nil
+	}
+	return keyProblems(path, data, doc.Keys)
+}
+
+// ValidateJSON checks that data is a JSON object matching the schema.
+// Unknown fields are only reported when they look like a typo of a known one,
+// so settings written by newer versions are tolerated.
+func ValidateJSON(file string, data []byte, schema []FieldSpec) []ValidationError {
+	var problems []ValidationError
+	report := func(offset int64, field, format string, args ...interface{}) {
+		line, col := lineColumn(data, offset)
+		problems = append(problems, ValidationError{
+			File:    file,
+			Line:    line,
+			Column:  col,
+			Field:   field,
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+	syntaxError := func(err error) []ValidationError {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			report(syntaxErr.Offset, "", "invalid JSON: %v", syntaxErr)
+		} else if err == io.EOF || err == io.ErrUnexpectedEOF {
+			report(int64(len(data)), "", "invalid JSON: unexpected end of file")
+		} else {
+			report(0, "", "invalid JSON: %v", err)
+		}
+		return problems
+	}
+
+	specs := make(map[string]FieldSpec, len(schema))
+	for _, spec := range schema {
+		specs[spec.Name] = spec
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	tok, err := dec.Token()
+	if err != nil {
+		return syntaxError(err)
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		report(0, "", "expected a JSON object")
+		return problems
+	}
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return syntaxError(err)
+		}
+		key, _ := tok.(string)
+		keyOffset := dec.InputOffset() - int64(len(key)) - 2
+
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return syntaxError(err)
+		}
+		valueOffset := dec.InputOffset() - int64(len(raw))
+
+		spec, known := specs[key]
+		if !known {
+			if suggestion := closestName(key, schema); suggestion != "" {
+				report(keyOffset, key, "unknown field, did you mean %q?", suggestion)
+			}
+			continue
+		}
+		problems = append(problems, validateValue(file, data, spec, raw, valueOffset)...)
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return syntaxError(err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		report(dec.InputOffset(), "", "unexpected data after the JSON object")
+	}
+	return problems
+}
+
+// validateValue checks a single field value against its spec
+func validateValue(file string, data []byte, spec FieldSpec, raw json.RawMessage, offset int64) []ValidationError {
+	var problems []ValidationError
+	report := func(at int64, field, format string, args ...interface{}) {
+		line, col := lineColumn(data, at)
+		problems = append(problems, ValidationError{
+			File:    file,
+			Line:    line,
+			Column:  col,
+			Field:   field,
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	if string(raw) == "null" {
+		return nil
+	}
+
+	switch spec.Kind {
+	case "bool":
+		var v bool
+		if json.Unmarshal(raw, &v) != nil {
+			report(offset, spec.Name, "expected true or false, got %s", raw)
+		}
+
+	case "count":
+		var v int
+		if json.Unmarshal(raw, &v) != nil || v < 0 {
+			report(offset, spec.Name, "expected a whole number of 0 or more, got %s", raw)
+		}
+
+	case "mode":
+		var v string
+		if json.Unmarshal(raw, &v) != nil {
+			report(offset, spec.Name, "expected octal permissions as a string such as \"0644\", got %s", raw)
+		} else if _, ok := ParseMode(v); !ok {
+			report(offset, spec.Name, "%q is not octal permissions such as \"0644\"", v)
+		}
+
+	case "list":
+		var v []string
+		if json.Unmarshal(raw, &v) != nil {
+			report(offset, spec.Name, "expected a list of strings, got %s", raw)
+		}
+
+	case "lists":
+		var members map[string]json.RawMessage
+		if json.Unmarshal(raw, &members) != nil {
+			report(offset, spec.Name, "expected an object of string lists")
+			break
+		}
+		for name, value := range members {
+			var v []string
+			if json.Unmarshal(value, &v) != nil {
+				at := offset + int64(bytes.Index(raw, []byte(`"`+name+`"`)))
+				report(at, spec.Name+"."+name, "expected a list of strings, got %s", value)
+			}
+		}
+
+	case "string":
+		var v string
+		if json.Unmarshal(raw, &v) != nil {
+			report(offset, spec.Name, "expected a string, got %s", raw)
+		} else if len(spec.Allowed) > 0 && !containsFold(spec.Allowed, v) {
+			report(offset, spec.Name, "%q is not one of %s", v, strings.Join(spec.Allowed, ", "))
+		}
+
+	case "commands":
+		var commands []map[string]json.RawMessage
+		if json.Unmarshal(raw, &commands) != nil {
+			report(offset, spec.Name, "expected a list of objects with a name and a cmd")
+			break
+		}
+		for i, members := range commands {
+			field := fmt.Sprintf("%s[%d]", spec.Name, i)
+			var command Command
+			for name, value := range members {
+				var err error
+				switch name {
+				case "name":
+					err = json.Unmarshal(value, &command.Name)
+				case "cmd":
+					err = json.Unmarshal(value, &command.Cmd)
+				case "terminal":
+					err = json.Unmarshal(value, &command.Terminal)
+				default:
+					report(offset, field+"."+name, "unknown key")
+					continue
+				}
+				if err != nil {
+					report(offset, field+"."+name, "unexpected value %s", value)
+				}
+			}
+			if command.Name == "" || command.Cmd == "" {
+				report(offset, field, "needs a name and a cmd")
+			}
+		}
+
+	case "templates":
+		var templates []map[string]json.RawMessage
+		if json.Unmarshal(raw, &templates) != nil {
+			report(offset, spec.Name, "expected a list of objects with a name and a file")
+			break
+		}
+		for i, members := range templates {
+			field := fmt.Sprintf("%s[%d]", spec.Name, i)
+			var template Template
+			for name, value := range members {
+				var err error
+				switch name {
+				case "name":
+					err = json.Unmarshal(value, &template.Name)
+				case "file":
+					err = json.Unmarshal(value, &template.File)
+				case "edit":
+					err = json.Unmarshal(value, &template.Edit)
+				default:
+					report(offset, field+"."+name, "unknown key")
+					continue
+				}
+				if err != nil {
+					report(offset, field+"."+name, "unexpected value %s", value)
+				}
+			}
+			if template.Name == "" || template.File == "" {
+				report(offset, field, "needs a name and a file")
+			}
+		}
+
+	case "connections":
+		var connections []map[string]json.RawMessage
+		if json.Unmarshal(raw, &connections) != nil {
+			report(offset, spec.Name, "expected a list of objects with a name and a url")
+			break
+		}
+		for i, members := range connections {
+			field := fmt.Sprintf("%s[%d]", spec.Name, i)
+			var connection Connection
+			for name, value := range members {
+				var err error
+				switch name {
+				case "name":
+					err = json.Unmarshal(value, &connection.Name)
+				case "url":
+					err = json.Unmarshal(value, &connection.URL)
+				default:
+					report(offset, field+"."+name, "unknown key")
+					continue
+				}
+				if err != nil {
+					report(offset, field+"."+name, "unexpected value %s", value)
+				}
+			}
+			if connection.Name == "" || connection.URL == "" {
+				report(offset, field, "needs a name and a url")
+			} else if err := filesystem.CheckEndpoint(connection.URL); err != nil {
+				report(offset, field+".url", "%v", err)
+			}
+		}
+
+	case "object":
+		var members map[string]json.RawMessage
+		if json.Unmarshal(raw, &members) != nil {
+			report(offset, spec.Name, "expected an object")
+			break
+		}
+		for name, value := range members {
+			field := spec.Name + "." + name
+			at := offset + int64(bytes.Index(raw, []byte(`"`+name+`"`)))
+			if spec.Keys != nil && !containsFold(spec.Keys, name) {
+				report(at, field, "unknown key")
+				continue
+			}
+			var v string
+			if json.Unmarshal(value, &v) != nil {
+				report(at, field, "expected a string, got %s", value)
+			} else if len(spec.Allowed) > 0 && !containsFold(spec.Allowed, v) {
+				report(at, field, "unknown value %q", v)
+			}
+		}
+	}
+	return problems
+}
+
+// lineColumn converts a byte offset to a 1-based line and column
+func lineColumn(data []byte, offset int64) (int, int) {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	col := int(offset) - bytes.LastIndexByte(before, '\n')
+	return line, col
+}
+
+// containsFold reports whether list contains s, ignoring case
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// closestName returns the schema field within two edits of name, if any
+func closestName(name string, schema []FieldSpec) string {
+	best, bestDist := "", 3
+	for _, spec := range schema {
+		if d := editDistance(strings.ToLower(name), spec.Name); d < bestDist {
+			best, bestDist = spec.Name, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,207 @@
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// maxCells bounds the LCS table (lines of a × lines of b after trimming the
+// common prefix and suffix); larger changes are shown as one replaced block
+const maxCells = 4_000_000
+
+// Kind tells whether a line is shared, only in the old file or only in the new one
+type Kind byte
+
+const (
+	Equal  Kind = ' '
+	Delete Kind = '-'
+	Insert Kind = '+'
+)
+
+// Op is one line of a diff
+type Op struct {
+	Kind Kind
+	Text string
+}
+
+// Lines returns the line-by-line edit script turning a into b
+func Lines(a, b []string) []Op {
+	// Common prefix and suffix need no table
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var ops []Op
+	for _, line := range a[:prefix] {
+		ops = append(ops, Op{Equal, line})
+	}
+	ops = append(ops, middle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, Op{Equal, line})
+	}
+	return ops
+}
+
+// middle diffs the differing part of two files with a longest common
+// subsequence table
+func middle(a, b []string) []Op {
+	var ops []Op
+	if len(a)*len(b) > maxCells {
+		for _, line := range a {
+			ops = append(ops, Op{Delete, line})
+		}
+		for _, line := range b {
+			ops = append(ops, Op{Insert, line})
+		}
+		return ops
+	}
+
+	// lcs[i][j] is the LCS length of a[i:] and b[j:]
+	lcs := make([][]int32, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, Op{Equal, a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, Op{Delete, a[i]})
+			i++
+		default:
+			ops = append(ops, Op{Insert, b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, Op{Delete, a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, Op{Insert, b[j]})
+	}
+	return ops
+}
+
+// Unified formats an edit script as unified diff hunks with the given number
+// of context lines. It returns nil when there are no changes.
+func Unified(ops []Op, context int) []string {
+	var out []string
+	oldLine, newLine := 1, 1
+	for start := 0; start < len(ops); {
+		// Find the next change
+		first := start
+		for first < len(ops) && ops[first].Kind == Equal {
+			first++
+		}
+		if first == len(ops) {
+			break
+		}
+		hunkStart := max(first-context, start)
+		for k := start; k < hunkStart; k++ {
+			oldLine++
+			newLine++
+		}
+
+		// Extend the hunk while changes are within 2*context lines of each other
+		end := first
+		for end < len(ops) {
+			next := end
+			for next < len(ops) && ops[next].Kind != Equal {
+				next++
+			}
+			gap := next
+			for gap < len(ops) && ops[gap].Kind == Equal {
+				gap++
+			}
+			end = next
+			if gap == len(ops) || gap-next > 2*context {
+				break
+			}
+			end = gap
+		}
+		hunkEnd := min(end+context, len(ops))
+
+		oldCount, newCount := 0, 0
+		var body []string
+		for _, op := range ops[hunkStart:hunkEnd] {
+			if op.Kind != Insert {
+				oldCount++
+			}
+			if op.Kind != Delete {
+				newCount++
+			}
+			body = append(body, string(op.Kind)+op.Text)
+		}
+		out = append(out, fmt.Sprintf("@@ -%d,%d +%d,%d @@", oldLine, oldCount, newLine, newCount))
+		out = append(out, body...)
+		oldLine += oldCount
+		newLine += newCount
+		start = hunkEnd
+	}
+	return out
+}
+
+// Files returns a unified diff of two files, or a one-line summary for
+// identical or binary files
+func Files(oldPath, newPath string) ([]string, error) {
+	oldData, err := os.ReadFile(oldPath)
+	if err != nil {
+		return nil, err
+	}
+	newData, err := os.ReadFile(newPath)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.Equal(oldData, newData) {
+		return []string{"Files are identical"}, nil
+	}
+	if isBinary(oldData) || isBinary(newData) {
+		return []string{fmt.Sprintf("Binary files differ (%d vs %d bytes)", len(oldData), len(newData))}, nil
+	}
+
+	header := []string{"--- " + oldPath, "+++ " + newPath}
+	hunks := Unified(Lines(splitLines(oldData), splitLines(newData)), 3)
+	if len(hunks) == 0 {
+		// Only line endings differ
+		return append(header, "Files differ only in line endings or the final newline"), nil
+	}
+	return append(header, hunks...), nil
+}
+
+// splitLines splits file content into lines without their terminators
+func splitLines(data []byte) []string {
+	text := strings.TrimSuffix(string(data), "\n")
+	if text == "" {
+		return nil
+	}
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return lines
+}
+
+// isBinary reports whether data looks like a binary file
+func isBinary(data []byte) bool {
+	return bytes.IndexByte(data[:min(len(data), 8000)], 0) >= 0
+}
@@ -0,0 +1,233 @@
+package history
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alexcostache/Xplorer/internal/paths"
+)
+
+// maxEntries limits the number of directories remembered
+const maxEntries = 200
+
+// saveDelay is how long changes wait before they are written, so a burst
+// of directory changes is saved once and off the UI goroutine
+const saveDelay = 2 * time.Second
+
+// Entry represents a visited directory
+type Entry struct {
+	Path      string    `json:"path"`
+	Visits    int       `json:"visits"`
+	LastVisit time.Time `json:"last_visit"`
+}
+
+// Manager tracks visited directories and ranks them by frecency
+// (a combination of visit frequency and recency). Changes are saved in the
+// background shortly after they are made; call Save before quitting to
+// write the last ones. It is safe for concurrent use.
+type Manager struct {
+	mu      sync.Mutex
+	path    string // The history file, fixed when the manager is made
+	entries []Entry
+	dirty   bool        // Changed since the last save
+	timer   *time.Timer // Pending background save
+}
+
+// NewManager creates a new history manager
+func NewManager() *Manager {
+	m := &Manager{
+		path:    paths.File(".xp_history.json"),
+		entries: []Entry{},
+	}
+	m.Load()
+	return m
+}
+
+// GetAll returns all history entries
+func (m *Manager) GetAll() []Entry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Entry(nil), m.entries...)
+}
+
+// Visit records a visit to a directory
+func (m *Manager) Visit(path string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cleanPath := filepath.Clean(path)
+	now := time.Now()
+
+	for i := range m.entries {
+		if m.entries[i].Path == cleanPath {
+			m.entries[i].Visits++
+			m.entries[i].LastVisit = now
+			m.changed()
+			return
+		}
+	}
+
+	m.entries = append(m.entries, Entry{
+		Path:      cleanPath,
+		Visits:    1,
+		LastVisit: now,
+	})
+
+	// Drop the lowest ranked entries when the history grows too large
+	if len(m.entries) > maxEntries {
+		m.sortByScore(now)
+		m.entries = m.entries[:maxEntries]
+	}
+	m.changed()
+}
+
+// Remove removes a directory from the history
+func (m *Manager) Remove(path string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cleanPath := filepath.Clean(path)
+	for i, e := range m.entries {
+		if e.Path == cleanPath {
+			m.entries = append(m.entries[:i], m.entries[i+1:]...)
+			m.changed()
+			return true
+		}
+	}
+	return false
+}
+
+// Move follows a directory that was renamed or moved from src to dest,
+// along with the directories below it
+func (m *Manager) Move(src, dest string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	src, dest = filepath.Clean(src), filepath.Clean(dest)
+	changed := false
+	for i, e := range m.entries {
+		if e.Path == src {
+			m.entries[i].Path = dest
+			changed = true
+		} else if isBelow(e.Path, src) {
+			m.entries[i].Path = dest + e.Path[len(src):]
+			changed = true
+		}
+	}
+	if changed {
+		m.changed()
+	}
+}
+
+// Forget removes a deleted directory from the history, along with the
+// directories below it
+func (m *Manager) Forget(path string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	path = filepath.Clean(path)
+	kept := m.entries[:0]
+	for _, e := range m.entries {
+		if e.Path != path && !isBelow(e.Path, path) {
+			kept = append(kept, e)
+		}
+	}
+	if len(kept) != len(m.entries) {
+		m.entries = kept
+		m.changed()
+	}
+}
+
+// isBelow reports whether path is inside dir
+func isBelow(path, dir string) bool {
+	return strings.HasPrefix(path, strings.TrimSuffix(dir, string(filepath.Separator))+string(filepath.Separator))
+}
+
+// Match returns the paths of visited directories whose base name contains
+// the query (case-insensitive), best frecency first
+func (m *Manager) Match(query string) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	m.sortByScore(now)
+
+	lowerQuery := strings.ToLower(query)
+	var matches []string
+	for _, e := range m.entries {
+		if strings.Contains(strings.ToLower(filepath.Base(e.Path)), lowerQuery) {
+			matches = append(matches, e.Path)
+		}
+	}
+	return matches
+}
+
+// Score returns the frecency score of an entry at the given time
+func Score(e Entry, now time.Time) float64 {
+	age := now.Sub(e.LastVisit)
+	weight := 0.25
+	switch {
+	case age < time.Hour:
+		weight = 4
+	case age < 24*time.Hour:
+		weight = 2
+	case age < 7*24*time.Hour:
+		weight = 0.5
+	}
+	return float64(e.Visits) * weight
+}
+
+// sortByScore sorts entries by frecency, highest first
+func (m *Manager) sortByScore(now time.Time) {
+	sort.SliceStable(m.entries, func(i, j int) bool {
+		return Score(m.entries[i], now) > Score(m.entries[j], now)
+	})
+}
+
+// getHistoryFile returns the path to the history file
+func (m *Manager) getHistoryFile() string {
+	return m.path
+}
+
+// Load loads history from disk
+func (m *Manager) Load() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, err := os.ReadFile(m.getHistoryFile())
+	if err != nil {
+		return // File doesn't exist yet, that's ok
+	}
+	_ = json.Unmarshal(data, &m.entries)
+}
+
+// changed marks the history for saving after saveDelay; the mutex must be
+// held
+func (m *Manager) changed() {
+	m.dirty = true
+	if m.timer == nil {
+		m.timer = time.AfterFunc(saveDelay, func() { _ = m.Save() })
+	}
+}
+
+// Save writes the history to disk if it changed since the last save. A
+// failed save is tried again by the next one.
+func (m *Manager) Save() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.timer != nil {
+		m.timer.Stop()
+		m.timer = nil
+	}
+	if !m.dirty {
+		return nil
+	}
+	data, err := json.MarshalIndent(m.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(m.getHistoryFile(), data, 0644); err != nil {
+		return err
+	}
+	m.dirty = false
+	return nil
+}
@@ -0,0 +1,136 @@
+// Package keyring keeps passwords in the system keyring: the Secret Service
+// (through secret-tool) on Linux and the BSDs, and the login keychain
+// (through security) on macOS. Other systems have no keyring here, and
+// their callers ask for the password each time.
+package keyring
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync/atomic"
+)
+
+// Service names Xplorer's entries in the keyring
+const Service = "xplorer"
+
+var (
+	// ErrNotFound is returned by Get for an account without a password
+	ErrNotFound = errors.New("no password in the keyring")
+	// ErrUnsupported is returned when the system has no keyring to use
+	ErrUnsupported = errors.New("no keyring available (install secret-tool)")
+)
+
+// Keyring stores one secret per service and account
+type Keyring interface {
+	Get(service, account string) (string, error)
+	Set(service, account, secret string) error
+	Delete(service, account string) error
+}
+
+// active holds the keyring in use
+var active atomic.Pointer[Keyring]
+
+func init() {
+	var k Keyring = system{}
+	active.Store(&k)
+}
+
+// Use makes k the keyring of Get, Set and Delete, returning the previous
+// one so it can be restored. Tests use it to keep out of the real keyring.
+func Use(k Keyring) Keyring {
+	return *active.Swap(&k)
+}
+
+// Get returns the password of an account
+func Get(account string) (string, error) {
+	return (*active.Load()).Get(Service, account)
+}
+
+// Set stores the password of an account, replacing any earlier one
+func Set(account, secret string) error {
+	return (*active.Load()).Set(Service, account, secret)
+}
+
+// Delete removes the password of an account
+func Delete(account string) error {
+	return (*active.Load()).Delete(Service, account)
+}
+
+// system is the keyring of the operating system, reached through its
+// command-line tool
+type system struct{}
+
+// tool returns the keyring command of this system
+func tool() (string, error) {
+	name := "secret-tool"
+	switch runtime.GOOS {
+	case "darwin":
+		name = "security"
+	case "linux", "freebsd", "openbsd", "netbsd", "dragonfly":
+	default:
+		return "", ErrUnsupported
+	}
+	if _, err := exec.LookPath(name); err != nil {
+		return "", ErrUnsupported
+	}
+	return name, nil
+}
+
+func (system) Get(service, account string) (string, error) {
+	name, err := tool()
+	if err != nil {
+		return "", err
+	}
+	args := []string{"lookup", "service", service, "account", account}
+	if name == "security" {
+		args = []string{"find-generic-password", "-s", service, "-a", account, "-w"}
+	}
+	out, err := exec.Command(name, args...).Output()
+	var exit *exec.ExitError
+	if errors.As(err, &exit) {
+		// Both tools fail without output when there is no such entry
+		if len(exit.Stderr) == 0 || name == "security" {
+			return "", ErrNotFound
+		}
+		return "", fmt.Errorf("%s: %s", name, strings.TrimSpace(string(exit.Stderr)))
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+func (system) Set(service, account, secret string) error {
+	name, err := tool()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(name, "store", "--label", "Xplorer: "+account, "service", service, "account", account)
+	cmd.Stdin = strings.NewReader(secret)
+	if name == "security" {
+		// security only takes the password as an argument when not interactive
+		cmd = exec.Command(name, "add-generic-password", "-U", "-s", service, "-a", account, "-w", secret)
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s: %v %s", name, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func (system) Delete(service, account string) error {
+	name, err := tool()
+	if err != nil {
+		return err
+	}
+	args := []string{"clear", "service", service, "account", account}
+	if name == "security" {
+		args = []string{"delete-generic-password", "-s", service, "-a", account}
+	}
+	if out, err := exec.Command(name, args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("%s: %v %s", name, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
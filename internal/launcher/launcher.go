@@ -0,0 +1,218 @@
+package launcher
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Target is what a shortcut file points to: a web address, a file or
+// folder, or a program to run
+type Target struct {
+	Name     string   // Of the shortcut, when it has one
+	URL      string   // Opened in the browser
+	Path     string   // Opened or shown in Xplorer
+	Command  []string // Program and arguments (.desktop applications)
+	Terminal bool     // The command runs in a terminal
+}
+
+// IsShortcut reports whether path is a shortcut file by its extension:
+// .url (Windows), .webloc (macOS), .desktop (Linux) or .lnk (Windows)
+func IsShortcut(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".url", ".webloc", ".desktop", ".lnk":
+		return true
+	}
+	return false
+}
+
+// Resolve reads the shortcut file at path and returns its target. Paths are
+// made absolute against the shortcut's directory, and file:// URLs become
+// paths.
+func Resolve(path string) (*Target, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var target *Target
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".url":
+		target, err = parseInternetShortcut(data)
+	case ".webloc":
+		target, err = parseWebloc(data)
+	case ".desktop":
+		target, err = parseDesktop(data)
+	case ".lnk":
+		target, err = parseShellLink(data)
+	default:
+		return nil, fmt.Errorf("%s is not a shortcut", filepath.Base(path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filepath.Base(path), err)
+	}
+
+	if strings.HasPrefix(strings.ToLower(target.URL), "file:") {
+		if u, err := url.Parse(target.URL); err == nil && u.Path != "" {
+			target.URL, target.Path = "", u.Path
+		}
+	}
+	if target.Path != "" {
+		target.Path = filepath.FromSlash(strings.ReplaceAll(target.Path, `\`, "/"))
+		if !filepath.IsAbs(target.Path) && !isWindowsPath(target.Path) {
+			target.Path = filepath.Join(filepath.Dir(path), target.Path)
+		}
+	}
+	return target, nil
+}
+
+// isWindowsPath reports whether p starts with a drive letter, which is an
+// absolute path on Windows even when read elsewhere
+func isWindowsPath(p string) bool {
+	return len(p) >= 2 && p[1] == ':' && (p[0] >= 'A' && p[0] <= 'Z' || p[0] >= 'a' && p[0] <= 'z')
+}
+
+// iniValues returns the key=value pairs of one [group] of an INI-style file
+func iniValues(data []byte, group string) map[string]string {
+	values := make(map[string]string)
+	inGroup := false
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			inGroup = strings.EqualFold(line, "["+group+"]")
+			continue
+		}
+		if key, value, ok := strings.Cut(line, "="); ok && inGroup {
+			values[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	return values
+}
+
+// parseInternetShortcut reads a Windows .url file
+func parseInternetShortcut(data []byte) (*Target, error) {
+	address := iniValues(data, "InternetShortcut")["URL"]
+	if address == "" {
+		return nil, errors.New("no URL in the shortcut")
+	}
+	return &Target{URL: address}, nil
+}
+
+// parseWebloc reads a macOS .webloc file: a property list with a URL key,
+// saved as XML
+func parseWebloc(data []byte) (*Target, error) {
+	if bytes.HasPrefix(data, []byte("bplist")) {
+		// Binary property lists keep the address as a plain string
+		if i := bytes.Index(data, []byte("://")); i >= 0 {
+			start := i
+			for start > 0 && isSchemeByte(data[start-1]) {
+				start--
+			}
+			end := i
+			for end < len(data) && data[end] >= 0x21 && data[end] < 0x7f {
+				end++
+			}
+			return &Target{URL: string(data[start:end])}, nil
+		}
+		return nil, errors.New("no URL in the binary property list")
+	}
+
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	afterKey := false
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, errors.New("no URL in the property list")
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "key" && start.Name.Local != "string" {
+			continue
+		}
+		var text string
+		if err := dec.DecodeElement(&text, &start); err != nil {
+			return nil, err
+		}
+		if start.Name.Local == "key" {
+			afterKey = text == "URL"
+		} else if afterKey {
+			return &Target{URL: strings.TrimSpace(text)}, nil
+		}
+	}
+}
+
+// isSchemeByte reports whether b may appear in a URL scheme
+func isSchemeByte(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9' || b == '+' || b == '-' || b == '.'
+}
+
+// parseDesktop reads a freedesktop.org .desktop file: links open their URL,
+// applications run their Exec line
+func parseDesktop(data []byte) (*Target, error) {
+	entry := iniValues(data, "Desktop Entry")
+	target := &Target{Name: entry["Name"], Terminal: entry["Terminal"] == "true"}
+	switch entry["Type"] {
+	case "Link":
+		if entry["URL"] == "" {
+			return nil, errors.New("no URL in the link")
+		}
+		target.URL = entry["URL"]
+	case "Application", "":
+		target.Command = execArgs(entry["Exec"])
+		if len(target.Command) == 0 {
+			return nil, errors.New("no command in the application entry")
+		}
+	default:
+		return nil, fmt.Errorf("cannot open entries of type %s", entry["Type"])
+	}
+	return target, nil
+}
+
+// execArgs splits an Exec line into arguments, honoring double quotes and
+// dropping field codes such as %f and %U, which stand for files to open
+func execArgs(line string) []string {
+	var args []string
+	var arg strings.Builder
+	inArg, quoted := false, false
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '"':
+			quoted = !quoted
+			inArg = true
+		case c == '\\' && quoted && i+1 < len(line):
+			i++
+			arg.WriteByte(line[i])
+		case (c == ' ' || c == '\t') && !quoted:
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteByte(c)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+
+	kept := args[:0]
+	for _, a := range args {
+		if len(a) == 2 && a[0] == '%' && a[1] != '%' {
+			continue
+		}
+		kept = append(kept, strings.ReplaceAll(a, "%%", "%"))
+	}
+	return kept
+}
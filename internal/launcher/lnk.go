@@ -0,0 +1,127 @@
+package launcher
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"unicode/utf16"
+)
+
+// Shell link (.lnk) layout, from the [MS-SHLLINK] specification
+const (
+	lnkHeaderSize = 0x4C
+
+	lnkHasTargetIDList = 1 << 0
+	lnkHasLinkInfo     = 1 << 1
+	lnkHasName         = 1 << 2
+	lnkHasRelativePath = 1 << 3
+	lnkIsUnicode       = 1 << 7
+
+	linkInfoLocalPath = 1 << 0 // VolumeIDAndLocalBasePath
+	linkInfoNetwork   = 1 << 1 // CommonNetworkRelativeLinkAndPathSuffix
+)
+
+// parseShellLink reads the target path of a Windows shell link: the local
+// or network path in its link info, or else its relative path
+func parseShellLink(data []byte) (*Target, error) {
+	if len(data) < lnkHeaderSize || binary.LittleEndian.Uint32(data) != lnkHeaderSize {
+		return nil, errors.New("not a shell link")
+	}
+	flags := binary.LittleEndian.Uint32(data[0x14:])
+	pos := lnkHeaderSize
+	if flags&lnkHasTargetIDList != 0 {
+		if len(data) < pos+2 {
+			return nil, errors.New("truncated shell link")
+		}
+		pos += 2 + int(binary.LittleEndian.Uint16(data[pos:]))
+	}
+
+	if flags&lnkHasLinkInfo != 0 {
+		if len(data) < pos+28 {
+			return nil, errors.New("truncated shell link")
+		}
+		info := data[pos:]
+		size := int(binary.LittleEndian.Uint32(info))
+		if size < 28 || size > len(info) {
+			return nil, errors.New("truncated shell link")
+		}
+		info = info[:size]
+		infoFlags := binary.LittleEndian.Uint32(info[8:])
+		suffix := cString(info, binary.LittleEndian.Uint32(info[24:]))
+		switch {
+		case infoFlags&linkInfoLocalPath != 0:
+			if base := cString(info, binary.LittleEndian.Uint32(info[16:])); base != "" {
+				return &Target{Path: joinLinkPath(base, suffix)}, nil
+			}
+		case infoFlags&linkInfoNetwork != 0:
+			network := int(binary.LittleEndian.Uint32(info[20:]))
+			if network+12 <= len(info) {
+				share := info[network:]
+				if name := cString(share, binary.LittleEndian.Uint32(share[8:])); name != "" {
+					return &Target{Path: joinLinkPath(name, suffix)}, nil
+				}
+			}
+		}
+		pos += size
+	}
+
+	// The string data follows: the description, then the relative path
+	for _, flag := range []uint32{lnkHasName, lnkHasRelativePath} {
+		if flags&flag == 0 {
+			continue
+		}
+		text, next, ok := countedString(data, pos, flags&lnkIsUnicode != 0)
+		if !ok {
+			return nil, errors.New("truncated shell link")
+		}
+		if flag == lnkHasRelativePath && text != "" {
+			return &Target{Path: text}, nil
+		}
+		pos = next
+	}
+	return nil, errors.New("no target path in the shell link")
+}
+
+// cString returns the NUL-terminated string at offset in data
+func cString(data []byte, offset uint32) string {
+	if offset == 0 || int(offset) >= len(data) {
+		return ""
+	}
+	s := data[offset:]
+	if end := bytes.IndexByte(s, 0); end >= 0 {
+		s = s[:end]
+	}
+	return string(s)
+}
+
+// countedString reads a string prefixed by its length in characters,
+// returning it and the position after it
+func countedString(data []byte, pos int, unicode bool) (string, int, bool) {
+	if len(data) < pos+2 {
+		return "", pos, false
+	}
+	count := int(binary.LittleEndian.Uint16(data[pos:]))
+	pos += 2
+	if !unicode {
+		if len(data) < pos+count {
+			return "", pos, false
+		}
+		return string(data[pos : pos+count]), pos + count, true
+	}
+	if len(data) < pos+2*count {
+		return "", pos, false
+	}
+	units := make([]uint16, count)
+	for i := range units {
+		units[i] = binary.LittleEndian.Uint16(data[pos+2*i:])
+	}
+	return string(utf16.Decode(units)), pos + 2*count, true
+}
+
+// joinLinkPath joins a base path and a suffix with one backslash
+func joinLinkPath(base, suffix string) string {
+	if suffix == "" || len(base) > 0 && base[len(base)-1] == '\\' {
+		return base + suffix
+	}
+	return base + `\` + suffix
+}
@@ -0,0 +1,121 @@
+package listing
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format is a file format for exported listings
+type Format int
+
+const (
+	Text Format = iota
+	CSV
+	JSON
+)
+
+// FormatNames maps formats to their names, which are also their extensions
+var FormatNames = map[Format]string{
+	Text: "txt",
+	CSV:  "csv",
+	JSON: "json",
+}
+
+// ParseFormat converts a format name ("text" or "txt", "csv", "json")
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(name) {
+	case "text", "txt":
+		return Text, nil
+	case "csv":
+		return CSV, nil
+	case "json":
+		return JSON, nil
+	}
+	return Text, fmt.Errorf("unknown listing format %q (use text, csv or json)", name)
+}
+
+// Entry is one exported file
+type Entry struct {
+	Name        string    `json:"name"`
+	Type        string    `json:"type"`
+	Size        int64     `json:"size"`
+	Modified    time.Time `json:"modified"`
+	Permissions string    `json:"permissions"`
+}
+
+// entryType names the kind of file behind mode
+func entryType(mode os.FileMode) string {
+	switch {
+	case mode.IsDir():
+		return "dir"
+	case mode&os.ModeSymlink != 0:
+		return "symlink"
+	case mode.IsRegular():
+		return "file"
+	default:
+		return "other"
+	}
+}
+
+// Entries converts file infos to exported entries, in the same order
+func Entries(files []os.FileInfo) []Entry {
+	entries := make([]Entry, len(files))
+	for i, f := range files {
+		entries[i] = Entry{
+			Name:        f.Name(),
+			Type:        entryType(f.Mode()),
+			Size:        f.Size(),
+			Modified:    f.ModTime().Truncate(time.Second),
+			Permissions: f.Mode().String(),
+		}
+	}
+	return entries
+}
+
+// Write writes the listing of dir to w in the given format. Text is an
+// aligned table headed by the directory, like ls -l; CSV has a header row;
+// JSON is an object with the directory and its entries.
+func Write(w io.Writer, dir string, files []os.FileInfo, format Format) error {
+	entries := Entries(files)
+	switch format {
+	case CSV:
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"name", "type", "size", "modified", "permissions"})
+		for _, e := range entries {
+			cw.Write([]string{e.Name, e.Type, strconv.FormatInt(e.Size, 10), e.Modified.Format(time.RFC3339), e.Permissions})
+		}
+		cw.Flush()
+		return cw.Error()
+
+	case JSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			Dir     string  `json:"dir"`
+			Entries []Entry `json:"entries"`
+		}{dir, entries})
+
+	default:
+		sizeWidth := 1
+		for _, e := range entries {
+			sizeWidth = max(sizeWidth, len(strconv.FormatInt(e.Size, 10)))
+		}
+		fmt.Fprintf(w, "%s\n\n", dir)
+		for _, e := range entries {
+			name := e.Name
+			if e.Type == "dir" {
+				name += "/"
+			}
+			if _, err := fmt.Fprintf(w, "%s  %*d  %s  %s\n", e.Permissions, sizeWidth, e.Size, e.Modified.Format("2006-01-02 15:04"), name); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
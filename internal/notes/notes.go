@@ -0,0 +1,83 @@
+package notes
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileName is the note Xplorer keeps for a directory
+const FileName = ".xp_notes.md"
+
+// readmeNames are the READMEs shown when a directory has no note, best first
+var readmeNames = []string{"readme.md", "readme", "readme.txt", "readme.rst"}
+
+// maxTitleBytes limits how much of a note is read to find its title
+const maxTitleBytes = 4096
+
+// Find returns the note of dir: its .xp_notes.md or else its README,
+// matched case-insensitively. It returns "" if there is neither.
+func Find(dir string) string {
+	if info, err := os.Stat(filepath.Join(dir, FileName)); err == nil && info.Mode().IsRegular() {
+		return filepath.Join(dir, FileName)
+	}
+	f, err := os.Open(dir)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	names, _ := f.Readdirnames(-1)
+
+	best := len(readmeNames)
+	found := ""
+	for _, name := range names {
+		for i, readme := range readmeNames[:best] {
+			if strings.EqualFold(name, readme) {
+				best, found = i, name
+				break
+			}
+		}
+	}
+	if found == "" {
+		return ""
+	}
+	return filepath.Join(dir, found)
+}
+
+// Title returns the first non-empty line of a note, without Markdown
+// heading marks
+func Title(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(io.LimitReader(f, maxTitleBytes))
+	for scanner.Scan() {
+		line := strings.TrimSpace(strings.TrimLeft(scanner.Text(), "#="))
+		if line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// Create writes a new note for dir headed by the directory name, unless it
+// already has one, and returns its path
+func Create(dir string) (string, error) {
+	path := filepath.Join(dir, FileName)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if os.IsExist(err) {
+		return path, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	_, err = f.WriteString("# " + filepath.Base(dir) + "\n\n")
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return path, err
+}
@@ -0,0 +1,248 @@
+package openwith
+
+import (
+	"bufio"
+	"bytes"
+	"mime"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/alexcostache/Xplorer/internal/config"
+)
+
+// MimeType returns the MIME type of a file from its extension, falling back
+// to sniffing the first bytes of its content
+func MimeType(path string) string {
+	if t := mime.TypeByExtension(filepath.Ext(path)); t != "" {
+		return baseMimeType(t)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	buf := make([]byte, 512)
+	n, _ := f.Read(buf)
+	if n == 0 {
+		return "text/plain"
+	}
+	return baseMimeType(http.DetectContentType(buf[:n]))
+}
+
+// baseMimeType strips parameters such as "; charset=utf-8"
+func baseMimeType(t string) string {
+	if i := strings.IndexByte(t, ';'); i >= 0 {
+		t = t[:i]
+	}
+	return strings.TrimSpace(t)
+}
+
+// SystemApps returns the installed applications registered for the file's
+// type: .desktop entries on Linux, Launch Services on macOS
+func SystemApps(path string) []config.EditorOption {
+	switch runtime.GOOS {
+	case "darwin":
+		return launchServicesApps(path)
+	case "windows":
+		return nil
+	default:
+		return DesktopApps(MimeType(path), dataDirs())
+	}
+}
+
+// dataDirs returns the XDG data directories in order of precedence
+func dataDirs() []string {
+	home := os.Getenv("XDG_DATA_HOME")
+	if home == "" {
+		if userHome, err := os.UserHomeDir(); err == nil {
+			home = filepath.Join(userHome, ".local", "share")
+		}
+	}
+	system := os.Getenv("XDG_DATA_DIRS")
+	if system == "" {
+		system = "/usr/local/share:/usr/share"
+	}
+	var dirs []string
+	if home != "" {
+		dirs = append(dirs, home)
+	}
+	return append(dirs, filepath.SplitList(system)...)
+}
+
+// DesktopApps returns the applications from the .desktop files under
+// <dir>/applications that declare support for mimeType, sorted by name.
+// Earlier directories override entries with the same desktop file ID.
+func DesktopApps(mimeType string, dirs []string) []config.EditorOption {
+	if mimeType == "" {
+		return nil
+	}
+	seen := make(map[string]bool)
+	commands := make(map[string]bool)
+	var apps []config.EditorOption
+
+	for _, dir := range dirs {
+		root := filepath.Join(dir, "applications")
+		_ = filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+			if err != nil || d.IsDir() || !strings.HasSuffix(path, ".desktop") {
+				return nil
+			}
+			rel, _ := filepath.Rel(root, path)
+			id := strings.ReplaceAll(rel, string(filepath.Separator), "-")
+			if seen[id] {
+				return nil
+			}
+			seen[id] = true
+
+			entry := parseDesktopEntry(path)
+			if entry == nil || !entry.handles(mimeType) {
+				return nil
+			}
+			command := entry.command()
+			if command == "" || commands[command] {
+				return nil
+			}
+			commands[command] = true
+			description := entry.comment
+			if description == "" {
+				description = "Installed application"
+			}
+			apps = append(apps, config.EditorOption{
+				Name:        entry.name,
+				Command:     command,
+				IsTerminal:  entry.terminal,
+				Description: description,
+			})
+			return nil
+		})
+	}
+
+	sort.SliceStable(apps, func(i, j int) bool {
+		return strings.ToLower(apps[i].Name) < strings.ToLower(apps[j].Name)
+	})
+	return apps
+}
+
+// desktopEntry holds the fields of a .desktop file used for Open With
+type desktopEntry struct {
+	name      string
+	comment   string
+	exec      string
+	mimeTypes []string
+	terminal  bool
+	hidden    bool
+}
+
+// parseDesktopEntry reads the [Desktop Entry] group of a .desktop file
+func parseDesktopEntry(path string) *desktopEntry {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	entry := &desktopEntry{}
+	inGroup := false
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			inGroup = line == "[Desktop Entry]"
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !inGroup || !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(key) {
+		case "Name":
+			entry.name = value
+		case "Comment":
+			entry.comment = value
+		case "Exec":
+			entry.exec = value
+		case "MimeType":
+			entry.mimeTypes = strings.Split(strings.TrimSuffix(value, ";"), ";")
+		case "Terminal":
+			entry.terminal = value == "true"
+		case "Hidden":
+			entry.hidden = value == "true"
+		case "Type":
+			if value != "Application" {
+				entry.hidden = true
+			}
+		}
+	}
+	if entry.hidden || entry.name == "" || entry.exec == "" {
+		return nil
+	}
+	return entry
+}
+
+// handles reports whether the entry declares mimeType. Text editors usually
+// only declare text/plain, so they are offered for every text/* type.
+func (e *desktopEntry) handles(mimeType string) bool {
+	for _, t := range e.mimeTypes {
+		if t == mimeType || (t == "text/plain" && strings.HasPrefix(mimeType, "text/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// command returns the Exec line without field codes; the file path is
+// appended when the command is run
+func (e *desktopEntry) command() string {
+	var parts []string
+	for _, field := range strings.Fields(e.exec) {
+		if len(field) == 2 && field[0] == '%' {
+			continue // %f, %F, %u, %U, %i, %c, %k ...
+		}
+		parts = append(parts, strings.Trim(field, `"`))
+	}
+	return strings.Join(parts, " ")
+}
+
+// launchServicesScript asks Launch Services (through the JavaScript for
+// Automation bridge to NSWorkspace) for the applications that open a file
+const launchServicesScript = `ObjC.import('AppKit');
+function run(argv) {
+	var urls = $.NSWorkspace.sharedWorkspace.URLsForApplicationsToOpenURL($.NSURL.fileURLWithPath(argv[0]));
+	var apps = [];
+	for (var i = 0; i < urls.count; i++) {
+		var url = urls.objectAtIndex(i);
+		var id = $.NSBundle.bundleWithURL(url).bundleIdentifier;
+		if (id.isNil()) continue;
+		apps.push(id.js + '\t' + url.path.js);
+	}
+	return apps.join('\n');
+}`
+
+// launchServicesApps returns the applications Launch Services offers for a file (macOS)
+func launchServicesApps(path string) []config.EditorOption {
+	out, err := exec.Command("osascript", "-l", "JavaScript", "-e", launchServicesScript, path).Output()
+	if err != nil {
+		return nil
+	}
+	var apps []config.EditorOption
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		// "bundle.id<TAB>/Applications/Name.app"; apps are opened by bundle ID
+		// because their paths may contain spaces
+		bundleID, appPath, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		apps = append(apps, config.EditorOption{
+			Name:        strings.TrimSuffix(filepath.Base(appPath), ".app"),
+			Command:     "open -b " + bundleID,
+			Description: "Installed application",
+		})
+	}
+	return apps
+}
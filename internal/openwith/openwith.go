@@ -0,0 +1,119 @@
+package openwith
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alexcostache/Xplorer/internal/paths"
+)
+
+// choices is the on-disk format of the open-with file
+type choices struct {
+	Last     map[string]string `json:"last"`     // Extension -> last command chosen in the Open With popup
+	Handlers map[string]string `json:"handlers"` // Extension -> command used without asking
+}
+
+// Manager remembers which command files of each type were opened with
+type Manager struct {
+	choices  choices
+	defaults map[string]string // Handlers from the config file, by lowercase key
+}
+
+// NewManager creates a new open-with manager
+func NewManager() *Manager {
+	m := &Manager{
+		choices: choices{
+			Last:     map[string]string{},
+			Handlers: map[string]string{},
+		},
+	}
+	m.Load()
+	return m
+}
+
+// Key returns the file type key for a path: its lowercase extension, or the
+// lowercase file name for files without one (Makefile, Dockerfile)
+func Key(path string) string {
+	name := strings.ToLower(filepath.Base(path))
+	if ext := filepath.Ext(name); ext != "" && ext != name {
+		return ext
+	}
+	return name
+}
+
+// Last returns the command last chosen for files like path, if any
+func (m *Manager) Last(path string) string {
+	return m.choices.Last[Key(path)]
+}
+
+// Remember records command as the last choice for files like path
+func (m *Manager) Remember(path, command string) {
+	key := Key(path)
+	if m.choices.Last[key] == command {
+		return
+	}
+	m.choices.Last[key] = command
+	m.Save()
+}
+
+// Handler returns the permanent handler for files like path, if any: the
+// one chosen in the Open With popup, or else the one from the config file
+func (m *Manager) Handler(path string) string {
+	key := Key(path)
+	if command := m.choices.Handlers[key]; command != "" {
+		return command
+	}
+	if command := m.defaults[key]; command != "" {
+		return command
+	}
+	return m.defaults[strings.TrimPrefix(key, ".")]
+}
+
+// SetDefaults sets the handlers configured per type, keyed by extension
+// (".pdf" or "pdf") or by the name of files without one ("Makefile")
+func (m *Manager) SetDefaults(defaults map[string]string) {
+	m.defaults = make(map[string]string, len(defaults))
+	for key, command := range defaults {
+		m.defaults[strings.ToLower(key)] = command
+	}
+}
+
+// SetHandler makes command the permanent handler for files like path.
+// An empty command removes the handler.
+func (m *Manager) SetHandler(path, command string) {
+	key := Key(path)
+	if command == "" {
+		delete(m.choices.Handlers, key)
+	} else {
+		m.choices.Handlers[key] = command
+	}
+	m.Save()
+}
+
+// getOpenWithFile returns the path to the open-with file
+func (m *Manager) getOpenWithFile() string {
+	return paths.File(".xp_open_with.json")
+}
+
+// Load loads remembered choices from disk
+func (m *Manager) Load() {
+	data, err := os.ReadFile(m.getOpenWithFile())
+	if err != nil {
+		return // File doesn't exist yet, that's ok
+	}
+	_ = json.Unmarshal(data, &m.choices)
+	if m.choices.Last == nil {
+		m.choices.Last = map[string]string{}
+	}
+	if m.choices.Handlers == nil {
+		m.choices.Handlers = map[string]string{}
+	}
+}
+
+// Save saves remembered choices to disk
+func (m *Manager) Save() {
+	data, _ := json.MarshalIndent(m.choices, "", "  ")
+	_ = os.WriteFile(m.getOpenWithFile(), data, 0644)
+}
@@ -0,0 +1,159 @@
+package openwith
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/alexcostache/Xplorer/internal/config"
+)
+
+// SystemDefault is the Open With command that hands a file to the desktop,
+// which opens it with the application associated with its type
+const SystemDefault = "__DEFAULT__"
+
+// DefaultOption returns the Open With entry for the desktop's default
+// application, named after that application where it can be found
+func DefaultOption(path string) config.EditorOption {
+	option := config.EditorOption{Name: "System Default", Command: SystemDefault}
+	mimeType := MimeType(path)
+	if runtime.GOOS != "darwin" && runtime.GOOS != "windows" {
+		if app := DefaultDesktopApp(mimeType, configDirs(), dataDirs()); app != "" {
+			option.Name += " (" + app + ")"
+		}
+	}
+	option.Description = "Open with " + DefaultCommand(path).Args[0]
+	if mimeType != "" {
+		option.Description += " as " + mimeType
+	}
+	return option
+}
+
+// DefaultCommand returns the command that opens path with the desktop's
+// default application: open on macOS, start on Windows, xdg-open elsewhere
+func DefaultCommand(path string) *exec.Cmd {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("open", path)
+	case "windows":
+		// The empty argument is the window title start expects before a
+		// quoted path
+		return exec.Command("cmd", "/c", "start", "", path)
+	}
+	return exec.Command("xdg-open", path)
+}
+
+// configDirs returns the XDG config directories in order of precedence
+func configDirs() []string {
+	home := os.Getenv("XDG_CONFIG_HOME")
+	if home == "" {
+		if userHome, err := os.UserHomeDir(); err == nil {
+			home = filepath.Join(userHome, ".config")
+		}
+	}
+	system := os.Getenv("XDG_CONFIG_DIRS")
+	if system == "" {
+		system = "/etc/xdg"
+	}
+	var dirs []string
+	if home != "" {
+		dirs = append(dirs, home)
+	}
+	return append(dirs, filepath.SplitList(system)...)
+}
+
+// DefaultDesktopApp returns the name of the application xdg-open uses for
+// mimeType: the first installed entry listed under [Default Applications]
+// in the mimeapps.list files of configDirs, then in those (and the older
+// defaults.list) under <dir>/applications of dataDirs. It returns "" when
+// no default is set.
+func DefaultDesktopApp(mimeType string, configDirs, dataDirs []string) string {
+	if mimeType == "" {
+		return ""
+	}
+	var lists []string
+	for _, dir := range configDirs {
+		lists = append(lists, filepath.Join(dir, "mimeapps.list"))
+	}
+	for _, dir := range dataDirs {
+		lists = append(lists,
+			filepath.Join(dir, "applications", "mimeapps.list"),
+			filepath.Join(dir, "applications", "defaults.list"))
+	}
+
+	for _, list := range lists {
+		for _, id := range defaultIDs(list, mimeType) {
+			for _, dir := range dataDirs {
+				if entry := findDesktopEntry(filepath.Join(dir, "applications"), id); entry != nil {
+					return entry.name
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// defaultIDs returns the desktop file IDs a mimeapps.list file names as
+// defaults for mimeType
+func defaultIDs(list, mimeType string) []string {
+	data, err := os.ReadFile(list)
+	if err != nil {
+		return nil
+	}
+	inGroup := false
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			inGroup = line == "[Default Applications]"
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if inGroup && ok && strings.TrimSpace(key) == mimeType {
+			return strings.FieldsFunc(value, func(r rune) bool { return r == ';' || r == ' ' })
+		}
+	}
+	return nil
+}
+
+// findDesktopEntry parses the desktop file with the given ID under root.
+// IDs map "-" to subdirectories, so "kde-okular.desktop" may be
+// kde/okular.desktop.
+func findDesktopEntry(root, id string) *desktopEntry {
+	if entry := parseDesktopEntry(filepath.Join(root, id)); entry != nil {
+		return entry
+	}
+	if prefix, rest, ok := strings.Cut(id, "-"); ok {
+		return findDesktopEntry(filepath.Join(root, prefix), rest)
+	}
+	return nil
+}
+
+// Expand builds the arguments of a command line for file: %f stands for
+// the file, %d for the directory and %s for the selected files, one
+// argument each when %s is a word of its own. %% is a literal %. A command
+// without placeholders gets the file as its last argument.
+func Expand(command, file, dir string, selection []string) []string {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return nil
+	}
+	replacer := strings.NewReplacer("%%", "%", "%f", file, "%d", dir, "%s", strings.Join(selection, " "))
+	var args []string
+	for _, field := range fields {
+		if field == "%s" {
+			args = append(args, selection...)
+			continue
+		}
+		args = append(args, replacer.Replace(field))
+	}
+	placeholders := strings.ReplaceAll(command, "%%", "")
+	if !strings.Contains(placeholders, "%f") && !strings.Contains(placeholders, "%d") && !strings.Contains(placeholders, "%s") {
+		args = append(args, file)
+	}
+	return args
+}
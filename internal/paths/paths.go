@@ -0,0 +1,108 @@
+package paths
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+)
+
+// ProfileEnvVar selects a profile when --profile is not given
+const ProfileEnvVar = "XP_PROFILE"
+
+// profile is the active profile name; "" is the default profile
+var profile string
+
+// baseDir replaces the home directory in portable mode
+var baseDir string
+
+// SetPortable keeps all state in dir instead of the home directory. An empty
+// dir selects the directory containing the executable.
+func SetPortable(dir string) error {
+	if dir == "" {
+		exe, err := os.Executable()
+		if err != nil {
+			return err
+		}
+		if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+			exe = resolved
+		}
+		dir = filepath.Dir(exe)
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	baseDir = abs
+	return nil
+}
+
+// Reset goes back to the default profile in the home directory, undoing
+// SetPortable and SetProfile
+func Reset() {
+	baseDir, profile = "", ""
+}
+
+// IsPortable reports whether portable mode is active
+func IsPortable() bool {
+	return baseDir != ""
+}
+
+// SetProfile selects the named profile for all state files
+func SetProfile(name string) error {
+	if name != "" && (strings.ContainsAny(name, `/\`) || name == "." || name == "..") {
+		return fmt.Errorf("invalid profile name %q", name)
+	}
+	profile = name
+	return nil
+}
+
+// Profile returns the active profile name, or "" for the default profile
+func Profile() string {
+	return profile
+}
+
+// Dir returns the directory holding state files: the home directory (or the
+// portable directory) for the default profile, or .xp_profiles/<name> below it
+// for a named profile
+func Dir() string {
+	home := baseDir
+	if home == "" {
+		if usr, err := user.Current(); err == nil {
+			home = usr.HomeDir
+		}
+	}
+	if profile == "" {
+		return home
+	}
+	return filepath.Join(home, ".xp_profiles", profile)
+}
+
+// EnsureDir creates the state directory if it does not exist yet
+func EnsureDir() error {
+	return os.MkdirAll(Dir(), 0755)
+}
+
+// File returns the path of a state file such as ".xp_config.json"
+func File(name string) string {
+	return filepath.Join(Dir(), name)
+}
+
+// ThemesDir returns the directory holding theme files. Outside portable mode
+// themes are read from ./themes, as they always have been.
+func ThemesDir() string {
+	if baseDir == "" {
+		return "themes"
+	}
+	return filepath.Join(baseDir, "themes")
+}
+
+// CacheDir returns the directory for cached data such as thumbnails, or ""
+// to use the user cache directory
+func CacheDir() string {
+	if baseDir == "" {
+		return ""
+	}
+	return filepath.Join(baseDir, "cache")
+}
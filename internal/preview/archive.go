@@ -0,0 +1,189 @@
+package preview
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// maxArchiveScan limits how much of a tar archive is read for its entries;
+// a zip archive lists them all in its central directory
+const maxArchiveScan = 64 << 20
+
+// archiveNode is a file or folder of an archive's tree
+type archiveNode struct {
+	name     string
+	size     int64
+	dir      bool
+	children map[string]*archiveNode
+}
+
+// IsArchiveFile reports whether name is an archive previewed as the tree
+// of its entries
+func IsArchiveFile(name string) bool {
+	return archiveKind(name) != ""
+}
+
+// archiveKind returns "zip", "tar" or "tar.gz" for the archives previewed,
+// or ""
+func archiveKind(name string) string {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return "zip"
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return "tar.gz"
+	case strings.HasSuffix(lower, ".tar"):
+		return "tar"
+	}
+	return ""
+}
+
+// archiveHandler names how the entries of an archive are found
+func archiveHandler(name string) string {
+	if archiveKind(name) == "zip" {
+		return "Archive contents (zip directory)"
+	}
+	return fmt.Sprintf("Archive contents (%s headers in the first %s)", archiveKind(name), formatSize(maxArchiveScan))
+}
+
+// archiveLines describes an archive by its counts and sizes, followed by
+// the tree of its entries cut to maxLines lines (0 for all)
+func archiveLines(name string, maxLines int) ([]string, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	root := &archiveNode{dir: true}
+	more := false
+	if archiveKind(name) == "zip" {
+		archive, err := zip.NewReader(file, stat.Size())
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range archive.File {
+			addArchiveEntry(root, entry.Name, int64(entry.UncompressedSize64), entry.FileInfo().IsDir())
+		}
+	} else {
+		var r io.Reader = io.LimitReader(file, maxArchiveScan)
+		if archiveKind(name) == "tar.gz" {
+			gz, err := gzip.NewReader(r)
+			if err != nil {
+				return nil, err
+			}
+			defer gz.Close()
+			r = gz
+		}
+		archive := tar.NewReader(r)
+		for {
+			header, err := archive.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				// An entry cut off at the scan limit is no reason to give up
+				if stat.Size() > maxArchiveScan && len(root.children) > 0 {
+					more = true
+					break
+				}
+				return nil, err
+			}
+			addArchiveEntry(root, header.Name, header.Size, header.Typeflag == tar.TypeDir)
+		}
+	}
+	if len(root.children) == 0 {
+		return nil, errors.New("empty archive")
+	}
+
+	files, folders, size := root.count()
+	plus := ""
+	if more {
+		plus = "+"
+	}
+	lines := []string{
+		fmt.Sprintf("[Archive: %d%s files, %d%s folders, %s%s unpacked (%s packed)]", files, plus, folders, plus, formatSize(size), plus, formatSize(stat.Size())),
+		"",
+	}
+	root.lines(&lines, "", maxLines)
+	return lines, nil
+}
+
+// addArchiveEntry adds an entry to the tree below root, with the folders
+// its path implies
+func addArchiveEntry(root *archiveNode, name string, size int64, dir bool) {
+	name = strings.Trim(path.Clean("/"+filepath.ToSlash(name)), "/")
+	if name == "" {
+		return
+	}
+	node := root
+	parts := strings.Split(name, "/")
+	for i, part := range parts {
+		child := node.children[part]
+		if child == nil {
+			child = &archiveNode{name: part, dir: true}
+			if node.children == nil {
+				node.children = make(map[string]*archiveNode)
+			}
+			node.children[part] = child
+		}
+		if i == len(parts)-1 && !dir {
+			child.dir = false
+			child.size = size
+		}
+		node = child
+	}
+}
+
+// count returns the files, folders and unpacked bytes below n
+func (n *archiveNode) count() (files, folders int, size int64) {
+	for _, child := range n.children {
+		if !child.dir {
+			files++
+			size += child.size
+			continue
+		}
+		f, d, s := child.count()
+		files, folders, size = files+f, folders+d+1, size+s
+	}
+	return files, folders, size
+}
+
+// lines appends the entries below n to out, folders first, each level
+// indented by two spaces, until out holds maxLines lines (0 for no limit)
+func (n *archiveNode) lines(out *[]string, indent string, maxLines int) {
+	children := make([]*archiveNode, 0, len(n.children))
+	for _, child := range n.children {
+		children = append(children, child)
+	}
+	sort.Slice(children, func(i, j int) bool {
+		if children[i].dir != children[j].dir {
+			return children[i].dir
+		}
+		return strings.ToLower(children[i].name) < strings.ToLower(children[j].name)
+	})
+	for _, child := range children {
+		if maxLines > 0 && len(*out) >= maxLines {
+			return
+		}
+		if child.dir {
+			*out = append(*out, indent+child.name+"/")
+			child.lines(out, indent+"  ", maxLines)
+		} else {
+			*out = append(*out, fmt.Sprintf("%s%s  %s", indent, child.name, formatSize(child.size)))
+		}
+	}
+}
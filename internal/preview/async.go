@@ -0,0 +1,150 @@
+package preview
+
+import (
+	"container/list"
+	"os"
+	"sync"
+	"time"
+)
+
+// previewCacheSize bounds the number of previews kept for revisiting
+const previewCacheSize = 32
+
+// previewKey identifies a preview; it changes whenever the file does
+type previewKey struct {
+	path       string
+	size       int64
+	modTime    time.Time
+	showHidden bool
+	maxLines   int
+}
+
+// cachedPreview is an entry of the preview cache
+type cachedPreview struct {
+	key    previewKey
+	result previewResult
+}
+
+// asyncState holds the previews read in the background. The mutex also
+// guards the Manager's limits, which background reads consult.
+type asyncState struct {
+	mu       sync.Mutex
+	recent   *list.List // Of *cachedPreview, most recently used first
+	entries  map[previewKey]*list.Element
+	inflight map[previewKey]chan struct{} // Closed when the read is cached
+	wanted   *previewKey                  // Awaited by the current preview, nil when shown
+}
+
+// lookup returns a cached preview and marks it as recently used; the mutex
+// must be held
+func (s *asyncState) lookup(key previewKey) (previewResult, bool) {
+	element, ok := s.entries[key]
+	if !ok {
+		return previewResult{}, false
+	}
+	s.recent.MoveToFront(element)
+	return element.Value.(*cachedPreview).result, true
+}
+
+// store caches a preview, dropping the least recently used one when full;
+// the mutex must be held
+func (s *asyncState) store(key previewKey, result previewResult) {
+	if s.entries == nil {
+		s.recent = list.New()
+		s.entries = make(map[previewKey]*list.Element)
+	}
+	if element, ok := s.entries[key]; ok {
+		element.Value.(*cachedPreview).result = result
+		s.recent.MoveToFront(element)
+		return
+	}
+	s.entries[key] = s.recent.PushFront(&cachedPreview{key: key, result: result})
+	if s.recent.Len() > previewCacheSize {
+		oldest := s.recent.Back()
+		s.recent.Remove(oldest)
+		delete(s.entries, oldest.Value.(*cachedPreview).key)
+	}
+}
+
+// clear drops the cached previews; the mutex must be held
+func (s *asyncState) clear() {
+	s.recent = nil
+	s.entries = nil
+}
+
+// Request shows the preview of path, whose listing info is used to tell
+// whether a cached preview is still current. A preview not cached is read
+// in the background: if it takes longer than wait, the preview is left
+// empty and done is called (from another goroutine) once Settle can show
+// it. Requests for the same file share one read. With nil info the preview
+// is loaded at once, as by LoadPreview.
+func (m *Manager) Request(path string, info os.FileInfo, showHidden bool, maxLines int, wait time.Duration, done func()) {
+	if info == nil {
+		m.LoadPreview(path, showHidden, maxLines)
+		return
+	}
+	key := previewKey{path, info.Size(), info.ModTime(), showHidden, maxLines}
+
+	s := &m.async
+	s.mu.Lock()
+	if result, ok := s.lookup(key); ok {
+		s.mu.Unlock()
+		s.wanted = nil
+		m.show(result)
+		return
+	}
+	ready, reading := s.inflight[key]
+	if !reading {
+		ready = make(chan struct{})
+		if s.inflight == nil {
+			s.inflight = make(map[previewKey]chan struct{})
+		}
+		s.inflight[key] = ready
+		go func() {
+			result, _ := m.read(path, showHidden, maxLines)
+			s.mu.Lock()
+			s.store(key, result)
+			delete(s.inflight, key)
+			close(ready)
+			s.mu.Unlock()
+			if done != nil {
+				done()
+			}
+		}()
+	}
+	s.mu.Unlock()
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ready:
+		s.wanted = &key
+		m.Settle()
+	case <-timer.C:
+		s.wanted = &key
+		m.show(previewResult{})
+	}
+}
+
+// Settle shows the preview awaited since Request once it has been read,
+// reporting whether it did
+func (m *Manager) Settle() bool {
+	s := &m.async
+	if s.wanted == nil {
+		return false
+	}
+	s.mu.Lock()
+	result, ok := s.lookup(*s.wanted)
+	s.mu.Unlock()
+	if !ok {
+		return false
+	}
+	s.wanted = nil
+	m.show(result)
+	return true
+}
+
+// Loading reports whether the preview shown is still being read
+func (m *Manager) Loading() bool {
+	return m.async.wanted != nil
+}
@@ -0,0 +1,658 @@
+package preview
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// documentWidth is the column at which document paragraphs are wrapped, as
+// the preview panel clips rather than wraps long lines
+const documentWidth = 60
+
+// pdftotextTimeout bounds how long pdftotext may take for one preview
+const pdftotextTimeout = 3 * time.Second
+
+// pdfPages is how many pages of a PDF are converted for the preview
+const pdfPages = 10
+
+// maxPDFRead limits how much of a PDF the built-in extractor reads
+const maxPDFRead = 32 << 20
+
+// errNoText is returned when a document holds no text that can be shown
+var errNoText = errors.New("no text found")
+
+// IsDocumentFile checks if the text of a file can be previewed as a document
+func IsDocumentFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".pdf", ".epub", ".docx", ".odt":
+		return true
+	}
+	return false
+}
+
+// documentHandler names how the text of a document is extracted
+func documentHandler(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".pdf":
+		if _, err := exec.LookPath("pdftotext"); err == nil {
+			return "Document text (pdftotext)"
+		}
+		return "Document text (built-in PDF reader)"
+	case ".epub":
+		return "Document text (EPUB chapters)"
+	}
+	return "Document text (" + strings.TrimPrefix(strings.ToLower(filepath.Ext(name)), ".") + " paragraphs)"
+}
+
+// documentLines extracts the text of a document as wrapped lines, stopping
+// after maxLines (0 for no limit)
+func documentLines(name string, maxLines int) ([]string, error) {
+	var paragraphs []string
+	var err error
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".pdf":
+		paragraphs, err = pdfText(name)
+	case ".epub":
+		paragraphs, err = epubText(name, maxLines)
+	case ".docx":
+		paragraphs, err = zipXMLText(name, "word/document.xml", docxParagraphs)
+	case ".odt":
+		paragraphs, err = zipXMLText(name, "content.xml", odtParagraphs)
+	default:
+		return nil, errNoText
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	for _, p := range paragraphs {
+		if strings.TrimSpace(p) == "" {
+			// Keep one blank line between paragraphs
+			if len(lines) > 0 && lines[len(lines)-1] != "" {
+				lines = append(lines, "")
+			}
+			continue
+		}
+		lines = append(lines, wrapText(p, documentWidth)...)
+		if maxLines > 0 && len(lines) >= maxLines {
+			return lines[:maxLines], nil
+		}
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		return nil, errNoText
+	}
+	return lines, nil
+}
+
+// wrapText breaks a paragraph into lines at most width columns wide,
+// between words where possible
+func wrapText(text string, width int) []string {
+	var lines []string
+	var line strings.Builder
+	lineWidth := 0
+	for _, word := range strings.Fields(text) {
+		wordWidth := 0
+		for _, r := range word {
+			wordWidth += RuneWidth(r)
+		}
+		if lineWidth > 0 && lineWidth+1+wordWidth > width {
+			lines = append(lines, line.String())
+			line.Reset()
+			lineWidth = 0
+		}
+		// Words longer than a line are split
+		for wordWidth > width {
+			cut, cutWidth := 0, 0
+			for i, r := range word {
+				if cutWidth+RuneWidth(r) > width {
+					cut = i
+					break
+				}
+				cutWidth += RuneWidth(r)
+			}
+			lines = append(lines, word[:cut])
+			word, wordWidth = word[cut:], wordWidth-cutWidth
+		}
+		if lineWidth > 0 {
+			line.WriteByte(' ')
+			lineWidth++
+		}
+		line.WriteString(word)
+		lineWidth += wordWidth
+	}
+	if lineWidth > 0 {
+		lines = append(lines, line.String())
+	}
+	return lines
+}
+
+// pdfText returns the text of the first pages of a PDF, from pdftotext when
+// it is installed and the built-in extractor otherwise
+func pdfText(name string) ([]string, error) {
+	if _, err := exec.LookPath("pdftotext"); err == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), pdftotextTimeout)
+		defer cancel()
+		out, err := exec.CommandContext(ctx, "pdftotext", "-l", strconv.Itoa(pdfPages), "-enc", "UTF-8", name, "-").Output()
+		if err == nil {
+			return textParagraphs(string(out)), nil
+		}
+	}
+
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, maxPDFRead))
+	if err != nil {
+		return nil, err
+	}
+	return pdfContentText(data), nil
+}
+
+// textParagraphs splits plain text into paragraphs at blank lines and form
+// feeds, joining the lines of each paragraph
+func textParagraphs(text string) []string {
+	var paragraphs []string
+	var current []string
+	flush := func() {
+		if len(current) > 0 {
+			paragraphs = append(paragraphs, strings.Join(current, " "), "")
+			current = nil
+		}
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\f", "\n\n"), "\n") {
+		if line = strings.TrimSpace(line); line == "" {
+			flush()
+			continue
+		}
+		current = append(current, line)
+	}
+	flush()
+	return paragraphs
+}
+
+// pdfContentText pulls the strings shown by the text operators of a PDF's
+// page content streams. Fonts with their own encodings (most CID fonts)
+// come out unreadable and are left out, so this covers simple PDFs only.
+func pdfContentText(data []byte) []string {
+	var paragraphs []string
+	for offset := 0; ; {
+		start := bytes.Index(data[offset:], []byte("stream"))
+		if start < 0 {
+			break
+		}
+		start += offset
+		offset = start + len("stream")
+
+		// The keyword must follow a dictionary and end its line
+		before := bytes.TrimRight(data[:start], " \t\r\n")
+		if !bytes.HasSuffix(before, []byte(">>")) {
+			continue
+		}
+		dictEnd := len(before) - 2
+		body := offset
+		if bytes.HasPrefix(data[body:], []byte("\r\n")) {
+			body += 2
+		} else if body < len(data) && data[body] == '\n' {
+			body++
+		} else {
+			continue
+		}
+		end := bytes.Index(data[body:], []byte("endstream"))
+		if end < 0 {
+			break
+		}
+		content := data[body : body+end]
+		offset = body + end
+
+		dictStart := bytes.LastIndex(data[:dictEnd], []byte("obj"))
+		if dictStart < 0 {
+			dictStart = 0
+		}
+		dict := data[dictStart:dictEnd]
+		if bytes.Contains(dict, []byte("/Subtype")) || bytes.Contains(dict, []byte("/Type")) || bytes.Contains(dict, []byte("/Length1")) {
+			continue // Images, fonts, object and xref streams
+		}
+		if bytes.Contains(dict, []byte("/FlateDecode")) {
+			r, err := zlib.NewReader(bytes.NewReader(content))
+			if err != nil {
+				continue
+			}
+			content, _ = io.ReadAll(io.LimitReader(r, maxPDFRead)) // Keep what decodes
+		} else if bytes.Contains(dict, []byte("/Filter")) {
+			continue
+		}
+		if !bytes.Contains(content, []byte("BT")) {
+			continue
+		}
+		paragraphs = append(paragraphs, pdfTextOperators(content)...)
+	}
+
+	// Leave out text that is mostly not printable
+	var readable []string
+	for _, p := range paragraphs {
+		printable := 0
+		for _, r := range p {
+			if unicode.IsPrint(r) {
+				printable++
+			}
+		}
+		if printable*10 >= len([]rune(p))*9 {
+			readable = append(readable, p)
+		}
+	}
+	return readable
+}
+
+// pdfTextOperators returns the lines of text shown by one content stream
+func pdfTextOperators(content []byte) []string {
+	var lines []string
+	var line strings.Builder
+	var operands []string
+	inArray := false
+	var array strings.Builder
+	newLine := func() {
+		if text := strings.TrimSpace(line.String()); text != "" {
+			lines = append(lines, text)
+		}
+		line.Reset()
+	}
+
+	for i := 0; i < len(content); {
+		c := content[i]
+		switch {
+		case c == '(':
+			s, next := pdfLiteralString(content, i)
+			if inArray {
+				array.WriteString(s)
+			} else {
+				operands = append(operands, s)
+			}
+			i = next
+		case c == '<' && i+1 < len(content) && content[i+1] != '<':
+			end := bytes.IndexByte(content[i:], '>')
+			if end < 0 {
+				i = len(content)
+				break
+			}
+			s := pdfHexString(content[i+1 : i+end])
+			if inArray {
+				array.WriteString(s)
+			} else {
+				operands = append(operands, s)
+			}
+			i += end + 1
+		case c == '[':
+			inArray = true
+			array.Reset()
+			i++
+		case c == ']':
+			inArray = false
+			operands = append(operands, array.String())
+			i++
+		case c == '%':
+			for i < len(content) && content[i] != '\n' && content[i] != '\r' {
+				i++
+			}
+		case c == '-' || c == '.' || (c >= '0' && c <= '9'):
+			start := i
+			for i < len(content) && (content[i] == '-' || content[i] == '.' || (content[i] >= '0' && content[i] <= '9')) {
+				i++
+			}
+			// Wide negative kerning inside TJ arrays stands for a space
+			if inArray {
+				if n, err := strconv.ParseFloat(string(content[start:i]), 64); err == nil && n < -200 {
+					array.WriteByte(' ')
+				}
+			}
+		case c == '/' || unicode.IsLetter(rune(c)) || c == '\'' || c == '"' || c == '*':
+			start := i
+			i++
+			for i < len(content) && (unicode.IsLetter(rune(content[i])) || content[i] == '*' || (c == '/' && content[i] >= '0' && content[i] <= '9')) {
+				i++
+			}
+			if c == '/' {
+				break // A name, e.g. a font
+			}
+			switch string(content[start:i]) {
+			case "Tj", "TJ":
+				if len(operands) > 0 {
+					line.WriteString(operands[len(operands)-1])
+				}
+			case "'", "\"":
+				newLine()
+				if len(operands) > 0 {
+					line.WriteString(operands[len(operands)-1])
+				}
+			case "Td", "TD", "T*", "Tm", "ET":
+				newLine()
+			}
+			operands = operands[:0]
+		default:
+			i++
+		}
+	}
+	newLine()
+	return lines
+}
+
+// pdfLiteralString decodes the (parenthesized) string starting at i and
+// returns it with the index after it. Bytes are read as Latin-1.
+func pdfLiteralString(content []byte, i int) (string, int) {
+	var s strings.Builder
+	depth := 0
+	for i++; i < len(content); i++ {
+		c := content[i]
+		switch c {
+		case '\\':
+			i++
+			if i >= len(content) {
+				return s.String(), i
+			}
+			switch e := content[i]; e {
+			case 'n':
+				s.WriteByte('\n')
+			case 't':
+				s.WriteByte(' ')
+			case 'r', 'b', 'f', '\n', '\r':
+			default:
+				if e >= '0' && e <= '7' {
+					n, j := 0, i
+					for ; j < len(content) && j < i+3 && content[j] >= '0' && content[j] <= '7'; j++ {
+						n = n*8 + int(content[j]-'0')
+					}
+					s.WriteRune(rune(n & 0xFF))
+					i = j - 1
+				} else {
+					s.WriteRune(rune(e))
+				}
+			}
+		case '(':
+			depth++
+			s.WriteByte(c)
+		case ')':
+			if depth == 0 {
+				return s.String(), i + 1
+			}
+			depth--
+			s.WriteByte(c)
+		default:
+			s.WriteRune(rune(c))
+		}
+	}
+	return s.String(), i
+}
+
+// pdfHexString decodes a <hex> string, reading bytes as Latin-1
+func pdfHexString(hex []byte) string {
+	var digits []byte
+	for _, c := range hex {
+		if _, err := strconv.ParseUint(string(c), 16, 8); err == nil {
+			digits = append(digits, c)
+		}
+	}
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	var s strings.Builder
+	for i := 0; i < len(digits); i += 2 {
+		n, _ := strconv.ParseUint(string(digits[i:i+2]), 16, 8)
+		s.WriteRune(rune(n))
+	}
+	return s.String()
+}
+
+// zipXMLText reads one XML part of a zipped document and returns its
+// paragraphs as the given reader finds them
+func zipXMLText(name, part string, paragraphs func(io.Reader) ([]string, error)) ([]string, error) {
+	archive, err := zip.OpenReader(name)
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+	file, err := archive.Open(part)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return paragraphs(file)
+}
+
+// docxParagraphs returns the paragraphs of a Word document.xml part
+func docxParagraphs(r io.Reader) ([]string, error) {
+	var paragraphs []string
+	var current strings.Builder
+	inText := false
+	decoder := xml.NewDecoder(r)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return paragraphs, nil // Keep what was read
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				current.WriteByte(' ')
+			case "br", "cr":
+				paragraphs = append(paragraphs, current.String())
+				current.Reset()
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				paragraphs = append(paragraphs, current.String(), "")
+				current.Reset()
+			}
+		case xml.CharData:
+			if inText {
+				current.Write(t)
+			}
+		}
+	}
+	return paragraphs, nil
+}
+
+// odtParagraphs returns the paragraphs and headings of an OpenDocument
+// content.xml part
+func odtParagraphs(r io.Reader) ([]string, error) {
+	var paragraphs []string
+	var current strings.Builder
+	depth := 0 // Nesting of text:p and text:h
+	decoder := xml.NewDecoder(r)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return paragraphs, nil
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "p", "h":
+				depth++
+			case "s":
+				count := 1
+				for _, attr := range t.Attr {
+					if attr.Name.Local == "c" {
+						if n, err := strconv.Atoi(attr.Value); err == nil && n > 0 {
+							count = n
+						}
+					}
+				}
+				current.WriteString(strings.Repeat(" ", count))
+			case "tab":
+				current.WriteByte(' ')
+			case "line-break":
+				paragraphs = append(paragraphs, current.String())
+				current.Reset()
+			}
+		case xml.EndElement:
+			if t.Name.Local == "p" || t.Name.Local == "h" {
+				if depth--; depth == 0 {
+					paragraphs = append(paragraphs, current.String(), "")
+					current.Reset()
+				}
+			}
+		case xml.CharData:
+			if depth > 0 {
+				current.Write(t)
+			}
+		}
+	}
+	return paragraphs, nil
+}
+
+// epubText returns the paragraphs of an EPUB's chapters in reading order,
+// stopping once about maxLines lines of text were found
+func epubText(name string, maxLines int) ([]string, error) {
+	archive, err := zip.OpenReader(name)
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+
+	var container struct {
+		Rootfiles []struct {
+			Path string `xml:"full-path,attr"`
+		} `xml:"rootfiles>rootfile"`
+	}
+	if err := decodeZipXML(archive, "META-INF/container.xml", &container); err != nil {
+		return nil, err
+	}
+	if len(container.Rootfiles) == 0 {
+		return nil, errors.New("no package document in container.xml")
+	}
+	opfPath := container.Rootfiles[0].Path
+
+	var pkg struct {
+		Items []struct {
+			ID   string `xml:"id,attr"`
+			Href string `xml:"href,attr"`
+		} `xml:"manifest>item"`
+		Spine []struct {
+			IDRef string `xml:"idref,attr"`
+		} `xml:"spine>itemref"`
+	}
+	if err := decodeZipXML(archive, opfPath, &pkg); err != nil {
+		return nil, err
+	}
+	hrefs := make(map[string]string)
+	for _, item := range pkg.Items {
+		hrefs[item.ID] = item.Href
+	}
+
+	var paragraphs []string
+	for _, ref := range pkg.Spine {
+		href, ok := hrefs[ref.IDRef]
+		if !ok {
+			continue
+		}
+		// Hrefs are URL-encoded and relative to the package document
+		if unescaped, err := url.PathUnescape(href); err == nil {
+			href = unescaped
+		}
+		file, err := archive.Open(path.Join(path.Dir(opfPath), href))
+		if err != nil {
+			continue
+		}
+		paragraphs = append(paragraphs, htmlParagraphs(file)...)
+		file.Close()
+		if maxLines > 0 && len(paragraphs) >= maxLines {
+			break
+		}
+	}
+	return paragraphs, nil
+}
+
+// decodeZipXML decodes an XML file of a zip archive into v
+func decodeZipXML(archive *zip.ReadCloser, name string, v any) error {
+	file, err := archive.Open(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return xml.NewDecoder(file).Decode(v)
+}
+
+// htmlBlocks are the (X)HTML elements that start a new paragraph
+var htmlBlocks = map[string]bool{
+	"p": true, "div": true, "br": true, "li": true, "tr": true, "blockquote": true, "pre": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true, "section": true,
+}
+
+// htmlParagraphs returns the text of an (X)HTML document by paragraph,
+// leaving out the head, scripts and styles
+func htmlParagraphs(r io.Reader) []string {
+	var paragraphs []string
+	var current strings.Builder
+	skip := 0
+	flush := func() {
+		if text := strings.Join(strings.Fields(current.String()), " "); text != "" {
+			paragraphs = append(paragraphs, text, "")
+		}
+		current.Reset()
+	}
+
+	decoder := xml.NewDecoder(r)
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch name := strings.ToLower(t.Name.Local); {
+			case name == "head" || name == "script" || name == "style":
+				skip++
+			case htmlBlocks[name]:
+				flush()
+			}
+		case xml.EndElement:
+			switch name := strings.ToLower(t.Name.Local); {
+			case name == "head" || name == "script" || name == "style":
+				skip = max(skip-1, 0)
+			case htmlBlocks[name]:
+				flush()
+			}
+		case xml.CharData:
+			if skip == 0 {
+				current.WriteByte(' ')
+				current.Write(t)
+			}
+		}
+	}
+	flush()
+	return paragraphs
+}
@@ -0,0 +1,187 @@
+package preview
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// EXIF tags shown for photos
+const (
+	exifMake         = 0x010F
+	exifModel        = 0x0110
+	exifDateTime     = 0x0132
+	exifIFDPointer   = 0x8769
+	exifExposureTime = 0x829A
+	exifFNumber      = 0x829D
+	exifISO          = 0x8827
+	exifTaken        = 0x9003
+	exifFocalLength  = 0x920A
+	exifLensModel    = 0xA434
+)
+
+// exifTypeSizes are the sizes in bytes of the EXIF value types read
+var exifTypeSizes = map[uint16]int{2: 1, 3: 2, 4: 4, 5: 8, 7: 1, 10: 8}
+
+// maxJPEGHeader limits how far into a JPEG the EXIF segment is looked for
+const maxJPEGHeader = 256 << 10
+
+// exifLines describes the camera, lens and exposure a JPEG photo was taken
+// with, or returns nil when it has no EXIF data
+func exifLines(path string) []string {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+	head, _ := io.ReadAll(io.LimitReader(file, maxJPEGHeader))
+	tags := readEXIF(head)
+	if len(tags) == 0 {
+		return nil
+	}
+
+	var lines []string
+	add := func(label, value string) {
+		if value = strings.TrimSpace(value); value != "" {
+			lines = append(lines, fmt.Sprintf("%-9s %s", label+":", value))
+		}
+	}
+	camera := tags[exifModel]
+	// Models often repeat the maker ("Canon EOS R5" by "Canon")
+	if maker := tags[exifMake]; maker != "" && !strings.HasPrefix(strings.ToLower(camera), strings.ToLower(strings.Fields(maker + " x")[0])) {
+		camera = maker + " " + camera
+	}
+	add("Camera", camera)
+	add("Lens", tags[exifLensModel])
+	taken := tags[exifTaken]
+	if taken == "" {
+		taken = tags[exifDateTime]
+	}
+	add("Taken", taken)
+
+	var exposure []string
+	if t := tags[exifExposureTime]; t != "" {
+		exposure = append(exposure, t+" s")
+	}
+	if f := tags[exifFNumber]; f != "" {
+		exposure = append(exposure, "f/"+f)
+	}
+	if iso := tags[exifISO]; iso != "" {
+		exposure = append(exposure, "ISO "+iso)
+	}
+	if focal := tags[exifFocalLength]; focal != "" {
+		exposure = append(exposure, focal+" mm")
+	}
+	add("Exposure", strings.Join(exposure, "  "))
+	return lines
+}
+
+// readEXIF finds the EXIF segment of a JPEG and returns the shown tags
+// formatted as text
+func readEXIF(data []byte) map[uint16]string {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil
+	}
+	for pos := 2; pos+4 <= len(data); {
+		if data[pos] != 0xFF {
+			return nil
+		}
+		marker := data[pos+1]
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		if marker == 0xDA || length < 2 { // Start of scan: no more headers
+			return nil
+		}
+		segment := data[pos+4 : min(pos+2+length, len(data))]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return readTIFF(segment[6:])
+		}
+		pos += 2 + length
+	}
+	return nil
+}
+
+// readTIFF reads the first image directory of TIFF-structured EXIF data
+// and the EXIF directory it points to
+func readTIFF(tiff []byte) map[uint16]string {
+	if len(tiff) < 8 {
+		return nil
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return nil
+	}
+	tags := make(map[uint16]string)
+	exifIFD := readIFD(tiff, int(order.Uint32(tiff[4:])), order, tags)
+	if exifIFD > 0 {
+		readIFD(tiff, exifIFD, order, tags)
+	}
+	return tags
+}
+
+// readIFD reads the shown tags of one image file directory into tags and
+// returns the offset of the EXIF directory if it points to one
+func readIFD(tiff []byte, offset int, order binary.ByteOrder, tags map[uint16]string) int {
+	if offset <= 0 || offset+2 > len(tiff) {
+		return 0
+	}
+	exifIFD := 0
+	count := int(order.Uint16(tiff[offset:]))
+	for i := 0; i < count; i++ {
+		entry := offset + 2 + 12*i
+		if entry+12 > len(tiff) {
+			break
+		}
+		tag := order.Uint16(tiff[entry:])
+		kind := order.Uint16(tiff[entry+2:])
+		n := int(order.Uint32(tiff[entry+4:]))
+
+		// Values over four bytes are stored elsewhere
+		width, ok := exifTypeSizes[kind]
+		if !ok || n <= 0 || n > len(tiff) {
+			continue
+		}
+		value := tiff[entry+8 : entry+12]
+		if width*n > 4 {
+			at := int(order.Uint32(value))
+			if at < 0 || at+width*n > len(tiff) {
+				continue
+			}
+			value = tiff[at : at+width*n]
+		}
+
+		switch kind {
+		case 2: // ASCII
+			text, _, _ := strings.Cut(string(value[:n]), "\x00")
+			tags[tag] = text
+		case 3: // SHORT
+			tags[tag] = fmt.Sprint(order.Uint16(value))
+		case 4: // LONG
+			if tag == exifIFDPointer {
+				exifIFD = int(order.Uint32(value))
+			}
+			tags[tag] = fmt.Sprint(order.Uint32(value))
+		case 5, 10: // RATIONAL, SRATIONAL
+			num, den := float64(order.Uint32(value)), float64(order.Uint32(value[4:]))
+			if kind == 10 {
+				num, den = float64(int32(order.Uint32(value))), float64(int32(order.Uint32(value[4:])))
+			}
+			if den == 0 {
+				continue
+			}
+			if tag == exifExposureTime && num > 0 && num < den {
+				tags[tag] = fmt.Sprintf("1/%d", int(den/num+0.5))
+			} else {
+				tags[tag] = strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.1f", num/den), "0"), ".")
+			}
+		}
+	}
+	return exifIFD
+}
@@ -0,0 +1,67 @@
+package preview
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Link is a URL or file path found in a preview line
+type Link struct {
+	Target string // URL, or path as written (without a :line suffix)
+	Line   int    // Line number from a "path:line" reference, 0 if none
+	IsURL  bool
+}
+
+// urlPattern matches http(s) and file URLs
+var urlPattern = regexp.MustCompile(`(?:https?|file)://[^\s"'<>` + "`" + `)\]]+`)
+
+// lineSuffix matches a trailing ":line" or ":line:column" reference
+var lineSuffix = regexp.MustCompile(`:(\d+)(?::\d+)?$`)
+
+// FindLinks returns the URLs and path-like words in a line, in order.
+// Paths are candidates only; callers check whether they exist.
+func FindLinks(line string) []Link {
+	var links []Link
+	for _, loc := range urlPattern.FindAllStringIndex(line, -1) {
+		links = append(links, Link{Target: strings.TrimRight(line[loc[0]:loc[1]], ".,;:!?"), IsURL: true})
+	}
+	line = urlPattern.ReplaceAllString(line, " ")
+
+	words := strings.FieldsFunc(line, func(r rune) bool {
+		return r == ' ' || r == '\t' || strings.ContainsRune("\"'`()[]{}<>,;=|", r)
+	})
+	for _, word := range words {
+		word = strings.TrimRight(word, ".,:;!?")
+		lineNum := 0
+		if m := lineSuffix.FindStringSubmatch(word); m != nil {
+			word = strings.TrimSuffix(word, m[0])
+			lineNum = atoi(m[1])
+		}
+		if looksLikePath(word) {
+			links = append(links, Link{Target: word, Line: lineNum})
+		}
+	}
+	return links
+}
+
+// looksLikePath reports whether a word is worth checking as a file path:
+// it contains a slash, starts with ~, or has a file extension
+func looksLikePath(word string) bool {
+	if word == "" || word == "/" || strings.Contains(word, "//") {
+		return false
+	}
+	if strings.Contains(word, "/") || strings.HasPrefix(word, "~") {
+		return true
+	}
+	dot := strings.LastIndexByte(word, '.')
+	return dot > 0 && dot < len(word)-1
+}
+
+// atoi converts a string of digits to an int
+func atoi(s string) int {
+	n := 0
+	for _, c := range s {
+		n = n*10 + int(c-'0')
+	}
+	return n
+}
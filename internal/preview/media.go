@@ -0,0 +1,704 @@
+package preview
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// ffprobeTimeout bounds how long ffprobe may take for one preview
+const ffprobeTimeout = 3 * time.Second
+
+// maxMovieHeader limits how much of an MP4 movie header is read
+const maxMovieHeader = 16 << 20
+
+// mediaTags are the tags shown, in order, with their labels
+var mediaTags = []struct{ key, label string }{
+	{"title", "Title"},
+	{"artist", "Artist"},
+	{"album", "Album"},
+	{"date", "Year"},
+	{"genre", "Genre"},
+	{"track", "Track"},
+}
+
+// mediaInfo is what a preview shows of an audio or video file
+type mediaInfo struct {
+	format   string
+	duration float64 // Seconds; 0 when unknown
+	bitrate  int     // Bits per second; 0 when unknown
+	video    string  // e.g. "h264, 1920x1080"
+	audio    string  // e.g. "aac, 44100 Hz, stereo"
+	tags     map[string]string // Lower-case keys as in mediaTags
+}
+
+// IsMediaFile checks if a file is audio or video with metadata to preview
+func IsMediaFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".mp3", ".flac", ".wav", ".m4a", ".aac", ".ogg", ".opus", ".wma",
+		".mp4", ".m4v", ".mov", ".mkv", ".webm", ".avi", ".wmv":
+		return true
+	}
+	return false
+}
+
+// mediaHandler names how the metadata of a media file is read
+func mediaHandler(name string) string {
+	if _, err := exec.LookPath("ffprobe"); err == nil {
+		return "Media metadata (ffprobe)"
+	}
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".mp3", ".flac", ".wav", ".m4a", ".mp4", ".m4v", ".mov":
+		return "Media metadata (built-in reader)"
+	}
+	return "Media type only (install ffprobe for metadata)"
+}
+
+// mediaLines describes an audio or video file: its format, duration,
+// bitrate, streams and tags
+func mediaLines(path string, info os.FileInfo) []string {
+	media, err := probeMedia(path)
+	if err != nil {
+		media, err = readMedia(path, info.Size())
+	}
+	lines := []string{"[" + describeFileByExt(filepath.Base(path)) + "]", ""}
+	if err != nil {
+		return append(lines, "Size:     "+formatSize(info.Size()), "", "No metadata: "+err.Error())
+	}
+
+	add := func(label, value string) {
+		if value != "" {
+			lines = append(lines, fmt.Sprintf("%-9s %s", label+":", value))
+		}
+	}
+	add("Format", media.format)
+	if media.duration > 0 {
+		add("Duration", formatDuration(media.duration))
+	}
+	if media.bitrate > 0 {
+		add("Bitrate", fmt.Sprintf("%d kb/s", media.bitrate/1000))
+	}
+	add("Video", media.video)
+	add("Audio", media.audio)
+	add("Size", formatSize(info.Size()))
+	if len(media.tags) > 0 {
+		lines = append(lines, "")
+		for _, tag := range mediaTags {
+			add(tag.label, strings.TrimSpace(media.tags[tag.key]))
+		}
+	}
+	return lines
+}
+
+// formatDuration formats seconds as m:ss or h:mm:ss
+func formatDuration(seconds float64) string {
+	total := int(seconds + 0.5)
+	if total >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", total/3600, total/60%60, total%60)
+	}
+	return fmt.Sprintf("%d:%02d", total/60, total%60)
+}
+
+// channelName describes a channel count
+func channelName(channels int) string {
+	switch channels {
+	case 1:
+		return "mono"
+	case 2:
+		return "stereo"
+	}
+	return fmt.Sprintf("%d channels", channels)
+}
+
+// probeMedia reads metadata with ffprobe, when it is installed
+func probeMedia(path string) (*mediaInfo, error) {
+	if _, err := exec.LookPath("ffprobe"); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), ffprobeTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "ffprobe", "-v", "quiet", "-print_format", "json", "-show_format", "-show_streams", path).Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var probe struct {
+		Format struct {
+			LongName string            `json:"format_long_name"`
+			Duration string            `json:"duration"`
+			BitRate  string            `json:"bit_rate"`
+			Tags     map[string]string `json:"tags"`
+		} `json:"format"`
+		Streams []struct {
+			Type       string            `json:"codec_type"`
+			Codec      string            `json:"codec_name"`
+			Width      int               `json:"width"`
+			Height     int               `json:"height"`
+			SampleRate string            `json:"sample_rate"`
+			Channels   int               `json:"channels"`
+			FrameRate  string            `json:"avg_frame_rate"`
+			Tags       map[string]string `json:"tags"`
+		} `json:"streams"`
+	}
+	if err := json.Unmarshal(out, &probe); err != nil {
+		return nil, err
+	}
+
+	media := &mediaInfo{format: probe.Format.LongName, tags: make(map[string]string)}
+	media.duration, _ = strconv.ParseFloat(probe.Format.Duration, 64)
+	media.bitrate, _ = strconv.Atoi(probe.Format.BitRate)
+	for key, value := range probe.Format.Tags {
+		media.tags[strings.ToLower(key)] = value
+	}
+	for _, s := range probe.Streams {
+		switch {
+		case s.Type == "video" && media.video == "" && s.Width > 0:
+			media.video = fmt.Sprintf("%s, %dx%d", s.Codec, s.Width, s.Height)
+			if num, den, ok := strings.Cut(s.FrameRate, "/"); ok {
+				n, _ := strconv.ParseFloat(num, 64)
+				d, _ := strconv.ParseFloat(den, 64)
+				if n > 0 && d > 0 {
+					media.video += fmt.Sprintf(", %.3g fps", n/d)
+				}
+			}
+		case s.Type == "audio" && media.audio == "":
+			media.audio = s.Codec
+			if s.SampleRate != "" {
+				media.audio += ", " + s.SampleRate + " Hz"
+			}
+			if s.Channels > 0 {
+				media.audio += ", " + channelName(s.Channels)
+			}
+		}
+	}
+	return media, nil
+}
+
+// readMedia reads metadata of the formats the built-in readers know
+func readMedia(path string, size int64) (*mediaInfo, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".mp3":
+		return readMP3(file, size)
+	case ".flac":
+		return readFLAC(file)
+	case ".wav":
+		return readWAV(file)
+	case ".m4a", ".mp4", ".m4v", ".mov":
+		return readMP4(file, size)
+	}
+	return nil, fmt.Errorf("install ffprobe to read %s files", strings.TrimPrefix(filepath.Ext(path), "."))
+}
+
+// MP3 bitrates in kb/s by bitrate index, for MPEG-1 and MPEG-2/2.5 layer III
+var (
+	mp3Bitrates1 = [16]int{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320}
+	mp3Bitrates2 = [16]int{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160}
+	mp3Rates     = [3]int{44100, 48000, 32000}
+)
+
+// readMP3 reads the ID3 tags and the first frame header of an MP3 file
+func readMP3(file *os.File, size int64) (*mediaInfo, error) {
+	head := make([]byte, 10)
+	if _, err := io.ReadFull(file, head); err != nil {
+		return nil, err
+	}
+	media := &mediaInfo{format: "MP3", tags: make(map[string]string)}
+
+	// The ID3v2 tag comes first; the audio starts after it
+	var audioStart int64
+	if string(head[:3]) == "ID3" {
+		tagSize := int64(syncsafe(head[6:10]))
+		tag := make([]byte, tagSize)
+		if _, err := io.ReadFull(file, tag); err != nil {
+			return nil, err
+		}
+		readID3v2(tag, head[3], media.tags)
+		audioStart = 10 + tagSize
+	} else if size >= 128 {
+		tail := make([]byte, 128)
+		if _, err := file.ReadAt(tail, size-128); err == nil && string(tail[:3]) == "TAG" {
+			readID3v1(tail, media.tags)
+			size -= 128
+		}
+	}
+
+	// Find the first frame header within the next 64 KB
+	buf := make([]byte, 64<<10)
+	n, _ := file.ReadAt(buf, audioStart)
+	buf = buf[:n]
+	for i := 0; i+4 <= len(buf); i++ {
+		if buf[i] != 0xFF || buf[i+1]&0xE0 != 0xE0 {
+			continue
+		}
+		version := buf[i+1] >> 3 & 3 // 3: MPEG-1, 2: MPEG-2, 0: MPEG-2.5
+		layer := buf[i+1] >> 1 & 3   // 1: layer III
+		bitrateIndex := buf[i+2] >> 4
+		rateIndex := buf[i+2] >> 2 & 3
+		if version == 1 || layer != 1 || bitrateIndex == 0 || bitrateIndex == 15 || rateIndex == 3 {
+			continue
+		}
+		mono := buf[i+3]>>6 == 3
+		rate := mp3Rates[rateIndex]
+		bitrate := mp3Bitrates1[bitrateIndex]
+		samples := 1152
+		sideInfo := 32
+		if mono {
+			sideInfo = 17
+		}
+		if version != 3 {
+			rate /= 2
+			if version == 0 {
+				rate /= 2
+			}
+			bitrate = mp3Bitrates2[bitrateIndex]
+			samples = 576
+			sideInfo = 17
+			if mono {
+				sideInfo = 9
+			}
+		}
+		channels := 2
+		if mono {
+			channels = 1
+		}
+		media.audio = fmt.Sprintf("mp3, %d Hz, %s", rate, channelName(channels))
+
+		// A Xing or Info header counts the frames of VBR files
+		if x := i + 4 + sideInfo; x+12 <= len(buf) && (string(buf[x:x+4]) == "Xing" || string(buf[x:x+4]) == "Info") {
+			if binary.BigEndian.Uint32(buf[x+4:])&1 != 0 {
+				frames := binary.BigEndian.Uint32(buf[x+8:])
+				media.duration = float64(frames) * float64(samples) / float64(rate)
+				if media.duration > 0 {
+					media.bitrate = int(float64(size-audioStart-int64(i)) * 8 / media.duration)
+				}
+				return media, nil
+			}
+		}
+		media.bitrate = bitrate * 1000
+		media.duration = float64(size-audioStart-int64(i)) * 8 / float64(media.bitrate)
+		return media, nil
+	}
+	if len(media.tags) == 0 {
+		return nil, fmt.Errorf("no MP3 frames found")
+	}
+	return media, nil
+}
+
+// syncsafe decodes a 28-bit ID3v2 integer stored in 7 bits per byte
+func syncsafe(b []byte) int {
+	return int(b[0]&0x7F)<<21 | int(b[1]&0x7F)<<14 | int(b[2]&0x7F)<<7 | int(b[3]&0x7F)
+}
+
+// id3Frames maps ID3v2 text frame IDs (v2.3/2.4, then v2.2) to tag keys
+var id3Frames = map[string]string{
+	"TIT2": "title", "TPE1": "artist", "TALB": "album", "TYER": "date", "TDRC": "date", "TCON": "genre", "TRCK": "track",
+	"TT2": "title", "TP1": "artist", "TAL": "album", "TYE": "date", "TCO": "genre", "TRK": "track",
+}
+
+// readID3v2 reads the text frames of an ID3v2 tag body of a major version
+func readID3v2(tag []byte, version byte, tags map[string]string) {
+	idLen, headLen := 4, 10
+	if version == 2 {
+		idLen, headLen = 3, 6
+	}
+	for pos := 0; pos+headLen <= len(tag); {
+		id := string(tag[pos : pos+idLen])
+		if id[0] == 0 {
+			break // Padding
+		}
+		var size int
+		switch version {
+		case 2:
+			size = int(tag[pos+3])<<16 | int(tag[pos+4])<<8 | int(tag[pos+5])
+		case 4:
+			size = syncsafe(tag[pos+4 : pos+8])
+		default:
+			size = int(binary.BigEndian.Uint32(tag[pos+4 : pos+8]))
+		}
+		body := pos + headLen
+		if size <= 0 || body+size > len(tag) {
+			break
+		}
+		if key, ok := id3Frames[id]; ok {
+			tags[key] = id3Text(tag[body : body+size])
+		}
+		pos = body + size
+	}
+}
+
+// id3Text decodes an ID3v2 text frame body by its encoding byte
+func id3Text(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	text := body[1:]
+	var s string
+	switch body[0] {
+	case 1, 2: // UTF-16 with a byte order mark, or big-endian
+		bigEndian := body[0] == 2
+		if len(text) >= 2 && text[0] == 0xFE && text[1] == 0xFF {
+			bigEndian, text = true, text[2:]
+		} else if len(text) >= 2 && text[0] == 0xFF && text[1] == 0xFE {
+			bigEndian, text = false, text[2:]
+		}
+		units := make([]uint16, len(text)/2)
+		for i := range units {
+			if bigEndian {
+				units[i] = binary.BigEndian.Uint16(text[2*i:])
+			} else {
+				units[i] = binary.LittleEndian.Uint16(text[2*i:])
+			}
+		}
+		s = string(utf16.Decode(units))
+	case 3:
+		s = string(text)
+	default:
+		s = latin1(text)
+	}
+	// Several values are separated by NULs; show the first
+	s, _, _ = strings.Cut(s, "\x00")
+	return s
+}
+
+// latin1 decodes ISO-8859-1 bytes
+func latin1(b []byte) string {
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = rune(c)
+	}
+	return string(runes)
+}
+
+// readID3v1 reads the fixed fields of an ID3v1 tag
+func readID3v1(tail []byte, tags map[string]string) {
+	field := func(b []byte) string {
+		s, _, _ := strings.Cut(latin1(b), "\x00")
+		return strings.TrimSpace(s)
+	}
+	tags["title"] = field(tail[3:33])
+	tags["artist"] = field(tail[33:63])
+	tags["album"] = field(tail[63:93])
+	tags["date"] = field(tail[93:97])
+	if tail[125] == 0 && tail[126] != 0 {
+		tags["track"] = strconv.Itoa(int(tail[126]))
+	}
+}
+
+// readFLAC reads the stream info and Vorbis comments of a FLAC file
+func readFLAC(file *os.File) (*mediaInfo, error) {
+	magic := make([]byte, 4)
+	if _, err := io.ReadFull(file, magic); err != nil || string(magic) != "fLaC" {
+		return nil, fmt.Errorf("not a FLAC file")
+	}
+	media := &mediaInfo{format: "FLAC", tags: make(map[string]string)}
+	for last := false; !last; {
+		header := make([]byte, 4)
+		if _, err := io.ReadFull(file, header); err != nil {
+			break
+		}
+		last = header[0]&0x80 != 0
+		length := int(header[1])<<16 | int(header[2])<<8 | int(header[3])
+		block := make([]byte, length)
+		if _, err := io.ReadFull(file, block); err != nil {
+			break
+		}
+		switch header[0] & 0x7F {
+		case 0: // STREAMINFO
+			if len(block) < 18 {
+				continue
+			}
+			rate := int(block[10])<<12 | int(block[11])<<4 | int(block[12])>>4
+			channels := int(block[12]>>1&7) + 1
+			bits := int(block[12]&1)<<4 | int(block[13]>>4) + 1
+			samples := int64(block[13]&0x0F)<<32 | int64(binary.BigEndian.Uint32(block[14:18]))
+			media.audio = fmt.Sprintf("flac, %d Hz, %d bit, %s", rate, bits, channelName(channels))
+			if rate > 0 {
+				media.duration = float64(samples) / float64(rate)
+			}
+		case 4: // VORBIS_COMMENT
+			readVorbisComments(block, media.tags)
+		}
+	}
+	if media.duration > 0 {
+		if info, err := file.Stat(); err == nil {
+			media.bitrate = int(float64(info.Size()) * 8 / media.duration)
+		}
+	}
+	return media, nil
+}
+
+// readVorbisComments reads KEY=value comments, keeping the known tags
+func readVorbisComments(block []byte, tags map[string]string) {
+	read := func(pos int) (string, int) {
+		if pos+4 > len(block) {
+			return "", -1
+		}
+		n := int(binary.LittleEndian.Uint32(block[pos:]))
+		if n < 0 || pos+4+n > len(block) {
+			return "", -1
+		}
+		return string(block[pos+4 : pos+4+n]), pos + 4 + n
+	}
+	_, pos := read(0) // Vendor
+	if pos < 0 || pos+4 > len(block) {
+		return
+	}
+	count := int(binary.LittleEndian.Uint32(block[pos:]))
+	pos += 4
+	for i := 0; i < count && pos >= 0; i++ {
+		var comment string
+		comment, pos = read(pos)
+		key, value, ok := strings.Cut(comment, "=")
+		key = strings.ToLower(key)
+		if key == "tracknumber" {
+			key = "track"
+		}
+		if ok && tags[key] == "" {
+			tags[key] = value
+		}
+	}
+}
+
+// readWAV reads the format and length of a WAV file
+func readWAV(file *os.File) (*mediaInfo, error) {
+	head := make([]byte, 12)
+	if _, err := io.ReadFull(file, head); err != nil || string(head[:4]) != "RIFF" || string(head[8:]) != "WAVE" {
+		return nil, fmt.Errorf("not a WAV file")
+	}
+	media := &mediaInfo{format: "WAV"}
+	byteRate := 0
+	for {
+		chunk := make([]byte, 8)
+		if _, err := io.ReadFull(file, chunk); err != nil {
+			break
+		}
+		size := int64(binary.LittleEndian.Uint32(chunk[4:]))
+		switch string(chunk[:4]) {
+		case "fmt ":
+			format := make([]byte, min(int(size), 16))
+			if _, err := io.ReadFull(file, format); err != nil || len(format) < 16 {
+				return nil, fmt.Errorf("broken WAV format chunk")
+			}
+			channels := int(binary.LittleEndian.Uint16(format[2:]))
+			rate := int(binary.LittleEndian.Uint32(format[4:]))
+			byteRate = int(binary.LittleEndian.Uint32(format[8:]))
+			bits := int(binary.LittleEndian.Uint16(format[14:]))
+			codec := "pcm"
+			if binary.LittleEndian.Uint16(format) == 3 {
+				codec = "pcm float"
+			}
+			media.audio = fmt.Sprintf("%s, %d Hz, %d bit, %s", codec, rate, bits, channelName(channels))
+			media.bitrate = byteRate * 8
+			size -= int64(len(format))
+		case "data":
+			if byteRate > 0 {
+				media.duration = float64(size) / float64(byteRate)
+			}
+			return media, nil
+		}
+		// Chunks are padded to an even size
+		if _, err := file.Seek(size+size%2, io.SeekCurrent); err != nil {
+			break
+		}
+	}
+	if media.audio == "" {
+		return nil, fmt.Errorf("no WAV format chunk")
+	}
+	return media, nil
+}
+
+// readMP4 reads the movie header of an MP4 or QuickTime file: duration,
+// track formats and iTunes-style tags
+func readMP4(file *os.File, size int64) (*mediaInfo, error) {
+	// Walk the top-level boxes to the movie box, which may come last
+	for pos := int64(0); pos+8 <= size; {
+		head := make([]byte, 16)
+		if _, err := file.ReadAt(head[:8], pos); err != nil {
+			break
+		}
+		boxSize := int64(binary.BigEndian.Uint32(head))
+		headLen := int64(8)
+		if boxSize == 1 {
+			if _, err := file.ReadAt(head[8:], pos+8); err != nil {
+				break
+			}
+			boxSize, headLen = int64(binary.BigEndian.Uint64(head[8:])), 16
+		} else if boxSize == 0 {
+			boxSize = size - pos
+		}
+		if boxSize < headLen {
+			break
+		}
+		if string(head[4:8]) == "moov" {
+			if boxSize-headLen > maxMovieHeader {
+				return nil, fmt.Errorf("movie header too large")
+			}
+			moov := make([]byte, boxSize-headLen)
+			if _, err := file.ReadAt(moov, pos+headLen); err != nil {
+				return nil, err
+			}
+			return parseMovie(moov, size)
+		}
+		pos += boxSize
+	}
+	return nil, fmt.Errorf("no movie header found")
+}
+
+// mp4Boxes calls fn with the type and body of each box in data
+func mp4Boxes(data []byte, fn func(kind string, body []byte)) {
+	for len(data) >= 8 {
+		size := int(binary.BigEndian.Uint32(data))
+		headLen := 8
+		if size == 1 && len(data) >= 16 {
+			size, headLen = int(binary.BigEndian.Uint64(data[8:])), 16
+		} else if size == 0 {
+			size = len(data)
+		}
+		if size < headLen || size > len(data) {
+			return
+		}
+		fn(string(data[4:8]), data[headLen:size])
+		data = data[size:]
+	}
+}
+
+// mp4Tags maps iTunes metadata atoms to tag keys
+var mp4Tags = map[string]string{
+	"\xa9nam": "title", "\xa9ART": "artist", "\xa9alb": "album", "\xa9day": "date", "\xa9gen": "genre",
+}
+
+// parseMovie reads the contents of a moov box
+func parseMovie(moov []byte, size int64) (*mediaInfo, error) {
+	media := &mediaInfo{format: "MPEG-4", tags: make(map[string]string)}
+	mp4Boxes(moov, func(kind string, body []byte) {
+		switch kind {
+		case "mvhd":
+			var timescale, duration uint64
+			if len(body) >= 32 && body[0] == 1 {
+				timescale, duration = uint64(binary.BigEndian.Uint32(body[20:])), binary.BigEndian.Uint64(body[24:])
+			} else if len(body) >= 20 {
+				timescale, duration = uint64(binary.BigEndian.Uint32(body[12:])), uint64(binary.BigEndian.Uint32(body[16:]))
+			}
+			if timescale > 0 {
+				media.duration = float64(duration) / float64(timescale)
+			}
+		case "trak":
+			parseTrack(body, media)
+		case "udta":
+			mp4Boxes(body, func(kind string, body []byte) {
+				if kind != "meta" || len(body) < 4 {
+					return
+				}
+				// meta is a full box: skip its version and flags
+				mp4Boxes(body[4:], func(kind string, body []byte) {
+					if kind == "ilst" {
+						parseItemList(body, media.tags)
+					}
+				})
+			})
+		}
+	})
+	if media.duration > 0 {
+		media.bitrate = int(float64(size) * 8 / media.duration)
+	}
+	if media.video == "" && media.audio == "" && media.duration == 0 {
+		return nil, fmt.Errorf("empty movie header")
+	}
+	return media, nil
+}
+
+// parseTrack reads the kind, size and codec of a trak box
+func parseTrack(trak []byte, media *mediaInfo) {
+	var width, height int
+	var handler, codec string
+	var rate, channels int
+	var walk func(data []byte)
+	walk = func(data []byte) {
+		mp4Boxes(data, func(kind string, body []byte) {
+			switch kind {
+			case "tkhd":
+				if len(body) >= 8 {
+					// Width and height end the box as 16.16 fixed point
+					width = int(binary.BigEndian.Uint32(body[len(body)-8:]) >> 16)
+					height = int(binary.BigEndian.Uint32(body[len(body)-4:]) >> 16)
+				}
+			case "mdia", "minf", "stbl":
+				walk(body)
+			case "hdlr":
+				if len(body) >= 12 {
+					handler = string(body[8:12])
+				}
+			case "stsd":
+				// The first sample entry follows the version, flags and count
+				if len(body) >= 16 {
+					codec = strings.TrimSpace(string(body[12:16]))
+					if entry := body[8:]; handler == "soun" && len(entry) >= 36 {
+						channels = int(binary.BigEndian.Uint16(entry[24:]))
+						rate = int(binary.BigEndian.Uint16(entry[32:]))
+					}
+				}
+			}
+		})
+	}
+	walk(trak)
+
+	switch handler {
+	case "vide":
+		if media.video == "" {
+			media.video = fmt.Sprintf("%s, %dx%d", codec, width, height)
+		}
+	case "soun":
+		if media.audio == "" {
+			media.audio = codec
+			if rate > 0 {
+				media.audio += fmt.Sprintf(", %d Hz", rate)
+			}
+			if channels > 0 {
+				media.audio += ", " + channelName(channels)
+			}
+		}
+	}
+}
+
+// parseItemList reads the text tags of an ilst box
+func parseItemList(ilst []byte, tags map[string]string) {
+	mp4Boxes(ilst, func(kind string, body []byte) {
+		key, known := mp4Tags[kind]
+		if kind == "trkn" {
+			key, known = "track", true
+		}
+		if !known {
+			return
+		}
+		mp4Boxes(body, func(dataKind string, data []byte) {
+			// data boxes hold a type and a locale before the value
+			if dataKind != "data" || len(data) < 8 {
+				return
+			}
+			value := data[8:]
+			if key == "track" {
+				if len(value) >= 4 && binary.BigEndian.Uint16(value[2:]) > 0 {
+					tags[key] = strconv.Itoa(int(binary.BigEndian.Uint16(value[2:])))
+				}
+				return
+			}
+			tags[key] = string(bytes.TrimRight(value, "\x00"))
+		})
+	})
+}
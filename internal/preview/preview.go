@@ -2,10 +2,15 @@ package preview
 
 import (
 	"bufio"
+	"bytes"
+	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"unicode/utf8"
+
+	"github.com/alexcostache/Xplorer/internal/screen"
 
 	"github.com/alecthomas/chroma"
 	"github.com/alecthomas/chroma/lexers"
@@ -16,7 +21,15 @@ import (
 // Manager handles file preview operations
 type Manager struct {
 	lastPreviewLines []string
+	lastImage        *Thumbnail
+	lastTable        *TableInfo // Set when the preview is a table
+	lastEncoding     string     // Of a text preview, "" for others
 	scrollOffset     int
+	columnOffset     int // Display columns scrolled to the right
+	thumbnails       *ThumbnailCache
+	skipExtensions   []string // Lower-case extensions (without dot) previewed as metadata only
+	maxSize          int64    // Files larger than this are previewed as metadata only; 0 disables
+	async            asyncState
 }
 
 // NewManager creates a new preview manager
@@ -24,7 +37,90 @@ func NewManager() *Manager {
 	return &Manager{
 		lastPreviewLines: nil,
 		scrollOffset:     0,
+		thumbnails:       NewThumbnailCache(),
+	}
+}
+
+// SetLimits configures which files are described instead of read: those
+// with one of the given extensions ("log" or ".log", case-insensitive) and
+// those larger than maxSize bytes (0 for no limit)
+func (m *Manager) SetLimits(skipExtensions []string, maxSize int64) {
+	var exts []string
+	for _, ext := range skipExtensions {
+		if ext = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(ext), ".")); ext != "" {
+			exts = append(exts, ext)
+		}
+	}
+	m.async.mu.Lock()
+	defer m.async.mu.Unlock()
+	m.skipExtensions = exts
+	m.maxSize = maxSize
+	m.async.clear()
+}
+
+// skipReason returns why a file's content should not be read, or ""
+func (m *Manager) skipReason(path string, info os.FileInfo) string {
+	m.async.mu.Lock()
+	defer m.async.mu.Unlock()
+	name := strings.ToLower(filepath.Base(path))
+	for _, ext := range m.skipExtensions {
+		if strings.HasSuffix(name, "."+ext) {
+			return "Preview disabled for ." + ext + " files"
+		}
+	}
+	if m.maxSize > 0 && info != nil && info.Size() > m.maxSize {
+		return "Too large to preview (over " + formatSize(m.maxSize) + ")"
+	}
+	return ""
+}
+
+// Handler names how LoadPreview shows path, judging from its name and, when
+// info is not nil, its type and size. Content is not read, so a file that
+// turns out to be binary is still reported as text.
+func (m *Manager) Handler(path string, info os.FileInfo) string {
+	if info != nil && info.IsDir() {
+		return "Directory listing"
+	}
+	if reason := m.skipReason(path, info); reason != "" {
+		return "Metadata only: " + reason
+	}
+	if IsImageFile(path) {
+		return "Image thumbnail"
+	}
+	if IsMediaFile(path) {
+		return mediaHandler(path)
+	}
+	if IsArchiveFile(path) {
+		return archiveHandler(path)
+	}
+	if IsDocumentFile(path) {
+		return documentHandler(path)
+	}
+	if IsTableFile(path) {
+		return tableHandler(path)
 	}
+	if lang := DetectLanguage(path); lang != "" {
+		return "Text, highlighted as " + lang
+	}
+	return "Plain text (binary files are described by type)"
+}
+
+// metadataLines describes a file without reading it
+func metadataLines(path string, info os.FileInfo, reason string) []string {
+	return []string{
+		"[" + reason + "]",
+		"",
+		"Type:     " + describeFileByExt(filepath.Base(path)),
+		"Size:     " + formatSize(info.Size()),
+		"Modified: " + info.ModTime().Format("2006-01-02 15:04:05"),
+	}
+}
+
+// ShowInfo previews a file by its metadata, headed by reason, without
+// reading it; for files whose content is out of reach, like remote ones
+func (m *Manager) ShowInfo(path string, info os.FileInfo, reason string) {
+	m.async.wanted = nil
+	m.show(previewResult{lines: metadataLines(path, info, reason)})
 }
 
 // GetLines returns the cached preview lines
@@ -32,6 +128,36 @@ func (m *Manager) GetLines() []string {
 	return m.lastPreviewLines
 }
 
+// GetImage returns the cached image thumbnail, or nil if the preview is not
+// an image. GetLines then holds its size and any EXIF details.
+func (m *Manager) GetImage() *Thumbnail {
+	return m.lastImage
+}
+
+// HeaderLines returns how many lines at the top of the preview stay in
+// place when it scrolls: the header row and rule of a table
+func (m *Manager) HeaderLines() int {
+	if m.lastTable != nil {
+		return min(2, len(m.lastPreviewLines))
+	}
+	return 0
+}
+
+// TableSummary describes the rows and columns of a table preview, e.g.
+// "120 rows x 5 columns", or returns "" for other previews
+func (m *Manager) TableSummary() string {
+	if m.lastTable == nil {
+		return ""
+	}
+	return describeTable(*m.lastTable)
+}
+
+// Encoding names the encoding and line endings of a text preview, e.g.
+// "UTF-8" or "ASCII, CRLF", or returns "" for other previews
+func (m *Manager) Encoding() string {
+	return m.lastEncoding
+}
+
 // GetScrollOffset returns the current scroll offset
 func (m *Manager) GetScrollOffset() int {
 	return m.scrollOffset
@@ -55,26 +181,76 @@ func (m *Manager) ScrollUp(amount int) {
 	m.scrollOffset = max(m.scrollOffset-amount, 0)
 }
 
-// ResetScroll resets the scroll offset
+// GetColumnOffset returns how many display columns the preview is
+// scrolled to the right
+func (m *Manager) GetColumnOffset() int {
+	return m.columnOffset
+}
+
+// ScrollRight scrolls the preview right, as far as the widest line still
+// fills visibleWidth columns
+func (m *Manager) ScrollRight(amount, visibleWidth int) {
+	widest := 0
+	for _, line := range m.lastPreviewLines {
+		widest = max(widest, stringWidth(line))
+	}
+	if widest > visibleWidth {
+		m.columnOffset = min(m.columnOffset+amount, widest-visibleWidth)
+	}
+}
+
+// ScrollLeft scrolls the preview left
+func (m *Manager) ScrollLeft(amount int) {
+	m.columnOffset = max(m.columnOffset-amount, 0)
+}
+
+// ResetScroll resets the scroll offsets
 func (m *Manager) ResetScroll() {
 	m.scrollOffset = 0
+	m.columnOffset = 0
 }
 
 // LoadPreview loads preview for a file or directory
 func (m *Manager) LoadPreview(path string, showHidden bool, maxLines int) error {
+	result, err := m.read(path, showHidden, maxLines)
+	m.async.wanted = nil
+	m.show(result)
+	return err
+}
+
+// previewResult is the preview of one file, built by read and shown by show
+type previewResult struct {
+	lines    []string
+	image    *Thumbnail
+	table    *TableInfo
+	encoding string
+}
+
+// show makes r the current preview, scrolled to its start
+func (m *Manager) show(r previewResult) {
+	m.lastPreviewLines = r.lines
+	m.lastImage = r.image
+	m.lastTable = r.table
+	m.lastEncoding = r.encoding
+	m.scrollOffset = 0
+	m.columnOffset = 0
+}
+
+// read builds the preview of a file or directory. It only reads the
+// Manager's limits and thumbnail cache, so it can run in the background.
+func (m *Manager) read(path string, showHidden bool, maxLines int) (previewResult, error) {
+	var r previewResult
 	info, err := os.Stat(path)
 	if err != nil {
-		m.lastPreviewLines = []string{err.Error()}
-		m.scrollOffset = 0
-		return err
+		r.lines = []string{err.Error()}
+		return r, err
 	}
 
 	if info.IsDir() {
 		entries, err := os.ReadDir(path)
 		if err != nil {
-			m.lastPreviewLines = []string{err.Error()}
-			m.scrollOffset = 0
-			return err
+			r.lines = []string{err.Error()}
+			return r, err
 		}
 		
 		sort.Slice(entries, func(i, j int) bool {
@@ -91,30 +267,81 @@ func (m *Manager) LoadPreview(path string, showHidden bool, maxLines int) error
 				break
 			}
 		}
-		m.lastPreviewLines = lines
-		m.scrollOffset = 0
-		return nil
+		r.lines = lines
+		return r, nil
+	}
+
+	if reason := m.skipReason(path, info); reason != "" {
+		r.lines = metadataLines(path, info, reason)
+		return r, nil
+	}
+
+	// Images are rendered from a cached thumbnail
+	if IsImageFile(path) {
+		if thumb, err := m.thumbnails.Get(path, info); err == nil {
+			r.image = thumb
+			r.lines = []string{fmt.Sprintf("[Image %dx%d]", thumb.SourceWidth, thumb.SourceHeight)}
+			r.lines = append(r.lines, exifLines(path)...)
+			return r, nil
+		}
+	}
+
+	// Audio and video are described by their metadata
+	if IsMediaFile(path) {
+		r.lines = mediaLines(path, info)
+		return r, nil
+	}
+
+	// Archives are shown as the tree of their entries
+	if IsArchiveFile(path) {
+		lines, err := archiveLines(path, maxLines)
+		if err != nil {
+			lines = []string{"[" + describeFileByExt(filepath.Base(path)) + "]", "", "Cannot list the entries: " + err.Error()}
+		}
+		r.lines = lines
+		return r, nil
+	}
+
+	// Documents are shown as their extracted text
+	if IsDocumentFile(path) {
+		lines, err := documentLines(path, maxLines)
+		if err != nil {
+			lines = []string{"[" + describeFileByExt(filepath.Base(path)) + "]", "", "No text to preview: " + err.Error()}
+		}
+		r.lines = lines
+		return r, nil
+	}
+
+	// Delimiter-separated values are aligned into a table; files that
+	// don't parse are shown as text
+	if IsTableFile(path) {
+		if lines, info, err := tableLines(path, maxLines); err == nil {
+			r.lines = lines
+			r.table = &info
+			return r, nil
+		}
 	}
 
 	// Try to read text file
 	file, err := os.Open(path)
 	if err != nil {
-		m.lastPreviewLines = []string{describeFileByExt(filepath.Base(path))}
-		m.scrollOffset = 0
-		return nil
+		r.lines = []string{describeFileByExt(filepath.Base(path))}
+		return r, nil
 	}
 	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
+	// Lines are read without their endings; the first ones are kept to tell
+	reader := bufio.NewReader(file)
+	head, _ := reader.Peek(4096)
+	scanner := bufio.NewScanner(reader)
 	var lines []string
 	for scanner.Scan() {
 		line := scanner.Text()
 		
 		// Detect binary files
 		if strings.ContainsRune(line, '\x00') {
-			m.lastPreviewLines = []string{"[" + describeFileByExt(filepath.Base(path)) + "]"}
-			m.scrollOffset = 0
-			return nil
+			r.lines = []string{"[" + describeFileByExt(filepath.Base(path)) + "]"}
+			return r, nil
 		}
 		
 		lines = append(lines, line)
@@ -124,20 +351,38 @@ func (m *Manager) LoadPreview(path string, showHidden bool, maxLines int) error
 	}
 	
 	if err := scanner.Err(); err != nil {
-		m.lastPreviewLines = []string{"[error reading file]"}
-		m.scrollOffset = 0
-		return nil
+		r.lines = []string{"[error reading file]"}
+		return r, nil
 	}
 	
 	if len(lines) == 0 {
-		m.lastPreviewLines = []string{"[" + describeFileByExt(filepath.Base(path)) + "]"}
-		m.scrollOffset = 0
-		return nil
+		r.lines = []string{"[" + describeFileByExt(filepath.Base(path)) + "]"}
+		return r, nil
 	}
 	
-	m.lastPreviewLines = lines
-	m.scrollOffset = 0
-	return nil
+	r.lines = lines
+	r.encoding = textEncoding(lines, head)
+	return r, nil
+}
+
+// textEncoding names the encoding of the lines of a text file as read, and
+// its line endings when head, the start of the file, ends them with CRLF
+func textEncoding(lines []string, head []byte) string {
+	encoding := "ASCII"
+	if strings.HasPrefix(lines[0], "\ufeff") {
+		encoding = "UTF-8 BOM"
+	}
+	for _, line := range lines {
+		if !utf8.ValidString(line) {
+			encoding = "8-bit"
+		} else if encoding == "ASCII" && strings.IndexFunc(line, func(r rune) bool { return r >= utf8.RuneSelf }) >= 0 {
+			encoding = "UTF-8"
+		}
+	}
+	if crlf := bytes.Count(head, []byte("\r\n")); crlf > 0 && crlf == bytes.Count(head, []byte("\n")) {
+		encoding += ", CRLF"
+	}
+	return encoding
 }
 
 // DrawText draws syntax-highlighted text with theme-aware colors
@@ -145,7 +390,7 @@ func DrawText(x, y int, line string, lang string, colorText, colorBackground, co
 	// Fallback for no language
 	if lang == "" {
 		for i, r := range line {
-			termbox.SetCell(x+i, y, r, colorText, colorBackground)
+			screen.SetCell(x+i, y, r, colorText, colorBackground)
 		}
 		return
 	}
@@ -156,7 +401,7 @@ func DrawText(x, y int, line string, lang string, colorText, colorBackground, co
 	}
 	if lexer == nil {
 		for i, r := range line {
-			termbox.SetCell(x+i, y, r, colorText, colorBackground)
+			screen.SetCell(x+i, y, r, colorText, colorBackground)
 		}
 		return
 	}
@@ -166,13 +411,13 @@ func DrawText(x, y int, line string, lang string, colorText, colorBackground, co
 	iterator, err := lexer.Tokenise(nil, code)
 	if err != nil {
 		for i, r := range line {
-			termbox.SetCell(x+i, y, r, colorText, colorBackground)
+			screen.SetCell(x+i, y, r, colorText, colorBackground)
 		}
 		return
 	}
 
 	xPos := x
-	w, _ := termbox.Size()
+	w, _ := screen.Size()
 
 	for token := iterator(); token != chroma.EOF; token = iterator() {
 		fg := getSyntaxColor(token.Type, colorText, colorDim)
@@ -181,7 +426,7 @@ func DrawText(x, y int, line string, lang string, colorText, colorBackground, co
 			if r == '\n' || xPos >= w {
 				break
 			}
-			termbox.SetCell(xPos, y, r, fg, colorBackground)
+			screen.SetCell(xPos, y, r, fg, colorBackground)
 			xPos += RuneWidth(r)
 		}
 	}
@@ -306,6 +551,20 @@ func RuneWidth(r rune) int {
 	}
 }
 
+// formatSize formats a byte count for metadata previews
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
 // describeFileByExt returns a description of a file type
 func describeFileByExt(name string) string {
 	ext := strings.ToLower(filepath.Ext(name))
@@ -323,6 +582,9 @@ func describeFileByExt(name string) string {
 		".gz":   "Archive File",
 		".rar":  "Archive File",
 		".pdf":  "PDF Document",
+		".epub": "EPUB Book",
+		".docx": "Word Document",
+		".odt":  "OpenDocument Text",
 		".mp4":  "Video File",
 		".mkv":  "Video File",
 		".avi":  "Video File",
@@ -0,0 +1,241 @@
+package preview
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// tableExtensions maps the extensions previewed as tables to the delimiter
+// used when the first lines don't show another one
+var tableExtensions = map[string]rune{
+	".csv": ',',
+	".tsv": '\t',
+	".tab": '\t',
+	".psv": '|',
+}
+
+// tableDelimiters are the delimiters looked for in the first lines
+var tableDelimiters = []rune{',', '\t', ';', '|'}
+
+// Limits of table previews
+const (
+	maxCellWidth  = 30      // Longer cells are cut, ending in "~"
+	maxTableScan  = 8 << 20 // Rows are counted in this much of a file
+	tableSniff    = 10      // Lines looked at to find the delimiter
+	columnPadding = "  "    // Between columns
+)
+
+// TableInfo describes a table preview
+type TableInfo struct {
+	Rows      int  // Data rows, without the header
+	Columns   int
+	More      bool // The file goes on past the rows counted
+	Delimiter rune
+}
+
+// IsTableFile reports whether path is previewed as a table of
+// delimiter-separated values
+func IsTableFile(path string) bool {
+	_, ok := tableExtensions[strings.ToLower(filepath.Ext(path))]
+	return ok
+}
+
+// tableHandler names how a table file is previewed
+func tableHandler(path string) string {
+	switch tableExtensions[strings.ToLower(filepath.Ext(path))] {
+	case '\t':
+		return "Table (tab-separated, delimiter detected from the first lines)"
+	case '|':
+		return "Table (pipe-separated, delimiter detected from the first lines)"
+	}
+	return "Table (comma-separated, delimiter detected from the first lines)"
+}
+
+// tableLines reads a delimiter-separated file into aligned columns under
+// its header row, followed by a rule. Up to maxLines rows are shown (0 for
+// all); rows are counted in the first maxTableScan bytes.
+func tableLines(path string, maxLines int) ([]string, TableInfo, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, TableInfo{}, err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(io.LimitReader(file, maxTableScan))
+	head, _ := reader.Peek(64 << 10)
+	if bytes.IndexByte(head, 0) >= 0 {
+		return nil, TableInfo{}, errors.New("binary data")
+	}
+	info := TableInfo{Delimiter: sniffDelimiter(head, tableExtensions[strings.ToLower(filepath.Ext(path))])}
+	if stat, err := file.Stat(); err == nil && stat.Size() > maxTableScan {
+		info.More = true
+	}
+
+	records := csv.NewReader(reader)
+	records.Comma = info.Delimiter
+	records.FieldsPerRecord = -1
+	records.LazyQuotes = true
+	var rows [][]string
+	count := 0
+	for {
+		record, err := records.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// A row cut off at the scan limit is no reason to give up
+			if info.More && count > 0 {
+				break
+			}
+			return nil, TableInfo{}, err
+		}
+		count++
+		info.Columns = max(info.Columns, len(record))
+		if maxLines <= 0 || len(rows) < maxLines {
+			rows = append(rows, record)
+		}
+	}
+	if len(rows) == 0 {
+		return nil, TableInfo{}, errors.New("no rows")
+	}
+	info.Rows = count - 1
+	return alignTable(rows, info.Columns), info, nil
+}
+
+// sniffDelimiter returns the delimiter that splits the first lines of head
+// into the same number of fields, the most fields when several do, or
+// fallback when none does
+func sniffDelimiter(head []byte, fallback rune) rune {
+	lines := strings.Split(strings.TrimRight(string(head), "\r\n"), "\n")
+	if len(lines) > tableSniff {
+		lines = lines[:tableSniff]
+	} else if len(lines) > 1 && len(head) == 64<<10 {
+		lines = lines[:len(lines)-1] // The last line may be cut off
+	}
+	best, bestFields := fallback, 0
+	for _, delimiter := range tableDelimiters {
+		fields := -1
+		for _, line := range lines {
+			n := strings.Count(line, string(delimiter)) + 1
+			if fields >= 0 && n != fields {
+				fields = 0
+				break
+			}
+			fields = n
+		}
+		if fields > bestFields && (fields > 1 || delimiter == fallback) {
+			best, bestFields = delimiter, fields
+		}
+	}
+	return best
+}
+
+// alignTable pads each cell to the width of its column, with numeric
+// columns aligned right, and puts a rule under the first row
+func alignTable(rows [][]string, columns int) []string {
+	widths := make([]int, columns)
+	numeric := make([]bool, columns)
+	for i := range numeric {
+		numeric[i] = len(rows) > 1
+	}
+	for r, row := range rows {
+		for c := range row {
+			row[c] = tableCell(row[c])
+			widths[c] = max(widths[c], stringWidth(row[c]))
+			if _, err := strconv.ParseFloat(row[c], 64); r > 0 && row[c] != "" && err != nil {
+				numeric[c] = false
+			}
+		}
+	}
+
+	lines := make([]string, 0, len(rows)+1)
+	for r, row := range rows {
+		var line strings.Builder
+		for c := 0; c < columns; c++ {
+			cell := ""
+			if c < len(row) {
+				cell = row[c]
+			}
+			pad := strings.Repeat(" ", widths[c]-stringWidth(cell))
+			if c > 0 {
+				line.WriteString(columnPadding)
+			}
+			if numeric[c] && r > 0 {
+				line.WriteString(pad + cell)
+			} else {
+				line.WriteString(cell + pad)
+			}
+		}
+		lines = append(lines, strings.TrimRight(line.String(), " "))
+		if r == 0 {
+			rule := make([]string, columns)
+			for c, width := range widths {
+				rule[c] = strings.Repeat("-", width)
+			}
+			lines = append(lines, strings.Join(rule, columnPadding))
+		}
+	}
+	return lines
+}
+
+// tableCell flattens a cell to one line and cuts it to maxCellWidth
+func tableCell(cell string) string {
+	cell = strings.Join(strings.Fields(cell), " ")
+	if stringWidth(cell) <= maxCellWidth {
+		return cell
+	}
+	width := 0
+	for i, r := range cell {
+		if width+RuneWidth(r) > maxCellWidth-1 {
+			return cell[:i] + "~"
+		}
+		width += RuneWidth(r)
+	}
+	return cell
+}
+
+// stringWidth returns the display width of s
+func stringWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		width += RuneWidth(r)
+	}
+	return width
+}
+
+// SkipColumns drops the first n display columns of line, for previews
+// scrolled to the right. A wide rune cut in half becomes a space.
+func SkipColumns(line string, n int) string {
+	if n <= 0 {
+		return line
+	}
+	width := 0
+	for i, r := range line {
+		if width >= n {
+			return strings.Repeat(" ", width-n) + line[i:]
+		}
+		width += RuneWidth(r)
+	}
+	return strings.Repeat(" ", max(width-n, 0))
+}
+
+// describeTable summarizes a table for the metadata bar, e.g.
+// "120 rows x 5 columns"
+func describeTable(info TableInfo) string {
+	rows := fmt.Sprintf("%d rows", info.Rows)
+	if info.Rows == 1 {
+		rows = "1 row"
+	}
+	if info.More {
+		rows = fmt.Sprintf("%d+ rows", info.Rows)
+	}
+	return fmt.Sprintf("%s x %d columns", rows, info.Columns)
+}
@@ -0,0 +1,298 @@
+package preview
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"image"
+	_ "image/gif"  // Register GIF decoder
+	_ "image/jpeg" // Register JPEG decoder
+	_ "image/png"  // Register PNG decoder
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/alexcostache/Xplorer/internal/paths"
+	"github.com/alexcostache/Xplorer/internal/screen"
+	"github.com/nsf/termbox-go"
+)
+
+// Maximum thumbnail resolution in pixels. Each terminal cell shows two
+// vertically stacked pixels, so this covers a 160x60 cell preview.
+const (
+	thumbnailMaxWidth  = 160
+	thumbnailMaxHeight = 120
+)
+
+// thumbnailMemoryLimit bounds the number of thumbnails kept in memory
+const thumbnailMemoryLimit = 256
+
+// Thumbnail is a downscaled RGB copy of an image used for previews
+type Thumbnail struct {
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+	SourceWidth  int    `json:"source_width"`
+	SourceHeight int    `json:"source_height"`
+	Pixels       []byte `json:"pixels"` // RGB triples, row-major
+}
+
+// ThumbnailCache caches thumbnails in memory and on disk, keyed by
+// path, size and modification time. It is safe for concurrent use.
+type ThumbnailCache struct {
+	dir    string
+	mu     sync.Mutex // Guards memory
+	memory map[string]*Thumbnail
+}
+
+// NewThumbnailCache creates a thumbnail cache in the user cache directory
+func NewThumbnailCache() *ThumbnailCache {
+	dir := ""
+	if cacheDir := paths.CacheDir(); cacheDir != "" {
+		dir = filepath.Join(cacheDir, "thumbnails")
+	} else if cacheDir, err := os.UserCacheDir(); err == nil {
+		dir = filepath.Join(cacheDir, "xplorer", "thumbnails")
+	}
+	return &ThumbnailCache{
+		dir:    dir,
+		memory: make(map[string]*Thumbnail),
+	}
+}
+
+// IsImageFile checks if a file can be previewed as an image
+func IsImageFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".png", ".jpg", ".jpeg", ".gif":
+		return true
+	}
+	return false
+}
+
+// Get returns the thumbnail for an image, decoding it only on a cache miss
+func (c *ThumbnailCache) Get(path string, info os.FileInfo) (*Thumbnail, error) {
+	key := thumbnailKey(path, info)
+	c.mu.Lock()
+	t, ok := c.memory[key]
+	c.mu.Unlock()
+	if ok {
+		return t, nil
+	}
+
+	if t = c.load(key); t == nil {
+		var err error
+		if t, err = decodeThumbnail(path); err != nil {
+			return nil, err
+		}
+		c.save(key, t)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// Start over rather than growing without bound; the disk cache keeps the rest
+	if len(c.memory) >= thumbnailMemoryLimit {
+		c.memory = make(map[string]*Thumbnail)
+	}
+	c.memory[key] = t
+	return t, nil
+}
+
+// thumbnailKey builds a cache key that changes whenever the file changes
+func thumbnailKey(path string, info os.FileInfo) string {
+	sum := sha1.Sum([]byte(fmt.Sprintf("%s|%d|%d", path, info.Size(), info.ModTime().UnixNano())))
+	return hex.EncodeToString(sum[:])
+}
+
+// load reads a thumbnail from the disk cache
+func (c *ThumbnailCache) load(key string) *Thumbnail {
+	if c.dir == "" {
+		return nil
+	}
+	data, err := os.ReadFile(filepath.Join(c.dir, key+".json"))
+	if err != nil {
+		return nil
+	}
+	var t Thumbnail
+	if err := json.Unmarshal(data, &t); err != nil || len(t.Pixels) != t.Width*t.Height*3 {
+		return nil
+	}
+	return &t
+}
+
+// save writes a thumbnail to the disk cache
+func (c *ThumbnailCache) save(key string, t *Thumbnail) {
+	if c.dir == "" {
+		return
+	}
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return
+	}
+	data, err := json.Marshal(t)
+	if err != nil {
+		return
+	}
+	_ = os.WriteFile(filepath.Join(c.dir, key+".json"), data, 0644)
+}
+
+// decodeThumbnail decodes an image and downscales it with box sampling
+func decodeThumbnail(path string) (*Thumbnail, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := img.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	if srcW == 0 || srcH == 0 {
+		return nil, fmt.Errorf("empty image")
+	}
+
+	// Preserve aspect ratio within the maximum thumbnail size
+	w, h := srcW, srcH
+	if w > thumbnailMaxWidth {
+		h = h * thumbnailMaxWidth / w
+		w = thumbnailMaxWidth
+	}
+	if h > thumbnailMaxHeight {
+		w = w * thumbnailMaxHeight / h
+		h = thumbnailMaxHeight
+	}
+	w = max(w, 1)
+	h = max(h, 1)
+
+	pixels := make([]byte, 0, w*h*3)
+	for ty := 0; ty < h; ty++ {
+		y0 := bounds.Min.Y + ty*srcH/h
+		y1 := max(bounds.Min.Y+(ty+1)*srcH/h, y0+1)
+		for tx := 0; tx < w; tx++ {
+			x0 := bounds.Min.X + tx*srcW/w
+			x1 := max(bounds.Min.X+(tx+1)*srcW/w, x0+1)
+
+			// Average the source pixels covered by this thumbnail pixel
+			var rSum, gSum, bSum, count uint64
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					r, g, b, _ := img.At(x, y).RGBA()
+					rSum += uint64(r >> 8)
+					gSum += uint64(g >> 8)
+					bSum += uint64(b >> 8)
+					count++
+				}
+			}
+			pixels = append(pixels, byte(rSum/count), byte(gSum/count), byte(bSum/count))
+		}
+	}
+
+	return &Thumbnail{
+		Width:        w,
+		Height:       h,
+		SourceWidth:  srcW,
+		SourceHeight: srcH,
+		Pixels:       pixels,
+	}, nil
+}
+
+// paletteColor pairs a termbox color with its approximate RGB value
+type paletteColor struct {
+	attr    termbox.Attribute
+	r, g, b int
+}
+
+// basePalette holds the eight standard terminal colors
+var basePalette = []paletteColor{
+	{termbox.ColorBlack, 0, 0, 0},
+	{termbox.ColorRed, 170, 0, 0},
+	{termbox.ColorGreen, 0, 170, 0},
+	{termbox.ColorYellow, 170, 85, 0},
+	{termbox.ColorBlue, 0, 0, 170},
+	{termbox.ColorMagenta, 170, 0, 170},
+	{termbox.ColorCyan, 0, 170, 170},
+	{termbox.ColorWhite, 170, 170, 170},
+}
+
+// brightPalette adds the bold (bright) variants usable as foreground colors
+var brightPalette = append(append([]paletteColor{}, basePalette...),
+	paletteColor{termbox.ColorBlack | termbox.AttrBold, 85, 85, 85},
+	paletteColor{termbox.ColorRed | termbox.AttrBold, 255, 85, 85},
+	paletteColor{termbox.ColorGreen | termbox.AttrBold, 85, 255, 85},
+	paletteColor{termbox.ColorYellow | termbox.AttrBold, 255, 255, 85},
+	paletteColor{termbox.ColorBlue | termbox.AttrBold, 85, 85, 255},
+	paletteColor{termbox.ColorMagenta | termbox.AttrBold, 255, 85, 255},
+	paletteColor{termbox.ColorCyan | termbox.AttrBold, 85, 255, 255},
+	paletteColor{termbox.ColorWhite | termbox.AttrBold, 255, 255, 255},
+)
+
+// nearestColor returns the palette color closest to the given RGB value
+func nearestColor(palette []paletteColor, r, g, b int) termbox.Attribute {
+	best := palette[0].attr
+	bestDist := -1
+	for _, c := range palette {
+		dr, dg, db := r-c.r, g-c.g, b-c.b
+		dist := dr*dr + dg*dg + db*db
+		if bestDist < 0 || dist < bestDist {
+			best = c.attr
+			bestDist = dist
+		}
+	}
+	return best
+}
+
+// pixel returns the RGB value of a thumbnail pixel
+func (t *Thumbnail) pixel(x, y int) (int, int, int) {
+	i := (y*t.Width + x) * 3
+	return int(t.Pixels[i]), int(t.Pixels[i+1]), int(t.Pixels[i+2])
+}
+
+// fitPixels fits the thumbnail into width x (height*2) pixels, never upscaling
+func (t *Thumbnail) fitPixels(width, height int) (int, int) {
+	cols, rows := t.Width, t.Height
+	if cols > width {
+		rows = rows * width / cols
+		cols = width
+	}
+	if rows > height*2 {
+		cols = cols * height * 2 / rows
+		rows = height * 2
+	}
+	return max(cols, 1), max(rows, 1)
+}
+
+// FitSize returns the number of cell columns and rows the thumbnail
+// occupies when drawn into the given area
+func (t *Thumbnail) FitSize(width, height int) (int, int) {
+	cols, rows := t.fitPixels(width, height)
+	return cols, (rows + 1) / 2
+}
+
+// DrawImage draws a thumbnail scaled to fit the given cell area using
+// half-block characters, two pixels per cell
+func DrawImage(x, y, width, height int, t *Thumbnail) {
+	if t == nil || width <= 0 || height <= 0 {
+		return
+	}
+
+	cols, rows := t.fitPixels(width, height)
+
+	for cy := 0; cy*2 < rows; cy++ {
+		for cx := 0; cx < cols; cx++ {
+			sx := cx * t.Width / cols
+			topY := (cy * 2) * t.Height / rows
+			r1, g1, b1 := t.pixel(sx, topY)
+			fg := nearestColor(brightPalette, r1, g1, b1)
+
+			bg := termbox.ColorDefault
+			if cy*2+1 < rows {
+				bottomY := (cy*2 + 1) * t.Height / rows
+				r2, g2, b2 := t.pixel(sx, bottomY)
+				bg = nearestColor(basePalette, r2, g2, b2)
+			}
+			screen.SetCell(x+cx, y+cy, '▀', fg, bg)
+		}
+	}
+}
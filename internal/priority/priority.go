@@ -0,0 +1,13 @@
+package priority
+
+// Lower lowers the CPU and IO priority of the OS thread the calling
+// goroutine runs on, so that its work yields to interactive programs. The
+// goroutine must stay locked to the thread (runtime.LockOSThread) until the
+// returned restore function has been called from it. Restore reports false
+// when the thread can't get its priority back, as Linux doesn't allow
+// unprivileged threads to lower their nice value again; the goroutine then
+// has to stay locked, so that the thread ends with it instead of running
+// other goroutines slowly.
+func Lower() (restore func() bool, err error) {
+	return lower()
+}
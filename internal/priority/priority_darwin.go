@@ -0,0 +1,19 @@
+package priority
+
+import "syscall"
+
+// setpriority(2) values that put the calling thread in the background
+// band, which throttles both its CPU and its disk use
+const (
+	prioDarwinThread = 3
+	prioDarwinBG     = 0x1000
+)
+
+func lower() (func() bool, error) {
+	if err := syscall.Setpriority(prioDarwinThread, 0, prioDarwinBG); err != nil {
+		return nil, err
+	}
+	return func() bool {
+		return syscall.Setpriority(prioDarwinThread, 0, 0) == nil
+	}, nil
+}
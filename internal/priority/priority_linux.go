@@ -0,0 +1,43 @@
+package priority
+
+import "syscall"
+
+// ioprio_set(2) values: IO priorities of one thread in the best-effort
+// class, whose level 7 is the lowest (as ionice -c2 -n7)
+const (
+	ioprioWhoProcess = 1
+	ioprioClassShift = 13
+	ioprioClassBE    = 2
+	ioprioLowest     = 7
+)
+
+// backgroundNice is the nice value of lowered threads, as nice(1) uses
+const backgroundNice = 10
+
+func lower() (func() bool, error) {
+	tid := syscall.Gettid()
+	// The raw getpriority returns 20 - nice so as not to return negative numbers
+	raw, err := syscall.Getpriority(syscall.PRIO_PROCESS, tid)
+	if err != nil {
+		return nil, err
+	}
+	nice := 20 - raw
+	ioprio, _, errno := syscall.Syscall(syscall.SYS_IOPRIO_GET, ioprioWhoProcess, uintptr(tid), 0)
+	if errno != 0 {
+		return nil, errno
+	}
+
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOPRIO_SET, ioprioWhoProcess, uintptr(tid), ioprioClassBE<<ioprioClassShift|ioprioLowest); errno != 0 {
+		return nil, errno
+	}
+	if nice < backgroundNice {
+		if err := syscall.Setpriority(syscall.PRIO_PROCESS, tid, backgroundNice); err != nil {
+			syscall.Syscall(syscall.SYS_IOPRIO_SET, ioprioWhoProcess, uintptr(tid), ioprio)
+			return nil, err
+		}
+	}
+	return func() bool {
+		_, _, errno := syscall.Syscall(syscall.SYS_IOPRIO_SET, ioprioWhoProcess, uintptr(tid), ioprio)
+		return syscall.Setpriority(syscall.PRIO_PROCESS, tid, nice) == nil && errno == 0
+	}, nil
+}
@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !windows
+
+package priority
+
+import "errors"
+
+func lower() (func() bool, error) {
+	return nil, errors.New("lowering priority is not supported on this system")
+}
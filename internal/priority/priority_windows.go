@@ -0,0 +1,25 @@
+package priority
+
+import "syscall"
+
+// SetThreadPriority modes that lower the CPU, IO and memory priority of
+// the calling thread and raise them again
+const (
+	threadModeBackgroundBegin = 0x00010000
+	threadModeBackgroundEnd   = 0x00020000
+)
+
+// currentThread is the pseudo handle GetCurrentThread returns
+const currentThread = ^uintptr(1)
+
+var setThreadPriority = syscall.NewLazyDLL("kernel32.dll").NewProc("SetThreadPriority")
+
+func lower() (func() bool, error) {
+	if ok, _, err := setThreadPriority.Call(currentThread, threadModeBackgroundBegin); ok == 0 {
+		return nil, err
+	}
+	return func() bool {
+		ok, _, _ := setThreadPriority.Call(currentThread, threadModeBackgroundEnd)
+		return ok != 0
+	}, nil
+}
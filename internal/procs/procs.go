@@ -0,0 +1,179 @@
+package procs
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxFinished is how many ended processes are kept for the process panel
+const maxFinished = 20
+
+// Process is a program started in the background, such as a GUI editor,
+// a terminal or a file manager
+type Process struct {
+	ID      int
+	Name    string // What it was started as, e.g. "Editor"
+	Command string // The command line
+	PID     int
+	Started time.Time
+	Ended   time.Time // Zero while running
+	Err     error     // Why it failed; nil when it exited cleanly
+	Killed  bool      // Ended by Kill
+}
+
+// Running reports whether the process hasn't ended yet
+func (p Process) Running() bool {
+	return p.Ended.IsZero()
+}
+
+// Status describes the state of the process in a few words
+func (p Process) Status() string {
+	switch {
+	case p.Running():
+		return "running " + time.Since(p.Started).Round(time.Second).String()
+	case p.Killed:
+		return "killed"
+	case p.Err != nil:
+		return "failed: " + p.Err.Error()
+	}
+	return "exited"
+}
+
+// entry is a tracked process with the command that runs it
+type entry struct {
+	Process
+	cmd *exec.Cmd
+}
+
+// Manager starts background processes and keeps track of them. Every
+// process is waited for, so none is left behind as a zombie.
+type Manager struct {
+	mu     sync.Mutex
+	procs  []*entry // In the order they were started
+	nextID int
+}
+
+// NewManager creates a process manager
+func NewManager() *Manager {
+	return &Manager{}
+}
+
+// Start starts cmd in the background under name and tracks it until it
+// ends
+func (m *Manager) Start(name string, cmd *exec.Cmd) error {
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start %s: %v", name, err)
+	}
+
+	m.mu.Lock()
+	m.nextID++
+	e := &entry{
+		Process: Process{
+			ID:      m.nextID,
+			Name:    name,
+			Command: strings.Join(cmd.Args, " "),
+			PID:     cmd.Process.Pid,
+			Started: time.Now(),
+		},
+		cmd: cmd,
+	}
+	m.procs = append(m.procs, e)
+	m.prune()
+	m.mu.Unlock()
+
+	go m.wait(e)
+	return nil
+}
+
+// wait reaps a process and records how it ended
+func (m *Manager) wait(e *entry) {
+	err := e.cmd.Wait()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e.Ended = time.Now()
+	if !e.Killed {
+		e.Err = err
+	}
+}
+
+// prune drops the oldest ended processes beyond maxFinished. The caller
+// holds mu.
+func (m *Manager) prune() {
+	finished := 0
+	for _, e := range m.procs {
+		if !e.Running() {
+			finished++
+		}
+	}
+	kept := m.procs[:0]
+	for _, e := range m.procs {
+		if !e.Running() && finished > maxFinished {
+			finished--
+			continue
+		}
+		kept = append(kept, e)
+	}
+	m.procs = kept
+}
+
+// List returns the tracked processes, most recently started first
+func (m *Manager) List() []Process {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	list := make([]Process, 0, len(m.procs))
+	for i := len(m.procs) - 1; i >= 0; i-- {
+		list = append(list, m.procs[i].Process)
+	}
+	return list
+}
+
+// Running returns how many tracked processes haven't ended yet
+func (m *Manager) Running() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	running := 0
+	for _, e := range m.procs {
+		if e.Running() {
+			running++
+		}
+	}
+	return running
+}
+
+// Kill ends the running process with the given ID
+func (m *Manager) Kill(id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, e := range m.procs {
+		if e.ID != id {
+			continue
+		}
+		if !e.Running() {
+			return fmt.Errorf("%s has already ended", e.Name)
+		}
+		if err := e.cmd.Process.Kill(); err != nil {
+			return err
+		}
+		e.Killed = true
+		return nil
+	}
+	return errors.New("no such process")
+}
+
+// Clear forgets the processes that have ended
+func (m *Manager) Clear() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	kept := m.procs[:0]
+	for _, e := range m.procs {
+		if e.Running() {
+			kept = append(kept, e)
+		}
+	}
+	m.procs = kept
+}
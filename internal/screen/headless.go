@@ -0,0 +1,231 @@
+package screen
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nsf/termbox-go"
+)
+
+// Headless is an in-memory screen for tests: events are fed with Send and
+// what the UI drew is read back with Text, Row and Cell after the last Flush
+type Headless struct {
+	mu      sync.Mutex
+	width   int
+	height  int
+	back    []termbox.Cell
+	front   []termbox.Cell // As of the last Flush
+	queue   []termbox.Event
+	wake    chan struct{}
+	waiting bool      // PollEvent is blocked on an empty queue
+	since   time.Time // When PollEvent started waiting
+	closed  bool
+	mode    termbox.InputMode
+}
+
+// NewHeadless creates a headless screen of the given size
+func NewHeadless(width, height int) *Headless {
+	h := &Headless{width: width, height: height, wake: make(chan struct{}, 1)}
+	h.back = make([]termbox.Cell, width*height)
+	h.front = make([]termbox.Cell, width*height)
+	return h
+}
+
+// Init does nothing; a headless screen is always ready
+func (h *Headless) Init() error { return nil }
+
+// Close marks the screen closed; WaitIdle returns at once afterwards
+func (h *Headless) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+}
+
+// Closed reports whether the UI has closed the screen (quit)
+func (h *Headless) Closed() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.closed
+}
+
+// Size returns the screen size
+func (h *Headless) Size() (int, int) { return h.width, h.height }
+
+// SetCell changes a cell of the back buffer; cells off screen are ignored
+func (h *Headless) SetCell(x, y int, ch rune, fg, bg termbox.Attribute) {
+	if x < 0 || y < 0 || x >= h.width || y >= h.height {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.back[y*h.width+x] = termbox.Cell{Ch: ch, Fg: fg, Bg: bg}
+}
+
+// SetCursor does nothing; the cursor isn't tracked
+func (h *Headless) SetCursor(x, y int) {}
+
+// HideCursor does nothing; the cursor isn't tracked
+func (h *Headless) HideCursor() {}
+
+// Clear blanks the back buffer
+func (h *Headless) Clear(fg, bg termbox.Attribute) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i := range h.back {
+		h.back[i] = termbox.Cell{Ch: ' ', Fg: fg, Bg: bg}
+	}
+	return nil
+}
+
+// Flush copies the back buffer to the front buffer that tests inspect
+func (h *Headless) Flush() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	copy(h.front, h.back)
+	return nil
+}
+
+// PollEvent returns the next queued event, waiting for one if needed
+func (h *Headless) PollEvent() termbox.Event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for len(h.queue) == 0 {
+		h.waiting, h.since = true, time.Now()
+		h.mu.Unlock()
+		<-h.wake
+		h.mu.Lock()
+	}
+	h.waiting = false
+	ev := h.queue[0]
+	h.queue = h.queue[1:]
+	return ev
+}
+
+// Interrupt queues an EventInterrupt
+func (h *Headless) Interrupt() {
+	h.push(termbox.Event{Type: termbox.EventInterrupt})
+}
+
+// SetInputMode records the input mode and returns it
+func (h *Headless) SetInputMode(mode termbox.InputMode) termbox.InputMode {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if mode != termbox.InputCurrent {
+		h.mode = mode
+	}
+	return h.mode
+}
+
+// push queues events and wakes PollEvent
+func (h *Headless) push(events ...termbox.Event) {
+	h.mu.Lock()
+	h.queue = append(h.queue, events...)
+	h.mu.Unlock()
+	select {
+	case h.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Send queues events and waits until the UI has handled them all and has
+// been waiting for input for settle, so timers it started meanwhile (such
+// as the delay telling Esc from an Alt combination) have fired. It returns
+// false if the UI closed the screen or didn't settle within a few seconds.
+func (h *Headless) Send(settle time.Duration, events ...termbox.Event) bool {
+	h.push(events...)
+	return h.WaitIdle(settle)
+}
+
+// WaitIdle waits until the UI has been waiting for input for settle
+func (h *Headless) WaitIdle(settle time.Duration) bool {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		h.mu.Lock()
+		closed := h.closed
+		idle := h.waiting && len(h.queue) == 0 && time.Since(h.since) >= settle
+		h.mu.Unlock()
+		if closed {
+			return false
+		}
+		if idle {
+			return true
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return false
+}
+
+// Cell returns the cell at x, y as last flushed
+func (h *Headless) Cell(x, y int) termbox.Cell {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.front[y*h.width+x]
+}
+
+// Row returns the text of row y as last flushed, without trailing spaces.
+// Wide characters are followed by the cell they cover.
+func (h *Headless) Row(y int) string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var b strings.Builder
+	for _, cell := range h.front[y*h.width : (y+1)*h.width] {
+		if cell.Ch == 0 {
+			b.WriteRune(' ')
+		} else {
+			b.WriteRune(cell.Ch)
+		}
+	}
+	return strings.TrimRight(b.String(), " ")
+}
+
+// Text returns the whole screen as last flushed, one line per row
+func (h *Headless) Text() string {
+	rows := make([]string, h.height)
+	for y := range rows {
+		rows[y] = h.Row(y)
+	}
+	return strings.Join(rows, "\n")
+}
+
+// Key returns a key event for a special key
+func Key(key termbox.Key) termbox.Event {
+	return termbox.Event{Type: termbox.EventKey, Key: key}
+}
+
+// Char returns a key event for a typed character
+func Char(ch rune) termbox.Event {
+	if ch == ' ' {
+		return Key(termbox.KeySpace)
+	}
+	return termbox.Event{Type: termbox.EventKey, Ch: ch}
+}
+
+// Type returns key events typing text
+func Type(text string) []termbox.Event {
+	var events []termbox.Event
+	for _, ch := range text {
+		events = append(events, Char(ch))
+	}
+	return events
+}
+
+// Click returns a left mouse button press at x, y
+func Click(x, y int) termbox.Event {
+	return termbox.Event{Type: termbox.EventMouse, Key: termbox.MouseLeft, MouseX: x, MouseY: y}
+}
+
+// Drag returns a mouse motion to x, y with the left button held down
+func Drag(x, y int) termbox.Event {
+	return termbox.Event{Type: termbox.EventMouse, Key: termbox.MouseLeft, Mod: termbox.ModMotion, MouseX: x, MouseY: y}
+}
+
+// Release returns a mouse button release at x, y
+func Release(x, y int) termbox.Event {
+	return termbox.Event{Type: termbox.EventMouse, Key: termbox.MouseRelease, MouseX: x, MouseY: y}
+}
+
+// Hover returns a mouse motion to x, y with no button held
+func Hover(x, y int) termbox.Event {
+	return termbox.Event{Type: termbox.EventMouse, Key: termbox.MouseRelease, Mod: termbox.ModMotion, MouseX: x, MouseY: y}
+}
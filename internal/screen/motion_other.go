@@ -0,0 +1,7 @@
+//go:build !unix
+
+package screen
+
+// setMotion does nothing: the Windows console reports motion only while a
+// button is held
+func setMotion(on bool) {}
@@ -0,0 +1,29 @@
+//go:build unix
+
+package screen
+
+import "os"
+
+// motion is whether the terminal was asked to report all mouse motion
+var motion bool
+
+// setMotion asks the terminal to report mouse motion without a button held
+// (xterm's any-event tracking), or to stop. Terminals that don't know the
+// mode ignore it.
+func setMotion(on bool) {
+	if on == motion {
+		return
+	}
+	tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		return
+	}
+	defer tty.Close()
+	seq := "\x1b[?1003l"
+	if on {
+		seq = "\x1b[?1003h"
+	}
+	if _, err := tty.WriteString(seq); err == nil {
+		motion = on
+	}
+}
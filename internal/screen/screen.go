@@ -0,0 +1,106 @@
+package screen
+
+import (
+	"sync/atomic"
+
+	"github.com/nsf/termbox-go"
+)
+
+// Backend is the terminal the UI draws on and reads events from. The
+// functions of this package forward to the active backend, which is
+// termbox unless Use installs another one (such as a Headless screen for
+// tests). They mirror the termbox functions of the same name.
+type Backend interface {
+	Init() error
+	Close()
+	Size() (int, int)
+	SetCell(x, y int, ch rune, fg, bg termbox.Attribute)
+	SetCursor(x, y int)
+	HideCursor()
+	Clear(fg, bg termbox.Attribute) error
+	Flush() error
+	PollEvent() termbox.Event
+	Interrupt()
+	SetInputMode(mode termbox.InputMode) termbox.InputMode
+}
+
+// terminal is the real terminal, through termbox
+type terminal struct{}
+
+func (terminal) Init() error      { return termbox.Init() }
+func (terminal) Close()           { setMotion(false); termbox.Close() }
+func (terminal) Size() (int, int) { return termbox.Size() }
+func (terminal) SetCell(x, y int, ch rune, fg, bg termbox.Attribute) {
+	termbox.SetCell(x, y, ch, fg, bg)
+}
+func (terminal) SetCursor(x, y int)                   { termbox.SetCursor(x, y) }
+func (terminal) HideCursor()                          { termbox.HideCursor() }
+func (terminal) Clear(fg, bg termbox.Attribute) error { return termbox.Clear(fg, bg) }
+func (terminal) Flush() error                         { return termbox.Flush() }
+func (terminal) PollEvent() termbox.Event             { return termbox.PollEvent() }
+func (terminal) Interrupt()                           { termbox.Interrupt() }
+func (terminal) SetInputMode(mode termbox.InputMode) termbox.InputMode {
+	mode = termbox.SetInputMode(mode)
+	// termbox reports motion only while a button is held; hover hints
+	// need all of it
+	setMotion(mode&termbox.InputMouse != 0)
+	return mode
+}
+
+// active holds the backend in use. It is swapped atomically, as timers of
+// an app that has quit may still interrupt it while tests install another.
+var active atomic.Pointer[Backend]
+
+func init() {
+	var b Backend = terminal{}
+	active.Store(&b)
+}
+
+// backend returns the backend in use
+func backend() Backend { return *active.Load() }
+
+// Use makes b the backend for all drawing and input, returning the previous
+// one so it can be restored. Call it before the UI starts.
+func Use(b Backend) Backend {
+	return *active.Swap(&b)
+}
+
+// Init initializes the screen
+func Init() error { return backend().Init() }
+
+// Close restores the terminal
+func Close() { backend().Close() }
+
+// Size returns the screen width and height in cells
+func Size() (int, int) { return backend().Size() }
+
+// SetCell changes the cell at x, y in the back buffer
+func SetCell(x, y int, ch rune, fg, bg termbox.Attribute) { backend().SetCell(x, y, ch, fg, bg) }
+
+// SetCursor shows the cursor at x, y
+func SetCursor(x, y int) { backend().SetCursor(x, y) }
+
+// HideCursor hides the cursor
+func HideCursor() { backend().HideCursor() }
+
+// Clear fills the back buffer with blank cells
+func Clear(fg, bg termbox.Attribute) error { return backend().Clear(fg, bg) }
+
+// Flush shows the back buffer
+func Flush() error { return backend().Flush() }
+
+// PollEvent waits for the next event
+func PollEvent() termbox.Event { return backend().PollEvent() }
+
+// Interrupt makes PollEvent return an EventInterrupt; it is safe to call
+// from any goroutine
+func Interrupt() { backend().Interrupt() }
+
+// SetInputMode selects which input events are reported
+func SetInputMode(mode termbox.InputMode) termbox.InputMode { return backend().SetInputMode(mode) }
+
+// IsHover reports whether ev is mouse motion without a button held. The
+// terminal backend asks for it whenever the mouse is enabled.
+func IsHover(ev termbox.Event) bool {
+	return ev.Type == termbox.EventMouse && ev.Key == termbox.MouseRelease && ev.Mod&termbox.ModMotion != 0
+}
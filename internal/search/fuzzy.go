@@ -0,0 +1,176 @@
+package search
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/alexcostache/Xplorer/pkg/filesystem"
+)
+
+const maxFiles = 200000 // Stop listing a tree after this many entries
+
+// Fuzzy scoring: every matched character earns scoreMatch, plus bonuses for
+// starting a word, following the previous match directly and lying in the
+// file name; every skipped character between matches costs one point
+const (
+	scoreMatch       = 16
+	bonusBoundary    = 8
+	bonusConsecutive = 8
+	bonusBaseName    = 4
+	penaltyGap       = 1
+)
+
+// FuzzyMatch is a path matched by a fuzzy query
+type FuzzyMatch struct {
+	Path      string
+	Score     int
+	Positions []int // Rune offsets of the matched characters in Path
+}
+
+// Files lists the files and directories below root as paths relative to
+// root, directories with a trailing separator. The second result is true
+// when the listing stopped early because the tree is too large. Canceling
+// ctx stops the listing with ctx's error.
+func Files(ctx context.Context, root string, showHidden bool) ([]string, bool, error) {
+	var files []string
+	truncated := false
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil || path == root {
+			return nil // Skip unreadable entries
+		}
+		if !showHidden && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			rel += string(filepath.Separator)
+		}
+		files = append(files, rel)
+		if len(files) >= maxFiles {
+			truncated = true
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	return files, truncated, err
+}
+
+// Fuzzy returns the paths containing the characters of query in order,
+// best match first, keeping at most limit matches (0 keeps all). Spaces in
+// query are ignored. Ties go to the shorter path.
+func Fuzzy(query string, paths []string, mode filesystem.CaseMode, limit int) []FuzzyMatch {
+	pattern := []rune(strings.ReplaceAll(query, " ", ""))
+	ignoreCase := mode == filesystem.CaseInsensitive ||
+		(mode == filesystem.CaseSmart && !strings.ContainsFunc(query, unicode.IsUpper))
+	if ignoreCase {
+		for i, r := range pattern {
+			pattern[i] = unicode.ToLower(r)
+		}
+	}
+
+	var matches []FuzzyMatch
+	for _, path := range paths {
+		if m, ok := fuzzyMatch(pattern, path, ignoreCase); ok {
+			matches = append(matches, m)
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return len(matches[i].Path) < len(matches[j].Path)
+	})
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// fuzzyMatch matches pattern against path. The shortest window ending at
+// the first complete match is scored, found by matching forward and then
+// backward from the last matched character (as fzf does).
+func fuzzyMatch(pattern []rune, path string, ignoreCase bool) (FuzzyMatch, bool) {
+	text := []rune(path)
+	if len(pattern) == 0 {
+		return FuzzyMatch{Path: path}, true
+	}
+	equal := func(i int, r rune) bool {
+		if ignoreCase {
+			return unicode.ToLower(text[i]) == r
+		}
+		return text[i] == r
+	}
+
+	// Forward: find where the first complete match ends
+	p, end := 0, -1
+	for i := range text {
+		if equal(i, pattern[p]) {
+			p++
+			if p == len(pattern) {
+				end = i
+				break
+			}
+		}
+	}
+	if end < 0 {
+		return FuzzyMatch{}, false
+	}
+
+	// Backward: take each character as late as possible
+	positions := make([]int, len(pattern))
+	p = len(pattern) - 1
+	for i := end; i >= 0 && p >= 0; i-- {
+		if equal(i, pattern[p]) {
+			positions[p] = i
+			p--
+		}
+	}
+
+	baseStart := strings.LastIndexFunc(strings.TrimSuffix(path, string(filepath.Separator)), isSeparator) + 1
+	baseStart = len([]rune(path[:baseStart]))
+	score := 0
+	for k, pos := range positions {
+		score += scoreMatch
+		if pos == 0 || isWordStart(text[pos-1], text[pos]) {
+			score += bonusBoundary
+		}
+		if k > 0 {
+			if gap := pos - positions[k-1] - 1; gap == 0 {
+				score += bonusConsecutive
+			} else {
+				score -= gap * penaltyGap
+			}
+		}
+		if pos >= baseStart {
+			score += bonusBaseName
+		}
+	}
+	return FuzzyMatch{Path: path, Score: score, Positions: positions}, true
+}
+
+// isSeparator reports whether r separates path components
+func isSeparator(r rune) bool {
+	return r == '/' || r == filepath.Separator
+}
+
+// isWordStart reports whether cur starts a word after prev: after a path
+// separator or punctuation, or at a lower-to-upper case change
+func isWordStart(prev, cur rune) bool {
+	if isSeparator(prev) || strings.ContainsRune("_-. ", prev) {
+		return true
+	}
+	return unicode.IsLower(prev) && unicode.IsUpper(cur)
+}
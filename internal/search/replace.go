@@ -0,0 +1,141 @@
+package search
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Replacement is one line changed by a replace
+type Replacement struct {
+	Path string
+	Line int    // 1-based line number
+	Old  string // The whole line as it was found
+	New  string // The whole line after replacing
+}
+
+// PlanReplace works out the replacements for grep matches, without writing
+// anything. In regex mode, $1 or ${name} in replacement expand to the
+// pattern's groups; otherwise replacement is used literally. Lines that
+// wouldn't change are left out.
+func PlanReplace(matches []Match, opts Options, replacement string) ([]Replacement, error) {
+	re, err := Compile(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var replacements []Replacement
+	files := map[string][]string{}
+	for _, m := range matches {
+		lines, ok := files[m.Path]
+		if !ok {
+			data, err := os.ReadFile(m.Path)
+			if err != nil {
+				return nil, err
+			}
+			lines = strings.Split(string(data), "\n")
+			files[m.Path] = lines
+		}
+		if m.Line < 1 || m.Line > len(lines) {
+			continue
+		}
+		old := strings.TrimSuffix(lines[m.Line-1], "\r")
+		replaced := replaceLine(re, old, replacement, opts.Regex)
+		if replaced != old {
+			replacements = append(replacements, Replacement{Path: m.Path, Line: m.Line, Old: old, New: replaced})
+		}
+	}
+	return replacements, nil
+}
+
+// replaceLine replaces every match of re in line
+func replaceLine(re *regexp.Regexp, line, replacement string, expand bool) string {
+	if expand {
+		return re.ReplaceAllString(line, replacement)
+	}
+	return re.ReplaceAllLiteralString(line, replacement)
+}
+
+// ApplyReplacements writes replacements to their files and returns how many
+// files changed. Each file is written to a temporary file next to it and
+// renamed over the original, so a failure never leaves it half written. A
+// file whose lines changed since PlanReplace is left alone and reported.
+// backup, if set, is called with each file before it is rewritten.
+func ApplyReplacements(replacements []Replacement, backup func(path string) error) (int, error) {
+	byPath := map[string][]Replacement{}
+	var paths []string
+	for _, r := range replacements {
+		if _, ok := byPath[r.Path]; !ok {
+			paths = append(paths, r.Path)
+		}
+		byPath[r.Path] = append(byPath[r.Path], r)
+	}
+
+	changed := 0
+	var failed []string
+	for _, path := range paths {
+		if backup != nil {
+			if err := backup(path); err != nil {
+				failed = append(failed, fmt.Sprintf("%s: %v", filepath.Base(path), err))
+				continue
+			}
+		}
+		if err := rewriteLines(path, byPath[path]); err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", filepath.Base(path), err))
+			continue
+		}
+		changed++
+	}
+	if len(failed) > 0 {
+		return changed, fmt.Errorf("failed to replace in %s", strings.Join(failed, "; "))
+	}
+	return changed, nil
+}
+
+// rewriteLines applies the replacements of one file, keeping its line
+// endings and permissions
+func rewriteLines(path string, replacements []Replacement) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	lines := strings.Split(string(data), "\n")
+	for _, r := range replacements {
+		if r.Line < 1 || r.Line > len(lines) {
+			return fmt.Errorf("line %d no longer exists", r.Line)
+		}
+		line := lines[r.Line-1]
+		ending := ""
+		if strings.HasSuffix(line, "\r") {
+			line, ending = line[:len(line)-1], "\r"
+		}
+		if line != r.Old {
+			return fmt.Errorf("line %d changed since the search", r.Line)
+		}
+		lines[r.Line-1] = r.New + ending
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
+	if _, err := tmp.WriteString(strings.Join(lines, "\n")); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(info.Mode().Perm()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,122 @@
+package search
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/alexcostache/Xplorer/pkg/filesystem"
+)
+
+const (
+	maxMatches  = 1000             // Stop searching after this many matches
+	maxFileSize = 10 * 1024 * 1024 // Skip files larger than this
+	maxSnippet  = 200              // Truncate matched lines to this many bytes
+)
+
+// Options controls a content search
+type Options struct {
+	Pattern    string
+	Regex      bool                // Treat Pattern as a regular expression instead of literal text
+	Case       filesystem.CaseMode // Same modes as the filename filter
+	ShowHidden bool                // Search hidden files and directories
+}
+
+// Match is one matching line
+type Match struct {
+	Path string
+	Line int    // 1-based line number
+	Text string // The matching line, trimmed
+}
+
+// Compile builds the matcher for the options. Smart case searches
+// case-sensitively only when the pattern contains an uppercase letter.
+func Compile(opts Options) (*regexp.Regexp, error) {
+	pattern := opts.Pattern
+	if !opts.Regex {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	insensitive := opts.Case == filesystem.CaseInsensitive ||
+		(opts.Case == filesystem.CaseSmart && !strings.ContainsFunc(opts.Pattern, unicode.IsUpper))
+	if insensitive {
+		pattern = "(?i)" + pattern
+	}
+	return regexp.Compile(pattern)
+}
+
+// Grep searches the text files under root. The second result is true when
+// the search stopped early because there were too many matches. Canceling
+// ctx stops the search with ctx's error.
+func Grep(ctx context.Context, root string, opts Options) ([]Match, bool, error) {
+	re, err := Compile(opts)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var matches []Match
+	truncated := false
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			return nil // Skip unreadable entries
+		}
+		if path != root && !opts.ShowHidden && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if info, err := d.Info(); err != nil || info.Size() > maxFileSize {
+			return nil
+		}
+
+		found, err := grepFile(path, re, maxMatches-len(matches))
+		if err == nil {
+			matches = append(matches, found...)
+		}
+		if len(matches) >= maxMatches {
+			truncated = true
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	return matches, truncated, err
+}
+
+// grepFile returns up to limit matching lines of a text file
+func grepFile(path string, re *regexp.Regexp, limit int) ([]Match, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.IndexByte(data[:min(len(data), 8000)], 0) >= 0 {
+		return nil, nil // Binary file
+	}
+
+	var matches []Match
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), len(data)+1)
+	for line := 1; scanner.Scan() && len(matches) < limit; line++ {
+		text := scanner.Text()
+		if !re.MatchString(text) {
+			continue
+		}
+		text = strings.TrimSpace(text)
+		if len(text) > maxSnippet {
+			text = text[:maxSnippet]
+		}
+		matches = append(matches, Match{Path: path, Line: line, Text: text})
+	}
+	return matches, nil
+}
@@ -0,0 +1,97 @@
+package selections
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/alexcostache/Xplorer/internal/paths"
+)
+
+// Set is a named set of files, saved from a selection
+type Set struct {
+	Name  string    `json:"name"`
+	Paths []string  `json:"paths"`
+	Saved time.Time `json:"saved"`
+}
+
+// Existing returns the paths of the set that still exist
+func (s Set) Existing() []string {
+	var existing []string
+	for _, path := range s.Paths {
+		if _, err := os.Lstat(path); err == nil {
+			existing = append(existing, path)
+		}
+	}
+	return existing
+}
+
+// Manager keeps named selection sets across sessions, so a curated set of
+// files can be selected again for another operation
+type Manager struct {
+	sets []Set
+}
+
+// NewManager creates a selection set manager and loads the saved sets
+func NewManager() *Manager {
+	m := &Manager{
+		sets: []Set{},
+	}
+	m.Load()
+	return m
+}
+
+// GetAll returns all sets, most recently saved first
+func (m *Manager) GetAll() []Set {
+	return m.sets
+}
+
+// Add saves paths under name, replacing a set with the same name
+func (m *Manager) Add(name string, paths []string) {
+	m.removeName(name)
+	clean := make([]string, len(paths))
+	for i, path := range paths {
+		clean[i] = filepath.Clean(path)
+	}
+	m.sets = append([]Set{{Name: name, Paths: clean, Saved: time.Now()}}, m.sets...)
+	m.Save()
+}
+
+// Remove deletes the set called name
+func (m *Manager) Remove(name string) {
+	if m.removeName(name) {
+		m.Save()
+	}
+}
+
+// removeName drops the set called name, reporting whether there was one
+func (m *Manager) removeName(name string) bool {
+	for i, s := range m.sets {
+		if s.Name == name {
+			m.sets = append(m.sets[:i], m.sets[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// getSetsFile returns the path to the selection sets file
+func (m *Manager) getSetsFile() string {
+	return paths.File(".xp_selections.json")
+}
+
+// Load loads selection sets from disk
+func (m *Manager) Load() {
+	data, err := os.ReadFile(m.getSetsFile())
+	if err != nil {
+		return // File doesn't exist yet, that's ok
+	}
+	_ = json.Unmarshal(data, &m.sets)
+}
+
+// Save saves selection sets to disk
+func (m *Manager) Save() {
+	data, _ := json.MarshalIndent(m.sets, "", "  ")
+	_ = os.WriteFile(m.getSetsFile(), data, 0644)
+}
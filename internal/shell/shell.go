@@ -0,0 +1,97 @@
+package shell
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Expand fills in the placeholders of a shell command line, quoting each
+// path: %f is the file under the cursor, %s the selected files separated by
+// spaces and %d the current directory. %% is a literal %.
+func Expand(line, file, dir string, selection []string) string {
+	quoted := make([]string, len(selection))
+	for i, path := range selection {
+		quoted[i] = Quote(path)
+	}
+	return strings.NewReplacer(
+		"%%", "%",
+		"%f", Quote(file),
+		"%s", strings.Join(quoted, " "),
+		"%d", Quote(dir),
+	).Replace(line)
+}
+
+// Quote makes s a single word for the shell Command runs
+func Quote(s string) string {
+	if runtime.GOOS == "windows" {
+		return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// Command returns a command running line in the user's shell ($SHELL, or
+// sh; cmd on Windows) in dir. Canceling ctx kills it.
+func Command(ctx context.Context, line, dir string) *exec.Cmd {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", line)
+	} else {
+		sh := os.Getenv("SHELL")
+		if sh == "" {
+			sh = "/bin/sh"
+		}
+		cmd = exec.CommandContext(ctx, sh, "-c", line)
+	}
+	cmd.Dir = dir
+	return cmd
+}
+
+// Output collects what a command writes, up to a limit, so a command that
+// never stops writing can't exhaust memory. It is safe for stdout and
+// stderr to write at once.
+type Output struct {
+	mu        sync.Mutex
+	buf       []byte
+	limit     int
+	Truncated bool // More was written than kept
+}
+
+// NewOutput creates an output buffer keeping at most limit bytes
+func NewOutput(limit int) *Output {
+	return &Output{limit: limit}
+}
+
+// Write keeps p as far as the limit allows and always reports success, so
+// the command isn't stopped by a write error
+func (o *Output) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	room := o.limit - len(o.buf)
+	if len(p) > room {
+		o.Truncated = true
+		o.buf = append(o.buf, p[:max(room, 0)]...)
+	} else {
+		o.buf = append(o.buf, p...)
+	}
+	return len(p), nil
+}
+
+// Lines returns the output split into lines, with tabs expanded and
+// carriage returns removed
+func (o *Output) Lines() []string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	text := strings.TrimRight(string(o.buf), "\n")
+	if text == "" {
+		return nil
+	}
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.ReplaceAll(strings.ReplaceAll(line, "\r", ""), "\t", "    ")
+	}
+	return lines
+}
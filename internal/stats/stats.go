@@ -0,0 +1,158 @@
+package stats
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+// Counters are the totals kept for one session or for all of them
+type Counters struct {
+	Sessions     int   `json:"sessions"`
+	Seconds      int64 `json:"seconds"` // Time spent in xp
+	DirsVisited  int   `json:"dirs_visited"`
+	FilesCopied  int   `json:"files_copied"`
+	BytesCopied  int64 `json:"bytes_copied"`
+	FilesMoved   int   `json:"files_moved"`
+	BytesMoved   int64 `json:"bytes_moved"`
+	FilesDeleted int   `json:"files_deleted"` // Trashed or deleted permanently
+}
+
+// add returns the sum of c and o
+func (c Counters) add(o Counters) Counters {
+	return Counters{
+		Sessions:     c.Sessions + o.Sessions,
+		Seconds:      c.Seconds + o.Seconds,
+		DirsVisited:  c.DirsVisited + o.DirsVisited,
+		FilesCopied:  c.FilesCopied + o.FilesCopied,
+		BytesCopied:  c.BytesCopied + o.BytesCopied,
+		FilesMoved:   c.FilesMoved + o.FilesMoved,
+		BytesMoved:   c.BytesMoved + o.BytesMoved,
+		FilesDeleted: c.FilesDeleted + o.FilesDeleted,
+	}
+}
+
+// sub returns c minus o
+func (c Counters) sub(o Counters) Counters {
+	return c.add(Counters{
+		Sessions:     -o.Sessions,
+		Seconds:      -o.Seconds,
+		DirsVisited:  -o.DirsVisited,
+		FilesCopied:  -o.FilesCopied,
+		BytesCopied:  -o.BytesCopied,
+		FilesMoved:   -o.FilesMoved,
+		BytesMoved:   -o.BytesMoved,
+		FilesDeleted: -o.FilesDeleted,
+	})
+}
+
+// statsFile is the on-disk format of the statistics file
+type statsFile struct {
+	Since  time.Time `json:"since"` // Start of the first session counted
+	Totals Counters  `json:"totals"`
+}
+
+// Tracker counts what is done in this session and adds it to the totals
+// kept in a file. It is safe for concurrent use.
+type Tracker struct {
+	mu      sync.Mutex
+	path    string
+	started time.Time
+	session Counters
+	saved   Counters  // Part of session already added to the file
+	stored  statsFile // The file as last read or written
+}
+
+// NewTracker starts counting a session, with the totals of earlier
+// sessions read from path
+func NewTracker(path string) *Tracker {
+	t := &Tracker{path: path, started: time.Now()}
+	t.session.Sessions = 1
+	t.stored = t.load()
+	return t
+}
+
+// load reads the statistics file, starting afresh when it is missing
+func (t *Tracker) load() statsFile {
+	var stored statsFile
+	if data, err := os.ReadFile(t.path); err == nil {
+		_ = json.Unmarshal(data, &stored)
+	}
+	if stored.Since.IsZero() {
+		stored.Since = t.started
+	}
+	return stored
+}
+
+// Visited counts a directory visit
+func (t *Tracker) Visited() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.session.DirsVisited++
+}
+
+// Copied counts files and bytes copied
+func (t *Tracker) Copied(files int, bytes int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.session.FilesCopied += files
+	t.session.BytesCopied += bytes
+}
+
+// Moved counts files and bytes moved
+func (t *Tracker) Moved(files int, bytes int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.session.FilesMoved += files
+	t.session.BytesMoved += bytes
+}
+
+// Deleted counts files trashed or deleted
+func (t *Tracker) Deleted(files int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.session.FilesDeleted += files
+}
+
+// Session returns the counters of this session
+func (t *Tracker) Session() Counters {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.current()
+}
+
+// current returns the session counters with the time spent so far. The
+// caller holds mu.
+func (t *Tracker) current() Counters {
+	session := t.session
+	session.Seconds = int64(time.Since(t.started) / time.Second)
+	return session
+}
+
+// AllTime returns the counters of all sessions, this one included, and
+// when counting started
+func (t *Tracker) AllTime() (Counters, time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.stored.Totals.add(t.current().sub(t.saved)), t.stored.Since
+}
+
+// Save adds what happened since the last save to the totals in the file.
+// The file is read again first, so sessions running side by side all count.
+func (t *Tracker) Save() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	session := t.current()
+	stored := t.load()
+	stored.Totals = stored.Totals.add(session.sub(t.saved))
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(t.path, data, 0644); err != nil {
+		return err
+	}
+	t.stored, t.saved = stored, session
+	return nil
+}
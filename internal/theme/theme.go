@@ -4,10 +4,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"os/user"
 	"path/filepath"
 	"strings"
 
+	"github.com/alexcostache/Xplorer/internal/config"
+	"github.com/alexcostache/Xplorer/internal/paths"
 	"github.com/nsf/termbox-go"
 )
 
@@ -28,6 +29,8 @@ type Theme struct {
 	ColorFilterBg      termbox.Attribute
 	FileColors         map[string]termbox.Attribute
 	DirColor           termbox.Attribute
+	ColorForeign       termbox.Attribute // Files owned by another user
+	ColorWorldWritable termbox.Attribute // Files anyone may write to
 }
 
 // ThemeJSON represents the JSON structure for themes
@@ -37,11 +40,53 @@ type ThemeJSON struct {
 	FileColors map[string]string `json:"file_colors,omitempty"`
 }
 
+// colorKeys lists the UI elements a theme can color
+var colorKeys = []string{
+	"text", "background", "highlight", "highlight_text", "footer", "footer_bg",
+	"address_bar", "address_bar_bg", "separator", "dim", "filter", "filter_bg", "dir",
+	"foreign", "world_writable",
+}
+
+// colorNames maps the color names accepted in theme files to attributes
+var colorNames = map[string]termbox.Attribute{
+	"default":        termbox.ColorDefault,
+	"black":          termbox.ColorBlack,
+	"red":            termbox.ColorRed,
+	"green":          termbox.ColorGreen,
+	"yellow":         termbox.ColorYellow,
+	"blue":           termbox.ColorBlue,
+	"magenta":        termbox.ColorMagenta,
+	"cyan":           termbox.ColorCyan,
+	"white":          termbox.ColorWhite,
+	"bright_black":   termbox.ColorBlack | termbox.AttrBold,
+	"bright_red":     termbox.ColorRed | termbox.AttrBold,
+	"bright_green":   termbox.ColorGreen | termbox.AttrBold,
+	"bright_yellow":  termbox.ColorYellow | termbox.AttrBold,
+	"bright_blue":    termbox.ColorBlue | termbox.AttrBold,
+	"bright_magenta": termbox.ColorMagenta | termbox.AttrBold,
+	"bright_cyan":    termbox.ColorCyan | termbox.AttrBold,
+	"bright_white":   termbox.ColorWhite | termbox.AttrBold,
+}
+
+// themeSchema describes the fields accepted in theme files
+func themeSchema() []config.FieldSpec {
+	names := make([]string, 0, len(colorNames))
+	for name := range colorNames {
+		names = append(names, name)
+	}
+	return []config.FieldSpec{
+		{Name: "name", Kind: "string"},
+		{Name: "colors", Kind: "object", Keys: colorKeys, Allowed: names},
+		{Name: "file_colors", Kind: "object", Allowed: names},
+	}
+}
+
 // Manager handles theme operations
 type Manager struct {
 	themes       []Theme
 	current      *Theme
 	fileColorMap map[string]termbox.Attribute
+	problems     []config.ValidationError // Problems found in theme files
 }
 
 // NewManager creates a new theme manager
@@ -108,12 +153,17 @@ func (m *Manager) GetFileColor(name string, isDir bool) termbox.Attribute {
 	return m.GetCurrent().ColorText
 }
 
+// GetProblems returns the problems found while loading theme files
+func (m *Manager) GetProblems() []config.ValidationError {
+	return m.problems
+}
+
 // loadThemesFromJSON loads all theme JSON files from the themes directory
 func (m *Manager) loadThemesFromJSON() []Theme {
 	var themes []Theme
 	
 	// Get themes directory path
-	themesDir := "themes"
+	themesDir := paths.ThemesDir()
 	
 	// Read all JSON files in themes directory
 	files, err := os.ReadDir(themesDir)
@@ -127,10 +177,12 @@ func (m *Manager) loadThemesFromJSON() []Theme {
 		}
 		
 		themePath := filepath.Join(themesDir, file.Name())
+		if data, err := os.ReadFile(themePath); err == nil {
+			m.problems = append(m.problems, config.ValidateJSON(themePath, data, themeSchema())...)
+		}
 		theme, err := m.loadThemeFromFile(themePath)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: Failed to load theme %s: %v\n", file.Name(), err)
-			continue
+			continue // Reported by validation above
 		}
 		
 		themes = append(themes, theme)
@@ -171,6 +223,17 @@ func (m *Manager) loadThemeFromFile(path string) (Theme, error) {
 	theme.ColorFilterBg = parseColor(themeJSON.Colors["filter_bg"])
 	theme.DirColor = parseColor(themeJSON.Colors["dir"])
 	
+	// Warning colors are newer than most themes; keep them visible when unset
+	defaults := getDefaultTheme()
+	theme.ColorForeign = defaults.ColorForeign
+	if name, ok := themeJSON.Colors["foreign"]; ok {
+		theme.ColorForeign = parseColor(name)
+	}
+	theme.ColorWorldWritable = defaults.ColorWorldWritable
+	if name, ok := themeJSON.Colors["world_writable"]; ok {
+		theme.ColorWorldWritable = parseColor(name)
+	}
+	
 	// Parse file colors if provided, otherwise use defaults
 	if len(themeJSON.FileColors) > 0 {
 		for ext, colorName := range themeJSON.FileColors {
@@ -226,27 +289,7 @@ func (m *Manager) loadThemeFromFile(path string) (Theme, error) {
 
 // parseColor converts a color name string to termbox.Attribute
 func parseColor(colorName string) termbox.Attribute {
-	colorMap := map[string]termbox.Attribute{
-		"default":        termbox.ColorDefault,
-		"black":          termbox.ColorBlack,
-		"red":            termbox.ColorRed,
-		"green":          termbox.ColorGreen,
-		"yellow":         termbox.ColorYellow,
-		"blue":           termbox.ColorBlue,
-		"magenta":        termbox.ColorMagenta,
-		"cyan":           termbox.ColorCyan,
-		"white":          termbox.ColorWhite,
-		"bright_black":   termbox.ColorBlack | termbox.AttrBold,
-		"bright_red":     termbox.ColorRed | termbox.AttrBold,
-		"bright_green":   termbox.ColorGreen | termbox.AttrBold,
-		"bright_yellow":  termbox.ColorYellow | termbox.AttrBold,
-		"bright_blue":    termbox.ColorBlue | termbox.AttrBold,
-		"bright_magenta": termbox.ColorMagenta | termbox.AttrBold,
-		"bright_cyan":    termbox.ColorCyan | termbox.AttrBold,
-		"bright_white":   termbox.ColorWhite | termbox.AttrBold,
-	}
-	
-	if color, ok := colorMap[strings.ToLower(colorName)]; ok {
+	if color, ok := colorNames[strings.ToLower(colorName)]; ok {
 		return color
 	}
 	return termbox.ColorDefault
@@ -254,8 +297,7 @@ func parseColor(colorName string) termbox.Attribute {
 
 // getThemeConfigFile returns the path to the theme config file
 func (m *Manager) getThemeConfigFile() string {
-	usr, _ := user.Current()
-	return filepath.Join(usr.HomeDir, ".xp_theme")
+	return paths.File(".xp_theme")
 }
 
 // saveThemeName saves the theme name to disk
@@ -292,6 +334,8 @@ func getDefaultTheme() Theme {
 		ColorFilter:        termbox.ColorWhite,
 		ColorFilterBg:      termbox.ColorMagenta,
 		DirColor:           termbox.ColorCyan,
+		ColorForeign:       termbox.ColorYellow,
+		ColorWorldWritable: termbox.ColorRed | termbox.AttrBold,
 	}
 }
 
@@ -343,7 +387,7 @@ func getDefaultFileColors() map[string]termbox.Attribute {
 
 // SaveTheme saves a theme to a JSON file
 func (m *Manager) SaveTheme(theme *Theme) error {
-	themesDir := "themes"
+	themesDir := paths.ThemesDir()
 	
 	// Ensure themes directory exists
 	if err := os.MkdirAll(themesDir, 0755); err != nil {
@@ -371,6 +415,8 @@ func (m *Manager) SaveTheme(theme *Theme) error {
 	themeJSON.Colors["filter"] = colorToString(theme.ColorFilter)
 	themeJSON.Colors["filter_bg"] = colorToString(theme.ColorFilterBg)
 	themeJSON.Colors["dir"] = colorToString(theme.DirColor)
+	themeJSON.Colors["foreign"] = colorToString(theme.ColorForeign)
+	themeJSON.Colors["world_writable"] = colorToString(theme.ColorWorldWritable)
 	
 	// Convert file colors
 	for ext, color := range theme.FileColors {
@@ -447,6 +493,10 @@ func (m *Manager) UpdateThemeColorPreview(element, colorName string) {
 		m.current.ColorFilterBg = color
 	case "Directory Color":
 		m.current.DirColor = color
+	case "Other Owner Color":
+		m.current.ColorForeign = color
+	case "World-Writable Color":
+		m.current.ColorWorldWritable = color
 	}
 }
 
@@ -469,7 +519,7 @@ func (m *Manager) DeleteTheme(themeName string) error {
 	}
 	
 	// Find and delete the theme file
-	themesDir := "themes"
+	themesDir := paths.ThemesDir()
 	filename := strings.ToLower(strings.ReplaceAll(themeName, " ", "-")) + ".json"
 	filepath := filepath.Join(themesDir, filename)
 	
@@ -514,7 +564,7 @@ func (m *Manager) RenameTheme(oldName, newName string) error {
 	}
 	
 	// Delete old file
-	themesDir := "themes"
+	themesDir := paths.ThemesDir()
 	oldFilename := strings.ToLower(strings.ReplaceAll(oldName, " ", "-")) + ".json"
 	oldFilepath := filepath.Join(themesDir, oldFilename)
 	
@@ -0,0 +1,87 @@
+package ui
+
+import (
+	"github.com/alexcostache/Xplorer/pkg/fileops"
+)
+
+// bar is a transient one-line bar drawn above the metadata bar
+type bar int
+
+const (
+	barFilter bar = iota
+	barProgress
+	barMessage // Messages and prompts, drawn above every other bar
+)
+
+// bottomLayout stacks the active transient bars upwards from the row above
+// the metadata bar, in the order they were given, so they never draw over
+// each other. Draw sets it up for each frame.
+type bottomLayout struct {
+	active []bar
+}
+
+// reset makes bars the active ones for the next frame
+func (l *bottomLayout) reset(bars ...bar) {
+	l.active = bars
+}
+
+// row returns the screen row of b on a screen h rows high. A bar that
+// isn't active gets the row above all active ones.
+func (l *bottomLayout) row(b bar, h int) int {
+	for i, active := range l.active {
+		if active == b {
+			return h - 2 - i
+		}
+	}
+	return h - 2 - len(l.active)
+}
+
+// extraRows returns how many rows the bars take from the file list beyond
+// the one kept free for them
+func (l *bottomLayout) extraRows() int {
+	return max(len(l.active)-1, 0)
+}
+
+// BarRows lays out the transient bars as a frame showing a filter and a
+// progress bar, as asked, does. It returns the row of each bar on a screen
+// h rows high and how many rows they take from the file list.
+func BarRows(filter, progress bool, h int) (filterRow, progressRow, messageRow, extra int) {
+	var layout bottomLayout
+	layout.reset(activeBars(filter, progress)...)
+	return layout.row(barFilter, h), layout.row(barProgress, h), layout.row(barMessage, h), layout.extraRows()
+}
+
+// activeBars returns the bars a frame shows, bottom first
+func activeBars(filter, progress bool) []bar {
+	var bars []bar
+	if filter {
+		bars = append(bars, barFilter)
+	}
+	if progress {
+		bars = append(bars, barProgress)
+	}
+	return bars
+}
+
+// progressVisible reports whether DrawProgressBar has anything to show:
+// an operation running, or the result of the last one
+func progressVisible(progress *fileops.ProgressInfo) bool {
+	if progress == nil {
+		return false
+	}
+	progress.Mu.RLock()
+	defer progress.Mu.RUnlock()
+	return progress.Active || progress.TotalFiles > 0
+}
+
+// layoutBottom decides which transient bars the next frame shows
+func (r *Renderer) layoutBottom(filter string) {
+	progress := r.fileOpsManager != nil && progressVisible(r.fileOpsManager.GetProgress())
+	r.bottom.reset(activeBars(filter != "", progress)...)
+}
+
+// messageRow returns the row for a message or prompt on a screen h rows
+// high, above any bar that is showing
+func (r *Renderer) messageRow(h int) int {
+	return r.bottom.row(barMessage, h)
+}
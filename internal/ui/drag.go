@@ -0,0 +1,107 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alexcostache/Xplorer/internal/screen"
+	"github.com/alexcostache/Xplorer/pkg/filesystem"
+)
+
+// dragState is the label drawn next to the mouse while files are dragged
+type dragState struct {
+	label string
+	x, y  int
+}
+
+// SetDrag shows label next to the mouse at x, y while files are dragged.
+// An empty label ends the drag.
+func (r *Renderer) SetDrag(label string, x, y int) {
+	r.drag = dragState{label: label, x: x, y: y}
+}
+
+// drawDrag draws the drag label over everything else, kept on screen
+func (r *Renderer) drawDrag() {
+	if r.drag.label == "" {
+		return
+	}
+	w, _ := screen.Size()
+	text := " " + r.drag.label + " "
+	x := min(r.drag.x+1, w-textWidth(text))
+	drawClipped(max(x, 0), r.drag.y, w, text, r.theme().ColorHighlightText, r.theme().ColorHighlight)
+}
+
+// DropTarget returns the folder under column x and row y that files dragged
+// from nav's list can be dropped on: a folder row of the parent or preview
+// panel, or else the folder the panel shows. In dual-pane mode it is a
+// folder row of the other pane, or that pane's folder. It returns "" where
+// nothing can be dropped.
+func (r *Renderer) DropTarget(nav *filesystem.Navigator, x, y int) string {
+	if r.minimal || r.quickLook {
+		return ""
+	}
+	// Panels start below their titles; a header row may precede the lists
+	listTop, rows := r.ListArea()
+	if y < 2 || y >= listTop+rows {
+		return ""
+	}
+	row := y - listTop
+
+	if r.panes[0] != nil {
+		pane := r.panes[r.PaneAt(x)]
+		if pane == nav {
+			return ""
+		}
+		files := pane.GetFileList()
+		if i := pane.GetScrollOffset() + row; row >= 0 && i < len(files) && files[i].IsDir() {
+			return filepath.Join(pane.GetCurrentDir(), files[i].Name())
+		}
+		return pane.GetCurrentDir()
+	}
+
+	// The parent and preview panels have no header row
+	row = y - 2
+	w, _ := screen.Size()
+	separator1Pos := w / 5
+	separator2Pos := separator1Pos + 1 + (w*2)/5
+	switch {
+	case x < separator1Pos:
+		parentDir := nav.GetParentDir()
+		if parentDir == nav.GetCurrentDir() {
+			return "" // At the root
+		}
+		entries := nav.GetParentEntries()
+		if row < len(entries) && entries[row].IsDir() {
+			return filepath.Join(parentDir, entries[row].Name())
+		}
+		return parentDir
+	case x > separator2Pos:
+		previewed := nav.GetSelectedPath()
+		if info, err := os.Stat(previewed); err != nil || !info.IsDir() {
+			return ""
+		}
+		if r.treemap {
+			if dir := r.tileAt(x, y); dir != "" {
+				return dir
+			}
+			return previewed
+		}
+		// The rows of the directory preview, as drawPreviewPanel lists them
+		entries, _ := os.ReadDir(previewed)
+		for _, entry := range entries {
+			if !nav.GetShowHidden() && strings.HasPrefix(entry.Name(), ".") {
+				continue
+			}
+			if row == 0 {
+				if entry.IsDir() {
+					return filepath.Join(previewed, entry.Name())
+				}
+				break
+			}
+			row--
+		}
+		return previewed
+	}
+	return ""
+}
@@ -0,0 +1,113 @@
+package ui
+
+// glyphSet holds the characters used for borders and indicators
+type glyphSet struct {
+	Separator      rune
+	BoxTopLeft     rune
+	BoxTopRight    rune
+	BoxBottomLeft  rune
+	BoxBottomRight rune
+	BoxHorizontal  rune
+	BoxVertical    rune
+	Breadcrumb     string
+	Bookmark       string
+	Selected       string
+	Basket         string
+	ProgressFill   rune
+	CountParent    string
+	CountCurrent   string
+	CountPreview   string
+	Pending        string
+	Ellipsis       string
+	Newer          string
+	Older          string
+	SizeDiffers    string
+	OnlyHere       string
+	Link           rune
+	Note           string
+	Shades         []rune // Fills of treemap tiles, taken in turn
+	Companion      string // Marks a derived file listed after its source
+	SortAscending  string // After the active column header label
+	SortDescending string
+	Spinner        []rune // Frames of the loading spinner
+}
+
+// unicodeGlyphs uses box-drawing and symbol characters
+var unicodeGlyphs = glyphSet{
+	Separator:      '│',
+	BoxTopLeft:     '╔',
+	BoxTopRight:    '╗',
+	BoxBottomLeft:  '╚',
+	BoxBottomRight: '╝',
+	BoxHorizontal:  '═',
+	BoxVertical:    '║',
+	Breadcrumb:     " › ",
+	Bookmark:       " ★",
+	Selected:       "✓ ",
+	Basket:         " ●",
+	ProgressFill:   '█',
+	CountParent:    "▲",
+	CountCurrent:   "◀",
+	CountPreview:   "▶",
+	Pending:        "…",
+	Ellipsis:       "…",
+	Newer:          "↑",
+	Older:          "↓",
+	SizeDiffers:    "≠",
+	OnlyHere:       "+",
+	Link:           '↪',
+	Note:           "✎",
+	Shades:         []rune{'▓', '▒', '░'},
+	Companion:      "└ ",
+	SortAscending:  "▲",
+	SortDescending: "▼",
+	Spinner:        []rune("⠋⠙⠹⠸⠼⠴⠦⠧⠇⠏"),
+}
+
+// asciiGlyphs is a fallback for terminals or fonts that misrender symbols
+var asciiGlyphs = glyphSet{
+	Separator:      '|',
+	BoxTopLeft:     '+',
+	BoxTopRight:    '+',
+	BoxBottomLeft:  '+',
+	BoxBottomRight: '+',
+	BoxHorizontal:  '-',
+	BoxVertical:    '|',
+	Breadcrumb:     " > ",
+	Bookmark:       " *",
+	Selected:       "x ",
+	Basket:         " o",
+	ProgressFill:   '#',
+	CountParent:    "^",
+	CountCurrent:   "<",
+	CountPreview:   ">",
+	Pending:        "...",
+	Ellipsis:       "~",
+	Newer:          ">",
+	Older:          "<",
+	SizeDiffers:    "~",
+	OnlyHere:       "+",
+	Link:           '>',
+	Note:           "i",
+	Shades:         []rune{'#', '+', ':'},
+	Companion:      "`-",
+	SortAscending:  "^",
+	SortDescending: "v",
+	Spinner:        []rune(`|/-\`),
+}
+
+// safeGlyphs selects the ASCII glyph set for all drawing in this package
+var safeGlyphs bool
+
+// SetSafeGlyphs switches between Unicode and ASCII-safe glyphs
+func SetSafeGlyphs(enabled bool) {
+	safeGlyphs = enabled
+}
+
+// glyphs returns the active glyph set
+func glyphs() *glyphSet {
+	if safeGlyphs {
+		return &asciiGlyphs
+	}
+	return &unicodeGlyphs
+}
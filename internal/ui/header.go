@@ -0,0 +1,110 @@
+package ui
+
+import (
+	"github.com/alexcostache/Xplorer/internal/screen"
+	"github.com/alexcostache/Xplorer/pkg/filesystem"
+	"github.com/nsf/termbox-go"
+)
+
+// headerColumns are the sort labels of the header row, left to right.
+// Name sits over the names; the others end at the size column.
+var headerColumns = []struct {
+	mode  filesystem.SortMode
+	label string
+}{
+	{filesystem.SortByName, "Name"},
+	{filesystem.SortByExtension, "Type"},
+	{filesystem.SortByModTime, "Modified"},
+	{filesystem.SortBySize, "Size"},
+}
+
+// headerLabel is a label of the header row as drawn
+type headerLabel struct {
+	mode filesystem.SortMode
+	x    int
+	text string
+}
+
+// showsHeader reports whether the file list is drawn under a header row
+func (r *Renderer) showsHeader() bool {
+	return r.config.ColumnHeader && !r.minimal
+}
+
+// headerLabels lays out the labels of the header row of nav's list drawn
+// from startX over width cells, with the direction of the active sort
+// after its label
+func (r *Renderer) headerLabels(nav *filesystem.Navigator, startX, width int) []headerLabel {
+	labels := make([]headerLabel, len(headerColumns))
+	for i, column := range headerColumns {
+		labels[i] = headerLabel{mode: column.mode, text: column.label}
+		if column.mode == nav.GetSortMode() {
+			// Sizes and times sort largest and newest first unless reversed
+			descending := column.mode == filesystem.SortBySize || column.mode == filesystem.SortByModTime
+			if descending != nav.GetSortReverse() {
+				labels[i].text += " " + glyphs().SortDescending
+			} else {
+				labels[i].text += " " + glyphs().SortAscending
+			}
+		}
+	}
+
+	x := startX + textWidth(glyphs().Selected)
+	if !r.config.UseAsciiIcons {
+		x++
+	}
+	labels[0].x = x
+	right := startX + width
+	for i := len(labels) - 1; i > 0; i-- {
+		labels[i].x = right - textWidth(labels[i].text)
+		right = labels[i].x - 2
+	}
+
+	// Narrow lists drop the labels that would run into Name
+	nameEnd := x + textWidth(labels[0].text) + 2
+	for len(labels) > 1 && labels[1].x < nameEnd {
+		labels = append(labels[:1], labels[2:]...)
+	}
+	return labels
+}
+
+// drawColumnHeader draws the header row of nav's list at row y
+func (r *Renderer) drawColumnHeader(nav *filesystem.Navigator, startX, width, y int) {
+	fg, bg := r.theme().ColorDim|termbox.AttrUnderline, r.theme().ColorBackground
+	for i := 0; i < width; i++ {
+		screen.SetCell(startX+i, y, ' ', fg, bg)
+	}
+	for _, label := range r.headerLabels(nav, startX, width) {
+		labelFg := fg
+		if label.mode == nav.GetSortMode() {
+			labelFg = r.theme().ColorText | termbox.AttrBold | termbox.AttrUnderline
+		}
+		drawClipped(label.x, y, startX+width-label.x, label.text, labelFg, bg)
+	}
+}
+
+// HeaderSortAt returns the sort mode of the header label at column x and
+// row y, in the list of nav or, in dual-pane mode, of the pane under it
+func (r *Renderer) HeaderSortAt(nav *filesystem.Navigator, x, y int) (filesystem.SortMode, bool) {
+	listTop, _ := r.ListArea()
+	if !r.showsHeader() || r.quickLook || y != listTop-1 {
+		return 0, false
+	}
+	w, _ := screen.Size()
+	startX, width := w/5+1, (w*2)/5
+	if r.panes[0] != nil {
+		half := (w - 1) / 2
+		startX, width, nav = 0, half, r.panes[0]
+		if r.PaneAt(x) == 1 {
+			startX, width, nav = half+1, w-half-1, r.panes[1]
+		}
+	}
+	if x < startX || x >= startX+width {
+		return 0, false
+	}
+	for _, label := range r.headerLabels(nav, startX, width) {
+		if x >= label.x && x < label.x+textWidth(label.text) {
+			return label.mode, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,80 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/alexcostache/Xplorer/internal/screen"
+	"github.com/alexcostache/Xplorer/pkg/filesystem"
+)
+
+// hoverState is the listed file under the mouse, described in the status
+// bar until a key is pressed or the mouse leaves the lists
+type hoverState struct {
+	path string
+	info os.FileInfo
+}
+
+// HoveredFile returns the path and info of the file listed under column x
+// and row y: a row of nav's list (the pane under the mouse in dual-pane
+// mode) or of the parent panel. It returns "" and nil elsewhere.
+func (r *Renderer) HoveredFile(nav *filesystem.Navigator, x, y int) (string, os.FileInfo) {
+	if r.quickLook {
+		return "", nil
+	}
+	listTop, rows := r.ListArea()
+	if y < listTop || y >= listTop+rows {
+		return "", nil
+	}
+	row := y - listTop
+
+	list := nav
+	if r.panes[0] != nil && !r.minimal {
+		list = r.panes[r.PaneAt(x)]
+	} else if !r.minimal {
+		w, _ := screen.Size()
+		separator1Pos := w / 5
+		separator2Pos := separator1Pos + 1 + (w*2)/5
+		if x < separator1Pos {
+			// The parent panel has no header row
+			entries := nav.GetParentEntries()
+			row = y - 2
+			if row >= 0 && row < len(entries) && nav.GetParentDir() != nav.GetCurrentDir() {
+				return filepath.Join(nav.GetParentDir(), entries[row].Name()), entries[row]
+			}
+			return "", nil
+		}
+		if x <= separator1Pos || x >= separator2Pos {
+			return "", nil
+		}
+	}
+	files := list.GetFileList()
+	if i := list.GetScrollOffset() + row; i < len(files) {
+		return filepath.Join(list.GetCurrentDir(), files[i].Name()), files[i]
+	}
+	return "", nil
+}
+
+// SetHover describes the file at path in the status bar in place of the
+// one under the cursor; nil info goes back to the cursor. It reports
+// whether the status bar changes.
+func (r *Renderer) SetHover(path string, info os.FileInfo) bool {
+	if info == nil {
+		path = ""
+	}
+	if path == r.hover.path {
+		return false
+	}
+	r.hover = hoverState{path: path, info: info}
+	return true
+}
+
+// hoverInfo is the status bar text for the file under the mouse, or ""
+func (r *Renderer) hoverInfo() string {
+	if r.hover.info == nil {
+		return ""
+	}
+	info := r.hover.info
+	return fmt.Sprintf(" Mouse: %s | %s | %s", info.Name(), formatSize(info.Size()), info.ModTime().Format("2006-01-02 15:04:05"))
+}
@@ -0,0 +1,147 @@
+package ui
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/alexcostache/Xplorer/internal/config"
+	"github.com/alexcostache/Xplorer/internal/screen"
+	"github.com/nsf/termbox-go"
+)
+
+// rowMarks returns the marker column for a listing entry: one fixed-width
+// slot each for the bookmark and basket marks, blank when unset
+func (r *Renderer) rowMarks(path string) string {
+	bookmark := glyphs().Bookmark
+	if !r.bookmarkManager.IsBookmarked(path) {
+		bookmark = strings.Repeat(" ", textWidth(bookmark))
+	}
+	basket := glyphs().Basket
+	if !r.fileOpsManager.InBasket(path) {
+		basket = strings.Repeat(" ", textWidth(basket))
+	}
+	return bookmark + basket
+}
+
+// drawName draws a file name in at most maxWidth cells, shortened with an
+// ellipsis at the end or, in middle mode, before the extension. Runes in
+// [matchStart, matchEnd) are emphasized for the active filter.
+func (r *Renderer) drawName(x, y, maxWidth int, name string, fg, bg termbox.Attribute, matchStart, matchEnd int) {
+	middle := r.config.NameTruncation == config.TruncateMiddle
+	runes, sources := fitText(name, maxWidth, middle)
+	for i, rn := range runes {
+		cellFg := fg
+		if src := sources[i]; src >= 0 && src >= matchStart && src < matchEnd {
+			cellFg = fg | termbox.AttrBold | termbox.AttrUnderline
+		}
+		screen.SetCell(x, y, rn, cellFg, bg)
+		x += runeWidth(rn)
+	}
+}
+
+// FitName shortens a file name to maxWidth cells as the file list does,
+// returning the text to draw and the sources described at fitText
+func FitName(name string, maxWidth int, middle bool) (string, []int) {
+	runes, sources := fitText(name, maxWidth, middle)
+	return string(runes), sources
+}
+
+// fitText shortens text to maxWidth cells. It returns the runes to draw and,
+// for each one, the index of the rune in text it came from (-1 for the
+// ellipsis). Middle mode keeps the end of the name, so the extension stays
+// visible.
+func fitText(text string, maxWidth int, middle bool) ([]rune, []int) {
+	runes := []rune(text)
+	all := make([]int, len(runes))
+	for i := range all {
+		all[i] = i
+	}
+	if maxWidth <= 0 {
+		return nil, nil
+	}
+	if textWidth(text) <= maxWidth {
+		return runes, all
+	}
+
+	ellipsis := []rune(glyphs().Ellipsis)
+	avail := maxWidth - textWidth(glyphs().Ellipsis)
+	if avail <= 0 {
+		return takeWidth(runes, all, maxWidth)
+	}
+
+	if !middle {
+		head, headSrc := takeWidth(runes, all, avail)
+		return append(head, ellipsis...), append(headSrc, repeatIndex(-1, len(ellipsis))...)
+	}
+
+	// Give the tail half the room, and at least enough for the extension
+	tailWidth := avail / 2
+	if ext := textWidth(filepath.Ext(text)); ext > tailWidth && ext < avail {
+		tailWidth = ext
+	}
+	tail, tailSrc := takeWidthFromEnd(runes, all, tailWidth)
+	head, headSrc := takeWidth(runes, all, avail-textWidth(string(tail)))
+	out := append(append(head, ellipsis...), tail...)
+	src := append(append(headSrc, repeatIndex(-1, len(ellipsis))...), tailSrc...)
+	return out, src
+}
+
+// takeWidth returns the longest prefix of runes that fits in width cells
+func takeWidth(runes []rune, src []int, width int) ([]rune, []int) {
+	used := 0
+	for i, rn := range runes {
+		used += runeWidth(rn)
+		if used > width {
+			return runes[:i:i], src[:i:i]
+		}
+	}
+	return runes, src
+}
+
+// takeWidthFromEnd returns the longest suffix of runes that fits in width cells
+func takeWidthFromEnd(runes []rune, src []int, width int) ([]rune, []int) {
+	used := 0
+	for i := len(runes) - 1; i >= 0; i-- {
+		used += runeWidth(runes[i])
+		if used > width {
+			return runes[i+1:], src[i+1:]
+		}
+	}
+	return runes, src
+}
+
+// repeatIndex returns n copies of index
+func repeatIndex(index, n int) []int {
+	out := make([]int, n)
+	for i := range out {
+		out[i] = index
+	}
+	return out
+}
+
+// drawClipped draws text from x, stopping before it would exceed maxWidth cells
+func drawClipped(x, y, maxWidth int, text string, fg, bg termbox.Attribute) {
+	used := 0
+	for _, rn := range text {
+		w := runeWidth(rn)
+		if used+w > maxWidth {
+			return
+		}
+		screen.SetCell(x+used, y, rn, fg, bg)
+		used += w
+	}
+}
+
+// TextWidth returns the number of terminal cells text occupies
+func TextWidth(text string) int {
+	return textWidth(text)
+}
+
+// textWidth returns the number of terminal cells text occupies
+func textWidth(text string) int {
+	total := 0
+	for _, rn := range text {
+		total += runeWidth(rn)
+	}
+	return total
+}
@@ -0,0 +1,82 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alexcostache/Xplorer/pkg/filesystem"
+	"github.com/nsf/termbox-go"
+)
+
+// homePath shortens a path in the home directory to start with "~"
+func homePath(path string) string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return path
+	}
+	if path == home || strings.HasPrefix(path, home+string(filepath.Separator)) {
+		return "~" + strings.TrimPrefix(path, home)
+	}
+	return path
+}
+
+// fitTail shortens text to width cells by dropping its start, so the end
+// of a path stays visible
+func fitTail(text string, width int) string {
+	if textWidth(text) <= width {
+		return text
+	}
+	avail := width - textWidth(glyphs().Ellipsis)
+	if avail <= 0 {
+		return ""
+	}
+	runes := []rune(text)
+	tail, _ := takeWidthFromEnd(runes, make([]int, len(runes)), avail)
+	return glyphs().Ellipsis + string(tail)
+}
+
+// drawPanelTitles draws the row above the three panels: the parent and
+// current directories and what the preview shows, with the title of the
+// current directory's note after its path
+func (r *Renderer) drawPanelTitles(nav *filesystem.Navigator, parentStart, parentWidth, middleStart, middleWidth, previewStart, previewWidth int) {
+	dim, bg := r.theme().ColorDim, r.theme().ColorBackground
+	if parentDir := nav.GetParentDir(); parentDir != nav.GetCurrentDir() {
+		drawTextInBox(parentStart, 1, parentWidth, " "+fitTail(homePath(parentDir), parentWidth-1), dim, bg)
+	}
+
+	pathWidth := middleWidth
+	note := ""
+	if _, local := nav.Filesystem().(filesystem.OS); r.config.DirNotes && local {
+		note = r.noteTitle(nav.GetCurrentDir())
+	}
+	current := homePath(nav.GetCurrentDir())
+	if note != "" {
+		// The address bar has the full path; the note gets the room
+		current = filepath.Base(nav.GetCurrentDir())
+		pathWidth = min(textWidth(current)+1, middleWidth/2)
+		drawTextInBox(middleStart+pathWidth, 1, middleWidth-pathWidth, " "+glyphs().Note+" "+note, dim, bg)
+	}
+	drawTextInBox(middleStart, 1, pathWidth, " "+fitTail(current, pathWidth-1), r.theme().ColorText|termbox.AttrBold, bg)
+
+	r.drawPreviewTitle(nav, previewStart, previewWidth)
+}
+
+// drawPreviewTitle names the previewed file with its size and, for text,
+// its encoding
+func (r *Renderer) drawPreviewTitle(nav *filesystem.Navigator, startX, width int) {
+	file := nav.GetSelectedFile()
+	if file == nil {
+		return
+	}
+	title := " " + file.Name()
+	if file.IsDir() {
+		title += string(filepath.Separator)
+	} else {
+		title += " | " + formatSize(file.Size())
+		if encoding := r.previewManager.Encoding(); encoding != "" {
+			title += " | " + encoding
+		}
+	}
+	drawTextInBox(startX, 1, width, title, r.theme().ColorDim, r.theme().ColorBackground)
+}
@@ -0,0 +1,185 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/alexcostache/Xplorer/internal/screen"
+	"github.com/alexcostache/Xplorer/pkg/filesystem"
+)
+
+// maxTreemapItems is how many entries get a tile of their own; the rest
+// share one tile
+const maxTreemapItems = 40
+
+// treemapTile is the rectangle of cells given to one item of a treemap
+type treemapTile struct {
+	index      int // Of the item, in the order laid out
+	x, y, w, h int
+}
+
+// shownTile is a tile drawn in the preview panel, kept for mouse drops
+type shownTile struct {
+	treemapTile
+	path  string
+	isDir bool
+}
+
+// SetTreemap switches the preview of directories between a listing and a
+// treemap of their entries sized by bytes
+func (r *Renderer) SetTreemap(enabled bool) {
+	r.treemap = enabled
+	r.tiles = nil
+}
+
+// IsTreemap returns whether directories are previewed as a treemap
+func (r *Renderer) IsTreemap() bool {
+	return r.treemap
+}
+
+// TreemapTile is a rectangle of cells given to item Index of a treemap
+type TreemapTile struct {
+	Index      int
+	X, Y, W, H int
+}
+
+// TreemapLayout lays out items of the given sizes as directory previews do
+func TreemapLayout(sizes []int64, x, y, w, h int) []TreemapTile {
+	var tiles []TreemapTile
+	for _, tile := range treemapLayout(sizes, x, y, w, h) {
+		tiles = append(tiles, TreemapTile{tile.index, tile.x, tile.y, tile.w, tile.h})
+	}
+	return tiles
+}
+
+// treemapLayout divides the w×h cells at x, y among items of the given
+// sizes, sorted largest first, so each tile's area follows its size. Items
+// too small for a single cell get no tile.
+func treemapLayout(sizes []int64, x, y, w, h int) []treemapTile {
+	var tiles []treemapTile
+	layoutTiles(&tiles, sizes, 0, x, y, w, h)
+	return tiles
+}
+
+// layoutTiles splits the items into a first part holding about half their
+// weight and the rest, and the rectangle across its longer side to match,
+// counting rows as twice as tall as columns are wide
+func layoutTiles(tiles *[]treemapTile, sizes []int64, first, x, y, w, h int) {
+	if w <= 0 || h <= 0 || len(sizes) == 0 {
+		return
+	}
+	if len(sizes) == 1 {
+		*tiles = append(*tiles, treemapTile{index: first, x: x, y: y, w: w, h: h})
+		return
+	}
+	var total int64
+	for _, size := range sizes {
+		total += size
+	}
+	if total <= 0 {
+		return
+	}
+
+	split, head := 1, sizes[0]
+	for split < len(sizes)-1 && 2*(head+sizes[split]) <= total {
+		head += sizes[split]
+		split++
+	}
+	if w >= 2*h {
+		cols := int((int64(w)*head + total/2) / total)
+		layoutTiles(tiles, sizes[:split], first, x, y, cols, h)
+		layoutTiles(tiles, sizes[split:], first+split, x+cols, y, w-cols, h)
+	} else {
+		rows := int((int64(h)*head + total/2) / total)
+		layoutTiles(tiles, sizes[:split], first, x, y, w, rows)
+		layoutTiles(tiles, sizes[split:], first+split, x, y+rows, w, h-rows)
+	}
+}
+
+// treemapItem is an entry of the previewed directory with its size
+type treemapItem struct {
+	name  string
+	size  int64
+	isDir bool
+}
+
+// drawTreemap draws the entries of dir as tiles sized by bytes in the w×h
+// cells at x, y, with a summary on the last row. Folder sizes come from
+// the background size measurement and fill in as they become known.
+func (r *Renderer) drawTreemap(nav *filesystem.Navigator, dir string, x, y, w, h int) {
+	r.tiles = nil
+	if w <= 0 || h < 2 {
+		return
+	}
+	entries, _ := os.ReadDir(dir)
+	var items []treemapItem
+	var total int64
+	measuring := 0
+	for _, entry := range entries {
+		if !nav.GetShowHidden() && strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		item := treemapItem{name: entry.Name(), isDir: entry.IsDir()}
+		if item.isDir {
+			size, pending := r.fileOpsManager.AggregateSize([]string{filepath.Join(dir, item.name)}, r.wake)
+			item.size = size
+			if pending {
+				measuring++
+			}
+		} else if info, err := entry.Info(); err == nil {
+			item.size = info.Size()
+		}
+		total += item.size
+		items = append(items, item)
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].size > items[j].size })
+
+	count := len(items)
+	if len(items) > maxTreemapItems {
+		rest := treemapItem{name: fmt.Sprintf("%d more", len(items)-maxTreemapItems+1)}
+		for _, item := range items[maxTreemapItems-1:] {
+			rest.size += item.size
+		}
+		items = append(items[:maxTreemapItems-1], rest)
+	}
+
+	summary := fmt.Sprintf("%d items, %s", count, formatSize(total))
+	if measuring > 0 {
+		summary += fmt.Sprintf(", measuring %d folders%s", measuring, glyphs().Pending)
+	}
+	drawClipped(x, y+h-1, w, summary, r.theme().ColorDim, r.theme().ColorBackground)
+
+	sizes := make([]int64, len(items))
+	for i, item := range items {
+		sizes[i] = item.size
+	}
+	shades := glyphs().Shades
+	for n, tile := range treemapLayout(sizes, x, y, w, h-1) {
+		item := items[tile.index]
+		color := r.themeManager.GetFileColor(item.name, item.isDir)
+		shade := shades[n%len(shades)]
+		for row := tile.y; row < tile.y+tile.h; row++ {
+			for col := tile.x; col < tile.x+tile.w; col++ {
+				screen.SetCell(col, row, shade, color, r.theme().ColorBackground)
+			}
+		}
+		r.drawName(tile.x, tile.y, tile.w, item.name, color, r.theme().ColorBackground, -1, -1)
+		if tile.h > 1 {
+			drawClipped(tile.x, tile.y+1, tile.w, formatSize(item.size), r.theme().ColorText, r.theme().ColorBackground)
+		}
+		r.tiles = append(r.tiles, shownTile{treemapTile: tile, path: filepath.Join(dir, item.name), isDir: item.isDir})
+	}
+}
+
+// tileAt returns the folder drawn as a treemap tile at x, y, if any
+func (r *Renderer) tileAt(x, y int) string {
+	for _, tile := range r.tiles {
+		if tile.isDir && x >= tile.x && x < tile.x+tile.w && y >= tile.y && y < tile.y+tile.h {
+			return tile.path
+		}
+	}
+	return ""
+}
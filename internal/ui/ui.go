@@ -9,13 +9,19 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/alexcostache/Xplorer/internal/bookmark"
 	"github.com/alexcostache/Xplorer/internal/config"
-	"github.com/alexcostache/Xplorer/internal/fileops"
-	"github.com/alexcostache/Xplorer/internal/filesystem"
+	"github.com/alexcostache/Xplorer/internal/notes"
+	"github.com/alexcostache/Xplorer/internal/paths"
 	"github.com/alexcostache/Xplorer/internal/preview"
+	"github.com/alexcostache/Xplorer/internal/screen"
+	"github.com/alexcostache/Xplorer/internal/search"
 	"github.com/alexcostache/Xplorer/internal/theme"
+	"github.com/alexcostache/Xplorer/pkg/fileops"
+	"github.com/alexcostache/Xplorer/pkg/filesystem"
 
 	"github.com/nsf/termbox-go"
 	"golang.org/x/text/width"
@@ -44,10 +50,23 @@ type Renderer struct {
 	previewManager  *preview.Manager
 	config          *config.Config
 	fileOpsManager  *fileops.Manager
+	quickLook       bool
+	minimal         bool
+	compare         bool // Badge files against the pinned destination (or the other pane)
+	visual          bool // A range selection follows the cursor
+	panes           [2]*filesystem.Navigator // Left and right pane in dual-pane mode, nil otherwise
+	note            noteBanner // Title of the current directory's note, read once per change
+	bottom          bottomLayout // Transient bars of the current frame
+	drag            dragState    // Files being dragged with the mouse
+	hover           hoverState   // File under the mouse, shown in the status bar
+	treemap         bool         // Preview directories as a treemap of their entries
+	tiles           []shownTile  // Treemap tiles of the last frame
+	interrupt       func()       // Wakes the event loop when background work finishes
 }
 
 // NewRenderer creates a new UI renderer
 func NewRenderer(tm *theme.Manager, bm *bookmark.Manager, pm *preview.Manager, cfg *config.Config, fom *fileops.Manager) *Renderer {
+	SetSafeGlyphs(cfg.SafeGlyphs)
 	return &Renderer{
 		themeManager:    tm,
 		bookmarkManager: bm,
@@ -57,10 +76,36 @@ func NewRenderer(tm *theme.Manager, bm *bookmark.Manager, pm *preview.Manager, c
 	}
 }
 
+// SetInterrupt sets how background work started while drawing, such as
+// measuring folder sizes, wakes the event loop; screen.Interrupt by default
+func (r *Renderer) SetInterrupt(fn func()) {
+	r.interrupt = fn
+}
+
+// wake wakes the event loop once background work has finished
+func (r *Renderer) wake() {
+	if r.interrupt != nil {
+		r.interrupt()
+	} else {
+		screen.Interrupt()
+	}
+}
+
 // Draw renders the entire UI
 func (r *Renderer) Draw(nav *filesystem.Navigator, inPathEditMode bool, pathEditBuffer string, showHelp bool) {
-	termbox.Clear(r.theme().ColorBackground, r.theme().ColorBackground)
-	w, h := termbox.Size()
+	screen.Clear(r.theme().ColorBackground, r.theme().ColorBackground)
+	w, h := screen.Size()
+	defer r.drawDrag()
+
+	// Minimal mode shows only the file list, without bars or separators
+	if r.minimal {
+		r.bottom.reset()
+		r.drawCurrentPanel(nav, 0, w, h, true)
+		if showHelp {
+			r.drawHelpPanel()
+		}
+		return
+	}
 
 	// Define panel widths and positions with consistent spacing
 	// Layout: [Parent Panel] | [Middle Panel] | [Preview Panel]
@@ -74,26 +119,71 @@ func (r *Renderer) Draw(nav *filesystem.Navigator, inPathEditMode bool, pathEdit
 	separator2Pos := middlePanelStart + middlePanelWidth
 	previewPanelStart := separator2Pos + 1
 
+	// Stack the filter and progress bars, shortening the list to fit
+	filter := nav.GetFilter()
+	if r.quickLook {
+		filter = "" // Quick look has no filter bar
+	}
+	r.layoutBottom(filter)
+
 	// Draw address bar
 	r.drawAddressBar(nav.GetCurrentDir(), inPathEditMode, pathEditBuffer)
+	if !inPathEditMode && nav.IsStale() {
+		r.drawStaleIndicator(w)
+	}
+
+	// Dual-pane mode draws two file lists side by side instead of the three panels
+	if r.panes[0] != nil && !r.quickLook {
+		left, right := r.panes[0], r.panes[1]
+		half := (w - 1) / 2
+		r.drawPaneTitle(left, 0, half, left == nav)
+		r.drawCurrentPanel(left, 0, half, h, left == nav)
+		r.drawPaneTitle(right, half+1, w-half-1, right == nav)
+		r.drawCurrentPanel(right, half+1, w-half-1, h, right == nav)
+		for y := 1; y < h-1; y++ {
+			screen.SetCell(half, y, glyphs().Separator, r.theme().ColorSeparator, r.theme().ColorBackground)
+		}
+		if filter != "" {
+			r.drawFilterBar(filter, w, h)
+		}
+		r.drawMetadataBar(nav, w, h)
+		if showHelp {
+			r.drawHelpPanel()
+		}
+		return
+	}
+
+	// Quick look expands the preview to the full width of the screen
+	if r.quickLook {
+		r.drawPreviewTitle(nav, 0, w)
+		r.drawPreviewPanel(nav, 0, w, h)
+		r.drawMetadataBar(nav, w, h)
+		if showHelp {
+			r.drawHelpPanel()
+		}
+		return
+	}
 
 	// Draw left panel (parent directory)
 	r.drawParentPanel(nav, parentPanelStart, parentPanelWidth, h)
 
 	// Draw middle panel (current directory)
-	r.drawCurrentPanel(nav, middlePanelStart, middlePanelWidth, h)
+	r.drawCurrentPanel(nav, middlePanelStart, middlePanelWidth, h, true)
 
 	// Draw right panel (preview)
 	r.drawPreviewPanel(nav, previewPanelStart, w, h)
+	
+	// Draw what each panel shows above it
+	r.drawPanelTitles(nav, parentPanelStart, parentPanelWidth, middlePanelStart, middlePanelWidth, previewPanelStart, w-previewPanelStart)
 
 	// Draw vertical separators
 	for y := 1; y < h-1; y++ {
-		termbox.SetCell(separator1Pos, y, '│', r.theme().ColorSeparator, r.theme().ColorBackground)
-		termbox.SetCell(separator2Pos, y, '│', r.theme().ColorSeparator, r.theme().ColorBackground)
+		screen.SetCell(separator1Pos, y, glyphs().Separator, r.theme().ColorSeparator, r.theme().ColorBackground)
+		screen.SetCell(separator2Pos, y, glyphs().Separator, r.theme().ColorSeparator, r.theme().ColorBackground)
 	}
 
 	// Draw filter bar
-	if filter := nav.GetFilter(); filter != "" {
+	if filter != "" {
 		r.drawFilterBar(filter, w, h)
 	}
 
@@ -109,10 +199,116 @@ func (r *Renderer) Draw(nav *filesystem.Navigator, inPathEditMode bool, pathEdit
 	// This allows progress bar to be drawn as an overlay
 }
 
+// SetQuickLook enables or disables the full-screen preview
+func (r *Renderer) SetQuickLook(enabled bool) {
+	r.quickLook = enabled
+}
+
+// IsQuickLook returns whether the full-screen preview is shown
+func (r *Renderer) IsQuickLook() bool {
+	return r.quickLook
+}
+
+// PreviewWidth returns how many columns of preview text fit the preview
+// panel, as laid out by Draw
+func (r *Renderer) PreviewWidth() int {
+	w, _ := screen.Size()
+	if r.quickLook {
+		return w - 1
+	}
+	previewPanelStart := w/5 + 1 + (w*2)/5 + 1
+	return max(w-previewPanelStart-1, 1)
+}
+
+// SetMinimal enables or disables the borderless file-list-only mode
+func (r *Renderer) SetMinimal(enabled bool) {
+	r.minimal = enabled
+}
+
+// IsMinimal returns whether the borderless mode is active
+func (r *Renderer) IsMinimal() bool {
+	return r.minimal
+}
+
+// SetCompare enables or disables comparison badges against the pinned destination
+func (r *Renderer) SetCompare(enabled bool) {
+	r.compare = enabled
+}
+
+// IsCompare returns whether comparison badges are shown
+func (r *Renderer) IsCompare() bool {
+	return r.compare
+}
+
+// SetVisual shows or hides the visual mode indicator in the status bar
+func (r *Renderer) SetVisual(enabled bool) {
+	r.visual = enabled
+}
+
+// SetDualPane shows two file lists side by side; the navigator passed to Draw
+// is the focused one. Nil navigators return to the three-panel layout.
+func (r *Renderer) SetDualPane(left, right *filesystem.Navigator) {
+	r.panes = [2]*filesystem.Navigator{left, right}
+}
+
+// IsDualPane returns whether two panes are shown
+func (r *Renderer) IsDualPane() bool {
+	return r.panes[0] != nil
+}
+
+// PaneAt returns 0 for the left pane and 1 for the right pane at column x
+func (r *Renderer) PaneAt(x int) int {
+	w, _ := screen.Size()
+	if x < (w-1)/2 {
+		return 0
+	}
+	return 1
+}
+
+// compareBadge returns the badge for a file compared with its counterpart
+// in the other pane (or the pinned destination outside dual-pane mode), or ""
+// when comparison is off or not applicable
+func (r *Renderer) compareBadge(nav *filesystem.Navigator, file os.FileInfo) string {
+	otherDir := r.fileOpsManager.GetPinnedDir()
+	if r.panes[0] != nil {
+		otherDir = r.panes[0].GetCurrentDir()
+		if nav == r.panes[0] {
+			otherDir = r.panes[1].GetCurrentDir()
+		}
+	}
+	if !r.compare || otherDir == "" || otherDir == nav.GetCurrentDir() {
+		return ""
+	}
+	switch filesystem.CompareWith(file, otherDir) {
+	case filesystem.CompareNewer:
+		return glyphs().Newer
+	case filesystem.CompareOlder:
+		return glyphs().Older
+	case filesystem.CompareSizeDiffers:
+		return glyphs().SizeDiffers
+	case filesystem.CompareOnlyHere:
+		return glyphs().OnlyHere
+	}
+	return ""
+}
+
+// ListArea returns the first screen row of the file list and the number
+// of rows available to it, below the header row when one is shown
+func (r *Renderer) ListArea() (int, int) {
+	_, h := screen.Size()
+	if r.minimal {
+		return 0, h
+	}
+	if r.showsHeader() {
+		return 3, h - 5 - r.bottom.extraRows()
+	}
+	return 2, h - 4 - r.bottom.extraRows()
+}
+
 // DrawAndFlush renders the UI and flushes to screen
 func (r *Renderer) DrawAndFlush(nav *filesystem.Navigator, inPathEditMode bool, pathEditBuffer string, showHelp bool) {
 	r.Draw(nav, inPathEditMode, pathEditBuffer, showHelp)
-	termbox.Flush()
+	screen.Flush()
 }
 // drawTextInBox draws text in a box with proper Unicode support
 func drawTextInBox(startX, y, maxWidth int, text string, fg, bg termbox.Attribute) {
@@ -123,12 +319,12 @@ func drawTextInBox(startX, y, maxWidth int, text string, fg, bg termbox.Attribut
 	
 	x := 0
 	for _, r := range runes {
-		termbox.SetCell(startX+x, y, r, fg, bg)
+		screen.SetCell(startX+x, y, r, fg, bg)
 		x++
 	}
 	// Fill remaining space
 	for x < maxWidth {
-		termbox.SetCell(startX+x, y, ' ', fg, bg)
+		screen.SetCell(startX+x, y, ' ', fg, bg)
 		x++
 	}
 }
@@ -136,18 +332,18 @@ func drawTextInBox(startX, y, maxWidth int, text string, fg, bg termbox.Attribut
 
 // drawAddressBar draws the address/path bar at the top
 func (r *Renderer) drawAddressBar(path string, inPathEditMode bool, pathEditBuffer string) {
-	w, _ := termbox.Size()
+	w, _ := screen.Size()
 
 	if inPathEditMode {
 		text := "Path: " + pathEditBuffer
 		for i := 0; i < w; i++ {
-			termbox.SetCell(i, 0, ' ', r.theme().ColorHighlightText, r.theme().ColorHighlight)
+			screen.SetCell(i, 0, ' ', r.theme().ColorHighlightText, r.theme().ColorHighlight)
 		}
 		for i, rn := range text {
 			if i >= w {
 				break
 			}
-			termbox.SetCell(i, 0, rn, r.theme().ColorHighlightText, r.theme().ColorHighlight)
+			screen.SetCell(i, 0, rn, r.theme().ColorHighlightText, r.theme().ColorHighlight)
 		}
 		return
 	}
@@ -163,13 +359,13 @@ func (r *Renderer) drawAddressBar(path string, inPathEditMode bool, pathEditBuff
 			}
 		}
 		for i := 0; i < w; i++ {
-			termbox.SetCell(i, 0, ' ', r.theme().ColorAddressBar, r.theme().ColorAddressBarBg)
+			screen.SetCell(i, 0, ' ', r.theme().ColorAddressBar, r.theme().ColorAddressBarBg)
 		}
 		for i, rn := range text {
 			if i >= w {
 				break
 			}
-			termbox.SetCell(i, 0, rn, r.theme().ColorAddressBar, r.theme().ColorAddressBarBg)
+			screen.SetCell(i, 0, rn, r.theme().ColorAddressBar, r.theme().ColorAddressBarBg)
 		}
 		return
 	}
@@ -197,21 +393,78 @@ func (r *Renderer) drawAddressBar(path string, inPathEditMode bool, pathEditBuff
 
 		text := part
 		if i > 0 {
-			text = " › " + part
+			text = glyphs().Breadcrumb + part
 		}
 		for _, rn := range text {
 			if x >= w {
 				break
 			}
-			termbox.SetCell(x, 0, rn, fg, bg)
+			screen.SetCell(x, 0, rn, fg, bg)
 			x += runeWidth(rn)
 		}
 	}
 	for ; x < w; x++ {
-		termbox.SetCell(x, 0, ' ', r.theme().ColorAddressBar, r.theme().ColorAddressBarBg)
+		screen.SetCell(x, 0, ' ', r.theme().ColorAddressBar, r.theme().ColorAddressBarBg)
 	}
 }
 
+// drawStaleIndicator hints at the right of the address bar that the listing is out of date
+func (r *Renderer) drawStaleIndicator(w int) {
+	text := " stale? F5 "
+	if r.IsDualPane() {
+		text = " stale? ^R " // F5 copies between panes
+	}
+	x := w - len(text)
+	if x < 0 {
+		return
+	}
+	for i, rn := range text {
+		screen.SetCell(x+i, 0, rn, r.theme().ColorDim, r.theme().ColorAddressBarBg)
+	}
+}
+
+// noteBanner caches the note title of a directory, keyed by the directory
+// and the note's modification time
+type noteBanner struct {
+	dir, path string
+	modTime   time.Time
+	checked   time.Time
+	title     string
+}
+
+// noteRecheck is how often the banner looks for a new or removed note
+const noteRecheck = 2 * time.Second
+
+// noteTitle returns the title of the directory's note or README, or ""
+func (r *Renderer) noteTitle(dir string) string {
+	b := &r.note
+	if dir != b.dir || time.Since(b.checked) > noteRecheck {
+		path := notes.Find(dir)
+		var modTime time.Time
+		if info, err := os.Stat(path); err == nil {
+			modTime = info.ModTime()
+		}
+		if dir != b.dir || path != b.path || !modTime.Equal(b.modTime) {
+			b.title = ""
+			if path != "" {
+				b.title = notes.Title(path)
+			}
+		}
+		b.dir, b.path, b.modTime, b.checked = dir, path, modTime, time.Now()
+	}
+	return b.title
+}
+
+// drawPaneTitle draws a pane's directory above its list in dual-pane mode,
+// highlighted for the focused pane
+func (r *Renderer) drawPaneTitle(nav *filesystem.Navigator, startX, width int, active bool) {
+	fg, bg := r.theme().ColorDim, r.theme().ColorBackground
+	if active {
+		fg, bg = r.theme().ColorHighlightText, r.theme().ColorHighlight
+	}
+	drawTextInBox(startX, 1, width, " "+homePath(nav.GetCurrentDir()), fg, bg)
+}
+
 // drawParentPanel draws the left panel showing parent directory
 func (r *Renderer) drawParentPanel(nav *filesystem.Navigator, startX, width, height int) {
 	parentEntries := nav.GetParentEntries()
@@ -220,15 +473,12 @@ func (r *Renderer) drawParentPanel(nav *filesystem.Navigator, startX, width, hei
 	y := 2
 	for _, f := range parentEntries {
 		name := f.Name()
-		icon := config.FileIcon(name, f.IsDir(), r.config.UseAsciiIcons)
-		color := r.themeManager.GetFileColor(name, f.IsDir())
+		icon := r.fileIcon(name, f.IsDir())
+		color := r.entryColor(f)
 		fullPath := filepath.Join(nav.GetParentDir(), name)
 		
-		displayName := name
-		if r.bookmarkManager.IsBookmarked(fullPath) {
-			displayName += " ★"
-		}
-		line := formatFileLine(icon, displayName)
+		prefix := formatFileLine(icon, "")
+		marks := r.rowMarks(fullPath)
 
 		isActiveFolder := (name == currentBase)
 		bgColor := r.theme().ColorBackground
@@ -240,7 +490,7 @@ func (r *Renderer) drawParentPanel(nav *filesystem.Navigator, startX, width, hei
 
 		// Fill background
 		for i := 0; i < width; i++ {
-			termbox.SetCell(startX+i, y, ' ', r.theme().ColorText, bgColor)
+			screen.SetCell(startX+i, y, ' ', r.theme().ColorText, bgColor)
 		}
 		
 		// Add padding when icons are disabled
@@ -248,13 +498,11 @@ func (r *Renderer) drawParentPanel(nav *filesystem.Navigator, startX, width, hei
 		if !r.config.UseAsciiIcons {
 			x = startX + 1
 		}
-		for _, rn := range line {
-			if x >= startX+width {
-				break
-			}
-			termbox.SetCell(x, y, rn, textColor, bgColor)
-			x += runeWidth(rn)
-		}
+		marksX := startX + width - textWidth(marks)
+		drawClipped(x, y, marksX-x, prefix, textColor, bgColor)
+		x += textWidth(prefix)
+		r.drawName(x, y, marksX-x, name, textColor, bgColor, -1, -1)
+		drawClipped(marksX, y, textWidth(marks), marks, textColor, bgColor)
 		
 		y++
 		if y >= height-2 {
@@ -263,59 +511,83 @@ func (r *Renderer) drawParentPanel(nav *filesystem.Navigator, startX, width, hei
 	}
 }
 
-// drawCurrentPanel draws the middle panel showing current directory
-func (r *Renderer) drawCurrentPanel(nav *filesystem.Navigator, startX, width, height int) {
+// drawCurrentPanel draws the middle panel showing current directory. The
+// cursor of an inactive pane (dual-pane mode) is only underlined.
+func (r *Renderer) drawCurrentPanel(nav *filesystem.Navigator, startX, width, height int, active bool) {
 	fileList := nav.GetFileList()
 	cursor := nav.GetCursor()
 	scrollOffset := nav.GetScrollOffset()
-	visibleHeight := height - 4
+	listTop, visibleHeight := r.ListArea()
 	sizeColumnWidth := 12 // Width for size column (e.g., "1.23 MB")
 
+	if r.showsHeader() {
+		r.drawColumnHeader(nav, startX, width, listTop-1)
+	}
+	if len(fileList) == 0 && nav.GetReadError() != nil {
+		r.drawReadError(nav, startX, listTop, width)
+		return
+	}
+
 	for i := scrollOffset; i < len(fileList) && i < scrollOffset+visibleHeight; i++ {
-		y := (i - scrollOffset) + 2
+		y := (i - scrollOffset) + listTop
 		file := fileList[i]
-		icon := config.FileIcon(file.Name(), file.IsDir(), r.config.UseAsciiIcons)
-		color := r.themeManager.GetFileColor(file.Name(), file.IsDir())
+		icon := r.fileIcon(file.Name(), file.IsDir())
+		color := r.entryColor(file)
 		fullPath := filepath.Join(nav.GetCurrentDir(), file.Name())
 		
-		displayName := file.Name()
-		if r.bookmarkManager.IsBookmarked(fullPath) {
-			displayName += " ★"
+		prefix := formatFileLine(icon, "")
+		if nav.PairSource(file.Name()) != "" {
+			prefix = glyphs().Companion + prefix
 		}
+		marks := r.rowMarks(fullPath)
 		
-		line := formatFileLine(icon, displayName)
-		
-		// Get file size
+		// Get file size, unless sizes are hidden on this row
 		var sizeStr string
-		if file.IsDir() {
+		columnWidth := sizeColumnWidth
+		showSize := r.config.ShowSizes != config.SizesHidden && (r.config.ShowSizes != config.SizesCursor || i == cursor)
+		if !showSize {
+			columnWidth = 0
+		} else if file.IsDir() {
 			sizeStr = "<DIR>"
 		} else {
 			sizeStr = formatSize(file.Size())
 		}
+		if badge := r.compareBadge(nav, file); badge != "" {
+			sizeStr = strings.TrimSpace(badge + " " + sizeStr)
+			columnWidth = max(columnWidth, textWidth(sizeStr)+1)
+		}
 
 		// Determine if file is selected
 		isSelected := r.fileOpsManager.IsSelected(fullPath)
 		
-		// Add selection marker to line if selected
+		// The selection column is always reserved, so selecting doesn't
+		// shift the name
 		if isSelected {
-			line = "✓ " + line
+			prefix = glyphs().Selected + prefix
+		} else {
+			prefix = strings.Repeat(" ", textWidth(glyphs().Selected)) + prefix
 		}
 		
+		// Locate the part of the name matched by the active filter
+		matchStart, matchEnd := filesystem.MatchRange(file.Name(), nav.GetFilter(), nav.GetCaseMode())
+		
 		// Draw background
 		for x := 0; x < width; x++ {
 			bg := r.theme().ColorBackground
-			if i == cursor {
+			if i == cursor && active {
 				bg = r.theme().ColorHighlight
 			}
-			termbox.SetCell(startX+x, y, ' ', r.theme().ColorText, bg)
+			screen.SetCell(startX+x, y, ' ', r.theme().ColorText, bg)
 		}
 
 		// Draw filename
 		fg := color
 		bg := r.theme().ColorBackground
-		if i == cursor {
+		if i == cursor && active {
 			fg = r.theme().ColorHighlightText
 			bg = r.theme().ColorHighlight
+		} else if i == cursor {
+			fg = color | termbox.AttrUnderline
 		} else if isSelected {
 			// Selected files use highlight color for text (no background change)
 			fg = r.theme().ColorHighlight
@@ -326,29 +598,59 @@ func (r *Renderer) drawCurrentPanel(nav *filesystem.Navigator, startX, width, he
 		if !r.config.UseAsciiIcons {
 			x = startX + 1
 		}
-		maxNameWidth := width - sizeColumnWidth - 1
-		if !r.config.UseAsciiIcons {
-			maxNameWidth--
+		// Marks sit in a fixed column between the name and the size
+		marksX := startX + width - columnWidth - 1 - textWidth(marks)
+		drawClipped(x, y, marksX-x, prefix, fg, bg)
+		x += textWidth(prefix)
+		r.drawName(x, y, marksX-x, file.Name(), fg, bg, matchStart, matchEnd)
+		
+		drawClipped(marksX, y, textWidth(marks), marks, fg, bg)
+		
+		// Draw size column (right-aligned) - same color as filename
+		sizeX := startX + width - utf8.RuneCountInString(sizeStr)
+		for _, rn := range sizeStr {
+			screen.SetCell(sizeX, y, rn, fg, bg)
+			sizeX++
 		}
-		charCount := 0
+	}
+}
+
+// drawReadError explains why the current directory has no entries and how to leave it
+func (r *Renderer) drawReadError(nav *filesystem.Navigator, startX, y, width int) {
+	lines := []string{}
+	if nav.IsPermissionDenied() {
+		lines = append(lines, "Permission denied")
+	} else {
+		lines = append(lines, "Cannot read directory")
+	}
+	lines = append(lines, "")
+	if prev := nav.GetPreviousDir(); prev != "" {
+		lines = append(lines, "Backspace  Back to "+filepath.Base(prev))
+	}
+	lines = append(lines, "Left       Parent directory")
+	if nav.IsPermissionDenied() && runtime.GOOS != "windows" {
+		lines = append(lines, "Enter      Open root shell here (sudo)")
+	}
+
+	for i, line := range lines {
+		fg := r.theme().ColorDim
+		if i == 0 {
+			fg = r.theme().ColorHighlight | termbox.AttrBold
+		}
+		x := startX + 1
 		for _, rn := range line {
-			if charCount >= maxNameWidth {
+			if x >= startX+width {
 				break
 			}
-			termbox.SetCell(x, y, rn, fg, bg)
-			w := runeWidth(rn)
-			x += w
-			charCount += w
-		}
-		
-		// Draw size column (right-aligned) - same color as filename
-		sizeX := startX + width - len(sizeStr)
-		for j, rn := range sizeStr {
-			termbox.SetCell(sizeX+j, y, rn, fg, bg)
+			screen.SetCell(x, y+i, rn, fg, r.theme().ColorBackground)
+			x += runeWidth(rn)
 		}
 	}
 }
 
+// SpinnerFrame is how long each frame of the loading spinner shows
+const SpinnerFrame = 100 * time.Millisecond
+
 // drawPreviewPanel draws the right panel showing file/directory preview
 func (r *Renderer) drawPreviewPanel(nav *filesystem.Navigator, startX, width, height int) {
 	fileList := nav.GetFileList()
@@ -359,11 +661,18 @@ func (r *Renderer) drawPreviewPanel(nav *filesystem.Navigator, startX, width, he
 	cursor := nav.GetCursor()
 	selected := filepath.Join(nav.GetCurrentDir(), fileList[cursor].Name())
 	info, err := os.Stat(selected)
+	_, local := nav.Filesystem().(filesystem.OS)
+	if !local {
+		// Entries elsewhere are shown by the lines the app gave the preview
+		info, err = fileList[cursor], nil
+	}
 	if err != nil {
 		return
 	}
 
-	if info.IsDir() {
+	if local && info.IsDir() && r.treemap {
+		r.drawTreemap(nav, selected, startX+1, 2, width-startX-1, height-4)
+	} else if local && info.IsDir() {
 		// Directory preview
 		entries, _ := os.ReadDir(selected)
 		lineNum := 0
@@ -371,59 +680,117 @@ func (r *Renderer) drawPreviewPanel(nav *filesystem.Navigator, startX, width, he
 			if !nav.GetShowHidden() && strings.HasPrefix(entry.Name(), ".") {
 				continue
 			}
-			icon := config.FileIcon(entry.Name(), entry.IsDir(), r.config.UseAsciiIcons)
+			icon := r.fileIcon(entry.Name(), entry.IsDir())
 			color := r.themeManager.GetFileColor(entry.Name(), entry.IsDir())
-			text := formatFileLine(icon, entry.Name())
+			prefix := formatFileLine(icon, "")
 			
 			// Add padding when icons are disabled
 			x := startX
 			if !r.config.UseAsciiIcons {
 				x = startX + 1
 			}
-			for _, rn := range text {
-				if x >= width {
-					break
-				}
-				termbox.SetCell(x, lineNum+2, rn, color, r.theme().ColorBackground)
-				x += runeWidth(rn)
-			}
+			drawClipped(x, lineNum+2, width-x, prefix, color, r.theme().ColorBackground)
+			x += textWidth(prefix)
+			r.drawName(x, lineNum+2, width-x, entry.Name(), color, r.theme().ColorBackground, -1, -1)
 			lineNum++
 			if lineNum >= height-4 {
 				break
 			}
 		}
+	} else if r.previewManager.Loading() {
+		// Still being read in the background
+		spinner := glyphs().Spinner
+		frame := spinner[time.Now().UnixNano()/int64(SpinnerFrame)%int64(len(spinner))]
+		drawClipped(startX+1, 2, width-startX-1, string(frame)+" Loading"+glyphs().Ellipsis, r.theme().ColorDim, r.theme().ColorBackground)
 	} else {
+		// Image preview rendered from the thumbnail cache
+		if img := r.previewManager.GetImage(); img != nil {
+			// EXIF details go below the image, with its size
+			var details []string
+			if lines := r.previewManager.GetLines(); len(lines) > 1 && len(lines) < (height-4)/2 {
+				details = lines
+			}
+			imageHeight := height - 4
+			if len(details) > 0 {
+				imageHeight -= len(details) + 1
+			}
+			preview.DrawImage(startX+1, 2, width-startX-1, imageHeight, img)
+			for i, line := range details {
+				drawClipped(startX+1, 2+imageHeight+1+i, width-startX-1, line, r.theme().ColorDim, r.theme().ColorBackground)
+			}
+			return
+		}
+		
 		// File preview with syntax highlighting
 		lines := r.previewManager.GetLines()
 		if lines != nil {
 			visibleHeight := height - 4
+			columns := r.previewManager.GetColumnOffset()
+			lang := preview.DetectLanguage(fileList[cursor].Name())
+			
+			// A table's header row and rule stay on top while it scrolls
+			header := min(r.previewManager.HeaderLines(), visibleHeight)
+			for i := 0; i < header; i++ {
+				fg := r.theme().ColorHighlight
+				if i > 0 {
+					fg = r.theme().ColorDim
+				}
+				preview.DrawText(startX+1, 2+i, preview.SkipColumns(lines[i], columns), "", fg, r.theme().ColorBackground, r.theme().ColorDim)
+			}
+			
 			scrollOffset := r.previewManager.GetScrollOffset()
-			start := scrollOffset
-			end := start + visibleHeight
+			start := header + scrollOffset
+			end := start + visibleHeight - header
 			if end > len(lines) {
 				end = len(lines)
 			}
 			
-			lang := preview.DetectLanguage(fileList[cursor].Name())
 			for i := start; i < end; i++ {
-				y := (i - start) + 2
-				preview.DrawText(startX+1, y, lines[i], lang, r.theme().ColorText, r.theme().ColorBackground, r.theme().ColorDim)
+				y := (i - start) + 2 + header
+				preview.DrawText(startX+1, y, preview.SkipColumns(lines[i], columns), lang, r.theme().ColorText, r.theme().ColorBackground, r.theme().ColorDim)
+			}
+			
+			// Mark the top line when it holds a path or URL to follow
+			if header == 0 && start < end && len(preview.FindLinks(lines[start])) > 0 {
+				screen.SetCell(startX, 2, glyphs().Link, r.theme().ColorHighlight, r.theme().ColorBackground)
 			}
 		}
 	}
 }
 
+// DrawSlideshow draws a full-screen image with a status line at the bottom
+func (r *Renderer) DrawSlideshow(img *preview.Thumbnail, name string, index, total int, autoAdvance bool) {
+	screen.Clear(r.theme().ColorBackground, r.theme().ColorBackground)
+	w, h := screen.Size()
+
+	if img != nil {
+		// Center the image in the area above the status line
+		cols, rows := img.FitSize(w, h-1)
+		preview.DrawImage((w-cols)/2, (h-1-rows)/2, cols, rows, img)
+	} else {
+		drawTextInBox(0, h/2, w, "[cannot display "+name+"]", r.theme().ColorDim, r.theme().ColorBackground)
+	}
+
+	auto := ""
+	if autoAdvance {
+		auto = " | auto"
+	}
+	status := fmt.Sprintf(" %s [%d/%d]%s | n/p: next/prev  a: auto  d: delete  m: move  Esc: exit", name, index+1, total, auto)
+	drawTextInBox(0, h-1, w, status, r.theme().ColorFooter, r.theme().ColorFooterBg)
+	screen.Flush()
+}
+
 // drawFilterBar draws the filter input bar
 func (r *Renderer) drawFilterBar(filter string, width, height int) {
 	filterText := "Filter: " + filter
 	for i := 0; i < width; i++ {
-		termbox.SetCell(i, height-2, ' ', r.theme().ColorFilter, r.theme().ColorFilterBg)
+		screen.SetCell(i, r.bottom.row(barFilter, height), ' ', r.theme().ColorFilter, r.theme().ColorFilterBg)
 	}
 	for i, rn := range filterText {
 		if i >= width {
 			break
 		}
-		termbox.SetCell(i, height-2, rn, r.theme().ColorFilter, r.theme().ColorFilterBg)
+		screen.SetCell(i, r.bottom.row(barFilter, height), rn, r.theme().ColorFilter, r.theme().ColorFilterBg)
 	}
 }
 
@@ -447,7 +814,7 @@ func (r *Renderer) drawMetadataBar(nav *filesystem.Navigator, width, height int)
 	
 	previewCount := 0
 	selected := filepath.Join(nav.GetCurrentDir(), fileList[cursor].Name())
-	if info.IsDir() {
+	if _, local := nav.Filesystem().(filesystem.OS); info.IsDir() && local {
 		entries, _ := os.ReadDir(selected)
 		for _, e := range entries {
 			if !nav.GetShowHidden() && strings.HasPrefix(e.Name(), ".") {
@@ -459,84 +826,115 @@ func (r *Renderer) drawMetadataBar(nav *filesystem.Navigator, width, height int)
 		previewCount = len(r.previewManager.GetLines())
 	}
 
+	filterInfo := ""
+	if nav.GetFilter() != "" {
+		var fileBytes int64
+		var dirs []string
+		for _, f := range fileList {
+			if f.IsDir() {
+				dirs = append(dirs, filepath.Join(nav.GetCurrentDir(), f.Name()))
+			} else {
+				fileBytes += f.Size()
+			}
+		}
+		dirBytes, pending := r.fileOpsManager.AggregateSize(dirs, r.wake)
+		filterInfo = fmt.Sprintf(" | %d matched, %s", currentCount, formatAggregateSize(fileBytes+dirBytes, pending))
+	}
 	selectedCount := r.fileOpsManager.GetSelectedCount()
 	selectionInfo := ""
+	if r.visual {
+		selectionInfo = " | VISUAL"
+	}
 	if selectedCount > 0 {
-		selectionInfo = fmt.Sprintf(" | Selected: %d", selectedCount)
+		selectedBytes, pending := r.fileOpsManager.AggregateSize(r.fileOpsManager.GetSelectedFiles(), r.wake)
+		selectionInfo += fmt.Sprintf(" | %d selected, %s", selectedCount, formatAggregateSize(selectedBytes, pending))
+	}
+	basketInfo := ""
+	if basket := r.fileOpsManager.GetBasket(); len(basket) > 0 {
+		basketBytes, pending := r.fileOpsManager.AggregateSize(basket, r.wake)
+		basketInfo = fmt.Sprintf(" | Basket: %d, %s", len(basket), formatAggregateSize(basketBytes, pending))
+	}
+	tableInfo := ""
+	if summary := r.previewManager.TableSummary(); summary != "" && !info.IsDir() {
+		tableInfo = " | " + summary
+	}
+	pinnedInfo := ""
+	if pinnedDir := r.fileOpsManager.GetPinnedDir(); pinnedDir != "" {
+		pinnedInfo = " | Dest: " + filepath.Base(pinnedDir)
+	}
+	left := fmt.Sprintf(" %s | %s | %s | %s%s%s%s%s%s", name, size, mode, modTime, tableInfo, filterInfo, selectionInfo, basketInfo, pinnedInfo)
+	if hover := r.hoverInfo(); hover != "" {
+		left = hover
+	}
+	right := fmt.Sprintf("%s %d %s %d %s %d | Hidden: %s | Sort: %s", glyphs().CountParent, parentCount, glyphs().CountCurrent, currentCount, glyphs().CountPreview, previewCount, boolStr(nav.GetShowHidden()), nav.GetSortModeName())
+	leftMax := width
+	if r.IsDualPane() {
+		// There are no parent and preview panels to count, and the focused
+		// pane's state is kept in view at the expense of the file details
+		pane := "Left"
+		if nav != r.panes[0] {
+			pane = "Right"
+		}
+		right = fmt.Sprintf("%s %d | Hidden: %s | Sort: %s | Pane: %s", glyphs().CountCurrent, currentCount, boolStr(nav.GetShowHidden()), nav.GetSortModeName(), pane)
+		leftMax = width - textWidth(right) - 2
+	}
+	if profile := paths.Profile(); profile != "" {
+		right += " | Profile: " + profile
 	}
-	left := fmt.Sprintf(" %s | %s | %s | %s%s", name, size, mode, modTime, selectionInfo)
-	right := fmt.Sprintf("▲ %d ◀ %d ▶ %d | Hidden: %s | Sort: %s", parentCount, currentCount, previewCount, boolStr(nav.GetShowHidden()), nav.GetSortModeName())
 
 	for i := 0; i < width; i++ {
-		termbox.SetCell(i, height-1, ' ', r.theme().ColorFooter, r.theme().ColorFooterBg)
+		screen.SetCell(i, height-1, ' ', r.theme().ColorFooter, r.theme().ColorFooterBg)
 	}
-	for i, rn := range left {
-		if i >= width {
+	leftWidth := 0
+	for _, rn := range left {
+		if leftWidth+runeWidth(rn) > leftMax {
 			break
 		}
-		termbox.SetCell(i, height-1, rn, r.theme().ColorFooter, r.theme().ColorFooterBg)
+		screen.SetCell(leftWidth, height-1, rn, r.theme().ColorFooter, r.theme().ColorFooterBg)
+		leftWidth += runeWidth(rn)
 	}
-	startX := width - len(right)
-	if startX > len(left)+2 {
-		for i, rn := range right {
-			if startX+i >= width {
-				break
-			}
-			termbox.SetCell(startX+i, height-1, rn, r.theme().ColorFooter, r.theme().ColorFooterBg)
-		}
+	startX := width - textWidth(right)
+	if startX >= leftWidth+2 {
+		drawClipped(startX, height-1, width-startX, right, r.theme().ColorFooter, r.theme().ColorFooterBg)
 	}
 }
 
 // drawHelpPanel draws the help overlay
 func (r *Renderer) drawHelpPanel() {
-	w, h := termbox.Size()
+	w, h := screen.Size()
 	keys := r.config.Keys
 
-	help := []string{
-		"↑↓       Navigate",
-		"PgUp/Dn  Navigate fast (5 lines)",
-		"←→       Enter/Back Dir",
-		"Enter    Open with... (select editor)",
-		"Space    Select/Deselect file",
-		"Ctrl+O   File operations menu",
-		"Ctrl+S   Change sorting mode",
-		fmt.Sprintf("%c        Filter", keys.Filter),
-		fmt.Sprintf("%c        Themes", keys.OpenThemePopup),
-		fmt.Sprintf("%c        Configuration Menu", keys.ConfigMenu),
-		fmt.Sprintf("%c        Toggle Hidden", keys.ToggleHidden),
-		fmt.Sprintf("%c        Open in Terminal", keys.OpenTerminal),
-		fmt.Sprintf("%c        Quit", keys.Quit),
-		fmt.Sprintf("%c        Toggle Help", keys.Help),
-		fmt.Sprintf("%c        Bookmark current folder", keys.BookmarkToggle),
-		fmt.Sprintf("%c        Jump to a bookmark", keys.BookmarkPopup),
-		fmt.Sprintf("%c        Edit path", keys.EditPath),
-		fmt.Sprintf("%c        Scroll preview ↓", keys.ScrollDown),
-		fmt.Sprintf("%c        Scroll preview ↑", keys.ScrollUp),
-		fmt.Sprintf("%c        Scroll preview ↓ (fast)", keys.ScrollDownFast),
-		fmt.Sprintf("%c        Scroll preview ↑ (fast)", keys.ScrollUpFast),
-		fmt.Sprintf("%c        Toggle path display", keys.TogglePath),
+	// One line per binding, so remapped keys show up as configured
+	var help []string
+	for _, b := range keys.Bindings() {
+		help = append(help, fmt.Sprintf("%-9s%s", b.Key.String(), b.Description))
 	}
+	help = append(help, "Esc      Close popups/quit")
 
-	boxWidth := 50
-	boxHeight := len(help) + 4
+	// Use two columns when one doesn't fit the screen
+	const columnWidth = 50
+	columns := 1
+	if len(help)+4 > h {
+		columns = 2
+	}
+	rows := (len(help) + columns - 1) / columns
+
+	boxWidth := columnWidth * columns
+	boxHeight := rows + 4
 	startX := (w - boxWidth) / 2
 	startY := (h - boxHeight) / 2
 
 	DrawBoxWithTitle(startX, startY, boxWidth, boxHeight, "Help", r.theme().ColorFooter, r.theme().ColorFooterBg)
 
 	for i, line := range help {
-		for j, ch := range line {
-			if startX+2+j >= startX+boxWidth-2 {
-				break
-			}
-			termbox.SetCell(startX+2+j, startY+2+i, ch, r.theme().ColorFooter, r.theme().ColorFooterBg)
-		}
+		x := startX + 2 + (i/rows)*columnWidth
+		drawClipped(x, startY+2+i%rows, columnWidth-4, line, r.theme().ColorFooter, r.theme().ColorFooterBg)
 	}
 }
 
 // ShowThemeSelector shows the theme selection with full window preview
 func (r *Renderer) ShowThemeSelector(nav *filesystem.Navigator, inPathEditMode bool, pathEditBuffer string, showHelp bool) {
-	w, h := termbox.Size()
+	w, h := screen.Size()
 	themes := r.themeManager.GetThemes()
 	boxWidth := 40
 	boxHeight := len(themes) + 4
@@ -567,7 +965,7 @@ func (r *Renderer) ShowThemeSelector(nav *filesystem.Navigator, inPathEditMode b
 		
 		for j, ch := range "[Themes] ↑↓, Enter to confirm, Esc to cancel" {
 			if startX+2+j < startX+boxWidth-2 {
-				termbox.SetCell(startX+2+j, startY, ch, r.theme().ColorFooter, r.theme().ColorFooterBg)
+				screen.SetCell(startX+2+j, startY, ch, r.theme().ColorFooter, r.theme().ColorFooterBg)
 			}
 		}
 
@@ -581,14 +979,14 @@ func (r *Renderer) ShowThemeSelector(nav *filesystem.Navigator, inPathEditMode b
 			}
 			for j, ch := range name {
 				if startX+2+j < startX+boxWidth-2 {
-					termbox.SetCell(startX+2+j, startY+2+i, ch, fg, bg)
+					screen.SetCell(startX+2+j, startY+2+i, ch, fg, bg)
 				}
 			}
 		}
 
-		termbox.Flush()
+		screen.Flush()
 
-		ev := termbox.PollEvent()
+		ev := screen.PollEvent()
 		if ev.Type == termbox.EventKey {
 			switch ev.Key {
 			case termbox.KeyArrowUp:
@@ -617,7 +1015,7 @@ func (r *Renderer) ShowThemeSelector(nav *filesystem.Navigator, inPathEditMode b
 
 // ShowBookmarkPopup shows the bookmark selection popup
 func (r *Renderer) ShowBookmarkPopup() string {
-	w, h := termbox.Size()
+	w, h := screen.Size()
 	bookmarks := r.bookmarkManager.GetAll()
 	boxWidth := 50
 	boxHeight := len(bookmarks) + 4
@@ -642,9 +1040,9 @@ func (r *Renderer) ShowBookmarkPopup() string {
 			drawTextInBox(startX+1, y, boxWidth-2, text, fg, bg)
 		}
 
-		termbox.Flush()
+		screen.Flush()
 
-		ev := termbox.PollEvent()
+		ev := screen.PollEvent()
 		if ev.Type == termbox.EventKey {
 			switch ev.Key {
 			case termbox.KeyArrowUp:
@@ -668,27 +1066,28 @@ func (r *Renderer) ShowBookmarkPopup() string {
 
 // Prompt shows an input prompt (for filter - updates file list)
 func (r *Renderer) Prompt(label string, nav *filesystem.Navigator) string {
-	w, h := termbox.Size()
+	w, h := screen.Size()
 	input := ""
 
 	for {
 		nav.SetFilter(input)
-		nav.MoveCursorToBestMatch(h - 4)
+		_, visibleLines := r.ListArea()
+		nav.MoveCursorToBestMatch(visibleLines)
 		r.Draw(nav, false, "", false)
 
 		full := label + input
 		for i := 0; i < w; i++ {
-			termbox.SetCell(i, h-2, ' ', r.theme().ColorFilter, r.theme().ColorFilterBg)
+			screen.SetCell(i, r.bottom.row(barFilter, h), ' ', r.theme().ColorFilter, r.theme().ColorFilterBg)
 		}
 		for i, rn := range full {
 			if i >= w {
 				break
 			}
-			termbox.SetCell(i, h-2, rn, r.theme().ColorFilter, r.theme().ColorFilterBg)
+			screen.SetCell(i, r.bottom.row(barFilter, h), rn, r.theme().ColorFilter, r.theme().ColorFilterBg)
 		}
-		termbox.Flush()
+		screen.Flush()
 
-		e := termbox.PollEvent()
+		e := screen.PollEvent()
 		if e.Type == termbox.EventKey {
 			switch e.Key {
 			case termbox.KeyEnter:
@@ -712,7 +1111,7 @@ func (r *Renderer) Prompt(label string, nav *filesystem.Navigator) string {
 
 // SimplePrompt shows a simple input prompt without filtering (allows spaces)
 func (r *Renderer) SimplePrompt(label string, nav *filesystem.Navigator) string {
-	w, h := termbox.Size()
+	w, h := screen.Size()
 	input := ""
 
 	for {
@@ -721,17 +1120,17 @@ func (r *Renderer) SimplePrompt(label string, nav *filesystem.Navigator) string
 
 		full := label + input
 		for i := 0; i < w; i++ {
-			termbox.SetCell(i, h-2, ' ', r.theme().ColorHighlightText, r.theme().ColorHighlight)
+			screen.SetCell(i, r.messageRow(h), ' ', r.theme().ColorHighlightText, r.theme().ColorHighlight)
 		}
 		for i, rn := range full {
 			if i >= w {
 				break
 			}
-			termbox.SetCell(i, h-2, rn, r.theme().ColorHighlightText, r.theme().ColorHighlight)
+			screen.SetCell(i, r.messageRow(h), rn, r.theme().ColorHighlightText, r.theme().ColorHighlight)
 		}
-		termbox.Flush()
+		screen.Flush()
 
-		e := termbox.PollEvent()
+		e := screen.PollEvent()
 		if e.Type == termbox.EventKey {
 			switch e.Key {
 			case termbox.KeyEnter:
@@ -753,24 +1152,210 @@ func (r *Renderer) SimplePrompt(label string, nav *filesystem.Navigator) string
 	}
 }
 
+// PasswordPrompt shows an input prompt that echoes a * per typed character
+// and returns the input, or "" on Esc
+func (r *Renderer) PasswordPrompt(label string, nav *filesystem.Navigator) string {
+	w, h := screen.Size()
+	var input []rune
+
+	for {
+		r.Draw(nav, false, "", false)
+
+		full := label + strings.Repeat("*", len(input))
+		for i := 0; i < w; i++ {
+			screen.SetCell(i, r.messageRow(h), ' ', r.theme().ColorHighlightText, r.theme().ColorHighlight)
+		}
+		for i, rn := range []rune(full) {
+			if i >= w {
+				break
+			}
+			screen.SetCell(i, r.messageRow(h), rn, r.theme().ColorHighlightText, r.theme().ColorHighlight)
+		}
+		screen.Flush()
+
+		e := screen.PollEvent()
+		if e.Type == termbox.EventKey {
+			switch e.Key {
+			case termbox.KeyEnter:
+				return string(input)
+			case termbox.KeyEsc:
+				return ""
+			case termbox.KeyBackspace, termbox.KeyBackspace2:
+				if len(input) > 0 {
+					input = input[:len(input)-1]
+				}
+			case termbox.KeySpace:
+				input = append(input, ' ')
+			default:
+				if e.Ch != 0 {
+					input = append(input, e.Ch)
+				}
+			}
+		}
+	}
+}
+
+// Rename prompt selection modes, cycled with Tab
+const (
+	renameSelectStem = iota
+	renameSelectExt
+	renameSelectAll
+)
+
+// RenamePrompt shows an input prompt pre-filled with the current name.
+// The cursor starts before the extension with the stem selected, and Tab
+// cycles the selection between stem, extension and the whole name.
+func (r *Renderer) RenamePrompt(label, name string, nav *filesystem.Navigator) string {
+	w, h := screen.Size()
+	input := []rune(name)
+	stemLen := len([]rune(NameStem(name)))
+
+	mode := renameSelectStem
+	selStart, selEnd := 0, stemLen
+	cursor := stemLen
+
+	// selectMode updates the selection range for the given mode
+	selectMode := func(m int) {
+		stem := len([]rune(NameStem(string(input))))
+		switch m {
+		case renameSelectStem:
+			selStart, selEnd = 0, stem
+		case renameSelectExt:
+			selStart, selEnd = stem, len(input)
+		case renameSelectAll:
+			selStart, selEnd = 0, len(input)
+		}
+		cursor = selEnd
+	}
+
+	// deleteSelection removes the selected runes, returning true if anything was removed
+	deleteSelection := func() bool {
+		if selStart == selEnd {
+			return false
+		}
+		input = append(input[:selStart:selStart], input[selEnd:]...)
+		cursor = selStart
+		selEnd = selStart
+		return true
+	}
+
+	insert := func(ch rune) {
+		deleteSelection()
+		input = append(input[:cursor], append([]rune{ch}, input[cursor:]...)...)
+		cursor++
+		selStart, selEnd = cursor, cursor
+	}
+
+	labelLen := len([]rune(label))
+	defer screen.HideCursor()
+
+	for {
+		r.Draw(nav, false, "", false)
+
+		fg := r.theme().ColorHighlightText
+		bg := r.theme().ColorHighlight
+		for i := 0; i < w; i++ {
+			screen.SetCell(i, r.messageRow(h), ' ', fg, bg)
+		}
+		x := 0
+		for _, rn := range label {
+			if x >= w {
+				break
+			}
+			screen.SetCell(x, r.messageRow(h), rn, fg, bg)
+			x++
+		}
+		for i, rn := range input {
+			if x >= w {
+				break
+			}
+			// Selected runes are drawn with inverted colors
+			if i >= selStart && i < selEnd {
+				screen.SetCell(x, r.messageRow(h), rn, bg, fg)
+			} else {
+				screen.SetCell(x, r.messageRow(h), rn, fg, bg)
+			}
+			x++
+		}
+		screen.SetCursor(labelLen+cursor, r.messageRow(h))
+		screen.Flush()
+
+		e := screen.PollEvent()
+		if e.Type != termbox.EventKey {
+			continue
+		}
+		switch e.Key {
+		case termbox.KeyEnter:
+			return string(input)
+		case termbox.KeyEsc:
+			return ""
+		case termbox.KeyTab:
+			mode = (mode + 1) % 3
+			selectMode(mode)
+		case termbox.KeyArrowLeft:
+			if cursor > 0 {
+				cursor--
+			}
+			selStart, selEnd = cursor, cursor
+		case termbox.KeyArrowRight:
+			if cursor < len(input) {
+				cursor++
+			}
+			selStart, selEnd = cursor, cursor
+		case termbox.KeyHome, termbox.KeyCtrlA:
+			cursor = 0
+			selStart, selEnd = cursor, cursor
+		case termbox.KeyEnd, termbox.KeyCtrlE:
+			cursor = len(input)
+			selStart, selEnd = cursor, cursor
+		case termbox.KeyBackspace, termbox.KeyBackspace2:
+			if !deleteSelection() && cursor > 0 {
+				input = append(input[:cursor-1], input[cursor:]...)
+				cursor--
+				selStart, selEnd = cursor, cursor
+			}
+		case termbox.KeyDelete:
+			if !deleteSelection() && cursor < len(input) {
+				input = append(input[:cursor], input[cursor+1:]...)
+			}
+		case termbox.KeySpace:
+			insert(' ')
+		default:
+			if e.Ch != 0 {
+				insert(e.Ch)
+			}
+		}
+	}
+}
+
+// NameStem returns the stem of a file name, i.e. the name without its
+// extension. Dotfiles such as ".bashrc" are treated as having no extension.
+func NameStem(name string) string {
+	ext := filepath.Ext(name)
+	if ext == "" || ext == name {
+		return name
+	}
+	return strings.TrimSuffix(name, ext)
+}
+
 // ConfirmPrompt shows a yes/no confirmation prompt
 func (r *Renderer) ConfirmPrompt(message string) bool {
-	w, h := termbox.Size()
+	w, h := screen.Size()
 	prompt := message + " (y/n)"
 	
 	for {
 		for i := 0; i < w; i++ {
-			termbox.SetCell(i, h-2, ' ', r.theme().ColorHighlightText, r.theme().ColorHighlight)
+			screen.SetCell(i, r.messageRow(h), ' ', r.theme().ColorHighlightText, r.theme().ColorHighlight)
 		}
 		for i, rn := range prompt {
 			if i >= w {
 				break
 			}
-			termbox.SetCell(i, h-2, rn, r.theme().ColorHighlightText, r.theme().ColorHighlight)
+			screen.SetCell(i, r.messageRow(h), rn, r.theme().ColorHighlightText, r.theme().ColorHighlight)
 		}
-		termbox.Flush()
+		screen.Flush()
 
-		ev := termbox.PollEvent()
+		ev := screen.PollEvent()
 		if ev.Type == termbox.EventKey {
 			switch ev.Ch {
 			case 'y', 'Y':
@@ -785,15 +1370,16 @@ func (r *Renderer) ConfirmPrompt(message string) bool {
 	}
 }
 
-// OpenTerminal opens a terminal in the given directory
-func OpenTerminal(path, terminalApp string) {
+// TerminalCommand returns the command that opens a terminal in the given
+// directory
+func TerminalCommand(path, terminalApp string) *exec.Cmd {
 	switch runtime.GOOS {
 	case "windows":
-		exec.Command("cmd", "/C", "start", "cmd", "/K", "cd", "/d", path).Start()
+		return exec.Command("cmd", "/C", "start", "cmd", "/K", "cd", "/d", path)
 	case "darwin":
-		exec.Command("open", "-a", terminalApp, path).Start()
+		return exec.Command("open", "-a", terminalApp, path)
 	default:
-		exec.Command(terminalApp, "--working-directory="+path).Start()
+		return exec.Command(terminalApp, "--working-directory="+path)
 	}
 }
 
@@ -803,6 +1389,30 @@ func (r *Renderer) theme() *theme.Theme {
 	return r.themeManager.GetCurrent()
 }
 
+// fileIcon returns the icon for a file, falling back to ASCII when the
+// terminal cannot render emoji or Nerd Font glyphs reliably
+func (r *Renderer) fileIcon(name string, isDir bool) string {
+	if r.config.SafeGlyphs {
+		return config.SafeFileIcon(isDir)
+	}
+	return config.FileIcon(name, isDir, r.config.UseAsciiIcons)
+}
+
+// entryColor returns the color of a listed entry: a warning color when
+// ownership colors are on and it is world-writable or owned by another user,
+// otherwise the theme's color for its type
+func (r *Renderer) entryColor(info os.FileInfo) termbox.Attribute {
+	if r.config.OwnershipColors {
+		if filesystem.IsWorldWritable(info) {
+			return r.theme().ColorWorldWritable
+		}
+		if filesystem.IsForeign(info) {
+			return r.theme().ColorForeign
+		}
+	}
+	return r.themeManager.GetFileColor(info.Name(), info.IsDir())
+}
+
 func formatFileLine(icon, name string) string {
 	if icon == "" {
 		return name
@@ -810,6 +1420,14 @@ func formatFileLine(icon, name string) string {
 	return IconSpacing + icon + IconSpacing + name
 }
 
+// formatAggregateSize formats a total size, marking it while still being measured
+func formatAggregateSize(size int64, pending bool) string {
+	if pending {
+		return formatSize(size) + glyphs().Pending
+	}
+	return formatSize(size)
+}
+
 func formatSize(size int64) string {
 	const unit = 1024
 	if size < unit {
@@ -847,19 +1465,19 @@ func DrawBoxWithTitle(startX, startY, width, height int, title string, fg, bg te
 			ch := ' '
 			switch {
 			case y == 0 && x == 0:
-				ch = '╔'
+				ch = glyphs().BoxTopLeft
 			case y == 0 && x == width-1:
-				ch = '╗'
+				ch = glyphs().BoxTopRight
 			case y == height-1 && x == 0:
-				ch = '╚'
+				ch = glyphs().BoxBottomLeft
 			case y == height-1 && x == width-1:
-				ch = '╝'
+				ch = glyphs().BoxBottomRight
 			case y == 0 || y == height-1:
-				ch = '═'
+				ch = glyphs().BoxHorizontal
 			case x == 0 || x == width-1:
-				ch = '║'
+				ch = glyphs().BoxVertical
 			}
-			termbox.SetCell(startX+x, startY+y, ch, fg, bg)
+			screen.SetCell(startX+x, startY+y, ch, fg, bg)
 		}
 	}
 
@@ -868,31 +1486,47 @@ func DrawBoxWithTitle(startX, startY, width, height int, title string, fg, bg te
 	titleStartX := startX + (width-len(title))/2
 	for i, r := range title {
 		if titleStartX+i >= startX && titleStartX+i < startX+width {
-			termbox.SetCell(titleStartX+i, startY, r, fg, bg)
+			screen.SetCell(titleStartX+i, startY, r, fg, bg)
 		}
 	}
 }
 
-// ShowEditorSelectionPopup displays a popup to select an editor
-func (r *Renderer) ShowEditorSelectionPopup(editors []config.EditorOption, nav *filesystem.Navigator, inPathEditMode bool, pathEditBuffer string, showHelp bool) int {
-	w, h := termbox.Size()
-	popupWidth := 60
-	popupHeight := len(editors) + 4
-	startX := (w - popupWidth) / 2
-	startY := (h - popupHeight) / 2
-
-	selected := 0
+// ShowEditorSelectionPopup displays a popup to select an editor, starting at
+// index selected. The second result is true when the choice was made with 'p'
+// (make it the permanent handler for the file type).
+func (r *Renderer) ShowEditorSelectionPopup(editors []config.EditorOption, selected int, nav *filesystem.Navigator, inPathEditMode bool, pathEditBuffer string, showHelp bool) (int, bool) {
+	if selected < 0 || selected >= len(editors) {
+		selected = 0
+	}
+	offset := 0
 
 	for {
-		termbox.Clear(r.theme().ColorText, r.theme().ColorBackground)
+		// Long lists (many installed applications) scroll within the screen
+		w, h := screen.Size()
+		visible := len(editors)
+		if visible > h-7 {
+			visible = max(h-7, 1)
+		}
+		if selected < offset {
+			offset = selected
+		} else if selected >= offset+visible {
+			offset = selected - visible + 1
+		}
+		popupWidth := 60
+		popupHeight := visible + 5
+		startX := (w - popupWidth) / 2
+		startY := (h - popupHeight) / 2
+
+		screen.Clear(r.theme().ColorText, r.theme().ColorBackground)
 		r.Draw(nav, inPathEditMode, pathEditBuffer, showHelp)
 
 		// Draw popup box
 		DrawBoxWithTitle(startX, startY, popupWidth, popupHeight, "Open With", r.theme().ColorText, r.theme().ColorBackground)
 
 		// Draw editor options
-		for i, editor := range editors {
-			y := startY + 2 + i
+		for i := offset; i < offset+visible && i < len(editors); i++ {
+			editor := editors[i]
+			y := startY + 2 + i - offset
 			fg := r.theme().ColorText
 			bg := r.theme().ColorBackground
 
@@ -905,10 +1539,11 @@ func (r *Renderer) ShowEditorSelectionPopup(editors []config.EditorOption, nav *
 			text := fmt.Sprintf(" %s - %s", editor.Name, editor.Description)
 			drawTextInBox(startX+1, y, popupWidth-2, text, fg, bg)
 		}
+		drawTextInBox(startX+1, startY+popupHeight-2, popupWidth-2, " p: always open this type with it", r.theme().ColorDim, r.theme().ColorBackground)
 
-		termbox.Flush()
+		screen.Flush()
 
-		ev := termbox.PollEvent()
+		ev := screen.PollEvent()
 		
 		// Handle window focus events - redraw on any event type
 		if ev.Type == termbox.EventResize || ev.Type == termbox.EventInterrupt {
@@ -928,9 +1563,12 @@ func (r *Renderer) ShowEditorSelectionPopup(editors []config.EditorOption, nav *
 					selected = 0
 				}
 			case termbox.KeyEnter:
-				return selected
+				return selected, false
 			case termbox.KeyEsc:
-				return -1
+				return -1, false
+			}
+			if ev.Ch == 'p' {
+				return selected, true
 			}
 		}
 	}
@@ -940,24 +1578,38 @@ func (r *Renderer) ShowEditorSelectionPopup(editors []config.EditorOption, nav *
 
 // ShowContextMenu displays a context menu for file operations
 func (r *Renderer) ShowContextMenu(options []string, nav *filesystem.Navigator, inPathEditMode bool, pathEditBuffer string, showHelp bool) int {
-	w, h := termbox.Size()
-	popupWidth := 40
-	popupHeight := len(options) + 4
-	startX := (w - popupWidth) / 2
-	startY := (h - popupHeight) / 2
+	return r.ShowChoicePopup("File Operations", 40, options, nav, inPathEditMode, pathEditBuffer, showHelp)
+}
 
+// ShowChoicePopup displays a titled list of options and returns the index
+// chosen, or -1 when canceled
+func (r *Renderer) ShowChoicePopup(title string, popupWidth int, options []string, nav *filesystem.Navigator, inPathEditMode bool, pathEditBuffer string, showHelp bool) int {
 	selected := 0
+	offset := 0
 
 	for {
-		termbox.Clear(r.theme().ColorText, r.theme().ColorBackground)
+		// Long lists scroll within the screen
+		w, h := screen.Size()
+		width := min(popupWidth, w-2)
+		visible := min(len(options), max(h-6, 1))
+		if selected < offset {
+			offset = selected
+		} else if selected >= offset+visible {
+			offset = selected - visible + 1
+		}
+		popupHeight := visible + 4
+		startX := (w - width) / 2
+		startY := (h - popupHeight) / 2
+
+		screen.Clear(r.theme().ColorText, r.theme().ColorBackground)
 		r.Draw(nav, inPathEditMode, pathEditBuffer, showHelp)
 
 		// Draw popup box
-		DrawBoxWithTitle(startX, startY, popupWidth, popupHeight, "File Operations", r.theme().ColorText, r.theme().ColorBackground)
+		DrawBoxWithTitle(startX, startY, width, popupHeight, title, r.theme().ColorText, r.theme().ColorBackground)
 
 		// Draw menu options
-		for i, option := range options {
-			y := startY + 2 + i
+		for i := offset; i < offset+visible; i++ {
+			y := startY + 2 + i - offset
 			fg := r.theme().ColorText
 			bg := r.theme().ColorBackground
 
@@ -966,14 +1618,14 @@ func (r *Renderer) ShowContextMenu(options []string, nav *filesystem.Navigator,
 				bg = r.theme().ColorHighlight
 			}
 
-			text := " " + option
-			drawTextInBox(startX+1, y, popupWidth-2, text, fg, bg)
+			text := " " + options[i]
+			drawTextInBox(startX+1, y, width-2, text, fg, bg)
 		}
 
-		termbox.Flush()
+		screen.Flush()
 		debugLog("ShowSortingPopup: Waiting for event...")
 
-		ev := termbox.PollEvent()
+		ev := screen.PollEvent()
 		debugLog("ShowSortingPopup: Got event type=%d key=%d ch=%c", ev.Type, ev.Key, ev.Ch)
 		
 		// Handle window focus events - redraw on any event type
@@ -997,6 +1649,10 @@ func (r *Renderer) ShowContextMenu(options []string, nav *filesystem.Navigator,
 				if selected >= len(options) {
 					selected = 0 // Wrap to top
 				}
+			case termbox.KeyPgup:
+				selected = max(selected-visible, 0)
+			case termbox.KeyPgdn:
+				selected = min(selected+visible, len(options)-1)
 			case termbox.KeyEnter:
 				debugLog("ShowSortingPopup: Enter pressed, returning %d", selected)
 				return selected
@@ -1010,7 +1666,7 @@ func (r *Renderer) ShowContextMenu(options []string, nav *filesystem.Navigator,
 // ShowSortingPopup displays a popup to select sorting mode
 func (r *Renderer) ShowSortingPopup(nav *filesystem.Navigator, inPathEditMode bool, pathEditBuffer string, showHelp bool) int {
 	debugLog("ShowSortingPopup: ENTER")
-	w, h := termbox.Size()
+	w, h := screen.Size()
 	
 	// Build sorting options
 	options := []string{
@@ -1030,7 +1686,7 @@ func (r *Renderer) ShowSortingPopup(nav *filesystem.Navigator, inPathEditMode bo
 	debugLog("ShowSortingPopup: Starting event loop")
 
 	for {
-		termbox.Clear(r.theme().ColorText, r.theme().ColorBackground)
+		screen.Clear(r.theme().ColorText, r.theme().ColorBackground)
 		r.Draw(nav, inPathEditMode, pathEditBuffer, showHelp)
 
 		// Draw popup box
@@ -1051,7 +1707,7 @@ func (r *Renderer) ShowSortingPopup(nav *filesystem.Navigator, inPathEditMode bo
 			prefix := "  "
 			suffix := ""
 			if i == int(nav.GetSortMode()) {
-				prefix = "✓ "
+				prefix = glyphs().Selected
 				if nav.GetSortReverse() {
 					suffix = " ↓"
 				}
@@ -1068,19 +1724,19 @@ func (r *Renderer) ShowSortingPopup(nav *filesystem.Navigator, inPathEditMode bo
 			// Draw the text with proper Unicode support
 			x := 0
 			for _, r := range runes {
-				termbox.SetCell(startX+1+x, y, r, fg, bg)
+				screen.SetCell(startX+1+x, y, r, fg, bg)
 				x++
 			}
 			// Fill remaining space
 			for x < popupWidth-2 {
-				termbox.SetCell(startX+1+x, y, ' ', fg, bg)
+				screen.SetCell(startX+1+x, y, ' ', fg, bg)
 				x++
 			}
 		}
 
-		termbox.Flush()
+		screen.Flush()
 
-		ev := termbox.PollEvent()
+		ev := screen.PollEvent()
 		if ev.Type == termbox.EventKey {
 			switch ev.Key {
 			case termbox.KeyArrowUp:
@@ -1105,10 +1761,10 @@ func (r *Renderer) ShowSortingPopup(nav *filesystem.Navigator, inPathEditMode bo
 
 // ShowError displays an error message
 func (r *Renderer) ShowError(message string) {
-	w, h := termbox.Size()
+	w, h := screen.Size()
 	
 	for i := 0; i < w; i++ {
-		termbox.SetCell(i, h-2, ' ', r.theme().ColorHighlightText, r.theme().ColorHighlight)
+		screen.SetCell(i, r.messageRow(h), ' ', r.theme().ColorHighlightText, r.theme().ColorHighlight)
 	}
 	
 	errorMsg := "Error: " + message
@@ -1116,17 +1772,18 @@ func (r *Renderer) ShowError(message string) {
 		if i >= w {
 			break
 		}
-		termbox.SetCell(i, h-2, rn, r.theme().ColorHighlightText, r.theme().ColorHighlight)
+		screen.SetCell(i, r.messageRow(h), rn, r.theme().ColorHighlightText, r.theme().ColorHighlight)
 	}
-	termbox.Flush()
+	screen.Flush()
 	
-	// Wait for any key press
-	termbox.PollEvent()
+	// Wait for any key press or click; moving the mouse doesn't count
+	for screen.IsHover(screen.PollEvent()) {
+	}
 }
 
 // ShowConfigMenu displays the main configuration menu
 func (r *Renderer) ShowConfigMenu() string {
-	w, h := termbox.Size()
+	w, h := screen.Size()
 	
 	// Build options with current state
 	mouseStatus := "disabled"
@@ -1139,6 +1796,46 @@ func (r *Renderer) ShowConfigMenu() string {
 		iconStatus = "Unicode"
 	}
 	
+	keepStatus := "off"
+	if r.config.KeepSelection {
+		keepStatus = "on"
+	}
+	
+	refreshStatus := "off"
+	if r.config.AutoRefresh {
+		refreshStatus = "on"
+	}
+	
+	viewStatus := "off"
+	if r.config.RememberView {
+		viewStatus = "on"
+	}
+	
+	stagingStatus := "off"
+	if r.config.SoftDeleteDays > 0 {
+		stagingStatus = fmt.Sprintf("%d days", r.config.SoftDeleteDays)
+	}
+	
+	verifyStatus := "off"
+	if r.config.VerifyCopies {
+		verifyStatus = "on"
+	}
+	
+	headerStatus := "off"
+	if r.config.ColumnHeader {
+		headerStatus = "on"
+	}
+	
+	priorityStatus := "off"
+	if r.config.LowPriority {
+		priorityStatus = "on"
+	}
+	
+	dryRunStatus := "off"
+	if r.config.DryRunCommands {
+		dryRunStatus = "on"
+	}
+	
 	options := []string{
 		"Select Theme",
 		"Create New Theme",
@@ -1148,6 +1845,24 @@ func (r *Renderer) ShowConfigMenu() string {
 		"Set Default Editor",
 		"Toggle Mouse Support [" + mouseStatus + "]",
 		"Toggle Icon Style [" + iconStatus + "]",
+		"Glyph Mode [" + r.config.GlyphMode + "]",
+		"Calibrate Glyphs",
+		"Filter Case [" + r.config.FilterCase + "]",
+		"Keep Selection Across Folders [" + keepStatus + "]",
+		"Truncate Names [" + r.config.NameTruncation + "]",
+		fmt.Sprintf("Scroll Margin [%d]", r.config.ScrollOff),
+		"Column Header [" + headerStatus + "]",
+		"File Sizes [" + r.config.ShowSizes + "]",
+		"Auto Refresh [" + refreshStatus + "]",
+		"Remember View Per Folder [" + viewStatus + "]",
+		"Soft Delete [" + stagingStatus + "]",
+		"Verify Copies [" + verifyStatus + "]",
+		"Low Priority Jobs [" + priorityStatus + "]",
+		"Dry Run Commands [" + dryRunStatus + "]",
+		"Backups Before Overwrite [" + r.config.BackupMode + "]",
+		"Test File Associations",
+		"Edit Config File",
+		"Check for Updates",
 		"Restore to Default",
 		"Cancel",
 	}
@@ -1177,9 +1892,9 @@ func (r *Renderer) ShowConfigMenu() string {
 			drawTextInBox(startX+1, y, boxWidth-2, text, fg, bg)
 		}
 		
-		termbox.Flush()
+		screen.Flush()
 		
-		ev := termbox.PollEvent()
+		ev := screen.PollEvent()
 		if ev.Type == termbox.EventKey {
 			switch ev.Key {
 			case termbox.KeyArrowUp:
@@ -1201,6 +1916,464 @@ func (r *Renderer) ShowConfigMenu() string {
 	}
 }
 
+// ShowGlyphCalibration draws sample emoji and box-drawing glyphs followed by
+// a marker column and asks whether the markers line up. It returns whether
+// the glyphs render correctly and whether the user answered at all.
+func (r *Renderer) ShowGlyphCalibration() (supported bool, answered bool) {
+	w, h := screen.Size()
+	fg := r.theme().ColorFooter
+	bg := r.theme().ColorFooterBg
+
+	samples := []string{"ab", "📁", "📄", "🖼", "╔═", "✓ ", "★ "}
+	boxWidth := 50
+	boxHeight := len(samples) + 7
+	startX := (w - boxWidth) / 2
+	startY := (h - boxHeight) / 2
+
+	for {
+		// Always draw the frame with ASCII so the frame itself is reliable
+		wasSafe := safeGlyphs
+		safeGlyphs = true
+		DrawBoxWithTitle(startX, startY, boxWidth, boxHeight, "Calibrate Glyphs", fg, bg)
+		safeGlyphs = wasSafe
+
+		drawTextInBox(startX+2, startY+2, boxWidth-4, "Do all the | markers form a straight line?", fg, bg)
+		for i, sample := range samples {
+			y := startY + 4 + i
+			x := startX + 4
+			drawTextInBox(startX+1, y, boxWidth-2, "", fg, bg)
+			for _, rn := range sample {
+				screen.SetCell(x, y, rn, fg, bg)
+				x += runeWidth(rn)
+			}
+			// Every sample is two columns wide when rendered correctly
+			screen.SetCell(startX+6, y, '|', r.theme().ColorHighlight, bg)
+		}
+		drawTextInBox(startX+2, startY+boxHeight-2, boxWidth-4, "y: yes (use Unicode)  n: no (use ASCII)  Esc", fg, bg)
+		screen.Flush()
+
+		ev := screen.PollEvent()
+		if ev.Type != termbox.EventKey {
+			continue
+		}
+		switch {
+		case ev.Ch == 'y' || ev.Ch == 'Y':
+			return true, true
+		case ev.Ch == 'n' || ev.Ch == 'N':
+			return false, true
+		case ev.Key == termbox.KeyEsc:
+			return false, false
+		}
+	}
+}
+
+// ShowProblems lists configuration and theme file problems found at startup.
+// It returns true if the user chose to open the first problem's file.
+func (r *Renderer) ShowProblems(problems []config.ValidationError) bool {
+	w, h := screen.Size()
+	fg := r.theme().ColorFooter
+	bg := r.theme().ColorFooterBg
+
+	boxWidth := min(w-4, 100)
+	maxLines := max(h-8, 1)
+	lines := make([]string, 0, len(problems))
+	for _, p := range problems {
+		lines = append(lines, p.Error())
+	}
+	if len(lines) > maxLines {
+		lines = append(lines[:maxLines-1], fmt.Sprintf("... and %d more", len(problems)-maxLines+1))
+	}
+	boxHeight := len(lines) + 6
+	startX := (w - boxWidth) / 2
+	startY := (h - boxHeight) / 2
+
+	screen.Clear(r.theme().ColorBackground, r.theme().ColorBackground)
+	DrawBoxWithTitle(startX, startY, boxWidth, boxHeight, "Settings Problems", fg, bg)
+	for i, line := range lines {
+		drawTextInBox(startX+2, startY+2+i, boxWidth-4, line, fg, bg)
+	}
+	drawTextInBox(startX+2, startY+boxHeight-2, boxWidth-4, "e: edit "+filepath.Base(problems[0].File)+"  any other key: continue", r.theme().ColorHighlight, bg)
+	screen.Flush()
+
+	for {
+		ev := screen.PollEvent()
+		if ev.Type == termbox.EventKey {
+			return ev.Ch == 'e' || ev.Ch == 'E'
+		}
+	}
+}
+
+// ShowTextPopup shows lines of text in a scrollable box until Esc, Enter or q
+func (r *Renderer) ShowTextPopup(title string, lines []string) {
+	w, h := screen.Size()
+	fg := r.theme().ColorFooter
+	bg := r.theme().ColorFooterBg
+
+	boxWidth := min(w-4, 100)
+	visible := max(min(len(lines), h-8), 1)
+	boxHeight := visible + 5
+	startX := (w - boxWidth) / 2
+	startY := (h - boxHeight) / 2
+	offset := 0
+
+	for {
+		DrawBoxWithTitle(startX, startY, boxWidth, boxHeight, title, fg, bg)
+		for i := 0; i < visible; i++ {
+			line := ""
+			if offset+i < len(lines) {
+				line = lines[offset+i]
+			}
+			drawTextInBox(startX+2, startY+2+i, boxWidth-4, line, fg, bg)
+		}
+		drawTextInBox(startX+2, startY+boxHeight-2, boxWidth-4, "↑↓ scroll  Esc/Enter: close", r.theme().ColorDim, bg)
+		screen.Flush()
+
+		ev := screen.PollEvent()
+		if ev.Type != termbox.EventKey {
+			continue
+		}
+		switch {
+		case ev.Key == termbox.KeyArrowDown && offset+visible < len(lines):
+			offset++
+		case ev.Key == termbox.KeyArrowUp && offset > 0:
+			offset--
+		case ev.Key == termbox.KeyEsc, ev.Key == termbox.KeyEnter, ev.Ch == 'q':
+			return
+		}
+	}
+}
+
+// ShowAssociationTester shows how a file name is classified while it is
+// typed: the icon and color from the renderer, then the lines returned by
+// describe. ↑/↓ pick names from the current listing; a trailing "/" marks
+// a directory.
+func (r *Renderer) ShowAssociationTester(nav *filesystem.Navigator, describe func(name string) []string) {
+	w, h := screen.Size()
+	fg := r.theme().ColorFooter
+	bg := r.theme().ColorFooterBg
+
+	files := nav.GetFileList()
+	picked := nav.GetCursor()
+	pick := func() []rune {
+		if picked < 0 || picked >= len(files) {
+			return nil
+		}
+		name := files[picked].Name()
+		if files[picked].IsDir() {
+			name += "/"
+		}
+		return []rune(name)
+	}
+	input := pick()
+
+	boxWidth := min(w-4, 90)
+	for {
+		name := string(input)
+		isDir := strings.HasSuffix(name, "/")
+		base := strings.TrimSuffix(name, "/")
+		lines := describe(name)
+
+		boxHeight := len(lines) + 9
+		startX := (w - boxWidth) / 2
+		startY := max((h-boxHeight)/2, 0)
+		DrawBoxWithTitle(startX, startY, boxWidth, boxHeight, "File Association Test", fg, bg)
+		drawTextInBox(startX+2, startY+2, boxWidth-4, "File name: "+name+"_", r.theme().ColorHighlightText, r.theme().ColorHighlight)
+
+		colorRule := "default text color"
+		if isDir {
+			colorRule = "directory color"
+		} else if ext := strings.ToLower(filepath.Ext(base)); ext != "" {
+			if _, ok := r.theme().FileColors[ext]; ok {
+				colorRule = "theme color for " + ext
+			}
+		}
+		drawTextInBox(startX+2, startY+4, boxWidth-4, "Icon:      "+r.fileIcon(base, isDir), fg, bg)
+		drawTextInBox(startX+2, startY+5, boxWidth-4, "Color:", fg, bg)
+		nameWidth := min(textWidth(base), boxWidth-15)
+		drawClipped(startX+13, startY+5, nameWidth, base, r.themeManager.GetFileColor(base, isDir), bg)
+		drawTextInBox(startX+14+nameWidth, startY+5, max(boxWidth-16-nameWidth, 0), "("+colorRule+")", r.theme().ColorDim, bg)
+		for i, line := range lines {
+			drawTextInBox(startX+2, startY+6+i, boxWidth-4, line, fg, bg)
+		}
+		drawTextInBox(startX+2, startY+boxHeight-2, boxWidth-4, "Type a name  ↑↓ pick from listing  Esc/Enter: close", r.theme().ColorDim, bg)
+		screen.Flush()
+
+		ev := screen.PollEvent()
+		if ev.Type != termbox.EventKey {
+			continue
+		}
+		switch ev.Key {
+		case termbox.KeyEsc, termbox.KeyEnter:
+			return
+		case termbox.KeyArrowUp, termbox.KeyArrowDown:
+			if len(files) == 0 {
+				continue
+			}
+			if ev.Key == termbox.KeyArrowUp {
+				picked = (picked - 1 + len(files)) % len(files)
+			} else {
+				picked = (picked + 1) % len(files)
+			}
+			input = pick()
+		case termbox.KeyBackspace, termbox.KeyBackspace2:
+			if len(input) > 0 {
+				input = input[:len(input)-1]
+			}
+		case termbox.KeySpace:
+			input = append(input, ' ')
+		default:
+			if ev.Ch != 0 {
+				input = append(input, ev.Ch)
+			}
+		}
+		// Clear the previous box, which may have been taller
+		r.Draw(nav, false, "", false)
+	}
+}
+
+// FuzzyFinder lets the user narrow a list of paths down by typing, asking
+// rank for the best matches on every keystroke. Matched characters are
+// underlined and long paths are shortened in the middle to keep the file
+// name. It returns the chosen path, or "" when canceled.
+func (r *Renderer) FuzzyFinder(title string, rank func(query string) []search.FuzzyMatch) string {
+	fg := r.theme().ColorText
+	bg := r.theme().ColorBackground
+	input := []rune{}
+	matches := rank("")
+	selected, offset := 0, 0
+
+	for {
+		w, h := screen.Size()
+		width := min(w-2, 120)
+		height := max(h-4, 6)
+		visible := height - 5
+		startX := (w - width) / 2
+		startY := (h - height) / 2
+		if selected < offset {
+			offset = selected
+		} else if selected >= offset+visible {
+			offset = selected - visible + 1
+		}
+
+		DrawBoxWithTitle(startX, startY, width, height, title, fg, bg)
+		drawTextInBox(startX+2, startY+2, width-4, "> "+string(input)+"_", r.theme().ColorHighlightText, r.theme().ColorHighlight)
+		for i := 0; i < visible; i++ {
+			y := startY + 3 + i
+			drawTextInBox(startX+1, y, width-2, "", fg, bg)
+			if offset+i >= len(matches) {
+				continue
+			}
+			m := matches[offset+i]
+			rowFg, rowBg := fg, bg
+			if offset+i == selected {
+				rowFg, rowBg = r.theme().ColorHighlightText, r.theme().ColorHighlight
+				drawTextInBox(startX+1, y, width-2, "", rowFg, rowBg)
+			}
+			matched := make(map[int]bool, len(m.Positions))
+			for _, pos := range m.Positions {
+				matched[pos] = true
+			}
+			runes, sources := fitText(m.Path, width-4, true)
+			x := startX + 2
+			for j, rn := range runes {
+				cellFg := rowFg
+				if matched[sources[j]] {
+					cellFg |= termbox.AttrBold | termbox.AttrUnderline
+				}
+				screen.SetCell(x, y, rn, cellFg, rowBg)
+				x += runeWidth(rn)
+			}
+		}
+		status := fmt.Sprintf("%d matches  ↑↓ choose  Enter: jump  Esc: cancel", len(matches))
+		drawTextInBox(startX+2, startY+height-2, width-4, status, r.theme().ColorDim, bg)
+		screen.Flush()
+
+		ev := screen.PollEvent()
+		if ev.Type != termbox.EventKey {
+			continue
+		}
+		switch ev.Key {
+		case termbox.KeyEsc:
+			return ""
+		case termbox.KeyEnter:
+			if selected < len(matches) {
+				return matches[selected].Path
+			}
+			continue
+		case termbox.KeyArrowUp:
+			selected = max(selected-1, 0)
+			continue
+		case termbox.KeyArrowDown:
+			selected = max(min(selected+1, len(matches)-1), 0)
+			continue
+		case termbox.KeyPgup:
+			selected = max(selected-visible, 0)
+			continue
+		case termbox.KeyPgdn:
+			selected = max(min(selected+visible, len(matches)-1), 0)
+			continue
+		case termbox.KeyBackspace, termbox.KeyBackspace2:
+			if len(input) == 0 {
+				continue
+			}
+			input = input[:len(input)-1]
+		case termbox.KeySpace:
+			input = append(input, ' ')
+		default:
+			if ev.Ch == 0 {
+				continue
+			}
+			input = append(input, ev.Ch)
+		}
+		matches = rank(string(input))
+		selected, offset = 0, 0
+	}
+}
+
+// GrepPrompt asks for a content search pattern. Tab cycles the case mode and
+// Ctrl+R toggles regular expressions; the chosen options are returned with
+// the pattern ("" when canceled).
+func (r *Renderer) GrepPrompt(nav *filesystem.Navigator, mode filesystem.CaseMode, regex bool) (string, filesystem.CaseMode, bool) {
+	w, h := screen.Size()
+	input := []rune{}
+
+	for {
+		r.Draw(nav, false, "", false)
+
+		kind := "text"
+		if regex {
+			kind = "regex"
+		}
+		full := fmt.Sprintf("Grep [%s, %s] (Tab: case, ^R: regex): %s", filesystem.CaseModeNames[mode], kind, string(input))
+		drawTextInBox(0, r.messageRow(h), w, full, r.theme().ColorHighlightText, r.theme().ColorHighlight)
+		screen.Flush()
+
+		e := screen.PollEvent()
+		if e.Type != termbox.EventKey {
+			continue
+		}
+		switch e.Key {
+		case termbox.KeyEnter:
+			return string(input), mode, regex
+		case termbox.KeyEsc:
+			return "", mode, regex
+		case termbox.KeyTab:
+			mode = (mode + 1) % filesystem.CaseMode(len(filesystem.CaseModeNames))
+		case termbox.KeyCtrlR:
+			regex = !regex
+		case termbox.KeyBackspace, termbox.KeyBackspace2:
+			if len(input) > 0 {
+				input = input[:len(input)-1]
+			}
+		case termbox.KeySpace:
+			input = append(input, ' ')
+		default:
+			if e.Ch != 0 {
+				input = append(input, e.Ch)
+			}
+		}
+	}
+}
+
+// ShowDiff displays unified diff output full-screen with added lines green,
+// removed lines red and hunk headers highlighted
+func (r *Renderer) ShowDiff(title string, lines []string) {
+	offset := 0
+	for {
+		w, h := screen.Size()
+		bg := r.theme().ColorBackground
+		visible := max(h-2, 1)
+		screen.Clear(r.theme().ColorText, bg)
+
+		drawTextInBox(0, 0, w, " "+title, r.theme().ColorAddressBar, r.theme().ColorAddressBarBg)
+		for i := 0; i < visible && offset+i < len(lines); i++ {
+			line := lines[offset+i]
+			fg := r.theme().ColorText
+			switch {
+			case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+				fg = r.theme().ColorText | termbox.AttrBold
+			case strings.HasPrefix(line, "@@"):
+				fg = r.theme().ColorHighlight
+			case strings.HasPrefix(line, "+"):
+				fg = termbox.ColorGreen
+			case strings.HasPrefix(line, "-"):
+				fg = termbox.ColorRed
+			}
+			x := 0
+			for _, rn := range strings.ReplaceAll(line, "\t", "    ") {
+				if x >= w {
+					break
+				}
+				screen.SetCell(x, 1+i, rn, fg, bg)
+				x += runeWidth(rn)
+			}
+		}
+		status := fmt.Sprintf(" %d-%d of %d | ↑↓ PgUp/PgDn scroll  Esc/q: close", min(offset+1, len(lines)), min(offset+visible, len(lines)), len(lines))
+		drawTextInBox(0, h-1, w, status, r.theme().ColorFooter, r.theme().ColorFooterBg)
+		screen.Flush()
+
+		ev := screen.PollEvent()
+		if ev.Type != termbox.EventKey {
+			continue
+		}
+		last := max(len(lines)-visible, 0)
+		switch {
+		case ev.Key == termbox.KeyArrowDown:
+			offset = min(offset+1, last)
+		case ev.Key == termbox.KeyArrowUp:
+			offset = max(offset-1, 0)
+		case ev.Key == termbox.KeyPgdn, ev.Key == termbox.KeySpace:
+			offset = min(offset+visible, last)
+		case ev.Key == termbox.KeyPgup:
+			offset = max(offset-visible, 0)
+		case ev.Key == termbox.KeyEsc, ev.Key == termbox.KeyEnter, ev.Ch == 'q':
+			return
+		}
+	}
+}
+
+// Replace answers of ReplacePreview
+const (
+	ReplaceCancel = iota // Esc: stop and write nothing
+	ReplaceYes           // y or Enter: replace this line
+	ReplaceNo            // n: keep this line
+	ReplaceAll           // a: replace this line and all remaining ones
+)
+
+// ReplacePreview shows one line before and after a replace and asks whether
+// to apply it. The lines are shown whole, with tabs expanded.
+func (r *Renderer) ReplacePreview(title string, index, total int, before, after string) int {
+	for {
+		w, h := screen.Size()
+		fg := r.theme().ColorFooter
+		bg := r.theme().ColorFooterBg
+		boxWidth := min(w-4, 120)
+		startX := (w - boxWidth) / 2
+		startY := (h - 8) / 2
+
+		DrawBoxWithTitle(startX, startY, boxWidth, 8, fmt.Sprintf("%s (%d of %d)", title, index, total), fg, bg)
+		drawTextInBox(startX+2, startY+2, boxWidth-4, "- "+strings.ReplaceAll(before, "\t", "    "), termbox.ColorRed, bg)
+		drawTextInBox(startX+2, startY+3, boxWidth-4, "+ "+strings.ReplaceAll(after, "\t", "    "), termbox.ColorGreen, bg)
+		drawTextInBox(startX+2, startY+5, boxWidth-4, "y: replace  n: skip  a: replace all remaining  Esc: cancel", r.theme().ColorDim, bg)
+		screen.Flush()
+
+		ev := screen.PollEvent()
+		if ev.Type != termbox.EventKey {
+			continue
+		}
+		switch {
+		case ev.Ch == 'y', ev.Ch == 'Y', ev.Key == termbox.KeyEnter:
+			return ReplaceYes
+		case ev.Ch == 'n', ev.Ch == 'N':
+			return ReplaceNo
+		case ev.Ch == 'a', ev.Ch == 'A':
+			return ReplaceAll
+		case ev.Key == termbox.KeyEsc:
+			return ReplaceCancel
+		}
+	}
+}
+
 // ShowThemeCreator shows the theme creation interface
 func (r *Renderer) ShowThemeCreator() bool {
 	themeName := r.promptForInput("Enter theme name: ")
@@ -1224,7 +2397,7 @@ func (r *Renderer) ShowThemeCreator() bool {
 
 // ShowThemeColorModifier shows the color modification interface
 func (r *Renderer) ShowThemeColorModifier(nav *filesystem.Navigator, inPathEditMode bool, pathEditBuffer string, showHelp bool) {
-	w, h := termbox.Size()
+	w, h := screen.Size()
 	
 	colorOptions := []string{
 		"Text Color",
@@ -1240,6 +2413,8 @@ func (r *Renderer) ShowThemeColorModifier(nav *filesystem.Navigator, inPathEditM
 		"Filter Color",
 		"Filter Background",
 		"Directory Color",
+		"Other Owner Color",
+		"World-Writable Color",
 		"Done",
 	}
 	
@@ -1267,9 +2442,9 @@ func (r *Renderer) ShowThemeColorModifier(nav *filesystem.Navigator, inPathEditM
 			drawTextInBox(startX+1, y, boxWidth-2, text, fg, bg)
 		}
 		
-		termbox.Flush()
+		screen.Flush()
 		
-		ev := termbox.PollEvent()
+		ev := screen.PollEvent()
 		if ev.Type == termbox.EventKey {
 			switch ev.Key {
 			case termbox.KeyArrowUp:
@@ -1296,7 +2471,7 @@ func (r *Renderer) ShowThemeColorModifier(nav *filesystem.Navigator, inPathEditM
 
 // modifyColor shows color selection for a specific element with live preview
 func (r *Renderer) modifyColor(element string, nav *filesystem.Navigator, inPathEditMode bool, pathEditBuffer string, showHelp bool) {
-	w, h := termbox.Size()
+	w, h := screen.Size()
 	
 	colors := []string{
 		"default",
@@ -1348,13 +2523,13 @@ func (r *Renderer) modifyColor(element string, nav *filesystem.Navigator, inPath
 		instruction := "↑↓ Navigate, Enter to confirm, Esc to cancel"
 		for i, ch := range instruction {
 			if startX+2+i < startX+boxWidth-2 {
-				termbox.SetCell(startX+2+i, startY+boxHeight-1, ch, r.theme().ColorFooter, r.theme().ColorFooterBg)
+				screen.SetCell(startX+2+i, startY+boxHeight-1, ch, r.theme().ColorFooter, r.theme().ColorFooterBg)
 			}
 		}
 		
-		termbox.Flush()
+		screen.Flush()
 		
-		ev := termbox.PollEvent()
+		ev := screen.PollEvent()
 		if ev.Type == termbox.EventKey {
 			switch ev.Key {
 			case termbox.KeyArrowUp:
@@ -1382,12 +2557,12 @@ func (r *Renderer) modifyColor(element string, nav *filesystem.Navigator, inPath
 
 // promptForInput shows a simple input prompt
 func (r *Renderer) promptForInput(label string) string {
-	w, h := termbox.Size()
+	w, h := screen.Size()
 	input := ""
 	
 	for {
 		for i := 0; i < w; i++ {
-			termbox.SetCell(i, h-2, ' ', r.theme().ColorHighlightText, r.theme().ColorHighlight)
+			screen.SetCell(i, r.messageRow(h), ' ', r.theme().ColorHighlightText, r.theme().ColorHighlight)
 		}
 		
 		full := label + input
@@ -1395,11 +2570,11 @@ func (r *Renderer) promptForInput(label string) string {
 			if i >= w {
 				break
 			}
-			termbox.SetCell(i, h-2, rn, r.theme().ColorHighlightText, r.theme().ColorHighlight)
+			screen.SetCell(i, r.messageRow(h), rn, r.theme().ColorHighlightText, r.theme().ColorHighlight)
 		}
-		termbox.Flush()
+		screen.Flush()
 		
-		ev := termbox.PollEvent()
+		ev := screen.PollEvent()
 		if ev.Type == termbox.EventKey {
 			switch ev.Key {
 			case termbox.KeyEnter:
@@ -1423,27 +2598,36 @@ func (r *Renderer) promptForInput(label string) string {
 
 // ShowMessage displays a message to the user
 func (r *Renderer) ShowMessage(message string) {
-	w, h := termbox.Size()
+	w, h := screen.Size()
 	
 	for i := 0; i < w; i++ {
-		termbox.SetCell(i, h-2, ' ', r.theme().ColorHighlightText, r.theme().ColorHighlight)
+		screen.SetCell(i, r.messageRow(h), ' ', r.theme().ColorHighlightText, r.theme().ColorHighlight)
 	}
 	
 	for i, rn := range message {
 		if i >= w {
 			break
 		}
-		termbox.SetCell(i, h-2, rn, r.theme().ColorHighlightText, r.theme().ColorHighlight)
+		screen.SetCell(i, r.messageRow(h), rn, r.theme().ColorHighlightText, r.theme().ColorHighlight)
 	}
-	termbox.Flush()
+	screen.Flush()
 	
-	// Wait for any key press
-	termbox.PollEvent()
+	// Wait for any key press or click; moving the mouse doesn't count
+	for screen.IsHover(screen.PollEvent()) {
+	}
+}
+
+// ShowStatus draws a message above the status bar without waiting for a key,
+// for work that blocks the UI briefly
+func (r *Renderer) ShowStatus(message string) {
+	w, h := screen.Size()
+	drawTextInBox(0, r.messageRow(h), w, message, r.theme().ColorHighlightText, r.theme().ColorHighlight)
+	screen.Flush()
 }
 
 // ShowThemeDeleter shows theme deletion interface
 func (r *Renderer) ShowThemeDeleter() bool {
-	w, h := termbox.Size()
+	w, h := screen.Size()
 	themes := r.themeManager.GetThemes()
 	
 	// Filter out default theme and current theme
@@ -1484,9 +2668,9 @@ func (r *Renderer) ShowThemeDeleter() bool {
 			drawTextInBox(startX+1, y, boxWidth-2, text, fg, bg)
 		}
 		
-		termbox.Flush()
+		screen.Flush()
 		
-		ev := termbox.PollEvent()
+		ev := screen.PollEvent()
 		if ev.Type == termbox.EventKey {
 			switch ev.Key {
 			case termbox.KeyArrowUp:
@@ -1518,7 +2702,7 @@ func (r *Renderer) ShowThemeDeleter() bool {
 
 // ShowThemeRenamer shows theme renaming interface
 func (r *Renderer) ShowThemeRenamer() bool {
-	w, h := termbox.Size()
+	w, h := screen.Size()
 	themes := r.themeManager.GetThemes()
 	
 	// Filter out default theme
@@ -1558,9 +2742,9 @@ func (r *Renderer) ShowThemeRenamer() bool {
 			drawTextInBox(startX+1, y, boxWidth-2, text, fg, bg)
 		}
 		
-		termbox.Flush()
+		screen.Flush()
 		
-		ev := termbox.PollEvent()
+		ev := screen.PollEvent()
 		if ev.Type == termbox.EventKey {
 			switch ev.Key {
 			case termbox.KeyArrowUp:
@@ -1594,7 +2778,7 @@ func (r *Renderer) ShowThemeRenamer() bool {
 
 // ShowDefaultEditorSelector shows editor selection for setting default editor
 func (r *Renderer) ShowDefaultEditorSelector() string {
-	w, h := termbox.Size()
+	w, h := screen.Size()
 	
 	// Get available editors
 	editors := config.GetAvailableEditors()
@@ -1639,16 +2823,16 @@ func (r *Renderer) ShowDefaultEditorSelector() string {
 			// Show current editor marker
 			marker := "  "
 			if editor.Command == currentCmd {
-				marker = "✓ "
+				marker = glyphs().Selected
 			}
 			
 			text := marker + editor.Name + " - " + editor.Description
 			drawTextInBox(startX+1, y, boxWidth-2, text, fg, bg)
 		}
 		
-		termbox.Flush()
+		screen.Flush()
 		
-		ev := termbox.PollEvent()
+		ev := screen.PollEvent()
 		if ev.Type == termbox.EventKey {
 			switch ev.Key {
 			case termbox.KeyArrowUp:
@@ -1670,10 +2854,11 @@ func (r *Renderer) ShowDefaultEditorSelector() string {
 	}
 }
 
-// DrawProgressBar draws a progress bar above the metadata bar
+// DrawProgressBar draws a progress bar above the metadata bar, or above
+// the filter bar when one is showing
 func (r *Renderer) DrawProgressBar(progress *fileops.ProgressInfo) {
-	w, h := termbox.Size()
-	y := h - 2 // One line above the metadata bar
+	w, h := screen.Size()
+	y := r.bottom.row(barProgress, h)
 	
 	if progress == nil {
 		return
@@ -1681,6 +2866,9 @@ func (r *Renderer) DrawProgressBar(progress *fileops.ProgressInfo) {
 	
 	progress.Mu.RLock()
 	isActive := progress.Active
+	cancelable := progress.Cancelable
+	canceled := progress.Canceled
+	lowPriority := progress.LowPriority
 	opType := progress.Operation
 	currentFile := progress.CurrentFile
 	processedFiles := progress.ProcessedFiles
@@ -1707,12 +2895,21 @@ func (r *Renderer) DrawProgressBar(progress *fileops.ProgressInfo) {
 		opName = "Moving"
 	case fileops.OpDelete:
 		opName = "Deleting"
+	case fileops.OpTrash:
+		opName = "Trashing"
+	case fileops.OpCompress:
+		opName = "Compressing"
+	case fileops.OpExtract:
+		opName = "Extracting"
 	}
 	
 	// If not active, show completion message
 	if !isActive && totalFiles > 0 {
 		opName = opName[:len(opName)-3] // Remove "ing" suffix
 		statusText := fmt.Sprintf("%s completed! (%d files)", opName, totalFiles)
+		if canceled {
+			statusText = fmt.Sprintf("%s canceled (%d of %d files done)", opName, processedFiles, totalFiles)
+		}
 		
 		// Draw completion message across the bottom
 		for x := 0; x < w; x++ {
@@ -1720,7 +2917,7 @@ func (r *Renderer) DrawProgressBar(progress *fileops.ProgressInfo) {
 			if x < len(statusText) {
 				ch = rune(statusText[x])
 			}
-			termbox.SetCell(x, y, ch, r.theme().ColorHighlight, r.theme().ColorHighlightText)
+			screen.SetCell(x, y, ch, r.theme().ColorHighlight, r.theme().ColorHighlightText)
 		}
 		return
 	}
@@ -1746,6 +2943,12 @@ func (r *Renderer) DrawProgressBar(progress *fileops.ProgressInfo) {
 	// Build status text
 	statusText := fmt.Sprintf("%s: %s (%d/%d files) %d%% - %s",
 		opName, currentFile, processedFiles, totalFiles, percent, speedStr)
+	if lowPriority {
+		statusText += " - low priority"
+	}
+	if cancelable {
+		statusText += " - Esc to cancel"
+	}
 	
 	// Calculate progress bar width (leave space for text)
 	barWidth := w - len(statusText) - 4
@@ -1762,7 +2965,7 @@ func (r *Renderer) DrawProgressBar(progress *fileops.ProgressInfo) {
 		if x >= w-barWidth-3 {
 			break
 		}
-		termbox.SetCell(x, y, ch, r.theme().ColorFooter, r.theme().ColorFooterBg)
+		screen.SetCell(x, y, ch, r.theme().ColorFooter, r.theme().ColorFooterBg)
 		x++
 	}
 	
@@ -1771,8 +2974,8 @@ func (r *Renderer) DrawProgressBar(progress *fileops.ProgressInfo) {
 	filledWidth := (barWidth * percent) / 100
 	
 	// Draw bar border
-	termbox.SetCell(barStart, y, '[', r.theme().ColorFooter, r.theme().ColorFooterBg)
-	termbox.SetCell(barStart+barWidth+1, y, ']', r.theme().ColorFooter, r.theme().ColorFooterBg)
+	screen.SetCell(barStart, y, '[', r.theme().ColorFooter, r.theme().ColorFooterBg)
+	screen.SetCell(barStart+barWidth+1, y, ']', r.theme().ColorFooter, r.theme().ColorFooterBg)
 	
 	// Draw filled portion
 	for i := 0; i < barWidth; i++ {
@@ -1781,11 +2984,11 @@ func (r *Renderer) DrawProgressBar(progress *fileops.ProgressInfo) {
 		bg := r.theme().ColorFooterBg
 		
 		if i < filledWidth {
-			ch = '█'
+			ch = glyphs().ProgressFill
 			fg = r.theme().ColorHighlight
 		}
 		
-		termbox.SetCell(barStart+1+i, y, ch, fg, bg)
+		screen.SetCell(barStart+1+i, y, ch, fg, bg)
 	}
 }
 
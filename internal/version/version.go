@@ -0,0 +1,130 @@
+package version
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Version is the release version, set at build time with
+// -ldflags "-X github.com/alexcostache/Xplorer/internal/version.Version=v1.2.3"
+var Version = "dev"
+
+// ReleasesURL is the GitHub API endpoint for the latest release
+const ReleasesURL = "https://api.github.com/repos/alexcostache/Xplorer/releases/latest"
+
+// Release describes a published release
+type Release struct {
+	TagName string `json:"tag_name"`
+	Name    string `json:"name"`
+	Body    string `json:"body"`
+	URL     string `json:"html_url"`
+}
+
+// Current returns the version of this build, falling back to the module
+// version recorded by "go install" for untagged builds
+func Current() string {
+	if Version != "dev" {
+		return Version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return Version
+}
+
+// Info returns a one-line description of the build for --version
+func Info() string {
+	details := []string{}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		settings := make(map[string]string)
+		for _, s := range info.Settings {
+			settings[s.Key] = s.Value
+		}
+		if rev := settings["vcs.revision"]; rev != "" {
+			if len(rev) > 7 {
+				rev = rev[:7]
+			}
+			if settings["vcs.modified"] == "true" {
+				rev += "-dirty"
+			}
+			details = append(details, "commit "+rev)
+		}
+		if built := settings["vcs.time"]; built != "" {
+			details = append(details, "built "+built)
+		}
+	}
+	details = append(details, fmt.Sprintf("%s %s/%s", runtime.Version(), runtime.GOOS, runtime.GOARCH))
+	return fmt.Sprintf("xp %s (%s)", Current(), strings.Join(details, ", "))
+}
+
+// CheckLatest fetches the latest release from url (normally ReleasesURL)
+func CheckLatest(url string) (*Release, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("release check failed: %s", resp.Status)
+	}
+
+	var release Release
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return nil, err
+	}
+	return &release, nil
+}
+
+// IsNewer reports whether version latest is newer than current. Versions are
+// compared as vMAJOR.MINOR.PATCH; unparsable versions (such as "dev") are
+// considered older than any release.
+func IsNewer(latest, current string) bool {
+	l, okLatest := parseVersion(latest)
+	c, okCurrent := parseVersion(current)
+	if !okLatest {
+		return false
+	}
+	if !okCurrent {
+		return true
+	}
+	for i := range l {
+		if l[i] != c[i] {
+			return l[i] > c[i]
+		}
+	}
+	return false
+}
+
+// parseVersion parses "v1.2.3" (pre-release and build suffixes are ignored)
+func parseVersion(v string) ([3]int, bool) {
+	var parts [3]int
+	v = strings.TrimPrefix(v, "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	fields := strings.Split(v, ".")
+	if len(fields) != 3 {
+		return parts, false
+	}
+	for i, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil {
+			return parts, false
+		}
+		parts[i] = n
+	}
+	return parts, true
+}
@@ -0,0 +1,109 @@
+package viewstate
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/alexcostache/Xplorer/internal/paths"
+	"github.com/alexcostache/Xplorer/pkg/filesystem"
+)
+
+// maxEntries limits the number of directories remembered
+const maxEntries = 500
+
+// Entry is the view state saved for one directory
+type Entry struct {
+	SortMode     filesystem.SortMode `json:"sort_mode"`
+	SortReverse  bool                `json:"sort_reverse,omitempty"`
+	ShowHidden   bool                `json:"show_hidden,omitempty"`
+	Selected     string              `json:"selected,omitempty"`
+	Cursor       int                 `json:"cursor"`
+	ScrollOffset int                 `json:"scroll_offset"`
+	LastUsed     time.Time           `json:"last_used"`
+}
+
+// Manager remembers how each directory was last viewed: sorting, hidden
+// files, cursor and scroll position. It is the view memory of the panes'
+// navigators (filesystem.ViewMemory).
+type Manager struct {
+	entries map[string]Entry
+}
+
+// NewManager creates a view state manager and loads the saved states
+func NewManager() *Manager {
+	m := &Manager{
+		entries: map[string]Entry{},
+	}
+	m.Load()
+	return m
+}
+
+// Remember records the view state of dir and saves all states
+func (m *Manager) Remember(dir string, state filesystem.ViewState) {
+	m.entries[filepath.Clean(dir)] = Entry{
+		SortMode:     state.SortMode,
+		SortReverse:  state.SortReverse,
+		ShowHidden:   state.ShowHidden,
+		Selected:     state.Selected,
+		Cursor:       state.Cursor,
+		ScrollOffset: state.ScrollOffset,
+		LastUsed:     time.Now(),
+	}
+
+	// Forget the least recently used directories when there are too many
+	if len(m.entries) > maxEntries {
+		dirs := make([]string, 0, len(m.entries))
+		for d := range m.entries {
+			dirs = append(dirs, d)
+		}
+		sort.Slice(dirs, func(i, j int) bool {
+			return m.entries[dirs[i]].LastUsed.After(m.entries[dirs[j]].LastUsed)
+		})
+		for _, d := range dirs[maxEntries:] {
+			delete(m.entries, d)
+		}
+	}
+	m.Save()
+}
+
+// Recall returns the view state remembered for dir
+func (m *Manager) Recall(dir string) (filesystem.ViewState, bool) {
+	e, ok := m.entries[filepath.Clean(dir)]
+	if !ok {
+		return filesystem.ViewState{}, false
+	}
+	return filesystem.ViewState{
+		SortMode:     e.SortMode,
+		SortReverse:  e.SortReverse,
+		ShowHidden:   e.ShowHidden,
+		Selected:     e.Selected,
+		Cursor:       e.Cursor,
+		ScrollOffset: e.ScrollOffset,
+	}, true
+}
+
+// getStateFile returns the path to the view state file
+func (m *Manager) getStateFile() string {
+	return paths.File(".xplorer_state.json")
+}
+
+// Load loads view states from disk
+func (m *Manager) Load() {
+	data, err := os.ReadFile(m.getStateFile())
+	if err != nil {
+		return // File doesn't exist yet, that's ok
+	}
+	_ = json.Unmarshal(data, &m.entries)
+	if m.entries == nil {
+		m.entries = map[string]Entry{}
+	}
+}
+
+// Save saves view states to disk
+func (m *Manager) Save() {
+	data, _ := json.MarshalIndent(m.entries, "", "  ")
+	_ = os.WriteFile(m.getStateFile(), data, 0644)
+}
@@ -0,0 +1,154 @@
+package worker
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/alexcostache/Xplorer/internal/priority"
+)
+
+// Pool runs background filesystem scans (directory sizes, content search)
+// on a fixed number of goroutines. Jobs have a key, so the same scan is
+// never queued twice, and can be canceled by key.
+type Pool struct {
+	mu     sync.Mutex
+	ready  *sync.Cond
+	queue  []*job
+	active map[string]*job // Queued or running jobs by key
+	low    bool            // Jobs run with lowered CPU and IO priority
+}
+
+type job struct {
+	key    string
+	ctx    context.Context
+	cancel context.CancelFunc
+	fn     func(ctx context.Context)
+}
+
+// NewPool starts a pool with the given number of workers
+func NewPool(workers int) *Pool {
+	p := &Pool{active: make(map[string]*job)}
+	p.ready = sync.NewCond(&p.mu)
+	for i := 0; i < max(1, workers); i++ {
+		go p.work()
+	}
+	return p
+}
+
+var (
+	sharedOnce sync.Once
+	shared     *Pool
+)
+
+// Shared returns the pool used by all features, sized to the machine but
+// small enough not to saturate a disk with parallel tree walks
+func Shared() *Pool {
+	sharedOnce.Do(func() {
+		shared = NewPool(min(4, max(2, runtime.NumCPU())))
+	})
+	return shared
+}
+
+// Submit queues fn under key and reports whether it was queued; it is not
+// when a job with the same key is already queued or running. fn is called
+// exactly once, with a context that is done when the job is canceled (it
+// may already be done, so fn can still clean up).
+func (p *Pool) Submit(key string, fn func(ctx context.Context)) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.active[key]; ok {
+		return false
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	j := &job{key: key, ctx: ctx, cancel: cancel, fn: fn}
+	p.active[key] = j
+	p.queue = append(p.queue, j)
+	p.ready.Signal()
+	return true
+}
+
+// Cancel cancels the job with the given key. A new job with the same key
+// can be submitted right away.
+func (p *Pool) Cancel(key string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if j, ok := p.active[key]; ok {
+		j.cancel()
+		delete(p.active, key)
+	}
+}
+
+// CancelPrefix cancels every job whose key starts with prefix
+func (p *Pool) CancelPrefix(prefix string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for key, j := range p.active {
+		if strings.HasPrefix(key, prefix) {
+			j.cancel()
+			delete(p.active, key)
+		}
+	}
+}
+
+// Pending returns the number of jobs queued or running
+func (p *Pool) Pending() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.active)
+}
+
+// SetLowPriority sets whether jobs run with lowered CPU and IO priority,
+// from the next job each worker takes on
+func (p *Pool) SetLowPriority(low bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.low = low
+}
+
+// work runs queued jobs, oldest first. A worker whose thread can't get its
+// priority back is replaced by a new one after its job, and ends locked to
+// the thread so that the thread ends too.
+func (p *Pool) work() {
+	var restore func() bool // Set while the worker's thread has lowered priority
+	for {
+		p.mu.Lock()
+		for len(p.queue) == 0 {
+			p.ready.Wait()
+		}
+		j := p.queue[0]
+		p.queue = p.queue[1:]
+		low := p.low
+		p.mu.Unlock()
+
+		retire := false
+		if low && restore == nil {
+			runtime.LockOSThread()
+			var err error
+			if restore, err = priority.Lower(); err != nil {
+				restore = nil
+				runtime.UnlockOSThread()
+			}
+		} else if !low && restore != nil {
+			retire = !restore()
+			restore = nil
+			if !retire {
+				runtime.UnlockOSThread()
+			}
+		}
+
+		j.fn(j.ctx)
+
+		p.mu.Lock()
+		if p.active[j.key] == j {
+			delete(p.active, j.key)
+		}
+		p.mu.Unlock()
+		j.cancel()
+		if retire {
+			go p.work()
+			return
+		}
+	}
+}
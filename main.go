@@ -2,26 +2,116 @@ package main
 
 import (
 	"flag"
-	"log"
+	"fmt"
+	"os"
 
 	"github.com/alexcostache/Xplorer/internal/app"
+	"github.com/alexcostache/Xplorer/internal/listing"
+	"github.com/alexcostache/Xplorer/internal/paths"
+	"github.com/alexcostache/Xplorer/internal/version"
+)
+
+// Exit codes, documented in README.md
+const (
+	exitSelected = 0 // Quit normally, or a path was printed
+	exitCanceled = 1 // --print-path/--pick: quit without a result
+	exitError    = 2 // Xplorer failed to start or run
 )
 
 func main() {
 	// Parse command line flags
 	debugFlag := flag.Bool("debug", false, "Enable debug logging to /tmp/xp_debug.log")
+	profileFlag := flag.String("profile", os.Getenv(paths.ProfileEnvVar), "Use a named settings profile (also $"+paths.ProfileEnvVar+")")
+	portableFlag := flag.Bool("portable", false, "Keep settings, themes, bookmarks and history next to the executable")
+	portableDirFlag := flag.String("portable-dir", "", "Like --portable, but keep state in the given directory")
+	versionFlag := flag.Bool("version", false, "Print version and build information")
+	checkUpdatesFlag := flag.Bool("check-updates", false, "Check GitHub for a newer release and print its changelog")
+	printPathFlag := flag.Bool("print-path", false, "On quit with q, print the final directory to stdout (Esc cancels)")
+	pickFlag := flag.Bool("pick", false, "Enter on a file prints it (or the selection) to stdout and quits")
+	benchFlag := flag.String("bench", "", "Time listing, sorting, rendering and previewing in the given directory and print the results")
+	exportFlag := flag.String("export", "", "Print the listing of the current directory as text, csv or json and exit")
 	flag.Parse()
 	
+	if *versionFlag {
+		fmt.Println(version.Info())
+		return
+	}
+	if *checkUpdatesFlag {
+		os.Exit(checkUpdates())
+	}
+	
+	// Select where state lives before any settings are loaded
+	if *portableFlag || *portableDirFlag != "" {
+		if err := paths.SetPortable(*portableDirFlag); err != nil {
+			fail(err)
+		}
+	}
+	if err := paths.SetProfile(*profileFlag); err != nil {
+		fail(err)
+	}
+	if err := paths.EnsureDir(); err != nil {
+		fail(err)
+	}
+	
 	application := app.New()
 	
 	// Enable debug mode if flag is set
 	if *debugFlag {
 		application.EnableDebug()
 	}
+	if *benchFlag != "" {
+		if err := application.Benchmark(*benchFlag, os.Stdout); err != nil {
+			fail(err)
+		}
+		return
+	}
+	if *exportFlag != "" {
+		format, err := listing.ParseFormat(*exportFlag)
+		if err == nil {
+			err = application.ExportListing(os.Stdout, format)
+		}
+		if err != nil {
+			fail(err)
+		}
+		return
+	}
+	application.SetPrintPath(*printPathFlag)
+	application.SetPickMode(*pickFlag)
 	
 	if err := application.Run(); err != nil {
-		log.Fatal(err)
+		fail(err)
+	}
+	
+	// The terminal is restored by now, so output goes to the real stdout
+	output, accepted := application.Result()
+	for _, path := range output {
+		fmt.Println(path)
+	}
+	if (*printPathFlag || *pickFlag) && !accepted {
+		os.Exit(exitCanceled)
+	}
+}
+
+// checkUpdates prints whether a newer release exists and returns the exit code
+func checkUpdates() int {
+	release, err := version.CheckLatest(version.ReleasesURL)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Update check failed:", err)
+		return exitError
+	}
+	if !version.IsNewer(release.TagName, version.Current()) {
+		fmt.Printf("xp %s is up to date\n", version.Current())
+		return exitSelected
 	}
+	fmt.Printf("xp %s is available (you have %s): %s\n\n%s\n", release.TagName, version.Current(), release.URL, release.Body)
+	return exitSelected
+}
+
+// fail reports err and exits with exitError, so wrapper scripts using
+// --print-path don't mistake it for a cancel
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "xp:", err)
+	os.Exit(exitError)
 }
 
 // Made with Bob
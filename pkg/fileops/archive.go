@@ -0,0 +1,477 @@
+package fileops
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ArchiveFormat is a supported archive type
+type ArchiveFormat int
+
+const (
+	FormatNone ArchiveFormat = iota
+	FormatZip
+	FormatTarGz
+)
+
+// ArchiveFormatOf detects the archive format from a file name
+func ArchiveFormatOf(path string) ArchiveFormat {
+	name := strings.ToLower(filepath.Base(path))
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return FormatZip
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return FormatTarGz
+	}
+	return FormatNone
+}
+
+// IsArchive reports whether path is an archive that can be extracted
+func IsArchive(path string) bool {
+	return ArchiveFormatOf(path) != FormatNone
+}
+
+// ArchiveStem returns the archive name without its archive extension,
+// e.g. "photos" for photos.tar.gz
+func ArchiveStem(path string) string {
+	name := filepath.Base(path)
+	lower := strings.ToLower(name)
+	for _, ext := range []string{".tar.gz", ".tgz", ".zip"} {
+		if strings.HasSuffix(lower, ext) {
+			return name[:len(name)-len(ext)]
+		}
+	}
+	return name
+}
+
+// Compress packs files into a new zip or tar.gz archive, chosen by the
+// archive's extension. Entries are stored relative to each file's parent.
+func (m *Manager) Compress(files []string, archivePath string) error {
+	format := ArchiveFormatOf(archivePath)
+	if format == FormatNone {
+		return fmt.Errorf("unsupported archive type: %s (use .zip or .tar.gz)", filepath.Base(archivePath))
+	}
+	for _, path := range files {
+		if isWithin(archivePath, path) {
+			return fmt.Errorf("cannot compress %s into itself", filepath.Base(path))
+		}
+	}
+
+	var totalSize int64
+	for _, path := range files {
+		size, err := m.getPathSize(path)
+		if err != nil {
+			return fmt.Errorf("failed to calculate total size: %v", err)
+		}
+		totalSize += size
+	}
+
+	ctx, err := m.startProgress(context.Background(), OpCompress, len(files), totalSize)
+	if err != nil {
+		return err
+	}
+	defer m.finishProgress(ctx)
+
+	out, err := os.OpenFile(archivePath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+
+	if format == FormatZip {
+		err = m.writeZip(ctx, out, files)
+	} else {
+		err = m.writeTarGz(ctx, out, files)
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(archivePath)
+		return fmt.Errorf("failed to compress: %v", err)
+	}
+	m.emit(Event{Kind: EventCreated, Path: archivePath})
+	return nil
+}
+
+// walkForArchive visits every path under files with its archive entry name,
+// counting each top-level item as processed once it is done
+func (m *Manager) walkForArchive(files []string, visit func(path, name string, info fs.FileInfo) error) error {
+	for _, root := range files {
+		base := filepath.Dir(root)
+		err := filepath.Walk(root, func(path string, info fs.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(base, path)
+			if err != nil {
+				return err
+			}
+			return visit(path, filepath.ToSlash(rel), info)
+		})
+		if err != nil {
+			return err
+		}
+		m.progress.Mu.Lock()
+		m.progress.ProcessedFiles++
+		m.progress.Mu.Unlock()
+	}
+	return nil
+}
+
+// writeZip writes a zip archive of files. Symlinks are skipped.
+func (m *Manager) writeZip(ctx context.Context, out io.Writer, files []string) error {
+	zw := zip.NewWriter(out)
+	var processedBytes int64
+	err := m.walkForArchive(files, func(path, name string, info fs.FileInfo) error {
+		if !info.IsDir() && !info.Mode().IsRegular() {
+			return nil
+		}
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = name
+		if info.IsDir() {
+			header.Name += "/"
+			_, err := zw.CreateHeader(header)
+			return err
+		}
+		header.Method = zip.Deflate
+		w, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		return m.copyFromFile(ctx, w, path, &processedBytes)
+	})
+	if err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// writeTarGz writes a gzip-compressed tar archive of files
+func (m *Manager) writeTarGz(ctx context.Context, out io.Writer, files []string) error {
+	gw := gzip.NewWriter(out)
+	tw := tar.NewWriter(gw)
+	var processedBytes int64
+	err := m.walkForArchive(files, func(path, name string, info fs.FileInfo) error {
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			var err error
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		} else if !info.IsDir() && !info.Mode().IsRegular() {
+			return nil
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = name
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		return m.copyFromFile(ctx, tw, path, &processedBytes)
+	})
+	if err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+// copyFromFile streams a file into w with progress tracking
+func (m *Manager) copyFromFile(ctx context.Context, w io.Writer, path string, processedBytes *int64) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return m.copyWithProgress(ctx, w, f, filepath.Base(path), processedBytes)
+}
+
+// copyWithProgress copies src to dst, counting bytes into processedBytes
+func (m *Manager) copyWithProgress(ctx context.Context, dst io.Writer, src io.Reader, name string, processedBytes *int64) error {
+	m.updateProgress(ctx, *processedBytes, name)
+	buf := make([]byte, 32*1024) // 32KB buffer
+	for {
+		n, err := src.Read(buf)
+		if n > 0 {
+			if _, writeErr := dst.Write(buf[:n]); writeErr != nil {
+				return writeErr
+			}
+			*processedBytes += int64(n)
+			m.updateProgress(ctx, *processedBytes, name)
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Extract unpacks a zip or tar.gz archive into destDir, creating it when
+// needed. Existing files are never overwritten, and entries that would
+// land outside destDir are rejected.
+func (m *Manager) Extract(archivePath, destDir string) error {
+	// Whatever got written is reported, even when extraction fails midway
+	defer m.emitNew(destDir, dirSnapshot(destDir))
+
+	var err error
+	switch ArchiveFormatOf(archivePath) {
+	case FormatZip:
+		err = m.extractZip(archivePath, destDir)
+	case FormatTarGz:
+		err = m.extractTarGz(archivePath, destDir)
+	default:
+		return fmt.Errorf("unsupported archive type: %s", filepath.Base(archivePath))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to extract %s: %v", filepath.Base(archivePath), err)
+	}
+	return nil
+}
+
+// extractZip unpacks a zip archive. Conflicts are checked before anything
+// is written.
+func (m *Manager) extractZip(archivePath, destDir string) error {
+	zr, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	var total int64
+	for _, f := range zr.File {
+		target, err := entryPath(destDir, f.Name)
+		if err != nil {
+			return err
+		}
+		if _, err := os.Lstat(target); err == nil && !f.FileInfo().IsDir() {
+			return fmt.Errorf("%s already exists", target)
+		}
+		total += int64(f.UncompressedSize64)
+	}
+
+	ctx, err := m.startProgress(context.Background(), OpExtract, len(zr.File), total)
+	if err != nil {
+		return err
+	}
+	defer m.finishProgress(ctx)
+
+	var processedBytes int64
+	for _, f := range zr.File {
+		target, _ := entryPath(destDir, f.Name)
+		info := f.FileInfo()
+		switch {
+		case info.IsDir():
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case info.Mode().IsRegular():
+			rc, err := f.Open()
+			if err != nil {
+				return err
+			}
+			err = m.writeEntry(ctx, target, info.Mode().Perm(), rc, &processedBytes)
+			rc.Close()
+			if err != nil {
+				return err
+			}
+			os.Chtimes(target, f.Modified, f.Modified)
+		}
+		m.progress.Mu.Lock()
+		m.progress.ProcessedFiles++
+		m.progress.Mu.Unlock()
+	}
+	return nil
+}
+
+// extractTarGz unpacks a gzip-compressed tar archive. Progress follows the
+// compressed bytes read, since the unpacked size isn't known up front.
+func (m *Manager) extractTarGz(archivePath, destDir string) error {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	ctx, err := m.startProgress(context.Background(), OpExtract, 1, info.Size())
+	if err != nil {
+		return err
+	}
+	defer m.finishProgress(ctx)
+
+	counter := &countingReader{ctx: ctx, r: f, m: m, name: filepath.Base(archivePath)}
+	gr, err := gzip.NewReader(counter)
+	if err != nil {
+		return err
+	}
+	defer gr.Close()
+
+	tr := tar.NewReader(gr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		target, err := entryPath(destDir, header.Name)
+		if err != nil {
+			return err
+		}
+		// Links made by earlier entries must not lead out of destDir either
+		dir, root, err := realEntryDir(destDir, target)
+		if err != nil {
+			return err
+		}
+		if !isWithin(dir, root) {
+			return fmt.Errorf("unsafe path in archive: %s", header.Name)
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := m.writeEntry(ctx, target, os.FileMode(header.Mode).Perm(), tr, nil); err != nil {
+				return err
+			}
+			os.Chtimes(target, header.ModTime, header.ModTime)
+		case tar.TypeSymlink:
+			// Links out of destDir would let later entries write through them
+			if filepath.IsAbs(header.Linkname) || !isWithin(filepath.Join(dir, header.Linkname), root) {
+				return fmt.Errorf("unsafe link in archive: %s -> %s", header.Name, header.Linkname)
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			if err := os.Symlink(header.Linkname, target); err != nil {
+				return err
+			}
+		}
+	}
+	m.progress.Mu.Lock()
+	m.progress.ProcessedFiles++
+	m.progress.Mu.Unlock()
+	return nil
+}
+
+// writeEntry creates a new file from an archive entry without overwriting.
+// A nil processedBytes leaves progress to the caller.
+func (m *Manager) writeEntry(ctx context.Context, target string, perm os.FileMode, r io.Reader, processedBytes *int64) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm|0200)
+	if err != nil {
+		if os.IsExist(err) {
+			return fmt.Errorf("%s already exists", target)
+		}
+		return err
+	}
+	if processedBytes != nil {
+		err = m.copyWithProgress(ctx, out, r, filepath.Base(target), processedBytes)
+	} else {
+		_, err = io.Copy(out, r)
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// entryPath resolves an archive entry name inside destDir, rejecting
+// absolute names and ".." components that would escape it
+func entryPath(destDir, name string) (string, error) {
+	clean := filepath.FromSlash(name)
+	if filepath.IsAbs(clean) || filepath.VolumeName(clean) != "" {
+		return "", fmt.Errorf("unsafe path in archive: %s", name)
+	}
+	target := filepath.Join(destDir, clean)
+	if !isWithin(target, destDir) {
+		return "", fmt.Errorf("unsafe path in archive: %s", name)
+	}
+	return target, nil
+}
+
+// realEntryDir returns the folder target is written to with the links on
+// the way from destDir resolved, and destDir resolved the same way. Folders
+// that don't exist yet are kept as named, as they are created as plain
+// folders; a link that leads nowhere is an error.
+func realEntryDir(destDir, target string) (dir, root string, err error) {
+	root, err = filepath.EvalSymlinks(destDir)
+	if os.IsNotExist(err) {
+		return filepath.Dir(target), destDir, nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+	rel, err := filepath.Rel(destDir, filepath.Dir(target))
+	if err != nil {
+		return "", "", err
+	}
+	dir = root
+	parts := strings.Split(rel, string(filepath.Separator))
+	for i, part := range parts {
+		if part == "." {
+			continue
+		}
+		next := filepath.Join(dir, part)
+		info, err := os.Lstat(next)
+		if os.IsNotExist(err) {
+			return filepath.Join(append([]string{dir}, parts[i:]...)...), root, nil
+		}
+		if err != nil {
+			return "", "", err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			if next, err = filepath.EvalSymlinks(next); err != nil {
+				return "", "", err
+			}
+		}
+		dir = next
+	}
+	return dir, root, nil
+}
+
+// countingReader reports the bytes read from an archive as progress
+type countingReader struct {
+	ctx  context.Context
+	r    io.Reader
+	m    *Manager
+	name string
+	read int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.read += int64(n)
+	c.m.updateProgress(c.ctx, c.read, c.name)
+	return n, err
+}
@@ -0,0 +1,90 @@
+package fileops
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// BackupMode says where overwritten items are kept
+type BackupMode int
+
+const (
+	BackupOff    BackupMode = iota
+	BackupSuffix            // Next to the item, as name~
+	BackupDir               // In a backups directory, one folder per minute
+)
+
+// backupStamp names the folders of a backups directory
+const backupStamp = "20060102-1504"
+
+// SetBackup makes operations copy an item before they overwrite or replace
+// it. dir is the backups directory used by BackupDir.
+func (m *Manager) SetBackup(mode BackupMode, dir string) {
+	m.backupMode = mode
+	m.backupDir = dir
+}
+
+// Backup copies path before it gets overwritten, as set up by SetBackup.
+// With BackupSuffix an older name~ is replaced; with BackupDir the copy goes
+// to a folder named after the current minute, below the item's absolute
+// path. A missing path or BackupOff does nothing.
+func (m *Manager) Backup(path string) error {
+	if m.backupMode == BackupOff {
+		return nil
+	}
+	if _, err := os.Lstat(path); os.IsNotExist(err) {
+		return nil
+	}
+
+	var dest string
+	switch m.backupMode {
+	case BackupSuffix:
+		dest = path + "~"
+	case BackupDir:
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		rel := abs[len(filepath.VolumeName(abs)):]
+		dest = filepath.Join(m.backupDir, time.Now().Format(backupStamp), rel)
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return fmt.Errorf("failed to back up %s: %v", filepath.Base(path), err)
+		}
+	}
+	if err := os.RemoveAll(dest); err != nil {
+		return fmt.Errorf("failed to back up %s: %v", filepath.Base(path), err)
+	}
+	if err := m.copyFileOrDir(path, dest); err != nil {
+		os.RemoveAll(dest)
+		return fmt.Errorf("failed to back up %s: %v", filepath.Base(path), err)
+	}
+	m.emit(Event{Kind: EventCreated, Path: dest})
+	return nil
+}
+
+// PurgeBackups removes the folders of a backups directory made more than
+// age ago and returns how many it removed. A missing directory is empty.
+func PurgeBackups(dir string, age time.Duration) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	cutoff := time.Now().Add(-age)
+	purged := 0
+	for _, entry := range entries {
+		made, err := time.ParseInLocation(backupStamp, entry.Name(), time.Local)
+		if err != nil || !entry.IsDir() || !made.Before(cutoff) {
+			continue // Not a backup folder, or still kept
+		}
+		if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
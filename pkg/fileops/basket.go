@@ -0,0 +1,63 @@
+package fileops
+
+import "os"
+
+// The basket collects files from any number of directories so they can be
+// copied, moved or trashed together. Unlike the selection it is never
+// cleared by changing directory.
+
+// AddToBasket adds paths to the basket, keeping the order they were added
+func (m *Manager) AddToBasket(paths []string) {
+	for _, path := range paths {
+		if !m.inBasket[path] {
+			m.inBasket[path] = true
+			m.basket = append(m.basket, path)
+		}
+	}
+}
+
+// RemoveFromBasket takes paths out of the basket
+func (m *Manager) RemoveFromBasket(paths []string) {
+	for _, path := range paths {
+		delete(m.inBasket, path)
+	}
+	m.rebuildBasket()
+}
+
+// InBasket reports whether path is in the basket
+func (m *Manager) InBasket(path string) bool {
+	return m.inBasket[path]
+}
+
+// GetBasket returns the basket contents in the order they were added
+func (m *Manager) GetBasket() []string {
+	return append([]string(nil), m.basket...)
+}
+
+// ClearBasket empties the basket
+func (m *Manager) ClearBasket() {
+	m.basket = nil
+	m.inBasket = make(map[string]bool)
+}
+
+// PruneBasket drops basket entries that no longer exist, e.g. after they
+// were moved or deleted
+func (m *Manager) PruneBasket() {
+	for _, path := range m.basket {
+		if _, err := os.Lstat(path); os.IsNotExist(err) {
+			delete(m.inBasket, path)
+		}
+	}
+	m.rebuildBasket()
+}
+
+// rebuildBasket drops entries from the ordered list that left the set
+func (m *Manager) rebuildBasket() {
+	kept := m.basket[:0]
+	for _, path := range m.basket {
+		if m.inBasket[path] {
+			kept = append(kept, path)
+		}
+	}
+	m.basket = kept
+}
@@ -0,0 +1,90 @@
+package fileops
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// The clipboard outlives directory changes, and with SetClipboardFile the
+// session too, so files can be copied or cut now and pasted much later.
+
+// savedClipboard is the clipboard as stored on disk
+type savedClipboard struct {
+	Operation string   `json:"operation"` // "copy" or "cut"
+	Paths     []string `json:"paths"`
+}
+
+// SetClipboardFile loads the clipboard saved in path, dropping entries
+// that no longer exist, and saves every later change there
+func (m *Manager) SetClipboardFile(path string) {
+	m.clipboardFile = path
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return // Nothing saved yet
+	}
+	var saved savedClipboard
+	if json.Unmarshal(data, &saved) != nil {
+		return
+	}
+	op := OpCopy
+	if saved.Operation == "cut" {
+		op = OpCut
+	}
+	m.clipboard = make([]string, 0, len(saved.Paths))
+	for _, p := range saved.Paths {
+		if _, err := os.Lstat(p); err == nil {
+			m.clipboard = append(m.clipboard, p)
+		}
+	}
+	m.operation = op
+	if len(m.clipboard) == 0 {
+		m.operation = OpNone
+	}
+}
+
+// RemoveFromClipboard takes paths off the clipboard
+func (m *Manager) RemoveFromClipboard(paths []string) {
+	remove := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		remove[path] = true
+	}
+	kept := make([]string, 0, len(m.clipboard))
+	for _, path := range m.clipboard {
+		if !remove[path] {
+			kept = append(kept, path)
+		}
+	}
+	m.clipboard = kept
+	if len(kept) == 0 {
+		m.operation = OpNone
+	}
+	m.saveClipboard()
+}
+
+// ClearClipboard empties the clipboard
+func (m *Manager) ClearClipboard() {
+	m.clipboard = make([]string, 0)
+	m.operation = OpNone
+	m.saveClipboard()
+}
+
+// saveClipboard writes the clipboard to the file set by SetClipboardFile,
+// removing the file once the clipboard is empty. Saving is best effort.
+func (m *Manager) saveClipboard() {
+	if m.clipboardFile == "" {
+		return
+	}
+	if len(m.clipboard) == 0 {
+		os.Remove(m.clipboardFile)
+		return
+	}
+	saved := savedClipboard{Operation: "copy", Paths: m.clipboard}
+	if m.operation == OpCut {
+		saved.Operation = "cut"
+	}
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return
+	}
+	_ = os.WriteFile(m.clipboardFile, data, 0600)
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package fileops
+
+import "path/filepath"
+
+// sameDevice reports whether two paths are on the same volume, so a move
+// between them is a rename
+func sameDevice(a, b string) bool {
+	return filepath.VolumeName(a) == filepath.VolumeName(b)
+}
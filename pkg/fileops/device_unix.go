@@ -0,0 +1,35 @@
+//go:build unix
+
+package fileops
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// sameDevice reports whether two paths are on the same filesystem, so a move
+// between them is a rename. A missing path is looked up through its parent.
+func sameDevice(a, b string) bool {
+	devA, okA := deviceOf(a)
+	devB, okB := deviceOf(b)
+	return okA && okB && devA == devB
+}
+
+// deviceOf returns the device of path or of its nearest existing ancestor
+func deviceOf(path string) (uint64, bool) {
+	for {
+		info, err := os.Lstat(path)
+		if err == nil {
+			if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+				return uint64(stat.Dev), true
+			}
+			return 0, false
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return 0, false
+		}
+		path = parent
+	}
+}
@@ -0,0 +1,9 @@
+// Package fileops performs Xplorer's file operations: selection, clipboard,
+// copy, move, trash, delete and archives (zip and tar.gz). Every operation
+// is first planned (sizes, destinations, name conflicts) and then executed
+// from the plan, publishing progress through GetProgress so a UI can poll
+// it from another goroutine.
+//
+// The exported API of this package is kept backward compatible; new
+// features are added as new methods.
+package fileops
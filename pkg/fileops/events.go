@@ -0,0 +1,176 @@
+package fileops
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// EventKind says what an operation did to a path
+type EventKind int
+
+const (
+	EventCreated EventKind = iota
+	EventMoved
+	EventDeleted
+)
+
+// Event reports one change made by the manager, so caches and views can
+// update just what it touched instead of starting over
+type Event struct {
+	Kind EventKind
+	Path string // The created, deleted or moved path
+	Dest string // Where a moved path went
+}
+
+// Dirs returns the directories whose listings the event changed
+func (e Event) Dirs() []string {
+	dirs := []string{filepath.Dir(e.Path)}
+	if e.Kind == EventMoved && filepath.Dir(e.Dest) != dirs[0] {
+		dirs = append(dirs, filepath.Dir(e.Dest))
+	}
+	return dirs
+}
+
+// Subscribe calls fn with every event from now on. fn runs on the goroutine
+// doing the operation, so it must be quick and do its own locking.
+// Subscribe before starting operations; it isn't safe to call during one.
+func (m *Manager) Subscribe(fn func(Event)) {
+	m.subscribers = append(m.subscribers, fn)
+}
+
+// emit hands e to every subscriber
+func (m *Manager) emit(e Event) {
+	for _, fn := range m.subscribers {
+		fn(e)
+	}
+}
+
+// emitNew emits a created event for each entry of dir missing from before,
+// or for dir itself when it didn't exist then but does now
+func (m *Manager) emitNew(dir string, before map[string]bool) {
+	if before == nil {
+		if _, err := os.Stat(dir); err == nil {
+			m.emit(Event{Kind: EventCreated, Path: dir})
+		}
+		return
+	}
+	entries, _ := os.ReadDir(dir)
+	for _, entry := range entries {
+		if !before[entry.Name()] {
+			m.emit(Event{Kind: EventCreated, Path: filepath.Join(dir, entry.Name())})
+		}
+	}
+}
+
+// dirSnapshot returns the entry names of dir as a set, or nil when dir
+// can't be read
+func dirSnapshot(dir string) map[string]bool {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	set := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		set[entry.Name()] = true
+	}
+	return set
+}
+
+// invalidateSizes drops the cached sizes an event made stale: those of the
+// paths and their ancestors, and of everything below a moved or deleted
+// path. Measurements of them under way are canceled.
+func (m *Manager) invalidateSizes(e Event) {
+	paths := []string{e.Path}
+	if e.Dest != "" {
+		paths = append(paths, e.Dest)
+	}
+
+	m.sizeMu.Lock()
+	defer m.sizeMu.Unlock()
+	for _, path := range paths {
+		for dir := path; ; dir = filepath.Dir(dir) {
+			m.forgetSize(dir)
+			if filepath.Dir(dir) == dir {
+				break
+			}
+		}
+	}
+	if e.Kind != EventCreated {
+		for path := range m.sizeCache {
+			if isBelow(path, e.Path) {
+				m.forgetSize(path)
+			}
+		}
+		for path := range m.sizePending {
+			if isBelow(path, e.Path) {
+				m.forgetSize(path)
+			}
+		}
+	}
+}
+
+// forgetSize drops the cached size of path and cancels its measurement.
+// The caller holds sizeMu.
+func (m *Manager) forgetSize(path string) {
+	delete(m.sizeCache, path)
+	if m.sizePending[path] {
+		delete(m.sizePending, path)
+		m.pool.Cancel(sizeJobPrefix + path)
+	}
+}
+
+// TrackEvent keeps the selection and the basket pointing at moved items
+// and drops deleted ones, along with anything below them. Call it from the
+// goroutine that owns the selection, not from a subscriber.
+func (m *Manager) TrackEvent(e Event) {
+	switch e.Kind {
+	case EventMoved:
+		var selected []string
+		for path := range m.selectedFiles {
+			selected = append(selected, path)
+		}
+		for _, path := range selected {
+			if moved, ok := movedPath(path, e.Path, e.Dest); ok {
+				delete(m.selectedFiles, path)
+				m.selectedFiles[moved] = true
+			}
+		}
+		for i, path := range m.basket {
+			if moved, ok := movedPath(path, e.Path, e.Dest); ok {
+				delete(m.inBasket, path)
+				m.inBasket[moved] = true
+				m.basket[i] = moved
+			}
+		}
+	case EventDeleted:
+		for path := range m.selectedFiles {
+			if path == e.Path || isBelow(path, e.Path) {
+				delete(m.selectedFiles, path)
+			}
+		}
+		for _, path := range m.basket {
+			if path == e.Path || isBelow(path, e.Path) {
+				delete(m.inBasket, path)
+			}
+		}
+		m.rebuildBasket()
+	}
+}
+
+// movedPath returns where path ends up when src moves to dest, and whether
+// the move concerns it at all
+func movedPath(path, src, dest string) (string, bool) {
+	if path == src {
+		return dest, true
+	}
+	if isBelow(path, src) {
+		return dest + path[len(src):], true
+	}
+	return "", false
+}
+
+// isBelow reports whether path lies strictly inside dir
+func isBelow(path, dir string) bool {
+	return strings.HasPrefix(path, strings.TrimSuffix(dir, string(filepath.Separator))+string(filepath.Separator))
+}
@@ -0,0 +1,57 @@
+package fileops_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/alexcostache/Xplorer/pkg/fileops"
+)
+
+func ExampleManager_PlanTransfer() {
+	src, _ := os.MkdirTemp("", "xplorer-example")
+	dest, _ := os.MkdirTemp("", "xplorer-example")
+	defer os.RemoveAll(src)
+	defer os.RemoveAll(dest)
+	os.WriteFile(filepath.Join(src, "report.txt"), []byte("quarterly numbers"), 0644)
+	os.WriteFile(filepath.Join(dest, "report.txt"), []byte("older numbers"), 0644)
+
+	m := fileops.NewManager()
+	plan, err := m.PlanTransfer([]string{filepath.Join(src, "report.txt")}, fileops.OpCopy, dest)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	// The plan can be shown for confirmation before anything is written;
+	// meanwhile GetProgress reports on the running operation
+	fmt.Println(plan.Conflicts(), "conflict")
+	if err := m.Execute(plan); err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(filepath.Base(plan.Actions[0].Dest))
+	// Output:
+	// 1 conflict
+	// report_copy1.txt
+}
+
+func ExampleManager_Compress() {
+	dir, _ := os.MkdirTemp("", "xplorer-example")
+	defer os.RemoveAll(dir)
+	os.WriteFile(filepath.Join(dir, "readme.md"), []byte("# Hello"), 0644)
+
+	m := fileops.NewManager()
+	archive := filepath.Join(dir, "bundle.tar.gz")
+	if err := m.Compress([]string{filepath.Join(dir, "readme.md")}, archive); err != nil {
+		fmt.Println(err)
+		return
+	}
+	out := filepath.Join(dir, fileops.ArchiveStem(archive))
+	if err := m.Extract(archive, out); err != nil {
+		fmt.Println(err)
+		return
+	}
+	data, _ := os.ReadFile(filepath.Join(out, "readme.md"))
+	fmt.Println(string(data))
+	// Output: # Hello
+}
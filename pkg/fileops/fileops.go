@@ -0,0 +1,838 @@
+package fileops
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alexcostache/Xplorer/internal/priority"
+	"github.com/alexcostache/Xplorer/internal/worker"
+)
+
+// minRateSample is the smallest copy whose speed is used for estimates
+const minRateSample = 1024 * 1024
+
+// Operation represents a file operation type
+type Operation int
+
+const (
+	OpNone Operation = iota
+	OpCopy
+	OpCut
+	OpDelete
+	OpTrash
+	OpCompress
+	OpExtract
+)
+
+// ProgressInfo contains information about ongoing file operation
+type ProgressInfo struct {
+	Operation     Operation
+	TotalBytes    int64
+	ProcessedBytes int64
+	TotalFiles    int
+	ProcessedFiles int
+	CurrentFile   string
+	StartTime     time.Time
+	Active        bool
+	Cancelable    bool // Cancel can stop the operation
+	Canceled      bool // The operation was stopped by Cancel
+	LowPriority   bool // The operation yields CPU and disk to other programs
+	Mu            sync.RWMutex
+}
+
+// Manager handles file operations
+type Manager struct {
+	clipboard      []string  // Files in clipboard
+	operation      Operation // Current operation (copy or cut)
+	clipboardFile  string    // Where the clipboard is kept between sessions
+	selectedFiles  map[string]bool // Selected files, keyed by full path
+	basket         []string        // Files gathered across directories, in order added
+	inBasket       map[string]bool
+	pinnedDir      string          // Destination for quick copy/move
+	binMu          sync.Mutex
+	trashDir       string          // Where MoveToTrash puts deleted files; guarded by binMu
+	stagingDir     string          // Where MoveToStaging puts deleted files; guarded by binMu
+	progress       *ProgressInfo
+	cancel         context.CancelFunc // Stops the running plan; guarded by progress.Mu
+	lowPriority    bool               // Operations start with lowered priority; guarded by progress.Mu
+	sizeMu         sync.Mutex
+	sizeCache      map[string]int64 // Recursive directory sizes
+	sizePending    map[string]bool  // Directories being measured
+	copyRate       float64          // Bytes per second of the last measured copy
+	verify         bool             // Compare copies with their source by hash
+	backupMode     BackupMode       // Whether and where overwritten items are kept
+	backupDir      string           // Where BackupDir keeps them
+	fileMode       os.FileMode      // Permissions of new files, before the umask
+	dirMode        os.FileMode      // Permissions of new folders, before the umask
+	inheritGroup   bool             // New items get the group of their folder
+	pool           *worker.Pool     // Runs directory size measurements
+	subscribers    []func(Event)    // Told about every change an operation makes
+}
+
+// ErrBusy is returned when an operation is started while another one runs
+var ErrBusy = errors.New("another file operation is still running")
+
+// opThread is the OS thread an operation runs on. It travels in the
+// operation's context, so only the operation's own goroutine changes it.
+type opThread struct {
+	restore func() bool // Set while the thread has lowered priority
+	keep    bool        // The thread couldn't get its priority back, so it ends with its goroutine
+}
+
+// threadKey keys the opThread in an operation's context
+type threadKey struct{}
+
+// threadOf returns the thread of the operation ctx belongs to, if any
+func threadOf(ctx context.Context) *opThread {
+	thread, _ := ctx.Value(threadKey{}).(*opThread)
+	return thread
+}
+
+// sizeJobPrefix keys directory size measurements in the worker pool
+const sizeJobPrefix = "size:"
+
+// NewManager creates a new file operations manager
+func NewManager() *Manager {
+	m := &Manager{
+		clipboard:     make([]string, 0),
+		operation:     OpNone,
+		selectedFiles: make(map[string]bool),
+		inBasket:      make(map[string]bool),
+		sizeCache:     make(map[string]int64),
+		sizePending:   make(map[string]bool),
+		trashDir:      DefaultTrashDir(),
+		fileMode:      0644,
+		dirMode:       0755,
+		pool:          worker.Shared(),
+		progress: &ProgressInfo{
+			Active: false,
+		},
+	}
+	m.Subscribe(m.invalidateSizes)
+	return m
+}
+
+// GetProgress returns the current progress information
+func (m *Manager) GetProgress() *ProgressInfo {
+	return m.progress
+}
+
+// IsOperationActive returns whether a file operation is in progress
+func (m *Manager) IsOperationActive() bool {
+	m.progress.Mu.RLock()
+	defer m.progress.Mu.RUnlock()
+	return m.progress.Active
+}
+
+// GetProgressPercent returns the progress percentage (0-100)
+func (p *ProgressInfo) GetProgressPercent() int {
+	p.Mu.RLock()
+	defer p.Mu.RUnlock()
+	if p.TotalBytes == 0 {
+		return 0
+	}
+	return int((p.ProcessedBytes * 100) / p.TotalBytes)
+}
+
+// GetSpeed returns the current operation speed in bytes per second
+func (p *ProgressInfo) GetSpeed() float64 {
+	p.Mu.RLock()
+	defer p.Mu.RUnlock()
+	elapsed := time.Since(p.StartTime).Seconds()
+	if elapsed == 0 {
+		return 0
+	}
+	return float64(p.ProcessedBytes) / elapsed
+}
+
+// GetETA returns estimated time remaining in seconds
+func (p *ProgressInfo) GetETA() float64 {
+	p.Mu.RLock()
+	defer p.Mu.RUnlock()
+	if p.ProcessedBytes == 0 {
+		return 0
+	}
+	speed := p.GetSpeed()
+	if speed == 0 {
+		return 0
+	}
+	remaining := p.TotalBytes - p.ProcessedBytes
+	return float64(remaining) / speed
+}
+
+// startProgress initializes progress tracking for an operation. The
+// operation runs with the returned context, which it hands to
+// finishProgress when done. It returns ErrBusy while another operation is
+// running.
+func (m *Manager) startProgress(ctx context.Context, op Operation, totalFiles int, totalBytes int64) (context.Context, error) {
+	m.progress.Mu.Lock()
+	if m.progress.Active {
+		m.progress.Mu.Unlock()
+		return nil, ErrBusy
+	}
+	// Priority is set per thread, so the operation keeps to this one
+	runtime.LockOSThread()
+	thread := &opThread{}
+	m.progress.Operation = op
+	m.progress.TotalFiles = totalFiles
+	m.progress.TotalBytes = totalBytes
+	m.progress.ProcessedFiles = 0
+	m.progress.ProcessedBytes = 0
+	m.progress.CurrentFile = ""
+	m.progress.StartTime = time.Now()
+	m.progress.Active = true
+	m.progress.Cancelable = false
+	m.progress.Canceled = false
+	m.progress.LowPriority = m.lowPriority
+	low := m.lowPriority
+	m.progress.Mu.Unlock()
+	thread.apply(low)
+	return context.WithValue(ctx, threadKey{}, thread), nil
+}
+
+// Cancel stops the running copy, move or delete. Items already done stay
+// done and the item in progress is left as it was before. It reports
+// whether there was an operation to cancel.
+func (m *Manager) Cancel() bool {
+	m.progress.Mu.RLock()
+	cancel := m.cancel
+	m.progress.Mu.RUnlock()
+	if cancel == nil {
+		return false
+	}
+	cancel()
+	return true
+}
+
+// SetLowPriority sets whether operations start with lowered CPU and IO
+// priority (nice and ionice on Linux, background mode on macOS and
+// Windows), so that big copies don't slow down other programs
+func (m *Manager) SetLowPriority(low bool) {
+	m.progress.Mu.Lock()
+	defer m.progress.Mu.Unlock()
+	m.lowPriority = low
+}
+
+// ToggleLowPriority switches the priority of the running operation from
+// its next block of data on. It reports false when no operation is running.
+func (m *Manager) ToggleLowPriority() bool {
+	m.progress.Mu.Lock()
+	defer m.progress.Mu.Unlock()
+	if !m.progress.Active {
+		return false
+	}
+	m.progress.LowPriority = !m.progress.LowPriority
+	return true
+}
+
+// apply lowers or restores the thread's priority. It is only called from
+// the operation's goroutine, which startProgress locked to the thread.
+func (t *opThread) apply(low bool) {
+	if low && t.restore == nil {
+		if restore, err := priority.Lower(); err == nil {
+			t.restore = restore
+		}
+	} else if !low && t.restore != nil {
+		t.keep = !t.restore() || t.keep
+		t.restore = nil
+	}
+}
+
+// updateProgress updates the current progress. Calls from the running
+// operation, whose ctx holds its thread, also apply a priority change asked
+// for by ToggleLowPriority.
+func (m *Manager) updateProgress(ctx context.Context, processedBytes int64, currentFile string) {
+	m.progress.Mu.Lock()
+	m.progress.ProcessedBytes = processedBytes
+	m.progress.CurrentFile = currentFile
+	low := m.progress.LowPriority
+	m.progress.Mu.Unlock()
+	if thread := threadOf(ctx); thread != nil {
+		thread.apply(low)
+	}
+}
+
+// finishProgress marks the operation started with ctx as complete and
+// releases its thread, unless the thread's priority couldn't be restored
+func (m *Manager) finishProgress(ctx context.Context) {
+	thread := threadOf(ctx)
+	thread.apply(false)
+	if !thread.keep {
+		runtime.UnlockOSThread()
+	}
+
+	m.progress.Mu.Lock()
+	defer m.progress.Mu.Unlock()
+	m.progress.Active = false
+	
+	// Remember the throughput of sizeable copies for plan estimates
+	m.sizeMu.Lock()
+	elapsed := time.Since(m.progress.StartTime).Seconds()
+	if m.progress.Operation == OpCopy && m.progress.ProcessedBytes >= minRateSample && elapsed > 0 {
+		m.copyRate = float64(m.progress.ProcessedBytes) / elapsed
+	}
+	m.sizeMu.Unlock()
+}
+
+// getPathSize returns the total size of a file or directory
+func (m *Manager) getPathSize(path string) (int64, error) {
+	return pathSize(context.Background(), path)
+}
+
+// pathSize returns the total size of a file or directory, stopping early
+// with ctx's error when it is canceled
+func pathSize(ctx context.Context, path string) (int64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	
+	if !info.IsDir() {
+		return info.Size(), nil
+	}
+	
+	var total int64
+	err = filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !info.IsDir() {
+			total += info.Size()
+		}
+		return nil
+	})
+	
+	return total, err
+}
+
+// AggregateSize returns the total size of the given paths. Directory sizes
+// are measured lazily in the background: pending is true while any are still
+// unknown, and onReady is called each time one becomes available.
+func (m *Manager) AggregateSize(paths []string, onReady func()) (total int64, pending bool) {
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if !info.IsDir() {
+			total += info.Size()
+			continue
+		}
+		
+		m.sizeMu.Lock()
+		size, ok := m.sizeCache[path]
+		if !ok && !m.sizePending[path] {
+			m.sizePending[path] = true
+			m.pool.Submit(sizeJobPrefix+path, func(ctx context.Context) {
+				m.measureDir(ctx, path, onReady)
+			})
+		}
+		m.sizeMu.Unlock()
+		
+		if ok {
+			total += size
+		} else {
+			pending = true
+		}
+	}
+	return total, pending
+}
+
+// measureDir computes a directory size and stores it in the size cache.
+// A canceled measurement leaves the bookkeeping to whoever canceled it.
+func (m *Manager) measureDir(ctx context.Context, path string, onReady func()) {
+	size, _ := pathSize(ctx, path) // Keep the partial size on errors
+	if ctx.Err() != nil {
+		return
+	}
+	
+	m.sizeMu.Lock()
+	m.sizeCache[path] = size
+	delete(m.sizePending, path)
+	m.sizeMu.Unlock()
+	
+	if onReady != nil {
+		onReady()
+	}
+}
+
+// ToggleSelection toggles selection for a file
+func (m *Manager) ToggleSelection(path string) {
+	if m.selectedFiles[path] {
+		delete(m.selectedFiles, path)
+	} else {
+		m.selectedFiles[path] = true
+	}
+}
+
+// Select adds paths to the selection
+func (m *Manager) Select(paths []string) {
+	for _, path := range paths {
+		m.selectedFiles[path] = true
+	}
+}
+
+// Deselect removes paths from the selection
+func (m *Manager) Deselect(paths []string) {
+	for _, path := range paths {
+		delete(m.selectedFiles, path)
+	}
+}
+
+// InvertSelection selects those of paths that aren't selected and deselects
+// the others. Selected files outside paths stay selected.
+func (m *Manager) InvertSelection(paths []string) {
+	for _, path := range paths {
+		m.ToggleSelection(path)
+	}
+}
+
+// SelectMatching selects those of paths whose name matches pattern: a glob
+// such as "*.log", or a regular expression between slashes such as
+// "/^img_\d+/". It returns how many paths matched.
+func (m *Manager) SelectMatching(paths []string, pattern string) (int, error) {
+	match, err := namePattern(pattern)
+	if err != nil {
+		return 0, err
+	}
+	var matched []string
+	for _, path := range paths {
+		if match(filepath.Base(path)) {
+			matched = append(matched, path)
+		}
+	}
+	m.Select(matched)
+	return len(matched), nil
+}
+
+// namePattern compiles a SelectMatching pattern into a name matcher
+func namePattern(pattern string) (func(name string) bool, error) {
+	if len(pattern) >= 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		re, err := regexp.Compile(pattern[1 : len(pattern)-1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression: %v", err)
+		}
+		return re.MatchString, nil
+	}
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %v", pattern, err)
+	}
+	return func(name string) bool {
+		matched, _ := filepath.Match(pattern, name)
+		return matched
+	}, nil
+}
+
+// IsSelected checks if a file is selected
+func (m *Manager) IsSelected(path string) bool {
+	return m.selectedFiles[path]
+}
+
+// ClearSelection clears all selections
+func (m *Manager) ClearSelection() {
+	m.selectedFiles = make(map[string]bool)
+}
+
+// PruneSelection drops selected files that no longer exist, e.g. after an
+// external change, so the rest of the selection survives a refresh
+func (m *Manager) PruneSelection() {
+	for path := range m.selectedFiles {
+		if _, err := os.Lstat(path); os.IsNotExist(err) {
+			delete(m.selectedFiles, path)
+		}
+	}
+}
+
+// GetSelectedFiles returns list of selected files
+func (m *Manager) GetSelectedFiles() []string {
+	files := make([]string, 0, len(m.selectedFiles))
+	for path := range m.selectedFiles {
+		files = append(files, path)
+	}
+	return files
+}
+
+// GetSelectedCount returns number of selected files
+func (m *Manager) GetSelectedCount() int {
+	return len(m.selectedFiles)
+}
+
+// Copy copies selected files to clipboard
+func (m *Manager) Copy(files []string) {
+	m.clipboard = make([]string, len(files))
+	copy(m.clipboard, files)
+	m.operation = OpCopy
+	m.saveClipboard()
+}
+
+// Cut cuts selected files to clipboard
+func (m *Manager) Cut(files []string) {
+	m.clipboard = make([]string, len(files))
+	copy(m.clipboard, files)
+	m.operation = OpCut
+	m.saveClipboard()
+}
+
+// Paste pastes files from clipboard to destination
+func (m *Manager) Paste(destDir string) error {
+	plan, err := m.PlanPaste(destDir)
+	if err != nil {
+		return err
+	}
+	return m.ExecutePaste(plan)
+}
+
+// ExecutePaste runs a plan made by PlanPaste, emptying the clipboard after
+// a successful cut
+func (m *Manager) ExecutePaste(plan *Plan) error {
+	if err := m.Execute(plan); err != nil {
+		return err
+	}
+
+	// Clear clipboard after cut operation
+	if plan.Op == OpCut {
+		m.ClearClipboard()
+	}
+
+	return nil
+}
+
+// CopyTo copies files into destDir without touching the clipboard
+func (m *Manager) CopyTo(files []string, destDir string) error {
+	return m.transfer(files, OpCopy, destDir)
+}
+
+// MoveTo moves files into destDir without touching the clipboard
+func (m *Manager) MoveTo(files []string, destDir string) error {
+	return m.transfer(files, OpCut, destDir)
+}
+
+// transfer copies or moves files into destDir with progress tracking,
+// resolving name conflicts by adding a _copyN suffix
+func (m *Manager) transfer(files []string, op Operation, destDir string) error {
+	plan, err := m.PlanTransfer(files, op, destDir)
+	if err != nil {
+		return err
+	}
+	return m.Execute(plan)
+}
+
+// Delete deletes specified files
+func (m *Manager) Delete(files []string) error {
+	plan, err := m.PlanDelete(files, OpDelete)
+	if err != nil {
+		return err
+	}
+	return m.Execute(plan)
+}
+
+// Rename renames a file
+func (m *Manager) Rename(oldPath, newName string) error {
+	dir := filepath.Dir(oldPath)
+	newPath := filepath.Join(dir, newName)
+	
+	if oldPath == newPath {
+		return nil // No change
+	}
+	
+	if _, err := os.Stat(newPath); err == nil {
+		return fmt.Errorf("file already exists: %s", newName)
+	}
+	
+	if err := os.Rename(oldPath, newPath); err != nil {
+		return err
+	}
+	m.emit(Event{Kind: EventMoved, Path: oldPath, Dest: newPath})
+	return nil
+}
+
+// CreateFile creates a new empty file
+func (m *Manager) CreateFile(dir, filename string) error {
+	if filename == "" {
+		return fmt.Errorf("filename cannot be empty")
+	}
+	
+	filePath := filepath.Join(dir, filename)
+	
+	if _, err := os.Stat(filePath); err == nil {
+		return fmt.Errorf("file already exists: %s", filename)
+	}
+	
+	file, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, m.fileMode&os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %v", err)
+	}
+	file.Close()
+	m.emit(Event{Kind: EventCreated, Path: filePath})
+	
+	if err := m.applyCreateMode(filePath, m.fileMode, false); err != nil {
+		return fmt.Errorf("failed to set permissions of %s: %v", filename, err)
+	}
+	return nil
+}
+
+// CreateFileFrom creates a new file holding a copy of the contents of
+// template
+func (m *Manager) CreateFileFrom(dir, filename, template string) error {
+	data, err := os.ReadFile(template)
+	if err != nil {
+		return fmt.Errorf("failed to read template: %v", err)
+	}
+	if err := m.CreateFile(dir, filename); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, filename), data, 0); err != nil {
+		return fmt.Errorf("failed to write %s: %v", filename, err)
+	}
+	return nil
+}
+
+// CreateFolder creates a new directory
+func (m *Manager) CreateFolder(dir, foldername string) error {
+	if foldername == "" {
+		return fmt.Errorf("folder name cannot be empty")
+	}
+	
+	folderPath := filepath.Join(dir, foldername)
+	
+	if _, err := os.Stat(folderPath); err == nil {
+		return fmt.Errorf("folder already exists: %s", foldername)
+	}
+	
+	err := os.Mkdir(folderPath, m.dirMode&os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("failed to create folder: %v", err)
+	}
+	m.emit(Event{Kind: EventCreated, Path: folderPath})
+	
+	if err := m.applyCreateMode(folderPath, m.dirMode, true); err != nil {
+		return fmt.Errorf("failed to set permissions of %s: %v", foldername, err)
+	}
+	return nil
+}
+
+// SetPinnedDir pins a destination directory for quick copy/move
+func (m *Manager) SetPinnedDir(dir string) {
+	m.pinnedDir = dir
+}
+
+// GetPinnedDir returns the pinned destination directory, or "" if none
+func (m *Manager) GetPinnedDir() string {
+	return m.pinnedDir
+}
+
+// GetClipboardInfo returns clipboard status
+func (m *Manager) GetClipboardInfo() (count int, op Operation) {
+	return len(m.clipboard), m.operation
+}
+
+// GetClipboard returns the paths on the clipboard
+func (m *Manager) GetClipboard() []string {
+	return append([]string(nil), m.clipboard...)
+}
+
+// HasClipboard checks if clipboard has files
+func (m *Manager) HasClipboard() bool {
+	return len(m.clipboard) > 0
+}
+
+// copyFileOrDir copies a file or directory recursively
+func (m *Manager) copyFileOrDir(src, dst string) error {
+	srcInfo, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+
+	if srcInfo.IsDir() {
+		return m.copyDir(src, dst)
+	}
+	return m.copyFile(src, dst)
+}
+
+// copyFileOrDirWithProgress copies a file or directory recursively with
+// progress tracking, until ctx is canceled
+func (m *Manager) copyFileOrDirWithProgress(ctx context.Context, src, dst string, processedBytes *int64) error {
+	srcInfo, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+
+	if srcInfo.IsDir() {
+		return m.copyDirWithProgress(ctx, src, dst, processedBytes)
+	}
+	return m.copyFileWithProgress(ctx, src, dst, processedBytes)
+}
+
+// copyFile copies a single file
+func (m *Manager) copyFile(src, dst string) error {
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer srcFile.Close()
+
+	dstFile, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer dstFile.Close()
+
+	if _, err := io.Copy(dstFile, srcFile); err != nil {
+		return err
+	}
+
+	// Copy permissions
+	srcInfo, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	return os.Chmod(dst, srcInfo.Mode())
+}
+
+// copyFileWithProgress copies a single file with progress tracking
+func (m *Manager) copyFileWithProgress(ctx context.Context, src, dst string, processedBytes *int64) error {
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer srcFile.Close()
+
+	dstFile, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer dstFile.Close()
+
+	// Update progress with current file
+	m.updateProgress(ctx, *processedBytes, filepath.Base(src))
+
+	// Copy with progress tracking
+	buf := make([]byte, 32*1024) // 32KB buffer
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		n, err := srcFile.Read(buf)
+		if n > 0 {
+			if _, writeErr := dstFile.Write(buf[:n]); writeErr != nil {
+				return writeErr
+			}
+			*processedBytes += int64(n)
+			m.updateProgress(ctx, *processedBytes, filepath.Base(src))
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	// Copy permissions
+	srcInfo, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	return os.Chmod(dst, srcInfo.Mode())
+}
+
+// copyDir copies a directory recursively
+func (m *Manager) copyDir(src, dst string) error {
+	srcInfo, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dst, srcInfo.Mode()); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		srcPath := filepath.Join(src, entry.Name())
+		dstPath := filepath.Join(dst, entry.Name())
+
+		if entry.IsDir() {
+			if err := m.copyDir(srcPath, dstPath); err != nil {
+				return err
+			}
+		} else {
+			if err := m.copyFile(srcPath, dstPath); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// copyDirWithProgress copies a directory recursively with progress tracking
+func (m *Manager) copyDirWithProgress(ctx context.Context, src, dst string, processedBytes *int64) error {
+	srcInfo, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dst, srcInfo.Mode()); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		srcPath := filepath.Join(src, entry.Name())
+		dstPath := filepath.Join(dst, entry.Name())
+
+		if entry.IsDir() {
+			if err := m.copyDirWithProgress(ctx, srcPath, dstPath, processedBytes); err != nil {
+				return err
+			}
+		} else {
+			if err := m.copyFileWithProgress(ctx, srcPath, dstPath, processedBytes); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// getUniqueDestPath generates a unique destination path if file exists
+func (m *Manager) getUniqueDestPath(path string) string {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return path
+	}
+
+	ext := filepath.Ext(path)
+	nameWithoutExt := path[:len(path)-len(ext)]
+	
+	counter := 1
+	for {
+		newPath := fmt.Sprintf("%s_copy%d%s", nameWithoutExt, counter, ext)
+		if _, err := os.Stat(newPath); os.IsNotExist(err) {
+			return newPath
+		}
+		counter++
+	}
+}
+
+// Made with Bob
@@ -0,0 +1,961 @@
+package fileops
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestToggleSelection(t *testing.T) {
+	m := NewManager()
+	
+	// Test selecting a file
+	path := "/test/file.txt"
+	m.ToggleSelection(path)
+	
+	if !m.IsSelected(path) {
+		t.Errorf("Expected file to be selected")
+	}
+	
+	// Test deselecting a file
+	m.ToggleSelection(path)
+	
+	if m.IsSelected(path) {
+		t.Errorf("Expected file to be deselected")
+	}
+}
+
+func TestMultipleSelections(t *testing.T) {
+	m := NewManager()
+	
+	paths := []string{
+		"/test/file1.txt",
+		"/test/file2.txt",
+		"/test/file3.txt",
+	}
+	
+	// Select multiple files
+	for _, path := range paths {
+		m.ToggleSelection(path)
+	}
+	
+	// Verify all are selected
+	for _, path := range paths {
+		if !m.IsSelected(path) {
+			t.Errorf("Expected %s to be selected", path)
+		}
+	}
+	
+	// Verify count
+	if m.GetSelectedCount() != 3 {
+		t.Errorf("Expected 3 selected files, got %d", m.GetSelectedCount())
+	}
+	
+	// Verify GetSelectedFiles returns all
+	selected := m.GetSelectedFiles()
+	if len(selected) != 3 {
+		t.Errorf("Expected 3 files in GetSelectedFiles, got %d", len(selected))
+	}
+}
+
+func TestClearSelection(t *testing.T) {
+	m := NewManager()
+	
+	// Select some files
+	m.ToggleSelection("/test/file1.txt")
+	m.ToggleSelection("/test/file2.txt")
+	
+	// Clear selection
+	m.ClearSelection()
+	
+	if m.GetSelectedCount() != 0 {
+		t.Errorf("Expected 0 selected files after clear, got %d", m.GetSelectedCount())
+	}
+	
+	if m.IsSelected("/test/file1.txt") {
+		t.Errorf("Expected file to not be selected after clear")
+	}
+}
+
+func TestCopyOperation(t *testing.T) {
+	m := NewManager()
+	
+	files := []string{"/test/file1.txt", "/test/file2.txt"}
+	m.Copy(files)
+	
+	if m.operation != OpCopy {
+		t.Errorf("Expected operation to be OpCopy")
+	}
+	
+	if len(m.clipboard) != 2 {
+		t.Errorf("Expected 2 files in clipboard, got %d", len(m.clipboard))
+	}
+}
+
+func TestCutOperation(t *testing.T) {
+	m := NewManager()
+	
+	files := []string{"/test/file1.txt", "/test/file2.txt"}
+	m.Cut(files)
+	
+	if m.operation != OpCut {
+		t.Errorf("Expected operation to be OpCut")
+	}
+	
+	if len(m.clipboard) != 2 {
+		t.Errorf("Expected 2 files in clipboard, got %d", len(m.clipboard))
+	}
+}
+
+func TestClipboardPersistence(t *testing.T) {
+	tmpDir := t.TempDir()
+	kept := filepath.Join(tmpDir, "kept.txt")
+	removed := filepath.Join(tmpDir, "removed.txt")
+	gone := filepath.Join(tmpDir, "gone.txt")
+	for _, path := range []string{kept, removed, gone} {
+		if err := ioutil.WriteFile(path, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	file := filepath.Join(tmpDir, "clipboard.json")
+	
+	m := NewManager()
+	m.SetClipboardFile(file)
+	m.Cut([]string{kept, removed, gone})
+	m.RemoveFromClipboard([]string{removed})
+	if err := os.Remove(gone); err != nil {
+		t.Fatal(err)
+	}
+	
+	// A new session gets the clipboard back, without missing files
+	m = NewManager()
+	m.SetClipboardFile(file)
+	count, op := m.GetClipboardInfo()
+	if count != 1 || op != OpCut || m.GetClipboard()[0] != kept {
+		t.Errorf("Expected %s to be cut, got %v (op %d)", kept, m.GetClipboard(), op)
+	}
+	
+	m.ClearClipboard()
+	if _, err := os.Stat(file); !os.IsNotExist(err) {
+		t.Error("Expected the clipboard file to be removed once empty")
+	}
+}
+
+func TestCreateFile(t *testing.T) {
+	// Create temp directory
+	tmpDir, err := ioutil.TempDir("", "fileops_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	
+	m := NewManager()
+	
+	// Test creating a file
+	filename := "test.txt"
+	err = m.CreateFile(tmpDir, filename)
+	if err != nil {
+		t.Errorf("Failed to create file: %v", err)
+	}
+	
+	// Verify file exists
+	filePath := filepath.Join(tmpDir, filename)
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		t.Errorf("File was not created")
+	}
+}
+
+func TestCreateFolder(t *testing.T) {
+	// Create temp directory
+	tmpDir, err := ioutil.TempDir("", "fileops_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	
+	m := NewManager()
+	
+	// Test creating a folder
+	foldername := "testfolder"
+	err = m.CreateFolder(tmpDir, foldername)
+	if err != nil {
+		t.Errorf("Failed to create folder: %v", err)
+	}
+	
+	// Verify folder exists
+	folderPath := filepath.Join(tmpDir, foldername)
+	if stat, err := os.Stat(folderPath); os.IsNotExist(err) || !stat.IsDir() {
+		t.Errorf("Folder was not created")
+	}
+}
+
+func TestCreateModes(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Unix permissions")
+	}
+	tmpDir := t.TempDir()
+	shared := filepath.Join(tmpDir, "shared")
+	if err := os.Mkdir(shared, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(shared, 0755|os.ModeSetgid); err != nil {
+		t.Fatal(err)
+	}
+	
+	m := NewManager()
+	m.SetCreateModes(0640, 0750, true)
+	if err := m.CreateFile(shared, "file.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.CreateFolder(shared, "sub"); err != nil {
+		t.Fatal(err)
+	}
+	
+	info, err := os.Stat(filepath.Join(shared, "file.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 0640 &^ umask(); info.Mode().Perm() != want {
+		t.Errorf("Expected file mode %v, got %v", want, info.Mode().Perm())
+	}
+	info, err = os.Stat(filepath.Join(shared, "sub"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 0750 &^ umask(); info.Mode().Perm() != want {
+		t.Errorf("Expected folder mode %v, got %v", want, info.Mode().Perm())
+	}
+	if info.Mode()&os.ModeSetgid == 0 {
+		t.Error("Expected the folder to keep the setgid bit of its parent")
+	}
+}
+
+func TestRename(t *testing.T) {
+	// Create temp directory
+	tmpDir, err := ioutil.TempDir("", "fileops_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	
+	m := NewManager()
+	
+	// Create a test file
+	oldPath := filepath.Join(tmpDir, "old.txt")
+	if err := ioutil.WriteFile(oldPath, []byte("test"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	
+	// Rename the file
+	newName := "new.txt"
+	err = m.Rename(oldPath, newName)
+	if err != nil {
+		t.Errorf("Failed to rename file: %v", err)
+	}
+	
+	// Verify old file doesn't exist
+	if _, err := os.Stat(oldPath); !os.IsNotExist(err) {
+		t.Errorf("Old file still exists")
+	}
+	
+	// Verify new file exists
+	newPath := filepath.Join(tmpDir, newName)
+	if _, err := os.Stat(newPath); os.IsNotExist(err) {
+		t.Errorf("New file was not created")
+	}
+}
+
+func TestDelete(t *testing.T) {
+	// Create temp directory
+	tmpDir, err := ioutil.TempDir("", "fileops_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	
+	m := NewManager()
+	
+	// Create a test file
+	filePath := filepath.Join(tmpDir, "test.txt")
+	if err := ioutil.WriteFile(filePath, []byte("test"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	
+	// Delete the file
+	err = m.Delete([]string{filePath})
+	if err != nil {
+		t.Errorf("Failed to delete file: %v", err)
+	}
+	
+	// Verify file doesn't exist
+	if _, err := os.Stat(filePath); !os.IsNotExist(err) {
+		t.Errorf("File still exists after delete")
+	}
+}
+
+func TestPasteCopy(t *testing.T) {
+	// Create temp directories
+	srcDir, err := ioutil.TempDir("", "fileops_test_src")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(srcDir)
+	
+	dstDir, err := ioutil.TempDir("", "fileops_test_dst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dstDir)
+	
+	m := NewManager()
+	
+	// Create a test file
+	srcFile := filepath.Join(srcDir, "test.txt")
+	if err := ioutil.WriteFile(srcFile, []byte("test content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	
+	// Copy the file
+	m.Copy([]string{srcFile})
+	
+	// Paste to destination
+	err = m.Paste(dstDir)
+	if err != nil {
+		t.Errorf("Failed to paste file: %v", err)
+	}
+	
+	// Verify source still exists
+	if _, err := os.Stat(srcFile); os.IsNotExist(err) {
+		t.Errorf("Source file was removed (should be copy)")
+	}
+	
+	// Verify destination exists
+	dstFile := filepath.Join(dstDir, "test.txt")
+	if _, err := os.Stat(dstFile); os.IsNotExist(err) {
+		t.Errorf("Destination file was not created")
+	}
+	
+	// Verify content
+	content, err := ioutil.ReadFile(dstFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "test content" {
+		t.Errorf("File content mismatch")
+	}
+}
+
+func TestPasteCut(t *testing.T) {
+	// Create temp directories
+	srcDir, err := ioutil.TempDir("", "fileops_test_src")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(srcDir)
+	
+	dstDir, err := ioutil.TempDir("", "fileops_test_dst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dstDir)
+	
+	m := NewManager()
+	
+	// Create a test file
+	srcFile := filepath.Join(srcDir, "test.txt")
+	if err := ioutil.WriteFile(srcFile, []byte("test content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	
+	// Cut the file
+	m.Cut([]string{srcFile})
+	
+	// Paste to destination
+	err = m.Paste(dstDir)
+	if err != nil {
+		t.Errorf("Failed to paste file: %v", err)
+	}
+	
+	// Verify source no longer exists
+	if _, err := os.Stat(srcFile); !os.IsNotExist(err) {
+		t.Errorf("Source file still exists (should be cut)")
+	}
+	
+	// Verify destination exists
+	dstFile := filepath.Join(dstDir, "test.txt")
+	if _, err := os.Stat(dstFile); os.IsNotExist(err) {
+		t.Errorf("Destination file was not created")
+	}
+}
+
+// Made with Bob
+
+func TestMoveTo(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "fileops_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	
+	m := NewManager()
+	
+	srcPath := filepath.Join(tmpDir, "photo.jpg")
+	destDir := filepath.Join(tmpDir, "keep")
+	if err := ioutil.WriteFile(srcPath, []byte("test"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(destDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	// Existing file forces a unique destination name
+	if err := ioutil.WriteFile(filepath.Join(destDir, "photo.jpg"), []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	
+	if err := m.MoveTo([]string{srcPath}, destDir); err != nil {
+		t.Fatalf("MoveTo failed: %v", err)
+	}
+	
+	if _, err := os.Stat(srcPath); !os.IsNotExist(err) {
+		t.Errorf("Source file still exists")
+	}
+	if _, err := os.Stat(filepath.Join(destDir, "photo_copy1.jpg")); err != nil {
+		t.Errorf("Expected moved file with unique name: %v", err)
+	}
+}
+
+func TestAggregateSize(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "fileops_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	
+	m := NewManager()
+	
+	filePath := filepath.Join(tmpDir, "a.txt")
+	dirPath := filepath.Join(tmpDir, "dir")
+	if err := ioutil.WriteFile(filePath, []byte("12345"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(dirPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dirPath, "b.txt"), []byte("1234567890"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	
+	ready := make(chan struct{}, 1)
+	total, pending := m.AggregateSize([]string{filePath, dirPath}, func() { ready <- struct{}{} })
+	if !pending || total != 5 {
+		t.Errorf("Expected 5 bytes with directory pending, got %d (pending=%v)", total, pending)
+	}
+	
+	<-ready
+	total, pending = m.AggregateSize([]string{filePath, dirPath}, nil)
+	if pending || total != 15 {
+		t.Errorf("Expected 15 bytes after measuring, got %d (pending=%v)", total, pending)
+	}
+}
+
+func TestMoveByCopyPreservesTimes(t *testing.T) {
+	tmpDir := t.TempDir()
+	srcDir := filepath.Join(tmpDir, "src")
+	if err := os.MkdirAll(filepath.Join(srcDir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	filePath := filepath.Join(srcDir, "sub", "data.txt")
+	if err := ioutil.WriteFile(filePath, []byte("0123456789"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, path := range []string{filePath, filepath.Join(srcDir, "sub"), srcDir} {
+		if err := os.Chtimes(path, old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+	
+	m := NewManager()
+	dstDir := filepath.Join(tmpDir, "dst")
+	var processed int64
+	if err := m.moveByCopy(context.Background(), srcDir, dstDir, &processed); err != nil {
+		t.Fatal(err)
+	}
+	
+	if processed != 10 {
+		t.Errorf("Expected 10 processed bytes, got %d", processed)
+	}
+	if _, err := os.Stat(srcDir); !os.IsNotExist(err) {
+		t.Errorf("Source still exists after move")
+	}
+	for _, rel := range []string{"", "sub", filepath.Join("sub", "data.txt")} {
+		info, err := os.Stat(filepath.Join(dstDir, rel))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !info.ModTime().Equal(old) {
+			t.Errorf("%q: expected mtime %v, got %v", rel, old, info.ModTime())
+		}
+	}
+}
+
+func TestVerifyCopy(t *testing.T) {
+	tmpDir := t.TempDir()
+	srcDir := filepath.Join(tmpDir, "src")
+	if err := os.MkdirAll(filepath.Join(srcDir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a.txt", filepath.Join("sub", "b.txt")} {
+		if err := ioutil.WriteFile(filepath.Join(srcDir, name), []byte("contents of "+name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	
+	m := NewManager()
+	m.SetVerify(true)
+	if err := m.CopyTo([]string{srcDir}, filepath.Join(tmpDir, "dst")); err != nil {
+		t.Fatalf("Verified copy failed: %v", err)
+	}
+	
+	// A copy that differs from its source is reported
+	dstDir := filepath.Join(tmpDir, "dst", "src")
+	if err := ioutil.WriteFile(filepath.Join(dstDir, "sub", "b.txt"), []byte("corrupted"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err := m.verifyCopy(context.Background(), srcDir, dstDir)
+	var verifyErr *VerifyError
+	if !errors.As(err, &verifyErr) || len(verifyErr.Paths) != 1 || filepath.Base(verifyErr.Paths[0]) != "b.txt" {
+		t.Errorf("Expected b.txt to be reported, got %v", err)
+	}
+}
+
+func TestEvents(t *testing.T) {
+	tmpDir := t.TempDir()
+	dirA := filepath.Join(tmpDir, "a")
+	dirB := filepath.Join(tmpDir, "b")
+	for _, dir := range []string{dirA, dirB} {
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	file := filepath.Join(dirA, "file.txt")
+	if err := ioutil.WriteFile(file, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	
+	m := NewManager()
+	var events []Event
+	m.Subscribe(func(e Event) { events = append(events, e) })
+	
+	// A cached size of the source directory goes stale with the copy
+	m.sizeCache[dirA] = 5
+	if err := m.CopyTo([]string{file}, dirB); err != nil {
+		t.Fatal(err)
+	}
+	copied := filepath.Join(dirB, "file.txt")
+	if len(events) != 1 || events[0] != (Event{Kind: EventCreated, Path: copied}) {
+		t.Fatalf("Expected a created event for the copy, got %v", events)
+	}
+	if _, ok := m.sizeCache[dirB]; ok {
+		t.Error("Expected the size of the destination to be dropped")
+	}
+	if _, ok := m.sizeCache[dirA]; !ok {
+		t.Error("Expected the size of the untouched source to be kept")
+	}
+	
+	// Moves are followed by the selection and the basket
+	m.Select([]string{file})
+	m.AddToBasket([]string{file})
+	events = nil
+	if err := m.Rename(file, "renamed.txt"); err != nil {
+		t.Fatal(err)
+	}
+	renamed := filepath.Join(dirA, "renamed.txt")
+	if len(events) != 1 || events[0] != (Event{Kind: EventMoved, Path: file, Dest: renamed}) {
+		t.Fatalf("Expected a moved event for the rename, got %v", events)
+	}
+	m.TrackEvent(events[0])
+	if !m.IsSelected(renamed) || m.IsSelected(file) || !m.InBasket(renamed) {
+		t.Error("Expected the selection and basket to follow the rename")
+	}
+	
+	// Deleting a directory drops what was selected inside it
+	events = nil
+	if err := m.Delete([]string{dirA}); err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0] != (Event{Kind: EventDeleted, Path: dirA}) {
+		t.Fatalf("Expected a deleted event, got %v", events)
+	}
+	m.TrackEvent(events[0])
+	if m.GetSelectedCount() != 0 || len(m.GetBasket()) != 0 {
+		t.Error("Expected deleted items to leave the selection and basket")
+	}
+}
+
+func TestBackup(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "app.conf")
+	if err := ioutil.WriteFile(path, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	
+	m := NewManager()
+	m.SetBackup(BackupSuffix, "")
+	if err := m.Backup(path); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := ioutil.ReadFile(path + "~"); err != nil || string(data) != "old" {
+		t.Errorf("Expected app.conf~ with the old contents, got %q (%v)", data, err)
+	}
+	
+	backupDir := filepath.Join(tmpDir, "backups")
+	m.SetBackup(BackupDir, backupDir)
+	if err := m.Backup(path); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := os.ReadDir(backupDir)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("Expected one backup folder, got %v (%v)", entries, err)
+	}
+	abs, _ := filepath.Abs(path)
+	copied := filepath.Join(backupDir, entries[0].Name(), abs[len(filepath.VolumeName(abs)):])
+	if data, err := ioutil.ReadFile(copied); err != nil || string(data) != "old" {
+		t.Errorf("Expected a backup at %s, got %q (%v)", copied, data, err)
+	}
+	
+	// Only folders older than the cutoff are purged
+	if purged, err := PurgeBackups(backupDir, time.Hour); err != nil || purged != 0 {
+		t.Errorf("Expected nothing purged, got %d (%v)", purged, err)
+	}
+	old := time.Now().Add(-48 * time.Hour).Format(backupStamp)
+	if err := os.MkdirAll(filepath.Join(backupDir, old), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if purged, err := PurgeBackups(backupDir, 24*time.Hour); err != nil || purged != 1 {
+		t.Errorf("Expected the old folder purged, got %d (%v)", purged, err)
+	}
+}
+
+func TestExecuteCanceled(t *testing.T) {
+	tmpDir := t.TempDir()
+	srcDir := filepath.Join(tmpDir, "src")
+	if err := os.MkdirAll(filepath.Join(srcDir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	ioutil.WriteFile(filepath.Join(srcDir, "sub", "data.txt"), []byte("0123456789"), 0644)
+	dstDir := filepath.Join(tmpDir, "dst")
+	os.Mkdir(dstDir, 0755)
+	
+	m := NewManager()
+	if m.Cancel() {
+		t.Error("Expected nothing to cancel without a running operation")
+	}
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	
+	// A copy stopped halfway leaves no partial destination behind
+	var processed int64
+	action := Action{Src: srcDir, Dest: filepath.Join(dstDir, "src")}
+	if err := m.runAction(canceled, &Plan{Op: OpCopy}, action, &processed); err == nil {
+		t.Fatal("Expected the canceled copy to fail")
+	}
+	if _, err := os.Stat(action.Dest); !os.IsNotExist(err) {
+		t.Error("Expected the partial copy to be removed")
+	}
+	
+	// A canceled move or delete keeps the source
+	for _, op := range []Operation{OpCut, OpDelete} {
+		var plan *Plan
+		var err error
+		if op == OpCut {
+			plan, err = m.PlanTransfer([]string{srcDir}, op, dstDir)
+			plan.Actions[0].Rename = false
+		} else {
+			plan, err = m.PlanDelete([]string{srcDir}, op)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := m.ExecuteContext(canceled, plan); !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(srcDir, "sub", "data.txt")); err != nil {
+			t.Errorf("Expected the source to survive a canceled %s", opVerb(op))
+		}
+		if progress := m.GetProgress(); !progress.Canceled || progress.Active {
+			t.Error("Expected the progress to show a finished, canceled operation")
+		}
+	}
+	
+	// Deleting stops between entries
+	if err := removeAll(canceled, srcDir); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected removeAll to stop, got %v", err)
+	}
+	if err := removeAll(context.Background(), srcDir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(srcDir); !os.IsNotExist(err) {
+		t.Error("Expected removeAll to remove the tree")
+	}
+}
+
+func TestTrashRestoreAndEmpty(t *testing.T) {
+	tmpDir := t.TempDir()
+	m := NewManager()
+	m.SetTrashDir(filepath.Join(tmpDir, "Trash"))
+	
+	workDir := filepath.Join(tmpDir, "work dir")
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	filePath := filepath.Join(workDir, "notes.txt")
+	if err := ioutil.WriteFile(filePath, []byte("first"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.MoveToTrash([]string{filePath}); err != nil {
+		t.Fatal(err)
+	}
+	// A second file with the same name must not clobber the first
+	if err := ioutil.WriteFile(filePath, []byte("second"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.MoveToTrash([]string{filePath}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filePath); !os.IsNotExist(err) {
+		t.Errorf("Expected file to be gone after trashing")
+	}
+	
+	items, err := m.TrashItems()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || items[0].Name == items[1].Name {
+		t.Fatalf("Expected two distinct trash items, got %+v", items)
+	}
+	for _, item := range items {
+		if item.OriginalPath != filePath {
+			t.Errorf("Expected original path %q, got %q", filePath, item.OriginalPath)
+		}
+	}
+	
+	// Restore recreates the missing parent directory
+	if err := os.RemoveAll(workDir); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := m.Restore(items[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored != filePath {
+		t.Errorf("Expected restore to %q, got %q", filePath, restored)
+	}
+	if items, _ := m.TrashItems(); len(items) != 1 {
+		t.Errorf("Expected one item left in trash, got %d", len(items))
+	}
+	
+	if err := m.EmptyTrash(); err != nil {
+		t.Fatal(err)
+	}
+	if items, _ := m.TrashItems(); len(items) != 0 {
+		t.Errorf("Expected empty trash, got %+v", items)
+	}
+}
+
+func TestStagingPurge(t *testing.T) {
+	tmpDir := t.TempDir()
+	m := NewManager()
+	m.SetTrashDir(filepath.Join(tmpDir, "Trash"))
+	m.SetStagingDir(filepath.Join(tmpDir, "Staging"))
+	
+	var paths []string
+	for _, name := range []string{"old.txt", "new.txt", "other.txt", "trashed.txt"} {
+		path := filepath.Join(tmpDir, name)
+		if err := ioutil.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	if err := m.MoveToStaging(paths[:3]); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.MoveToTrash(paths[3:]); err != nil {
+		t.Fatal(err)
+	}
+	
+	// Staging and trash are separate bins
+	if items, _ := m.TrashItems(); len(items) != 1 || items[0].OriginalPath != paths[3] {
+		t.Fatalf("Expected only trashed.txt in the trash, got %+v", items)
+	}
+	
+	// Backdate old.txt past the retention period
+	info := filepath.Join(tmpDir, "Staging", "info", "old.txt.trashinfo")
+	stale := fmt.Sprintf("[Trash Info]\nPath=%s\nDeletionDate=%s\n", paths[0], time.Now().AddDate(0, 0, -10).Format("2006-01-02T15:04:05"))
+	if err := ioutil.WriteFile(info, []byte(stale), 0600); err != nil {
+		t.Fatal(err)
+	}
+	purged, err := m.PurgeStagedOlderThan(7 * 24 * time.Hour)
+	if err != nil || purged != 1 {
+		t.Fatalf("Expected one item purged, got %d (%v)", purged, err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "Staging", "files", "old.txt")); !os.IsNotExist(err) {
+		t.Error("Expected the expired item to be deleted")
+	}
+	
+	items, _ := m.StagedItems()
+	if len(items) != 2 {
+		t.Fatalf("Expected two items left, got %+v", items)
+	}
+	if err := m.Purge(items[0]); err != nil {
+		t.Fatal(err)
+	}
+	if left, _ := m.StagedItems(); len(left) != 1 {
+		t.Errorf("Expected one item left after purging one, got %+v", left)
+	}
+	if _, err := m.Restore(items[1]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(items[1].OriginalPath); err != nil {
+		t.Errorf("Expected the staged item restored: %v", err)
+	}
+	
+	if err := m.EmptyStaging(); err != nil {
+		t.Fatal(err)
+	}
+	if items, _ := m.TrashItems(); len(items) != 1 {
+		t.Errorf("Expected emptying the staging area to leave the trash alone, got %+v", items)
+	}
+}
+
+func TestPruneSelection(t *testing.T) {
+	tmpDir := t.TempDir()
+	kept := filepath.Join(tmpDir, "kept.txt")
+	gone := filepath.Join(tmpDir, "gone.txt")
+	for _, path := range []string{kept, gone} {
+		if err := ioutil.WriteFile(path, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	
+	m := NewManager()
+	m.ToggleSelection(kept)
+	m.ToggleSelection(gone)
+	
+	// Removed externally while selected
+	if err := os.Remove(gone); err != nil {
+		t.Fatal(err)
+	}
+	m.PruneSelection()
+	
+	if !m.IsSelected(kept) || m.IsSelected(gone) || m.GetSelectedCount() != 1 {
+		t.Errorf("Expected only %s to stay selected, got %v", kept, m.GetSelectedFiles())
+	}
+}
+
+func TestSelectMatchingAndInvert(t *testing.T) {
+	m := NewManager()
+	paths := []string{"/logs/app.log", "/logs/app.log.1", "/logs/img_01.png", "/logs/notes.txt"}
+	
+	if n, err := m.SelectMatching(paths, "*.log"); err != nil || n != 1 || !m.IsSelected("/logs/app.log") {
+		t.Errorf("Expected only app.log selected by glob, got %d (%v)", n, err)
+	}
+	if n, err := m.SelectMatching(paths, `/^img_\d+/`); err != nil || n != 1 || !m.IsSelected("/logs/img_01.png") {
+		t.Errorf("Expected img_01.png selected by regex, got %d (%v)", n, err)
+	}
+	if _, err := m.SelectMatching(paths, "/(/"); err == nil {
+		t.Error("Expected an error for an invalid regular expression")
+	}
+	
+	m.InvertSelection(paths)
+	if m.GetSelectedCount() != 2 || !m.IsSelected("/logs/app.log.1") || !m.IsSelected("/logs/notes.txt") {
+		t.Errorf("Expected the other two files selected, got %v", m.GetSelectedFiles())
+	}
+	m.Deselect([]string{"/logs/notes.txt"})
+	if m.IsSelected("/logs/notes.txt") || m.GetSelectedCount() != 1 {
+		t.Errorf("Expected notes.txt deselected, got %v", m.GetSelectedFiles())
+	}
+}
+
+func TestBasket(t *testing.T) {
+	tmpDir := t.TempDir()
+	var paths []string
+	for _, dir := range []string{"a", "b"} {
+		path := filepath.Join(tmpDir, dir, "file.txt")
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(dir), 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	
+	m := NewManager()
+	m.AddToBasket(paths)
+	m.AddToBasket(paths[:1]) // Adding twice keeps one entry
+	m.ClearSelection()       // Independent of the selection
+	if got := m.GetBasket(); len(got) != 2 || got[0] != paths[0] || got[1] != paths[1] {
+		t.Fatalf("Expected basket %v, got %v", paths, got)
+	}
+	
+	// Moved items drop out of the basket
+	destDir := filepath.Join(tmpDir, "dest")
+	if err := os.Mkdir(destDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.MoveTo(m.GetBasket(), destDir); err != nil {
+		t.Fatal(err)
+	}
+	m.PruneBasket()
+	if got := m.GetBasket(); len(got) != 0 {
+		t.Errorf("Expected empty basket after moving its files, got %v", got)
+	}
+	if _, err := os.Stat(filepath.Join(destDir, "file_copy1.txt")); err != nil {
+		t.Errorf("Expected both same-named files in the destination: %v", err)
+	}
+	
+	m.AddToBasket([]string{destDir})
+	m.RemoveFromBasket([]string{destDir})
+	if m.InBasket(destDir) || len(m.GetBasket()) != 0 {
+		t.Errorf("Expected %s to be removed from the basket", destDir)
+	}
+}
+
+func TestToggleExecutable(t *testing.T) {
+	tmpDir := t.TempDir()
+	script := filepath.Join(tmpDir, "run.sh")
+	private := filepath.Join(tmpDir, "private.sh")
+	ioutil.WriteFile(script, []byte("#!/bin/sh\n"), 0644)
+	ioutil.WriteFile(private, []byte("#!/bin/sh\n"), 0600)
+	os.Chmod(script, 0644)
+	os.Chmod(private, 0600)
+	
+	executable, err := ToggleExecutable([]string{script, private, tmpDir})
+	if err != nil || !executable {
+		t.Fatalf("Expected the files to become executable, got %v (%v)", executable, err)
+	}
+	for path, want := range map[string]os.FileMode{script: 0755, private: 0700} {
+		if info, _ := os.Stat(path); info.Mode().Perm() != want {
+			t.Errorf("Expected %s to have mode %o, got %o", filepath.Base(path), want, info.Mode().Perm())
+		}
+	}
+	
+	executable, err = ToggleExecutable([]string{script, private})
+	if err != nil || executable {
+		t.Fatalf("Expected the executable bits to be removed, got %v (%v)", executable, err)
+	}
+	if info, _ := os.Stat(script); info.Mode().Perm() != 0644 {
+		t.Errorf("Expected mode 644 after toggling back, got %o", info.Mode().Perm())
+	}
+	
+	if _, err := ToggleExecutable([]string{tmpDir}); err == nil {
+		t.Error("Expected an error when there are no regular files")
+	}
+}
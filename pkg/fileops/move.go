@@ -0,0 +1,89 @@
+package fileops
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"syscall"
+)
+
+// errNotSameDevice is ERROR_NOT_SAME_DEVICE, returned by Windows instead of EXDEV
+const errNotSameDevice = syscall.Errno(17)
+
+// isCrossDevice reports whether a rename failed because source and
+// destination are on different filesystems
+func isCrossDevice(err error) bool {
+	if errors.Is(err, syscall.EXDEV) {
+		return true
+	}
+	return runtime.GOOS == "windows" && errors.Is(err, errNotSameDevice)
+}
+
+// move renames src to dst, falling back to copy + delete when they are on
+// different filesystems
+func (m *Manager) move(ctx context.Context, src, dst string, processedBytes *int64) error {
+	err := os.Rename(src, dst)
+	if err != nil && isCrossDevice(err) {
+		// Copy counts bytes as they are written
+		err = m.moveByCopy(ctx, src, dst, processedBytes)
+	}
+	return err
+}
+
+// moveByCopy moves src to dst when a rename is not possible: it copies with
+// progress tracking, carries the timestamps over and only then removes the
+// source. A failed, canceled or (with verification on) mismatched copy is
+// cleaned up and leaves the source untouched; once copied, the source is removed even if ctx is canceled.
+func (m *Manager) moveByCopy(ctx context.Context, src, dst string, processedBytes *int64) error {
+	if err := m.copyFileOrDirWithProgress(ctx, src, dst, processedBytes); err != nil {
+		os.RemoveAll(dst)
+		return err
+	}
+	if err := preserveTimes(src, dst); err != nil {
+		os.RemoveAll(dst)
+		return err
+	}
+	if m.verify {
+		if err := m.verifyCopy(ctx, src, dst); err != nil {
+			os.RemoveAll(dst)
+			return err
+		}
+	}
+	return os.RemoveAll(src)
+}
+
+// preserveTimes copies the modification times of the src tree onto the
+// matching paths under dst. Directories are stamped after their contents,
+// since writing into a directory changes its mtime.
+func preserveTimes(src, dst string) error {
+	var paths []string
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		paths = append(paths, path)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for i := len(paths) - 1; i >= 0; i-- {
+		info, err := os.Stat(paths[i])
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, paths[i])
+		if err != nil {
+			return err
+		}
+		mtime := info.ModTime()
+		if err := os.Chtimes(filepath.Join(dst, rel), mtime, mtime); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package fileops
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// SetCreateModes sets the permissions of files and folders made by
+// CreateFile and CreateFolder, before the umask clears bits from them.
+// With inheritGroup they also get the group of the folder they are made in.
+func (m *Manager) SetCreateModes(file, dir os.FileMode, inheritGroup bool) {
+	m.fileMode = file
+	m.dirMode = dir
+	m.inheritGroup = inheritGroup
+}
+
+// applyCreateMode gives a new item mode less the umask. A new folder keeps
+// the setgid bit of its parent, which the kernel passed on but a chmod
+// without it would clear, so the parent's group keeps being inherited
+// further down.
+func (m *Manager) applyCreateMode(path string, mode os.FileMode, isDir bool) error {
+	parent, err := os.Stat(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	mode &^= umask()
+	if isDir && parent.Mode()&os.ModeSetgid != 0 {
+		mode |= os.ModeSetgid
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		return err
+	}
+	if m.inheritGroup {
+		return chownGroup(path, parent)
+	}
+	return nil
+}
@@ -0,0 +1,16 @@
+//go:build !unix
+
+package fileops
+
+import "os"
+
+// umask returns the permission bits the process umask clears; there is no
+// umask here
+func umask() os.FileMode {
+	return 0
+}
+
+// chownGroup does nothing, as there are no Unix groups here
+func chownGroup(path string, parent os.FileInfo) error {
+	return nil
+}
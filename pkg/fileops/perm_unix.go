@@ -0,0 +1,34 @@
+//go:build unix
+
+package fileops
+
+import (
+	"os"
+	"syscall"
+)
+
+// processUmask is read once while the package initializes: reading the
+// umask means setting it, and a mask of 0 set for a moment later would
+// apply to files that background operations create meanwhile
+var processUmask = readUmask()
+
+// readUmask returns the permission bits the process umask clears
+func readUmask() os.FileMode {
+	mask := syscall.Umask(0)
+	syscall.Umask(mask)
+	return os.FileMode(mask) & os.ModePerm
+}
+
+// umask returns the permission bits the process umask clears
+func umask() os.FileMode {
+	return processUmask
+}
+
+// chownGroup gives path the group of parent
+func chownGroup(path string, parent os.FileInfo) error {
+	stat, ok := parent.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	return os.Lchown(path, -1, int(stat.Gid))
+}
@@ -0,0 +1,68 @@
+package fileops
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// quarantineAttr is the extended attribute macOS puts on downloaded files
+const quarantineAttr = "com.apple.quarantine"
+
+// ToggleExecutable makes the regular files among paths executable, or, when
+// all of them already are, removes their executable bits. Execute permission
+// is added for whoever may read the file, like chmod +x. It returns whether
+// the files are executable now; directories and other entries are skipped.
+func ToggleExecutable(paths []string) (bool, error) {
+	var files []string
+	var modes []os.FileMode
+	allExecutable := true
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return false, err
+		}
+		if !info.Mode().IsRegular() {
+			continue
+		}
+		files = append(files, path)
+		modes = append(modes, info.Mode())
+		if info.Mode().Perm()&0111 == 0 {
+			allExecutable = false
+		}
+	}
+	if len(files) == 0 {
+		return false, fmt.Errorf("no regular files to change")
+	}
+
+	for i, path := range files {
+		mode := modes[i].Perm()
+		if allExecutable {
+			mode &^= 0111
+		} else {
+			mode |= (mode & 0444) >> 2
+		}
+		if err := os.Chmod(path, mode|modes[i]&(os.ModeSetuid|os.ModeSetgid|os.ModeSticky)); err != nil {
+			return false, err
+		}
+	}
+	return !allExecutable, nil
+}
+
+// RemoveQuarantine removes the macOS quarantine attribute from paths,
+// recursively for directories, so downloaded files open without the
+// Gatekeeper warning. Paths without the attribute are left as they are.
+func RemoveQuarantine(paths []string) error {
+	if runtime.GOOS != "darwin" {
+		return fmt.Errorf("quarantine attributes only exist on macOS")
+	}
+	for _, path := range paths {
+		out, err := exec.Command("xattr", "-dr", quarantineAttr, path).CombinedOutput()
+		if err != nil && !strings.Contains(string(out), "No such xattr") {
+			return fmt.Errorf("xattr %s: %s", path, strings.TrimSpace(string(out)))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,536 @@
+package fileops
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// defaultCopyRate is assumed for time estimates until a copy has been measured
+const defaultCopyRate = 50 * 1024 * 1024 // bytes per second
+
+// Action is one top-level item of a planned operation
+type Action struct {
+	Src       string
+	Dest      string // Destination path; empty for deletes
+	Size      int64  // Recursive size, measured while planning
+	Conflict  bool   // The destination name was taken, so Dest has a _copyN suffix
+	Existing  string // The taken destination, when Conflict is set
+	Rename    bool   // A move within one filesystem, done without copying data
+	Overwrite bool   // Dest is the existing item, which is replaced
+	Skip      bool   // Left out by Resolve
+	CaseClash string // An item in the destination, or another source, whose name differs only by case
+}
+
+// Resolution is how a conflicting action is carried out
+type Resolution int
+
+const (
+	KeepBoth         Resolution = iota // Use the _copyN name chosen while planning
+	Overwrite                          // Replace the existing item
+	Skip                               // Leave the item out
+	OverwriteIfNewer                   // Replace the existing item if the source is newer, else skip
+)
+
+// Plan describes an operation before it runs: what goes where, how much
+// data is involved and which names had to change
+type Plan struct {
+	Op         Operation
+	DestDir    string // For OpTrash, the trash or staging area
+	Staged     bool   // OpTrash into the staging area
+	Actions    []Action
+	TotalBytes int64
+	copyRate   float64
+}
+
+// PlanTransfer plans copying or moving files into destDir. Conflicting
+// names, including two sources with the same name, get unique destinations.
+func (m *Manager) PlanTransfer(files []string, op Operation, destDir string) (*Plan, error) {
+	plan := m.newPlan(op)
+	plan.DestDir = destDir
+	taken := make(map[string]bool)
+	names := dirNames(destDir)
+	for _, src := range files {
+		size, err := m.getPathSize(src)
+		if err != nil {
+			return nil, fmt.Errorf("failed to calculate total size: %v", err)
+		}
+		if isWithin(destDir, src) {
+			return nil, fmt.Errorf("cannot %s %s into itself", opVerb(op), filepath.Base(src))
+		}
+
+		dest := filepath.Join(destDir, filepath.Base(src))
+		unique := dest
+		if _, err := os.Lstat(dest); err == nil || taken[dest] {
+			unique = nextFreePath(dest, taken)
+		}
+		taken[unique] = true
+
+		action := Action{
+			Src:      src,
+			Dest:     unique,
+			Size:     size,
+			Conflict: unique != dest,
+			Rename:   op == OpCut && sameDevice(src, destDir),
+		}
+		if action.Conflict {
+			action.Existing = dest
+		} else {
+			action.CaseClash = caseClash(names, filepath.Base(dest))
+		}
+		names = append(names, filepath.Base(unique))
+		plan.Actions = append(plan.Actions, action)
+		plan.TotalBytes += size
+	}
+	return plan, nil
+}
+
+// PlanDelete plans deleting files, permanently (OpDelete) or to the trash
+// (OpTrash)
+func (m *Manager) PlanDelete(files []string, op Operation) (*Plan, error) {
+	plan := m.newPlan(op)
+	if op == OpTrash {
+		plan.DestDir = m.GetTrashDir()
+	}
+	return m.planRemoval(plan, files)
+}
+
+// PlanStage plans moving files to the staging area
+func (m *Manager) PlanStage(files []string) (*Plan, error) {
+	bin := m.GetStagingDir()
+	if bin == "" {
+		return nil, fmt.Errorf("no staging area set")
+	}
+	plan := m.newPlan(OpTrash)
+	plan.DestDir = bin
+	plan.Staged = true
+	return m.planRemoval(plan, files)
+}
+
+// planRemoval adds an action per file to a delete, trash or staging plan
+func (m *Manager) planRemoval(plan *Plan, files []string) (*Plan, error) {
+	for _, path := range files {
+		size, err := m.getPathSize(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to calculate total size: %v", err)
+		}
+		plan.Actions = append(plan.Actions, Action{
+			Src:    path,
+			Size:   size,
+			Rename: plan.Op == OpTrash && sameDevice(path, plan.DestDir),
+		})
+		plan.TotalBytes += size
+	}
+	return plan, nil
+}
+
+// PlanPaste plans pasting the clipboard into destDir
+func (m *Manager) PlanPaste(destDir string) (*Plan, error) {
+	if len(m.clipboard) == 0 {
+		return nil, fmt.Errorf("clipboard is empty")
+	}
+	return m.PlanTransfer(m.clipboard, m.operation, destDir)
+}
+
+// newPlan starts a plan using the throughput of the last measured copy
+func (m *Manager) newPlan(op Operation) *Plan {
+	m.sizeMu.Lock()
+	rate := m.copyRate
+	m.sizeMu.Unlock()
+	if rate <= 0 {
+		rate = defaultCopyRate
+	}
+	return &Plan{Op: op, copyRate: rate}
+}
+
+// Conflicts returns how many items were renamed to avoid overwriting
+func (p *Plan) Conflicts() int {
+	count := 0
+	for _, action := range p.Actions {
+		if action.Conflict && !action.Overwrite && !action.Skip {
+			count++
+		}
+	}
+	return count
+}
+
+// Resolve decides how conflicting action i is carried out; call it once per
+// conflict, before Execute. Overwriting an item with itself skips it, and
+// overwriting an item another action already replaces keeps both instead.
+// It returns the resolution applied.
+func (p *Plan) Resolve(i int, resolution Resolution) Resolution {
+	action := &p.Actions[i]
+	if !action.Conflict {
+		return KeepBoth
+	}
+	if resolution == OverwriteIfNewer {
+		resolution = Skip
+		src, srcErr := os.Stat(action.Src)
+		existing, existingErr := os.Stat(action.Existing)
+		if srcErr == nil && existingErr == nil && src.ModTime().After(existing.ModTime()) {
+			resolution = Overwrite
+		}
+	}
+	if resolution == Overwrite {
+		if src, err := os.Lstat(action.Src); err == nil {
+			if existing, err := os.Lstat(action.Existing); err == nil && os.SameFile(src, existing) {
+				resolution = Skip
+			}
+		}
+		for j, other := range p.Actions {
+			if j != i && other.Overwrite && other.Dest == action.Existing {
+				resolution = KeepBoth
+			}
+		}
+	}
+
+	switch resolution {
+	case Overwrite:
+		action.Dest = action.Existing
+		action.Overwrite = true
+	case Skip:
+		action.Skip = true
+		p.TotalBytes -= action.Size
+	}
+	return resolution
+}
+
+// CaseClashes returns the actions that go ahead under a name differing
+// only by case from another item in the destination. Both fit on a
+// case-sensitive filesystem, but not when the folder is copied to a
+// case-insensitive one (macOS and Windows by default, most USB sticks).
+func (p *Plan) CaseClashes() []Action {
+	var clashes []Action
+	for _, action := range p.Actions {
+		if action.CaseClash != "" && !action.Skip {
+			clashes = append(clashes, action)
+		}
+	}
+	return clashes
+}
+
+// CaseClash returns the name of an item in dir that differs from name only
+// by case, or "" if there is none. On a case-insensitive filesystem such an
+// item is simply name itself, which already exists.
+func CaseClash(dir, name string) string {
+	return caseClash(dirNames(dir), name)
+}
+
+// caseClash returns the first of names equal to name apart from case
+func caseClash(names []string, name string) string {
+	for _, other := range names {
+		if other != name && strings.EqualFold(other, name) {
+			return other
+		}
+	}
+	return ""
+}
+
+// dirNames lists the names in dir, or nothing if it can't be read
+func dirNames(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	names := make([]string, len(entries))
+	for i, entry := range entries {
+		names[i] = entry.Name()
+	}
+	return names
+}
+
+// Skipped returns how many items Resolve left out
+func (p *Plan) Skipped() int {
+	count := 0
+	for _, action := range p.Actions {
+		if action.Skip {
+			count++
+		}
+	}
+	return count
+}
+
+// Overwrites returns how many existing items the plan replaces
+func (p *Plan) Overwrites() int {
+	count := 0
+	for _, action := range p.Actions {
+		if action.Overwrite && !action.Skip {
+			count++
+		}
+	}
+	return count
+}
+
+// CopyBytes returns how much data has to be copied; renames and deletes
+// don't move any
+func (p *Plan) CopyBytes() int64 {
+	var total int64
+	for _, action := range p.Actions {
+		if action.Skip {
+			continue
+		}
+		if p.Op == OpCopy || ((p.Op == OpCut || p.Op == OpTrash) && !action.Rename) {
+			total += action.Size
+		}
+	}
+	return total
+}
+
+// EstimatedTime guesses how long the plan takes to run
+func (p *Plan) EstimatedTime() time.Duration {
+	return time.Duration(float64(p.CopyBytes()) / p.copyRate * float64(time.Second))
+}
+
+// Summary describes the plan in one line, e.g.
+// "Copy 3 items (12.0 MB) to /tmp, 1 renamed to avoid conflicts, about 2s"
+func (p *Plan) Summary() string {
+	items := fmt.Sprintf("%d items", len(p.Actions)-p.Skipped())
+	if len(p.Actions) == 1 {
+		items = filepath.Base(p.Actions[0].Src)
+	}
+	var parts []string
+	switch p.Op {
+	case OpCopy, OpCut:
+		parts = append(parts, fmt.Sprintf("%s %s (%s) to %s", capitalize(opVerb(p.Op)), items, formatSize(p.TotalBytes), p.DestDir))
+	case OpTrash:
+		bin := "trash"
+		if p.Staged {
+			bin = "staging area"
+		}
+		parts = append(parts, fmt.Sprintf("Move %s (%s) to %s", items, formatSize(p.TotalBytes), bin))
+	default:
+		parts = append(parts, fmt.Sprintf("Permanently delete %s (%s)", items, formatSize(p.TotalBytes)))
+	}
+	if conflicts := p.Conflicts(); conflicts > 0 {
+		parts = append(parts, fmt.Sprintf("%d renamed to avoid conflicts", conflicts))
+	}
+	if overwrites := p.Overwrites(); overwrites > 0 {
+		parts = append(parts, fmt.Sprintf("%d overwritten", overwrites))
+	}
+	if skipped := p.Skipped(); skipped > 0 {
+		parts = append(parts, fmt.Sprintf("%d skipped", skipped))
+	}
+	if eta := p.EstimatedTime(); eta >= time.Second {
+		parts = append(parts, "about "+eta.Round(time.Second).String())
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Execute runs a plan with progress tracking. Progress advances by the sizes
+// measured while planning, so it stays right when sources disappear.
+func (m *Manager) Execute(plan *Plan) error {
+	return m.ExecuteContext(context.Background(), plan)
+}
+
+// ExecuteContext runs a plan like Execute until ctx is canceled or Cancel
+// is called. The item being copied when that happens is removed again and
+// a move leaves its source in place; items done before stay done. It then
+// returns context.Canceled (or the error of ctx).
+func (m *Manager) ExecuteContext(ctx context.Context, plan *Plan) error {
+	if plan.Op == OpTrash {
+		if err := ensureTrash(plan.DestDir); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	ctx, err := m.startProgress(ctx, plan.Op, len(plan.Actions)-plan.Skipped(), plan.TotalBytes)
+	if err != nil {
+		return err
+	}
+	m.progress.Mu.Lock()
+	m.cancel = cancel
+	m.progress.Cancelable = true
+	m.progress.Mu.Unlock()
+	defer func() {
+		m.progress.Mu.Lock()
+		m.cancel = nil
+		m.progress.Canceled = ctx.Err() != nil
+		m.progress.Mu.Unlock()
+		m.finishProgress(ctx)
+	}()
+
+	var processedBytes int64
+	var mismatched []string // Sources whose copy failed verification
+	for _, action := range plan.Actions {
+		if action.Skip {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		// Where this item's bytes end, whatever a copy counted on the way
+		itemEnd := processedBytes + action.Size
+		fileName := filepath.Base(action.Src)
+		m.updateProgress(ctx, processedBytes, fileName)
+
+		if err := m.runAction(ctx, plan, action, &processedBytes); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			var verifyErr *VerifyError
+			if !errors.As(err, &verifyErr) {
+				return err
+			}
+			mismatched = append(mismatched, verifyErr.Paths...)
+		}
+
+		processedBytes = itemEnd
+		m.updateProgress(ctx, processedBytes, fileName)
+		m.progress.Mu.Lock()
+		m.progress.ProcessedFiles++
+		m.progress.Mu.Unlock()
+	}
+	if len(mismatched) > 0 {
+		return &VerifyError{Paths: mismatched}
+	}
+	return nil
+}
+
+// runAction performs one planned action
+func (m *Manager) runAction(ctx context.Context, plan *Plan, action Action, processedBytes *int64) error {
+	if action.Overwrite {
+		return m.overwrite(ctx, plan, action, processedBytes)
+	}
+	switch plan.Op {
+	case OpCopy:
+		if err := m.copyFileOrDirWithProgress(ctx, action.Src, action.Dest, processedBytes); err != nil {
+			// The destination was a free name, so whatever is there is ours
+			if ctx.Err() != nil {
+				os.RemoveAll(action.Dest)
+			}
+			return fmt.Errorf("failed to copy %s: %v", action.Src, err)
+		}
+		m.emit(Event{Kind: EventCreated, Path: action.Dest})
+		if m.verify {
+			return m.verifyCopy(ctx, action.Src, action.Dest)
+		}
+	case OpCut:
+		if err := m.move(ctx, action.Src, action.Dest, processedBytes); err != nil {
+			return fmt.Errorf("failed to move %s: %w", action.Src, err)
+		}
+		m.emit(Event{Kind: EventMoved, Path: action.Src, Dest: action.Dest})
+	case OpTrash:
+		if err := m.trashItem(ctx, plan.DestDir, action.Src, processedBytes); err != nil {
+			return fmt.Errorf("failed to trash %s: %v", action.Src, err)
+		}
+		m.emit(Event{Kind: EventDeleted, Path: action.Src})
+	case OpDelete:
+		if err := removeAll(ctx, action.Src); err != nil {
+			return fmt.Errorf("failed to delete %s: %v", action.Src, err)
+		}
+		m.emit(Event{Kind: EventDeleted, Path: action.Src})
+	}
+	return nil
+}
+
+// overwrite carries out an action into a free name next to the existing
+// item and replaces the existing item only then, so a failed or canceled
+// copy or move leaves it alone. The existing item is backed up first when
+// backups are on.
+func (m *Manager) overwrite(ctx context.Context, plan *Plan, action Action, processedBytes *int64) error {
+	target := action.Dest
+	if err := m.Backup(target); err != nil {
+		return err
+	}
+	action.Dest = nextFreePath(target, nil)
+	action.Overwrite = false
+	if err := m.runAction(ctx, plan, action, processedBytes); err != nil {
+		var verifyErr *VerifyError
+		if errors.As(err, &verifyErr) {
+			os.RemoveAll(action.Dest)
+		}
+		return err
+	}
+	if err := os.RemoveAll(target); err != nil {
+		return fmt.Errorf("failed to replace %s: %v", target, err)
+	}
+	if err := os.Rename(action.Dest, target); err != nil {
+		return fmt.Errorf("failed to replace %s: %v", target, err)
+	}
+	m.emit(Event{Kind: EventMoved, Path: action.Dest, Dest: target})
+	return nil
+}
+
+// removeAll removes path and everything below it like os.RemoveAll, but
+// stops between entries once ctx is canceled
+func removeAll(ctx context.Context, path string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if err := removeAll(ctx, filepath.Join(path, entry.Name())); err != nil {
+				return err
+			}
+		}
+	}
+	return os.Remove(path)
+}
+
+// isWithin reports whether path is dir itself or inside it
+func isWithin(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// nextFreePath returns the first _copyN variant of path that neither exists
+// nor is already taken by the plan
+func nextFreePath(path string, taken map[string]bool) string {
+	ext := filepath.Ext(path)
+	nameWithoutExt := path[:len(path)-len(ext)]
+	for counter := 1; ; counter++ {
+		newPath := fmt.Sprintf("%s_copy%d%s", nameWithoutExt, counter, ext)
+		if _, err := os.Lstat(newPath); os.IsNotExist(err) && !taken[newPath] {
+			return newPath
+		}
+	}
+}
+
+// opVerb names an operation in prompts
+func opVerb(op Operation) string {
+	switch op {
+	case OpCopy:
+		return "copy"
+	case OpCut:
+		return "move"
+	case OpTrash:
+		return "trash"
+	}
+	return "delete"
+}
+
+// capitalize upper-cases the first letter of an ASCII word
+func capitalize(word string) string {
+	return strings.ToUpper(word[:1]) + word[1:]
+}
+
+// formatSize formats a byte count for summaries
+func formatSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
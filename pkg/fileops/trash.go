@@ -0,0 +1,301 @@
+package fileops
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// trashInfoTime is the DeletionDate layout of the XDG trash spec
+const trashInfoTime = "2006-01-02T15:04:05"
+
+// TrashItem is one entry in the trash or the staging area
+type TrashItem struct {
+	Bin          string // The trash or staging area directory holding the item
+	Name         string // Name inside the bin's files directory
+	OriginalPath string
+	DeletedAt    time.Time
+}
+
+// DefaultTrashDir returns the XDG trash on Linux and the BSDs, and
+// ~/.xplorer_trash elsewhere. Both use the XDG files/ + info/ layout.
+func DefaultTrashDir() string {
+	home, _ := os.UserHomeDir()
+	switch runtime.GOOS {
+	case "linux", "freebsd", "openbsd", "netbsd", "dragonfly":
+		if dataHome := os.Getenv("XDG_DATA_HOME"); dataHome != "" {
+			return filepath.Join(dataHome, "Trash")
+		}
+		return filepath.Join(home, ".local", "share", "Trash")
+	}
+	return filepath.Join(home, ".xplorer_trash")
+}
+
+// SetTrashDir changes where deleted files go
+func (m *Manager) SetTrashDir(dir string) {
+	m.binMu.Lock()
+	m.trashDir = dir
+	m.binMu.Unlock()
+}
+
+// GetTrashDir returns the trash directory
+func (m *Manager) GetTrashDir() string {
+	m.binMu.Lock()
+	defer m.binMu.Unlock()
+	return m.trashDir
+}
+
+// SetStagingDir sets the staging area, which keeps soft-deleted files apart
+// from the trash until they are purged
+func (m *Manager) SetStagingDir(dir string) {
+	m.binMu.Lock()
+	m.stagingDir = dir
+	m.binMu.Unlock()
+}
+
+// GetStagingDir returns the staging area directory
+func (m *Manager) GetStagingDir() string {
+	m.binMu.Lock()
+	defer m.binMu.Unlock()
+	return m.stagingDir
+}
+
+// MoveToTrash moves files to the trash with progress tracking, recording
+// their original paths so they can be restored
+func (m *Manager) MoveToTrash(files []string) error {
+	plan, err := m.PlanDelete(files, OpTrash)
+	if err != nil {
+		return err
+	}
+	return m.Execute(plan)
+}
+
+// MoveToStaging moves files to the staging area like MoveToTrash
+func (m *Manager) MoveToStaging(files []string) error {
+	plan, err := m.PlanStage(files)
+	if err != nil {
+		return err
+	}
+	return m.Execute(plan)
+}
+
+// ensureTrash creates the directories of the trash or staging area bin
+func ensureTrash(bin string) error {
+	for _, sub := range []string{"files", "info"} {
+		if err := os.MkdirAll(filepath.Join(bin, sub), 0700); err != nil {
+			return fmt.Errorf("failed to create trash: %v", err)
+		}
+	}
+	return nil
+}
+
+// trashItem moves one file or directory into bin next to a .trashinfo file
+// holding its original path and deletion time
+func (m *Manager) trashItem(ctx context.Context, bin, path string, processedBytes *int64) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	infoDir := filepath.Join(bin, "info")
+
+	// Reserve a unique name by creating the .trashinfo file exclusively
+	name, infoFile, err := createTrashInfo(infoDir, filepath.Base(absPath))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(infoFile, "[Trash Info]\nPath=%s\nDeletionDate=%s\n",
+		(&url.URL{Path: absPath}).EscapedPath(), time.Now().Format(trashInfoTime))
+	infoFile.Close()
+
+	if err := m.move(ctx, absPath, filepath.Join(bin, "files", name), processedBytes); err != nil {
+		os.Remove(filepath.Join(infoDir, name+".trashinfo"))
+		return err
+	}
+	return nil
+}
+
+// createTrashInfo creates the .trashinfo file for the first free name based
+// on fileName, adding a numeric suffix when the name is taken
+func createTrashInfo(infoDir, fileName string) (string, *os.File, error) {
+	ext := filepath.Ext(fileName)
+	stem := strings.TrimSuffix(fileName, ext)
+	name := fileName
+	for counter := 1; ; counter++ {
+		f, err := os.OpenFile(filepath.Join(infoDir, name+".trashinfo"), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err == nil {
+			return name, f, nil
+		}
+		if !os.IsExist(err) {
+			return "", nil, err
+		}
+		name = fmt.Sprintf("%s.%d%s", stem, counter, ext)
+	}
+}
+
+// TrashItems lists the trash, most recently deleted first. Entries without
+// readable metadata are skipped.
+func (m *Manager) TrashItems() ([]TrashItem, error) {
+	return binItems(m.GetTrashDir())
+}
+
+// StagedItems lists the staging area like TrashItems
+func (m *Manager) StagedItems() ([]TrashItem, error) {
+	return binItems(m.GetStagingDir())
+}
+
+// binItems lists the items of a trash or staging area bin
+func binItems(bin string) ([]TrashItem, error) {
+	if bin == "" {
+		return nil, nil
+	}
+	entries, err := os.ReadDir(filepath.Join(bin, "info"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var items []TrashItem
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".trashinfo")
+		if !ok {
+			continue
+		}
+		item, err := readTrashInfo(filepath.Join(bin, "info", entry.Name()))
+		if err != nil {
+			continue
+		}
+		if _, err := os.Lstat(filepath.Join(bin, "files", name)); err != nil {
+			continue
+		}
+		item.Bin = bin
+		item.Name = name
+		items = append(items, item)
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].DeletedAt.After(items[j].DeletedAt)
+	})
+	return items, nil
+}
+
+// readTrashInfo parses a .trashinfo file
+func readTrashInfo(path string) (TrashItem, error) {
+	var item TrashItem
+	f, err := os.Open(path)
+	if err != nil {
+		return item, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "Path":
+			if item.OriginalPath, err = url.PathUnescape(value); err != nil {
+				return item, err
+			}
+		case "DeletionDate":
+			item.DeletedAt, _ = time.ParseInLocation(trashInfoTime, value, time.Local)
+		}
+	}
+	if item.OriginalPath == "" {
+		return item, fmt.Errorf("%s: missing Path", path)
+	}
+	return item, scanner.Err()
+}
+
+// Restore moves a trashed item back to its original location, recreating
+// missing parent directories. It returns the restored path, which gets a
+// _copyN suffix when the original location is taken again.
+func (m *Manager) Restore(item TrashItem) (string, error) {
+	src := filepath.Join(item.Bin, "files", item.Name)
+	if err := os.MkdirAll(filepath.Dir(item.OriginalPath), 0755); err != nil {
+		return "", err
+	}
+	dest := m.getUniqueDestPath(item.OriginalPath)
+	var processed int64
+	if err := m.move(context.Background(), src, dest, &processed); err != nil {
+		return "", err
+	}
+	os.Remove(filepath.Join(item.Bin, "info", item.Name+".trashinfo"))
+	m.emit(Event{Kind: EventMoved, Path: src, Dest: dest})
+	return dest, nil
+}
+
+// EmptyTrash permanently deletes everything in the trash
+func (m *Manager) EmptyTrash() error {
+	return m.emptyBin(m.GetTrashDir())
+}
+
+// EmptyStaging permanently deletes everything in the staging area
+func (m *Manager) EmptyStaging() error {
+	return m.emptyBin(m.GetStagingDir())
+}
+
+// emptyBin permanently deletes everything in a trash or staging area bin
+func (m *Manager) emptyBin(bin string) error {
+	if bin == "" {
+		return nil
+	}
+	for _, sub := range []string{"files", "info"} {
+		dir := filepath.Join(bin, sub)
+		entries, err := os.ReadDir(dir)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			path := filepath.Join(dir, entry.Name())
+			if err := os.RemoveAll(path); err != nil {
+				return fmt.Errorf("failed to empty trash: %v", err)
+			}
+			m.emit(Event{Kind: EventDeleted, Path: path})
+		}
+	}
+	return nil
+}
+
+// Purge permanently deletes one item from the trash or staging area
+func (m *Manager) Purge(item TrashItem) error {
+	path := filepath.Join(item.Bin, "files", item.Name)
+	if err := os.RemoveAll(path); err != nil {
+		return fmt.Errorf("failed to purge %s: %v", item.Name, err)
+	}
+	m.emit(Event{Kind: EventDeleted, Path: path})
+	return os.Remove(filepath.Join(item.Bin, "info", item.Name+".trashinfo"))
+}
+
+// PurgeStagedOlderThan permanently deletes the items staged more than age
+// ago and returns how many were deleted
+func (m *Manager) PurgeStagedOlderThan(age time.Duration) (int, error) {
+	items, err := m.StagedItems()
+	if err != nil {
+		return 0, err
+	}
+	cutoff := time.Now().Add(-age)
+	purged := 0
+	for _, item := range items {
+		if !item.DeletedAt.Before(cutoff) {
+			continue
+		}
+		if err := m.Purge(item); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
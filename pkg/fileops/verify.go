@@ -0,0 +1,112 @@
+package fileops
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// VerifyError lists the copied files whose contents don't match their
+// source, found when verification is on
+type VerifyError struct {
+	Paths []string // Source paths
+}
+
+func (e *VerifyError) Error() string {
+	names := make([]string, 0, len(e.Paths))
+	for _, path := range e.Paths {
+		names = append(names, filepath.Base(path))
+	}
+	if len(names) > 5 {
+		names = append(names[:5], fmt.Sprintf("and %d more", len(e.Paths)-5))
+	}
+	return fmt.Sprintf("verification failed, %d copies differ from their source: %s", len(e.Paths), strings.Join(names, ", "))
+}
+
+// SetVerify turns checking copies against their source on or off. When on,
+// copies and moves across filesystems hash every copied file (SHA-256)
+// and compare it with the source; a move keeps its source when they differ.
+func (m *Manager) SetVerify(verify bool) {
+	m.verify = verify
+}
+
+// verifyCopy hashes every regular file under src and its copy under dst
+// and returns a *VerifyError listing the ones that differ
+func (m *Manager) verifyCopy(ctx context.Context, src, dst string) error {
+	var mismatched []string
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		m.updateProgress(ctx, m.processedBytes(), "verifying "+d.Name())
+		same, err := sameContents(path, filepath.Join(dst, rel))
+		if err != nil {
+			return err
+		}
+		if !same {
+			mismatched = append(mismatched, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(mismatched) > 0 {
+		return &VerifyError{Paths: mismatched}
+	}
+	return nil
+}
+
+// processedBytes returns the bytes done so far by the running operation
+func (m *Manager) processedBytes() int64 {
+	m.progress.Mu.RLock()
+	defer m.progress.Mu.RUnlock()
+	return m.progress.ProcessedBytes
+}
+
+// sameContents reports whether two files hash the same. A missing copy
+// counts as different.
+func sameContents(a, b string) (bool, error) {
+	sumA, err := fileHash(a)
+	if err != nil {
+		return false, err
+	}
+	sumB, err := fileHash(b)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(sumA, sumB), nil
+}
+
+// fileHash returns the SHA-256 of a file's contents
+func fileHash(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
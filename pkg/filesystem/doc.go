@@ -0,0 +1,13 @@
+// Package filesystem is Xplorer's navigation engine: a Navigator holding the
+// state of one file list (directory, filtered and sorted entries, cursor,
+// scroll position, history and jump list) and a polling Watcher reporting
+// directories changed on disk. It has no UI dependencies, so any terminal
+// UI can drive a Navigator and draw its GetFileList.
+//
+// A Navigator reads directories through a Filesystem, the local disk unless
+// SetFilesystem gives it another one (an archive, a remote host, a fake).
+// FTP and WebDAV are Remote filesystems on servers, opened with Dial.
+//
+// The exported API of this package is kept backward compatible; new
+// features are added as new methods.
+package filesystem
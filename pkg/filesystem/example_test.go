@@ -0,0 +1,51 @@
+package filesystem_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/alexcostache/Xplorer/pkg/filesystem"
+)
+
+func ExampleNavigator() {
+	dir, _ := os.MkdirTemp("", "xplorer-example")
+	defer os.RemoveAll(dir)
+	os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0644)
+	os.WriteFile(filepath.Join(dir, "main.go"), nil, 0644)
+	os.Mkdir(filepath.Join(dir, "src"), 0755)
+
+	nav := filesystem.NewNavigator()
+	nav.SetCurrentDir(dir)
+	for _, entry := range nav.GetFileList() {
+		fmt.Println(entry.Name())
+	}
+
+	// Filtering narrows the list; the cursor stays within it
+	nav.SetFilter("go")
+	fmt.Println(filepath.Base(nav.GetSelectedPath()))
+	// Output:
+	// src
+	// main.go
+	// notes.txt
+	// main.go
+}
+
+func ExampleWatcher() {
+	dir, _ := os.MkdirTemp("", "xplorer-example")
+	defer os.RemoveAll(dir)
+
+	nav := filesystem.NewNavigator()
+	nav.SetCurrentDir(dir)
+
+	// Watch the directory with the signature of the listing on screen;
+	// Start polls in the background, Poll checks once
+	watcher := filesystem.NewWatcher(time.Second, nil)
+	watcher.Watch(map[string]uint64{dir: nav.Signature()})
+	os.WriteFile(filepath.Join(dir, "new.txt"), nil, 0644)
+	if watcher.Poll() {
+		fmt.Println(len(watcher.Changed()), "directory changed")
+	}
+	// Output: 1 directory changed
+}
@@ -0,0 +1,904 @@
+package filesystem
+
+import (
+	"cmp"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// SortMode represents different file sorting modes
+type SortMode int
+
+const (
+	SortByName SortMode = iota
+	SortBySize
+	SortByModTime
+	SortByExtension
+)
+
+// SortModeNames maps sort modes to their display names
+var SortModeNames = map[SortMode]string{
+	SortByName:      "Alphabetical",
+	SortBySize:      "Size",
+	SortByModTime:   "Modified Time",
+	SortByExtension: "Type",
+}
+
+// CaseMode controls how the filter compares letter case
+type CaseMode int
+
+const (
+	CaseInsensitive CaseMode = iota
+	CaseSensitive
+	CaseSmart // Insensitive unless the filter contains an uppercase letter
+)
+
+// CaseModeNames maps case modes to their config names
+var CaseModeNames = map[CaseMode]string{
+	CaseInsensitive: "insensitive",
+	CaseSensitive:   "sensitive",
+	CaseSmart:       "smart",
+}
+
+// SortKeyNames maps sort modes to their config names
+var SortKeyNames = map[SortMode]string{
+	SortByName:      "name",
+	SortBySize:      "size",
+	SortByModTime:   "modified",
+	SortByExtension: "type",
+}
+
+// ParseSortKey converts a config name to a sort mode, defaulting to name
+func ParseSortKey(name string) SortMode {
+	for mode, modeName := range SortKeyNames {
+		if strings.EqualFold(name, modeName) {
+			return mode
+		}
+	}
+	return SortByName
+}
+
+// ParseCaseMode converts a config name to a case mode, defaulting to insensitive
+func ParseCaseMode(name string) CaseMode {
+	for mode, modeName := range CaseModeNames {
+		if strings.EqualFold(name, modeName) {
+			return mode
+		}
+	}
+	return CaseInsensitive
+}
+
+// MatchName reports whether name contains filter under the given case mode
+func MatchName(name, filter string, mode CaseMode) bool {
+	if filter == "" {
+		return true
+	}
+	start, _ := MatchRange(name, filter, mode)
+	return start >= 0
+}
+
+// MatchRange returns the rune range [start, end) of the first occurrence of
+// filter in name under the given case mode, or -1, -1 if there is none
+func MatchRange(name, filter string, mode CaseMode) (int, int) {
+	if filter == "" {
+		return -1, -1
+	}
+	nameRunes := []rune(name)
+	filterRunes := []rune(filter)
+	ignoreCase := mode == CaseInsensitive || (mode == CaseSmart && strings.ToLower(filter) == filter)
+	
+	for i := 0; i+len(filterRunes) <= len(nameRunes); i++ {
+		matched := true
+		for j, fr := range filterRunes {
+			nr := nameRunes[i+j]
+			if ignoreCase {
+				nr, fr = unicode.ToLower(nr), unicode.ToLower(fr)
+			}
+			if nr != fr {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return i, i + len(filterRunes)
+		}
+	}
+	return -1, -1
+}
+
+// CompareResult describes how a file relates to its counterpart in another directory
+type CompareResult int
+
+const (
+	CompareSame        CompareResult = iota
+	CompareNewer                     // Modified later than the counterpart
+	CompareOlder                     // Modified earlier than the counterpart
+	CompareSizeDiffers               // Same modification time, different size
+	CompareOnlyHere                  // No counterpart exists
+)
+
+// CompareWith compares a file with the entry of the same name in otherDir.
+// Directories are not compared and always report CompareSame.
+func CompareWith(info os.FileInfo, otherDir string) CompareResult {
+	if info.IsDir() {
+		return CompareSame
+	}
+	other, err := os.Stat(filepath.Join(otherDir, info.Name()))
+	if err != nil || other.IsDir() {
+		return CompareOnlyHere
+	}
+	// Allow for filesystems that store modification times coarsely
+	diff := info.ModTime().Sub(other.ModTime())
+	switch {
+	case diff > 2*time.Second:
+		return CompareNewer
+	case diff < -2*time.Second:
+		return CompareOlder
+	case info.Size() != other.Size():
+		return CompareSizeDiffers
+	}
+	return CompareSame
+}
+
+// Navigator handles file system navigation
+type Navigator struct {
+	currentDir   string
+	fileList     []os.FileInfo
+	cursor       int
+	scrollOffset int
+	scrollOff    int // Lines of context kept above and below the cursor
+	filter       string
+	caseMode     CaseMode
+	showHidden   bool
+	sortMode     SortMode
+	sortReverse  bool
+	secondarySort SortMode // Orders entries that tie under sortMode
+	pairRules    PairRules         // Groups derived files with their source; nil when off
+	pairs        map[string]string // Derived file name -> source name in the listing
+	history      []string
+	historyIndex int
+	readErr      error  // Error from the last directory listing
+	removedDir   string // Directory that vanished and was left, if not yet reported
+	listedMtime  time.Time // Directory modification time at the last listing
+	listedSig    uint64    // DirSignature of the last listing
+	jumps        []JumpPos // Session jump list, oldest first
+	jumpIndex    int       // Position in the jump list; len(jumps) when at the tip
+	views        ViewMemory // Per-directory view states, if remembered
+	fsys         Filesystem // File system being browsed
+}
+
+// maxJumps limits the length of the jump list
+const maxJumps = 100
+
+// JumpPos is a position recorded in the jump list
+type JumpPos struct {
+	Dir  string
+	Name string // Entry under the cursor, if any
+}
+
+// NewNavigator creates a new filesystem navigator
+func NewNavigator() *Navigator {
+	currentDir, _ := os.Getwd()
+	nav := &Navigator{
+		currentDir:   currentDir,
+		cursor:       0,
+		scrollOffset: 0,
+		filter:       "",
+		showHidden:   false,
+		sortMode:     SortByName,
+		sortReverse:  false,
+		history:      []string{currentDir},
+		historyIndex: 0,
+		fsys:         OS{},
+	}
+	nav.RefreshFileList()
+	return nav
+}
+
+// GetCurrentDir returns the current directory
+func (n *Navigator) GetCurrentDir() string {
+	return n.currentDir
+}
+
+// SetCurrentDir sets the current directory
+func (n *Navigator) SetCurrentDir(dir string) {
+	n.RememberView()
+	if dir != n.currentDir {
+		n.historyIndex++
+		n.history = append(n.history[:n.historyIndex], dir)
+	}
+	n.currentDir = dir
+	n.cursor = 0
+	n.scrollOffset = 0
+	n.RestoreView()
+}
+
+// GetFileList returns the current file list
+func (n *Navigator) GetFileList() []os.FileInfo {
+	return n.fileList
+}
+
+// GetCursor returns the current cursor position
+func (n *Navigator) GetCursor() int {
+	return n.cursor
+}
+
+// SetCursor sets the cursor position
+func (n *Navigator) SetCursor(pos int) {
+	if pos >= 0 && pos < len(n.fileList) {
+		n.cursor = pos
+	}
+}
+
+// SelectByName moves the cursor to the entry with the given name, if present
+func (n *Navigator) SelectByName(name string, visibleLines int) bool {
+	for i, f := range n.fileList {
+		if f.Name() == name {
+			n.cursor = i
+			n.scrollToCursor(visibleLines)
+			return true
+		}
+	}
+	return false
+}
+
+// GetScrollOffset returns the scroll offset
+func (n *Navigator) GetScrollOffset() int {
+	return n.scrollOffset
+}
+
+// SetScrollOffset sets the scroll offset
+func (n *Navigator) SetScrollOffset(offset int) {
+	n.scrollOffset = offset
+}
+
+// SetScrollOff sets how many lines of context to keep above and below the
+// cursor while scrolling (like vim's scrolloff)
+func (n *Navigator) SetScrollOff(lines int) {
+	n.scrollOff = max(0, lines)
+}
+
+// scrollToCursor adjusts the scroll offset so the cursor is visible with up
+// to scrollOff lines around it. The margin shrinks to fit small windows.
+func (n *Navigator) scrollToCursor(visibleLines int) {
+	margin := min(n.scrollOff, (visibleLines-1)/2)
+	if n.cursor-margin < n.scrollOffset {
+		n.scrollOffset = n.cursor - margin
+	} else if n.cursor+margin >= n.scrollOffset+visibleLines {
+		n.scrollOffset = n.cursor + margin - visibleLines + 1
+	}
+	n.scrollOffset = max(0, min(n.scrollOffset, len(n.fileList)-visibleLines))
+}
+
+// GetFilter returns the current filter
+func (n *Navigator) GetFilter() string {
+	return n.filter
+}
+
+// SetFilter sets the filter and refreshes the file list
+func (n *Navigator) SetFilter(filter string) {
+	n.filter = filter
+	n.cursor = 0
+	n.RefreshFileList()
+}
+
+// GetCaseMode returns the filter case mode
+func (n *Navigator) GetCaseMode() CaseMode {
+	return n.caseMode
+}
+
+// SetCaseMode sets the filter case mode and refreshes the file list
+func (n *Navigator) SetCaseMode(mode CaseMode) {
+	n.caseMode = mode
+	n.RefreshFileList()
+}
+
+// ClearFilter clears the filter
+func (n *Navigator) ClearFilter() {
+	n.filter = ""
+	n.cursor = 0
+	n.scrollOffset = 0
+}
+
+// ToggleHidden toggles showing hidden files
+func (n *Navigator) ToggleHidden() {
+	n.showHidden = !n.showHidden
+	n.cursor = 0
+	n.RefreshFileList()
+}
+
+// GetShowHidden returns whether hidden files are shown
+func (n *Navigator) GetShowHidden() bool {
+	return n.showHidden
+}
+
+// SetSortMode sets the sorting mode and toggles reverse if same mode
+func (n *Navigator) SetSortMode(mode SortMode) {
+	if n.sortMode == mode {
+		// Toggle reverse if selecting the same mode
+		n.sortReverse = !n.sortReverse
+	} else {
+		// New mode, reset reverse
+		n.sortMode = mode
+		n.sortReverse = false
+	}
+	n.RefreshFileList()
+}
+
+// SetSecondarySort sets the sort mode that orders entries tying under the
+// main one, e.g. files of the same size
+func (n *Navigator) SetSecondarySort(mode SortMode) {
+	if mode != n.secondarySort {
+		n.secondarySort = mode
+		n.sortFileList()
+	}
+}
+
+// GetSortMode returns the current sorting mode
+func (n *Navigator) GetSortMode() SortMode {
+	return n.sortMode
+}
+
+// GetSortReverse returns whether sorting is reversed
+func (n *Navigator) GetSortReverse() bool {
+	return n.sortReverse
+}
+
+// GetSortModeName returns the display name of the current sort mode
+func (n *Navigator) GetSortModeName() string {
+	name := SortModeNames[n.sortMode]
+	if n.sortReverse {
+		name += " ↓"
+	}
+	return name
+}
+
+// RefreshFileList refreshes the file list based on current directory and filter
+func (n *Navigator) RefreshFileList() {
+	entries, err := n.fsys.ReadDir(n.currentDir)
+	n.readErr = err
+	if err != nil {
+		n.fileList = nil
+		if os.IsNotExist(err) {
+			n.RecoverMissingDir()
+		}
+		return
+	}
+	if info, err := n.fsys.Stat(n.currentDir); err == nil {
+		n.listedMtime = info.ModTime()
+	}
+	n.listedSig = DirSignature(entries)
+	
+	n.fileList = nil
+	for _, file := range entries {
+		name := file.Name()
+		
+		// Skip hidden files if not showing them
+		if !n.showHidden && strings.HasPrefix(name, ".") {
+			continue
+		}
+		
+		// Apply filter
+		if MatchName(name, n.filter, n.caseMode) {
+			n.fileList = append(n.fileList, file)
+		}
+	}
+	
+	// Sort based on current sort mode
+	n.sortFileList()
+	
+	// Adjust cursor if out of bounds
+	if n.cursor >= len(n.fileList) {
+		n.cursor = 0
+	}
+}
+
+// sortFileList sorts the file list based on the current sort mode, then
+// moves derived files after their source when pairing is on
+func (n *Navigator) sortFileList() {
+	SortFilesBy(n.fileList, n.sortMode, n.secondarySort, n.sortReverse)
+	n.pairs = nil
+	if n.pairRules != nil {
+		n.pairs = GroupPairs(n.fileList, n.pairRules)
+	}
+}
+
+// SetPairRules turns grouping of derived files with their source on with
+// the given rules, or off with nil
+func (n *Navigator) SetPairRules(rules PairRules) {
+	n.pairRules = rules
+	n.sortFileList()
+}
+
+// IsPairing reports whether derived files are grouped with their source
+func (n *Navigator) IsPairing() bool {
+	return n.pairRules != nil
+}
+
+// PairSource returns the name of the source file a listed file is grouped
+// with, or "" when it isn't a derived file
+func (n *Navigator) PairSource(name string) string {
+	return n.pairs[name]
+}
+
+// Companions returns the names of the files grouped with a source file
+func (n *Navigator) Companions(name string) []string {
+	var names []string
+	for _, file := range n.fileList {
+		if n.pairs[file.Name()] == name {
+			names = append(names, file.Name())
+		}
+	}
+	return names
+}
+
+// SortFiles sorts files in place by mode, directories first, breaking ties
+// by name
+func SortFiles(files []os.FileInfo, mode SortMode, reverse bool) {
+	SortFilesBy(files, mode, SortByName, reverse)
+}
+
+// SortFilesBy sorts files in place by mode, directories first. Entries that
+// tie are ordered by secondary, then by exact name, so the order is the same
+// on every refresh. reverse flips everything but the directories coming first.
+func SortFilesBy(files []os.FileInfo, mode, secondary SortMode, reverse bool) {
+	sort.SliceStable(files, func(i, j int) bool {
+		a, b := files[i], files[j]
+		if a.IsDir() != b.IsDir() {
+			return a.IsDir()
+		}
+		result := compareBy(mode, a, b)
+		if result == 0 {
+			result = compareBy(secondary, a, b)
+		}
+		if result == 0 {
+			result = strings.Compare(a.Name(), b.Name())
+		}
+		if reverse {
+			return result > 0
+		}
+		return result < 0
+	})
+}
+
+// compareBy compares two entries under a sort mode: names and types
+// ascending, sizes and modification times largest and newest first
+func compareBy(mode SortMode, a, b os.FileInfo) int {
+	switch mode {
+	case SortBySize:
+		return cmp.Compare(b.Size(), a.Size())
+	case SortByModTime:
+		return b.ModTime().Compare(a.ModTime())
+	case SortByExtension:
+		return strings.Compare(strings.ToLower(filepath.Ext(a.Name())), strings.ToLower(filepath.Ext(b.Name())))
+	default:
+		return strings.Compare(strings.ToLower(a.Name()), strings.ToLower(b.Name()))
+	}
+}
+
+// MoveUp moves the cursor up
+func (n *Navigator) MoveUp(visibleLines int) {
+	if n.cursor > 0 {
+		n.cursor--
+	} else if len(n.fileList) > 0 {
+		// Wrap to bottom
+		n.cursor = len(n.fileList) - 1
+	}
+	n.scrollToCursor(visibleLines)
+}
+
+// MoveDown moves the cursor down
+func (n *Navigator) MoveDown(visibleLines int) {
+	if n.cursor < len(n.fileList)-1 {
+		n.cursor++
+	} else if len(n.fileList) > 0 {
+		// Wrap to top
+		n.cursor = 0
+	}
+	n.scrollToCursor(visibleLines)
+}
+
+// ScrollView scrolls the list by delta lines without following the cursor,
+// which only moves when it would leave the view or its scrolloff margin.
+// It returns whether the cursor moved.
+func (n *Navigator) ScrollView(delta, visibleLines int) bool {
+	if len(n.fileList) == 0 || visibleLines <= 0 {
+		return false
+	}
+	maxOffset := max(0, len(n.fileList)-visibleLines)
+	n.scrollOffset = max(0, min(n.scrollOffset+delta, maxOffset))
+	
+	// The margin doesn't apply at either end of the list
+	margin := min(n.scrollOff, (visibleLines-1)/2)
+	top := n.scrollOffset + margin
+	bottom := n.scrollOffset + visibleLines - 1 - margin
+	if n.scrollOffset == 0 {
+		top = 0
+	}
+	if n.scrollOffset == maxOffset {
+		bottom = len(n.fileList) - 1
+	}
+	
+	previous := n.cursor
+	n.cursor = max(top, min(n.cursor, bottom))
+	return n.cursor != previous
+}
+
+// MoveUpFast moves the cursor up by 5 lines (Page Up)
+func (n *Navigator) MoveUpFast(visibleLines int) {
+	if len(n.fileList) == 0 {
+		return
+	}
+	
+	// Move up by 5 lines
+	n.cursor -= 5
+	if n.cursor < 0 {
+		n.cursor = 0
+	}
+	
+	n.scrollToCursor(visibleLines)
+}
+
+// MoveDownFast moves the cursor down by 5 lines (Page Down)
+func (n *Navigator) MoveDownFast(visibleLines int) {
+	if len(n.fileList) == 0 {
+		return
+	}
+	
+	// Move down by 5 lines
+	n.cursor += 5
+	if n.cursor >= len(n.fileList) {
+		n.cursor = len(n.fileList) - 1
+	}
+	
+	n.scrollToCursor(visibleLines)
+}
+
+// GoToParent navigates to the parent directory
+func (n *Navigator) GoToParent() bool {
+	parent := filepath.Dir(n.currentDir)
+	if parent != n.currentDir {
+		n.RememberView()
+		n.currentDir = parent
+		n.ClearFilter()
+		n.historyIndex++
+		n.history = append(n.history[:n.historyIndex], n.currentDir)
+		n.RestoreView()
+		return true
+	}
+	return false
+}
+
+// currentPos returns the current directory and cursor entry
+func (n *Navigator) currentPos() JumpPos {
+	pos := JumpPos{Dir: n.currentDir}
+	if file := n.GetSelectedFile(); file != nil {
+		pos.Name = file.Name()
+	}
+	return pos
+}
+
+// RecordJump adds the current position to the jump list. Call it before a
+// jump (bookmark, path edit, search result) so the jump can be undone.
+func (n *Navigator) RecordJump() {
+	n.pushJump(n.currentPos())
+	n.jumpIndex = len(n.jumps)
+}
+
+// pushJump appends a position, dropping an older entry for the same place
+func (n *Navigator) pushJump(pos JumpPos) {
+	for i, j := range n.jumps {
+		if j == pos {
+			n.jumps = append(n.jumps[:i], n.jumps[i+1:]...)
+			break
+		}
+	}
+	n.jumps = append(n.jumps, pos)
+	if len(n.jumps) > maxJumps {
+		n.jumps = n.jumps[len(n.jumps)-maxJumps:]
+	}
+}
+
+// JumpBack returns to the previous position in the jump list
+func (n *Navigator) JumpBack(visibleLines int) bool {
+	if n.jumpIndex == 0 || len(n.jumps) == 0 {
+		return false
+	}
+	// Leaving the tip: remember where we are so JumpForward can return here
+	if n.jumpIndex >= len(n.jumps) {
+		n.pushJump(n.currentPos())
+		n.jumpIndex = len(n.jumps) - 1
+		if n.jumpIndex == 0 {
+			return false
+		}
+	}
+	n.jumpIndex--
+	n.jumpTo(n.jumps[n.jumpIndex], visibleLines)
+	return true
+}
+
+// JumpForward moves to the next position in the jump list
+func (n *Navigator) JumpForward(visibleLines int) bool {
+	if n.jumpIndex+1 >= len(n.jumps) {
+		return false
+	}
+	n.jumpIndex++
+	n.jumpTo(n.jumps[n.jumpIndex], visibleLines)
+	return true
+}
+
+// jumpTo restores a recorded position
+func (n *Navigator) jumpTo(pos JumpPos, visibleLines int) {
+	if pos.Dir != n.currentDir {
+		n.ClearFilter()
+		n.SetCurrentDir(pos.Dir)
+	}
+	if pos.Name != "" {
+		n.SelectByName(pos.Name, visibleLines)
+	}
+}
+
+// GoBack returns to the previously visited directory
+func (n *Navigator) GoBack() bool {
+	return n.GoToHistory(n.historyIndex - 1)
+}
+
+// GoForward returns to the directory GoBack left, if any
+func (n *Navigator) GoForward() bool {
+	return n.GoToHistory(n.historyIndex + 1)
+}
+
+// GoToHistory moves to the directory at index in the history, keeping the
+// rest of the history, so GoBack and GoForward continue from there
+func (n *Navigator) GoToHistory(index int) bool {
+	if index < 0 || index >= len(n.history) || index == n.historyIndex {
+		return false
+	}
+	n.RememberView()
+	n.historyIndex = index
+	n.currentDir = n.history[index]
+	n.ClearFilter()
+	n.RestoreView()
+	return true
+}
+
+// GetHistory returns the visited directories, oldest first, and the index
+// of the current one
+func (n *Navigator) GetHistory() ([]string, int) {
+	return n.history, n.historyIndex
+}
+
+// GetPreviousDir returns the directory GoBack would return to, if any
+func (n *Navigator) GetPreviousDir() string {
+	if n.historyIndex == 0 {
+		return ""
+	}
+	return n.history[n.historyIndex-1]
+}
+
+// RecoverMissingDir moves to the nearest existing ancestor when the current
+// directory has been removed. It returns true if the directory changed.
+func (n *Navigator) RecoverMissingDir() bool {
+	if _, err := n.fsys.Stat(n.currentDir); !os.IsNotExist(err) {
+		return false
+	}
+	
+	missing := n.currentDir
+	dir := missing
+	for {
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return false // Nothing left to fall back to
+		}
+		dir = parent
+		if info, err := n.fsys.Stat(dir); err == nil && info.IsDir() {
+			break
+		}
+	}
+	
+	n.removedDir = missing
+	n.currentDir = dir
+	n.history[n.historyIndex] = dir
+	n.ClearFilter()
+	n.RefreshFileList()
+	return true
+}
+
+// TakeRemovedDir returns the directory left by RecoverMissingDir since the
+// last call, or "" if there is none
+func (n *Navigator) TakeRemovedDir() string {
+	removed := n.removedDir
+	n.removedDir = ""
+	return removed
+}
+
+// IsStale reports whether the directory changed on disk since it was listed
+func (n *Navigator) IsStale() bool {
+	info, err := n.fsys.Stat(n.currentDir)
+	if err != nil || n.readErr != nil {
+		return false
+	}
+	return !info.ModTime().Equal(n.listedMtime)
+}
+
+// Signature returns the DirSignature of the last listing, for watching the
+// directory for changes
+func (n *Navigator) Signature() uint64 {
+	return n.listedSig
+}
+
+// GetReadError returns the error from listing the current directory, if any
+func (n *Navigator) GetReadError() error {
+	return n.readErr
+}
+
+// IsPermissionDenied reports whether the current directory could not be read
+// due to missing permissions
+func (n *Navigator) IsPermissionDenied() bool {
+	return n.readErr != nil && os.IsPermission(n.readErr)
+}
+
+// EnterDirectory enters the selected directory
+func (n *Navigator) EnterDirectory() bool {
+	if len(n.fileList) > 0 {
+		selected := n.fileList[n.cursor]
+		if selected.IsDir() {
+			n.RememberView()
+			n.currentDir = filepath.Join(n.currentDir, selected.Name())
+			n.ClearFilter()
+			n.historyIndex++
+			n.history = append(n.history[:n.historyIndex], n.currentDir)
+			n.RestoreView()
+			return true
+		}
+	}
+	return false
+}
+
+// GetSelectedPath returns the full path of the selected file
+func (n *Navigator) GetSelectedPath() string {
+	if len(n.fileList) > 0 && n.cursor < len(n.fileList) {
+		return filepath.Join(n.currentDir, n.fileList[n.cursor].Name())
+	}
+	return ""
+}
+
+// GetSelectedFile returns the selected file info
+func (n *Navigator) GetSelectedFile() os.FileInfo {
+	if len(n.fileList) > 0 && n.cursor < len(n.fileList) {
+		return n.fileList[n.cursor]
+	}
+	return nil
+}
+
+// GetParentDir returns the parent directory
+func (n *Navigator) GetParentDir() string {
+	return filepath.Dir(n.currentDir)
+}
+
+// GetParentEntries returns filtered entries from the parent directory
+func (n *Navigator) GetParentEntries() []os.FileInfo {
+	parent := n.GetParentDir()
+	entries, err := n.fsys.ReadDir(parent)
+	if err != nil {
+		return nil
+	}
+	
+	var filtered []os.FileInfo
+	for _, f := range entries {
+		if !n.showHidden && strings.HasPrefix(f.Name(), ".") {
+			continue
+		}
+		filtered = append(filtered, f)
+	}
+	
+	sort.Slice(filtered, func(i, j int) bool {
+		return filtered[i].Name() < filtered[j].Name()
+	})
+	
+	return filtered
+}
+
+// MoveCursorToBestMatch moves cursor to the best matching file
+func (n *Navigator) MoveCursorToBestMatch(visibleLines int) {
+	if len(n.fileList) == 0 {
+		n.cursor = 0
+		n.scrollOffset = 0
+		return
+	}
+	
+	n.cursor = 0
+	
+	// Find first file matching filter
+	for i, file := range n.fileList {
+		if MatchName(file.Name(), n.filter, n.caseMode) {
+			n.cursor = i
+			break
+		}
+	}
+	
+	n.scrollToCursor(visibleLines)
+}
+
+// ExpandPath expands a leading ~ and resolves relative paths against base
+func ExpandPath(path, base string) string {
+	if path == "~" || strings.HasPrefix(path, "~"+string(os.PathSeparator)) {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[1:])
+		}
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(base, path)
+	}
+	return filepath.Clean(path)
+}
+
+// CompleteDir returns the subdirectories matching a partially typed path.
+// The last path element is matched as a case-insensitive prefix.
+func CompleteDir(input, base string, showHidden bool) []string {
+	dir, prefix := filepath.Split(input)
+	if dir == "" {
+		dir = "."
+	}
+	dir = ExpandPath(dir, base)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	lowerPrefix := strings.ToLower(prefix)
+	var matches []string
+	for _, e := range entries {
+		name := e.Name()
+		if !showHidden && strings.HasPrefix(name, ".") && !strings.HasPrefix(prefix, ".") {
+			continue
+		}
+		if !strings.HasPrefix(strings.ToLower(name), lowerPrefix) {
+			continue
+		}
+		fullPath := filepath.Join(dir, name)
+		if info, err := os.Stat(fullPath); err == nil && info.IsDir() {
+			matches = append(matches, fullPath)
+		}
+	}
+	return matches
+}
+
+// Helper functions
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// Made with Bob
+
+// Refresh refreshes the file list (alias for RefreshFileList)
+func (n *Navigator) Refresh() {
+	n.RefreshFileList()
+}
+
+// Reload re-reads the directory, keeping the cursor on the same entry, or
+// at the same position if the entry is gone
+func (n *Navigator) Reload(visibleLines int) {
+	selected, position := n.GetSelectedFile(), n.cursor
+	n.RefreshFileList()
+	if selected != nil && n.SelectByName(selected.Name(), visibleLines) {
+		return
+	}
+	n.cursor = max(0, min(position, len(n.fileList)-1))
+	n.scrollToCursor(visibleLines)
+}
@@ -0,0 +1,401 @@
+package filesystem
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ftpTimeout bounds each exchange with an FTP server
+const ftpTimeout = 15 * time.Second
+
+// FTP is a Filesystem on an FTP server, reached over one control
+// connection. It lists directories with MLSD when the server supports it
+// and parses Unix-style LIST output otherwise. Calls wait for each other;
+// while a file from Open is being read, other calls wait until it is
+// closed.
+type FTP struct {
+	mu   sync.Mutex
+	raw  net.Conn
+	conn *textproto.Conn
+	host string // Passive data connections go here, whatever address the server reports
+	mlsd bool   // The server supports MLSD and MLST
+}
+
+// DialFTP logs in to the FTP server at addr (host or host:port), as
+// anonymous when user is empty
+func DialFTP(addr, user, password string) (*FTP, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+		addr = net.JoinHostPort(addr, "21")
+	}
+	raw, err := net.DialTimeout("tcp", addr, ftpTimeout)
+	if err != nil {
+		return nil, err
+	}
+	f := &FTP{raw: raw, conn: textproto.NewConn(raw), host: host}
+	if err := f.login(user, password); err != nil {
+		f.conn.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// login greets the server, logs in and switches to binary transfers
+func (f *FTP) login(user, password string) error {
+	f.raw.SetDeadline(time.Now().Add(ftpTimeout))
+	if _, _, err := f.conn.ReadResponse(220); err != nil {
+		return err
+	}
+	if user == "" {
+		user, password = "anonymous", "anonymous@"
+	}
+	code, _, err := f.cmd(0, "USER %s", user)
+	if err != nil {
+		return err
+	}
+	if code == 331 {
+		code, _, err = f.cmd(0, "PASS %s", password)
+		if err != nil {
+			return err
+		}
+	}
+	if code != 230 {
+		return fmt.Errorf("FTP login as %s failed (%d)", user, code)
+	}
+	if _, _, err := f.cmd(200, "TYPE I"); err != nil {
+		return err
+	}
+	if _, features, err := f.cmd(211, "FEAT"); err == nil {
+		for _, line := range strings.Split(features, "\n") {
+			if strings.HasPrefix(strings.ToUpper(strings.TrimSpace(line)), "MLST") {
+				f.mlsd = true
+			}
+		}
+	}
+	return nil
+}
+
+// cmd sends a command and reads the reply, which must have expect as its
+// code (or first digits, 0 for any)
+func (f *FTP) cmd(expect int, format string, args ...interface{}) (int, string, error) {
+	f.raw.SetDeadline(time.Now().Add(ftpTimeout))
+	if err := f.conn.PrintfLine(format, args...); err != nil {
+		return 0, "", err
+	}
+	return f.conn.ReadResponse(expect)
+}
+
+// pathError turns a failed reply about p into an error, reporting "file
+// unavailable" replies as missing files
+func pathError(op, p string, err error) error {
+	var reply *textproto.Error
+	if errors.As(err, &reply) && reply.Code == 550 {
+		return notExist(op, p)
+	}
+	return &os.PathError{Op: op, Path: p, Err: err}
+}
+
+// transfer opens a passive data connection and starts the command that
+// uses it
+func (f *FTP) transfer(format string, args ...interface{}) (net.Conn, error) {
+	var port string
+	if _, msg, err := f.cmd(229, "EPSV"); err == nil {
+		// "Entering Extended Passive Mode (|||6446|)"
+		start, end := strings.Index(msg, "(|||"), strings.LastIndex(msg, "|)")
+		if start < 0 || end < start+4 {
+			return nil, fmt.Errorf("unexpected EPSV reply %q", msg)
+		}
+		port = msg[start+4 : end]
+	} else {
+		_, msg, err := f.cmd(227, "PASV")
+		if err != nil {
+			return nil, err
+		}
+		// "Entering Passive Mode (h1,h2,h3,h4,p1,p2)"
+		start, end := strings.Index(msg, "("), strings.LastIndex(msg, ")")
+		fields := strings.Split(msg[start+1:max(start+1, end)], ",")
+		if start < 0 || len(fields) != 6 {
+			return nil, fmt.Errorf("unexpected PASV reply %q", msg)
+		}
+		high, _ := strconv.Atoi(strings.TrimSpace(fields[4]))
+		low, _ := strconv.Atoi(strings.TrimSpace(fields[5]))
+		port = strconv.Itoa(high<<8 | low)
+	}
+	data, err := net.DialTimeout("tcp", net.JoinHostPort(f.host, port), ftpTimeout)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := f.cmd(1, format, args...); err != nil {
+		data.Close()
+		return nil, err
+	}
+	data.SetDeadline(time.Now().Add(ftpTimeout))
+	return data, nil
+}
+
+// finish reads the reply ending a transfer
+func (f *FTP) finish() error {
+	f.raw.SetDeadline(time.Now().Add(ftpTimeout))
+	_, _, err := f.conn.ReadResponse(2)
+	return err
+}
+
+// ReadDir lists dir sorted by name
+func (f *FTP) ReadDir(dir string) ([]os.FileInfo, error) {
+	dir = remotePath(dir)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	command := "LIST -a %s"
+	if f.mlsd {
+		command = "MLSD %s"
+	}
+	data, err := f.transfer(command, dir)
+	if err != nil {
+		return nil, pathError("readdir", dir, err)
+	}
+	var infos []os.FileInfo
+	scanner := bufio.NewScanner(data)
+	for scanner.Scan() {
+		var info *remoteInfo
+		if f.mlsd {
+			info = parseMLSx(scanner.Text())
+		} else {
+			info = parseList(scanner.Text(), time.Now())
+		}
+		if info != nil && info.name != "." && info.name != ".." {
+			infos = append(infos, info)
+		}
+	}
+	data.Close()
+	if err := f.finish(); err != nil {
+		return nil, pathError("readdir", dir, err)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name() < infos[j].Name() })
+	return infos, nil
+}
+
+// Stat describes p
+func (f *FTP) Stat(p string) (os.FileInfo, error) {
+	p = remotePath(p)
+	if p == "/" {
+		return &remoteInfo{name: "/", mode: os.ModeDir | 0755}, nil
+	}
+	f.mu.Lock()
+	if f.mlsd {
+		defer f.mu.Unlock()
+		_, msg, err := f.cmd(250, "MLST %s", p)
+		if err != nil {
+			return nil, pathError("stat", p, err)
+		}
+		// The facts are on the line between the start and end lines
+		for _, line := range strings.Split(msg, "\n") {
+			if info := parseMLSx(line); info != nil {
+				info.name = path.Base(p)
+				return info, nil
+			}
+		}
+		return nil, fmt.Errorf("unexpected MLST reply %q", msg)
+	}
+	f.mu.Unlock()
+
+	// Without MLST, find the entry in its directory's listing
+	infos, err := f.ReadDir(path.Dir(p))
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range infos {
+		if info.Name() == path.Base(p) {
+			return info, nil
+		}
+	}
+	return nil, notExist("stat", p)
+}
+
+// Open reads the file at p. Other calls wait until the reader is closed.
+func (f *FTP) Open(p string) (io.ReadCloser, error) {
+	p = remotePath(p)
+	f.mu.Lock()
+	data, err := f.transfer("RETR %s", p)
+	if err != nil {
+		f.mu.Unlock()
+		return nil, pathError("open", p, err)
+	}
+	return &ftpReader{f: f, data: data}, nil
+}
+
+// ftpReader reads one file and frees the connection when closed
+type ftpReader struct {
+	f    *FTP
+	data net.Conn
+	done bool
+}
+
+func (r *ftpReader) Read(b []byte) (int, error) {
+	r.data.SetDeadline(time.Now().Add(ftpTimeout))
+	return r.data.Read(b)
+}
+
+// Close ends the transfer. A transfer closed early may be reported as
+// failed by the server; that is not an error of the caller.
+func (r *ftpReader) Close() error {
+	if r.done {
+		return nil
+	}
+	r.done = true
+	defer r.f.mu.Unlock()
+	r.data.Close()
+	r.f.finish()
+	return nil
+}
+
+// Rename moves oldPath to newPath
+func (f *FTP) Rename(oldPath, newPath string) error {
+	oldPath, newPath = remotePath(oldPath), remotePath(newPath)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, _, err := f.cmd(350, "RNFR %s", oldPath); err != nil {
+		return pathError("rename", oldPath, err)
+	}
+	if _, _, err := f.cmd(250, "RNTO %s", newPath); err != nil {
+		return pathError("rename", newPath, err)
+	}
+	return nil
+}
+
+// Remove removes a file or an empty directory
+func (f *FTP) Remove(p string) error {
+	p = remotePath(p)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, _, err := f.cmd(250, "DELE %s", p)
+	if err != nil {
+		// Directories can't be deleted as files
+		if _, _, dirErr := f.cmd(250, "RMD %s", p); dirErr == nil {
+			return nil
+		}
+		return pathError("remove", p, err)
+	}
+	return nil
+}
+
+// Mkdir creates a directory. Servers decide its permissions.
+func (f *FTP) Mkdir(p string, perm os.FileMode) error {
+	p = remotePath(p)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, _, err := f.cmd(257, "MKD %s", p); err != nil {
+		return pathError("mkdir", p, err)
+	}
+	return nil
+}
+
+// Close logs out and closes the connection
+func (f *FTP) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.cmd(0, "QUIT")
+	return f.conn.Close()
+}
+
+// parseMLSx parses an MLSD line or MLST fact line, e.g.
+// "type=file;size=12;modify=20240101120000; notes.txt", returning nil for
+// other lines
+func parseMLSx(line string) *remoteInfo {
+	facts, name, ok := strings.Cut(strings.TrimLeft(line, " "), " ")
+	if !ok || !strings.Contains(facts, "=") {
+		return nil
+	}
+	info := &remoteInfo{name: path.Base(name), mode: 0644}
+	for _, fact := range strings.Split(facts, ";") {
+		key, value, _ := strings.Cut(fact, "=")
+		switch strings.ToLower(key) {
+		case "type":
+			switch strings.ToLower(value) {
+			case "dir", "cdir", "pdir":
+				info.mode = os.ModeDir | 0755
+			case "os.unix=symlink", "os.unix=slink":
+				info.mode = os.ModeSymlink | 0777
+			}
+			if strings.EqualFold(value, "cdir") || strings.EqualFold(value, "pdir") {
+				info.name = "."
+			}
+		case "size":
+			info.size, _ = strconv.ParseInt(value, 10, 64)
+		case "modify":
+			info.modTime, _ = time.Parse("20060102150405", value[:min(len(value), 14)])
+		case "unix.mode":
+			if perm, err := strconv.ParseUint(value, 8, 32); err == nil {
+				info.mode = info.mode&^os.ModePerm | os.FileMode(perm)&os.ModePerm
+			}
+		}
+	}
+	return info
+}
+
+// parseList parses a Unix-style LIST line, e.g.
+// "drwxr-xr-x 2 user group 4096 Jan 01 12:00 docs", returning nil for
+// lines it doesn't understand. Dates without a year are in the year
+// before now at most.
+func parseList(line string, now time.Time) *remoteInfo {
+	fields := strings.Fields(line)
+	if len(fields) < 9 || len(fields[0]) < 10 {
+		return nil
+	}
+	info := &remoteInfo{}
+	switch fields[0][0] {
+	case 'd':
+		info.mode = os.ModeDir
+	case 'l':
+		info.mode = os.ModeSymlink
+	case '-':
+	default:
+		return nil
+	}
+	for i, c := range fields[0][1:10] {
+		if c != '-' {
+			info.mode |= 1 << (8 - i)
+		}
+	}
+	info.size, _ = strconv.ParseInt(fields[4], 10, 64)
+
+	stamp := strings.Join(fields[5:8], " ")
+	if strings.Contains(fields[7], ":") {
+		modTime, err := time.Parse("Jan 2 15:04 2006", stamp+" "+strconv.Itoa(now.Year()))
+		if err == nil && modTime.After(now.AddDate(0, 0, 1)) {
+			modTime = modTime.AddDate(-1, 0, 0)
+		}
+		info.modTime = modTime
+	} else {
+		info.modTime, _ = time.Parse("Jan 2 2006", stamp)
+	}
+
+	// The name is the rest of the line after the date, without a link's target
+	rest := line
+	for _, field := range fields[:8] {
+		rest = strings.TrimLeft(rest, " ")
+		rest = rest[len(field):]
+	}
+	info.name = strings.TrimLeft(rest, " ")
+	if info.mode&os.ModeSymlink != 0 {
+		info.name, _, _ = strings.Cut(info.name, " -> ")
+	}
+	if info.name == "" {
+		return nil
+	}
+	return info
+}
@@ -0,0 +1,17 @@
+//go:build !unix
+
+package filesystem
+
+import "os"
+
+// IsForeign reports whether the file is owned by another user; ownership
+// isn't checked on this platform
+func IsForeign(info os.FileInfo) bool {
+	return false
+}
+
+// IsWorldWritable reports whether anyone may write to the file; Unix
+// permissions aren't available on this platform
+func IsWorldWritable(info os.FileInfo) bool {
+	return false
+}
@@ -0,0 +1,29 @@
+//go:build unix
+
+package filesystem
+
+import (
+	"os"
+	"syscall"
+)
+
+// currentUID is the user whose files aren't foreign
+var currentUID = uint32(os.Getuid())
+
+// IsForeign reports whether the file is owned by another user than the one
+// running the program
+func IsForeign(info os.FileInfo) bool {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	return ok && stat.Uid != currentUID
+}
+
+// IsWorldWritable reports whether anyone may write to the file. Symbolic
+// links, whose permissions are meaningless, and sticky directories such as
+// /tmp, where users can only remove their own files, don't count.
+func IsWorldWritable(info os.FileInfo) bool {
+	mode := info.Mode()
+	if mode&os.ModeSymlink != 0 || (mode.IsDir() && mode&os.ModeSticky != 0) {
+		return false
+	}
+	return mode.Perm()&0002 != 0
+}
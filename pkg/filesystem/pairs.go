@@ -0,0 +1,99 @@
+package filesystem
+
+import (
+	"os"
+	"sort"
+	"strings"
+)
+
+// PairRules maps the extension of a source file ("c", without the dot) to
+// the extensions of files derived from it ("o", "js.map"). A derived file
+// has the source's name with its extension replaced. Case is ignored.
+type PairRules map[string][]string
+
+// sorted returns the source extensions of rules, longest first so that
+// "d.ts" is tried before "ts"
+func (rules PairRules) sorted() []string {
+	sources := make([]string, 0, len(rules))
+	for source := range rules {
+		sources = append(sources, source)
+	}
+	sort.Slice(sources, func(i, j int) bool {
+		if len(sources[i]) != len(sources[j]) {
+			return len(sources[i]) > len(sources[j])
+		}
+		return sources[i] < sources[j]
+	})
+	return sources
+}
+
+// GroupPairs reorders files in place so that the files derived from a
+// source directly follow it, keeping the order otherwise. It returns the
+// name of the source each moved file belongs to. Files derived from a
+// derived file (foo.js.map from foo.js from foo.ts) join the first source.
+func GroupPairs(files []os.FileInfo, rules PairRules) map[string]string {
+	index := make(map[string]int, len(files))
+	for i, file := range files {
+		if !file.IsDir() {
+			index[strings.ToLower(file.Name())] = i
+		}
+	}
+
+	// Find the direct source of each derived file. A file never becomes
+	// derived from its own companions, so rules both ways (jpg and raw)
+	// make no cycles.
+	sourceOf := make(map[int]int)
+	root := func(i int) int {
+		for {
+			source, ok := sourceOf[i]
+			if !ok {
+				return i
+			}
+			i = source
+		}
+	}
+	sources := rules.sorted()
+	for i, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		name := strings.ToLower(file.Name())
+		for _, ext := range sources {
+			stem, ok := strings.CutSuffix(name, "."+strings.ToLower(strings.TrimPrefix(ext, ".")))
+			if !ok || stem == "" {
+				continue
+			}
+			for _, derived := range rules[ext] {
+				j, found := index[stem+"."+strings.ToLower(strings.TrimPrefix(derived, "."))]
+				if _, taken := sourceOf[j]; found && !taken && root(i) != j {
+					sourceOf[j] = i
+				}
+			}
+		}
+	}
+	if len(sourceOf) == 0 {
+		return nil
+	}
+
+	companions := make(map[int][]int)
+	for i := range files {
+		if _, derived := sourceOf[i]; derived {
+			companions[root(i)] = append(companions[root(i)], i)
+		}
+	}
+
+	pairs := make(map[string]string)
+	grouped := make([]os.FileInfo, 0, len(files))
+	for i, file := range files {
+		if _, derived := sourceOf[i]; derived {
+			continue
+		}
+		grouped = append(grouped, file)
+		for _, j := range companions[i] {
+			grouped = append(grouped, files[j])
+			pairs[files[j].Name()] = file.Name()
+		}
+	}
+	copy(files, grouped)
+	return pairs
+}
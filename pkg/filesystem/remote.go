@@ -0,0 +1,172 @@
+package filesystem
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+)
+
+// Remote is a Filesystem on another host, an FTP or WebDAV server. Its
+// paths are absolute paths on the server. Close ends the connection.
+type Remote interface {
+	Filesystem
+	io.Closer
+}
+
+// Dial connects to the server of an endpoint URL: ftp://host[:port]/dir for
+// FTP, and http(s)://host/dir or dav(s)://host/dir for WebDAV. The user
+// name comes from the URL and the password is passed on its own, so
+// endpoints can be saved without secrets.
+func Dial(endpoint, password string) (Remote, error) {
+	u, err := parseEndpoint(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "ftp":
+		return DialFTP(u.Host, u.User.Username(), password)
+	default:
+		return NewWebDAV(endpoint, password)
+	}
+}
+
+// CheckEndpoint reports what is wrong with an endpoint URL, if anything
+func CheckEndpoint(endpoint string) error {
+	_, err := parseEndpoint(endpoint)
+	return err
+}
+
+// RemoteDir returns the directory an endpoint URL starts in, "/" when it
+// names none
+func RemoteDir(endpoint string) string {
+	u, err := parseEndpoint(endpoint)
+	if err != nil {
+		return "/"
+	}
+	return remotePath(u.Path)
+}
+
+// parseEndpoint parses an endpoint URL and checks its scheme
+func parseEndpoint(endpoint string) (*url.URL, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "ftp", "http", "https", "dav", "davs":
+	default:
+		return nil, fmt.Errorf("%s: use ftp://, http(s):// or dav(s)://", endpoint)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("%s: missing host", endpoint)
+	}
+	if _, ok := u.User.Password(); ok {
+		return nil, fmt.Errorf("%s: keep the password out of the URL", endpoint)
+	}
+	return u, nil
+}
+
+// remotePath turns a path as the navigator builds it into a clean,
+// slash-separated absolute path on the server
+func remotePath(p string) string {
+	return path.Clean("/" + filepath.ToSlash(p))
+}
+
+// notExist reports a missing remote path the way os does, so callers can
+// test it with os.IsNotExist
+func notExist(op, p string) error {
+	return &os.PathError{Op: op, Path: p, Err: os.ErrNotExist}
+}
+
+// remoteInfo describes an entry on a server
+type remoteInfo struct {
+	name    string
+	size    int64
+	mode    os.FileMode
+	modTime time.Time
+}
+
+func (i *remoteInfo) Name() string       { return i.name }
+func (i *remoteInfo) Size() int64        { return i.size }
+func (i *remoteInfo) Mode() os.FileMode  { return i.mode }
+func (i *remoteInfo) ModTime() time.Time { return i.modTime }
+func (i *remoteInfo) IsDir() bool        { return i.mode.IsDir() }
+func (i *remoteInfo) Sys() interface{}   { return nil }
+
+// Download copies src from fsys into the local directory destDir, folders
+// with everything in them, and returns the local path. It stops between
+// files once ctx is canceled and never replaces an existing item.
+func Download(ctx context.Context, fsys Filesystem, src, destDir string) (string, error) {
+	info, err := fsys.Stat(src)
+	if err != nil {
+		return "", err
+	}
+	dest := filepath.Join(destDir, info.Name())
+	if _, err := os.Lstat(dest); err == nil {
+		return "", fmt.Errorf("%s already exists", dest)
+	}
+	return dest, download(ctx, fsys, src, dest, info)
+}
+
+// download copies one file or folder of fsys to dest
+func download(ctx context.Context, fsys Filesystem, src, dest string, info os.FileInfo) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if info.IsDir() {
+		entries, err := fsys.ReadDir(src)
+		if err != nil {
+			return err
+		}
+		if err := os.Mkdir(dest, 0755); err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if err := download(ctx, fsys, filepath.Join(src, entry.Name()), filepath.Join(dest, entry.Name()), entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	r, err := fsys.Open(src)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	f, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		os.Remove(dest)
+		return err
+	}
+	return f.Close()
+}
+
+// RemoveAll removes path from fsys with everything below it
+func RemoveAll(fsys Filesystem, path string) error {
+	info, err := fsys.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		entries, err := fsys.ReadDir(path)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if err := RemoveAll(fsys, filepath.Join(path, entry.Name())); err != nil {
+				return err
+			}
+		}
+	}
+	return fsys.Remove(path)
+}
@@ -0,0 +1,77 @@
+package filesystem
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// Filesystem is the file system a Navigator browses. The local disk is OS;
+// other implementations can serve archives, remote hosts or test fixtures
+// without the UI knowing the difference. Paths use the host's separator.
+type Filesystem interface {
+	ReadDir(dir string) ([]os.FileInfo, error) // Entries sorted by name
+	Stat(path string) (os.FileInfo, error)
+	Open(path string) (io.ReadCloser, error)
+	Rename(oldPath, newPath string) error
+	Remove(path string) error
+	Mkdir(path string, perm os.FileMode) error
+}
+
+// OS is the local file system
+type OS struct{}
+
+// ReadDir lists dir sorted by name
+func (OS) ReadDir(dir string) ([]os.FileInfo, error) {
+	return ioutil.ReadDir(dir)
+}
+
+// Stat returns the file info of path, following symlinks
+func (OS) Stat(path string) (os.FileInfo, error) {
+	return os.Stat(path)
+}
+
+// Open opens path for reading
+func (OS) Open(path string) (io.ReadCloser, error) {
+	return os.Open(path)
+}
+
+// Rename moves oldPath to newPath
+func (OS) Rename(oldPath, newPath string) error {
+	return os.Rename(oldPath, newPath)
+}
+
+// Remove removes a file or an empty directory
+func (OS) Remove(path string) error {
+	return os.Remove(path)
+}
+
+// Mkdir creates a directory
+func (OS) Mkdir(path string, perm os.FileMode) error {
+	return os.Mkdir(path, perm)
+}
+
+// SetFilesystem makes the navigator browse fsys and lists the current
+// directory again from it; nil means the local file system
+func (n *Navigator) SetFilesystem(fsys Filesystem) {
+	if fsys == nil {
+		fsys = OS{}
+	}
+	n.fsys = fsys
+	n.RefreshFileList()
+}
+
+// Mount makes the navigator browse dir on fsys, switching both at once so
+// neither is listed with the other; nil means the local file system
+func (n *Navigator) Mount(fsys Filesystem, dir string) {
+	if fsys == nil {
+		fsys = OS{}
+	}
+	n.fsys = fsys
+	n.SetCurrentDir(dir)
+}
+
+// Filesystem returns the file system the navigator browses
+func (n *Navigator) Filesystem() Filesystem {
+	return n.fsys
+}
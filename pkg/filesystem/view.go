@@ -0,0 +1,78 @@
+package filesystem
+
+// ViewState is how a directory was last shown: its sorting, whether hidden
+// files were visible and where the cursor was
+type ViewState struct {
+	SortMode     SortMode
+	SortReverse  bool
+	ShowHidden   bool
+	Selected     string // Entry under the cursor, if any
+	Cursor       int
+	ScrollOffset int
+}
+
+// ViewMemory keeps view states per directory, so a Navigator can show a
+// directory again the way it was left
+type ViewMemory interface {
+	Remember(dir string, state ViewState)
+	Recall(dir string) (ViewState, bool)
+}
+
+// SetViewMemory makes the navigator record the view of every directory it
+// leaves in memory and restore it when the directory is entered again; nil
+// turns this off
+func (n *Navigator) SetViewMemory(memory ViewMemory) {
+	n.views = memory
+}
+
+// View returns the view state of the current directory
+func (n *Navigator) View() ViewState {
+	view := ViewState{
+		SortMode:     n.sortMode,
+		SortReverse:  n.sortReverse,
+		ShowHidden:   n.showHidden,
+		Cursor:       n.cursor,
+		ScrollOffset: n.scrollOffset,
+	}
+	if file := n.GetSelectedFile(); file != nil {
+		view.Selected = file.Name()
+	}
+	return view
+}
+
+// RememberView records the view of the current directory in the view
+// memory, if any. Directory changes do this by themselves; call it before
+// quitting.
+func (n *Navigator) RememberView() {
+	if n.views != nil && n.readErr == nil {
+		n.views.Remember(n.currentDir, n.View())
+	}
+}
+
+// RestoreView lists the current directory again, with the view remembered
+// for it if there is one. The cursor returns to the remembered entry, at the
+// same row of the panel if the entry moved. Directory changes do this by
+// themselves; call it to restore the view of the starting directory.
+func (n *Navigator) RestoreView() {
+	var view ViewState
+	ok := false
+	if n.views != nil {
+		view, ok = n.views.Recall(n.currentDir)
+	}
+	if ok {
+		n.sortMode, n.sortReverse, n.showHidden = view.SortMode, view.SortReverse, view.ShowHidden
+	}
+	n.RefreshFileList()
+	if !ok || len(n.fileList) == 0 {
+		return
+	}
+
+	n.cursor = max(0, min(view.Cursor, len(n.fileList)-1))
+	for i, f := range n.fileList {
+		if f.Name() == view.Selected {
+			n.cursor = i
+			break
+		}
+	}
+	n.scrollOffset = max(0, min(view.ScrollOffset+n.cursor-view.Cursor, n.cursor))
+}
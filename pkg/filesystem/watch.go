@@ -0,0 +1,157 @@
+package filesystem
+
+import (
+	"hash/fnv"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+// DirSignature summarizes a directory listing: any entry created, removed,
+// resized, retyped or touched changes the signature
+func DirSignature(entries []os.FileInfo) uint64 {
+	h := fnv.New64a()
+	buf := make([]byte, 0, 64)
+	for _, entry := range entries {
+		buf = append(buf[:0], entry.Name()...)
+		buf = append(buf, 0)
+		buf = appendInt(buf, entry.Size())
+		buf = appendInt(buf, entry.ModTime().UnixNano())
+		buf = appendInt(buf, int64(entry.Mode()))
+		h.Write(buf)
+	}
+	return h.Sum64()
+}
+
+// appendInt appends v to buf as 8 little-endian bytes
+func appendInt(buf []byte, v int64) []byte {
+	for i := 0; i < 8; i++ {
+		buf = append(buf, byte(v>>(8*i)))
+	}
+	return buf
+}
+
+// Watcher polls directories in the background and reports those whose
+// listing no longer matches the signature they are watched with. Polling
+// works on every platform and filesystem, including network mounts.
+type Watcher struct {
+	mu       sync.Mutex
+	watched  map[string]uint64 // Directory -> signature of the listing on screen
+	changed  map[string]bool
+	interval time.Duration
+	notify   func()
+	stop     chan struct{}
+}
+
+// NewWatcher creates a watcher that checks every interval and calls notify
+// (from its own goroutine) when a directory has changed
+func NewWatcher(interval time.Duration, notify func()) *Watcher {
+	return &Watcher{
+		watched:  make(map[string]uint64),
+		changed:  make(map[string]bool),
+		interval: interval,
+		notify:   notify,
+	}
+}
+
+// Start begins polling. Calling Start on a running watcher does nothing.
+func (w *Watcher) Start() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stop != nil {
+		return
+	}
+	w.stop = make(chan struct{})
+	go w.run(w.stop)
+}
+
+// Stop ends polling and forgets pending changes
+func (w *Watcher) Stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stop != nil {
+		close(w.stop)
+		w.stop = nil
+	}
+	w.changed = make(map[string]bool)
+}
+
+// IsRunning reports whether the watcher is polling
+func (w *Watcher) IsRunning() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.stop != nil
+}
+
+// Watch replaces the watched directories, each with the signature of the
+// listing currently shown for it
+func (w *Watcher) Watch(dirs map[string]uint64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.watched = dirs
+}
+
+// Changed returns the directories that changed since they were watched and
+// clears them
+func (w *Watcher) Changed() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var dirs []string
+	for dir := range w.changed {
+		dirs = append(dirs, dir)
+	}
+	w.changed = make(map[string]bool)
+	return dirs
+}
+
+// run polls until stop is closed
+func (w *Watcher) run(stop chan struct{}) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if w.Poll() && w.notify != nil {
+				w.notify()
+			}
+		}
+	}
+}
+
+// Poll checks every watched directory once and reports whether any of them
+// newly changed. Directories that can't be read are skipped; the navigator
+// deals with those when it next lists them.
+func (w *Watcher) Poll() bool {
+	w.mu.Lock()
+	dirs := make(map[string]uint64, len(w.watched))
+	for dir, sig := range w.watched {
+		dirs[dir] = sig
+	}
+	w.mu.Unlock()
+
+	found := false
+	for dir, sig := range dirs {
+		entries, err := ioutil.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		current := DirSignature(entries)
+		if current == sig {
+			continue
+		}
+
+		w.mu.Lock()
+		// Only report it if the directory is still watched with the old
+		// listing, and watch the new one so it isn't reported twice
+		if watchedSig, ok := w.watched[dir]; ok && watchedSig == sig {
+			w.watched[dir] = current
+			w.changed[dir] = true
+			found = true
+		}
+		w.mu.Unlock()
+	}
+	return found
+}
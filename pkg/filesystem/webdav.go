@@ -0,0 +1,237 @@
+package filesystem
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// webdavTimeout bounds each request to a WebDAV server
+const webdavTimeout = 30 * time.Second
+
+// propfindBody asks for the properties a listing needs
+const propfindBody = `<?xml version="1.0" encoding="utf-8"?>
+<d:propfind xmlns:d="DAV:"><d:prop><d:resourcetype/><d:getcontentlength/><d:getlastmodified/></d:prop></d:propfind>`
+
+// WebDAV is a Filesystem on a WebDAV server, such as Nextcloud or a NAS.
+// Paths are the paths of the server's URLs.
+type WebDAV struct {
+	base     url.URL // Scheme and host of the endpoint
+	user     string
+	password string
+	client   *http.Client
+}
+
+// NewWebDAV prepares a WebDAV filesystem for an http(s):// or dav(s)://
+// endpoint and checks that it can be listed. The user comes from the URL.
+func NewWebDAV(endpoint, password string) (*WebDAV, error) {
+	u, err := parseEndpoint(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	w := &WebDAV{
+		base:     url.URL{Scheme: u.Scheme, Host: u.Host},
+		user:     u.User.Username(),
+		password: password,
+		client:   &http.Client{Timeout: webdavTimeout},
+	}
+	switch u.Scheme {
+	case "dav":
+		w.base.Scheme = "http"
+	case "davs":
+		w.base.Scheme = "https"
+	}
+	if _, err := w.Stat(u.Path); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// url returns the URL of a path on the server
+func (w *WebDAV) url(p string) string {
+	u := w.base
+	u.Path = p
+	return u.String()
+}
+
+// do sends a request for p and checks that the reply has one of the
+// expected status codes. The caller closes the body of the reply.
+func (w *WebDAV) do(op, method, p string, body io.Reader, header map[string]string, expect ...int) (*http.Response, error) {
+	req, err := http.NewRequest(method, w.url(p), body)
+	if err != nil {
+		return nil, err
+	}
+	if w.user != "" {
+		req.SetBasicAuth(w.user, w.password)
+	}
+	for key, value := range header {
+		req.Header.Set(key, value)
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return nil, &os.PathError{Op: op, Path: p, Err: err}
+	}
+	for _, code := range expect {
+		if resp.StatusCode == code {
+			return resp, nil
+		}
+	}
+	resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return nil, notExist(op, p)
+	case http.StatusUnauthorized:
+		return nil, fmt.Errorf("WebDAV login as %s failed", w.user)
+	}
+	return nil, &os.PathError{Op: op, Path: p, Err: fmt.Errorf("server replied %s", resp.Status)}
+}
+
+// davMultistatus is the reply to PROPFIND
+type davMultistatus struct {
+	Responses []struct {
+		Href      string `xml:"href"`
+		Propstats []struct {
+			Status string `xml:"status"`
+			Prop   struct {
+				ResourceType struct {
+					Collection *struct{} `xml:"collection"`
+				} `xml:"resourcetype"`
+				Length   string `xml:"getcontentlength"`
+				Modified string `xml:"getlastmodified"`
+			} `xml:"prop"`
+		} `xml:"propstat"`
+	} `xml:"response"`
+}
+
+// propfind describes p, and with depth "1" also its entries
+func (w *WebDAV) propfind(op, p, depth string) (map[string]*remoteInfo, error) {
+	resp, err := w.do(op, "PROPFIND", p, strings.NewReader(propfindBody),
+		map[string]string{"Depth": depth, "Content-Type": "application/xml"}, http.StatusMultiStatus)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var status davMultistatus
+	if err := xml.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return nil, &os.PathError{Op: op, Path: p, Err: err}
+	}
+
+	infos := make(map[string]*remoteInfo)
+	for _, response := range status.Responses {
+		href, err := url.Parse(response.Href)
+		if err != nil {
+			continue
+		}
+		entry := remotePath(href.Path)
+		info := &remoteInfo{name: path.Base(entry), mode: 0644}
+		for _, propstat := range response.Propstats {
+			if !strings.Contains(propstat.Status, " 200") {
+				continue
+			}
+			prop := propstat.Prop
+			if prop.ResourceType.Collection != nil {
+				info.mode = os.ModeDir | 0755
+			}
+			if prop.Length != "" {
+				info.size, _ = strconv.ParseInt(prop.Length, 10, 64)
+			}
+			if prop.Modified != "" {
+				info.modTime, _ = http.ParseTime(prop.Modified)
+			}
+		}
+		infos[entry] = info
+	}
+	return infos, nil
+}
+
+// ReadDir lists dir sorted by name
+func (w *WebDAV) ReadDir(dir string) ([]os.FileInfo, error) {
+	dir = remotePath(dir)
+	// Collections are addressed with a trailing slash, saving a redirect
+	infos, err := w.propfind("readdir", strings.TrimSuffix(dir, "/")+"/", "1")
+	if err != nil {
+		return nil, err
+	}
+	var entries []os.FileInfo
+	for entry, info := range infos {
+		if entry != dir {
+			entries = append(entries, info)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+// Stat describes p
+func (w *WebDAV) Stat(p string) (os.FileInfo, error) {
+	p = remotePath(p)
+	infos, err := w.propfind("stat", p, "0")
+	if err != nil {
+		return nil, err
+	}
+	info, ok := infos[p]
+	if !ok {
+		// Some servers answer for the collection URL with its slash
+		for _, other := range infos {
+			info = other
+		}
+	}
+	if info == nil {
+		return nil, notExist("stat", p)
+	}
+	return info, nil
+}
+
+// Open reads the file at p
+func (w *WebDAV) Open(p string) (io.ReadCloser, error) {
+	resp, err := w.do("open", http.MethodGet, remotePath(p), nil, nil, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Rename moves oldPath to newPath, refusing to replace an existing item
+func (w *WebDAV) Rename(oldPath, newPath string) error {
+	resp, err := w.do("rename", "MOVE", remotePath(oldPath), nil,
+		map[string]string{"Destination": w.url(remotePath(newPath)), "Overwrite": "F"},
+		http.StatusCreated, http.StatusNoContent)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// Remove removes a file or a directory. Servers remove directories with
+// everything in them.
+func (w *WebDAV) Remove(p string) error {
+	resp, err := w.do("remove", http.MethodDelete, remotePath(p), nil, nil,
+		http.StatusOK, http.StatusNoContent)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// Mkdir creates a directory. Servers decide its permissions.
+func (w *WebDAV) Mkdir(p string, perm os.FileMode) error {
+	resp, err := w.do("mkdir", "MKCOL", remotePath(p), nil, nil, http.StatusCreated)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// Close drops the idle connections to the server
+func (w *WebDAV) Close() error {
+	w.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,185 @@
+package tests
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"github.com/alexcostache/Xplorer/internal/preview"
+	"github.com/alexcostache/Xplorer/pkg/fileops"
+)
+
+func TestArchiveRoundTrip(t *testing.T) {
+	for _, name := range []string{"bundle.zip", "bundle.tar.gz"} {
+		t.Run(name, func(t *testing.T) {
+			root := t.TempDir()
+			src := filepath.Join(root, "project")
+			if err := os.MkdirAll(filepath.Join(src, "docs"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			files := map[string]string{
+				"main.go":                      "package main\n",
+				filepath.Join("docs", "a.md"): "# Docs\n",
+			}
+			for rel, content := range files {
+				if err := os.WriteFile(filepath.Join(src, rel), []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			notes := filepath.Join(root, "notes.txt")
+			if err := os.WriteFile(notes, []byte("notes"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			manager := fileops.NewManager()
+			archive := filepath.Join(root, name)
+			if err := manager.Compress([]string{src, notes}, archive); err != nil {
+				t.Fatal(err)
+			}
+			if err := manager.Compress([]string{notes}, archive); err == nil {
+				t.Error("expected an error overwriting an existing archive")
+			}
+
+			out := filepath.Join(root, "out")
+			if err := manager.Extract(archive, out); err != nil {
+				t.Fatal(err)
+			}
+			for rel, content := range files {
+				data, err := os.ReadFile(filepath.Join(out, "project", rel))
+				if err != nil || string(data) != content {
+					t.Errorf("%s: got %q, %v", rel, data, err)
+				}
+			}
+			if data, err := os.ReadFile(filepath.Join(out, "notes.txt")); err != nil || string(data) != "notes" {
+				t.Errorf("notes.txt: got %q, %v", data, err)
+			}
+
+			// Extracting again must not overwrite anything
+			if err := manager.Extract(archive, out); err == nil {
+				t.Error("expected an error extracting over existing files")
+			}
+		})
+	}
+}
+
+func TestExtractRejectsUnsafePaths(t *testing.T) {
+	root := t.TempDir()
+	archive := filepath.Join(root, "evil.zip")
+	f, err := os.Create(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	w, err := zw.Create("../escaped.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("gotcha"))
+	zw.Close()
+	f.Close()
+
+	out := filepath.Join(root, "out")
+	if err := fileops.NewManager().Extract(archive, out); err == nil {
+		t.Error("expected an error for an entry outside the destination")
+	}
+	if _, err := os.Stat(filepath.Join(root, "escaped.txt")); !os.IsNotExist(err) {
+		t.Error("entry was written outside the destination")
+	}
+}
+
+func TestExtractRejectsLinkChains(t *testing.T) {
+	// Each link stays inside the destination on its own, but the second
+	// is made through the first and points above it
+	root := t.TempDir()
+	archive := filepath.Join(root, "evil.tar.gz")
+	f, err := os.Create(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gw)
+	headers := []*tar.Header{
+		{Name: "d/", Typeflag: tar.TypeDir, Mode: 0755},
+		{Name: "d/l", Typeflag: tar.TypeSymlink, Linkname: ".."},
+		{Name: "d/l/x", Typeflag: tar.TypeSymlink, Linkname: ".."},
+		{Name: "d/l/x/pwned.txt", Typeflag: tar.TypeReg, Mode: 0644, Size: 6},
+	}
+	for _, header := range headers {
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tw.Write([]byte("gotcha"))
+	tw.Close()
+	gw.Close()
+	f.Close()
+
+	out := filepath.Join(root, "out")
+	if err := os.Mkdir(out, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := fileops.NewManager().Extract(archive, out); err == nil {
+		t.Error("expected an error for a link leading outside the destination")
+	}
+	for _, path := range []string{filepath.Join(root, "pwned.txt"), filepath.Join(out, "pwned.txt")} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("entry was written through a link to %s", path)
+		}
+	}
+}
+
+func TestArchiveStem(t *testing.T) {
+	tests := map[string]string{
+		"photos.tar.gz": "photos",
+		"site.TGZ":      "site",
+		"data.zip":      "data",
+		"notes.txt":     "notes.txt",
+	}
+	for input, want := range tests {
+		if got := fileops.ArchiveStem(input); got != want {
+			t.Errorf("ArchiveStem(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestArchivePreview(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "project")
+	if err := os.MkdirAll(filepath.Join(src, "docs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for rel, content := range map[string]string{"main.go": "package main\n", "docs/a.md": "# Docs\n"} {
+		if err := os.WriteFile(filepath.Join(src, rel), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m := preview.NewManager()
+	for _, name := range []string{"bundle.zip", "bundle.tar.gz"} {
+		archive := filepath.Join(root, name)
+		if err := fileops.NewManager().Compress([]string{src}, archive); err != nil {
+			t.Fatal(err)
+		}
+		m.LoadPreview(archive, false, 100)
+		lines := m.GetLines()
+		if len(lines) != 6 || !strings.HasPrefix(lines[0], "[Archive: 2 files, 2 folders, 20 B unpacked (") {
+			t.Fatalf("%s: unexpected preview %q", name, lines)
+		}
+		want := []string{"project/", "  docs/", "    a.md  7 B", "  main.go  13 B"}
+		if got := lines[2:]; strings.Join(got, "|") != strings.Join(want, "|") {
+			t.Errorf("%s: got tree %q, want %q", name, got, want)
+		}
+	}
+
+	// Only the first lines of the tree are kept
+	m.LoadPreview(filepath.Join(root, "bundle.zip"), false, 4)
+	if lines := m.GetLines(); len(lines) != 4 || lines[3] != "  docs/" {
+		t.Errorf("got cut preview %q", lines)
+	}
+	if got := m.Handler("site.tgz", nil); got != "Archive contents (tar.gz headers in the first 64.0 MB)" {
+		t.Errorf("unexpected handler %q", got)
+	}
+}
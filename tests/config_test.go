@@ -1,8 +1,14 @@
 package tests
 
 import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
 	"testing"
 	"github.com/alexcostache/Xplorer/internal/config"
+	"github.com/alexcostache/Xplorer/internal/paths"
+	"github.com/nsf/termbox-go"
 )
 
 func TestFileIcon(t *testing.T) {
@@ -64,24 +70,24 @@ func TestDescribeFileByExt(t *testing.T) {
 func TestConfigDefaults(t *testing.T) {
 	cfg := config.New()
 
-	if cfg.Keys.Filter == 0 {
+	if cfg.Keys.Filter == "" {
 		t.Error("Filter key should be set")
 	}
-	if cfg.Keys.ToggleHidden == 0 {
+	if cfg.Keys.ToggleHidden == "" {
 		t.Error("ToggleHidden key should be set")
 	}
-	if cfg.Keys.Quit == 0 {
+	if cfg.Keys.Quit == "" {
 		t.Error("Quit key should be set")
 	}
-	if cfg.Keys.TogglePath == 0 {
+	if cfg.Keys.TogglePath == "" {
 		t.Error("TogglePath key should be set")
 	}
 
-	if cfg.Keys.Filter != '/' {
-		t.Errorf("Expected Filter key to be '/', got %q", cfg.Keys.Filter)
+	if cfg.Keys.Filter != "/" {
+		t.Errorf("Expected Filter key to be '/', got %q", string(cfg.Keys.Filter))
 	}
-	if cfg.Keys.TogglePath != 'r' {
-		t.Errorf("Expected TogglePath key to be 'r', got %q", cfg.Keys.TogglePath)
+	if cfg.Keys.TogglePath != "r" {
+		t.Errorf("Expected TogglePath key to be 'r', got %q", string(cfg.Keys.TogglePath))
 	}
 
 	if !cfg.ShowRawPath {
@@ -89,6 +95,71 @@ func TestConfigDefaults(t *testing.T) {
 	}
 }
 
+func TestDetectGlyphSupport(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("glyph detection on Windows depends on the console host")
+	}
+
+	t.Setenv("TERM", "xterm-256color")
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_CTYPE", "")
+	t.Setenv("LANG", "en_US.UTF-8")
+	if !config.DetectGlyphSupport() {
+		t.Error("Expected glyph support with a UTF-8 locale")
+	}
+
+	t.Setenv("LANG", "C")
+	if config.DetectGlyphSupport() {
+		t.Error("Expected no glyph support with a non-UTF-8 locale")
+	}
+
+	t.Setenv("LANG", "en_US.UTF-8")
+	t.Setenv("TERM", "linux")
+	if config.DetectGlyphSupport() {
+		t.Error("Expected no glyph support on the Linux console")
+	}
+}
+
+func TestResolveGlyphMode(t *testing.T) {
+	cfg := &config.Config{GlyphMode: config.GlyphModeASCII}
+	cfg.ResolveGlyphMode()
+	if !cfg.SafeGlyphs {
+		t.Error("Expected ascii mode to enable safe glyphs")
+	}
+
+	cfg.GlyphMode = config.GlyphModeUnicode
+	cfg.ResolveGlyphMode()
+	if cfg.SafeGlyphs {
+		t.Error("Expected unicode mode to disable safe glyphs")
+	}
+}
+
+func TestWorkspaceRoot(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+	work := filepath.Join(home, "work")
+	repo := filepath.Join(work, "repo")
+	cfg := &config.Config{WorkspaceRoots: []string{"~/work", repo + string(filepath.Separator), "relative/ignored"}}
+
+	tests := []struct {
+		dir  string
+		want string
+	}{
+		{repo, repo},
+		{filepath.Join(repo, "src", "pkg"), repo},
+		{filepath.Join(work, "other"), work},
+		{filepath.Join(home, "workshop"), ""},
+		{home, ""},
+	}
+	for _, tt := range tests {
+		if got := cfg.WorkspaceRoot(tt.dir); got != tt.want {
+			t.Errorf("WorkspaceRoot(%q) = %q, want %q", tt.dir, got, tt.want)
+		}
+	}
+}
+
 func BenchmarkFileIcon(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		config.FileIcon("main.go", false, true)
@@ -96,3 +167,206 @@ func BenchmarkFileIcon(b *testing.B) {
 }
 
 // Made with Bob
+
+func TestValidateJSON(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		wantField string
+		wantLine  int
+	}{
+		{"valid", "{\n  \"editor_cmd\": \"vim\",\n  \"mouse_enabled\": false\n}", "", 0},
+		{"syntax error", "{\n  \"editor_cmd\": \"vim\"\n  \"mouse_enabled\": true\n}", "", 3},
+		{"wrong type", "{\n  \"mouse_enabled\": \"yes\"\n}", "mouse_enabled", 2},
+		{"bad enum", "{\n  \"editor_cmd\": \"vim\",\n  \"glyph_mode\": \"fancy\"\n}", "glyph_mode", 3},
+		{"list of strings", "{\n  \"preview_skip_extensions\": [\"log\", \".csv\"]\n}", "", 0},
+		{"list of numbers", "{\n  \"preview_skip_extensions\": [1]\n}", "preview_skip_extensions", 2},
+		{"negative count", "{\n  \"scrolloff\": -2\n}", "scrolloff", 2},
+		{"octal mode", "{\n  \"new_dir_mode\": \"2775\"\n}", "", 0},
+		{"bad mode", "{\n  \"new_file_mode\": \"0689\"\n}", "new_file_mode", 2},
+		{"command", "{\n  \"commands\": [{\"name\": \"Probe\", \"cmd\": \"ffprobe %f\", \"terminal\": true}]\n}", "", 0},
+		{"command without cmd", "{\n  \"commands\": [{\"name\": \"Probe\"}]\n}", "commands[0]", 2},
+		{"command with bad terminal", "{\n  \"commands\": [{\"name\": \"Probe\", \"cmd\": \"ffprobe\", \"terminal\": \"yes\"}]\n}", "commands[0].terminal", 2},
+		{"template", "{\n  \"templates\": [{\"name\": \"Note\", \"file\": \"note.md\", \"edit\": true}]\n}", "", 0},
+		{"template without file", "{\n  \"templates\": [{\"name\": \"Note\"}]\n}", "templates[0]", 2},
+		{"connection", "{\n  \"connections\": [{\"name\": \"NAS\", \"url\": \"davs://me@nas.local/dav/\"}]\n}", "", 0},
+		{"connection with password", "{\n  \"connections\": [{\"name\": \"NAS\", \"url\": \"ftp://me:pw@nas.local\"}]\n}", "connections[0].url", 2},
+		{"connection without url", "{\n  \"connections\": [{\"name\": \"NAS\"}]\n}", "connections[0]", 2},
+		{"typo", "{\n  \"editor_cdm\": \"vim\"\n}", "editor_cdm", 2},
+		{"unrelated unknown field", "{\n  \"future_setting\": 1\n}", "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := config.ValidateJSON("cfg.json", []byte(tt.data), config.ConfigSchema)
+			if tt.wantLine == 0 {
+				if len(problems) != 0 {
+					t.Errorf("Expected no problems, got %v", problems)
+				}
+				return
+			}
+			if len(problems) != 1 {
+				t.Fatalf("Expected one problem, got %v", problems)
+			}
+			if problems[0].Field != tt.wantField || problems[0].Line != tt.wantLine {
+				t.Errorf("Expected %s on line %d, got %s", tt.wantField, tt.wantLine, problems[0].Error())
+			}
+		})
+	}
+}
+
+func TestKeyNames(t *testing.T) {
+	tests := []struct {
+		name  string
+		ev    termbox.Event
+		want  config.Key
+		label string
+	}{
+		{"character", termbox.Event{Type: termbox.EventKey, Ch: 'Q'}, "Q", "Q"},
+		{"space", termbox.Event{Type: termbox.EventKey, Key: termbox.KeySpace}, "space", "Space"},
+		{"arrow", termbox.Event{Type: termbox.EventKey, Key: termbox.KeyArrowUp}, "up", "↑"},
+		{"function key", termbox.Event{Type: termbox.EventKey, Key: termbox.KeyF5}, "f5", "F5"},
+		{"ctrl", termbox.Event{Type: termbox.EventKey, Key: termbox.KeyCtrlS}, "ctrl+s", "Ctrl+S"},
+		{"backspace", termbox.Event{Type: termbox.EventKey, Key: termbox.KeyBackspace}, "backspace", "Bksp"},
+		{"esc", termbox.Event{Type: termbox.EventKey, Key: termbox.KeyEsc}, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := config.EventKey(tt.ev)
+			if got != tt.want || got.String() != tt.label {
+				t.Errorf("Expected %q (%s), got %q (%s)", string(tt.want), tt.label, string(got), got.String())
+			}
+		})
+	}
+
+	if key, ok := config.ParseKey("Ctrl+S"); !ok || key != "ctrl+s" {
+		t.Errorf("Expected Ctrl+S to parse as ctrl+s, got %q", string(key))
+	}
+	if key, ok := config.ParseKey("Alt+Left"); !ok || key != "alt+left" || key.String() != "Alt+←" {
+		t.Errorf("Expected Alt+Left to parse as alt+left, got %q (%s)", string(key), key.String())
+	}
+	if key, ok := config.ParseKey("alt+X"); !ok || key != "alt+X" {
+		t.Errorf("Expected alt+X to keep the character's case, got %q", string(key))
+	}
+	if _, ok := config.ParseKey("alt+alt+x"); ok {
+		t.Error("Expected a doubled Alt to be refused")
+	}
+	if key := config.AltKey(termbox.Event{Type: termbox.EventKey, Key: termbox.KeyArrowRight}); key != "alt+right" {
+		t.Errorf("Expected Esc followed by → to be alt+right, got %q", string(key))
+	}
+	if _, ok := config.ParseKey("hyper+x"); ok {
+		t.Error("Expected an unknown key name to be refused")
+	}
+}
+
+// portableConfig keeps the settings in a temporary directory for the test
+// and returns the path of its config file
+func portableConfig(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := paths.SetPortable(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(paths.Reset)
+	return config.GetConfigFilePath()
+}
+
+func TestSaveConfigFilePreservesUnknownFields(t *testing.T) {
+	path := portableConfig(t)
+	existing := `{"editor_cmd": "nano", "plugin_dir": "/opt/plugins", "glyph_mode": "ascii"}`
+	if err := os.WriteFile(path, []byte(existing), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{EditorCmd: "vim", GlyphMode: config.GlyphModeUnicode, MouseEnabled: true}
+	if err := config.SaveConfigFile(cfg); err != nil {
+		t.Fatalf("SaveConfigFile failed: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("Saved config is not valid JSON: %v", err)
+	}
+	if doc["plugin_dir"] != "/opt/plugins" {
+		t.Errorf("Expected unknown field to be preserved, got %v", doc["plugin_dir"])
+	}
+	if doc["editor_cmd"] != "vim" || doc["glyph_mode"] != config.GlyphModeUnicode {
+		t.Errorf("Expected known fields to be updated, got %v", doc)
+	}
+	if doc["mouse_enabled"] != true {
+		t.Errorf("Expected mouse_enabled to be written, got %v", doc["mouse_enabled"])
+	}
+}
+
+func TestSaveConfigFileKeepsInvalidFile(t *testing.T) {
+	path := portableConfig(t)
+	broken := `{"editor_cmd": "nano",`
+	if err := os.WriteFile(path, []byte(broken), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := config.SaveConfigFile(&config.Config{EditorCmd: "vim"}); err == nil {
+		t.Error("Expected an error when the existing config is invalid")
+	}
+	if data, _ := os.ReadFile(path); string(data) != broken {
+		t.Error("Invalid config file should not be overwritten")
+	}
+}
+
+func TestSaveConfigFileWritesRemappedKeys(t *testing.T) {
+	path := portableConfig(t)
+	cfg := config.New() // Default key bindings, as there is no config file yet
+	existing := `{"keys": {"quit": "Q"}}`
+	if err := os.WriteFile(path, []byte(existing), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg.EditorCmd = "vim"
+	cfg.Keys.Quit = "Q"
+	if err := config.SaveConfigFile(cfg); err != nil {
+		t.Fatalf("SaveConfigFile failed: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	var doc struct {
+		Keys map[string]string `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil || len(doc.Keys) != 1 || doc.Keys["quit"] != "Q" {
+		t.Errorf("Expected only the remapped key to be written, got %s", data)
+	}
+}
+
+func TestValidateKeys(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		wantField string
+	}{
+		{"remap", `{"keys": {"quit": "Q", "sort_menu": "F2"}}`, ""},
+		{"bad name", `{"keys": {"filter": "ctrl+/"}}`, "keys.filter"},
+		{"ctrl alias", `{"keys": {"filter": "ctrl+i"}}`, "keys.filter"},
+		{"conflict with default", `{"keys": {"filter": "G"}}`, "keys.filter"},
+		{"swap", `{"keys": {"filter": "G", "grep": "/"}}`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := portableConfig(t)
+			if err := os.WriteFile(path, []byte(tt.data), 0644); err != nil {
+				t.Fatal(err)
+			}
+			problems := config.ValidateConfigFile()
+			if tt.wantField == "" {
+				if len(problems) != 0 {
+					t.Errorf("Expected no problems, got %v", problems)
+				}
+				return
+			}
+			if len(problems) != 1 || problems[0].Field != tt.wantField {
+				t.Errorf("Expected one problem with %s, got %v", tt.wantField, problems)
+			}
+		})
+	}
+}
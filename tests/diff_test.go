@@ -0,0 +1,63 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"github.com/alexcostache/Xplorer/internal/diff"
+)
+
+func TestDiffUnified(t *testing.T) {
+	oldLines := strings.Split("a b c d e f g h i j k l", " ")
+	newLines := strings.Split("a b C d e f g h i j k l m", " ")
+
+	got := diff.Unified(diff.Lines(oldLines, newLines), 1)
+	want := []string{
+		"@@ -2,3 +2,3 @@",
+		" b",
+		"-c",
+		"+C",
+		" d",
+		"@@ -12,1 +12,2 @@",
+		" l",
+		"+m",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Unified() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	if hunks := diff.Unified(diff.Lines(oldLines, oldLines), 3); hunks != nil {
+		t.Errorf("expected no hunks for identical input, got %v", hunks)
+	}
+}
+
+func TestDiffFiles(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	original := write("config.yml", "port: 80\nhost: example\n")
+	backup := write("config.yml.bak", "port: 8080\nhost: example\n")
+	binary := write("image.bin", "\x00\x01\x02")
+
+	lines, err := diff.Files(backup, original)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) < 4 || lines[3] != "-port: 8080" || lines[4] != "+port: 80" {
+		t.Errorf("unexpected diff %q", lines)
+	}
+
+	if lines, _ := diff.Files(original, original); len(lines) != 1 || lines[0] != "Files are identical" {
+		t.Errorf("expected identical summary, got %q", lines)
+	}
+	if lines, _ := diff.Files(original, binary); len(lines) != 1 || !strings.HasPrefix(lines[0], "Binary files differ") {
+		t.Errorf("expected binary summary, got %q", lines)
+	}
+}
@@ -0,0 +1,725 @@
+package tests
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexcostache/Xplorer/internal/paths"
+	"github.com/alexcostache/Xplorer/internal/viewstate"
+	"github.com/alexcostache/Xplorer/pkg/filesystem"
+)
+
+func TestCompleteDir(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"project", "Projects", "photos", ".private"} {
+		if err := os.Mkdir(filepath.Join(tmpDir, name), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "profile.txt"), []byte("x"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	matches := filesystem.CompleteDir("pro", tmpDir, false)
+	if len(matches) != 2 {
+		t.Fatalf("Expected 2 matches, got %d: %v", len(matches), matches)
+	}
+	for _, m := range matches {
+		if filepath.Dir(m) != tmpDir {
+			t.Errorf("Expected absolute match inside %s, got %s", tmpDir, m)
+		}
+	}
+
+	// Hidden directories are only offered when explicitly typed
+	if matches := filesystem.CompleteDir(".pr", tmpDir, false); len(matches) != 1 {
+		t.Errorf("Expected hidden dir match, got %v", matches)
+	}
+}
+
+func TestExpandPath(t *testing.T) {
+	if got := filesystem.ExpandPath("sub/dir", "/base"); got != filepath.Clean("/base/sub/dir") {
+		t.Errorf("Expected relative path to resolve against base, got %s", got)
+	}
+	if got := filesystem.ExpandPath("/abs/path/", "/base"); got != filepath.Clean("/abs/path") {
+		t.Errorf("Expected absolute path to be kept, got %s", got)
+	}
+	home, err := os.UserHomeDir()
+	if err == nil {
+		if got := filesystem.ExpandPath("~/docs", "/base"); got != filepath.Join(home, "docs") {
+			t.Errorf("Expected ~ to expand to home, got %s", got)
+		}
+	}
+}
+
+func TestMatchName(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter string
+		mode   filesystem.CaseMode
+		want   bool
+	}{
+		{"README.md", "readme", filesystem.CaseInsensitive, true},
+		{"README.md", "readme", filesystem.CaseSensitive, false},
+		{"README.md", "README", filesystem.CaseSensitive, true},
+		{"README.md", "readme", filesystem.CaseSmart, true},
+		{"readme.md", "Readme", filesystem.CaseSmart, false},
+		{"Readme.md", "Readme", filesystem.CaseSmart, true},
+	}
+
+	for _, tt := range tests {
+		if got := filesystem.MatchName(tt.name, tt.filter, tt.mode); got != tt.want {
+			t.Errorf("MatchName(%q, %q, %v) = %v, want %v", tt.name, tt.filter, tt.mode, got, tt.want)
+		}
+	}
+
+	if got := filesystem.ParseCaseMode("Smart"); got != filesystem.CaseSmart {
+		t.Errorf("Expected smart case mode, got %v", got)
+	}
+	if got := filesystem.ParseCaseMode("bogus"); got != filesystem.CaseInsensitive {
+		t.Errorf("Expected unknown mode to default to insensitive, got %v", got)
+	}
+}
+
+func TestMatchRange(t *testing.T) {
+	start, end := filesystem.MatchRange("My Notes.txt", "notes", filesystem.CaseInsensitive)
+	if start != 3 || end != 8 {
+		t.Errorf("Expected match at [3, 8), got [%d, %d)", start, end)
+	}
+	start, end = filesystem.MatchRange("résumé.pdf", "mé", filesystem.CaseInsensitive)
+	if start != 4 || end != 6 {
+		t.Errorf("Expected rune offsets [4, 6), got [%d, %d)", start, end)
+	}
+	if start, _ := filesystem.MatchRange("notes.txt", "Notes", filesystem.CaseSmart); start != -1 {
+		t.Errorf("Expected no smart-case match, got %d", start)
+	}
+}
+
+func TestNavigatorGoBack(t *testing.T) {
+	tmpDir := t.TempDir()
+	subDir := filepath.Join(tmpDir, "sub")
+	if err := os.Mkdir(subDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	nav := filesystem.NewNavigator()
+	nav.SetCurrentDir(tmpDir)
+	nav.SetCurrentDir(subDir)
+
+	if got := nav.GetPreviousDir(); got != tmpDir {
+		t.Errorf("Expected previous dir %s, got %s", tmpDir, got)
+	}
+	if !nav.GoBack() || nav.GetCurrentDir() != tmpDir {
+		t.Errorf("Expected GoBack to return to %s, got %s", tmpDir, nav.GetCurrentDir())
+	}
+}
+
+func TestNavigatorHistory(t *testing.T) {
+	tmpDir := t.TempDir()
+	first := filepath.Join(tmpDir, "first")
+	second := filepath.Join(tmpDir, "second")
+	third := filepath.Join(tmpDir, "third")
+	for _, dir := range []string{first, second, third} {
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	nav := filesystem.NewNavigator()
+	nav.SetCurrentDir(first)
+	nav.SetCurrentDir(second)
+	if nav.GoForward() {
+		t.Error("Expected no forward history at the newest directory")
+	}
+	if !nav.GoBack() || !nav.GoForward() || nav.GetCurrentDir() != second {
+		t.Errorf("Expected back then forward to return to %s, got %s", second, nav.GetCurrentDir())
+	}
+
+	history, current := nav.GetHistory()
+	if !nav.GoToHistory(current-1) || nav.GetCurrentDir() != first {
+		t.Errorf("Expected GoToHistory to move to %s, got %s", first, nav.GetCurrentDir())
+	}
+	if next, _ := nav.GetHistory(); len(next) != len(history) {
+		t.Errorf("Expected GoToHistory to keep the history, got %v", next)
+	}
+
+	// Visiting a new directory drops the forward history
+	nav.SetCurrentDir(third)
+	if nav.GoForward() {
+		t.Error("Expected visiting a directory to clear forward history")
+	}
+	if !nav.GoBack() || nav.GetCurrentDir() != first {
+		t.Errorf("Expected GoBack to return to %s, got %s", first, nav.GetCurrentDir())
+	}
+}
+
+func TestNavigatorViewMemory(t *testing.T) {
+	if err := paths.SetPortable(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer paths.Reset()
+
+	tmpDir := t.TempDir()
+	docs := filepath.Join(tmpDir, "docs")
+	other := filepath.Join(tmpDir, "other")
+	os.Mkdir(other, 0755)
+	os.Mkdir(docs, 0755)
+	for _, name := range []string{"a.txt", "b.txt", "c.txt", ".hidden"} {
+		os.WriteFile(filepath.Join(docs, name), []byte(name), 0644)
+	}
+
+	nav := filesystem.NewNavigator()
+	nav.SetViewMemory(viewstate.NewManager())
+	nav.SetCurrentDir(docs)
+	nav.ToggleHidden()
+	nav.SetSortMode(filesystem.SortByName) // Same mode again: reversed
+	nav.SelectByName("b.txt", 10)
+	nav.SetCurrentDir(other)
+	if !nav.GetShowHidden() || !nav.GetSortReverse() {
+		t.Error("Expected a directory without a remembered view to keep the current view")
+	}
+	nav.ToggleHidden() // Changes only the view of other
+	nav.SetSortMode(filesystem.SortByName)
+
+	nav.GoBack()
+	if !nav.GetShowHidden() || !nav.GetSortReverse() {
+		t.Error("Expected hidden files and reverse sorting to be restored")
+	}
+	if file := nav.GetSelectedFile(); file == nil || file.Name() != "b.txt" {
+		t.Errorf("Expected the cursor on b.txt, got %v", file)
+	}
+
+	// The view survives a restart
+	os.WriteFile(filepath.Join(docs, "0.txt"), nil, 0644)
+	nav.SetCurrentDir(other)
+	restarted := filesystem.NewNavigator()
+	restarted.SetViewMemory(viewstate.NewManager())
+	restarted.SetCurrentDir(docs)
+	if file := restarted.GetSelectedFile(); file == nil || file.Name() != "b.txt" {
+		t.Errorf("Expected the cursor on b.txt after a restart, got %v", file)
+	}
+
+	// Without view memory, entering a directory doesn't restore anything
+	plain := filesystem.NewNavigator()
+	plain.SetCurrentDir(docs)
+	if plain.GetShowHidden() || plain.GetCursor() != 0 {
+		t.Error("Expected no view to be restored without view memory")
+	}
+}
+
+func TestOwnershipChecks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Unix permissions only")
+	}
+	tmpDir := t.TempDir()
+	cases := []struct {
+		name  string
+		mode  os.FileMode
+		dir   bool
+		wants bool
+	}{
+		{"private.txt", 0644, false, false},
+		{"shared.txt", 0666, false, true},
+		{"shared", 0777, true, true},
+		{"tmp", 0777 | os.ModeSticky, true, false},
+	}
+	for _, c := range cases {
+		path := filepath.Join(tmpDir, c.name)
+		if c.dir {
+			os.Mkdir(path, 0755)
+		} else {
+			os.WriteFile(path, nil, 0644)
+		}
+		// Chmod after creating, so the umask doesn't interfere
+		if err := os.Chmod(path, c.mode); err != nil {
+			t.Fatal(err)
+		}
+		info, err := os.Lstat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := filesystem.IsWorldWritable(info); got != c.wants {
+			t.Errorf("IsWorldWritable(%s) = %v, want %v", c.name, got, c.wants)
+		}
+		if filesystem.IsForeign(info) {
+			t.Errorf("Expected %s, created by this user, not to be foreign", c.name)
+		}
+	}
+	
+	// Symbolic links always look world-writable, which means nothing
+	link := filepath.Join(tmpDir, "link")
+	os.Symlink("private.txt", link)
+	if info, err := os.Lstat(link); err == nil && filesystem.IsWorldWritable(info) {
+		t.Error("Expected symbolic links not to count as world-writable")
+	}
+}
+
+// fakeInfo is a file in memFS
+type fakeInfo struct {
+	name string
+	dir  bool
+	size int64
+}
+
+func (f fakeInfo) Name() string       { return f.name }
+func (f fakeInfo) Size() int64        { return f.size }
+func (f fakeInfo) ModTime() time.Time { return time.Time{} }
+func (f fakeInfo) IsDir() bool        { return f.dir }
+func (f fakeInfo) Sys() interface{}   { return nil }
+func (f fakeInfo) Mode() os.FileMode {
+	if f.dir {
+		return os.ModeDir | 0755
+	}
+	return 0644
+}
+
+func TestSortFilesBy(t *testing.T) {
+	names := func(files []os.FileInfo) string {
+		var out []string
+		for _, f := range files {
+			out = append(out, f.Name())
+		}
+		return strings.Join(out, " ")
+	}
+	files := []os.FileInfo{
+		fakeInfo{name: "b.txt", size: 10},
+		fakeInfo{name: "B.go", size: 10},
+		fakeInfo{name: "a.md", size: 20},
+		fakeInfo{name: "c.go", size: 10},
+		fakeInfo{name: "dir", dir: true},
+	}
+
+	// Equal sizes are ordered by name, whatever order they came in
+	for i := 0; i < 3; i++ {
+		filesystem.SortFilesBy(files, filesystem.SortBySize, filesystem.SortByName, false)
+		if got := names(files); got != "dir a.md B.go b.txt c.go" {
+			t.Fatalf("size, then name: got %q", got)
+		}
+		files[1], files[3] = files[3], files[1]
+	}
+
+	filesystem.SortFilesBy(files, filesystem.SortBySize, filesystem.SortByExtension, false)
+	if got := names(files); got != "dir a.md B.go c.go b.txt" {
+		t.Errorf("size, then type: got %q", got)
+	}
+	filesystem.SortFilesBy(files, filesystem.SortBySize, filesystem.SortByName, true)
+	if got := names(files); got != "dir c.go b.txt B.go a.md" {
+		t.Errorf("reversed: got %q", got)
+	}
+
+	if filesystem.ParseSortKey("Modified") != filesystem.SortByModTime || filesystem.ParseSortKey("bogus") != filesystem.SortByName {
+		t.Error("unexpected ParseSortKey result")
+	}
+}
+
+func TestGroupPairs(t *testing.T) {
+	var files []os.FileInfo
+	for _, name := range []string{"a.js", "a.js.map", "a.ts", "b.c", "b.o", "lone.o", "photo.JPG", "photo.raw", "zz.raw"} {
+		files = append(files, fakeInfo{name: name})
+	}
+	files = append(files, fakeInfo{name: "b.c.o", dir: true})
+	rules := filesystem.PairRules{"ts": {"js", "js.map"}, "js": {"js.map"}, "c": {"o"}, "jpg": {"raw"}, "raw": {"jpg"}}
+
+	pairs := filesystem.GroupPairs(files, rules)
+	var names []string
+	for _, f := range files {
+		names = append(names, f.Name())
+	}
+	if got := strings.Join(names, " "); got != "a.ts a.js a.js.map b.c b.o lone.o photo.JPG photo.raw zz.raw b.c.o" {
+		t.Errorf("got order %q", got)
+	}
+	want := map[string]string{"a.js": "a.ts", "a.js.map": "a.ts", "b.o": "b.c", "photo.raw": "photo.JPG"}
+	if len(pairs) != len(want) {
+		t.Errorf("got pairs %v, want %v", pairs, want)
+	}
+	for derived, source := range want {
+		if pairs[derived] != source {
+			t.Errorf("%s: got source %q, want %q", derived, pairs[derived], source)
+		}
+	}
+}
+
+// memFS is an in-memory filesystem.Filesystem holding files and directories
+// by path; only listing and stat are needed to browse it
+type memFS map[string]fakeInfo
+
+func (m memFS) ReadDir(dir string) ([]os.FileInfo, error) {
+	if info, ok := m[dir]; !ok || !info.dir {
+		return nil, os.ErrNotExist
+	}
+	var entries []os.FileInfo
+	for path, info := range m {
+		if path != dir && filepath.Dir(path) == dir {
+			entries = append(entries, info)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+func (m memFS) Stat(path string) (os.FileInfo, error) {
+	if info, ok := m[path]; ok {
+		return info, nil
+	}
+	return nil, os.ErrNotExist
+}
+
+func (m memFS) Open(path string) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader("")), nil
+}
+func (m memFS) Rename(oldPath, newPath string) error      { return os.ErrPermission }
+func (m memFS) Remove(path string) error                  { return os.ErrPermission }
+func (m memFS) Mkdir(path string, perm os.FileMode) error { return os.ErrPermission }
+
+func TestNavigatorFilesystem(t *testing.T) {
+	root := filepath.Join(string(filepath.Separator), "virtual")
+	fsys := memFS{}
+	add := func(path string, dir bool) {
+		fsys[path] = fakeInfo{name: filepath.Base(path), dir: dir, size: 10}
+	}
+	add(filepath.Dir(root), true)
+	add(root, true)
+	add(filepath.Join(root, "notes.txt"), false)
+	add(filepath.Join(root, "src"), true)
+	add(filepath.Join(root, "src", "main.go"), false)
+
+	nav := filesystem.NewNavigator()
+	nav.SetFilesystem(fsys)
+	nav.SetCurrentDir(root)
+	if err := nav.GetReadError(); err != nil {
+		t.Fatalf("Expected the fake directory to be listed, got %v", err)
+	}
+	if got := len(nav.GetFileList()); got != 2 {
+		t.Fatalf("Expected 2 entries, got %d", got)
+	}
+
+	nav.SelectByName("src", 10)
+	if !nav.EnterDirectory() {
+		t.Fatal("Expected to enter the fake src directory")
+	}
+	if files := nav.GetFileList(); len(files) != 1 || files[0].Name() != "main.go" {
+		t.Errorf("Expected main.go in src, got %v", files)
+	}
+	if parent := nav.GetParentEntries(); len(parent) != 2 {
+		t.Errorf("Expected the parent panel to list 2 fake entries, got %d", len(parent))
+	}
+
+	// Leaving a directory that vanished falls back to its fake parent
+	delete(fsys, filepath.Join(root, "src", "main.go"))
+	delete(fsys, filepath.Join(root, "src"))
+	nav.RefreshFileList()
+	if nav.GetCurrentDir() != root {
+		t.Errorf("Expected to fall back to %s, got %s", root, nav.GetCurrentDir())
+	}
+
+	// Mounting lists the new directory only, never the old one on fsys
+	nav.TakeRemovedDir()
+	local := t.TempDir()
+	nav.Mount(nil, local)
+	nav.Mount(fsys, root)
+	if nav.TakeRemovedDir() != "" || nav.GetCurrentDir() != root || len(nav.GetFileList()) != 1 {
+		t.Errorf("Expected to mount %s with notes.txt, got %s", root, nav.GetCurrentDir())
+	}
+
+	nav.SetFilesystem(nil)
+	if _, ok := nav.Filesystem().(filesystem.OS); !ok {
+		t.Error("Expected nil to select the local file system")
+	}
+}
+
+func TestNavigatorReadError(t *testing.T) {
+	notDir := filepath.Join(t.TempDir(), "file.txt")
+	if err := os.WriteFile(notDir, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	nav := filesystem.NewNavigator()
+	nav.SetCurrentDir(notDir)
+	if nav.GetReadError() == nil {
+		t.Error("Expected read error for a non-directory")
+	}
+	if nav.IsPermissionDenied() {
+		t.Error("Non-directory should not be reported as permission denied")
+	}
+
+	if os.Geteuid() == 0 {
+		t.Skip("Permissions are not enforced for root")
+	}
+	locked := filepath.Join(t.TempDir(), "locked")
+	if err := os.Mkdir(locked, 0000); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chmod(locked, 0755)
+	nav.SetCurrentDir(locked)
+	if !nav.IsPermissionDenied() {
+		t.Error("Expected permission denied for unreadable directory")
+	}
+}
+
+func TestNavigatorRecoverMissingDir(t *testing.T) {
+	tmpDir := t.TempDir()
+	deepDir := filepath.Join(tmpDir, "a", "b", "c")
+	if err := os.MkdirAll(deepDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	nav := filesystem.NewNavigator()
+	nav.SetCurrentDir(deepDir)
+	if err := os.RemoveAll(filepath.Join(tmpDir, "a", "b")); err != nil {
+		t.Fatal(err)
+	}
+
+	nav.Refresh()
+	if got := nav.GetCurrentDir(); got != filepath.Join(tmpDir, "a") {
+		t.Errorf("Expected to move up to nearest existing ancestor, got %s", got)
+	}
+	if got := nav.TakeRemovedDir(); got != deepDir {
+		t.Errorf("Expected removed dir %s, got %s", deepDir, got)
+	}
+	if got := nav.TakeRemovedDir(); got != "" {
+		t.Errorf("Expected removed dir to be reported once, got %s", got)
+	}
+	if nav.RecoverMissingDir() {
+		t.Error("Expected no change when the current directory exists")
+	}
+}
+
+func TestNavigatorIsStale(t *testing.T) {
+	tmpDir := t.TempDir()
+	nav := filesystem.NewNavigator()
+	nav.SetCurrentDir(tmpDir)
+	if nav.IsStale() {
+		t.Error("Expected fresh listing not to be stale")
+	}
+
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(tmpDir, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if !nav.IsStale() {
+		t.Error("Expected listing to be stale after directory mtime changed")
+	}
+
+	nav.Refresh()
+	if nav.IsStale() {
+		t.Error("Expected refresh to clear stale state")
+	}
+}
+
+func TestCompareWith(t *testing.T) {
+	here := t.TempDir()
+	there := t.TempDir()
+	now := time.Now()
+
+	write := func(dir, name, content string, mtime time.Time) os.FileInfo {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+		info, _ := os.Stat(path)
+		return info
+	}
+
+	newer := write(here, "newer.txt", "a", now)
+	write(there, "newer.txt", "a", now.Add(-time.Hour))
+	older := write(here, "older.txt", "a", now.Add(-time.Hour))
+	write(there, "older.txt", "a", now)
+	resized := write(here, "resized.txt", "abc", now)
+	write(there, "resized.txt", "a", now)
+	same := write(here, "same.txt", "a", now)
+	write(there, "same.txt", "a", now)
+	only := write(here, "only.txt", "a", now)
+
+	tests := []struct {
+		info os.FileInfo
+		want filesystem.CompareResult
+	}{
+		{newer, filesystem.CompareNewer},
+		{older, filesystem.CompareOlder},
+		{resized, filesystem.CompareSizeDiffers},
+		{same, filesystem.CompareSame},
+		{only, filesystem.CompareOnlyHere},
+	}
+	for _, tt := range tests {
+		if got := filesystem.CompareWith(tt.info, there); got != tt.want {
+			t.Errorf("CompareWith(%s) = %v, want %v", tt.info.Name(), got, tt.want)
+		}
+	}
+}
+
+func TestNavigatorJumpList(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"a", "b", "c"} {
+		if err := os.Mkdir(filepath.Join(tmpDir, name), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "a", "two.txt"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "a", "one.txt"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	nav := filesystem.NewNavigator()
+	nav.SetCurrentDir(filepath.Join(tmpDir, "a"))
+	nav.SelectByName("two.txt", 10)
+
+	// Two jumps: a -> b -> c
+	nav.RecordJump()
+	nav.SetCurrentDir(filepath.Join(tmpDir, "b"))
+	nav.RecordJump()
+	nav.SetCurrentDir(filepath.Join(tmpDir, "c"))
+
+	if !nav.JumpBack(10) || nav.GetCurrentDir() != filepath.Join(tmpDir, "b") {
+		t.Fatalf("Expected first jump back to b, got %s", nav.GetCurrentDir())
+	}
+	if !nav.JumpBack(10) || nav.GetCurrentDir() != filepath.Join(tmpDir, "a") {
+		t.Fatalf("Expected second jump back to a, got %s", nav.GetCurrentDir())
+	}
+	if file := nav.GetSelectedFile(); file == nil || file.Name() != "two.txt" {
+		t.Errorf("Expected cursor restored to two.txt")
+	}
+	if nav.JumpBack(10) {
+		t.Error("Expected no jump before the oldest entry")
+	}
+
+	if !nav.JumpForward(10) || !nav.JumpForward(10) || nav.GetCurrentDir() != filepath.Join(tmpDir, "c") {
+		t.Errorf("Expected jumping forward to return to c, got %s", nav.GetCurrentDir())
+	}
+	if nav.JumpForward(10) {
+		t.Error("Expected no jump past the newest entry")
+	}
+}
+
+func TestNavigatorScrollOff(t *testing.T) {
+	tmpDir := t.TempDir()
+	for i := 0; i < 20; i++ {
+		name := filepath.Join(tmpDir, string(rune('a'+i))+".txt")
+		if err := os.WriteFile(name, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	nav := filesystem.NewNavigator()
+	nav.SetScrollOff(3)
+	nav.SetCurrentDir(tmpDir)
+
+	// With 10 visible lines the view starts scrolling 3 lines before the bottom
+	for i := 0; i < 6; i++ {
+		nav.MoveDown(10)
+	}
+	if got := nav.GetScrollOffset(); got != 0 {
+		t.Errorf("Expected no scrolling at cursor 6, got offset %d", got)
+	}
+	nav.MoveDown(10)
+	if got := nav.GetScrollOffset(); got != 1 {
+		t.Errorf("Expected offset 1 at cursor 7, got %d", got)
+	}
+
+	// Near the end of the list the margin gives way to the last entry
+	nav.MoveDownFast(10)
+	nav.MoveDownFast(10)
+	if nav.GetCursor() != 17 || nav.GetScrollOffset() != 10 {
+		t.Errorf("Expected cursor 17 at offset 10, got %d at %d", nav.GetCursor(), nav.GetScrollOffset())
+	}
+
+	// Moving up keeps 3 lines above the cursor
+	nav.SelectByName("l.txt", 10)
+	if got := nav.GetScrollOffset(); got != 8 {
+		t.Errorf("Expected offset 8 with l.txt at the margin, got %d", got)
+	}
+
+	// Wrapping to the top resets the view
+	nav.SetCursor(19)
+	nav.MoveDown(10)
+	if nav.GetCursor() != 0 || nav.GetScrollOffset() != 0 {
+		t.Errorf("Expected wrap to the top, got %d at %d", nav.GetCursor(), nav.GetScrollOffset())
+	}
+}
+
+func TestNavigatorScrollView(t *testing.T) {
+	tmpDir := t.TempDir()
+	for i := 0; i < 20; i++ {
+		name := filepath.Join(tmpDir, string(rune('a'+i))+".txt")
+		if err := os.WriteFile(name, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	nav := filesystem.NewNavigator()
+	nav.SetCurrentDir(tmpDir)
+	nav.SetCursor(5)
+
+	// The cursor stays put while it remains in view
+	if nav.ScrollView(3, 10) || nav.GetCursor() != 5 || nav.GetScrollOffset() != 3 {
+		t.Errorf("Expected cursor 5 at offset 3, got %d at %d", nav.GetCursor(), nav.GetScrollOffset())
+	}
+
+	// Scrolling past it drags the cursor to the top of the view
+	if !nav.ScrollView(6, 10) || nav.GetCursor() != 9 || nav.GetScrollOffset() != 9 {
+		t.Errorf("Expected cursor 9 at offset 9, got %d at %d", nav.GetCursor(), nav.GetScrollOffset())
+	}
+
+	// The view stops at the end of the list
+	nav.ScrollView(100, 10)
+	if got := nav.GetScrollOffset(); got != 10 {
+		t.Errorf("Expected offset clamped to 10, got %d", got)
+	}
+
+	// Scrolling back up keeps the scrolloff margin below the cursor
+	nav.SetScrollOff(2)
+	if !nav.ScrollView(-8, 10) || nav.GetCursor() != 9 || nav.GetScrollOffset() != 2 {
+		t.Errorf("Expected cursor 9 at offset 2, got %d at %d", nav.GetCursor(), nav.GetScrollOffset())
+	}
+}
+
+func TestWatcherPoll(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "b.txt"), []byte("one"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	nav := filesystem.NewNavigator()
+	nav.SetCurrentDir(tmpDir)
+	watcher := filesystem.NewWatcher(time.Hour, nil)
+	watcher.Watch(map[string]uint64{tmpDir: nav.Signature()})
+
+	if watcher.Poll() {
+		t.Fatal("Expected no change before touching the directory")
+	}
+
+	// Creating a file is reported once
+	if err := os.WriteFile(filepath.Join(tmpDir, "a.txt"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if !watcher.Poll() || watcher.Poll() {
+		t.Fatal("Expected the new file to be reported exactly once")
+	}
+	if changed := watcher.Changed(); len(changed) != 1 || changed[0] != tmpDir {
+		t.Fatalf("Expected %s to have changed, got %v", tmpDir, changed)
+	}
+
+	// Reloading keeps the cursor on the same entry
+	nav.SelectByName("b.txt", 10)
+	nav.Reload(10)
+	if file := nav.GetSelectedFile(); file == nil || file.Name() != "b.txt" {
+		t.Errorf("Expected the cursor to stay on b.txt")
+	}
+	watcher.Watch(map[string]uint64{tmpDir: nav.Signature()})
+
+	// Rewriting a file counts as a change too
+	if err := os.WriteFile(filepath.Join(tmpDir, "b.txt"), []byte("longer"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if !watcher.Poll() {
+		t.Error("Expected the modified file to be reported")
+	}
+}
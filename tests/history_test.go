@@ -0,0 +1,63 @@
+package tests
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/alexcostache/Xplorer/internal/history"
+	"github.com/alexcostache/Xplorer/internal/paths"
+)
+
+// historyPaths returns the remembered directories, sorted
+func historyPaths(m *history.Manager) []string {
+	var dirs []string
+	for _, e := range m.GetAll() {
+		dirs = append(dirs, e.Path)
+	}
+	sort.Strings(dirs)
+	return dirs
+}
+
+func TestHistoryFollowsMoves(t *testing.T) {
+	if err := paths.SetPortable(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer paths.Reset()
+
+	root := t.TempDir()
+	projects := filepath.Join(root, "projects")
+	tool := filepath.Join(projects, "app")
+	apps := filepath.Join(root, "apps")
+	m := history.NewManager()
+	defer m.Save()
+	for _, dir := range []string{projects, tool, apps} {
+		m.Visit(dir)
+	}
+
+	// A sibling sharing the name as a prefix is left alone
+	m.Move(projects, filepath.Join(root, "work"))
+	want := []string{apps, filepath.Join(root, "work"), filepath.Join(root, "work", "app")}
+	if got := historyPaths(m); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("after the move got %q, want %q", got, want)
+	}
+	// Changes are written in the background or by Save
+	if got := historyPaths(history.NewManager()); len(got) != 0 {
+		t.Errorf("expected nothing saved yet, got %q", got)
+	}
+	if err := m.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if got := historyPaths(history.NewManager()); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("expected the move to be saved, got %q", got)
+	}
+
+	m.Forget(filepath.Join(root, "work"))
+	if got := historyPaths(m); len(got) != 1 || got[0] != apps {
+		t.Errorf("after deleting got %q, want only %s", got, apps)
+	}
+	if got := m.Match("app"); len(got) != 1 || got[0] != apps {
+		t.Errorf("expected completion to offer only %s, got %q", apps, got)
+	}
+}
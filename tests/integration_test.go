@@ -0,0 +1,639 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+	"github.com/alexcostache/Xplorer/internal/app"
+	"github.com/alexcostache/Xplorer/internal/paths"
+	"github.com/alexcostache/Xplorer/internal/screen"
+	"github.com/alexcostache/Xplorer/internal/worker"
+	"github.com/nsf/termbox-go"
+)
+
+// settle is how long the app must wait for input before the screen is
+// checked; longer than the delay telling Esc from Alt combinations
+const settle = 40 * time.Millisecond
+
+// driver runs the whole application on a headless screen in a temporary
+// directory tree, with its settings in a temporary portable directory
+type driver struct {
+	t      *testing.T
+	screen *screen.Headless
+	root   string
+	done   chan error
+}
+
+// startApp creates files (a trailing "/" makes a directory) below a
+// temporary root and starts xp there on an 100x30 headless screen
+func startApp(t *testing.T, files ...string) *driver {
+	t.Helper()
+	return startAppWith(t, nil, files...)
+}
+
+// startAppWith starts xp like startApp, with the given files in its
+// settings directory first
+func startAppWith(t *testing.T, settings map[string]string, files ...string) *driver {
+	t.Helper()
+	root := t.TempDir()
+	for _, name := range files {
+		path := filepath.Join(root, name)
+		if strings.HasSuffix(name, "/") {
+			if err := os.MkdirAll(path, 0755); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("content of "+name+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	settingsDir := t.TempDir()
+	if err := paths.SetPortable(settingsDir); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range settings {
+		if err := os.WriteFile(filepath.Join(settingsDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wd, _ := os.Getwd()
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+	headless := screen.NewHeadless(100, 30)
+	previous := screen.Use(headless)
+
+	d := &driver{t: t, screen: headless, root: root, done: make(chan error, 1)}
+	application := app.New()
+	go func() { d.done <- application.Run() }()
+	t.Cleanup(func() {
+		if !headless.Closed() {
+			headless.Send(0, screen.Key(termbox.KeyEsc))
+			select {
+			case <-d.done:
+			case <-time.After(5 * time.Second):
+				t.Error("app did not quit")
+			}
+		}
+		screen.Use(previous)
+		os.Chdir(wd)
+		paths.Reset()
+	})
+	if !headless.WaitIdle(settle) {
+		t.Fatal("app did not start")
+	}
+	return d
+}
+
+// send feeds events to the app and waits until it has handled them
+func (d *driver) send(events ...termbox.Event) {
+	d.t.Helper()
+	if !d.screen.Send(settle, events...) {
+		d.t.Fatalf("app quit or hung after %v\n%s", events, d.screen.Text())
+	}
+}
+
+// pick chooses label in the open menu, whose top entry is first
+func (d *driver) pick(first, label string) {
+	d.t.Helper()
+	top, target := -1, -1
+	for y := 0; y < 30; y++ {
+		row := d.screen.Row(y)
+		if top < 0 && strings.Contains(row, first) {
+			top = y
+		}
+		if top >= 0 && target < 0 && strings.Contains(row, label) {
+			target = y
+		}
+	}
+	if top < 0 || target < 0 {
+		d.t.Fatalf("expected the menu to offer %s:\n%s", label, d.screen.Text())
+	}
+	for i := top; i < target; i++ {
+		d.send(screen.Key(termbox.KeyArrowDown))
+	}
+	d.send(screen.Key(termbox.KeyEnter))
+}
+
+// expect fails unless the screen shows text
+func (d *driver) expect(text string) {
+	d.t.Helper()
+	if screenText := d.screen.Text(); !strings.Contains(screenText, text) {
+		d.t.Errorf("expected the screen to show %q:\n%s", text, screenText)
+	}
+}
+
+// expectNot fails if the screen shows text
+func (d *driver) expectNot(text string) {
+	d.t.Helper()
+	if screenText := d.screen.Text(); strings.Contains(screenText, text) {
+		d.t.Errorf("expected the screen not to show %q:\n%s", text, screenText)
+	}
+}
+
+func TestIntegrationNavigation(t *testing.T) {
+	d := startApp(t, "alpha/inner.txt", "beta.txt", "gamma.go")
+	d.expect("alpha | ")
+
+	d.send(screen.Key(termbox.KeyArrowDown))
+	d.expect("beta.txt | ")
+	d.expect("content of beta.txt")
+
+	d.send(screen.Key(termbox.KeyArrowUp), screen.Key(termbox.KeyArrowRight))
+	d.expect(filepath.Join(d.root, "alpha"))
+	d.expect("inner.txt | ")
+
+	d.send(screen.Key(termbox.KeyArrowLeft))
+	d.expect("alpha | ")
+	d.expectNot("inner.txt | ")
+}
+
+func TestIntegrationFilterAndSelection(t *testing.T) {
+	d := startApp(t, "beta.txt", "gamma.go", "delta.go")
+
+	d.send(screen.Char('/'))
+	d.send(screen.Type("ga")...)
+	d.send(screen.Key(termbox.KeyEnter))
+	d.expect("gamma.go")
+	d.expectNot("beta.txt")
+
+	d.send(screen.Char(' '))
+	d.expect("✓")
+}
+
+func TestIntegrationPopupAndQuit(t *testing.T) {
+	d := startApp(t, "beta.txt")
+
+	d.send(screen.Key(termbox.KeyCtrlO))
+	d.expect("New Folder")
+	d.send(screen.Key(termbox.KeyEsc))
+	d.expectNot("New Folder")
+
+	// Quitting closes the screen
+	if d.screen.Send(settle, screen.Char('q')) || !d.screen.Closed() {
+		t.Error("expected q to quit")
+	}
+}
+
+func TestIntegrationNewFileAndEdit(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the stand-in editor is a shell script")
+	}
+	// The editor records the file it was asked to open
+	tools := t.TempDir()
+	opened := filepath.Join(tools, "opened")
+	editor := filepath.Join(tools, "edit.sh")
+	if err := os.WriteFile(editor, []byte("#!/bin/sh\necho \"$1\" > "+opened+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("EDITOR_CMD", editor)
+	d := startApp(t, "beta.txt")
+
+	d.send(screen.Key(termbox.KeyCtrlO))
+	d.pick("Copy Contents to Clipboard", "New File and Edit")
+	d.send(screen.Type("todo.md")...)
+	d.send(screen.Key(termbox.KeyEnter))
+	d.expect("todo.md | ")
+	waitForFile(t, opened, filepath.Join(d.root, "todo.md"))
+}
+
+func TestIntegrationNewFromTemplate(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the stand-in editor is a shell script")
+	}
+	tools := t.TempDir()
+	opened := filepath.Join(tools, "opened")
+	editor := filepath.Join(tools, "edit.sh")
+	if err := os.WriteFile(editor, []byte("#!/bin/sh\necho \"$1\" > "+opened+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("EDITOR_CMD", editor)
+	d := startAppWith(t, map[string]string{
+		"note.md":         "# Title\n",
+		".xp_config.json": `{"templates": [{"name": "Note", "file": "note.md", "edit": true}]}`,
+	}, "beta.txt")
+
+	// The name defaults to the template's, and the file opens in the editor
+	d.send(screen.Key(termbox.KeyCtrlO))
+	d.pick("Copy Contents to Clipboard", "New from Note")
+	d.expect("New file name: note.md")
+	d.send(screen.Key(termbox.KeyEnter))
+	d.expect("note.md | ")
+	created := filepath.Join(d.root, "note.md")
+	if data, err := os.ReadFile(created); err != nil || string(data) != "# Title\n" {
+		t.Errorf("expected a copy of the template, got %q, %v", data, err)
+	}
+	waitForFile(t, opened, created)
+}
+
+// waitForFile waits until the stand-in editor has recorded opening want
+func waitForFile(t *testing.T, opened, want string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		data, _ := os.ReadFile(opened)
+		if strings.TrimSpace(string(data)) == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the editor to open %s, got %q", want, data)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestIntegrationReplaceNeedsAllMatches(t *testing.T) {
+	d := startApp(t, "beta.txt")
+	if err := os.WriteFile(filepath.Join(d.root, "many.txt"), []byte(strings.Repeat("hit\n", 1001)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	d.send(screen.Char('G'))
+	d.send(screen.Type("hit")...)
+	d.send(screen.Key(termbox.KeyEnter))
+	d.expect("First 1000 matches")
+
+	// Replacing is the last entry, and refused for a cut-off search
+	d.send(screen.Key(termbox.KeyArrowUp))
+	d.expect("Replace in Files...")
+	d.send(screen.Key(termbox.KeyEnter))
+	d.expect("More than 1000 matches: narrow the search before replacing")
+	d.expectNot("Replace with")
+	d.send(screen.Key(termbox.KeyEsc))
+}
+
+func TestIntegrationCancelQueuedScan(t *testing.T) {
+	d := startApp(t, "beta.txt")
+
+	// Keep every worker of the shared pool busy, so the search stays queued
+	release := make(chan struct{})
+	t.Cleanup(func() { close(release) })
+	for i := 0; i < 4; i++ {
+		worker.Shared().Submit(fmt.Sprintf("test:busy:%d", i), func(ctx context.Context) { <-release })
+	}
+
+	d.send(screen.Char('G'))
+	d.send(screen.Type("hit")...)
+	d.send(screen.Key(termbox.KeyEnter))
+	d.expect("Searching for hit... (Esc to cancel)")
+
+	// Esc gives the UI back without waiting for a worker
+	d.send(screen.Key(termbox.KeyEsc))
+	d.send(screen.Char('G'))
+	d.expect("Grep [")
+	d.send(screen.Key(termbox.KeyEsc))
+}
+
+func TestIntegrationDryRunCommands(t *testing.T) {
+	d := startApp(t, "beta.txt")
+	events := []termbox.Event{screen.Char('P')}
+	for i := 0; i < 21; i++ {
+		events = append(events, screen.Key(termbox.KeyArrowDown))
+	}
+	d.send(append(events, screen.Key(termbox.KeyEnter), screen.Key(termbox.KeyEsc))...)
+
+	// The command is logged with its placeholders filled in, not run
+	d.send(screen.Char(':'))
+	d.send(screen.Type("touch made.txt %f")...)
+	d.send(screen.Key(termbox.KeyEnter))
+	d.expect("Dry run, not run: touch made.txt ")
+	d.send(screen.Key(termbox.KeyEsc))
+	if _, err := os.Stat(filepath.Join(d.root, "made.txt")); !os.IsNotExist(err) {
+		t.Error("expected the command not to run")
+	}
+	log, err := os.ReadFile(paths.File(".xp_dry_run.log"))
+	if err != nil || !strings.Contains(string(log), "in "+d.root+": touch made.txt ") || !strings.Contains(string(log), "beta.txt") {
+		t.Errorf("expected the command in the dry-run log, got %q, %v", log, err)
+	}
+}
+
+func TestIntegrationRangeSelection(t *testing.T) {
+	d := startApp(t, "a.txt", "b.txt", "c.txt", "d.txt")
+
+	d.send(screen.Char('V'), screen.Key(termbox.KeyArrowDown), screen.Key(termbox.KeyArrowDown))
+	d.expect("VISUAL")
+	d.expect("3 selected")
+
+	// Esc leaves visual mode and keeps the range
+	d.send(screen.Key(termbox.KeyEsc))
+	d.expectNot("VISUAL")
+	d.expect("3 selected")
+
+	// Shift+Up, sent as an xterm sequence, extends a new range upwards
+	d.send(screen.Char('-'))
+	d.send(append([]termbox.Event{screen.Key(termbox.KeyEsc)}, screen.Type("[1;2A")...)...)
+	d.expect("2 selected")
+	d.send(screen.Key(termbox.KeyEsc))
+	d.expectNot("VISUAL")
+}
+
+func TestIntegrationMouse(t *testing.T) {
+	d := startApp(t, "alpha.txt", "beta.txt", "gamma.txt")
+
+	d.send(screen.Click(40, 4))
+	d.expect("gamma.txt | ")
+}
+
+func TestIntegrationDragToParent(t *testing.T) {
+	d := startApp(t, "alpha.txt", "beta.txt")
+
+	// Drag alpha.txt from the list onto the parent panel
+	d.send(screen.Click(40, 2), screen.Drag(30, 6), screen.Drag(5, 10))
+	d.expect(" Move alpha.txt to ")
+	d.send(screen.Release(5, 10))
+	d.expect("completed! (1 files)")
+	if _, err := os.Stat(filepath.Join(filepath.Dir(d.root), "alpha.txt")); err != nil {
+		t.Errorf("alpha.txt was not moved to the parent folder: %v", err)
+	}
+}
+
+func TestIntegrationCaseClash(t *testing.T) {
+	d := startApp(t, "alpha.txt", "beta.txt")
+	parent := filepath.Dir(d.root)
+	if err := os.WriteFile(filepath.Join(parent, "ALPHA.TXT"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(parent, "alpha.txt")); err == nil {
+		t.Skip("the temporary directory is on a case-insensitive filesystem")
+	}
+
+	// Declining the warning leaves alpha.txt where it is
+	d.send(screen.Click(40, 2), screen.Drag(30, 6), screen.Drag(5, 10), screen.Release(5, 10))
+	d.expect("alpha.txt and ALPHA.TXT differ only by case")
+	d.send(screen.Char('n'))
+	if _, err := os.Stat(filepath.Join(d.root, "alpha.txt")); err != nil {
+		t.Errorf("alpha.txt was moved despite the warning: %v", err)
+	}
+}
+
+func TestIntegrationHoverHint(t *testing.T) {
+	d := startApp(t, "alpha.txt", "beta.txt")
+	cursorInfo := " alpha.txt | 21 B | -"
+	d.expect(cursorInfo)
+
+	// Hovering beta.txt describes it without moving the cursor off alpha.txt
+	d.send(screen.Hover(40, 3))
+	d.expect(" Mouse: beta.txt | ")
+	d.expectNot(cursorInfo)
+
+	// Leaving the list, or a key, brings back the cursor's file
+	d.send(screen.Hover(40, 0))
+	d.expect(cursorInfo)
+	d.send(screen.Hover(40, 3), screen.Key(termbox.KeyCtrlR))
+	d.expectNot("Mouse: ")
+	d.expect(cursorInfo)
+}
+
+func TestIntegrationPanelTitles(t *testing.T) {
+	d := startApp(t, "docs/guide.txt", "notes.txt")
+
+	// Each panel names what it shows: the parent and current folders and
+	// the previewed file with its size and encoding
+	d.expect("│ docs" + string(filepath.Separator))
+	d.send(screen.Key(termbox.KeyArrowDown))
+	d.expect("│ notes.txt | 21 B | ASCII")
+	d.send(screen.Key(termbox.KeyArrowUp), screen.Key(termbox.KeyArrowRight))
+	d.expect(filepath.Base(d.root) + string(filepath.Separator) + "docs")
+	d.expect("│ guide.txt | 26 B | ASCII")
+}
+
+func TestIntegrationDualPaneState(t *testing.T) {
+	d := startApp(t, "alpha/inner.txt", ".hidden", "beta.txt", "gamma.go")
+	// pane returns the rows of the left (0) or right (1) half of the screen
+	pane := func(side int) string {
+		var rows []string
+		for y := 1; y < 28; y++ {
+			row := []rune(d.screen.Row(y) + strings.Repeat(" ", 100))
+			rows = append(rows, string(row[side*50:(side+1)*50]))
+		}
+		return strings.Join(rows, "\n")
+	}
+
+	d.send(screen.Char('|'))
+	d.expect("Pane: Left")
+
+	// Hidden files and the filter belong to the focused pane
+	d.send(screen.Char('.'))
+	d.expect("Hidden: ON")
+	d.send(screen.Key(termbox.KeyTab))
+	d.expect("Pane: Right")
+	d.expect("Hidden: OFF")
+	d.send(screen.Char('/'))
+	d.send(screen.Type("gam")...)
+	d.send(screen.Key(termbox.KeyEnter))
+	if left, right := pane(0), pane(1); !strings.Contains(left, ".hidden") || !strings.Contains(left, "beta.txt") ||
+		strings.Contains(right, ".hidden") || strings.Contains(right, "beta.txt") || !strings.Contains(right, "gamma.go") {
+		t.Errorf("expected independent panes:\n%s", d.screen.Text())
+	}
+
+	// Mirroring shows the focused directory in the other pane, unfiltered
+	d.send(screen.Key(termbox.KeyTab), screen.Key(termbox.KeyArrowRight))
+	d.send(screen.Char('='))
+	if right := pane(1); !strings.Contains(right, "inner.txt") {
+		t.Errorf("expected the right pane to show alpha:\n%s", d.screen.Text())
+	}
+
+	// Swapping keeps the focus on the left, now on the other pane's state
+	d.expect("Hidden: ON")
+	d.send(screen.Key(termbox.KeyCtrlU))
+	d.expect("Pane: Left")
+	d.expect("Hidden: OFF")
+	d.send(screen.Key(termbox.KeyTab))
+	d.expect("Hidden: ON")
+}
+
+func TestIntegrationColumnHeader(t *testing.T) {
+	d := startApp(t, "big.txt", "small.txt")
+	d.expectNot("Name ▲")
+
+	// Turn the header on in the configuration menu
+	events := []termbox.Event{screen.Char('P')}
+	for i := 0; i < 14; i++ {
+		events = append(events, screen.Key(termbox.KeyArrowDown))
+	}
+	d.send(append(events, screen.Key(termbox.KeyEnter), screen.Key(termbox.KeyEsc))...)
+	d.expect("Name ▲")
+	d.expect("Type  Modified  Size")
+
+	// Clicking Size sorts the largest first, clicking it again reverses
+	d.send(screen.Click(58, 2))
+	d.expect("Size ▼")
+	if row := d.screen.Row(3); !strings.Contains(row, "small.txt") {
+		t.Errorf("expected small.txt first, got %q", row)
+	}
+	d.send(screen.Click(58, 2))
+	d.expect("Size ▲")
+	if row := d.screen.Row(3); !strings.Contains(row, "big.txt") {
+		t.Errorf("expected big.txt first, got %q", row)
+	}
+}
+
+func TestIntegrationShowSizes(t *testing.T) {
+	d := startApp(t, "alpha.txt", "beta.txt")
+	fileSizes := func() []termbox.Event {
+		events := []termbox.Event{screen.Char('P')}
+		for i := 0; i < 15; i++ {
+			events = append(events, screen.Key(termbox.KeyArrowDown))
+		}
+		return append(events, screen.Key(termbox.KeyEnter), screen.Key(termbox.KeyEsc))
+	}
+	rowHas := func(y int, text string, want bool) {
+		t.Helper()
+		if row := d.screen.Row(y); strings.Contains(row, text) != want {
+			t.Errorf("row %d %q: expected %q shown to be %v", y, row, text, want)
+		}
+	}
+	rowHas(2, "21 B│", true)
+	rowHas(3, "20 B│", true)
+
+	// Only the cursor row keeps its size, then no row does
+	d.send(fileSizes()...)
+	d.expect("alpha.txt | 21 B | -")
+	rowHas(2, "21 B│", true)
+	rowHas(3, "20 B│", false)
+	d.send(fileSizes()...)
+	rowHas(2, "21 B│", false)
+	rowHas(3, "20 B│", false)
+}
+
+func TestIntegrationTablePreview(t *testing.T) {
+	d := startApp(t, "data.csv")
+	table := "city,country,population,area,founded,mayor\n"
+	for i := 1; i <= 40; i++ {
+		table += fmt.Sprintf("Town%02d,Atlantis,12000,345.6,1850,Jane Doe\n", i)
+	}
+	if err := os.WriteFile(filepath.Join(d.root, "data.csv"), []byte(table), 0644); err != nil {
+		t.Fatal(err)
+	}
+	d.send(screen.Key(termbox.KeyCtrlR))
+	d.expect("40 rows x 6 columns")
+	d.expect("city    country   population")
+	d.expect("Town01  Atlantis")
+
+	// The header stays while the rows scroll down and to the right
+	d.send(screen.Char('{'))
+	d.expect("city    country")
+	d.expectNot("Town01")
+	d.expect("Town11  Atlantis")
+	d.send(screen.Char('>'))
+	d.expectNot("Town11")
+	d.expect("Atlantis")
+	d.send(screen.Char('<'))
+	d.expect("Town11  Atlantis")
+}
+
+func TestIntegrationTreemap(t *testing.T) {
+	d := startApp(t, "data/one.txt", "data/sub/two.txt", "zz.txt")
+
+	// The cursor starts on data; its preview turns into a treemap
+	d.expectNot("items, ")
+	d.send(screen.Char('Z'))
+	d.expect("2 items, ")
+	d.expect("sub")
+	d.send(screen.Char('Z'))
+	d.expectNot("items, ")
+}
+
+func TestIntegrationPairFiles(t *testing.T) {
+	d := startApp(t, "app.js", "app.ts", "notes.txt")
+	d.expect("app.js | ")
+	d.expectNot("└")
+
+	// app.js moves below its source and keeps the cursor
+	d.send(screen.Char('L'))
+	d.expect("└")
+	d.expect("app.js | ")
+	d.send(screen.Key(termbox.KeyArrowUp))
+	d.expect("app.ts | ")
+
+	d.send(screen.Char('L'))
+	d.expectNot("└")
+}
+
+func TestBenchmark(t *testing.T) {
+	root := t.TempDir()
+	os.Mkdir(filepath.Join(root, "sub"), 0755)
+	os.WriteFile(filepath.Join(root, "small.txt"), []byte("small\n"), 0644)
+	os.WriteFile(filepath.Join(root, "large.txt"), []byte(strings.Repeat("large\n", 100)), 0644)
+	if err := paths.SetPortable(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer paths.Reset()
+
+	var out strings.Builder
+	if err := app.New().Benchmark(root, &out); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"3 entries", "List directory", "Sort: Size", "Render frame", "large.txt (600 B)"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output lacks %q:\n%s", want, out.String())
+		}
+	}
+	if err := app.New().Benchmark(filepath.Join(root, "small.txt"), &out); err == nil {
+		t.Error("benchmarking a file should fail")
+	}
+}
+
+func TestIntegrationDirNoteBanner(t *testing.T) {
+	d := startApp(t, "docs/README.md", "plain/file.txt")
+
+	d.send(screen.Key(termbox.KeyArrowRight))
+	d.expect("✎ content of docs/README.md")
+
+	d.send(screen.Key(termbox.KeyArrowLeft), screen.Key(termbox.KeyArrowDown), screen.Key(termbox.KeyArrowRight))
+	d.expectNot("✎")
+}
+
+func TestIntegrationSoftDelete(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	d := startAppWith(t, map[string]string{
+		".xp_config.json": `{"soft_delete_days": 7}`,
+	}, "beta.txt")
+	deleted := filepath.Join(d.root, "beta.txt")
+
+	// Delete stages the file instead of trashing it
+	d.send(screen.Key(termbox.KeyCtrlO))
+	d.pick("Copy Contents to Clipboard", "Delete")
+	d.expect("to staging area")
+	d.send(screen.Char('y'))
+	waitForGone(t, deleted)
+
+	d.send(screen.Key(termbox.KeyCtrlO))
+	d.pick("Paste", "Restore from Trash")
+	d.expect("Trash is empty")
+	d.send(screen.Key(termbox.KeyEsc))
+
+	d.send(screen.Key(termbox.KeyCtrlO))
+	d.pick("Paste", "Restore from Staging Area")
+	d.expect("7d left")
+	d.send(screen.Key(termbox.KeyEnter))
+	d.pick("Restore", "Restore")
+	if _, err := os.Stat(deleted); err != nil {
+		t.Errorf("expected the staged file restored: %v", err)
+	}
+}
+
+// waitForGone waits until a background operation has removed path
+func waitForGone(t *testing.T, path string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Lstat(path); os.IsNotExist(err) {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %s to be removed", path)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
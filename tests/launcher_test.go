@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alexcostache/Xplorer/internal/launcher"
+	"github.com/alexcostache/Xplorer/internal/screen"
+	"github.com/nsf/termbox-go"
+)
+
+// shellLink builds a minimal .lnk file whose link info holds a local path
+func shellLink(base, suffix string) []byte {
+	data := make([]byte, 0x4C)
+	binary.LittleEndian.PutUint32(data, 0x4C)
+	binary.LittleEndian.PutUint32(data[0x14:], 1<<1) // HasLinkInfo
+
+	text := append(append([]byte(base), 0), append([]byte(suffix), 0)...)
+	info := make([]byte, 28, 28+len(text))
+	binary.LittleEndian.PutUint32(info[0:], uint32(28+len(text)))
+	binary.LittleEndian.PutUint32(info[4:], 28)
+	binary.LittleEndian.PutUint32(info[8:], 1)   // VolumeIDAndLocalBasePath
+	binary.LittleEndian.PutUint32(info[16:], 28) // LocalBasePath
+	binary.LittleEndian.PutUint32(info[24:], uint32(28+len(base)+1))
+	return append(data, append(info, text...)...)
+}
+
+func TestResolveShortcuts(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name    string
+		content []byte
+		want    launcher.Target
+	}{
+		{"site.url", []byte("[InternetShortcut]\r\nURL=https://example.com/docs\r\nIconIndex=0\r\n"), launcher.Target{URL: "https://example.com/docs"}},
+		{"site.webloc", []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0"><dict><key>URL</key><string>https://example.com/mac</string></dict></plist>`), launcher.Target{URL: "https://example.com/mac"}},
+		{"home.url", []byte("[InternetShortcut]\nURL=file:///tmp/notes\n"), launcher.Target{Path: filepath.FromSlash("/tmp/notes")}},
+		{"link.desktop", []byte("[Desktop Entry]\nType=Link\nName=Docs\nURL=https://example.com/linux\n"), launcher.Target{Name: "Docs", URL: "https://example.com/linux"}},
+		{"doc.lnk", shellLink(`C:\Users\me`, `doc.txt`), launcher.Target{Path: filepath.FromSlash("C:/Users/me/doc.txt")}},
+	}
+	for _, tt := range tests {
+		target, err := launcher.Resolve(write(tt.name, tt.content))
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if target.URL != tt.want.URL || target.Path != tt.want.Path || target.Name != tt.want.Name {
+			t.Errorf("%s: got %+v, want %+v", tt.name, *target, tt.want)
+		}
+	}
+
+	// Applications run their Exec line without field codes
+	target, err := launcher.Resolve(write("app.desktop", []byte("[Desktop Entry]\nType=Application\nName=Top\nExec=\"/opt/my app/top\" --mode=%%x %U\nTerminal=true\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(target.Command, "|") != "/opt/my app/top|--mode=%x" || !target.Terminal {
+		t.Errorf("unexpected application target %+v", *target)
+	}
+
+	if _, err := launcher.Resolve(write("empty.url", []byte("[InternetShortcut]\n"))); err == nil {
+		t.Error("expected an error for a shortcut without a URL")
+	}
+	if _, err := launcher.Resolve(write("bad.lnk", []byte("not a link"))); err == nil {
+		t.Error("expected an error for a broken shell link")
+	}
+	if launcher.IsShortcut("notes.txt") || !launcher.IsShortcut("Site.URL") {
+		t.Error("IsShortcut misjudges extensions")
+	}
+}
+
+func TestIntegrationOpenShortcut(t *testing.T) {
+	d := startApp(t, "a.url", "target/inside.txt")
+	shortcut := "[InternetShortcut]\nURL=file://" + filepath.ToSlash(filepath.Join(d.root, "target")) + "\n"
+	if err := os.WriteFile(filepath.Join(d.root, "a.url"), []byte(shortcut), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Enter on the shortcut shows the folder it points to
+	d.send(screen.Key(termbox.KeyArrowDown))
+	d.expect("a.url | ")
+	d.send(screen.Key(termbox.KeyEnter))
+	d.expect("inside.txt | ")
+}
@@ -0,0 +1,67 @@
+package tests
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alexcostache/Xplorer/internal/listing"
+)
+
+func TestListingWrite(t *testing.T) {
+	dir := t.TempDir()
+	os.Mkdir(filepath.Join(dir, "sub"), 0755)
+	os.WriteFile(filepath.Join(dir, "a, b.txt"), []byte("hello"), 0644)
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var text strings.Builder
+	if err := listing.Write(&text, dir, files, listing.Text); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{dir + "\n", "     5  ", "  a, b.txt\n", "  sub/\n"} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("text listing lacks %q:\n%s", want, text.String())
+		}
+	}
+
+	var csvOut strings.Builder
+	if err := listing.Write(&csvOut, dir, files, listing.CSV); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(strings.NewReader(csvOut.String())).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 || records[1][0] != "a, b.txt" || records[1][2] != "5" || records[2][1] != "dir" {
+		t.Errorf("unexpected CSV records: %q", records)
+	}
+
+	var jsonOut strings.Builder
+	if err := listing.Write(&jsonOut, dir, files, listing.JSON); err != nil {
+		t.Fatal(err)
+	}
+	var decoded struct {
+		Dir     string          `json:"dir"`
+		Entries []listing.Entry `json:"entries"`
+	}
+	if err := json.Unmarshal([]byte(jsonOut.String()), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Dir != dir || len(decoded.Entries) != 2 || decoded.Entries[1].Permissions[0] != 'd' {
+		t.Errorf("unexpected JSON listing: %+v", decoded)
+	}
+
+	if _, err := listing.ParseFormat("xml"); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+	if format, _ := listing.ParseFormat("TEXT"); format != listing.Text {
+		t.Error("Expected format names to ignore case")
+	}
+}
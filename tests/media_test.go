@@ -0,0 +1,166 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alexcostache/Xplorer/internal/preview"
+)
+
+// id3Frame builds an ID3v2.3 text frame with a UTF-8 value
+func id3Frame(id, value string) []byte {
+	frame := []byte(id)
+	frame = binary.BigEndian.AppendUint32(frame, uint32(len(value)+1))
+	frame = append(frame, 0, 0, 3)
+	return append(frame, value...)
+}
+
+// mp4Box builds an MP4 box of a type holding the given bodies
+func mp4Box(kind string, bodies ...[]byte) []byte {
+	body := bytes.Join(bodies, nil)
+	box := binary.BigEndian.AppendUint32(nil, uint32(8+len(body)))
+	return append(append(box, kind...), body...)
+}
+
+// exifJPEG encodes a small JPEG carrying a big-endian EXIF segment
+func exifJPEG(t *testing.T) []byte {
+	var img bytes.Buffer
+	if err := jpeg.Encode(&img, image.NewGray(image.Rect(0, 0, 40, 30)), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// IFD0: Make, Model and the EXIF IFD pointer; EXIF IFD: exposure,
+	// f-number and ISO. Offsets are from the start of the TIFF header.
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08")
+	entry := func(tag, kind uint16, count, value uint32) []byte {
+		b := binary.BigEndian.AppendUint16(nil, tag)
+		b = binary.BigEndian.AppendUint16(b, kind)
+		b = binary.BigEndian.AppendUint32(b, count)
+		return binary.BigEndian.AppendUint32(b, value)
+	}
+	const exifIFD, text, rationals = 8 + 2 + 3*12 + 4, 100, 140
+	tiff = binary.BigEndian.AppendUint16(tiff, 3)
+	tiff = append(tiff, entry(0x010F, 2, 6, text)...)     // "Canon"
+	tiff = append(tiff, entry(0x0110, 2, 13, text+6)...)  // "Canon EOS R5"
+	tiff = append(tiff, entry(0x8769, 4, 1, exifIFD)...)
+	tiff = append(tiff, 0, 0, 0, 0)
+	tiff = binary.BigEndian.AppendUint16(tiff, 3)
+	tiff = append(tiff, entry(0x829A, 5, 1, rationals)...)
+	tiff = append(tiff, entry(0x829D, 5, 1, rationals+8)...)
+	tiff = append(tiff, entry(0x8827, 3, 1, 400<<16)...)
+	tiff = append(tiff, 0, 0, 0, 0)
+	tiff = append(tiff, make([]byte, text-len(tiff))...)
+	tiff = append(tiff, "Canon\x00Canon EOS R5\x00"...)
+	tiff = append(tiff, make([]byte, rationals-len(tiff))...)
+	for _, v := range []uint32{1, 200, 28, 10} {
+		tiff = binary.BigEndian.AppendUint32(tiff, v)
+	}
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := append([]byte{0xFF, 0xE1}, binary.BigEndian.AppendUint16(nil, uint16(len(segment)+2))...)
+	data := img.Bytes()
+	return append(append(append([]byte{}, data[:2]...), append(app1, segment...)...), data[2:]...)
+}
+
+func TestMediaPreview(t *testing.T) {
+	// Use the built-in readers even where ffprobe is installed
+	t.Setenv("PATH", "")
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(t.TempDir(), "cache"))
+	tmpDir := t.TempDir()
+	write := func(name string, data []byte) string {
+		path := filepath.Join(tmpDir, name)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	// 128 kb/s MPEG-1 layer III: one second of audio is 16000 bytes
+	frames := bytes.Join([][]byte{id3Frame("TIT2", "Song"), id3Frame("TPE1", "Band")}, nil)
+	tag := append([]byte("ID3\x03\x00\x00"), 0, 0, byte(len(frames)>>7), byte(len(frames)&0x7F))
+	audio := make([]byte, 16000*65)
+	copy(audio, []byte{0xFF, 0xFB, 0x90, 0x00})
+	mp3 := write("song.mp3", append(append(tag, frames...), audio...))
+
+	// FLAC stream info for 3 seconds at 48 kHz, 24 bit stereo
+	info := make([]byte, 18)
+	info[10], info[11], info[12] = 48000>>12, 48000>>4&0xFF, byte(48000&0x0F)<<4|1<<1|(23>>4)
+	info[13] = 23 & 0x0F << 4
+	binary.BigEndian.PutUint32(info[14:], 3*48000)
+	comments := binary.LittleEndian.AppendUint32(nil, 0)
+	comments = binary.LittleEndian.AppendUint32(comments, 1)
+	comments = binary.LittleEndian.AppendUint32(comments, uint32(len("ALBUM=Live")))
+	comments = append(comments, "ALBUM=Live"...)
+	flacData := []byte("fLaC")
+	flacData = append(flacData, 0, 0, 0, 18)
+	flacData = append(flacData, info...)
+	flacData = append(flacData, 0x84, 0, 0, byte(len(comments)))
+	flac := write("live.flac", append(flacData, comments...))
+
+	// Two seconds of 8 kHz 16 bit mono PCM
+	wav := []byte("RIFF\x00\x00\x00\x00WAVEfmt \x10\x00\x00\x00")
+	wav = binary.LittleEndian.AppendUint16(wav, 1)
+	wav = binary.LittleEndian.AppendUint16(wav, 1)
+	wav = binary.LittleEndian.AppendUint32(wav, 8000)
+	wav = binary.LittleEndian.AppendUint32(wav, 16000)
+	wav = binary.LittleEndian.AppendUint16(wav, 2)
+	wav = binary.LittleEndian.AppendUint16(wav, 16)
+	wav = append(wav, "data"...)
+	wav = binary.LittleEndian.AppendUint32(wav, 32000)
+	wavPath := write("beep.wav", append(wav, make([]byte, 32000)...))
+
+	// A movie of 90 seconds with one 640x480 avc1 video track
+	mvhd := make([]byte, 20)
+	binary.BigEndian.PutUint32(mvhd[12:], 1000)
+	binary.BigEndian.PutUint32(mvhd[16:], 90000)
+	tkhd := make([]byte, 84)
+	binary.BigEndian.PutUint32(tkhd[76:], 640<<16)
+	binary.BigEndian.PutUint32(tkhd[80:], 480<<16)
+	stsd := append(make([]byte, 8), mp4Box("avc1", make([]byte, 8))...)
+	hdlr := append(make([]byte, 8), "vide"...)
+	trak := mp4Box("trak", mp4Box("tkhd", tkhd), mp4Box("mdia", mp4Box("hdlr", hdlr), mp4Box("minf", mp4Box("stbl", mp4Box("stsd", stsd)))))
+	ilst := mp4Box("ilst", mp4Box("\xa9nam", mp4Box("data", append(make([]byte, 8), "Clip"...))))
+	movie := append(mp4Box("ftyp", []byte("isom")), mp4Box("mdat", make([]byte, 100))...)
+	movie = append(movie, mp4Box("moov", mp4Box("mvhd", mvhd), trak, mp4Box("udta", mp4Box("meta", make([]byte, 4), ilst)))...)
+	mp4 := write("clip.mp4", movie)
+
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{mp3, []string{"Duration: 1:05", "Bitrate:  128 kb/s", "Audio:    mp3, 44100 Hz, stereo", "Title:    Song", "Artist:   Band"}},
+		{flac, []string{"Duration: 0:03", "Audio:    flac, 48000 Hz, 24 bit, stereo", "Album:    Live"}},
+		{wavPath, []string{"Duration: 0:02", "Bitrate:  128 kb/s", "Audio:    pcm, 8000 Hz, 16 bit, mono"}},
+		{mp4, []string{"Duration: 1:30", "Video:    avc1, 640x480", "Title:    Clip"}},
+	}
+	m := preview.NewManager()
+	for _, tt := range tests {
+		m.LoadPreview(tt.path, false, 100)
+		text := strings.Join(m.GetLines(), "\n")
+		for _, want := range tt.want {
+			if !strings.Contains(text, want) {
+				t.Errorf("%s: expected %q in\n%s", filepath.Base(tt.path), want, text)
+			}
+		}
+	}
+
+	photo := write("photo.jpg", exifJPEG(t))
+	m.LoadPreview(photo, false, 100)
+	if m.GetImage() == nil {
+		t.Fatal("expected the photo to be previewed as an image")
+	}
+	want := "[Image 40x30]|Camera:   Canon EOS R5|Exposure: 1/200 s  f/2.8  ISO 400"
+	if got := strings.Join(m.GetLines(), "|"); got != want {
+		t.Errorf("got photo lines %q, want %q", got, want)
+	}
+
+	if got := m.Handler("movie.mkv", nil); got != "Media type only (install ffprobe for metadata)" {
+		t.Errorf("unexpected handler %q", got)
+	}
+}
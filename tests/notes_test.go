@@ -0,0 +1,46 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alexcostache/Xplorer/internal/notes"
+)
+
+func TestDirNotes(t *testing.T) {
+	dir := t.TempDir()
+	if path := notes.Find(dir); path != "" {
+		t.Errorf("Expected no note in an empty directory, got %s", path)
+	}
+
+	os.WriteFile(filepath.Join(dir, "Readme.txt"), []byte("plain readme\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("\n## Project  \n\nText\n"), 0644)
+	readme := notes.Find(dir)
+	if filepath.Base(readme) != "README.md" {
+		t.Errorf("Expected README.md to be preferred, got %s", readme)
+	}
+	if title := notes.Title(readme); title != "Project" {
+		t.Errorf("Expected the heading as title, got %q", title)
+	}
+
+	path, err := notes.Create(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if notes.Find(dir) != path {
+		t.Error("Expected the directory note to win over the README")
+	}
+	if title := notes.Title(path); title != filepath.Base(dir) {
+		t.Errorf("Expected a new note titled by the directory, got %q", title)
+	}
+
+	// Creating again keeps the edited note
+	os.WriteFile(path, []byte("# Edited\n"), 0644)
+	if _, err := notes.Create(dir); err != nil {
+		t.Fatal(err)
+	}
+	if title := notes.Title(path); title != "Edited" {
+		t.Errorf("Expected the existing note to be kept, got %q", title)
+	}
+}
@@ -0,0 +1,147 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"github.com/alexcostache/Xplorer/internal/openwith"
+	"github.com/alexcostache/Xplorer/internal/paths"
+)
+
+func TestOpenWithKey(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/src/main.go", ".go"},
+		{"/src/README.MD", ".md"},
+		{"/src/archive.tar.gz", ".gz"},
+		{"/src/Makefile", "makefile"},
+		{"/home/user/.bashrc", ".bashrc"},
+	}
+
+	for _, tt := range tests {
+		if got := openwith.Key(tt.path); got != tt.want {
+			t.Errorf("Key(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestDesktopApps(t *testing.T) {
+	userDir := t.TempDir()
+	systemDir := t.TempDir()
+	write := func(dir, name, content string) {
+		appsDir := filepath.Join(dir, "applications")
+		if err := os.MkdirAll(appsDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(appsDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(systemDir, "gimp.desktop", "[Desktop Entry]\nType=Application\nName=GIMP\nComment=Edit images\nExec=gimp-2.10 %U\nMimeType=image/png;image/jpeg;\n")
+	write(systemDir, "vim.desktop", "[Desktop Entry]\nType=Application\nName=Vim\nExec=vim %F\nTerminal=true\nMimeType=text/plain;\n")
+	write(systemDir, "viewer.desktop", "[Desktop Entry]\nType=Application\nName=Old Viewer\nExec=viewer %f\nMimeType=image/png;\n")
+	// The user's copy hides the system entry with the same ID
+	write(userDir, "viewer.desktop", "[Desktop Entry]\nType=Application\nName=Old Viewer\nExec=viewer %f\nHidden=true\nMimeType=image/png;\n")
+
+	apps := openwith.DesktopApps("image/png", []string{userDir, systemDir})
+	if len(apps) != 1 {
+		t.Fatalf("expected 1 application for image/png, got %+v", apps)
+	}
+	if apps[0].Name != "GIMP" || apps[0].Command != "gimp-2.10" || apps[0].Description != "Edit images" {
+		t.Errorf("unexpected application %+v", apps[0])
+	}
+
+	apps = openwith.DesktopApps("text/x-go", []string{userDir, systemDir})
+	if len(apps) != 1 || apps[0].Command != "vim" || !apps[0].IsTerminal {
+		t.Errorf("expected Vim for text/x-go via text/plain, got %+v", apps)
+	}
+}
+
+func TestDefaultDesktopApp(t *testing.T) {
+	configDir := t.TempDir()
+	dataDir := t.TempDir()
+	write := func(path, content string) {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(filepath.Join(dataDir, "applications", "kde", "okular.desktop"), "[Desktop Entry]\nType=Application\nName=Okular\nExec=okular %U\nMimeType=application/pdf;\n")
+	write(filepath.Join(dataDir, "applications", "evince.desktop"), "[Desktop Entry]\nType=Application\nName=Evince\nExec=evince %U\nMimeType=application/pdf;\n")
+	write(filepath.Join(dataDir, "applications", "mimeapps.list"), "[Default Applications]\napplication/pdf=evince.desktop\n")
+
+	if got := openwith.DefaultDesktopApp("application/pdf", []string{configDir}, []string{dataDir}); got != "Evince" {
+		t.Errorf("expected the system default Evince, got %q", got)
+	}
+
+	// The user's list wins; uninstalled entries are skipped and IDs map
+	// dashes to subdirectories
+	write(filepath.Join(configDir, "mimeapps.list"), "[Added Associations]\napplication/pdf=evince.desktop;\n\n[Default Applications]\napplication/pdf=missing.desktop;kde-okular.desktop;\n")
+	if got := openwith.DefaultDesktopApp("application/pdf", []string{configDir}, []string{dataDir}); got != "Okular" {
+		t.Errorf("expected the user's default Okular, got %q", got)
+	}
+
+	if got := openwith.DefaultDesktopApp("image/png", []string{configDir}, []string{dataDir}); got != "" {
+		t.Errorf("expected no default for image/png, got %q", got)
+	}
+}
+
+func TestOpenWithConfigDefaults(t *testing.T) {
+	if err := paths.SetPortable(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer paths.Reset()
+
+	m := openwith.NewManager()
+	m.SetDefaults(map[string]string{"PDF": "zathura", ".md": "typora", "Makefile": "vim"})
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/docs/manual.pdf", "zathura"},
+		{"/docs/README.md", "typora"},
+		{"/src/Makefile", "vim"},
+		{"/src/main.go", ""},
+	}
+	for _, tt := range tests {
+		if got := m.Handler(tt.path); got != tt.want {
+			t.Errorf("Handler(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+
+	// A handler chosen in the popup takes precedence
+	m.SetHandler("/docs/manual.pdf", "evince")
+	if got := m.Handler("/docs/other.pdf"); got != "evince" {
+		t.Errorf("expected the chosen handler evince, got %q", got)
+	}
+}
+
+func TestExpandCommand(t *testing.T) {
+	selection := []string{"/w/a.mp4", "/w/b b.mp4"}
+	tests := []struct {
+		command string
+		want    []string
+	}{
+		{"ffprobe %f", []string{"ffprobe", "/w/a.mp4"}},
+		{"code -n", []string{"code", "-n", "/w/a.mp4"}},
+		{"tar czf %d/out.tgz %s", []string{"tar", "czf", "/w/out.tgz", "/w/a.mp4", "/w/b b.mp4"}},
+		{"echo --files=%s", []string{"echo", "--files=/w/a.mp4 /w/b b.mp4"}},
+		{"date +%%H %f", []string{"date", "+%H", "/w/a.mp4"}},
+		{"printf 100%%", []string{"printf", "100%", "/w/a.mp4"}},
+		{"  ", nil},
+	}
+	for _, tt := range tests {
+		got := openwith.Expand(tt.command, "/w/a.mp4", "/w", selection)
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") || len(got) != len(tt.want) {
+			t.Errorf("Expand(%q) = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+}
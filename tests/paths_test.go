@@ -0,0 +1,53 @@
+package tests
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alexcostache/Xplorer/internal/paths"
+)
+
+func TestProfilePaths(t *testing.T) {
+	defer paths.Reset()
+
+	defaultFile := paths.File(".xp_config.json")
+
+	if err := paths.SetProfile("work"); err != nil {
+		t.Fatalf("SetProfile failed: %v", err)
+	}
+	profileFile := paths.File(".xp_config.json")
+	if profileFile == defaultFile {
+		t.Error("Expected profile to use a separate config file")
+	}
+	if !strings.HasSuffix(profileFile, filepath.Join(".xp_profiles", "work", ".xp_config.json")) {
+		t.Errorf("Unexpected profile path %s", profileFile)
+	}
+
+	for _, name := range []string{"../evil", "a/b", ".."} {
+		if err := paths.SetProfile(name); err == nil {
+			t.Errorf("Expected invalid profile name %q to be rejected", name)
+		}
+	}
+}
+
+func TestPortablePaths(t *testing.T) {
+	defer paths.Reset()
+
+	dir := t.TempDir()
+	if err := paths.SetPortable(dir); err != nil {
+		t.Fatalf("SetPortable failed: %v", err)
+	}
+	if !paths.IsPortable() {
+		t.Error("Expected portable mode to be active")
+	}
+	if got := paths.File(".xp_bookmarks.json"); got != filepath.Join(dir, ".xp_bookmarks.json") {
+		t.Errorf("Expected state file in portable dir, got %s", got)
+	}
+	if got := paths.ThemesDir(); got != filepath.Join(dir, "themes") {
+		t.Errorf("Expected themes in portable dir, got %s", got)
+	}
+	if got := paths.CacheDir(); got != filepath.Join(dir, "cache") {
+		t.Errorf("Expected cache in portable dir, got %s", got)
+	}
+}
@@ -0,0 +1,176 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"github.com/alexcostache/Xplorer/pkg/fileops"
+)
+
+func TestPlanTransfer(t *testing.T) {
+	root := t.TempDir()
+	write := func(rel, content string) string {
+		path := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	a := write("a/report.txt", "12345")
+	b := write("b/report.txt", "1234567890")
+	write("dest/report.txt", "existing")
+	destDir := filepath.Join(root, "dest")
+
+	manager := fileops.NewManager()
+	plan, err := manager.PlanTransfer([]string{a, b}, fileops.OpCopy, destDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Both sources clash with the existing file, and with each other
+	want := []string{"report_copy1.txt", "report_copy2.txt"}
+	for i, action := range plan.Actions {
+		if filepath.Base(action.Dest) != want[i] || !action.Conflict {
+			t.Errorf("action %d: expected conflicting dest %s, got %+v", i, want[i], action)
+		}
+	}
+	if plan.TotalBytes != 15 || plan.Conflicts() != 2 || plan.CopyBytes() != 15 {
+		t.Errorf("unexpected totals: %d bytes, %d conflicts, %d to copy", plan.TotalBytes, plan.Conflicts(), plan.CopyBytes())
+	}
+	if summary := plan.Summary(); !strings.HasPrefix(summary, "Copy 2 items (15 B) to ") || !strings.Contains(summary, "2 renamed") {
+		t.Errorf("unexpected summary %q", summary)
+	}
+
+	// A move within one filesystem is a rename and copies nothing
+	move, err := manager.PlanTransfer([]string{a}, fileops.OpCut, filepath.Join(root, "b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !move.Actions[0].Rename || move.CopyBytes() != 0 {
+		t.Errorf("expected a rename, got %+v", move.Actions[0])
+	}
+
+	if _, err := manager.PlanTransfer([]string{filepath.Join(root, "a")}, fileops.OpCopy, filepath.Join(root, "a", "sub")); err == nil {
+		t.Error("expected an error copying a folder into itself")
+	}
+
+	if err := manager.Execute(plan); err != nil {
+		t.Fatal(err)
+	}
+	for _, action := range plan.Actions {
+		if _, err := os.Stat(action.Dest); err != nil {
+			t.Errorf("expected %s to exist: %v", action.Dest, err)
+		}
+	}
+}
+
+func TestPlanResolve(t *testing.T) {
+	root := t.TempDir()
+	write := func(rel, content string, modTime time.Time) string {
+		path := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	now := time.Now()
+	newer := write("src/newer.txt", "new", now)
+	older := write("src/older.txt", "old", now.Add(-2*time.Hour))
+	skipped := write("src/skipped.txt", "skip", now)
+	write("dest/newer.txt", "existing", now.Add(-time.Hour))
+	write("dest/older.txt", "existing", now.Add(-time.Hour))
+	write("dest/skipped.txt", "existing", now)
+	destDir := filepath.Join(root, "dest")
+
+	manager := fileops.NewManager()
+	plan, err := manager.PlanTransfer([]string{newer, older, skipped}, fileops.OpCopy, destDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := plan.Resolve(0, fileops.OverwriteIfNewer); got != fileops.Overwrite {
+		t.Errorf("expected the newer file to overwrite, got %v", got)
+	}
+	if got := plan.Resolve(1, fileops.OverwriteIfNewer); got != fileops.Skip {
+		t.Errorf("expected the older file to be skipped, got %v", got)
+	}
+	plan.Resolve(2, fileops.Skip)
+	if plan.Conflicts() != 0 || plan.Overwrites() != 1 || plan.Skipped() != 2 || plan.TotalBytes != 3 {
+		t.Errorf("unexpected totals: %d conflicts, %d overwrites, %d skipped, %d bytes", plan.Conflicts(), plan.Overwrites(), plan.Skipped(), plan.TotalBytes)
+	}
+
+	if err := manager.Execute(plan); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{"newer.txt": "new", "older.txt": "existing", "skipped.txt": "existing"} {
+		data, err := os.ReadFile(filepath.Join(destDir, name))
+		if err != nil || string(data) != want {
+			t.Errorf("%s: expected %q, got %q (%v)", name, want, data, err)
+		}
+	}
+	entries, _ := os.ReadDir(destDir)
+	if len(entries) != 3 {
+		t.Errorf("expected no _copyN leftovers, got %d entries", len(entries))
+	}
+
+	// Overwriting a file with itself leaves it alone
+	self, err := manager.PlanTransfer([]string{filepath.Join(destDir, "newer.txt")}, fileops.OpCopy, destDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := self.Resolve(0, fileops.Overwrite); got != fileops.Skip {
+		t.Errorf("expected overwriting a file with itself to skip, got %v", got)
+	}
+}
+
+func TestPlanCaseClashes(t *testing.T) {
+	root := t.TempDir()
+	for _, rel := range []string{"src/README.md", "src/Notes.txt", "other/notes.TXT", "dest/readme.md"} {
+		path := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(rel), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	destDir := filepath.Join(root, "dest")
+	if _, err := os.Stat(filepath.Join(destDir, "README.md")); err == nil {
+		t.Skip("the temporary directory is on a case-insensitive filesystem")
+	}
+
+	if got := fileops.CaseClash(destDir, "Readme.MD"); got != "readme.md" {
+		t.Errorf("expected Readme.MD to clash with readme.md, got %q", got)
+	}
+	if got := fileops.CaseClash(destDir, "readme.md"); got != "" {
+		t.Errorf("expected the same name not to count as a clash, got %q", got)
+	}
+
+	// One source clashes with the destination, another with a source before it
+	sources := []string{filepath.Join(root, "src/README.md"), filepath.Join(root, "src/Notes.txt"), filepath.Join(root, "other/notes.TXT")}
+	plan, err := fileops.NewManager().PlanTransfer(sources, fileops.OpCopy, destDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Conflicts() != 0 {
+		t.Errorf("expected no conflicts, got %d", plan.Conflicts())
+	}
+	clashes := plan.CaseClashes()
+	if len(clashes) != 2 || clashes[0].CaseClash != "readme.md" || clashes[1].CaseClash != "Notes.txt" {
+		t.Fatalf("unexpected clashes %+v", clashes)
+	}
+	plan.Actions[0].Skip = true
+	if len(plan.CaseClashes()) != 1 {
+		t.Error("expected skipped items not to count")
+	}
+}
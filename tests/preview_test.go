@@ -1,7 +1,18 @@
 package tests
 
 import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 	"github.com/alexcostache/Xplorer/internal/preview"
 )
 
@@ -59,6 +70,53 @@ func TestRuneWidth(t *testing.T) {
 	}
 }
 
+func TestThumbnailCache(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(tmpDir, "cache"))
+
+	// Create a 400x100 test image
+	img := image.NewRGBA(image.Rect(0, 0, 400, 100))
+	for x := 0; x < 400; x++ {
+		for y := 0; y < 100; y++ {
+			img.Set(x, y, color.RGBA{255, 0, 0, 255})
+		}
+	}
+	imgPath := filepath.Join(tmpDir, "photo.png")
+	f, err := os.Create(imgPath)
+	if err != nil {
+		t.Fatalf("Failed to create image: %v", err)
+	}
+	if err := png.Encode(f, img); err != nil {
+		t.Fatalf("Failed to encode image: %v", err)
+	}
+	f.Close()
+
+	info, _ := os.Stat(imgPath)
+	cache := preview.NewThumbnailCache()
+	thumb, err := cache.Get(imgPath, info)
+	if err != nil {
+		t.Fatalf("Failed to create thumbnail: %v", err)
+	}
+	if thumb.SourceWidth != 400 || thumb.SourceHeight != 100 {
+		t.Errorf("Expected source size 400x100, got %dx%d", thumb.SourceWidth, thumb.SourceHeight)
+	}
+	if thumb.Width > 160 || thumb.Width*100 != thumb.Height*400 {
+		t.Errorf("Expected downscaled thumbnail with same aspect ratio, got %dx%d", thumb.Width, thumb.Height)
+	}
+
+	// A fresh cache must find the thumbnail on disk without the original
+	if err := os.Remove(imgPath); err != nil {
+		t.Fatalf("Failed to remove image: %v", err)
+	}
+	cached, err := preview.NewThumbnailCache().Get(imgPath, info)
+	if err != nil {
+		t.Fatalf("Expected thumbnail from disk cache, got error: %v", err)
+	}
+	if cached.Width != thumb.Width || cached.Height != thumb.Height {
+		t.Errorf("Cached thumbnail size mismatch: %dx%d vs %dx%d", cached.Width, cached.Height, thumb.Width, thumb.Height)
+	}
+}
+
 func BenchmarkDetectLanguage(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		preview.DetectLanguage("main.go")
@@ -66,3 +124,346 @@ func BenchmarkDetectLanguage(b *testing.B) {
 }
 
 // Made with Bob
+
+func TestFindLinks(t *testing.T) {
+	links := preview.FindLinks(`include ../common/rules.mk # see https://example.com/docs). Error in src/main.go:42:7, "config.json"`)
+	want := []preview.Link{
+		{Target: "https://example.com/docs", IsURL: true},
+		{Target: "../common/rules.mk"},
+		{Target: "src/main.go", Line: 42},
+		{Target: "config.json"},
+	}
+	if len(links) != len(want) {
+		t.Fatalf("FindLinks returned %+v, want %+v", links, want)
+	}
+	for i := range want {
+		if links[i] != want[i] {
+			t.Errorf("link %d = %+v, want %+v", i, links[i], want[i])
+		}
+	}
+
+	if links := preview.FindLinks("plain words and a number 42"); len(links) != 0 {
+		t.Errorf("expected no links, got %+v", links)
+	}
+}
+
+func TestPreviewLimits(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "server.LOG")
+	bigPath := filepath.Join(tmpDir, "big.txt")
+	smallPath := filepath.Join(tmpDir, "small.txt")
+	if err := os.WriteFile(logPath, []byte("secret line\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(bigPath, make([]byte, 2048), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(smallPath, []byte("hello\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := preview.NewManager()
+	m.SetLimits([]string{".log"}, 1024)
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{logPath, "[Preview disabled for .log files]"},
+		{bigPath, "[Too large to preview (over 1.0 KB)]"},
+		{smallPath, "hello"},
+	}
+	for _, tt := range tests {
+		m.LoadPreview(tt.path, false, 100)
+		if lines := m.GetLines(); len(lines) == 0 || lines[0] != tt.want {
+			t.Errorf("%s: expected first line %q, got %v", filepath.Base(tt.path), tt.want, lines)
+		}
+	}
+}
+
+func TestPreviewEncoding(t *testing.T) {
+	tmpDir := t.TempDir()
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"plain\ntext\n", "ASCII"},
+		{"caf\u00e9\n", "UTF-8"},
+		{"\ufeffbom\n", "UTF-8 BOM"},
+		{"caf\xe9\n", "8-bit"},
+		{"one\r\ntwo\r\nthree", "ASCII, CRLF"},
+	}
+	m := preview.NewManager()
+	for i, tt := range tests {
+		path := filepath.Join(tmpDir, fmt.Sprintf("file%d.txt", i))
+		if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		m.LoadPreview(path, false, 100)
+		if got := m.Encoding(); got != tt.want {
+			t.Errorf("%q: got encoding %q, want %q", tt.content, got, tt.want)
+		}
+	}
+
+	// Other previews have no encoding
+	m.LoadPreview(tmpDir, false, 100)
+	if got := m.Encoding(); got != "" {
+		t.Errorf("got encoding %q for a directory", got)
+	}
+}
+
+func TestPreviewHandler(t *testing.T) {
+	tmpDir := t.TempDir()
+	bigPath := filepath.Join(tmpDir, "big.go")
+	if err := os.WriteFile(bigPath, make([]byte, 2048), 0644); err != nil {
+		t.Fatal(err)
+	}
+	bigInfo, _ := os.Stat(bigPath)
+	dirInfo, _ := os.Stat(tmpDir)
+
+	m := preview.NewManager()
+	m.SetLimits([]string{"log"}, 1024)
+
+	tests := []struct {
+		name string
+		path string
+		info os.FileInfo
+		want string
+	}{
+		{"directory", tmpDir, dirInfo, "Directory listing"},
+		{"skipped extension", "app.log", nil, "Metadata only: Preview disabled for .log files"},
+		{"too large", bigPath, bigInfo, "Metadata only: Too large to preview (over 1.0 KB)"},
+		{"image", "photo.PNG", nil, "Image thumbnail"},
+		{"source", "main.go", nil, "Text, highlighted as go"},
+		{"unknown", "notes", nil, "Plain text (binary files are described by type)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := m.Handler(tt.path, tt.info); got != tt.want {
+				t.Errorf("Handler(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+// zipFile writes a zip archive holding the given files
+func zipFile(t *testing.T, path string, files map[string]string) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte(content))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// simplePDF builds a one-page PDF whose content stream is compressed
+func simplePDF(content string) []byte {
+	var stream bytes.Buffer
+	zw := zlib.NewWriter(&stream)
+	zw.Write([]byte(content))
+	zw.Close()
+
+	var pdf bytes.Buffer
+	pdf.WriteString("%PDF-1.4\n")
+	pdf.WriteString("1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n")
+	pdf.WriteString("2 0 obj\n<< /Type /Pages /Kids [3 0 R] /Count 1 >>\nendobj\n")
+	pdf.WriteString("3 0 obj\n<< /Type /Page /Parent 2 0 R /Contents 4 0 R >>\nendobj\n")
+	fmt.Fprintf(&pdf, "4 0 obj\n<< /Length %d /Filter /FlateDecode >>\nstream\n", stream.Len())
+	pdf.Write(stream.Bytes())
+	pdf.WriteString("\nendstream\nendobj\ntrailer\n<< /Root 1 0 R >>\n%%EOF\n")
+	return pdf.Bytes()
+}
+
+func TestDocumentPreview(t *testing.T) {
+	// Use the built-in PDF reader even where pdftotext is installed
+	t.Setenv("PATH", "")
+	tmpDir := t.TempDir()
+
+	docx := filepath.Join(tmpDir, "report.docx")
+	zipFile(t, docx, map[string]string{"word/document.xml": `<?xml version="1.0"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>
+<w:p><w:r><w:t>Quarterly</w:t></w:r><w:r><w:t xml:space="preserve"> report</w:t></w:r></w:p>
+<w:p><w:r><w:t>Second paragraph</w:t></w:r></w:p>
+</w:body></w:document>`})
+
+	odt := filepath.Join(tmpDir, "letter.odt")
+	zipFile(t, odt, map[string]string{"content.xml": `<?xml version="1.0"?>
+<office:document-content xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0">
+<office:body><office:text><text:h>Dear reader</text:h><text:p>Two<text:s text:c="2"/>spaces</text:p></office:text></office:body>
+</office:document-content>`})
+
+	epub := filepath.Join(tmpDir, "book.epub")
+	zipFile(t, epub, map[string]string{
+		"META-INF/container.xml": `<container><rootfiles><rootfile full-path="OEBPS/content.opf"/></rootfiles></container>`,
+		"OEBPS/content.opf": `<package><manifest><item id="c2" href="two.xhtml"/><item id="c1" href="chapter%20one.xhtml"/></manifest>
+<spine><itemref idref="c1"/><itemref idref="c2"/></spine></package>`,
+		"OEBPS/chapter one.xhtml": `<html><head><title>Skip me</title></head><body><h1>Chapter&nbsp;1</h1><p>It was a dark night.</p></body></html>`,
+		"OEBPS/two.xhtml":         `<html><body><p>The end.<br/>Really.</p></body></html>`,
+	})
+
+	pdf := filepath.Join(tmpDir, "paper.pdf")
+	content := "BT /F1 12 Tf 72 720 Td (Hello PDF) Tj 0 -14 Td [(Sec) 10 (ond) -300 (line)] TJ ET"
+	if err := os.WriteFile(pdf, simplePDF(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	broken := filepath.Join(tmpDir, "broken.docx")
+	if err := os.WriteFile(broken, []byte("not a zip"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{docx, []string{"Quarterly report", "", "Second paragraph"}},
+		{odt, []string{"Dear reader", "", "Two spaces"}},
+		{epub, []string{"Chapter 1", "", "It was a dark night.", "", "The end.", "", "Really."}},
+		{pdf, []string{"Hello PDF", "Second line"}},
+	}
+	m := preview.NewManager()
+	for _, tt := range tests {
+		m.LoadPreview(tt.path, false, 100)
+		if got := m.GetLines(); strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("%s: got %q, want %q", filepath.Base(tt.path), got, tt.want)
+		}
+	}
+
+	m.LoadPreview(broken, false, 100)
+	if lines := m.GetLines(); len(lines) == 0 || lines[0] != "[Word Document (.docx)]" {
+		t.Errorf("expected a broken document to be described, got %q", lines)
+	}
+	if got := m.Handler(docx, nil); got != "Document text (docx paragraphs)" {
+		t.Errorf("unexpected handler %q", got)
+	}
+
+	// Extraction stops at the line limit
+	m.LoadPreview(docx, false, 1)
+	if lines := m.GetLines(); len(lines) != 1 {
+		t.Errorf("expected the line limit to apply, got %q", lines)
+	}
+}
+
+func TestTablePreview(t *testing.T) {
+	tmpDir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(tmpDir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	// Semicolons win over the comma inside the quoted cell
+	csv := write("prices.csv", "item;price;note\napple;1.5;\"red, sweet\"\nwatermelon;12;\nfig;0.25;dried\n")
+	m := preview.NewManager()
+	m.LoadPreview(csv, false, 100)
+	want := []string{
+		"item        price  note",
+		"----------  -----  ----------",
+		"apple         1.5  red, sweet",
+		"watermelon     12",
+		"fig          0.25  dried",
+	}
+	if got := m.GetLines(); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got table\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if got := m.TableSummary(); got != "3 rows x 3 columns" {
+		t.Errorf("unexpected summary %q", got)
+	}
+	if m.HeaderLines() != 2 {
+		t.Errorf("expected the header and rule to stay on top, got %d lines", m.HeaderLines())
+	}
+
+	// Scrolling right stops once the widest line fits
+	m.ScrollRight(8, 20)
+	m.ScrollRight(8, 20)
+	if got := m.GetColumnOffset(); got != 9 {
+		t.Errorf("expected to scroll 9 columns, got %d", got)
+	}
+	if got := preview.SkipColumns(m.GetLines()[2], m.GetColumnOffset()); got != "     1.5  red, sweet" {
+		t.Errorf("unexpected scrolled line %q", got)
+	}
+	m.ScrollLeft(20)
+	if m.GetColumnOffset() != 0 {
+		t.Errorf("expected to scroll back to the start, got %d", m.GetColumnOffset())
+	}
+
+	tsv := write("wide.tsv", "name\tvalue\n"+strings.Repeat("x", 50)+"\t1\n")
+	m.LoadPreview(tsv, false, 100)
+	if lines := m.GetLines(); len(lines) != 3 || lines[2] != strings.Repeat("x", 29)+"~      1" {
+		t.Errorf("expected a cut cell, got %q", lines)
+	}
+	if got := m.TableSummary(); got != "1 row x 2 columns" {
+		t.Errorf("unexpected summary %q", got)
+	}
+
+	// Text previews have no table details
+	m.LoadPreview(write("notes.txt", "a,b\n"), false, 100)
+	if m.TableSummary() != "" || m.HeaderLines() != 0 {
+		t.Error("expected no table for a text file")
+	}
+	if got := m.Handler(csv, nil); !strings.HasPrefix(got, "Table (comma-separated") {
+		t.Errorf("unexpected handler %q", got)
+	}
+}
+
+func TestPreviewRequest(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "notes.txt")
+	if err := os.WriteFile(path, []byte("first\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	info, _ := os.Stat(path)
+
+	// A read that outlasts the wait finishes in the background
+	m := preview.NewManager()
+	done := make(chan struct{}, 1)
+	m.Request(path, info, false, 100, 0, func() { done <- struct{}{} })
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the read to finish")
+	}
+	if m.Loading() && !m.Settle() {
+		t.Fatal("expected the finished read to be shown")
+	}
+	if m.Loading() || strings.Join(m.GetLines(), "|") != "first" {
+		t.Fatalf("unexpected preview %q", m.GetLines())
+	}
+
+	// The cached preview is shown again while the file is unchanged
+	if err := os.WriteFile(path, []byte("other\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m.LoadPreview(tmpDir, false, 100)
+	m.Request(path, info, false, 100, 0, nil)
+	if m.Loading() || strings.Join(m.GetLines(), "|") != "first" {
+		t.Errorf("expected the cached preview, got %q", m.GetLines())
+	}
+
+	// A changed file is read again
+	later := info.ModTime().Add(time.Second)
+	os.Chtimes(path, later, later)
+	info, _ = os.Stat(path)
+	m.Request(path, info, false, 100, 5*time.Second, nil)
+	if m.Loading() || strings.Join(m.GetLines(), "|") != "other" {
+		t.Errorf("expected the new content, got %q", m.GetLines())
+	}
+
+	// Changed limits drop the cache
+	m.SetLimits([]string{"txt"}, 0)
+	m.Request(path, info, false, 100, 5*time.Second, nil)
+	if got := strings.Join(m.GetLines(), "|"); !strings.Contains(got, "Preview disabled for .txt files") {
+		t.Errorf("expected the limits to apply, got %q", got)
+	}
+}
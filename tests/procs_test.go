@@ -0,0 +1,64 @@
+package tests
+
+import (
+	"os/exec"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/alexcostache/Xplorer/internal/procs"
+)
+
+// waitEnded waits until the process with the given ID has ended
+func waitEnded(t *testing.T, m *procs.Manager, id int) procs.Process {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		for _, p := range m.List() {
+			if p.ID == id && !p.Running() {
+				return p
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("process %d did not end", id)
+	return procs.Process{}
+}
+
+func TestProcessesKillAndReap(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sleep and false")
+	}
+	m := procs.NewManager()
+	if err := m.Start("Sleeper", exec.Command("sleep", "30")); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Start("Failing", exec.Command("false")); err != nil {
+		t.Fatal(err)
+	}
+
+	list := m.List()
+	if len(list) != 2 || list[0].Name != "Failing" {
+		t.Fatalf("Expected the newest process first, got %v", list)
+	}
+	failed := waitEnded(t, m, list[0].ID)
+	if failed.Err == nil || failed.Status() != "failed: exit status 1" {
+		t.Errorf("Expected the failure to be recorded, got %q", failed.Status())
+	}
+
+	sleeper := list[1]
+	if !sleeper.Running() || m.Running() != 1 {
+		t.Fatalf("Expected the sleeper to be running, got %q", sleeper.Status())
+	}
+	if err := m.Kill(sleeper.ID); err != nil {
+		t.Fatal(err)
+	}
+	if killed := waitEnded(t, m, sleeper.ID); killed.Status() != "killed" {
+		t.Errorf("Expected the sleeper to be killed, got %q", killed.Status())
+	}
+
+	m.Clear()
+	if len(m.List()) != 0 {
+		t.Errorf("Expected ended processes to be cleared, got %v", m.List())
+	}
+}
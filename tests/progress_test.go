@@ -1,12 +1,15 @@
 package tests
 
 import (
+	"errors"
 	"io/ioutil"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"runtime"
 	"testing"
 	"time"
-	"github.com/alexcostache/Xplorer/internal/fileops"
+	"github.com/alexcostache/Xplorer/pkg/fileops"
 )
 
 // TestProgressTracking tests the progress tracking functionality
@@ -320,3 +323,146 @@ func TestProgressWithLargeFiles(t *testing.T) {
 }
 
 // Made with Bob
+
+// TestProgressBytesAfterCutAndDelete checks that moves and deletes account
+// for every byte, even though the sources are gone once they finish
+func TestProgressBytesAfterCutAndDelete(t *testing.T) {
+	tmpDir := t.TempDir()
+	srcDir := filepath.Join(tmpDir, "src")
+	destDir := filepath.Join(tmpDir, "dest")
+	for _, dir := range []string{filepath.Join(srcDir, "nested"), destDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files := map[string]string{
+		"a.txt":                          "12345",
+		filepath.Join("nested", "b.txt"): "1234567890",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(srcDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	items := []string{filepath.Join(srcDir, "a.txt"), filepath.Join(srcDir, "nested")}
+	
+	check := func(op string, progress *fileops.ProgressInfo) {
+		t.Helper()
+		progress.Mu.RLock()
+		defer progress.Mu.RUnlock()
+		if progress.TotalBytes != 15 || progress.ProcessedBytes != 15 {
+			t.Errorf("%s: expected 15/15 bytes, got %d/%d", op, progress.ProcessedBytes, progress.TotalBytes)
+		}
+		if progress.ProcessedFiles != 2 {
+			t.Errorf("%s: expected 2 processed items, got %d", op, progress.ProcessedFiles)
+		}
+	}
+	
+	manager := fileops.NewManager()
+	if err := manager.MoveTo(items, destDir); err != nil {
+		t.Fatal(err)
+	}
+	check("move", manager.GetProgress())
+	
+	moved := []string{filepath.Join(destDir, "a.txt"), filepath.Join(destDir, "nested")}
+	if err := manager.Delete(moved); err != nil {
+		t.Fatal(err)
+	}
+	check("delete", manager.GetProgress())
+}
+
+// TestLowPriorityOperation tests that operations run to the end with
+// lowered priority and that the priority can only be toggled while one runs
+func TestLowPriorityOperation(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "data.bin")
+	if err := os.WriteFile(src, make([]byte, 64<<10), 0644); err != nil {
+		t.Fatal(err)
+	}
+	destDir := filepath.Join(root, "dest")
+	if err := os.Mkdir(destDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	manager := fileops.NewManager()
+	manager.SetLowPriority(true)
+	if manager.ToggleLowPriority() {
+		t.Error("Expected no operation to toggle")
+	}
+	plan, err := manager.PlanTransfer([]string{src}, fileops.OpCopy, destDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The operation may retire its thread, so it gets a goroutine of its own
+	done := make(chan error)
+	go func() { done <- manager.Execute(plan) }()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(filepath.Join(destDir, "data.bin")); err != nil || info.Size() != 64<<10 {
+		t.Errorf("Expected a full copy, got %v", err)
+	}
+	if !manager.GetProgress().LowPriority {
+		t.Error("Expected the operation to have run with low priority")
+	}
+}
+
+// TestOperationsDontOverlap tests that an operation started while another
+// one runs is refused
+func TestOperationsDontOverlap(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a named pipe to hold the first operation")
+	}
+	root := t.TempDir()
+	// Reading the pipe blocks until it is opened for writing
+	pipe := filepath.Join(root, "pipe")
+	if err := exec.Command("mkfifo", pipe).Run(); err != nil {
+		t.Skip("mkfifo:", err)
+	}
+	other := filepath.Join(root, "other.txt")
+	if err := os.WriteFile(other, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	destDir := filepath.Join(root, "dest")
+	if err := os.Mkdir(destDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	manager := fileops.NewManager()
+	first, err := manager.PlanTransfer([]string{pipe}, fileops.OpCopy, destDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error)
+	go func() { done <- manager.Execute(first) }()
+	for deadline := time.Now().Add(5 * time.Second); !manager.IsOperationActive(); time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the first operation to start")
+		}
+	}
+
+	second, err := manager.PlanTransfer([]string{other}, fileops.OpCopy, destDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.Execute(second); !errors.Is(err, fileops.ErrBusy) {
+		t.Errorf("Expected ErrBusy, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(destDir, "other.txt")); !os.IsNotExist(err) {
+		t.Error("Expected the refused operation to leave nothing behind")
+	}
+
+	// Let the first operation finish; then the next one may run
+	writer, err := os.OpenFile(pipe, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer.Close()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.Execute(second); err != nil {
+		t.Errorf("Expected the operation to run after the first, got %v", err)
+	}
+}
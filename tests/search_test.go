@@ -0,0 +1,171 @@
+package tests
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"github.com/alexcostache/Xplorer/internal/search"
+	"github.com/alexcostache/Xplorer/pkg/filesystem"
+)
+
+func TestGrep(t *testing.T) {
+	root := t.TempDir()
+	write := func(name, content string) {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("main.go", "package main\n\nfunc main() {\n\tTODO()\n}\n")
+	write("docs/notes.txt", "first line\ntodo: write docs\n")
+	write(".git/config", "todo in a hidden dir\n")
+	write("image.bin", "todo\x00binary")
+
+	tests := []struct {
+		name  string
+		opts  search.Options
+		count int
+	}{
+		{"insensitive literal", search.Options{Pattern: "todo", Case: filesystem.CaseInsensitive}, 2},
+		{"sensitive literal", search.Options{Pattern: "todo", Case: filesystem.CaseSensitive}, 1},
+		{"smart case with uppercase", search.Options{Pattern: "TODO", Case: filesystem.CaseSmart}, 1},
+		{"smart case lowercase", search.Options{Pattern: "todo", Case: filesystem.CaseSmart}, 2},
+		{"regex", search.Options{Pattern: `^func \w+\(`, Regex: true, Case: filesystem.CaseSensitive}, 1},
+		{"literal is not regex", search.Options{Pattern: "main()", Case: filesystem.CaseSensitive}, 1},
+		{"hidden included", search.Options{Pattern: "todo", Case: filesystem.CaseInsensitive, ShowHidden: true}, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches, truncated, err := search.Grep(context.Background(), root, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if truncated {
+				t.Error("search should not be truncated")
+			}
+			if len(matches) != tt.count {
+				t.Errorf("expected %d matches, got %+v", tt.count, matches)
+			}
+		})
+	}
+
+	matches, _, _ := search.Grep(context.Background(), root, search.Options{Pattern: "write docs", Case: filesystem.CaseInsensitive})
+	if len(matches) != 1 || matches[0].Line != 2 || matches[0].Text != "todo: write docs" || filepath.Base(matches[0].Path) != "notes.txt" {
+		t.Errorf("unexpected match %+v", matches)
+	}
+
+	if _, _, err := search.Grep(context.Background(), root, search.Options{Pattern: "(", Regex: true}); err == nil {
+		t.Error("expected an error for an invalid regular expression")
+	}
+}
+
+func TestFiles(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"src/app/main.go", "README.md", ".git/config"} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, truncated, err := search.Files(context.Background(), root, false)
+	if err != nil || truncated {
+		t.Fatalf("Files failed: %v (truncated %v)", err, truncated)
+	}
+	sep := string(filepath.Separator)
+	want := []string{"README.md", "src" + sep, filepath.Join("src", "app") + sep, filepath.Join("src", "app", "main.go")}
+	if len(files) != len(want) {
+		t.Fatalf("expected %v, got %v", want, files)
+	}
+	for i := range want {
+		if files[i] != want[i] {
+			t.Errorf("entry %d: expected %q, got %q", i, want[i], files[i])
+		}
+	}
+}
+
+func TestFuzzy(t *testing.T) {
+	paths := []string{
+		"docs/main_notes.txt",
+		"internal/app/main.go",
+		"cmd/xp/main.go",
+		"vendor/m/a/i/n/x.go",
+		"README.md",
+	}
+
+	matches := search.Fuzzy("main.go", paths, filesystem.CaseInsensitive, 0)
+	if len(matches) != 3 {
+		t.Fatalf("expected 3 matches, got %v", matches)
+	}
+	// Whole-word matches in the file name rank first, shorter paths on ties
+	if matches[0].Path != "cmd/xp/main.go" || matches[1].Path != "internal/app/main.go" {
+		t.Errorf("unexpected ranking: %v", matches)
+	}
+	if got := matches[0].Positions; len(got) != 7 || got[0] != 7 || got[6] != 13 {
+		t.Errorf("expected positions 7..13, got %v", got)
+	}
+
+	if matches := search.Fuzzy("rdm", paths, filesystem.CaseInsensitive, 0); len(matches) != 1 || matches[0].Path != "README.md" {
+		t.Errorf("expected README.md for a subsequence, got %v", matches)
+	}
+	if matches := search.Fuzzy("RDM", paths, filesystem.CaseSmart, 0); len(matches) != 1 {
+		t.Errorf("expected smart case to match README.md, got %v", matches)
+	}
+	if matches := search.Fuzzy("Main", paths, filesystem.CaseSmart, 0); len(matches) != 0 {
+		t.Errorf("expected smart case with uppercase to be case-sensitive, got %v", matches)
+	}
+	if matches := search.Fuzzy("", paths, filesystem.CaseInsensitive, 2); len(matches) != 2 {
+		t.Errorf("expected the limit to apply to an empty query, got %v", matches)
+	}
+}
+
+func TestReplace(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "config.ini")
+	if err := os.WriteFile(path, []byte("host = alpha\r\nport = 80\r\nbackup = alpha-2\r\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	opts := search.Options{Pattern: `alpha(-\d)?`, Regex: true, Case: filesystem.CaseSensitive}
+	matches, _, err := search.Grep(context.Background(), root, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	planned, err := search.PlanReplace(matches, opts, "beta$1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(planned) != 2 || planned[0].Old != "host = alpha" || planned[1].New != "backup = beta-2" {
+		t.Fatalf("unexpected replacements %+v", planned)
+	}
+
+	// Only the accepted line is written; endings and permissions are kept
+	changed, err := search.ApplyReplacements(planned[1:], nil)
+	if err != nil || changed != 1 {
+		t.Fatalf("expected 1 changed file, got %d (%v)", changed, err)
+	}
+	data, _ := os.ReadFile(path)
+	if want := "host = alpha\r\nport = 80\r\nbackup = beta-2\r\n"; string(data) != want {
+		t.Errorf("expected %q, got %q", want, data)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("expected mode 0600, got %v", info.Mode().Perm())
+	}
+
+	// A line edited since the plan is left alone
+	if _, err := search.ApplyReplacements(planned[1:], nil); err == nil {
+		t.Error("expected an error replacing a line that changed")
+	}
+	entries, _ := os.ReadDir(root)
+	if len(entries) != 1 {
+		t.Errorf("expected no temporary files left, got %d entries", len(entries))
+	}
+}
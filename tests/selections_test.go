@@ -0,0 +1,41 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alexcostache/Xplorer/internal/paths"
+	"github.com/alexcostache/Xplorer/internal/selections"
+)
+
+func TestSelectionSets(t *testing.T) {
+	if err := paths.SetPortable(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer paths.Reset()
+
+	dir := t.TempDir()
+	kept := filepath.Join(dir, "report.pdf")
+	gone := filepath.Join(dir, "draft.txt")
+	os.WriteFile(kept, nil, 0644)
+
+	m := selections.NewManager()
+	m.Add("release", []string{kept, gone})
+	m.Add("other", []string{kept})
+	m.Add("release", []string{gone, kept}) // Replaces the first set
+
+	// Sets survive a restart, most recently saved first
+	sets := selections.NewManager().GetAll()
+	if len(sets) != 2 || sets[0].Name != "release" || sets[1].Name != "other" {
+		t.Fatalf("Expected release and other, got %+v", sets)
+	}
+	if existing := sets[0].Existing(); len(existing) != 1 || existing[0] != kept {
+		t.Errorf("Expected only %s to exist, got %v", kept, existing)
+	}
+
+	m.Remove("release")
+	if sets := selections.NewManager().GetAll(); len(sets) != 1 || sets[0].Name != "other" {
+		t.Errorf("Expected only other after removing release, got %+v", sets)
+	}
+}
@@ -0,0 +1,46 @@
+package tests
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/alexcostache/Xplorer/internal/screen"
+	"github.com/alexcostache/Xplorer/internal/shell"
+	"github.com/nsf/termbox-go"
+)
+
+func TestShellExpand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("quoting differs on Windows")
+	}
+	got := shell.Expand("wc -l %s > %d/out; echo 100%% %f", "/tmp/it's.txt", "/tmp", []string{"/tmp/a b", "/tmp/c"})
+	want := `wc -l '/tmp/a b' '/tmp/c' > '/tmp'/out; echo 100% '/tmp/it'\''s.txt'`
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	output := shell.NewOutput(8)
+	output.Write([]byte("ab\tc\r\n"))
+	output.Write([]byte("defgh"))
+	if lines := output.Lines(); len(lines) != 2 || lines[0] != "ab    c" || lines[1] != "de" || !output.Truncated {
+		t.Errorf("unexpected output %q (truncated %v)", lines, output.Truncated)
+	}
+}
+
+func TestIntegrationShellCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+	t.Setenv("SHELL", "/bin/sh")
+	d := startApp(t, "notes.txt")
+
+	d.send(screen.Char(':'))
+	for _, r := range "echo shell-$((40+2)) %f" {
+		d.send(screen.Char(r))
+	}
+	d.send(screen.Key(termbox.KeyEnter))
+	d.expect("shell-42 ")
+	d.expect("notes.txt")
+	d.send(screen.Key(termbox.KeyEsc))
+	d.expectNot("shell-42")
+}
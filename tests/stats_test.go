@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/alexcostache/Xplorer/internal/screen"
+	"github.com/alexcostache/Xplorer/internal/stats"
+	"github.com/nsf/termbox-go"
+)
+
+func TestStatsTracker(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stats.json")
+
+	first := stats.NewTracker(path)
+	first.Visited()
+	first.Visited()
+	first.Copied(3, 3000)
+	first.Deleted(1)
+	if err := first.Save(); err != nil {
+		t.Fatal(err)
+	}
+	// Saving again adds only what happened since
+	first.Moved(2, 500)
+	if err := first.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A session running side by side counts too
+	second := stats.NewTracker(path)
+	second.Visited()
+	second.Copied(1, 1000)
+	if s := second.Session(); s.DirsVisited != 1 || s.FilesCopied != 1 || s.Sessions != 1 {
+		t.Errorf("unexpected session counters %+v", s)
+	}
+	first.Deleted(4)
+	if err := first.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	total, since := stats.NewTracker(path).AllTime()
+	// The new tracker's own session is included
+	want := stats.Counters{Sessions: 3, DirsVisited: 3, FilesCopied: 4, BytesCopied: 4000, FilesMoved: 2, BytesMoved: 500, FilesDeleted: 5}
+	total.Seconds = 0
+	if total != want {
+		t.Errorf("got totals %+v, want %+v", total, want)
+	}
+	if since.IsZero() {
+		t.Error("expected the start of counting to be kept")
+	}
+}
+
+func TestIntegrationStats(t *testing.T) {
+	d := startApp(t, "alpha/inner.txt")
+
+	d.send(screen.Char('I'))
+	d.expect("Statistics")
+	d.expect("This session")
+	d.expect("Folders visited")
+	d.send(screen.Key(termbox.KeyEsc))
+	d.expectNot("This session")
+}
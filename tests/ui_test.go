@@ -2,6 +2,8 @@ package tests
 
 import (
 	"testing"
+
+	"github.com/alexcostache/Xplorer/internal/ui"
 )
 
 // Note: These test internal/unexported functions from ui package
@@ -28,6 +30,124 @@ func TestUIRuneWidth(t *testing.T) {
 	t.Skip("runeWidth is an internal function in ui package")
 }
 
+func TestNameStem(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"report.txt", "report"},
+		{"archive.tar.gz", "archive.tar"},
+		{"Makefile", "Makefile"},
+		{".bashrc", ".bashrc"},
+		{".config.json", ".config"},
+	}
+
+	for _, tt := range tests {
+		if got := ui.NameStem(tt.name); got != tt.expected {
+			t.Errorf("NameStem(%q) = %q, expected %q", tt.name, got, tt.expected)
+		}
+	}
+}
+
+func TestFitName(t *testing.T) {
+	tests := []struct {
+		text   string
+		width  int
+		middle bool
+		want   string
+	}{
+		{"short.txt", 20, false, "short.txt"},
+		{"quarterly_report_final.pdf", 12, false, "quarterly_r…"},
+		{"quarterly_report_final.pdf", 12, true, "quarte…l.pdf"},
+		{"a_very_long_name.extension", 8, true, "a_ve…ion"},
+		{"日本語のファイル.txt", 9, false, "日本語の…"},
+		{"anything", 0, false, ""},
+	}
+	for _, tt := range tests {
+		got, sources := ui.FitName(tt.text, tt.width, tt.middle)
+		if got != tt.want {
+			t.Errorf("FitName(%q, %d, %v) = %q, want %q", tt.text, tt.width, tt.middle, got, tt.want)
+		}
+		if len(sources) != len([]rune(got)) {
+			t.Errorf("FitName(%q): %d sources for %d runes", tt.text, len(sources), len([]rune(got)))
+		}
+		if ui.TextWidth(got) > tt.width {
+			t.Errorf("FitName(%q, %d) is %d cells wide", tt.text, tt.width, ui.TextWidth(got))
+		}
+	}
+
+	// Sources map the kept tail back to the original runes for highlighting
+	got, sources := ui.FitName("abcdefghij.go", 8, true)
+	if got != "abcd….go" || sources[4] != -1 || sources[5] != 10 {
+		t.Errorf("unexpected middle truncation %q %v", got, sources)
+	}
+}
+
+func TestBarRows(t *testing.T) {
+	const h = 30
+	if _, _, message, extra := ui.BarRows(false, false, h); message != h-2 || extra != 0 {
+		t.Errorf("message without bars: row %d with %d extra rows, want %d and 0", message, extra, h-2)
+	}
+
+	filter, progress, message, extra := ui.BarRows(true, true, h)
+	if filter != h-2 || progress != h-3 || message != h-4 {
+		t.Errorf("filter, progress and message on rows %d, %d, %d, want %d, %d, %d", filter, progress, message, h-2, h-3, h-4)
+	}
+	if extra != 1 {
+		t.Errorf("expected 1 extra row, got %d", extra)
+	}
+
+	// The progress bar moves down once the filter is cleared
+	_, progress, _, extra = ui.BarRows(false, true, h)
+	if progress != h-2 {
+		t.Errorf("progress alone: row %d, want %d", progress, h-2)
+	}
+	if extra != 0 {
+		t.Errorf("expected no extra rows, got %d", extra)
+	}
+}
+
+func TestTreemapLayout(t *testing.T) {
+	// Half, a quarter and two eighths of a 40×10 area
+	tiles := ui.TreemapLayout([]int64{400, 200, 100, 100}, 5, 2, 40, 10)
+	if len(tiles) != 4 {
+		t.Fatalf("expected 4 tiles, got %+v", tiles)
+	}
+
+	area := 0
+	covered := make(map[[2]int]bool)
+	for _, tile := range tiles {
+		area += tile.W * tile.H
+		for y := tile.Y; y < tile.Y+tile.H; y++ {
+			for x := tile.X; x < tile.X+tile.W; x++ {
+				if x < 5 || x >= 45 || y < 2 || y >= 12 {
+					t.Errorf("tile %+v leaves the area", tile)
+				}
+				if covered[[2]int{x, y}] {
+					t.Errorf("tile %+v overlaps another", tile)
+				}
+				covered[[2]int{x, y}] = true
+			}
+		}
+	}
+	if area != 400 {
+		t.Errorf("expected the tiles to fill 400 cells, got %d", area)
+	}
+
+	want := map[int]int{0: 200, 1: 100, 2: 50, 3: 50}
+	for _, tile := range tiles {
+		if got := tile.W * tile.H; got != want[tile.Index] {
+			t.Errorf("item %d: %d cells, want %d", tile.Index, got, want[tile.Index])
+		}
+	}
+
+	// Items too small for a cell get no tile
+	tiles = ui.TreemapLayout([]int64{1000000, 1}, 0, 0, 10, 2)
+	if len(tiles) != 1 || tiles[0].Index != 0 {
+		t.Errorf("expected a single tile for the large item, got %+v", tiles)
+	}
+}
+
 func BenchmarkUIFormatSize(b *testing.B) {
 	b.Skip("formatSize is an internal function in ui package")
 }
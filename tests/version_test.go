@@ -0,0 +1,53 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alexcostache/Xplorer/internal/version"
+)
+
+func TestVersionIsNewer(t *testing.T) {
+	tests := []struct {
+		latest, current string
+		want            bool
+	}{
+		{"v1.2.0", "v1.1.9", true},
+		{"v1.10.0", "v1.9.0", true},
+		{"v1.2.0", "v1.2.0", false},
+		{"v1.2.0", "v2.0.0", false},
+		{"v1.0.0", "dev", true},
+		{"nightly", "v1.0.0", false},
+		{"v1.2.1-rc1", "v1.2.0", true},
+	}
+
+	for _, tt := range tests {
+		if got := version.IsNewer(tt.latest, tt.current); got != tt.want {
+			t.Errorf("IsNewer(%q, %q) = %v, want %v", tt.latest, tt.current, got, tt.want)
+		}
+	}
+}
+
+func TestCheckLatest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"tag_name": "v9.0.0", "body": "- New things", "html_url": "https://example.com/r"}`))
+	}))
+	defer server.Close()
+
+	release, err := version.CheckLatest(server.URL)
+	if err != nil {
+		t.Fatalf("CheckLatest failed: %v", err)
+	}
+	if release.TagName != "v9.0.0" || release.Body != "- New things" {
+		t.Errorf("Unexpected release %+v", release)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "rate limited", http.StatusForbidden)
+	}))
+	defer failing.Close()
+	if _, err := version.CheckLatest(failing.URL); err == nil {
+		t.Error("Expected error for non-200 response")
+	}
+}
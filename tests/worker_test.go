@@ -0,0 +1,81 @@
+package tests
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+	"github.com/alexcostache/Xplorer/internal/worker"
+)
+
+func TestPoolDedupeAndCancel(t *testing.T) {
+	pool := worker.NewPool(1)
+
+	// Occupy the only worker until the job is canceled
+	started := make(chan struct{})
+	canceled := make(chan struct{})
+	if !pool.Submit("walk:/a", func(ctx context.Context) {
+		close(started)
+		<-ctx.Done()
+		close(canceled)
+	}) {
+		t.Fatal("Expected the first job to be queued")
+	}
+	<-started
+	if pool.Submit("walk:/a", func(ctx context.Context) {}) {
+		t.Error("Expected a duplicate key to be refused")
+	}
+
+	// Queued jobs wait for a free worker
+	var ran atomic.Int32
+	done := make(chan struct{})
+	pool.Submit("walk:/b", func(ctx context.Context) {
+		ran.Add(1)
+		close(done)
+	})
+	time.Sleep(20 * time.Millisecond)
+	if ran.Load() != 0 {
+		t.Error("Expected the second job to wait for the busy worker")
+	}
+
+	pool.CancelPrefix("walk:/a")
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the running job to see its cancellation")
+	}
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the queued job to run once the worker was free")
+	}
+
+	// A canceled key can be submitted again
+	again := make(chan struct{})
+	if !pool.Submit("walk:/a", func(ctx context.Context) { close(again) }) {
+		t.Fatal("Expected a canceled key to be accepted again")
+	}
+	<-again
+}
+
+func TestPoolLowPriority(t *testing.T) {
+	pool := worker.NewPool(1)
+	run := func(key string) {
+		t.Helper()
+		done := make(chan struct{})
+		pool.Submit(key, func(ctx context.Context) { close(done) })
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatalf("Expected job %s to run", key)
+		}
+	}
+
+	// Jobs keep running while the priority changes, even when the worker
+	// has to be replaced to get it back
+	pool.SetLowPriority(true)
+	run("scan:/low")
+	pool.SetLowPriority(false)
+	run("scan:/normal")
+	run("scan:/again")
+}